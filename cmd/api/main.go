@@ -13,8 +13,11 @@ import (
 	"github.com/tvoe/converter/internal/api"
 	"github.com/tvoe/converter/internal/config"
 	"github.com/tvoe/converter/internal/db"
+	"github.com/tvoe/converter/internal/events"
 	"github.com/tvoe/converter/internal/metrics"
+	"github.com/tvoe/converter/internal/publish"
 	"github.com/tvoe/converter/internal/storage/s3"
+	"github.com/tvoe/converter/internal/temporal/workflows"
 )
 
 func main() {
@@ -33,6 +36,11 @@ func main() {
 	if err != nil {
 		logger.Fatal("failed to load configuration", zap.Error(err))
 	}
+	if !workflows.IsConversionWorkflow(cfg.Temporal.ConversionWorkflow) {
+		logger.Fatal("unknown conversion workflow in TEMPORAL_CONVERSION_WORKFLOW",
+			zap.String("workflow", cfg.Temporal.ConversionWorkflow),
+			zap.Strings("available", workflows.ConversionWorkflowNames()))
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -44,10 +52,19 @@ func main() {
 	}
 	defer database.Close()
 
+	if schema, err := database.CheckSchema(ctx); err != nil {
+		logger.Fatal("database schema check failed", zap.Error(err))
+	} else {
+		logger.Info("database schema verified", zap.Int64("version", schema.Version))
+	}
+
 	// Initialize repositories
 	jobRepo := db.NewJobRepository(database)
 	errorRepo := db.NewErrorRepository(database)
 	artifactRepo := db.NewArtifactRepository(database)
+	profileRepo := db.NewProfileRepository(database)
+	stageRepo := db.NewStageRepository(database)
+	versionRepo := db.NewVersionRepository(database)
 
 	// Initialize S3 client
 	s3Client, err := s3.New(cfg.S3)
@@ -55,6 +72,9 @@ func main() {
 		logger.Fatal("failed to initialize S3 client", zap.Error(err))
 	}
 
+	// Initialize publisher of live video versions
+	publisher := publish.New(jobRepo, artifactRepo, versionRepo, s3Client, logger)
+
 	// Initialize Temporal client
 	temporalClient, err := client.Dial(client.Options{
 		HostPort:  cfg.Temporal.Address,
@@ -68,23 +88,45 @@ func main() {
 	// Initialize metrics
 	m := metrics.New()
 
+	// Initialize event sinks
+	bus, err := events.New(cfg.Events, logger)
+	if err != nil {
+		logger.Fatal("failed to initialize event sinks", zap.Error(err))
+	}
+	defer bus.Close()
+
 	// Initialize handler
 	handler := api.NewHandler(
 		cfg,
+		database,
 		jobRepo,
 		errorRepo,
 		artifactRepo,
+		profileRepo,
+		stageRepo,
+		versionRepo,
 		s3Client,
+		publisher,
 		temporalClient,
 		logger,
 		m,
+		bus,
 	)
 
 	// Create router
 	router := api.NewRouter(handler, logger)
 
 	// Create server
-	server := api.NewServer(cfg.API, router, logger)
+	server, err := api.NewServer(cfg.API, router, logger)
+	if err != nil {
+		logger.Fatal("failed to create server", zap.Error(err))
+	}
+
+	// Start fair dispatcher; otherwise workflows are started directly on job creation
+	if cfg.Scheduler.FairDispatch {
+		dispatcher := api.NewDispatcher(cfg, database, jobRepo, temporalClient, logger)
+		go dispatcher.Run(ctx)
+	}
 
 	// Handle shutdown signals
 	sigChan := make(chan os.Signal, 1)
@@ -0,0 +1,112 @@
+// Command replay checks that the workflow code is deterministic for running
+// executions. Run it with the new build before rolling out workers: it
+// replays the history of every open conversion, series and maintenance workflow, or of the JSON
+// history files given as arguments (temporal workflow show --output json).
+package main
+
+import (
+	"context"
+	"os"
+
+	"github.com/joho/godotenv"
+	filterpb "go.temporal.io/api/filter/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/config"
+	"github.com/tvoe/converter/internal/temporal/workflows"
+)
+
+func main() {
+	// Load .env file if exists
+	_ = godotenv.Load()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		panic("failed to initialize logger: " + err.Error())
+	}
+	defer logger.Sync()
+
+	replayer := worker.NewWorkflowReplayer()
+	workflows.Register(replayer)
+
+	var replayed, failed int
+	if len(os.Args) > 1 {
+		for _, path := range os.Args[1:] {
+			replayed++
+			if err := replayer.ReplayWorkflowHistoryFromJSONFile(nil, path); err != nil {
+				failed++
+				logger.Error("replay failed", zap.String("file", path), zap.Error(err))
+			}
+		}
+	} else {
+		replayed, failed = replayOpen(replayer, logger)
+	}
+
+	logger.Info("replay finished", zap.Int("replayed", replayed), zap.Int("failed", failed))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// replayOpen replays every open execution of the registered workflows
+func replayOpen(replayer worker.WorkflowReplayer, logger *zap.Logger) (replayed, failed int) {
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("failed to load configuration", zap.Error(err))
+	}
+
+	temporalClient, err := client.Dial(client.Options{
+		HostPort:  cfg.Temporal.Address,
+		Namespace: cfg.Temporal.Namespace,
+	})
+	if err != nil {
+		logger.Fatal("failed to connect to Temporal", zap.Error(err))
+	}
+	defer temporalClient.Close()
+
+	ctx := context.Background()
+
+	for _, name := range workflows.WorkflowNames() {
+		var pageToken []byte
+		for {
+			resp, err := temporalClient.ListOpenWorkflow(ctx, &workflowservice.ListOpenWorkflowExecutionsRequest{
+				Namespace:     cfg.Temporal.Namespace,
+				NextPageToken: pageToken,
+				Filters: &workflowservice.ListOpenWorkflowExecutionsRequest_TypeFilter{
+					TypeFilter: &filterpb.WorkflowTypeFilter{Name: name},
+				},
+			})
+			if err != nil {
+				logger.Fatal("failed to list open workflows", zap.String("workflowType", name), zap.Error(err))
+			}
+
+			for _, info := range resp.Executions {
+				execution := workflow.Execution{
+					ID:    info.Execution.WorkflowId,
+					RunID: info.Execution.RunId,
+				}
+				replayed++
+				err := replayer.ReplayWorkflowExecution(ctx, temporalClient.WorkflowService(), nil, cfg.Temporal.Namespace, execution)
+				if err != nil {
+					failed++
+					logger.Error("replay failed",
+						zap.String("workflowType", name),
+						zap.String("workflowId", execution.ID),
+						zap.String("runId", execution.RunID),
+						zap.Error(err))
+				}
+			}
+
+			pageToken = resp.NextPageToken
+			if len(pageToken) == 0 {
+				break
+			}
+		}
+	}
+
+	return replayed, failed
+}
@@ -2,22 +2,33 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"os"
+	"os/exec"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
 	"go.temporal.io/sdk/worker"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 
 	"github.com/tvoe/converter/internal/config"
 	"github.com/tvoe/converter/internal/db"
+	"github.com/tvoe/converter/internal/events"
 	"github.com/tvoe/converter/internal/ffmpeg"
+	// Registers the "mediaconvert" transcoder backend
+	_ "github.com/tvoe/converter/internal/mediaconvert"
 	"github.com/tvoe/converter/internal/metrics"
+	"github.com/tvoe/converter/internal/publish"
 	"github.com/tvoe/converter/internal/storage/s3"
 	"github.com/tvoe/converter/internal/temporal/activities"
 	"github.com/tvoe/converter/internal/temporal/workflows"
@@ -50,10 +61,18 @@ func main() {
 	}
 	defer database.Close()
 
+	if schema, err := database.CheckSchema(ctx); err != nil {
+		logger.Fatal("database schema check failed", zap.Error(err))
+	} else {
+		logger.Info("database schema verified", zap.Int64("version", schema.Version))
+	}
+
 	// Initialize repositories
 	jobRepo := db.NewJobRepository(database)
 	errorRepo := db.NewErrorRepository(database)
 	artifactRepo := db.NewArtifactRepository(database)
+	stageRepo := db.NewStageRepository(database)
+	versionRepo := db.NewVersionRepository(database)
 
 	// Initialize S3 client
 	s3Client, err := s3.New(cfg.S3)
@@ -61,49 +80,131 @@ func main() {
 		logger.Fatal("failed to initialize S3 client", zap.Error(err))
 	}
 
-	// Initialize Temporal client
+	// Initialize publisher of live video versions
+	publisher := publish.New(jobRepo, artifactRepo, versionRepo, s3Client, logger)
+
+	// Initialize metrics
+	m := metrics.New()
+
+	// Initialize event sinks
+	bus, err := events.New(cfg.Events, logger)
+	if err != nil {
+		logger.Fatal("failed to initialize event sinks", zap.Error(err))
+	}
+	defer bus.Close()
+
+	if !activities.IsTranscoderRegistered(cfg.Encoding.Transcoder) {
+		logger.Fatal("unknown transcoder backend",
+			zap.String("transcoder", cfg.Encoding.Transcoder),
+			zap.Strings("available", activities.RegisteredTranscoders()))
+	}
+	if b := cfg.Burst.Transcoder; b != "" && !activities.IsTranscoderRegistered(b) {
+		logger.Fatal("unknown burst transcoder backend",
+			zap.String("transcoder", b),
+			zap.Strings("available", activities.RegisteredTranscoders()))
+	}
+
+	// A worker whose hardware backend cannot encode falls back to the CPU and
+	// leaves the GPU queue to other workers
+	if cfg.Worker.EnableGPU {
+		if err := ffmpeg.DetectHWAccel(ctx, cfg.FFmpeg.BinaryPath, cfg.Worker.HWAccel, cfg.Worker.HWDevice); err != nil {
+			logger.Error("hardware acceleration unavailable, falling back to CPU encoding",
+				zap.String("hwaccel", string(cfg.Worker.HWAccel)),
+				zap.Error(err))
+			cfg.Worker.HWAccel = config.HWAccelNone
+			cfg.Worker.EnableGPU = false
+		}
+	}
+
+	// GPU transcodes are spread over all NVIDIA GPUs of the worker unless
+	// WORKER_GPU_DEVICES lists the devices
+	if cfg.Worker.HWAccel == config.HWAccelNVENC && len(cfg.Worker.GPUDevices) == 0 {
+		devices, err := gpuInventory(ctx)
+		if err != nil {
+			logger.Warn("failed to list GPUs, using the default GPU", zap.Error(err))
+		} else {
+			cfg.Worker.GPUDevices = devices
+		}
+	}
+
+	// Create activities
+	acts := activities.NewActivities(
+		cfg,
+		jobRepo,
+		errorRepo,
+		artifactRepo,
+		stageRepo,
+		s3Client,
+		publisher,
+		logger,
+		m,
+		bus,
+	)
+
+	// Initialize Temporal client. Its polls of transcode task queues are held
+	// while admission is closed.
 	temporalClient, err := client.Dial(client.Options{
 		HostPort:  cfg.Temporal.Address,
 		Namespace: cfg.Temporal.Namespace,
+		ConnectionOptions: client.ConnectionOptions{
+			DialOptions: []grpc.DialOption{
+				grpc.WithChainUnaryInterceptor(acts.Admission().PollInterceptor()),
+			},
+		},
 	})
 	if err != nil {
 		logger.Fatal("failed to connect to Temporal", zap.Error(err))
 	}
 	defer temporalClient.Close()
 
-	// Initialize metrics
-	m := metrics.New()
-
-	// Create activities
-	acts := activities.NewActivities(
+	maintenance := activities.NewMaintenance(
 		cfg,
+		database,
+		temporalClient,
 		jobRepo,
 		errorRepo,
 		artifactRepo,
 		s3Client,
-		logger,
+		bus,
 		m,
+		logger,
 	)
 
-	// Create worker
-	w := worker.New(temporalClient, cfg.Temporal.TaskQueue, worker.Options{
-		MaxConcurrentActivityExecutionSize:     cfg.Worker.MaxParallelJobs,
-		MaxConcurrentWorkflowTaskExecutionSize: cfg.Worker.MaxParallelJobs * 2,
-	})
+	// Create workers: the regular queue plus the high-priority queue if configured,
+	// and the GPU queue on workers that have a GPU
+	taskQueues := []string{cfg.Temporal.TaskQueue}
+	if q := cfg.Temporal.HighPriorityTaskQueue; q != "" && q != cfg.Temporal.TaskQueue {
+		taskQueues = append(taskQueues, q)
+	}
+	if q := cfg.Temporal.GPUTaskQueue; q != "" && cfg.Worker.EnableGPU && !slices.Contains(taskQueues, q) {
+		taskQueues = append(taskQueues, q)
+	}
 
-	// Register workflows
-	w.RegisterWorkflow(workflows.VideoConversionWorkflow)
+	// Activities of all workers share one process-wide budget of MAX_PARALLEL_JOBS
+	// job slots; the rest wait for a slot. Bookkeeping activities need no job
+	// slot, and queue workers take twice as many tasks so waiting ones do not
+	// starve them.
+	jobSlots := acts.JobSlotInterceptor()
 
-	// Register activities
-	w.RegisterActivity(acts.ExtractMetadata)
-	w.RegisterActivity(acts.ValidateInputs)
-	w.RegisterActivity(acts.Transcode)
-	w.RegisterActivity(acts.ExtractSubtitles)
-	w.RegisterActivity(acts.GenerateThumbnails)
-	w.RegisterActivity(acts.SegmentHLS)
-	w.RegisterActivity(acts.UploadArtifacts)
-	w.RegisterActivity(acts.Cleanup)
-	w.RegisterActivity(acts.FinalizeJob)
+	var workers []worker.Worker
+	for _, taskQueue := range taskQueues {
+		w := worker.New(temporalClient, taskQueue, worker.Options{
+			MaxConcurrentActivityExecutionSize:     cfg.Worker.MaxParallelJobs * 2,
+			MaxConcurrentWorkflowTaskExecutionSize: cfg.Worker.MaxParallelJobs * 2,
+			Interceptors:                           []interceptor.WorkerInterceptor{jobSlots},
+		})
+		registerWorker(w, acts, maintenance)
+		workers = append(workers, w)
+
+		// Video transcodes of the queue's workflows run on its transcode queue
+		tw := worker.New(temporalClient, activities.TranscodeTaskQueue(taskQueue), worker.Options{
+			MaxConcurrentActivityExecutionSize: cfg.Worker.MaxParallelJobs,
+			DisableWorkflowWorker:              true,
+			Interceptors:                       []interceptor.WorkerInterceptor{jobSlots},
+		})
+		registerTranscodeWorker(tw, acts)
+		workers = append(workers, tw)
+	}
 
 	// Handle shutdown signals
 	sigChan := make(chan os.Signal, 1)
@@ -117,6 +218,18 @@ func main() {
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte("OK"))
 		})
+		mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+			readyCtx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+			defer cancel()
+			schema, err := database.CheckSchema(readyCtx)
+			if err != nil {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprintf(w, "OK schema=%d", schema.Version)
+		})
 		metricsAddr := ":9090"
 		logger.Info("starting metrics server", zap.String("addr", metricsAddr))
 		if err := http.ListenAndServe(metricsAddr, mux); err != nil {
@@ -124,22 +237,27 @@ func main() {
 		}
 	}()
 
-	// Start disk space monitoring
-	go monitorDiskSpace(ctx, cfg.Worker.WorkdirRoot, m, logger)
+	// Start disk space monitoring; it also holds new transcodes under resource pressure
+	go monitorDiskSpace(ctx, cfg, acts.Admission(), m, logger)
 
-	// Start orphan cleanup
-	go runOrphanCleanup(ctx, cfg.Worker.WorkdirRoot, logger)
+	// Orphan cleanup, stale uploads and stale jobs run as Temporal schedules,
+	// once per period for the whole fleet
+	ensureMaintenanceSchedules(ctx, temporalClient, cfg, logger)
 
-	// Start worker in a goroutine
-	errChan := make(chan error, 1)
-	go func() {
-		errChan <- w.Run(worker.InterruptCh())
-	}()
+	// Start workers in goroutines
+	errChan := make(chan error, len(workers))
+	for _, w := range workers {
+		go func(w worker.Worker) {
+			errChan <- w.Run(worker.InterruptCh())
+		}(w)
+	}
 
 	logger.Info("worker started",
-		zap.String("taskQueue", cfg.Temporal.TaskQueue),
+		zap.Strings("taskQueues", taskQueues),
 		zap.Int("maxParallelJobs", cfg.Worker.MaxParallelJobs),
 		zap.Bool("gpuEnabled", cfg.Worker.EnableGPU),
+		zap.String("hwaccel", string(cfg.Worker.HWAccel)),
+		zap.Strings("gpuDevices", cfg.Worker.GPUDevices),
 	)
 
 	// Wait for shutdown signal or error
@@ -153,53 +271,145 @@ func main() {
 	}
 
 	cancel()
-	w.Stop()
+	for _, w := range workers {
+		w.Stop()
+	}
 	logger.Info("worker stopped")
 }
 
-// monitorDiskSpace monitors disk space and updates metrics
-func monitorDiskSpace(ctx context.Context, workdir string, m *metrics.Metrics, logger *zap.Logger) {
+// registerWorker registers workflows and activities on a worker
+func registerWorker(w worker.Worker, acts *activities.Activities, maintenance *activities.Maintenance) {
+	// Register workflows: every conversion workflow version that may still be running
+	workflows.Register(w)
+
+	// Register activities
+	w.RegisterActivity(acts.AcquireTenantSlot)
+	w.RegisterActivity(acts.ExtractMetadata)
+	w.RegisterActivity(acts.ValidateInputs)
+	w.RegisterActivity(acts.Transcode)
+	w.RegisterActivity(acts.PlanTranscode)
+	w.RegisterActivity(acts.PlanJob)
+	w.RegisterActivity(acts.AnalyzeComplexity)
+	w.RegisterActivity(acts.DetectCrop)
+	w.RegisterActivity(acts.TranscodeRendition)
+	w.RegisterActivity(acts.TranscodeAudio)
+	w.RegisterActivity(acts.StitchBumpers)
+	w.RegisterActivity(acts.BurnSubtitles)
+	w.RegisterActivity(acts.ReportProgress)
+	w.RegisterActivity(acts.GetJobStatus)
+	w.RegisterActivity(acts.ClaimSeriesJob)
+	w.RegisterActivity(acts.ExtractSubtitles)
+	w.RegisterActivity(acts.GenerateThumbnails)
+	w.RegisterActivity(acts.SegmentHLS)
+	w.RegisterActivity(acts.UploadArtifacts)
+	w.RegisterActivity(acts.VerifyOutput)
+	w.RegisterActivity(acts.Cleanup)
+	w.RegisterActivity(acts.QuarantineJob)
+	w.RegisterActivity(acts.FinalizeJob)
+
+	// Maintenance activities, run by MaintenanceWorkflow
+	w.RegisterActivity(maintenance.CleanupOrphanWorkspaces)
+	w.RegisterActivity(maintenance.AbortStaleUploads)
+	w.RegisterActivity(maintenance.ReconcileStaleJobs)
+}
+
+// registerTranscodeWorker registers the video transcode activities on a
+// worker of a transcode task queue
+func registerTranscodeWorker(w worker.Worker, acts *activities.Activities) {
+	w.RegisterActivity(acts.Transcode)
+	w.RegisterActivity(acts.TranscodeRendition)
+}
+
+// monitorDiskSpace monitors disk space and, with NVENC enabled, GPU memory. It
+// updates metrics and the admission of new transcodes.
+func monitorDiskSpace(ctx context.Context, cfg *config.Config, admission *activities.Admission, m *metrics.Metrics, logger *zap.Logger) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
 	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			var stat syscall.Statfs_t
-			if err := syscall.Statfs(workdir, &stat); err != nil {
-				logger.Warn("failed to get disk stats", zap.Error(err))
-				continue
-			}
-
-			freeBytes := float64(stat.Bavail) * float64(stat.Bsize)
-			m.SetDiskFreeBytes(freeBytes)
+		freeDisk := int64(-1)
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(cfg.Worker.WorkdirRoot, &stat); err != nil {
+			logger.Warn("failed to get disk stats", zap.Error(err))
+		} else {
+			freeDisk = int64(stat.Bavail) * int64(stat.Bsize)
+			m.SetDiskFreeBytes(float64(freeDisk))
 
 			// Log warning if disk space is low (less than 10GB)
-			if freeBytes < 10*1024*1024*1024 {
+			if freeDisk < 10*1024*1024*1024 {
 				logger.Warn("low disk space",
-					zap.Float64("freeGB", freeBytes/1024/1024/1024),
+					zap.Float64("freeGB", float64(freeDisk)/1024/1024/1024),
 				)
 			}
 		}
-	}
-}
 
-// runOrphanCleanup periodically cleans up orphan workspaces
-func runOrphanCleanup(ctx context.Context, workdir string, logger *zap.Logger) {
-	ticker := time.NewTicker(1 * time.Hour)
-	defer ticker.Stop()
+		freeGPU := int64(-1)
+		if cfg.Worker.HWAccel == config.HWAccelNVENC {
+			free, err := gpuFreeMemory(ctx)
+			if err != nil {
+				logger.Warn("failed to get GPU memory stats", zap.Error(err))
+			} else {
+				freeGPU = free
+			}
+		}
+
+		if reason, changed := admission.Update(freeDisk, freeGPU); changed {
+			m.SetAdmissionClosed(reason != "")
+			if reason != "" {
+				logger.Warn("holding new transcodes", zap.String("reason", reason))
+			} else {
+				logger.Info("admitting new transcodes again")
+			}
+		}
 
-	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			logger.Info("running orphan workspace cleanup")
-			if err := ffmpeg.CleanupOrphans(workdir, 24*time.Hour); err != nil {
-				logger.Warn("orphan cleanup failed", zap.Error(err))
-			}
 		}
 	}
 }
+
+// gpuInventory returns the indexes of the NVIDIA GPUs of the worker
+func gpuInventory(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "nvidia-smi", "--query-gpu=index", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return nil, fmt.Errorf("nvidia-smi failed: %w", err)
+	}
+
+	var devices []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		index := strings.TrimSpace(line)
+		if _, err := strconv.Atoi(index); err != nil {
+			return nil, fmt.Errorf("unexpected nvidia-smi output %q", line)
+		}
+		devices = append(devices, index)
+	}
+	return devices, nil
+}
+
+// gpuFreeMemory returns the free memory in bytes of the NVIDIA GPU with the most of it
+func gpuFreeMemory(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "nvidia-smi", "--query-gpu=memory.free", "--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return 0, fmt.Errorf("nvidia-smi failed: %w", err)
+	}
+
+	best := int64(-1)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		mib, err := strconv.ParseInt(strings.TrimSpace(line), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unexpected nvidia-smi output %q", line)
+		}
+		if mib<<20 > best {
+			best = mib << 20
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/config"
+	"github.com/tvoe/converter/internal/temporal/workflows"
+)
+
+// maintenanceScheduleID is the Temporal schedule of a maintenance task
+func maintenanceScheduleID(task workflows.MaintenanceTask) string {
+	return "converter-maintenance-" + string(task)
+}
+
+// maintenanceIntervals returns the period of every maintenance task; 0 disables it
+func maintenanceIntervals(cfg *config.Config) map[workflows.MaintenanceTask]time.Duration {
+	return map[workflows.MaintenanceTask]time.Duration{
+		workflows.MaintenanceOrphanWorkspaces: cfg.Maintenance.OrphanCleanupInterval,
+		workflows.MaintenanceStaleUploads:     cfg.S3.MultipartGCInterval,
+		workflows.MaintenanceStaleJobs:        cfg.Reconciler.Interval,
+	}
+}
+
+// ensureMaintenanceSchedules creates or updates the schedule of every enabled
+// maintenance task and deletes the schedules of disabled ones. Every worker calls
+// it at startup, so the last started worker's configuration wins. Failures are
+// logged: a worker without schedules still converts videos.
+func ensureMaintenanceSchedules(ctx context.Context, c client.Client, cfg *config.Config, logger *zap.Logger) {
+	intervals := maintenanceIntervals(cfg)
+	for _, task := range workflows.MaintenanceTasks() {
+		id := maintenanceScheduleID(task)
+		interval := intervals[task]
+		logger := logger.With(zap.String("scheduleId", id))
+
+		if interval <= 0 {
+			err := c.ScheduleClient().GetHandle(ctx, id).Delete(ctx)
+			var notFound *serviceerror.NotFound
+			if err != nil && !errors.As(err, &notFound) {
+				logger.Warn("failed to delete disabled maintenance schedule", zap.Error(err))
+			}
+			continue
+		}
+
+		spec := client.ScheduleSpec{
+			Intervals: []client.ScheduleIntervalSpec{{Every: interval}},
+		}
+		action := &client.ScheduleWorkflowAction{
+			ID:        "maintenance-" + string(task),
+			Workflow:  workflows.MaintenanceWorkflowName,
+			Args:      []interface{}{workflows.MaintenanceWorkflowInput{Task: task}},
+			TaskQueue: cfg.Temporal.TaskQueue,
+		}
+
+		_, err := c.ScheduleClient().Create(ctx, client.ScheduleOptions{
+			ID:      id,
+			Spec:    spec,
+			Action:  action,
+			Overlap: enumspb.SCHEDULE_OVERLAP_POLICY_SKIP,
+		})
+		if errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+			err = c.ScheduleClient().GetHandle(ctx, id).Update(ctx, client.ScheduleUpdateOptions{
+				DoUpdate: func(in client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+					schedule := in.Description.Schedule
+					schedule.Spec = &spec
+					schedule.Action = action
+					if schedule.Policy == nil {
+						schedule.Policy = &client.SchedulePolicies{}
+					}
+					schedule.Policy.Overlap = enumspb.SCHEDULE_OVERLAP_POLICY_SKIP
+					return &client.ScheduleUpdate{Schedule: &schedule}, nil
+				},
+			})
+		}
+		if err != nil {
+			logger.Warn("failed to set up maintenance schedule", zap.Error(err))
+			continue
+		}
+		logger.Info("maintenance schedule set up", zap.Duration("interval", interval))
+	}
+}
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/db"
+	"github.com/tvoe/converter/internal/domain"
+)
+
+const (
+	// exportPageSize is the number of jobs read from the database at a time
+	exportPageSize = 200
+	// maxImportLineSize bounds one JSONL record of an import
+	maxImportLineSize = 64 << 20
+)
+
+// ImportFailure describes a record the import could not store
+type ImportFailure struct {
+	Line  int        `json:"line"`
+	JobID *uuid.UUID `json:"jobId,omitempty"`
+	Error string     `json:"error"`
+}
+
+// ImportJobsResponse summarizes an import
+type ImportJobsResponse struct {
+	Imported int              `json:"imported"`
+	Skipped  int              `json:"skipped"` // jobs that already exist
+	Requeued int              `json:"requeued,omitempty"`
+	Failed   []*ImportFailure `json:"failed,omitempty"`
+}
+
+// ExportJobs streams jobs with their artifact records as JSONL, one
+// db.JobExport per line, oldest first. ?status=A,B limits the statuses.
+func (h *Handler) ExportJobs(w http.ResponseWriter, r *http.Request) {
+	var statuses []domain.JobStatus
+	if s := r.URL.Query().Get("status"); s != "" {
+		for _, status := range strings.Split(s, ",") {
+			statuses = append(statuses, domain.JobStatus(strings.ToUpper(strings.TrimSpace(status))))
+		}
+	}
+
+	ctx := r.Context()
+
+	// The first page is read before the headers are written, so a database
+	// error still gets an error status
+	exports, err := h.jobRepo.ListExports(ctx, statuses, nil, exportPageSize)
+	if err != nil {
+		h.logger.Error("failed to export jobs", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to export jobs")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="jobs.jsonl"`)
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	total := 0
+	for len(exports) > 0 {
+		for _, export := range exports {
+			if err := encoder.Encode(export); err != nil {
+				h.logger.Warn("job export interrupted", zap.Error(err), zap.Int("jobs", total))
+				return
+			}
+			total++
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if len(exports) < exportPageSize {
+			break
+		}
+
+		last := exports[len(exports)-1].Job
+		exports, err = h.jobRepo.ListExports(ctx, statuses, &db.ExportCursor{CreatedAt: last.CreatedAt, ID: last.ID}, exportPageSize)
+		if err != nil {
+			// The response is already streaming; the client sees a truncated export
+			h.logger.Error("failed to export jobs", zap.Error(err), zap.Int("jobs", total))
+			return
+		}
+	}
+
+	h.logger.Info("jobs exported", zap.Int("jobs", total))
+}
+
+// ImportJobs stores jobs from a JSONL export. Existing jobs are skipped, so
+// the import can be repeated. With ?requeue=true, jobs that were QUEUED,
+// RUNNING or PAUSED are restarted from the beginning under new workflows, for
+// recovery when the Temporal history of the old workflows is lost.
+func (h *Handler) ImportJobs(w http.ResponseWriter, r *http.Request) {
+	requeue := r.URL.Query().Get("requeue") == "true"
+	ctx := r.Context()
+
+	var resp ImportJobsResponse
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxImportLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var export db.JobExport
+		if err := json.Unmarshal(scanner.Bytes(), &export); err != nil {
+			resp.Failed = append(resp.Failed, &ImportFailure{Line: line, Error: "invalid record: " + err.Error()})
+			continue
+		}
+		job := export.Job
+		if job == nil || job.ID == uuid.Nil || job.Status == "" {
+			resp.Failed = append(resp.Failed, &ImportFailure{Line: line, Error: "record has no job"})
+			continue
+		}
+
+		restart := requeue && isActiveStatus(job.Status)
+		if restart {
+			requeueExport(&export)
+		}
+
+		imported, err := h.jobRepo.Import(ctx, &export)
+		if err != nil {
+			h.logger.Error("failed to import job", zap.Error(err), zap.String("jobId", job.ID.String()))
+			resp.Failed = append(resp.Failed, &ImportFailure{Line: line, JobID: &job.ID, Error: err.Error()})
+			continue
+		}
+		if !imported {
+			resp.Skipped++
+			continue
+		}
+		resp.Imported++
+
+		if !restart {
+			continue
+		}
+		// With fair dispatch the dispatcher picks up the job like a new one
+		if !h.config.Scheduler.FairDispatch {
+			run, err := h.startWorkflow(ctx, job)
+			if err != nil {
+				h.logger.Error("failed to start workflow", zap.Error(err), zap.String("jobId", job.ID.String()))
+				resp.Failed = append(resp.Failed, &ImportFailure{Line: line, JobID: &job.ID, Error: fmt.Sprintf("imported, but failed to start workflow: %v", err)})
+				continue
+			}
+			if err := h.jobRepo.SetWorkflowID(ctx, job.ID, run.GetID()); err != nil {
+				h.logger.Error("failed to set workflow ID", zap.Error(err))
+			}
+		}
+		resp.Requeued++
+	}
+	if err := scanner.Err(); err != nil {
+		resp.Failed = append(resp.Failed, &ImportFailure{Line: line + 1, Error: "failed to read body: " + err.Error()})
+	}
+
+	h.logger.Info("jobs imported",
+		zap.Int("imported", resp.Imported),
+		zap.Int("skipped", resp.Skipped),
+		zap.Int("requeued", resp.Requeued),
+		zap.Int("failed", len(resp.Failed)),
+	)
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+// requeueExport resets an unfinished job to a fresh QUEUED job without a
+// workflow. Its artifacts are dropped, as the new workflow produces them again.
+func requeueExport(export *db.JobExport) {
+	job := export.Job
+	job.Status = domain.JobStatusQueued
+	job.WorkflowID = nil
+	job.CurrentStage = nil
+	job.StageProgress = 0
+	job.OverallProgress = 0
+	job.StartedAt = nil
+	job.FinishedAt = nil
+	export.Artifacts = nil
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/db"
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// Bulk operations touch at most this many jobs per request: every job is
+// signaled or canceled in Temporal within the request
+const (
+	defaultBulkLimit = 100
+	maxBulkLimit     = 500
+)
+
+// BulkJobFilter selects jobs for a bulk operation. Tenant, SeriesID or IDs
+// scope it; the other criteria narrow the scope down.
+type BulkJobFilter struct {
+	Tenant        string             `json:"tenant,omitempty"`
+	SeriesID      *uuid.UUID         `json:"seriesId,omitempty"`
+	IDs           []uuid.UUID        `json:"ids,omitempty"`
+	Status        []domain.JobStatus `json:"status,omitempty"` // defaults to QUEUED, RUNNING and PAUSED
+	CreatedBefore *time.Time         `json:"createdBefore,omitempty"`
+	VideoIDPrefix string             `json:"videoIdPrefix,omitempty"`
+	Limit         int                `json:"limit,omitempty"`
+}
+
+// BulkCancelRequest represents the request to cancel jobs matching a filter
+type BulkCancelRequest struct {
+	Filter BulkJobFilter `json:"filter"`
+	Reason string        `json:"reason,omitempty"`
+	Actor  string        `json:"actor,omitempty"`
+}
+
+// BulkPriorityRequest represents the request to change priority of jobs matching a filter
+type BulkPriorityRequest struct {
+	Filter   BulkJobFilter `json:"filter"`
+	Priority int           `json:"priority"`
+}
+
+// BulkJobFailure describes a job the bulk operation could not update
+type BulkJobFailure struct {
+	JobID uuid.UUID `json:"jobId"`
+	Error string    `json:"error"`
+}
+
+// BulkJobResponse summarizes a bulk operation
+type BulkJobResponse struct {
+	Matched  int               `json:"matched"`
+	Updated  int               `json:"updated"`
+	Requeued int               `json:"requeued,omitempty"`
+	Failed   []*BulkJobFailure `json:"failed,omitempty"`
+}
+
+// activeStatuses are the statuses bulk operations may act on
+var activeStatuses = []domain.JobStatus{
+	domain.JobStatusQueued,
+	domain.JobStatusRunning,
+	domain.JobStatusPaused,
+}
+
+// BulkCancelJobs cancels all active jobs matching the filter
+func (h *Handler) BulkCancelJobs(w http.ResponseWriter, r *http.Request) {
+	var req BulkCancelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	filter, limit, err := parseBulkFilter(req.Filter)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	jobs, err := h.jobRepo.ListByFilter(ctx, filter, limit)
+	if err != nil {
+		h.logger.Error("failed to list jobs", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to list jobs")
+		return
+	}
+
+	response := BulkJobResponse{Matched: len(jobs)}
+	cancelReq := CancelJobRequest{Reason: req.Reason, Actor: req.Actor}
+	for _, job := range jobs {
+		if err := h.cancelJob(ctx, job, cancelReq); err != nil {
+			h.logger.Error("failed to cancel job", zap.String("jobId", job.ID.String()), zap.Error(err))
+			response.Failed = append(response.Failed, &BulkJobFailure{JobID: job.ID, Error: err.Error()})
+			continue
+		}
+		response.Updated++
+	}
+
+	h.logger.Info("bulk cancel finished",
+		zap.Int("matched", response.Matched),
+		zap.Int("cancelled", response.Updated),
+		zap.String("reason", req.Reason),
+		zap.String("actor", req.Actor),
+	)
+
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// BulkUpdatePriority changes priority of all active jobs matching the filter
+func (h *Handler) BulkUpdatePriority(w http.ResponseWriter, r *http.Request) {
+	var req BulkPriorityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	filter, limit, err := parseBulkFilter(req.Filter)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	jobs, err := h.jobRepo.ListByFilter(ctx, filter, limit)
+	if err != nil {
+		h.logger.Error("failed to list jobs", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to list jobs")
+		return
+	}
+
+	response := BulkJobResponse{Matched: len(jobs)}
+	for _, job := range jobs {
+		result, err := h.updatePriority(ctx, job, req.Priority)
+		if err != nil {
+			h.logger.Error("failed to update priority", zap.String("jobId", job.ID.String()), zap.Error(err))
+			response.Failed = append(response.Failed, &BulkJobFailure{JobID: job.ID, Error: err.Error()})
+			continue
+		}
+		response.Updated++
+		if result.Requeued {
+			response.Requeued++
+		}
+	}
+
+	h.logger.Info("bulk priority update finished",
+		zap.Int("matched", response.Matched),
+		zap.Int("updated", response.Updated),
+		zap.Int("requeued", response.Requeued),
+		zap.Int("priority", req.Priority),
+	)
+
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// parseBulkFilter validates a bulk filter. A tenant, series or job ids are
+// required so a typo does not drain the whole queue.
+func parseBulkFilter(f BulkJobFilter) (db.JobFilter, int, error) {
+	filter := db.JobFilter{
+		Statuses:      f.Status,
+		CreatedBefore: f.CreatedBefore,
+		VideoIDPrefix: strings.ToLower(f.VideoIDPrefix),
+		Tenant:        f.Tenant,
+		SeriesID:      f.SeriesID,
+		IDs:           f.IDs,
+	}
+
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = activeStatuses
+	}
+	for _, status := range filter.Statuses {
+		if !isActiveStatus(status) {
+			return filter, 0, fmt.Errorf("status %q is not allowed, use QUEUED, RUNNING or PAUSED", status)
+		}
+	}
+
+	if filter.VideoIDPrefix != "" && strings.Trim(filter.VideoIDPrefix, "0123456789abcdef-") != "" {
+		return filter, 0, fmt.Errorf("videoIdPrefix must be a UUID prefix")
+	}
+
+	if filter.Tenant == "" && filter.SeriesID == nil && len(filter.IDs) == 0 {
+		return filter, 0, fmt.Errorf("filter must set tenant, seriesId or ids")
+	}
+	if len(filter.IDs) > maxBulkLimit {
+		return filter, 0, fmt.Errorf("ids must not list more than %d jobs", maxBulkLimit)
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultBulkLimit
+	}
+	if limit > maxBulkLimit {
+		return filter, 0, fmt.Errorf("limit must not exceed %d", maxBulkLimit)
+	}
+
+	return filter, limit, nil
+}
+
+func isActiveStatus(status domain.JobStatus) bool {
+	for _, s := range activeStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
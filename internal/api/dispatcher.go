@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/config"
+	"github.com/tvoe/converter/internal/db"
+)
+
+// Dispatcher starts queued jobs round-robin across tenants so a bulk ingest from
+// one customer interleaves with other customers' jobs instead of blocking them.
+// Several API replicas may run dispatchers: each round runs on the replica that
+// takes the dispatcher lock, so concurrent rounds cannot together exceed
+// SCHEDULER_MAX_IN_FLIGHT. A job is also claimed in the database before its
+// workflow is started. Tenants at SCHEDULER_TENANT_MAX_RUNNING get no more jobs,
+// so the in-flight limit is not taken up by jobs waiting for tenant quota.
+type Dispatcher struct {
+	config         *config.Config
+	database       *db.DB
+	jobRepo        *db.JobRepository
+	temporalClient client.Client
+	logger         *zap.Logger
+}
+
+// NewDispatcher creates a new fair dispatcher
+func NewDispatcher(cfg *config.Config, database *db.DB, jobRepo *db.JobRepository, temporalClient client.Client, logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		config:         cfg,
+		database:       database,
+		jobRepo:        jobRepo,
+		temporalClient: temporalClient,
+		logger:         logger.With(zap.String("component", "dispatcher")),
+	}
+}
+
+// Run dispatches jobs every SCHEDULER_INTERVAL until ctx is canceled
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Scheduler.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Another replica holding the lock dispatches this round
+			if _, err := d.database.WithLock(ctx, "dispatcher", d.dispatch); err != nil {
+				d.logger.Error("dispatch failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// dispatch starts as many queued jobs as the in-flight limit allows
+func (d *Dispatcher) dispatch(ctx context.Context) error {
+	inFlight, err := d.jobRepo.CountInFlight(ctx)
+	if err != nil {
+		return err
+	}
+
+	capacity := d.config.Scheduler.MaxInFlight - inFlight
+	if capacity <= 0 {
+		return nil
+	}
+
+	jobs, err := d.jobRepo.ListDispatchable(ctx, capacity, d.config.Scheduler.TenantMaxRunning)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		logger := d.logger.With(zap.String("jobId", job.ID.String()))
+
+		claimed, err := d.jobRepo.ClaimForDispatch(ctx, job.ID, conversionWorkflowID(job.ID))
+		if err != nil {
+			return fmt.Errorf("failed to claim job %s: %w", job.ID, err)
+		}
+		if !claimed {
+			continue
+		}
+
+		if _, err := startConversionWorkflow(ctx, d.temporalClient, d.config, job); err != nil {
+			logger.Error("failed to start workflow", zap.Error(err))
+			if err := d.jobRepo.ReleaseDispatch(ctx, job.ID); err != nil {
+				logger.Error("failed to release job", zap.Error(err))
+			}
+			continue
+		}
+
+		tenant := ""
+		if job.Tenant != nil {
+			tenant = *job.Tenant
+		}
+		logger.Info("job dispatched", zap.String("tenant", tenant), zap.Int("priority", job.Priority))
+	}
+
+	return nil
+}
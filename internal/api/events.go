@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/db"
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// sseKeepAliveInterval is how often a comment line is sent to keep idle connections open
+const sseKeepAliveInterval = 15 * time.Second
+
+// JobEvent represents a progress event streamed to clients
+type JobEvent struct {
+	ID              uuid.UUID        `json:"id"`
+	Status          domain.JobStatus `json:"status"`
+	CurrentStage    *domain.Stage    `json:"currentStage,omitempty"`
+	StageProgress   int              `json:"stageProgress"`
+	OverallProgress int              `json:"overallProgress"`
+	UpdatedAt       time.Time        `json:"updatedAt"`
+}
+
+// StreamJobEvents streams job stage/progress updates as Server-Sent Events
+func (h *Handler) StreamJobEvents(w http.ResponseWriter, r *http.Request) {
+	jobIDStr := chi.URLParam(r, "jobId")
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid job ID")
+		return
+	}
+
+	ctx := r.Context()
+
+	job, err := h.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		h.logger.Error("failed to get job", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get job")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	// The stream outlives the server write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	pollInterval := h.config.API.EventsPollInterval
+	if pollInterval <= 0 {
+		pollInterval = 2 * time.Second
+	}
+	fmt.Fprintf(w, "retry: %d\n\n", pollInterval.Milliseconds())
+
+	var last *JobEvent
+	send := func(job *domain.Job) error {
+		event := &JobEvent{
+			ID:              job.ID,
+			Status:          job.Status,
+			CurrentStage:    job.CurrentStage,
+			StageProgress:   job.StageProgress,
+			OverallProgress: job.OverallProgress,
+			UpdatedAt:       job.UpdatedAt,
+		}
+		if last != nil && last.sameAs(event) {
+			return nil
+		}
+		last = event
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		eventName := "progress"
+		if isTerminalStatus(job.Status) {
+			eventName = "done"
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventName, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	if err := send(job); err != nil || isTerminalStatus(job.Status) {
+		return
+	}
+
+	pollTicker := time.NewTicker(pollInterval)
+	defer pollTicker.Stop()
+	keepAliveTicker := time.NewTicker(sseKeepAliveInterval)
+	defer keepAliveTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAliveTicker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-pollTicker.C:
+			job, err := h.jobRepo.GetByID(ctx, jobID)
+			if err != nil {
+				if ctx.Err() == nil {
+					h.logger.Warn("failed to poll job for events", zap.String("jobId", jobID.String()), zap.Error(err))
+				}
+				continue
+			}
+			if err := send(job); err != nil || isTerminalStatus(job.Status) {
+				return
+			}
+		}
+	}
+}
+
+// sameAs reports whether two events carry the same progress state
+func (e *JobEvent) sameAs(other *JobEvent) bool {
+	stage := func(s *domain.Stage) domain.Stage {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return e.Status == other.Status &&
+		stage(e.CurrentStage) == stage(other.CurrentStage) &&
+		e.StageProgress == other.StageProgress &&
+		e.OverallProgress == other.OverallProgress
+}
+
+// isTerminalStatus returns true if the job will not change status anymore
+func isTerminalStatus(status domain.JobStatus) bool {
+	switch status {
+	case domain.JobStatusCompleted, domain.JobStatusFailed, domain.JobStatusQuarantined, domain.JobStatusCanceled:
+		return true
+	default:
+		return false
+	}
+}
@@ -5,54 +5,86 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
 	"go.uber.org/zap"
 
 	"github.com/tvoe/converter/internal/config"
 	"github.com/tvoe/converter/internal/db"
 	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/events"
+	"github.com/tvoe/converter/internal/ffmpeg"
 	"github.com/tvoe/converter/internal/metrics"
+	"github.com/tvoe/converter/internal/publish"
 	"github.com/tvoe/converter/internal/storage/s3"
 	"github.com/tvoe/converter/internal/temporal/workflows"
 )
 
+// workflowQueryTimeout bounds live progress queries so a busy worker does not stall GetJob
+const workflowQueryTimeout = 5 * time.Second
+
 // Handler holds API dependencies
 type Handler struct {
 	config         *config.Config
+	database       *db.DB
 	jobRepo        *db.JobRepository
 	errorRepo      *db.ErrorRepository
 	artifactRepo   *db.ArtifactRepository
+	profileRepo    *db.ProfileRepository
+	stageRepo      *db.StageRepository
+	versionRepo    *db.VersionRepository
 	s3Client       *s3.Client
+	publisher      *publish.Publisher
 	temporalClient client.Client
 	logger         *zap.Logger
 	metrics        *metrics.Metrics
+	events         *events.Bus
 }
 
 // NewHandler creates a new handler
 func NewHandler(
 	cfg *config.Config,
+	database *db.DB,
 	jobRepo *db.JobRepository,
 	errorRepo *db.ErrorRepository,
 	artifactRepo *db.ArtifactRepository,
+	profileRepo *db.ProfileRepository,
+	stageRepo *db.StageRepository,
+	versionRepo *db.VersionRepository,
 	s3Client *s3.Client,
+	publisher *publish.Publisher,
 	temporalClient client.Client,
 	logger *zap.Logger,
 	m *metrics.Metrics,
+	bus *events.Bus,
 ) *Handler {
 	return &Handler{
 		config:         cfg,
+		database:       database,
 		jobRepo:        jobRepo,
 		errorRepo:      errorRepo,
 		artifactRepo:   artifactRepo,
+		profileRepo:    profileRepo,
+		stageRepo:      stageRepo,
+		versionRepo:    versionRepo,
 		s3Client:       s3Client,
+		publisher:      publisher,
 		temporalClient: temporalClient,
 		logger:         logger,
 		metrics:        m,
+		events:         bus,
 	}
 }
 
@@ -60,16 +92,179 @@ func NewHandler(
 type CreateJobRequest struct {
 	Source         SourceConfig   `json:"source"`
 	Profile        domain.Profile `json:"profile"`
+	ProfileID      string         `json:"profileId,omitempty"` // Profile template ID or name; overrides Profile
 	Priority       int            `json:"priority"`
 	IdempotencyKey string         `json:"idempotencyKey,omitempty"`
 	VideoID        *uuid.UUID     `json:"videoId,omitempty"`
+	Tenant         string         `json:"tenant,omitempty"` // Groups jobs for fair dispatch
+	// DryRun validates the source and returns the plan of the job without encoding
+	DryRun bool `json:"dryRun,omitempty"`
+	// StartTime and EndTime convert only a segment of the source, in seconds;
+	// they override the trim of the profile
+	StartTime *float64 `json:"startTime,omitempty"`
+	EndTime   *float64 `json:"endTime,omitempty"`
+	// Subtitles are subtitle files of the source, published with the tracks
+	// extracted from it
+	Subtitles []domain.SubtitleSource `json:"subtitles,omitempty"`
 }
 
 // SourceConfig represents source configuration
 type SourceConfig struct {
-	Type   string `json:"type"`
+	Type   string `json:"type"` // "s3" or "stream"
 	Bucket string `json:"bucket"`
 	Key    string `json:"key"`
+	// URL is an HLS (.m3u8) or DASH (.mpd) manifest for the stream source type
+	URL string `json:"url,omitempty"`
+}
+
+// validateSource checks source fields for the source type. S3 sources must
+// be in an allowed bucket and have a plain key with a supported extension;
+// stream sources must be on an allowed, public host.
+func (h *Handler) validateSource(ctx context.Context, src SourceConfig) error {
+	switch src.Type {
+	case "s3":
+		if src.Bucket == "" || src.Key == "" {
+			return errors.New("source bucket and key are required")
+		}
+		if err := validateSourceKey(src.Key, h.config.S3.SourceExtensions); err != nil {
+			return err
+		}
+		if !h.sourceAllowed(src.Bucket, src.Key) {
+			return errors.New("source bucket or prefix is not allowed")
+		}
+	case "stream":
+		u, err := url.Parse(src.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("source url must be an http(s) URL")
+		}
+		if ext := strings.ToLower(path.Ext(u.Path)); ext != ".m3u8" && ext != ".mpd" {
+			return errors.New("source url must point to an .m3u8 or .mpd manifest")
+		}
+		if err := ffmpeg.CheckStreamURL(ctx, src.URL, h.config.Input.StreamHosts); err != nil {
+			return err
+		}
+	default:
+		return errors.New("source type must be s3 or stream")
+	}
+	return nil
+}
+
+// validateSourceKey rejects keys with control characters, empty or dot
+// segments, and extensions that are not listed
+func validateSourceKey(key string, extensions []string) error {
+	for _, r := range key {
+		if r < 0x20 || r == 0x7f {
+			return errors.New("source key must not contain control characters")
+		}
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return errors.New("source key must not contain empty, '.' or '..' segments")
+		}
+	}
+	if !sourceExtensionAllowed(key, extensions) {
+		return fmt.Errorf("source extension %q is not supported", path.Ext(key))
+	}
+	return nil
+}
+
+// sourceExtensionAllowed reports whether the extension of name is listed,
+// case-insensitively and with or without the leading dot
+func sourceExtensionAllowed(name string, extensions []string) bool {
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(name)), ".")
+	if ext == "" {
+		return false
+	}
+	for _, e := range extensions {
+		if strings.TrimPrefix(strings.ToLower(e), ".") == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceAllowed reports whether key in bucket matches S3_SOURCE_ALLOWLIST.
+// A prefix matches whole path segments: "bucket/tenant1" allows "tenant1/a.mp4"
+// but not "tenant10/a.mp4". The staging bucket of direct uploads is always allowed.
+func (h *Handler) sourceAllowed(bucket, key string) bool {
+	allowList := h.config.S3.SourceAllowList
+	if len(allowList) == 0 || bucket == h.config.S3.BucketStaging {
+		return true
+	}
+	for _, entry := range allowList {
+		allowedBucket, prefix, _ := strings.Cut(entry, "/")
+		if allowedBucket == bucket && keyUnderPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyUnderPrefix reports whether key is prefix itself or lies under it as a directory
+func keyUnderPrefix(key, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == "" || key == prefix || strings.HasPrefix(key, prefix+"/")
+}
+
+// validateSubtitleSources checks the subtitle files of a job request. They are
+// read like the source, so the same allow list applies; a file of a stream
+// source names its bucket.
+func (h *Handler) validateSubtitleSources(src SourceConfig, subtitles []domain.SubtitleSource) error {
+	seen := make(map[string]bool, len(subtitles))
+	for _, s := range subtitles {
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("subtitles: %w", err)
+		}
+		bucket := s.Bucket
+		if bucket == "" {
+			bucket = src.Bucket
+		}
+		if bucket == "" {
+			return fmt.Errorf("subtitles: %s needs a bucket", s.S3Key)
+		}
+		if !h.sourceAllowed(bucket, s.S3Key) {
+			return fmt.Errorf("subtitles: bucket or prefix of %s is not allowed", s.S3Key)
+		}
+		name := s.Language
+		if s.Forced {
+			name += " forced"
+		}
+		if seen[name] {
+			return fmt.Errorf("subtitles: more than one %s file", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// validateBumper checks an intro or outro clip. It is read like the source, so
+// the source key rules and allow list apply. bucket is where the clip is read
+// from; a profile leaves it empty for the source bucket of its future jobs,
+// and the allow list is then checked when a job is created.
+func (h *Handler) validateBumper(c *domain.IntroConfig, bucket string) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if err := validateSourceKey(c.S3Key, h.config.S3.SourceExtensions); err != nil {
+		return err
+	}
+	if bucket != "" && !h.sourceAllowed(bucket, c.S3Key) {
+		return errors.New("bucket or prefix is not allowed")
+	}
+	return nil
+}
+
+// validateJobBumper checks an intro or outro clip of a job request; without a
+// bucket of its own the clip is in the source bucket
+func (h *Handler) validateJobBumper(src SourceConfig, c *domain.IntroConfig) error {
+	bucket := c.Bucket
+	if bucket == "" {
+		bucket = src.Bucket
+	}
+	if bucket == "" {
+		return fmt.Errorf("%s needs a bucket", c.S3Key)
+	}
+	return h.validateBumper(c, bucket)
 }
 
 // CreateJobResponse represents the response after creating a job
@@ -90,7 +285,27 @@ type JobStatusResponse struct {
 	StartedAt       *time.Time       `json:"startedAt,omitempty"`
 	UpdatedAt       time.Time        `json:"updatedAt"`
 	FinishedAt      *time.Time       `json:"finishedAt,omitempty"`
+	CancelReason    *string          `json:"cancelReason,omitempty"`
+	CanceledBy      *string          `json:"canceledBy,omitempty"`
+	DryRun          bool             `json:"dryRun,omitempty"`
 	Errors          []*ErrorResponse `json:"errors,omitempty"`
+	// Warnings are non-fatal problems, e.g. skipped subtitle tracks or downmixed audio
+	Warnings []domain.JobWarning `json:"warnings,omitempty"`
+	// PartialResults is set for canceled jobs
+	PartialResults *PartialResultsResponse `json:"partialResults,omitempty"`
+	// Plan is set for completed dry runs
+	Plan *domain.JobPlan `json:"plan,omitempty"`
+	// Complexity is the per-title analysis the ladder of the profile was scaled by
+	Complexity *domain.ComplexityAnalysis `json:"complexity,omitempty"`
+	// Live is set when progress was read from the running workflow (?live=true)
+	Live       bool                           `json:"live,omitempty"`
+	Renditions []*workflows.RenditionProgress `json:"renditions,omitempty"`
+}
+
+// PartialResultsResponse describes what a canceled job managed to produce
+type PartialResultsResponse struct {
+	CompletedStages []domain.Stage      `json:"completedStages"`
+	Artifacts       []*ArtifactResponse `json:"artifacts"`
 }
 
 // ErrorResponse represents error response
@@ -109,9 +324,35 @@ type ArtifactResponse struct {
 	Bucket    string              `json:"bucket"`
 	Key       string              `json:"key"`
 	SizeBytes *int64              `json:"sizeBytes,omitempty"`
+	URL       string              `json:"url,omitempty"`
+	ExpiresAt *time.Time          `json:"expiresAt,omitempty"`
 	CreatedAt time.Time           `json:"createdAt"`
 }
 
+// JobMetadataResponse represents source metadata of a job
+type JobMetadataResponse struct {
+	JobID    uuid.UUID             `json:"jobId"`
+	Metadata *domain.VideoMetadata `json:"metadata"`
+	FFprobe  json.RawMessage       `json:"ffprobe,omitempty"`
+}
+
+// StageTimingResponse represents a single stage attempt timing
+type StageTimingResponse struct {
+	Stage      domain.Stage       `json:"stage"`
+	Attempt    int                `json:"attempt"`
+	Status     domain.StageStatus `json:"status"`
+	StartedAt  time.Time          `json:"startedAt"`
+	FinishedAt *time.Time         `json:"finishedAt,omitempty"`
+	DurationMs int64              `json:"durationMs"`
+}
+
+// JobStagesResponse represents the stage timing breakdown of a job
+type JobStagesResponse struct {
+	JobID           uuid.UUID              `json:"jobId"`
+	Stages          []*StageTimingResponse `json:"stages"`
+	TotalDurationMs int64                  `json:"totalDurationMs"`
+}
+
 // DRMKeyResponse represents DRM key response for testing/development
 type DRMKeyResponse struct {
 	KeyID    string `json:"keyId"`
@@ -121,6 +362,18 @@ type DRMKeyResponse struct {
 	CertURL  string `json:"certUrl,omitempty"` // Certificate URL (FairPlay)
 }
 
+// ProbeRequest represents the request to probe a source without creating a job
+type ProbeRequest struct {
+	Source SourceConfig `json:"source"`
+}
+
+// ProbeResponse represents probe result
+type ProbeResponse struct {
+	Metadata  *domain.VideoMetadata `json:"metadata"`
+	Supported bool                  `json:"supported"`
+	Problems  []string              `json:"problems,omitempty"`
+}
+
 // CreateJob creates a new conversion job
 func (h *Handler) CreateJob(w http.ResponseWriter, r *http.Request) {
 	var req CreateJobRequest
@@ -129,86 +382,729 @@ func (h *Handler) CreateJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate request
-	if req.Source.Type != "s3" {
-		h.writeError(w, http.StatusBadRequest, "only s3 source type is supported")
+	h.submitJob(w, r, &req)
+}
+
+// submitJob validates the request, stores the job and starts or queues its workflow
+func (h *Handler) submitJob(w http.ResponseWriter, r *http.Request, req *CreateJobRequest) {
+	// Validate request
+	if err := h.validateSource(r.Context(), req.Source); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+
+	// Check idempotency
+	if req.IdempotencyKey != "" {
+		existingJob, err := h.jobRepo.GetByIdempotencyKey(ctx, req.IdempotencyKey)
+		if err == nil && existingJob != nil {
+			h.writeJSON(w, http.StatusOK, CreateJobResponse{
+				JobID:     existingJob.ID,
+				Status:    existingJob.Status,
+				CreatedAt: existingJob.CreatedAt,
+			})
+			return
+		}
+	}
+
+	job, status, err := h.newJob(ctx, req)
+	if err != nil {
+		h.writeError(w, status, err.Error())
+		return
+	}
+
+	if err := h.jobRepo.Create(ctx, job); err != nil {
+		h.logger.Error("failed to create job", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to create job")
+		return
+	}
+
+	// With fair dispatch the dispatcher starts the workflow when the job's turn comes
+	if h.config.Scheduler.FairDispatch {
+		h.metrics.IncrementJobsTotal(string(domain.JobStatusQueued))
+		h.events.Publish(events.JobEvent(events.JobCreated, job.ID, job.Status))
+		h.logger.Info("job queued for dispatch", zap.String("jobId", job.ID.String()))
+
+		h.writeJSON(w, http.StatusCreated, CreateJobResponse{
+			JobID:     job.ID,
+			Status:    job.Status,
+			CreatedAt: job.CreatedAt,
+		})
+		return
+	}
+
+	// Start Temporal workflow
+	workflowRun, err := h.startWorkflow(ctx, job)
+	if err != nil {
+		h.logger.Error("failed to start workflow", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to start workflow")
+		return
+	}
+
+	// Update job with workflow ID
+	if err := h.jobRepo.SetWorkflowID(ctx, job.ID, workflowRun.GetID()); err != nil {
+		h.logger.Error("failed to set workflow ID", zap.Error(err))
+	}
+
+	h.metrics.IncrementJobsTotal(string(domain.JobStatusQueued))
+	h.events.Publish(events.JobEvent(events.JobCreated, job.ID, job.Status))
+	h.logger.Info("job created",
+		zap.String("jobId", job.ID.String()),
+		zap.String("workflowId", workflowRun.GetID()),
+	)
+
+	h.writeJSON(w, http.StatusCreated, CreateJobResponse{
+		JobID:     job.ID,
+		Status:    job.Status,
+		CreatedAt: job.CreatedAt,
+	})
+}
+
+// newJob resolves the profile of a validated request and builds the job.
+// On error the status is the HTTP status to respond with.
+func (h *Handler) newJob(ctx context.Context, req *CreateJobRequest) (*domain.Job, int, error) {
+	// Resolve profile template
+	if req.ProfileID != "" {
+		tmpl, err := h.resolveProfile(ctx, req.ProfileID)
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				return nil, http.StatusBadRequest, fmt.Errorf("profile not found")
+			}
+			h.logger.Error("failed to get profile", zap.Error(err))
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to get profile")
+		}
+		req.Profile = tmpl.Profile
+	}
+
+	if req.StartTime != nil || req.EndTime != nil {
+		trim := domain.TrimConfig{}
+		if t := req.Profile.Trim; t != nil {
+			trim = *t
+		}
+		if req.StartTime != nil {
+			trim.Start = *req.StartTime
+		}
+		if req.EndTime != nil {
+			trim.End = *req.EndTime
+		}
+		req.Profile.Trim = &trim
+	}
+
+	if err := req.Profile.ValidateLadder(); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	if c := req.Profile.Intro; c != nil {
+		if err := h.validateJobBumper(req.Source, c); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("intro: %w", err)
+		}
+	}
+	if c := req.Profile.Outro; c != nil {
+		if err := h.validateJobBumper(req.Source, c); err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("outro: %w", err)
+		}
+	}
+	if c := req.Profile.Trim; c != nil {
+		if err := c.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	if m := req.Profile.Mezzanine; m != nil && !m.Codec.IsValid() {
+		return nil, http.StatusBadRequest, fmt.Errorf("mezzanine codec must be prores or dnxhr")
+	}
+
+	if b := req.Profile.Budget; b != nil && (b.MaxRenditions < 0 || b.MaxEncodeMinutes < 0) {
+		return nil, http.StatusBadRequest, fmt.Errorf("profile budget limits must not be negative")
+	}
+
+	if err := domain.ValidateAudioTracks(req.Profile.AudioTracks); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if err := req.Profile.HLS.Validate(); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	if c := req.Profile.Algorithm.FPSCap; c != nil {
+		if err := c.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	if c := req.Profile.Algorithm.FrameRate; c != nil {
+		if err := c.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	if c := req.Profile.Algorithm.Deinterlace; c != nil {
+		if err := c.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	if c := req.Profile.Algorithm.AutoCrop; c != nil {
+		if err := c.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	if c := req.Profile.PerTitle; c != nil {
+		if err := c.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	if c := req.Profile.BitDepth; c != nil {
+		if err := c.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	if c := req.Profile.Encoder; c != nil {
+		if err := c.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	if c := req.Profile.Audio; c != nil {
+		if err := c.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	if c := req.Profile.AudioOnly; c != nil {
+		if err := c.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	if c := req.Profile.Hardsub; c != nil {
+		if err := c.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	if err := domain.ValidateCuePoints(req.Profile.CuePoints); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	if r := req.Profile.Retry; r != nil {
+		if err := r.Validate(); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+
+	// Set default profile values; audio-only profiles have no qualities
+	if len(req.Profile.Qualities) == 0 && !req.Profile.IsAudioOnly() {
+		req.Profile = domain.DefaultProfile()
+	}
+
+	// Subtitle files come only from the checked top-level field, never from the
+	// profile of the request or a template
+	if len(req.Subtitles) > 0 {
+		if err := h.validateSubtitleSources(req.Source, req.Subtitles); err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+	}
+	req.Profile.ExternalSubtitles = req.Subtitles
+
+	// Create job
+	job := domain.NewJob(req.Source.Bucket, req.Source.Key, req.Profile)
+	if req.Source.Type == "stream" {
+		job.SourceURL = &req.Source.URL
+	}
+	job.Priority = req.Priority
+	job.VideoID = req.VideoID
+	if req.IdempotencyKey != "" {
+		job.IdempotencyKey = &req.IdempotencyKey
+	}
+	if req.Tenant != "" {
+		job.Tenant = &req.Tenant
+	}
+	job.DryRun = req.DryRun
+
+	return job, 0, nil
+}
+
+// ProbeSource extracts source metadata without creating a job.
+// ffprobe reads the object over a presigned URL, fetching only the byte ranges it needs.
+func (h *Handler) ProbeSource(w http.ResponseWriter, r *http.Request) {
+	var req ProbeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.validateSource(r.Context(), req.Source); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.config.API.ProbeTimeout)
+	defer cancel()
+
+	sourceURL := req.Source.URL
+	if req.Source.Type == "s3" {
+		exists, err := h.s3Client.Exists(ctx, req.Source.Bucket, req.Source.Key)
+		if err != nil {
+			h.logger.Error("failed to check source", zap.Error(err))
+			h.writeError(w, http.StatusBadGateway, "failed to access source")
+			return
+		}
+		if !exists {
+			h.writeError(w, http.StatusNotFound, "source not found")
+			return
+		}
+
+		sourceURL, err = h.s3Client.PresignGet(ctx, req.Source.Bucket, req.Source.Key, h.config.API.ProbeTimeout)
+		if err != nil {
+			h.logger.Error("failed to presign source", zap.Error(err))
+			h.writeError(w, http.StatusInternalServerError, "failed to access source")
+			return
+		}
+	}
+
+	prober := ffmpeg.NewProber(h.config.FFmpeg.FFprobePath)
+	metadata, err := prober.Probe(ctx, sourceURL)
+	if err != nil {
+		h.logger.Warn("failed to probe source",
+			zap.String("bucket", req.Source.Bucket),
+			zap.String("key", req.Source.Key),
+			zap.Error(err),
+		)
+		h.writeError(w, http.StatusUnprocessableEntity, "failed to probe source")
+		return
+	}
+
+	policy := domain.FormatPolicy{
+		AllowContainers:  h.config.Input.AllowContainers,
+		DenyContainers:   h.config.Input.DenyContainers,
+		AllowVideoCodecs: h.config.Input.AllowVideoCodecs,
+		DenyVideoCodecs:  h.config.Input.DenyVideoCodecs,
+		AllowAudioCodecs: h.config.Input.AllowAudioCodecs,
+		DenyAudioCodecs:  h.config.Input.DenyAudioCodecs,
+	}
+
+	var problems []string
+	if !policy.IsContainerSupported(metadata.Container) {
+		problems = append(problems, fmt.Sprintf("unsupported container: %s", metadata.Container))
+	}
+	if !policy.IsVideoCodecSupported(metadata.VideoCodec) {
+		problems = append(problems, fmt.Sprintf("unsupported video codec: %s", metadata.VideoCodec))
+	}
+	if metadata.AudioCodec != "" && !policy.IsAudioCodecSupported(metadata.AudioCodec) {
+		problems = append(problems, fmt.Sprintf("unsupported audio codec: %s", metadata.AudioCodec))
+	}
+	if domain.IsAdaptiveContainer(metadata.Container) && metadata.Duration <= 0 {
+		problems = append(problems, "live streams are not supported")
+	}
+	if !metadata.HDR.HasCompatibleBaseLayer() {
+		problems = append(problems, fmt.Sprintf("dolby vision profile %d has no compatible base layer", metadata.HDR.DolbyVisionProfile))
+	}
+
+	h.writeJSON(w, http.StatusOK, ProbeResponse{
+		Metadata:  metadata,
+		Supported: len(problems) == 0,
+		Problems:  problems,
+	})
+}
+
+// UpdatePriorityRequest represents the request to change job priority
+type UpdatePriorityRequest struct {
+	Priority int `json:"priority"`
+}
+
+// UpdatePriorityResponse represents the response after changing job priority
+type UpdatePriorityResponse struct {
+	JobID     uuid.UUID `json:"jobId"`
+	Priority  int       `json:"priority"`
+	TaskQueue string    `json:"taskQueue"`
+	Requeued  bool      `json:"requeued"`
+}
+
+// UpdateJobPriority changes job priority. Jobs that have not started yet are
+// moved to the task queue matching the new priority; running jobs keep their
+// queue because their workspace lives on the worker that picked them up.
+func (h *Handler) UpdateJobPriority(w http.ResponseWriter, r *http.Request) {
+	jobIDStr := chi.URLParam(r, "jobId")
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid job ID")
+		return
+	}
+
+	var req UpdatePriorityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ctx := r.Context()
+
+	job, err := h.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		h.logger.Error("failed to get job", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get job")
+		return
+	}
+
+	if isTerminalStatus(job.Status) {
+		h.writeError(w, http.StatusBadRequest, "job is already finished")
+		return
+	}
+
+	response, err := h.updatePriority(ctx, job, req.Priority)
+	if err != nil {
+		h.logger.Error("failed to update priority", zap.Error(err))
+		if errors.Is(err, errRequeueFailed) {
+			h.writeError(w, http.StatusConflict, "job could not be requeued")
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, "failed to update priority")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// errRequeueFailed is returned when a queued workflow could not be moved to another task queue
+var errRequeueFailed = errors.New("job could not be requeued")
+
+// updatePriority stores the new priority and requeues the job if it has not started yet
+func (h *Handler) updatePriority(ctx context.Context, job *domain.Job, priority int) (*UpdatePriorityResponse, error) {
+	if err := h.jobRepo.UpdatePriority(ctx, job.ID, priority); err != nil {
+		return nil, err
+	}
+
+	oldQueue := jobTaskQueue(h.config, job)
+	job.Priority = priority
+	newQueue := jobTaskQueue(h.config, job)
+
+	response := &UpdatePriorityResponse{
+		JobID:     job.ID,
+		Priority:  priority,
+		TaskQueue: oldQueue,
+	}
+
+	// Jobs of a series are started by their series workflow on its queue
+	if job.Status == domain.JobStatusQueued && job.WorkflowID != nil && job.SeriesID == nil && oldQueue != newQueue {
+		// The job row stays locked from the status check until the new run is
+		// started: a job its workflow has marked RUNNING keeps running, and a
+		// workflow marking it meanwhile waits for the lock in vain
+		requeued, err := h.jobRepo.RequeueQueued(ctx, job.ID, func(ctx context.Context) error {
+			// The new run keeps the workflow ID of the job and terminates the
+			// queued one as it starts, which skips FinalizeJob, so the job row
+			// stays QUEUED for it. A failed start leaves the queued run as is.
+			if _, err := requeueConversionWorkflow(ctx, h.temporalClient, h.config, job); err != nil {
+				return fmt.Errorf("%w: %v", errRequeueFailed, err)
+			}
+			return nil
+		})
+		if err != nil {
+			h.releaseIfWorkflowGone(ctx, job)
+			return nil, err
+		}
+		if requeued {
+			response.TaskQueue = newQueue
+			response.Requeued = true
+		}
+	}
+
+	h.logger.Info("job priority updated",
+		zap.String("jobId", job.ID.String()),
+		zap.Int("priority", priority),
+		zap.String("taskQueue", response.TaskQueue),
+		zap.Bool("requeued", response.Requeued),
+	)
+
+	return response, nil
+}
+
+// releaseIfWorkflowGone returns a job to the dispatch queue after a failed
+// requeue if it was left without a running workflow, so it is not orphaned
+func (h *Handler) releaseIfWorkflowGone(ctx context.Context, job *domain.Job) {
+	resp, err := h.temporalClient.DescribeWorkflowExecution(ctx, *job.WorkflowID, "")
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if !errors.As(err, &notFound) {
+			h.logger.Error("failed to describe workflow", zap.String("jobId", job.ID.String()), zap.Error(err))
+			return
+		}
+	} else if resp.GetWorkflowExecutionInfo().GetStatus() == enumspb.WORKFLOW_EXECUTION_STATUS_RUNNING {
+		return
+	}
+	if err := h.jobRepo.ReleaseDispatch(ctx, job.ID); err != nil {
+		h.logger.Error("failed to release job", zap.String("jobId", job.ID.String()), zap.Error(err))
+		return
+	}
+	h.logger.Warn("job returned to the dispatch queue after a failed requeue", zap.String("jobId", job.ID.String()))
+}
+
+// GetJob gets job status
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobIDStr := chi.URLParam(r, "jobId")
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid job ID")
+		return
+	}
+
+	ctx := r.Context()
+
+	job, err := h.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		h.logger.Error("failed to get job", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get job")
+		return
+	}
+
+	response := JobStatusResponse{
+		ID:              job.ID,
+		Status:          job.Status,
+		CurrentStage:    job.CurrentStage,
+		StageProgress:   job.StageProgress,
+		OverallProgress: job.OverallProgress,
+		CreatedAt:       job.CreatedAt,
+		StartedAt:       job.StartedAt,
+		UpdatedAt:       job.UpdatedAt,
+		FinishedAt:      job.FinishedAt,
+		CancelReason:    job.CancelReason,
+		CanceledBy:      job.CanceledBy,
+		DryRun:          job.DryRun,
+	}
+
+	// Progress in the job row lags behind the workflow; query it directly on request
+	if r.URL.Query().Get("live") == "true" && job.WorkflowID != nil &&
+		(job.Status == domain.JobStatusRunning || job.Status == domain.JobStatusPaused) {
+		progress, err := h.queryProgress(ctx, *job.WorkflowID)
+		if err != nil {
+			h.logger.Warn("failed to query workflow progress, using stored progress",
+				zap.String("jobId", jobID.String()), zap.Error(err))
+		} else if progress.Stage != "" {
+			response.CurrentStage = &progress.Stage
+			response.StageProgress = progress.StageProgress
+			response.OverallProgress = progress.OverallProgress
+			response.Renditions = progress.Renditions
+			response.Live = true
+		}
+	}
+
+	warnings, err := h.jobRepo.GetWarnings(ctx, jobID)
+	if err != nil {
+		h.logger.Warn("failed to get job warnings", zap.String("jobId", jobID.String()), zap.Error(err))
+	} else {
+		response.Warnings = warnings
+	}
+
+	if perTitleEnabled(h.config, job.Profile) {
+		complexity, err := h.jobRepo.GetComplexity(ctx, jobID)
+		if err != nil {
+			h.logger.Warn("failed to get job complexity", zap.String("jobId", jobID.String()), zap.Error(err))
+		} else {
+			response.Complexity = complexity
+		}
+	}
+
+	if job.DryRun {
+		plan, err := h.jobRepo.GetPlan(ctx, jobID)
+		if err != nil {
+			h.logger.Warn("failed to get job plan", zap.String("jobId", jobID.String()), zap.Error(err))
+		} else {
+			response.Plan = plan
+		}
+	}
+
+	// Get errors if job failed
+	if job.Status == domain.JobStatusFailed || job.Status == domain.JobStatusQuarantined {
+		errors, err := h.errorRepo.GetByJobID(ctx, jobID)
+		if err == nil {
+			for _, e := range errors {
+				response.Errors = append(response.Errors, &ErrorResponse{
+					Stage:     e.Stage,
+					Class:     e.Class,
+					Code:      e.Code,
+					Message:   e.Message,
+					CreatedAt: e.CreatedAt,
+				})
+			}
+		}
+	}
+
+	if job.Status == domain.JobStatusCanceled {
+		partial, err := h.partialResults(ctx, jobID)
+		if err != nil {
+			h.logger.Error("failed to get partial results", zap.Error(err))
+		} else {
+			response.PartialResults = partial
+		}
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// queryProgress reads in-flight progress from the conversion workflow
+func (h *Handler) queryProgress(ctx context.Context, workflowID string) (*workflows.Progress, error) {
+	ctx, cancel := context.WithTimeout(ctx, workflowQueryTimeout)
+	defer cancel()
+
+	value, err := h.temporalClient.QueryWorkflow(ctx, workflowID, "", workflows.QueryProgress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workflow: %w", err)
+	}
+	var progress workflows.Progress
+	if err := value.Get(&progress); err != nil {
+		return nil, fmt.Errorf("failed to decode workflow progress: %w", err)
+	}
+	return &progress, nil
+}
+
+// partialResults collects completed stages and uploaded artifacts of a job
+func (h *Handler) partialResults(ctx context.Context, jobID uuid.UUID) (*PartialResultsResponse, error) {
+	stages, err := h.stageRepo.GetByJobID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job stages: %w", err)
+	}
+	artifacts, err := h.artifactRepo.GetByJobID(ctx, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artifacts: %w", err)
+	}
+
+	completed := make(map[domain.Stage]bool)
+	for _, s := range stages {
+		if s.Status == domain.StageStatusCompleted {
+			completed[s.Stage] = true
+		}
+	}
+
+	partial := &PartialResultsResponse{
+		CompletedStages: make([]domain.Stage, 0, len(completed)),
+		Artifacts:       make([]*ArtifactResponse, 0, len(artifacts)),
+	}
+	for _, stage := range domain.AllStages() {
+		if completed[stage] {
+			partial.CompletedStages = append(partial.CompletedStages, stage)
+		}
+	}
+	for _, a := range artifacts {
+		partial.Artifacts = append(partial.Artifacts, &ArtifactResponse{
+			ID:        a.ID,
+			Type:      a.Type,
+			Bucket:    a.Bucket,
+			Key:       a.Key,
+			SizeBytes: a.SizeBytes,
+			CreatedAt: a.CreatedAt,
+		})
+	}
+	return partial, nil
+}
+
+// CancelJobRequest represents the optional body of a cancel request
+type CancelJobRequest struct {
+	Reason string `json:"reason,omitempty"`
+	Actor  string `json:"actor,omitempty"` // e.g. user ID or "takedown-bot"
+}
+
+// CancelJob cancels a job
+func (h *Handler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	jobIDStr := chi.URLParam(r, "jobId")
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid job ID")
 		return
 	}
-	if req.Source.Bucket == "" || req.Source.Key == "" {
-		h.writeError(w, http.StatusBadRequest, "source bucket and key are required")
+
+	// Body is optional
+	var req CancelJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	ctx := r.Context()
 
-	// Check idempotency
-	if req.IdempotencyKey != "" {
-		existingJob, err := h.jobRepo.GetByIdempotencyKey(ctx, req.IdempotencyKey)
-		if err == nil && existingJob != nil {
-			h.writeJSON(w, http.StatusOK, CreateJobResponse{
-				JobID:     existingJob.ID,
-				Status:    existingJob.Status,
-				CreatedAt: existingJob.CreatedAt,
-			})
+	job, err := h.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "job not found")
 			return
 		}
+		h.logger.Error("failed to get job", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get job")
+		return
 	}
 
-	// Set default profile values
-	if len(req.Profile.Qualities) == 0 {
-		req.Profile = domain.DefaultProfile()
-	}
-
-	// Create job
-	job := domain.NewJob(req.Source.Bucket, req.Source.Key, req.Profile)
-	job.Priority = req.Priority
-	job.VideoID = req.VideoID
-	if req.IdempotencyKey != "" {
-		job.IdempotencyKey = &req.IdempotencyKey
+	// Check if job can be cancelled
+	if job.Status == domain.JobStatusCompleted || job.Status == domain.JobStatusCanceled || job.Status == domain.JobStatusQuarantined {
+		h.writeError(w, http.StatusBadRequest, "job cannot be cancelled")
+		return
 	}
 
-	if err := h.jobRepo.Create(ctx, job); err != nil {
-		h.logger.Error("failed to create job", zap.Error(err))
-		h.writeError(w, http.StatusInternalServerError, "failed to create job")
+	if err := h.cancelJob(ctx, job, req); err != nil {
+		h.logger.Error("failed to update job status", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to cancel job")
 		return
 	}
 
-	// Start Temporal workflow
-	workflowID := "video-conversion-" + job.ID.String()
-	workflowOptions := client.StartWorkflowOptions{
-		ID:        workflowID,
-		TaskQueue: h.config.Temporal.TaskQueue,
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "cancelled"})
+}
+
+// cancelJob signals the workflow to stop and marks the job CANCELED
+func (h *Handler) cancelJob(ctx context.Context, job *domain.Job, req CancelJobRequest) error {
+	// Cancel Temporal workflow
+	if job.WorkflowID != nil {
+		err := h.temporalClient.SignalWorkflow(ctx, *job.WorkflowID, "", workflows.SignalCancel, workflows.CancelSignal{
+			Reason: req.Reason,
+			Actor:  req.Actor,
+		})
+		if err != nil {
+			h.logger.Error("failed to signal workflow", zap.Error(err))
+		}
+
+		// Also try to cancel the workflow
+		err = h.temporalClient.CancelWorkflow(ctx, *job.WorkflowID, "")
+		if err != nil {
+			h.logger.Error("failed to cancel workflow", zap.Error(err))
+		}
 	}
 
-	workflowRun, err := h.temporalClient.ExecuteWorkflow(ctx, workflowOptions, workflows.VideoConversionWorkflow, workflows.VideoConversionWorkflowInput{
-		JobID: job.ID,
-	})
-	if err != nil {
-		h.logger.Error("failed to start workflow", zap.Error(err))
-		h.writeError(w, http.StatusInternalServerError, "failed to start workflow")
-		return
+	if req.Reason != "" || req.Actor != "" {
+		if err := h.jobRepo.SetCancelInfo(ctx, job.ID, optionalString(req.Reason), optionalString(req.Actor)); err != nil {
+			h.logger.Error("failed to record cancel reason", zap.Error(err))
+		}
 	}
 
-	// Update job with workflow ID
-	if err := h.jobRepo.SetWorkflowID(ctx, job.ID, workflowRun.GetID()); err != nil {
-		h.logger.Error("failed to set workflow ID", zap.Error(err))
+	// Update job status
+	if err := h.jobRepo.SetFinished(ctx, job.ID, domain.JobStatusCanceled); err != nil {
+		return err
 	}
 
-	h.metrics.IncrementJobsTotal(string(domain.JobStatusQueued))
-	h.logger.Info("job created",
+	h.metrics.IncrementJobsTotal(string(domain.JobStatusCanceled))
+	event := events.JobEvent(events.JobCanceled, job.ID, domain.JobStatusCanceled)
+	event.Reason = req.Reason
+	event.Actor = req.Actor
+	h.events.Publish(event)
+	h.logger.Info("job cancelled",
 		zap.String("jobId", job.ID.String()),
-		zap.String("workflowId", workflowRun.GetID()),
+		zap.String("reason", req.Reason),
+		zap.String("actor", req.Actor),
 	)
+	return nil
+}
 
-	h.writeJSON(w, http.StatusCreated, CreateJobResponse{
-		JobID:     job.ID,
-		Status:    job.Status,
-		CreatedAt: job.CreatedAt,
-	})
+// PauseJob pauses a running job. The workflow stops dispatching new activities
+// and the worker suspends running FFmpeg processes until the job is resumed.
+func (h *Handler) PauseJob(w http.ResponseWriter, r *http.Request) {
+	h.switchPause(w, r, domain.JobStatusRunning, domain.JobStatusPaused, workflows.SignalPause)
 }
 
-// GetJob gets job status
-func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+// ResumeJob resumes a paused job
+func (h *Handler) ResumeJob(w http.ResponseWriter, r *http.Request) {
+	h.switchPause(w, r, domain.JobStatusPaused, domain.JobStatusRunning, workflows.SignalResume)
+}
+
+// switchPause moves a job between RUNNING and PAUSED and signals its workflow
+func (h *Handler) switchPause(w http.ResponseWriter, r *http.Request, from, to domain.JobStatus, signal string) {
 	jobIDStr := chi.URLParam(r, "jobId")
 	jobID, err := uuid.Parse(jobIDStr)
 	if err != nil {
@@ -229,39 +1125,52 @@ func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := JobStatusResponse{
-		ID:              job.ID,
-		Status:          job.Status,
-		CurrentStage:    job.CurrentStage,
-		StageProgress:   job.StageProgress,
-		OverallProgress: job.OverallProgress,
-		CreatedAt:       job.CreatedAt,
-		StartedAt:       job.StartedAt,
-		UpdatedAt:       job.UpdatedAt,
-		FinishedAt:      job.FinishedAt,
+	if job.WorkflowID == nil {
+		h.writeError(w, http.StatusConflict, fmt.Sprintf("job must be %s", from))
+		return
 	}
 
-	// Get errors if job failed
-	if job.Status == domain.JobStatusFailed {
-		errors, err := h.errorRepo.GetByJobID(ctx, jobID)
-		if err == nil {
-			for _, e := range errors {
-				response.Errors = append(response.Errors, &ErrorResponse{
-					Stage:     e.Stage,
-					Class:     e.Class,
-					Code:      e.Code,
-					Message:   e.Message,
-					CreatedAt: e.CreatedAt,
-				})
-			}
+	ok, err := h.jobRepo.TransitionStatus(ctx, jobID, from, to)
+	if err != nil {
+		h.logger.Error("failed to update job status", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to update job status")
+		return
+	}
+	if !ok {
+		h.writeError(w, http.StatusConflict, fmt.Sprintf("job must be %s", from))
+		return
+	}
+
+	if err := h.temporalClient.SignalWorkflow(ctx, *job.WorkflowID, "", signal, nil); err != nil {
+		h.logger.Error("failed to signal workflow", zap.Error(err), zap.String("signal", signal))
+		// Roll back so the status matches the workflow state
+		if _, rbErr := h.jobRepo.TransitionStatus(ctx, jobID, to, from); rbErr != nil {
+			h.logger.Error("failed to restore job status", zap.Error(rbErr))
 		}
+		h.writeError(w, http.StatusInternalServerError, "failed to signal workflow")
+		return
 	}
 
-	h.writeJSON(w, http.StatusOK, response)
+	eventType := events.JobResumed
+	if to == domain.JobStatusPaused {
+		eventType = events.JobPaused
+	}
+	h.events.Publish(events.JobEvent(eventType, jobID, to))
+
+	h.logger.Info("job status changed", zap.String("jobId", jobID.String()), zap.String("status", string(to)))
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": string(to)})
 }
 
-// CancelJob cancels a job
-func (h *Handler) CancelJob(w http.ResponseWriter, r *http.Request) {
+// DeleteJobResponse represents the response after deleting a job
+type DeleteJobResponse struct {
+	JobID         uuid.UUID `json:"jobId"`
+	PurgedObjects int       `json:"purgedObjects"`
+}
+
+// DeleteJob removes a finished job with its errors and artifact rows.
+// With purge=true the uploaded S3 objects are deleted first; DB rows are kept
+// if purging fails so the request can be retried.
+func (h *Handler) DeleteJob(w http.ResponseWriter, r *http.Request) {
 	jobIDStr := chi.URLParam(r, "jobId")
 	jobID, err := uuid.Parse(jobIDStr)
 	if err != nil {
@@ -282,37 +1191,66 @@ func (h *Handler) CancelJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if job can be cancelled
-	if job.Status == domain.JobStatusCompleted || job.Status == domain.JobStatusCanceled {
-		h.writeError(w, http.StatusBadRequest, "job cannot be cancelled")
+	if !isTerminalStatus(job.Status) {
+		h.writeError(w, http.StatusConflict, "job is still active, cancel it first")
 		return
 	}
 
-	// Cancel Temporal workflow
-	if job.WorkflowID != nil {
-		err := h.temporalClient.SignalWorkflow(ctx, *job.WorkflowID, "", "cancel", nil)
+	live, err := h.versionRepo.IsCurrent(ctx, jobID)
+	if err != nil {
+		h.logger.Error("failed to check published version", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to check published version")
+		return
+	}
+	if live {
+		h.writeError(w, http.StatusConflict, "job is the live version of its video, promote another job first")
+		return
+	}
+
+	purged := 0
+	if r.URL.Query().Get("purge") == "true" {
+		artifacts, err := h.artifactRepo.GetByJobID(ctx, jobID)
 		if err != nil {
-			h.logger.Error("failed to signal workflow", zap.Error(err))
+			h.logger.Error("failed to get artifacts", zap.Error(err))
+			h.writeError(w, http.StatusInternalServerError, "failed to get artifacts")
+			return
 		}
 
-		// Also try to cancel the workflow
-		err = h.temporalClient.CancelWorkflow(ctx, *job.WorkflowID, "")
-		if err != nil {
-			h.logger.Error("failed to cancel workflow", zap.Error(err))
+		keysByBucket := make(map[string][]string)
+		for _, a := range artifacts {
+			keysByBucket[a.Bucket] = append(keysByBucket[a.Bucket], a.Key)
+		}
+
+		for bucket, keys := range keysByBucket {
+			n, err := h.s3Client.DeleteMany(ctx, bucket, keys)
+			purged += n
+			if err != nil {
+				h.logger.Error("failed to purge artifacts",
+					zap.String("jobId", jobID.String()),
+					zap.String("bucket", bucket),
+					zap.Error(err),
+				)
+				h.writeError(w, http.StatusBadGateway, "failed to purge artifacts")
+				return
+			}
 		}
 	}
 
-	// Update job status
-	if err := h.jobRepo.SetFinished(ctx, jobID, domain.JobStatusCanceled); err != nil {
-		h.logger.Error("failed to update job status", zap.Error(err))
-		h.writeError(w, http.StatusInternalServerError, "failed to cancel job")
+	if err := h.jobRepo.Delete(ctx, jobID); err != nil && !errors.Is(err, db.ErrNotFound) {
+		h.logger.Error("failed to delete job", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to delete job")
 		return
 	}
 
-	h.metrics.IncrementJobsTotal(string(domain.JobStatusCanceled))
-	h.logger.Info("job cancelled", zap.String("jobId", jobID.String()))
+	h.logger.Info("job deleted",
+		zap.String("jobId", jobID.String()),
+		zap.Int("purgedObjects", purged),
+	)
 
-	h.writeJSON(w, http.StatusOK, map[string]string{"status": "cancelled"})
+	h.writeJSON(w, http.StatusOK, DeleteJobResponse{
+		JobID:         jobID,
+		PurgedObjects: purged,
+	})
 }
 
 // GetArtifacts gets job artifacts
@@ -333,16 +1271,111 @@ func (h *Handler) GetArtifacts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	presign := r.URL.Query().Get("presign") == "true"
+	expiresAt := time.Now().UTC().Add(h.config.API.PresignExpiry)
+
 	response := make([]*ArtifactResponse, 0, len(artifacts))
 	for _, a := range artifacts {
-		response = append(response, &ArtifactResponse{
+		resp := &ArtifactResponse{
 			ID:        a.ID,
 			Type:      a.Type,
 			Bucket:    a.Bucket,
 			Key:       a.Key,
 			SizeBytes: a.SizeBytes,
 			CreatedAt: a.CreatedAt,
+		}
+
+		if presign {
+			url, err := h.s3Client.PresignGet(ctx, a.Bucket, a.Key, h.config.API.PresignExpiry)
+			if err != nil {
+				h.logger.Error("failed to presign artifact", zap.Error(err), zap.String("key", a.Key))
+				h.writeError(w, http.StatusInternalServerError, "failed to presign artifacts")
+				return
+			}
+			resp.URL = url
+			resp.ExpiresAt = &expiresAt
+		}
+
+		response = append(response, resp)
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// GetJobMetadata returns the probed source metadata together with the raw ffprobe output
+func (h *Handler) GetJobMetadata(w http.ResponseWriter, r *http.Request) {
+	jobIDStr := chi.URLParam(r, "jobId")
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid job ID")
+		return
+	}
+
+	metadata, err := h.jobRepo.GetMetadata(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		h.logger.Error("failed to get metadata", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get metadata")
+		return
+	}
+	if metadata == nil {
+		h.writeError(w, http.StatusNotFound, "metadata not available yet")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, JobMetadataResponse{
+		JobID:    jobID,
+		Metadata: metadata,
+		FFprobe:  metadata.Raw,
+	})
+}
+
+// GetJobStages returns per-stage start/end timestamps so slow stages can be spotted
+func (h *Handler) GetJobStages(w http.ResponseWriter, r *http.Request) {
+	jobIDStr := chi.URLParam(r, "jobId")
+	jobID, err := uuid.Parse(jobIDStr)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid job ID")
+		return
+	}
+
+	ctx := r.Context()
+
+	if _, err := h.jobRepo.GetByID(ctx, jobID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		h.logger.Error("failed to get job", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get job")
+		return
+	}
+
+	stages, err := h.stageRepo.GetByJobID(ctx, jobID)
+	if err != nil {
+		h.logger.Error("failed to get job stages", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get job stages")
+		return
+	}
+
+	response := JobStagesResponse{
+		JobID:  jobID,
+		Stages: make([]*StageTimingResponse, 0, len(stages)),
+	}
+	for _, s := range stages {
+		durationMs := s.Duration().Milliseconds()
+		response.Stages = append(response.Stages, &StageTimingResponse{
+			Stage:      s.Stage,
+			Attempt:    s.Attempt,
+			Status:     s.Status,
+			StartedAt:  s.StartedAt,
+			FinishedAt: s.FinishedAt,
+			DurationMs: durationMs,
 		})
+		response.TotalDurationMs += durationMs
 	}
 
 	h.writeJSON(w, http.StatusOK, response)
@@ -401,6 +1434,19 @@ func (h *Handler) ReadyCheck(w http.ResponseWriter, r *http.Request) {
 		status["database"] = "not connected"
 	}
 
+	// Check that migrations are applied
+	if schema, err := h.database.SchemaStatus(ctx); err == nil {
+		status["schemaVersion"] = strconv.FormatInt(schema.Version, 10)
+		status["expectedSchemaVersion"] = strconv.FormatInt(schema.Expected, 10)
+		if err := schema.Check(); err != nil {
+			status["status"] = "not ready"
+			status["schema"] = err.Error()
+		}
+	} else if status["database"] == "" {
+		status["status"] = "not ready"
+		status["schema"] = "unknown"
+	}
+
 	// Check S3
 	if err := h.s3Client.Health(ctx); err != nil {
 		status["status"] = "not ready"
@@ -495,15 +1541,26 @@ func (h *Handler) GetDRMKey(w http.ResponseWriter, r *http.Request) {
 // ServeDRMKeyFile serves the raw encryption key file (for HLS AES-128)
 func (h *Handler) ServeDRMKeyFile(w http.ResponseWriter, r *http.Request) {
 	jobIDStr := chi.URLParam(r, "jobId")
-	_, err := uuid.Parse(jobIDStr)
+	jobID, err := uuid.Parse(jobIDStr)
 	if err != nil {
 		h.writeError(w, http.StatusBadRequest, "invalid job ID")
 		return
 	}
 
+	job, err := h.jobRepo.GetByID(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		h.logger.Error("failed to get job", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get job")
+		return
+	}
+
 	// For HLS AES-128 encryption, serve the raw key
 	// In production, this should be protected by authentication
-	if !h.config.HLS.EnableEncryption && !h.config.DRM.Enabled {
+	if !job.Profile.HLS.EncryptionEnabled(h.config.HLS.EnableEncryption) && !h.config.DRM.Enabled {
 		h.writeError(w, http.StatusNotFound, "encryption is not enabled")
 		return
 	}
@@ -532,6 +1589,125 @@ func (h *Handler) ServeDRMKeyFile(w http.ResponseWriter, r *http.Request) {
 	w.Write(keyBytes)
 }
 
+// startWorkflow starts the conversion workflow on the task queue matching job priority
+func (h *Handler) startWorkflow(ctx context.Context, job *domain.Job) (client.WorkflowRun, error) {
+	return startConversionWorkflow(ctx, h.temporalClient, h.config, job)
+}
+
+// conversionWorkflowID returns the deterministic workflow ID of a job
+func conversionWorkflowID(jobID uuid.UUID) string {
+	return "video-conversion-" + jobID.String()
+}
+
+func startConversionWorkflow(ctx context.Context, c client.Client, cfg *config.Config, job *domain.Job) (client.WorkflowRun, error) {
+	workflowOptions := client.StartWorkflowOptions{
+		ID:        conversionWorkflowID(job.ID),
+		TaskQueue: jobTaskQueue(cfg, job),
+	}
+
+	// Started by name so the workflow version can be switched without redeploying the API code
+	return c.ExecuteWorkflow(ctx, workflowOptions, cfg.Temporal.ConversionWorkflow, conversionInput(cfg, job))
+}
+
+// requeueConversionWorkflow starts the workflow of a queued job again on its
+// current task queue. The server terminates the running workflow and starts
+// the new one in one step, so a failed start keeps the running one.
+func requeueConversionWorkflow(ctx context.Context, c client.Client, cfg *config.Config, job *domain.Job) (client.WorkflowRun, error) {
+	workflowOptions := client.StartWorkflowOptions{
+		ID:                    conversionWorkflowID(job.ID),
+		TaskQueue:             jobTaskQueue(cfg, job),
+		WorkflowIDReusePolicy: enumspb.WORKFLOW_ID_REUSE_POLICY_TERMINATE_IF_RUNNING,
+	}
+
+	return c.ExecuteWorkflow(ctx, workflowOptions, cfg.Temporal.ConversionWorkflow, conversionInput(cfg, job))
+}
+
+// jobTaskQueue returns the task queue of a job from its priority and whether it needs a GPU
+func jobTaskQueue(cfg *config.Config, job *domain.Job) string {
+	// A dry run encodes nothing, so any worker can plan it
+	return cfg.Temporal.TaskQueueFor(job.Priority, !job.DryRun && needsGPU(cfg, job.Profile))
+}
+
+// needsGPU reports whether a profile is too heavy for CPU-only workers: it
+// encodes a quality of at least TEMPORAL_GPU_MIN_HEIGHT, or H.265 with
+// TEMPORAL_GPU_FOR_HEVC. Audio-only profiles encode no video.
+func needsGPU(cfg *config.Config, profile domain.Profile) bool {
+	if profile.IsAudioOnly() {
+		return false
+	}
+	if cfg.Temporal.GPUForHEVC && cfg.Encoding.EnableModernTier {
+		return true
+	}
+	if cfg.Temporal.GPUMinHeight <= 0 {
+		return false
+	}
+	for _, q := range profile.Qualities {
+		if profile.QualityParams(q).Height >= cfg.Temporal.GPUMinHeight {
+			return true
+		}
+	}
+	return false
+}
+
+// perTitleEnabled reports whether the ladder of a profile is scaled to the complexity of the source
+func perTitleEnabled(cfg *config.Config, profile domain.Profile) bool {
+	return cfg.Encoding.PerTitle || profile.PerTitle != nil
+}
+
+// conversionInput builds the conversion workflow input of a job
+func conversionInput(cfg *config.Config, job *domain.Job) workflows.VideoConversionWorkflowInput {
+	// Audio-only jobs have no video to crop, scale or stitch
+	video := !job.Profile.IsAudioOnly()
+	return workflows.VideoConversionWorkflowInput{
+		JobID:  job.ID,
+		Stages: job.Profile.StageOptions,
+		Retry:  retryPolicies(cfg.Retry, job.Profile.Retry),
+		DryRun: job.DryRun,
+		// Dry runs plan with the nominal ladder
+		PerTitle:  video && !job.DryRun && perTitleEnabled(cfg, job.Profile),
+		Bumpers:   video && job.Profile.HasBumpers(),
+		AutoCrop:  video && !job.DryRun && job.Profile.Algorithm.AutoCrop != nil,
+		AudioOnly: !video,
+		Hardsub:   video && job.Profile.Hardsub != nil,
+		Timeouts: &workflows.ActivityTimeouts{
+			Heartbeat:          cfg.Temporal.HeartbeatTimeout,
+			TranscodeHeartbeat: cfg.Temporal.TranscodeHeartbeatTimeout,
+			UploadHeartbeat:    cfg.Temporal.UploadHeartbeatTimeout,
+			HeartbeatPerGB:     cfg.Temporal.HeartbeatTimeoutPerGB,
+		},
+	}
+}
+
+// retryPolicies resolves activity retry policies: RETRY_<GROUP>_* settings
+// with the non-zero fields of the profile applied on top
+func retryPolicies(cfg config.RetryConfig, profile *domain.RetryPolicies) *domain.RetryPolicies {
+	policy := func(r config.ActivityRetryConfig) *domain.RetryPolicy {
+		return &domain.RetryPolicy{
+			MaxAttempts:         r.MaxAttempts,
+			InitialIntervalSec:  int(r.InitialInterval / time.Second),
+			BackoffCoefficient:  r.BackoffCoefficient,
+			MaxIntervalSec:      int(r.MaxInterval / time.Second),
+			TimeoutSec:          int(r.Timeout / time.Second),
+			TimeoutPerMinuteSec: int(r.TimeoutPerMinute / time.Second),
+		}
+	}
+	resolved := domain.RetryPolicies{
+		Default:   policy(cfg.Default),
+		Metadata:  policy(cfg.Metadata),
+		Transcode: policy(cfg.Transcode),
+		Upload:    policy(cfg.Upload),
+	}.Override(profile)
+	return &resolved
+}
+
+// optionalString returns nil for an empty string
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 func (h *Handler) writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/db"
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// maxPreviewManifestSize bounds playlists and manifests served by the preview
+const maxPreviewManifestSize = 16 << 20
+
+// Player scripts of the preview page
+const (
+	hlsJSURL  = "https://cdn.jsdelivr.net/npm/hls.js@1.5.17/dist/hls.min.js"
+	dashJSURL = "https://cdn.jsdelivr.net/npm/dashjs@4.7.4/dist/dash.all.min.js"
+)
+
+// previewPage is the data of previewTemplate
+type previewPage struct {
+	JobID     uuid.UUID
+	Status    domain.JobStatus
+	Format    string // "hls", "dash" or "mp4"
+	SourceURL string
+	HasHLS    bool
+	HasDASH   bool
+	// Renditions are presigned MP4 URLs by name, for jobs without HLS output
+	Renditions []previewRendition
+	HLSJSURL   string
+	DashJSURL  string
+}
+
+type previewRendition struct {
+	Name string
+	URL  string
+}
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Preview {{.JobID}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; background: #111; color: #ddd; }
+video { width: 100%; max-width: 1280px; background: #000; }
+a { color: #6af; margin-right: 1em; }
+#info { margin-top: 1em; font-family: monospace; }
+</style>
+</head>
+<body>
+<h3>Job {{.JobID}} ({{.Status}})</h3>
+<p>
+{{if .HasHLS}}<a href="?format=hls">HLS</a>{{end}}
+{{if .HasDASH}}<a href="?format=dash">DASH</a>{{end}}
+{{range .Renditions}}<a href="{{.URL}}">{{.Name}}</a>{{end}}
+</p>
+<video id="video" controls playsinline></video>
+<div id="info"></div>
+{{if eq .Format "hls"}}<script src="{{.HLSJSURL}}"></script>{{end}}
+{{if eq .Format "dash"}}<script src="{{.DashJSURL}}"></script>{{end}}
+<script>
+var video = document.getElementById("video");
+var info = document.getElementById("info");
+var format = {{.Format}};
+var src = {{.SourceURL}};
+function show() {
+  var q = video.videoWidth ? video.videoWidth + "x" + video.videoHeight : "";
+  info.textContent = format.toUpperCase() + " " + q;
+}
+video.addEventListener("resize", show);
+if (format === "hls" && window.Hls && Hls.isSupported()) {
+  var hls = new Hls();
+  hls.on(Hls.Events.ERROR, function (e, data) {
+    if (data.fatal) { info.textContent = "error: " + data.type + " " + data.details; }
+  });
+  hls.loadSource(src);
+  hls.attachMedia(video);
+} else if (format === "dash" && window.dashjs) {
+  var player = dashjs.MediaPlayer().create();
+  player.on(dashjs.MediaPlayer.events.ERROR, function (e) {
+    info.textContent = "error: " + JSON.stringify(e.error);
+  });
+  player.initialize(video, src, false);
+} else {
+  video.src = src;
+}
+show();
+</script>
+</body>
+</html>
+`))
+
+// GetJobPreview serves an HTML player of the job output for QA. HLS and DASH
+// manifests are loaded through GetJobPreviewFile; MP4 renditions of jobs
+// without HLS are played from presigned URLs.
+func (h *Handler) GetJobPreview(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobId"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid job ID")
+		return
+	}
+
+	ctx := r.Context()
+
+	job, err := h.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		h.logger.Error("failed to get job", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get job")
+		return
+	}
+
+	artifacts, err := h.artifactRepo.GetByJobID(ctx, jobID)
+	if err != nil {
+		h.logger.Error("failed to get artifacts", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get artifacts")
+		return
+	}
+
+	page := previewPage{
+		JobID:     jobID,
+		Status:    job.Status,
+		HLSJSURL:  hlsJSURL,
+		DashJSURL: dashJSURL,
+	}
+	for _, a := range artifacts {
+		switch a.Type {
+		case domain.ArtifactTypeHLSMaster:
+			page.HasHLS = true
+		case domain.ArtifactTypeDASHManifest:
+			page.HasDASH = true
+		case domain.ArtifactTypeRendition:
+			url, err := h.s3Client.PresignGet(ctx, a.Bucket, a.Key, h.config.API.PresignExpiry)
+			if err != nil {
+				h.logger.Error("failed to presign artifact", zap.Error(err), zap.String("key", a.Key))
+				h.writeError(w, http.StatusInternalServerError, "failed to presign artifacts")
+				return
+			}
+			name := strings.TrimSuffix(path.Base(a.Key), path.Ext(a.Key))
+			page.Renditions = append(page.Renditions, previewRendition{Name: name, URL: url})
+		}
+	}
+
+	// Manifests are relative to this page: /v1/jobs/{jobId}/preview/<file>
+	format := r.URL.Query().Get("format")
+	switch {
+	case format == "dash" && page.HasDASH:
+		page.Format, page.SourceURL = "dash", "preview/manifest.mpd"
+	case page.HasHLS && format != "dash":
+		page.Format, page.SourceURL = "hls", "preview/master.m3u8"
+	case len(page.Renditions) > 0:
+		page.Format, page.SourceURL = "mp4", page.Renditions[0].URL
+	default:
+		h.writeError(w, http.StatusNotFound, "job has no playable output")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := previewTemplate.Execute(w, page); err != nil {
+		h.logger.Error("failed to render preview", zap.Error(err))
+	}
+}
+
+// GetJobPreviewFile serves a file of the job's HLS output by its path relative
+// to the master playlist. Playlists and manifests are proxied, so the relative
+// URIs in them resolve back here; segments and other files redirect to
+// presigned URLs, which needs CORS on the output bucket for the API origin.
+func (h *Handler) GetJobPreviewFile(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(chi.URLParam(r, "jobId"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid job ID")
+		return
+	}
+
+	rel := chi.URLParam(r, "*")
+	clean := path.Clean("/" + rel)
+	if rel == "" || clean != "/"+rel {
+		h.writeError(w, http.StatusBadRequest, "invalid path")
+		return
+	}
+
+	ctx := r.Context()
+
+	masters, err := h.artifactRepo.GetByJobIDAndType(ctx, jobID, domain.ArtifactTypeHLSMaster)
+	if err != nil {
+		h.logger.Error("failed to get artifacts", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get artifacts")
+		return
+	}
+	if len(masters) == 0 {
+		h.writeError(w, http.StatusNotFound, "job has no HLS output")
+		return
+	}
+
+	// Only files under the HLS directory of the job can be reached
+	master := masters[0]
+	key := path.Dir(master.Key) + clean
+
+	switch path.Ext(key) {
+	case ".m3u8", ".mpd":
+		content, err := h.s3Client.ReadObject(ctx, master.Bucket, key, maxPreviewManifestSize)
+		if err != nil {
+			h.logger.Warn("failed to read preview manifest", zap.Error(err), zap.String("key", key))
+			h.writeError(w, http.StatusNotFound, "file not found")
+			return
+		}
+		contentType := "application/vnd.apple.mpegurl"
+		if path.Ext(key) == ".mpd" {
+			contentType = "application/dash+xml"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(content)
+	default:
+		url, err := h.s3Client.PresignGet(ctx, master.Bucket, key, h.config.API.PresignExpiry)
+		if err != nil {
+			h.logger.Error("failed to presign artifact", zap.Error(err), zap.String("key", key))
+			h.writeError(w, http.StatusInternalServerError, "failed to presign file")
+			return
+		}
+		http.Redirect(w, r, url, http.StatusFound)
+	}
+}
@@ -0,0 +1,276 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/db"
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// ProfileRequest represents the request to create or replace a profile template
+type ProfileRequest struct {
+	Name        string         `json:"name"`
+	Description *string        `json:"description,omitempty"`
+	Profile     domain.Profile `json:"profile"`
+	// Version enables optimistic locking on update; 0 skips the check
+	Version int `json:"version,omitempty"`
+}
+
+// CreateProfile creates a new profile template
+func (h *Handler) CreateProfile(w http.ResponseWriter, r *http.Request) {
+	var req ProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.validateProfileRequest(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tmpl := domain.NewProfileTemplate(req.Name, req.Profile)
+	tmpl.Description = req.Description
+
+	if err := h.profileRepo.Create(r.Context(), tmpl); err != nil {
+		if errors.Is(err, db.ErrAlreadyExists) {
+			h.writeError(w, http.StatusConflict, "profile with this name already exists")
+			return
+		}
+		h.logger.Error("failed to create profile", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to create profile")
+		return
+	}
+
+	h.logger.Info("profile created", zap.String("profileId", tmpl.ID.String()), zap.String("name", tmpl.Name))
+	h.writeJSON(w, http.StatusCreated, tmpl)
+}
+
+// ListProfiles lists all profile templates
+func (h *Handler) ListProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.profileRepo.List(r.Context())
+	if err != nil {
+		h.logger.Error("failed to list profiles", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to list profiles")
+		return
+	}
+
+	if profiles == nil {
+		profiles = []*domain.ProfileTemplate{}
+	}
+	h.writeJSON(w, http.StatusOK, profiles)
+}
+
+// GetProfile gets a profile template by ID or name
+func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := h.resolveProfile(r.Context(), chi.URLParam(r, "profileId"))
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "profile not found")
+			return
+		}
+		h.logger.Error("failed to get profile", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get profile")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, tmpl)
+}
+
+// UpdateProfile replaces a profile template and bumps its version.
+// Jobs keep a snapshot of the profile, so updates never affect existing jobs.
+func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	profileID, err := uuid.Parse(chi.URLParam(r, "profileId"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid profile ID")
+		return
+	}
+
+	var req ProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.validateProfileRequest(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tmpl := &domain.ProfileTemplate{
+		ID:          profileID,
+		Name:        req.Name,
+		Description: req.Description,
+		Profile:     req.Profile,
+	}
+
+	ctx := r.Context()
+	if err := h.profileRepo.Update(ctx, tmpl, req.Version); err != nil {
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			h.writeError(w, http.StatusNotFound, "profile not found")
+		case errors.Is(err, db.ErrConcurrentModification):
+			h.writeError(w, http.StatusConflict, "profile version mismatch")
+		case errors.Is(err, db.ErrAlreadyExists):
+			h.writeError(w, http.StatusConflict, "profile with this name already exists")
+		default:
+			h.logger.Error("failed to update profile", zap.Error(err))
+			h.writeError(w, http.StatusInternalServerError, "failed to update profile")
+		}
+		return
+	}
+
+	updated, err := h.profileRepo.GetByID(ctx, profileID)
+	if err != nil {
+		h.logger.Error("failed to get profile", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get profile")
+		return
+	}
+
+	h.logger.Info("profile updated", zap.String("profileId", profileID.String()), zap.Int("version", updated.Version))
+	h.writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteProfile deletes a profile template
+func (h *Handler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
+	profileID, err := uuid.Parse(chi.URLParam(r, "profileId"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid profile ID")
+		return
+	}
+
+	if err := h.profileRepo.Delete(r.Context(), profileID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "profile not found")
+			return
+		}
+		h.logger.Error("failed to delete profile", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to delete profile")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resolveProfile looks up a profile template by UUID, falling back to name
+func (h *Handler) resolveProfile(ctx context.Context, ref string) (*domain.ProfileTemplate, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return h.profileRepo.GetByID(ctx, id)
+	}
+	return h.profileRepo.GetByName(ctx, ref)
+}
+
+// validateProfileRequest checks required fields, quality names and the sources
+// of intro and outro clips
+func (h *Handler) validateProfileRequest(req *ProfileRequest) error {
+	if req.Name == "" {
+		return errors.New("profile name is required")
+	}
+	if _, err := uuid.Parse(req.Name); err == nil {
+		return errors.New("profile name must not be a UUID")
+	}
+	if len(req.Profile.ExternalSubtitles) > 0 {
+		return errors.New("externalSubtitles cannot be stored in a profile, send subtitles with the job")
+	}
+	if err := req.Profile.ValidateLadder(); err != nil {
+		return err
+	}
+	if len(req.Profile.Qualities) == 0 && !req.Profile.IsAudioOnly() {
+		return errors.New("profile must contain at least one quality")
+	}
+	if c := req.Profile.Intro; c != nil {
+		if err := h.validateBumper(c, c.Bucket); err != nil {
+			return fmt.Errorf("intro: %w", err)
+		}
+	}
+	if c := req.Profile.Outro; c != nil {
+		if err := h.validateBumper(c, c.Bucket); err != nil {
+			return fmt.Errorf("outro: %w", err)
+		}
+	}
+	if c := req.Profile.Trim; c != nil {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if m := req.Profile.Mezzanine; m != nil && !m.Codec.IsValid() {
+		return fmt.Errorf("unknown mezzanine codec: %s", m.Codec)
+	}
+	if b := req.Profile.Budget; b != nil && (b.MaxRenditions < 0 || b.MaxEncodeMinutes < 0) {
+		return errors.New("profile budget limits must not be negative")
+	}
+	if err := domain.ValidateAudioTracks(req.Profile.AudioTracks); err != nil {
+		return err
+	}
+	if err := req.Profile.HLS.Validate(); err != nil {
+		return err
+	}
+	if c := req.Profile.Algorithm.FPSCap; c != nil {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if c := req.Profile.Algorithm.FrameRate; c != nil {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if c := req.Profile.Algorithm.Deinterlace; c != nil {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if c := req.Profile.Algorithm.AutoCrop; c != nil {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if c := req.Profile.PerTitle; c != nil {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if c := req.Profile.BitDepth; c != nil {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if c := req.Profile.Encoder; c != nil {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if c := req.Profile.Audio; c != nil {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if c := req.Profile.AudioOnly; c != nil {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if c := req.Profile.Hardsub; c != nil {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := domain.ValidateCuePoints(req.Profile.CuePoints); err != nil {
+		return err
+	}
+	if r := req.Profile.Retry; r != nil {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/db"
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// Quarantined job listing returns at most this many jobs
+const (
+	defaultQuarantineLimit = 100
+	maxQuarantineLimit     = 1000
+)
+
+// QuarantinedJobResponse describes a quarantined job and where its diagnostic bundle is
+type QuarantinedJobResponse struct {
+	ID                uuid.UUID      `json:"id"`
+	VideoID           *uuid.UUID     `json:"videoId,omitempty"`
+	SourceBucket      string         `json:"sourceBucket,omitempty"`
+	SourceKey         string         `json:"sourceKey,omitempty"`
+	SourceURL         *string        `json:"sourceUrl,omitempty"`
+	CreatedAt         time.Time      `json:"createdAt"`
+	QuarantinedAt     *time.Time     `json:"quarantinedAt,omitempty"`
+	LastError         *ErrorResponse `json:"lastError,omitempty"`
+	DiagnosticsBucket string         `json:"diagnosticsBucket"`
+	DiagnosticsPrefix string         `json:"diagnosticsPrefix"`
+}
+
+// ListQuarantinedJobs lists quarantined jobs, oldest first
+func (h *Handler) ListQuarantinedJobs(w http.ResponseWriter, r *http.Request) {
+	limit := defaultQuarantineLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > maxQuarantineLimit {
+			h.writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxQuarantineLimit))
+			return
+		}
+		limit = n
+	}
+
+	ctx := r.Context()
+
+	jobs, err := h.jobRepo.ListByFilter(ctx, db.JobFilter{
+		Statuses: []domain.JobStatus{domain.JobStatusQuarantined},
+	}, limit)
+	if err != nil {
+		h.logger.Error("failed to list quarantined jobs", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to list quarantined jobs")
+		return
+	}
+
+	response := make([]*QuarantinedJobResponse, 0, len(jobs))
+	for _, job := range jobs {
+		resp := &QuarantinedJobResponse{
+			ID:                job.ID,
+			VideoID:           job.VideoID,
+			SourceBucket:      job.SourceBucket,
+			SourceKey:         job.SourceKey,
+			SourceURL:         job.SourceURL,
+			CreatedAt:         job.CreatedAt,
+			QuarantinedAt:     job.FinishedAt,
+			DiagnosticsBucket: h.s3Client.GetDefaultBucket(),
+			DiagnosticsPrefix: job.DiagnosticsPrefix(),
+		}
+
+		lastErr, err := h.errorRepo.GetLatestByJobID(ctx, job.ID)
+		if err != nil {
+			h.logger.Warn("failed to get last error", zap.String("jobId", job.ID.String()), zap.Error(err))
+		} else if lastErr != nil {
+			resp.LastError = &ErrorResponse{
+				Stage:     lastErr.Stage,
+				Class:     lastErr.Class,
+				Code:      lastErr.Code,
+				Message:   lastErr.Message,
+				CreatedAt: lastErr.CreatedAt,
+			}
+		}
+
+		response = append(response, resp)
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
+}
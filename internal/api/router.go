@@ -18,35 +18,108 @@ func NewRouter(h *Handler, logger *zap.Logger) *chi.Mux {
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
 	r.Use(requestLogger(logger))
 
-	// Health endpoints
-	r.Get("/healthz", h.HealthCheck)
-	r.Get("/readyz", h.ReadyCheck)
+	// Streaming endpoints are long-lived and must not be cut by the request timeout
+	r.With(h.requireClientCert).Get("/v1/jobs/{jobId}/events", h.StreamJobEvents)
 
-	// Metrics endpoint
-	r.Handle("/metrics", promhttp.Handler())
+	// Export and import go through whole tables and are not cut by the request timeout either
+	r.Route("/v1/admin/jobs", func(r chi.Router) {
+		r.Use(h.requireClientCert)
+		r.Get("/export", h.ExportJobs)
+		r.Post("/import", h.ImportJobs)
+	})
 
-	// API routes
-	r.Route("/v1", func(r chi.Router) {
-		r.Route("/jobs", func(r chi.Router) {
-			r.Post("/", h.CreateJob)
-			r.Get("/{jobId}", h.GetJob)
-			r.Post("/{jobId}/cancel", h.CancelJob)
-			r.Get("/{jobId}/artifacts", h.GetArtifacts)
-		})
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.Timeout(60 * time.Second))
+
+		// Health endpoints
+		r.Get("/healthz", h.HealthCheck)
+		r.Get("/readyz", h.ReadyCheck)
+
+		// Metrics endpoint
+		r.Handle("/metrics", promhttp.Handler())
+
+		// API routes
+		r.Route("/v1", func(r chi.Router) {
+			// With mTLS the API routes need a client certificate, unlike
+			// health checks and metrics
+			r.Use(h.requireClientCert)
+			r.Route("/jobs", func(r chi.Router) {
+				r.Post("/", h.CreateJob)
+				r.Post("/bulk-cancel", h.BulkCancelJobs)
+				r.Post("/bulk-priority", h.BulkUpdatePriority)
+				r.Get("/quarantined", h.ListQuarantinedJobs)
+				r.Get("/{jobId}", h.GetJob)
+				r.Delete("/{jobId}", h.DeleteJob)
+				r.Post("/{jobId}/cancel", h.CancelJob)
+				r.Post("/{jobId}/pause", h.PauseJob)
+				r.Post("/{jobId}/resume", h.ResumeJob)
+				r.Patch("/{jobId}/priority", h.UpdateJobPriority)
+				r.Get("/{jobId}/artifacts", h.GetArtifacts)
+				r.Get("/{jobId}/stages", h.GetJobStages)
+				r.Get("/{jobId}/metadata", h.GetJobMetadata)
+				r.Get("/{jobId}/preview", h.GetJobPreview)
+				r.Get("/{jobId}/preview/*", h.GetJobPreviewFile)
+			})
+
+			r.Route("/series", func(r chi.Router) {
+				r.Post("/", h.CreateSeries)
+				r.Get("/{seriesId}", h.GetSeries)
+			})
+
+			r.Route("/videos/{videoId}", func(r chi.Router) {
+				r.Get("/versions", h.ListVideoVersions)
+				r.Post("/versions", h.PromoteVideoVersion)
+				r.Post("/rollback", h.RollbackVideoVersion)
+			})
+
+			r.Route("/profiles", func(r chi.Router) {
+				r.Post("/", h.CreateProfile)
+				r.Get("/", h.ListProfiles)
+				r.Get("/{profileId}", h.GetProfile)
+				r.Put("/{profileId}", h.UpdateProfile)
+				r.Delete("/{profileId}", h.DeleteProfile)
+			})
 
-		// DRM key endpoints (for testing/development)
-		r.Route("/keys", func(r chi.Router) {
-			r.Get("/{jobId}", h.GetDRMKey)
-			r.Get("/{jobId}/encryption.key", h.ServeDRMKeyFile)
+			r.Route("/uploads", func(r chi.Router) {
+				r.Post("/", h.CreateUpload)
+				r.Post("/{uploadId}/complete", h.CompleteUpload)
+				r.Delete("/{uploadId}", h.AbortUpload)
+			})
+
+			r.Post("/probe", h.ProbeSource)
+
+			r.Get("/usage", h.GetStorageUsage)
+
+			// DRM key endpoints (for testing/development)
+			r.Route("/keys", func(r chi.Router) {
+				r.Get("/{jobId}", h.GetDRMKey)
+				r.Get("/{jobId}/encryption.key", h.ServeDRMKeyFile)
+			})
 		})
 	})
 
 	return r
 }
 
+// requireClientCert rejects requests without a client certificate verified
+// against API_TLS_CLIENT_CA_FILE when mTLS is enabled. The server only
+// verifies the certificates clients present, so routes that need one are
+// checked here.
+func (h *Handler) requireClientCert(next http.Handler) http.Handler {
+	if h.config.API.TLSClientCAFile == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			h.writeError(w, http.StatusUnauthorized, "client certificate required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // requestLogger logs HTTP requests
 func requestLogger(logger *zap.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
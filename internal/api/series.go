@@ -0,0 +1,200 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/events"
+	"github.com/tvoe/converter/internal/temporal/workflows"
+)
+
+// Series limits. The whole item list is part of the series workflow input,
+// so it is bounded to keep the payload small.
+const (
+	maxSeriesItems    = 1000
+	maxSeriesParallel = 20
+)
+
+// CreateSeriesRequest represents a batch of jobs converted by one series workflow
+type CreateSeriesRequest struct {
+	Items []CreateJobRequest `json:"items"`
+	// MaxParallel is how many items convert at the same time, 1 by default
+	MaxParallel int `json:"maxParallel,omitempty"`
+}
+
+// CreateSeriesResponse represents a started series
+type CreateSeriesResponse struct {
+	SeriesID   uuid.UUID   `json:"seriesId"`
+	WorkflowID string      `json:"workflowId"`
+	JobIDs     []uuid.UUID `json:"jobIds"`
+}
+
+// SeriesResponse represents series progress
+type SeriesResponse struct {
+	SeriesID   uuid.UUID `json:"seriesId"`
+	WorkflowID string    `json:"workflowId"`
+	*workflows.SeriesProgress
+}
+
+// seriesWorkflowID returns the workflow ID of a series
+func seriesWorkflowID(seriesID uuid.UUID) string {
+	return "video-series-" + seriesID.String()
+}
+
+// CreateSeries creates a job per item and starts a series workflow that
+// converts them in order, MaxParallel at a time
+func (h *Handler) CreateSeries(w http.ResponseWriter, r *http.Request) {
+	var req CreateSeriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if len(req.Items) == 0 || len(req.Items) > maxSeriesItems {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("items must contain between 1 and %d jobs", maxSeriesItems))
+		return
+	}
+	if req.MaxParallel == 0 {
+		req.MaxParallel = 1
+	}
+	if req.MaxParallel < 1 || req.MaxParallel > maxSeriesParallel {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("maxParallel must be between 1 and %d", maxSeriesParallel))
+		return
+	}
+
+	ctx := r.Context()
+
+	// Every item is validated before any job is stored
+	jobs := make([]*domain.Job, 0, len(req.Items))
+	for i := range req.Items {
+		item := &req.Items[i]
+		if item.IdempotencyKey != "" {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("items[%d]: idempotencyKey is not supported in a series", i))
+			return
+		}
+		if err := h.validateSource(ctx, item.Source); err != nil {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("items[%d]: %v", i, err))
+			return
+		}
+		job, status, err := h.newJob(ctx, item)
+		if err != nil {
+			h.writeError(w, status, fmt.Sprintf("items[%d]: %v", i, err))
+			return
+		}
+		jobs = append(jobs, job)
+	}
+
+	// The series ID keeps the fair dispatcher away from the jobs; each gets its
+	// workflow ID when the series workflow starts it, so waiting jobs do not
+	// count as in flight
+	seriesID := uuid.New()
+	for _, job := range jobs {
+		job.SeriesID = &seriesID
+	}
+	input := workflows.SeriesWorkflowInput{
+		SeriesID:           seriesID,
+		ConversionWorkflow: h.config.Temporal.ConversionWorkflow,
+		MaxParallel:        req.MaxParallel,
+		Progress:           workflows.SeriesProgress{Total: len(jobs)},
+	}
+	for i, job := range jobs {
+		if err := h.jobRepo.Create(ctx, job); err != nil {
+			h.logger.Error("failed to create series job", zap.Error(err))
+			h.failSeriesJobs(ctx, jobs[:i])
+			h.writeError(w, http.StatusInternalServerError, "failed to create job")
+			return
+		}
+		input.Items = append(input.Items, workflows.SeriesItem{
+			WorkflowID: conversionWorkflowID(job.ID),
+			TaskQueue:  jobTaskQueue(h.config, job),
+			Input:      conversionInput(h.config, job),
+		})
+	}
+
+	workflowRun, err := h.temporalClient.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:        seriesWorkflowID(seriesID),
+		TaskQueue: h.config.Temporal.TaskQueue,
+	}, workflows.SeriesWorkflowName, input)
+	if err != nil {
+		h.logger.Error("failed to start series workflow", zap.Error(err))
+		h.failSeriesJobs(ctx, jobs)
+		h.writeError(w, http.StatusInternalServerError, "failed to start workflow")
+		return
+	}
+
+	response := CreateSeriesResponse{
+		SeriesID:   seriesID,
+		WorkflowID: workflowRun.GetID(),
+		JobIDs:     make([]uuid.UUID, 0, len(jobs)),
+	}
+	for _, job := range jobs {
+		h.metrics.IncrementJobsTotal(string(domain.JobStatusQueued))
+		h.events.Publish(events.JobEvent(events.JobCreated, job.ID, job.Status))
+		response.JobIDs = append(response.JobIDs, job.ID)
+	}
+
+	h.logger.Info("series created",
+		zap.String("seriesId", seriesID.String()),
+		zap.Int("jobs", len(jobs)),
+		zap.Int("maxParallel", req.MaxParallel),
+	)
+
+	h.writeJSON(w, http.StatusCreated, response)
+}
+
+// failSeriesJobs marks stored jobs of a series that could not be started as failed
+func (h *Handler) failSeriesJobs(ctx context.Context, jobs []*domain.Job) {
+	for _, job := range jobs {
+		if err := h.jobRepo.SetFinished(ctx, job.ID, domain.JobStatusFailed); err != nil {
+			h.logger.Error("failed to fail series job", zap.String("jobId", job.ID.String()), zap.Error(err))
+		}
+	}
+}
+
+// GetSeries returns progress of a series across all its workflow runs
+func (h *Handler) GetSeries(w http.ResponseWriter, r *http.Request) {
+	seriesID, err := uuid.Parse(chi.URLParam(r, "seriesId"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid series ID")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), workflowQueryTimeout)
+	defer cancel()
+
+	workflowID := seriesWorkflowID(seriesID)
+	value, err := h.temporalClient.QueryWorkflow(ctx, workflowID, "", workflows.QuerySeriesProgress)
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			h.writeError(w, http.StatusNotFound, "series not found")
+			return
+		}
+		h.logger.Error("failed to query series", zap.String("seriesId", seriesID.String()), zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to query series")
+		return
+	}
+
+	var progress workflows.SeriesProgress
+	if err := value.Get(&progress); err != nil {
+		h.logger.Error("failed to decode series progress", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to query series")
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, SeriesResponse{
+		SeriesID:       seriesID,
+		WorkflowID:     workflowID,
+		SeriesProgress: &progress,
+	})
+}
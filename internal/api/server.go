@@ -2,8 +2,12 @@ package api
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"go.uber.org/zap"
@@ -17,9 +21,11 @@ type Server struct {
 	logger *zap.Logger
 }
 
-// NewServer creates a new HTTP server
-func NewServer(cfg config.APIConfig, handler http.Handler, logger *zap.Logger) *Server {
-	return &Server{
+// NewServer creates a new HTTP server. With API_TLS_CERT_FILE and API_TLS_KEY_FILE
+// it serves HTTPS, and with API_TLS_CLIENT_CA_FILE it also verifies client
+// certificates, which the router requires on the API routes.
+func NewServer(cfg config.APIConfig, handler http.Handler, logger *zap.Logger) (*Server, error) {
+	s := &Server{
 		server: &http.Server{
 			Addr:         fmt.Sprintf(":%d", cfg.Port),
 			Handler:      handler,
@@ -28,12 +34,65 @@ func NewServer(cfg config.APIConfig, handler http.Handler, logger *zap.Logger) *
 		},
 		logger: logger,
 	}
+
+	if cfg.TLSCertFile != "" {
+		tlsConfig, err := serverTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		s.server.TLSConfig = tlsConfig
+	}
+
+	return s, nil
+}
+
+// serverTLSConfig builds the TLS configuration of the server. The certificate
+// is loaded here so a bad file fails at startup rather than on the first request.
+func serverTLSConfig(cfg config.APIConfig) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if cfg.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("client CA file contains no PEM certificates")
+		}
+		tlsConfig.ClientCAs = pool
+		// Probes and metrics connect without a certificate; the API routes
+		// require a verified one, see requireClientCert
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig, nil
 }
 
 // Start starts the HTTP server
 func (s *Server) Start() error {
-	s.logger.Info("starting HTTP server", zap.String("addr", s.server.Addr))
-	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	s.logger.Info("starting HTTP server",
+		zap.String("addr", s.server.Addr),
+		zap.Bool("tls", s.server.TLSConfig != nil),
+		zap.Bool("mtls", s.server.TLSConfig != nil && s.server.TLSConfig.ClientCAs != nil),
+	)
+
+	var err error
+	if s.server.TLSConfig != nil {
+		// The certificate is already in TLSConfig
+		err = s.server.ListenAndServeTLS("", "")
+	} else {
+		err = s.server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("server error: %w", err)
 	}
 	return nil
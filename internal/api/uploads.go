@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/storage/s3"
+)
+
+// uploadKeyPrefix is the staging bucket prefix for direct uploads
+const uploadKeyPrefix = "uploads/"
+
+// CreateUploadRequest represents the request to start a direct upload
+type CreateUploadRequest struct {
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+}
+
+// UploadPartResponse holds the presigned URL for one part
+type UploadPartResponse struct {
+	PartNumber int    `json:"partNumber"`
+	URL        string `json:"url"`
+}
+
+// CreateUploadResponse represents a started direct upload
+type CreateUploadResponse struct {
+	UploadID  string                `json:"uploadId"`
+	Bucket    string                `json:"bucket"`
+	Key       string                `json:"key"`
+	PartSize  int64                 `json:"partSize"`
+	Parts     []*UploadPartResponse `json:"parts"`
+	ExpiresAt time.Time             `json:"expiresAt"`
+}
+
+// CompletedPartRequest identifies an uploaded part by its ETag
+type CompletedPartRequest struct {
+	PartNumber int    `json:"partNumber"`
+	ETag       string `json:"etag"`
+}
+
+// CompleteUploadRequest represents the request to finish a direct upload and create a job from it
+type CompleteUploadRequest struct {
+	Key string `json:"key"`
+	// Parts may be omitted when the client cannot read ETag headers; they are then listed from S3
+	Parts []CompletedPartRequest `json:"parts,omitempty"`
+	// Job holds job parameters; its source is replaced by the uploaded object
+	Job CreateJobRequest `json:"job"`
+}
+
+// CreateUpload starts a multipart upload into the staging bucket and returns presigned part URLs
+func (h *Handler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	var req CreateUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	filename := path.Base(strings.ReplaceAll(req.Filename, "\\", "/"))
+	if filename == "" || filename == "." || filename == "/" || path.Ext(filename) == "" {
+		h.writeError(w, http.StatusBadRequest, "filename with extension is required")
+		return
+	}
+	if !sourceExtensionAllowed(filename, h.config.S3.SourceExtensions) {
+		h.writeError(w, http.StatusBadRequest, "file extension "+path.Ext(filename)+" is not supported")
+		return
+	}
+	if req.Size <= 0 {
+		h.writeError(w, http.StatusBadRequest, "size must be positive")
+		return
+	}
+
+	bucket := h.config.S3.BucketStaging
+	key := uploadKeyPrefix + uuid.New().String() + "/" + filename
+	expiresAt := time.Now().UTC().Add(h.config.API.UploadExpiry)
+
+	upload, err := h.s3Client.CreatePresignedUpload(r.Context(), bucket, key, req.Size, h.config.API.UploadExpiry)
+	if err != nil {
+		h.logger.Error("failed to create upload", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to create upload")
+		return
+	}
+
+	response := CreateUploadResponse{
+		UploadID:  upload.UploadID,
+		Bucket:    upload.Bucket,
+		Key:       upload.Key,
+		PartSize:  upload.PartSize,
+		Parts:     make([]*UploadPartResponse, 0, len(upload.Parts)),
+		ExpiresAt: expiresAt,
+	}
+	for _, p := range upload.Parts {
+		response.Parts = append(response.Parts, &UploadPartResponse{PartNumber: p.PartNumber, URL: p.URL})
+	}
+
+	h.logger.Info("upload created",
+		zap.String("key", key),
+		zap.Int64("size", req.Size),
+		zap.Int("parts", len(upload.Parts)),
+	)
+	h.writeJSON(w, http.StatusCreated, response)
+}
+
+// CompleteUpload assembles the uploaded parts and creates a job from the uploaded object.
+// Retries are safe: the upload ID is used as the default idempotency key.
+func (h *Handler) CompleteUpload(w http.ResponseWriter, r *http.Request) {
+	uploadID := chi.URLParam(r, "uploadId")
+
+	var req CompleteUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !strings.HasPrefix(req.Key, uploadKeyPrefix) {
+		h.writeError(w, http.StatusBadRequest, "key must be the one returned by POST /v1/uploads")
+		return
+	}
+
+	ctx := r.Context()
+	bucket := h.config.S3.BucketStaging
+
+	parts := make([]s3.UploadedPart, 0, len(req.Parts))
+	for _, p := range req.Parts {
+		parts = append(parts, s3.UploadedPart{PartNumber: p.PartNumber, ETag: p.ETag})
+	}
+
+	result, err := h.s3Client.CompletePresignedUpload(ctx, bucket, req.Key, uploadID, parts)
+	if err != nil {
+		if !s3.IsNotFound(err) {
+			h.logger.Error("failed to complete upload", zap.Error(err), zap.String("key", req.Key))
+			h.writeError(w, http.StatusInternalServerError, "failed to complete upload")
+			return
+		}
+
+		// A retry after a successful completion finds the object instead of the upload
+		exists, err := h.s3Client.Exists(ctx, bucket, req.Key)
+		if err != nil {
+			h.logger.Error("failed to check uploaded object", zap.Error(err), zap.String("key", req.Key))
+			h.writeError(w, http.StatusInternalServerError, "failed to complete upload")
+			return
+		}
+		if !exists {
+			h.writeError(w, http.StatusNotFound, "upload not found")
+			return
+		}
+	} else {
+		h.logger.Info("upload completed", zap.String("key", result.Key), zap.Int64("size", result.Size))
+	}
+
+	job := req.Job
+	job.Source = SourceConfig{Type: "s3", Bucket: bucket, Key: req.Key}
+	if job.IdempotencyKey == "" {
+		job.IdempotencyKey = "upload:" + uploadID
+	}
+
+	h.submitJob(w, r, &job)
+}
+
+// AbortUpload aborts an unfinished direct upload
+func (h *Handler) AbortUpload(w http.ResponseWriter, r *http.Request) {
+	uploadID := chi.URLParam(r, "uploadId")
+	key := r.URL.Query().Get("key")
+	if !strings.HasPrefix(key, uploadKeyPrefix) {
+		h.writeError(w, http.StatusBadRequest, "key query parameter is required")
+		return
+	}
+
+	if err := h.s3Client.AbortPresignedUpload(r.Context(), h.config.S3.BucketStaging, key, uploadID); err != nil {
+		if s3.IsNotFound(err) {
+			h.writeError(w, http.StatusNotFound, "upload not found")
+			return
+		}
+		h.logger.Error("failed to abort upload", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to abort upload")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
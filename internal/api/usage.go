@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/db"
+)
+
+// Storage usage listing returns at most this many groups
+const (
+	defaultUsageLimit = 100
+	maxUsageLimit     = 1000
+)
+
+// UsageResponse is the output size of a job, title or tenant
+type UsageResponse struct {
+	*db.StorageUsage
+	// ExceedsAlert is set for titles over S3_TITLE_USAGE_ALERT_GB
+	ExceedsAlert bool `json:"exceedsAlert,omitempty"`
+}
+
+// GetStorageUsage lists artifact sizes aggregated by job, video or tenant, largest first
+func (h *Handler) GetStorageUsage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	group := db.UsageByVideo
+	if s := query.Get("groupBy"); s != "" {
+		group = db.UsageGroup(s)
+	}
+	switch group {
+	case db.UsageByJob, db.UsageByVideo, db.UsageByTenant:
+	default:
+		h.writeError(w, http.StatusBadRequest, "groupBy must be one of job, video, tenant")
+		return
+	}
+
+	limit := defaultUsageLimit
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 || n > maxUsageLimit {
+			h.writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxUsageLimit))
+			return
+		}
+		limit = n
+	}
+
+	var minBytes int64
+	if s := query.Get("minBytes"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 0 {
+			h.writeError(w, http.StatusBadRequest, "minBytes must be a non-negative integer")
+			return
+		}
+		minBytes = n
+	}
+
+	usage, err := h.artifactRepo.ListUsage(r.Context(), group, minBytes, limit)
+	if err != nil {
+		h.logger.Error("failed to list storage usage", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to list storage usage")
+		return
+	}
+
+	alertBytes := int64(h.config.S3.TitleUsageAlertGB) << 30
+	response := make([]*UsageResponse, 0, len(usage))
+	for _, u := range usage {
+		response = append(response, &UsageResponse{
+			StorageUsage: u,
+			ExceedsAlert: group == db.UsageByVideo && alertBytes > 0 && u.TotalBytes > alertBytes,
+		})
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/db"
+	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/publish"
+)
+
+// VersionsResponse represents the publication history of a video
+type VersionsResponse struct {
+	VideoID  uuid.UUID                  `json:"videoId"`
+	Current  *domain.PublishedVersion   `json:"current,omitempty"`
+	Master   string                     `json:"master,omitempty"`
+	Versions []*domain.PublishedVersion `json:"versions"`
+}
+
+// PromoteRequest selects the job to make live
+type PromoteRequest struct {
+	JobID uuid.UUID `json:"jobId"`
+}
+
+// ListVideoVersions returns the publication history of a video, newest first
+func (h *Handler) ListVideoVersions(w http.ResponseWriter, r *http.Request) {
+	videoID, ok := h.videoIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	versions, err := h.versionRepo.ListByVideoID(r.Context(), videoID)
+	if err != nil {
+		h.logger.Error("failed to list versions", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to list versions")
+		return
+	}
+
+	response := VersionsResponse{
+		VideoID:  videoID,
+		Versions: versions,
+	}
+	if len(versions) > 0 {
+		response.Current = versions[0]
+		response.Master = domain.CurrentPrefix(videoID) + "/master.m3u8"
+	} else {
+		response.Versions = []*domain.PublishedVersion{}
+	}
+
+	h.writeJSON(w, http.StatusOK, response)
+}
+
+// PromoteVideoVersion makes a completed job of the video its live version
+func (h *Handler) PromoteVideoVersion(w http.ResponseWriter, r *http.Request) {
+	videoID, ok := h.videoIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req PromoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.JobID == uuid.Nil {
+		h.writeError(w, http.StatusBadRequest, "jobId is required")
+		return
+	}
+
+	ctx := r.Context()
+
+	job, err := h.jobRepo.GetByID(ctx, req.JobID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		h.logger.Error("failed to get job", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to get job")
+		return
+	}
+	if job.VideoID == nil || *job.VideoID != videoID {
+		h.writeError(w, http.StatusBadRequest, "job does not belong to this video")
+		return
+	}
+
+	current, _, err := h.publisher.Publish(ctx, job, domain.PublishActionPromote)
+	h.writePublishResult(w, current, err)
+}
+
+// RollbackVideoVersion republishes the job that was live before the current one
+func (h *Handler) RollbackVideoVersion(w http.ResponseWriter, r *http.Request) {
+	videoID, ok := h.videoIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	current, err := h.publisher.Rollback(r.Context(), videoID)
+	h.writePublishResult(w, current, err)
+}
+
+// writePublishResult writes the live version after a promotion or rollback.
+// A version recorded without an updated pointer is reported as a gateway error:
+// repeating the request rewrites the pointer.
+func (h *Handler) writePublishResult(w http.ResponseWriter, current *domain.PublishedVersion, err error) {
+	switch {
+	case err == nil:
+		h.writeJSON(w, http.StatusOK, current)
+	case errors.Is(err, publish.ErrNotPublishable), errors.Is(err, publish.ErrNoPreviousVersion):
+		h.writeError(w, http.StatusConflict, err.Error())
+	case current != nil:
+		h.logger.Error("failed to update live version pointer", zap.Error(err))
+		h.writeError(w, http.StatusBadGateway, "version recorded but pointer not updated, retry the request")
+	default:
+		h.logger.Error("failed to publish version", zap.Error(err))
+		h.writeError(w, http.StatusInternalServerError, "failed to publish version")
+	}
+}
+
+// videoIDParam parses the videoId URL parameter, writing 400 if it is invalid
+func (h *Handler) videoIDParam(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	videoID, err := uuid.Parse(chi.URLParam(r, "videoId"))
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "invalid video ID")
+		return uuid.Nil, false
+	}
+	return videoID, true
+}
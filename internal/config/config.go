@@ -1,10 +1,15 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"runtime"
 	"strconv"
+	"strings"
 	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
 )
 
 // Config holds all application configuration
@@ -14,12 +19,20 @@ type Config struct {
 	S3         S3Config
 	Worker     WorkerConfig
 	API        APIConfig
+	Scheduler  SchedulerConfig
+	Reconciler ReconcilerConfig
+	Maintenance MaintenanceConfig
+	Publish    PublishConfig
 	FFmpeg     FFmpegConfig
 	Thumbnails ThumbnailsConfig
+	Subtitles  SubtitlesConfig
 	HLS        HLSConfig
 	Encoding   EncodingConfig
+	Burst      BurstConfig
+	Input      InputConfig
 	DRM        DRMConfig
 	Retry      RetryConfig
+	Events     EventsConfig
 	Log        LogConfig
 }
 
@@ -36,6 +49,27 @@ type TemporalConfig struct {
 	Address   string
 	Namespace string
 	TaskQueue string
+	// Jobs with priority >= HighPriorityThreshold are routed to HighPriorityTaskQueue.
+	// Empty queue name disables priority routing.
+	HighPriorityTaskQueue string
+	HighPriorityThreshold int
+	// Jobs that need a GPU are routed to GPUTaskQueue, which only workers with
+	// ENABLE_GPU poll. Empty queue name disables GPU routing.
+	GPUTaskQueue string
+	// GPUMinHeight routes profiles with a quality at least this tall to the GPU queue; 0 disables
+	GPUMinHeight int
+	// GPUForHEVC routes every job to the GPU queue while the H.265 tier is enabled
+	GPUForHEVC bool
+	// Activity heartbeats. HeartbeatInterval is how often workers heartbeat during
+	// long I/O; the timeouts are how long Temporal waits for a heartbeat.
+	HeartbeatInterval         time.Duration
+	HeartbeatTimeout          time.Duration // metadata, validation, thumbnails, HLS, ...
+	TranscodeHeartbeatTimeout time.Duration
+	UploadHeartbeatTimeout    time.Duration
+	// HeartbeatTimeoutPerGB extends transcode/upload heartbeat timeouts per GB of source
+	HeartbeatTimeoutPerGB time.Duration
+	// ConversionWorkflow is the registered workflow version new jobs start
+	ConversionWorkflow string
 }
 
 // S3Config holds S3 configuration
@@ -45,7 +79,22 @@ type S3Config struct {
 	AccessKey    string
 	SecretKey    string
 	BucketOutput string
+	// BucketStaging receives direct uploads made through the API
+	BucketStaging string
 	UseSSL       bool
+	// Garbage collection of incomplete multipart uploads
+	MultipartGCInterval time.Duration // 0 disables the cleanup loop
+	MultipartMaxAge     time.Duration
+	// VerifyOutput re-reads playlists and HEAD-checks sampled segments after upload
+	VerifyOutput         bool
+	VerifySegmentSamples int // segments checked per variant playlist
+	// TitleUsageAlertGB flags titles whose output across all jobs exceeds it; 0 disables
+	TitleUsageAlertGB int
+	// SourceAllowList restricts job sources to "bucket" or "bucket/prefix" entries;
+	// empty allows any bucket. The staging bucket is always allowed.
+	SourceAllowList []string
+	// SourceExtensions lists the file extensions accepted for source keys
+	SourceExtensions []string
 }
 
 // WorkerConfig holds worker configuration
@@ -54,21 +103,111 @@ type WorkerConfig struct {
 	MaxParallelJobs   int
 	MaxParallelFFmpeg int
 	MaxParallelUploads int
-	EnableGPU         bool
+	// EnableGPU is set when HWAccel selects a hardware backend
+	EnableGPU bool
+	// HWAccel is the hardware decoding and encoding backend; without
+	// WORKER_HWACCEL, ENABLE_GPU selects NVENC
+	HWAccel HWAccel
+	// HWDevice is the DRM render node of the VAAPI and QSV backends; empty
+	// uses /dev/dri/renderD128
+	HWDevice string
+	// GPUDevices are the devices GPU transcodes are spread over: NVIDIA GPU
+	// indexes or DRM render nodes. Empty detects NVIDIA GPUs with nvidia-smi.
+	GPUDevices []string
+	// FFmpegThreads is the thread count per ffmpeg encoder; 0 derives it from cores and MaxParallelFFmpeg
+	FFmpegThreads int
+	// PausePollInterval is how often transcoding checks whether the job was paused or canceled
+	PausePollInterval time.Duration
+	// AdmissionMinFreeDiskGB holds new local transcodes while free disk is below it; 0 disables
+	AdmissionMinFreeDiskGB int
+	// AdmissionMinFreeGPUMB holds new local transcodes while free GPU memory is below it; 0 disables
+	AdmissionMinFreeGPUMB int
 }
 
+// HWAccel is a hardware acceleration backend of ffmpeg
+type HWAccel string
+
+// Hardware acceleration backends
+const (
+	HWAccelNone         HWAccel = "none"
+	HWAccelNVENC        HWAccel = "nvenc"        // NVIDIA NVDEC/NVENC
+	HWAccelQSV          HWAccel = "qsv"          // Intel Quick Sync Video
+	HWAccelVAAPI        HWAccel = "vaapi"        // VA-API on Linux (Intel, AMD)
+	HWAccelVideoToolbox HWAccel = "videotoolbox" // macOS
+)
+
 // APIConfig holds API configuration
 type APIConfig struct {
 	Port         int
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	// EventsPollInterval is how often the SSE endpoint polls job progress
+	EventsPollInterval time.Duration
+	// ProbeTimeout limits ffprobe runtime for the probe endpoint
+	ProbeTimeout time.Duration
+	// PresignExpiry is the lifetime of presigned artifact URLs
+	PresignExpiry time.Duration
+	// UploadExpiry is the lifetime of presigned part URLs for direct uploads
+	UploadExpiry time.Duration
+	// TLSCertFile and TLSKeyFile enable HTTPS; both or neither must be set
+	TLSCertFile string
+	TLSKeyFile  string
+	// TLSClientCAFile enables mutual TLS: clients must present a certificate signed by one of its CAs
+	TLSClientCAFile string
+}
+
+// SchedulerConfig holds job dispatch configuration
+type SchedulerConfig struct {
+	// FairDispatch keeps new jobs in the database and lets the dispatcher start them
+	// round-robin across tenants instead of starting workflows immediately
+	FairDispatch bool
+	Interval     time.Duration
+	// MaxInFlight limits dispatched jobs that have not finished yet
+	MaxInFlight int
+	// TenantMaxRunning limits RUNNING jobs per tenant; further jobs of the tenant
+	// wait in their workflow. 0 disables the limit.
+	TenantMaxRunning int
+}
+
+// ReconcilerConfig holds configuration of the stale job reconciler
+type ReconcilerConfig struct {
+	// Interval between reconciliation passes; 0 disables the reconciler
+	Interval time.Duration
+	// StaleAfter is how long a RUNNING job may go without updates before its workflow is checked
+	StaleAfter time.Duration
+	// BatchSize limits jobs checked per pass
+	BatchSize int
+}
+
+// MaintenanceConfig holds periodic maintenance tasks run by Temporal schedules
+type MaintenanceConfig struct {
+	// OrphanCleanupInterval between removals of abandoned workspaces; 0 disables it
+	OrphanCleanupInterval time.Duration
+	// OrphanMaxAge is how long an unlocked workspace is kept
+	OrphanMaxAge time.Duration
+}
+
+// PublishConfig holds publishing of job outputs as live video versions
+type PublishConfig struct {
+	// OnComplete publishes a completed job unless a newer job of its video is live
+	OnComplete bool
 }
 
 // FFmpegConfig holds FFmpeg configuration
 type FFmpegConfig struct {
-	BinaryPath      string
-	FFprobePath     string
-	ProcessTimeout  time.Duration
+	BinaryPath     string
+	FFprobePath    string
+	ProcessTimeout time.Duration
+	// HDR10PlusToolPath is the hdr10plus_tool binary that extracts HDR10+
+	// metadata for libx265; empty strips the metadata
+	HDR10PlusToolPath string
+	// SubtitleOCRCommand converts bitmap subtitles (PGS, VobSub) to SRT. Its
+	// arguments may contain {input}, {output} and {language}; empty skips
+	// bitmap subtitle tracks. Set as a JSON array for arguments with spaces.
+	SubtitleOCRCommand []string
+	// Progress callback throttling
+	ProgressInterval time.Duration // minimum time between progress updates
+	ProgressMinDelta time.Duration // minimum encoded media time between progress updates
 }
 
 // ThumbnailsConfig holds thumbnail generation defaults
@@ -76,11 +215,23 @@ type ThumbnailsConfig struct {
 	MaxFrames int
 }
 
+// SubtitlesConfig holds normalization settings for extracted subtitles
+type SubtitlesConfig struct {
+	// Normalize rewrites extracted WebVTT as UTF-8 without BOM and drops broken cues
+	Normalize bool
+	// FallbackCharset decodes subtitles that are not valid UTF-8
+	FallbackCharset string
+	// StripTags lists cue tags to remove ("b", "i", "font", ...); "*" removes all tags
+	StripTags []string
+}
+
 // HLSConfig holds HLS generation defaults
 type HLSConfig struct {
 	SegmentDurationSec int
 	EnableEncryption   bool
 	KeyURL             string // URL template for key delivery, e.g., "https://example.com/keys/{job_id}/key"
+	// SingleFile writes each rendition as one file addressed by EXT-X-BYTERANGE
+	SingleFile bool
 }
 
 // EncodingConfig holds multi-codec encoding configuration
@@ -89,12 +240,81 @@ type EncodingConfig struct {
 	EnableLegacyTier bool // H264/AAC/TS - maximum compatibility
 	EnableModernTier bool // H265/AAC/fMP4 - 40% bandwidth savings
 
-	// Container format for HLS segments
-	HLSSegmentType string // "ts" or "fmp4"
+	// Container format of the legacy tier HLS segments, "ts" or "fmp4" (CMAF);
+	// the modern tier is always fMP4
+	HLSSegmentType string
+
+	// H.264 specific settings of libx264; profiles may override them
+	H264Preset string // CPU preset, as for H.265
+	H264Tune   string // film, animation, grain, ...; empty sets none
+	H264Level  string // e.g. 4.1
+	H264Params string // extra x264-params, key=value:key=value
 
 	// H.265 specific settings
 	H265Preset string // CPU preset: ultrafast, superfast, veryfast, faster, fast, medium, slow, slower, veryslow
 	H265CRF    int    // Constant Rate Factor (0-51, lower = better quality, 26 recommended)
+	H265Tune   string // animation, grain, ...; empty sets none
+	H265Level  string // empty leaves the level to x265
+	H265Params string // extra x265-params, key=value:key=value
+
+	// SinglePass encodes all qualities of a tier in one ffmpeg invocation, decoding the source once
+	SinglePass bool
+
+	// ParallelRenditions encodes every (tier, quality) in its own activity so workers sharing
+	// WORKDIR_ROOT can encode renditions of one job concurrently
+	ParallelRenditions bool
+
+	// PreserveDolbyVision passes Dolby Vision RPU through libx265 (requires FFmpeg 7+)
+	PreserveDolbyVision bool
+
+	// Output budget enforced by ValidateInputs; 0 means unlimited
+	MaxRenditions    int // qualities × tiers, plus the mezzanine
+	MaxEncodeMinutes int // source duration × renditions
+
+	// Transcoder is the default transcode backend; profiles may pick another registered one
+	Transcoder string
+
+	// SafeRetryAfter is how many times a rendition may fail with encoder errors
+	// before it is encoded with safe settings; 0 fails the job instead
+	SafeRetryAfter int
+
+	// PerTitle scales the ladder of every job to the complexity of its source;
+	// profiles can enable it individually with perTitle
+	PerTitle bool
+
+	// Bit depth of H.264 and H.265 renditions of sources with more than 8 bits: 8 or 10
+	H264BitDepth int
+	H265BitDepth int
+}
+
+// BurstConfig holds configuration of transcode offload to a cloud service
+type BurstConfig struct {
+	// Transcoder is the backend transcodes are offloaded to; empty disables offload
+	Transcoder string
+	// BacklogThreshold is the number of QUEUED jobs above which transcodes are offloaded
+	BacklogThreshold int
+	// AWS Elemental MediaConvert backend
+	MediaConvertEndpoint  string // account endpoint, https://<id>.mediaconvert.<region>.amazonaws.com
+	MediaConvertRegion    string
+	MediaConvertAccessKey string
+	MediaConvertSecretKey string
+	MediaConvertRoleARN   string // IAM role MediaConvert assumes to read and write the bucket
+	MediaConvertQueue     string // queue ARN, empty uses the default queue
+	MediaConvertBucket    string // AWS S3 bucket for source copies and outputs
+	MediaConvertPollInterval time.Duration
+}
+
+// InputConfig holds allow/deny lists for input formats on top of the built-in ones
+type InputConfig struct {
+	AllowContainers  []string
+	DenyContainers   []string
+	AllowVideoCodecs []string
+	DenyVideoCodecs  []string
+	AllowAudioCodecs []string
+	DenyAudioCodecs  []string
+	// StreamHosts restricts HLS/DASH source URLs to these hosts; an entry
+	// starting with a dot matches subdomains. Empty allows any public host.
+	StreamHosts []string
 }
 
 // DRMConfig holds DRM configuration
@@ -118,11 +338,43 @@ type DRMConfig struct {
 	PlayReadyLAURL     string // License Acquisition URL
 }
 
+// EventsConfig holds event sink configuration
+type EventsConfig struct {
+	// Sinks lists enabled sinks by name: log, webhook, kafka, prometheus
+	Sinks          []string
+	QueueSize      int
+	PublishTimeout time.Duration
+	// Webhook sink
+	WebhookURL    string
+	WebhookSecret string // HMAC-SHA256 signing key, optional
+	// Kafka sink (via Confluent REST Proxy)
+	KafkaRESTURL string
+	KafkaTopic   string
+}
+
 // RetryConfig holds retry policy configuration
 type RetryConfig struct {
+	// Retries of a single S3 upload inside UploadArtifacts
 	Count        int
 	BaseDelayMs  int
 	MaxDelayMs   int
+	// Temporal retry policies of workflow activities. Default covers validation,
+	// subtitles, thumbnails, HLS and output verification.
+	Default   ActivityRetryConfig
+	Metadata  ActivityRetryConfig
+	Transcode ActivityRetryConfig
+	Upload    ActivityRetryConfig
+}
+
+// ActivityRetryConfig is the Temporal retry policy and start-to-close timeout of an activity group
+type ActivityRetryConfig struct {
+	MaxAttempts        int
+	InitialInterval    time.Duration
+	BackoffCoefficient float64
+	MaxInterval        time.Duration
+	Timeout            time.Duration
+	// TimeoutPerMinute extends Timeout per minute of source duration; 0 keeps it fixed
+	TimeoutPerMinute time.Duration
 }
 
 // LogConfig holds logging configuration
@@ -144,6 +396,20 @@ func Load() (*Config, error) {
 			Address:   getEnv("TEMPORAL_ADDRESS", "localhost:7233"),
 			Namespace: getEnv("TEMPORAL_NAMESPACE", "default"),
 			TaskQueue: getEnv("TEMPORAL_TASK_QUEUE", "video-conversion"),
+			// Priority routing
+			HighPriorityTaskQueue: getEnv("TEMPORAL_HIGH_PRIORITY_TASK_QUEUE", "video-conversion-high"),
+			HighPriorityThreshold: getEnvInt("JOB_HIGH_PRIORITY_THRESHOLD", 10),
+			// GPU routing
+			GPUTaskQueue: getEnv("TEMPORAL_GPU_TASK_QUEUE", ""),
+			GPUMinHeight: getEnvInt("TEMPORAL_GPU_MIN_HEIGHT", 2160),
+			GPUForHEVC:   getEnvBool("TEMPORAL_GPU_FOR_HEVC", false),
+			// Activity heartbeats
+			HeartbeatInterval:         getEnvDuration("TEMPORAL_HEARTBEAT_INTERVAL", 30*time.Second),
+			HeartbeatTimeout:          getEnvDuration("TEMPORAL_HEARTBEAT_TIMEOUT", 1*time.Minute),
+			TranscodeHeartbeatTimeout: getEnvDuration("TEMPORAL_TRANSCODE_HEARTBEAT_TIMEOUT", 5*time.Minute),
+			UploadHeartbeatTimeout:    getEnvDuration("TEMPORAL_UPLOAD_HEARTBEAT_TIMEOUT", 1*time.Minute),
+			HeartbeatTimeoutPerGB:     getEnvDuration("TEMPORAL_HEARTBEAT_TIMEOUT_PER_GB", 15*time.Second),
+			ConversionWorkflow:        getEnv("TEMPORAL_CONVERSION_WORKFLOW", "VideoConversionWorkflow"),
 		},
 		S3: S3Config{
 			Endpoint:     getEnv("S3_ENDPOINT", "http://localhost:9000"),
@@ -151,7 +417,16 @@ func Load() (*Config, error) {
 			AccessKey:    getEnv("S3_ACCESS_KEY", ""),
 			SecretKey:    getEnv("S3_SECRET_KEY", ""),
 			BucketOutput: getEnv("S3_BUCKET_OUTPUT", "converted"),
+			BucketStaging: getEnv("S3_BUCKET_STAGING", "source"),
 			UseSSL:       getEnvBool("S3_USE_SSL", false),
+			// Multipart upload GC
+			MultipartGCInterval: getEnvDuration("S3_MULTIPART_GC_INTERVAL", 1*time.Hour),
+			MultipartMaxAge:     getEnvDuration("S3_MULTIPART_MAX_AGE", 24*time.Hour),
+			VerifyOutput:         getEnvBool("S3_VERIFY_OUTPUT", true),
+			VerifySegmentSamples: getEnvInt("S3_VERIFY_SEGMENT_SAMPLES", 5),
+			TitleUsageAlertGB:    getEnvInt("S3_TITLE_USAGE_ALERT_GB", 0),
+			SourceAllowList:      getEnvList("S3_SOURCE_ALLOWLIST"),
+			SourceExtensions:     getEnvListDefault("S3_SOURCE_EXTENSIONS", defaultSourceExtensions),
 		},
 		Worker: WorkerConfig{
 			WorkdirRoot:        getEnv("WORKDIR_ROOT", "/work"),
@@ -159,31 +434,111 @@ func Load() (*Config, error) {
 			MaxParallelFFmpeg:  getEnvInt("MAX_PARALLEL_FFMPEG", 4),
 			MaxParallelUploads: getEnvInt("MAX_PARALLEL_UPLOADS", 10),
 			EnableGPU:          getEnvBool("ENABLE_GPU", true),
+			HWAccel:            HWAccel(strings.ToLower(getEnv("WORKER_HWACCEL", ""))),
+			HWDevice:           getEnv("WORKER_HWACCEL_DEVICE", ""),
+			GPUDevices:         getEnvList("WORKER_GPU_DEVICES"),
+			FFmpegThreads:      getEnvInt("FFMPEG_THREADS", 0),
+			PausePollInterval:  getEnvDuration("WORKER_PAUSE_POLL_INTERVAL", 10*time.Second),
+			AdmissionMinFreeDiskGB: getEnvInt("WORKER_ADMISSION_MIN_FREE_DISK_GB", 10),
+			AdmissionMinFreeGPUMB:  getEnvInt("WORKER_ADMISSION_MIN_FREE_GPU_MB", 1024),
 		},
 		API: APIConfig{
-			Port:         getEnvInt("API_PORT", 8080),
-			ReadTimeout:  getEnvDuration("API_READ_TIMEOUT", 30*time.Second),
-			WriteTimeout: getEnvDuration("API_WRITE_TIMEOUT", 30*time.Second),
+			Port:               getEnvInt("API_PORT", 8080),
+			ReadTimeout:        getEnvDuration("API_READ_TIMEOUT", 30*time.Second),
+			WriteTimeout:       getEnvDuration("API_WRITE_TIMEOUT", 30*time.Second),
+			EventsPollInterval: getEnvDuration("API_EVENTS_POLL_INTERVAL", 2*time.Second),
+			ProbeTimeout:       getEnvDuration("API_PROBE_TIMEOUT", 30*time.Second),
+			PresignExpiry:      getEnvDuration("API_PRESIGN_EXPIRY", time.Hour),
+			UploadExpiry:       getEnvDuration("API_UPLOAD_EXPIRY", 24*time.Hour),
+			TLSCertFile:        getEnv("API_TLS_CERT_FILE", ""),
+			TLSKeyFile:         getEnv("API_TLS_KEY_FILE", ""),
+			TLSClientCAFile:    getEnv("API_TLS_CLIENT_CA_FILE", ""),
+		},
+		Scheduler: SchedulerConfig{
+			FairDispatch:     getEnvBool("SCHEDULER_FAIR_DISPATCH", false),
+			Interval:         getEnvDuration("SCHEDULER_INTERVAL", 2*time.Second),
+			MaxInFlight:      getEnvInt("SCHEDULER_MAX_IN_FLIGHT", 20),
+			TenantMaxRunning: getEnvInt("SCHEDULER_TENANT_MAX_RUNNING", 0),
+		},
+		Reconciler: ReconcilerConfig{
+			Interval:   getEnvDuration("RECONCILER_INTERVAL", 5*time.Minute),
+			StaleAfter: getEnvDuration("RECONCILER_STALE_AFTER", 15*time.Minute),
+			BatchSize:  getEnvInt("RECONCILER_BATCH_SIZE", 100),
+		},
+		Maintenance: MaintenanceConfig{
+			OrphanCleanupInterval: getEnvDuration("MAINTENANCE_ORPHAN_INTERVAL", time.Hour),
+			OrphanMaxAge:          getEnvDuration("MAINTENANCE_ORPHAN_MAX_AGE", 24*time.Hour),
+		},
+		Publish: PublishConfig{
+			OnComplete: getEnvBool("PUBLISH_ON_COMPLETE", true),
 		},
 		FFmpeg: FFmpegConfig{
-			BinaryPath:     getEnv("FFMPEG_PATH", "ffmpeg"),
-			FFprobePath:    getEnv("FFPROBE_PATH", "ffprobe"),
-			ProcessTimeout: getEnvDuration("FFMPEG_PROCESS_TIMEOUT", 6*time.Hour),
+			BinaryPath:       getEnv("FFMPEG_PATH", "ffmpeg"),
+			FFprobePath:      getEnv("FFPROBE_PATH", "ffprobe"),
+			ProcessTimeout:   getEnvDuration("FFMPEG_PROCESS_TIMEOUT", 6*time.Hour),
+			HDR10PlusToolPath: getEnv("HDR10PLUS_TOOL_PATH", ""),
+			SubtitleOCRCommand: getEnvCommand("SUBTITLE_OCR_COMMAND"),
+			ProgressInterval: getEnvDuration("FFMPEG_PROGRESS_INTERVAL", 5*time.Second),
+			ProgressMinDelta: getEnvDuration("FFMPEG_PROGRESS_MIN_DELTA", 0),
 		},
 		Thumbnails: ThumbnailsConfig{
 			MaxFrames: getEnvInt("THUMB_MAX_FRAMES", 200),
 		},
+		Subtitles: SubtitlesConfig{
+			Normalize:       getEnvBool("SUBTITLES_NORMALIZE", true),
+			FallbackCharset: getEnv("SUBTITLES_FALLBACK_CHARSET", "windows-1251"),
+			StripTags:       getEnvList("SUBTITLES_STRIP_TAGS"),
+		},
 		HLS: HLSConfig{
 			SegmentDurationSec: getEnvInt("HLS_SEGMENT_DURATION_SEC", 4),
 			EnableEncryption:   getEnvBool("HLS_ENABLE_ENCRYPTION", false),
 			KeyURL:             getEnv("HLS_KEY_URL", ""),
+			SingleFile:         getEnvBool("HLS_SINGLE_FILE", false),
 		},
 		Encoding: EncodingConfig{
 			EnableLegacyTier: getEnvBool("ENCODING_LEGACY_TIER", true),
 			EnableModernTier: getEnvBool("ENCODING_MODERN_TIER", true),
-			HLSSegmentType:   getEnv("HLS_SEGMENT_TYPE", "fmp4"),
+			HLSSegmentType:   getEnv("HLS_SEGMENT_TYPE", "ts"),
+			H264Preset:       getEnv("H264_PRESET", "slower"),
+			H264Tune:         getEnv("H264_TUNE", ""),
+			H264Level:        getEnv("H264_LEVEL", "4.1"),
+			H264Params:       getEnv("H264_PARAMS", ""),
 			H265Preset:       getEnv("H265_PRESET", "medium"),
 			H265CRF:          getEnvInt("H265_CRF", 26),
+			H265Tune:         getEnv("H265_TUNE", ""),
+			H265Level:        getEnv("H265_LEVEL", ""),
+			H265Params:       getEnv("H265_PARAMS", ""),
+			SinglePass:       getEnvBool("ENCODING_SINGLE_PASS", false),
+			ParallelRenditions: getEnvBool("ENCODING_PARALLEL_RENDITIONS", false),
+			PreserveDolbyVision: getEnvBool("ENCODING_PRESERVE_DOLBY_VISION", false),
+			MaxRenditions:       getEnvInt("ENCODING_MAX_RENDITIONS", 0),
+			MaxEncodeMinutes:    getEnvInt("ENCODING_MAX_ENCODE_MINUTES", 0),
+			Transcoder:          getEnv("TRANSCODER_BACKEND", "ffmpeg"),
+			SafeRetryAfter:      getEnvInt("ENCODING_SAFE_RETRY_AFTER", 2),
+			PerTitle:            getEnvBool("ENCODING_PER_TITLE", false),
+			H264BitDepth:        getEnvInt("ENCODING_H264_BIT_DEPTH", 8),
+			H265BitDepth:        getEnvInt("ENCODING_H265_BIT_DEPTH", 10),
+		},
+		Burst: BurstConfig{
+			Transcoder:               getEnv("BURST_TRANSCODER", ""),
+			BacklogThreshold:         getEnvInt("BURST_BACKLOG_THRESHOLD", 20),
+			MediaConvertEndpoint:     getEnv("MEDIACONVERT_ENDPOINT", ""),
+			MediaConvertRegion:       getEnv("MEDIACONVERT_REGION", "us-east-1"),
+			MediaConvertAccessKey:    getEnv("MEDIACONVERT_ACCESS_KEY", ""),
+			MediaConvertSecretKey:    getEnv("MEDIACONVERT_SECRET_KEY", ""),
+			MediaConvertRoleARN:      getEnv("MEDIACONVERT_ROLE_ARN", ""),
+			MediaConvertQueue:        getEnv("MEDIACONVERT_QUEUE", ""),
+			MediaConvertBucket:       getEnv("MEDIACONVERT_BUCKET", ""),
+			MediaConvertPollInterval: getEnvDuration("MEDIACONVERT_POLL_INTERVAL", 15*time.Second),
+		},
+		Input: InputConfig{
+			AllowContainers:  getEnvList("INPUT_ALLOW_CONTAINERS"),
+			DenyContainers:   getEnvList("INPUT_DENY_CONTAINERS"),
+			AllowVideoCodecs: getEnvList("INPUT_ALLOW_VIDEO_CODECS"),
+			DenyVideoCodecs:  getEnvList("INPUT_DENY_VIDEO_CODECS"),
+			AllowAudioCodecs: getEnvList("INPUT_ALLOW_AUDIO_CODECS"),
+			DenyAudioCodecs:  getEnvList("INPUT_DENY_AUDIO_CODECS"),
+			StreamHosts:      getEnvList("INPUT_STREAM_HOSTS"),
 		},
 		DRM: DRMConfig{
 			Enabled:           getEnvBool("DRM_ENABLED", false),
@@ -208,6 +563,31 @@ func Load() (*Config, error) {
 			Count:       getEnvInt("RETRY_COUNT", 3),
 			BaseDelayMs: getEnvInt("RETRY_BASE_DELAY_MS", 1000),
 			MaxDelayMs:  getEnvInt("RETRY_MAX_DELAY_MS", 30000),
+			Default: getActivityRetry("DEFAULT", ActivityRetryConfig{
+				MaxAttempts: 3, InitialInterval: time.Second, BackoffCoefficient: 2.0,
+				MaxInterval: time.Minute, Timeout: 6 * time.Hour,
+			}),
+			Metadata: getActivityRetry("METADATA", ActivityRetryConfig{
+				MaxAttempts: 3, InitialInterval: time.Second, BackoffCoefficient: 2.0,
+				MaxInterval: time.Minute, Timeout: 6 * time.Hour,
+			}),
+			Transcode: getActivityRetry("TRANSCODE", ActivityRetryConfig{
+				MaxAttempts: 2, InitialInterval: 10 * time.Second, BackoffCoefficient: 2.0,
+				MaxInterval: 5 * time.Minute, Timeout: 12 * time.Hour,
+			}),
+			Upload: getActivityRetry("UPLOAD", ActivityRetryConfig{
+				MaxAttempts: 5, InitialInterval: 5 * time.Second, BackoffCoefficient: 2.0,
+				MaxInterval: 2 * time.Minute, Timeout: 2 * time.Hour,
+			}),
+		},
+		Events: EventsConfig{
+			Sinks:          getEnvList("EVENT_SINKS"),
+			QueueSize:      getEnvInt("EVENT_QUEUE_SIZE", 1000),
+			PublishTimeout: getEnvDuration("EVENT_PUBLISH_TIMEOUT", 5*time.Second),
+			WebhookURL:     getEnv("EVENT_WEBHOOK_URL", ""),
+			WebhookSecret:  getEnv("EVENT_WEBHOOK_SECRET", ""),
+			KafkaRESTURL:   getEnv("EVENT_KAFKA_REST_URL", ""),
+			KafkaTopic:     getEnv("EVENT_KAFKA_TOPIC", "converter-events"),
 		},
 		Log: LogConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
@@ -215,6 +595,15 @@ func Load() (*Config, error) {
 		},
 	}
 
+	// ENABLE_GPU without WORKER_HWACCEL keeps selecting NVENC
+	if cfg.Worker.HWAccel == "" {
+		cfg.Worker.HWAccel = HWAccelNone
+		if cfg.Worker.EnableGPU {
+			cfg.Worker.HWAccel = HWAccelNVENC
+		}
+	}
+	cfg.Worker.EnableGPU = cfg.Worker.HWAccel != HWAccelNone
+
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
@@ -222,8 +611,45 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// TaskQueueFor returns the Temporal task queue of a job. Jobs that need a GPU
+// go to the GPU queue whatever their priority.
+func (c TemporalConfig) TaskQueueFor(priority int, needsGPU bool) string {
+	if c.GPUTaskQueue != "" && needsGPU {
+		return c.GPUTaskQueue
+	}
+	return c.TaskQueueForPriority(priority)
+}
+
+// TaskQueueForPriority returns the Temporal task queue for a job priority
+func (c TemporalConfig) TaskQueueForPriority(priority int) string {
+	if c.HighPriorityTaskQueue != "" && priority >= c.HighPriorityThreshold {
+		return c.HighPriorityTaskQueue
+	}
+	return c.TaskQueue
+}
+
+// ThreadsPerFFmpeg returns the encoder thread budget for one ffmpeg process.
+// When not set explicitly, available cores are split evenly between ffmpeg slots.
+func (c WorkerConfig) ThreadsPerFFmpeg() int {
+	if c.FFmpegThreads > 0 {
+		return c.FFmpegThreads
+	}
+	slots := c.MaxParallelFFmpeg
+	if slots < 1 {
+		slots = 1
+	}
+	threads := runtime.NumCPU() / slots
+	if threads < 1 {
+		threads = 1
+	}
+	return threads
+}
+
 // Validate validates the configuration
 func (c *Config) Validate() error {
+	if isJSONArray(os.Getenv("SUBTITLE_OCR_COMMAND")) && len(c.FFmpeg.SubtitleOCRCommand) == 0 {
+		return fmt.Errorf("SUBTITLE_OCR_COMMAND must be a non-empty JSON array of strings")
+	}
 	if c.S3.AccessKey == "" {
 		return fmt.Errorf("S3_ACCESS_KEY is required")
 	}
@@ -233,12 +659,116 @@ func (c *Config) Validate() error {
 	if c.S3.BucketOutput == "" {
 		return fmt.Errorf("S3_BUCKET_OUTPUT is required")
 	}
+	if (c.API.TLSCertFile == "") != (c.API.TLSKeyFile == "") {
+		return fmt.Errorf("API_TLS_CERT_FILE and API_TLS_KEY_FILE must be set together")
+	}
+	if c.API.TLSClientCAFile != "" && c.API.TLSCertFile == "" {
+		return fmt.Errorf("API_TLS_CLIENT_CA_FILE requires API_TLS_CERT_FILE and API_TLS_KEY_FILE")
+	}
 	if c.Worker.MaxParallelJobs < 1 {
 		return fmt.Errorf("MAX_PARALLEL_JOBS must be at least 1")
 	}
 	if c.Worker.MaxParallelFFmpeg < 1 {
 		return fmt.Errorf("MAX_PARALLEL_FFMPEG must be at least 1")
 	}
+	if c.Worker.PausePollInterval <= 0 {
+		return fmt.Errorf("WORKER_PAUSE_POLL_INTERVAL must be positive")
+	}
+	if c.Temporal.GPUMinHeight < 0 {
+		return fmt.Errorf("TEMPORAL_GPU_MIN_HEIGHT must not be negative")
+	}
+	switch c.Worker.HWAccel {
+	case HWAccelNone, HWAccelNVENC, HWAccelQSV, HWAccelVAAPI, HWAccelVideoToolbox:
+	default:
+		return fmt.Errorf("WORKER_HWACCEL must be none, nvenc, qsv, vaapi or videotoolbox")
+	}
+	if c.Worker.AdmissionMinFreeDiskGB < 0 || c.Worker.AdmissionMinFreeGPUMB < 0 {
+		return fmt.Errorf("WORKER_ADMISSION_MIN_FREE_DISK_GB and WORKER_ADMISSION_MIN_FREE_GPU_MB must not be negative")
+	}
+	if c.Encoding.SinglePass && c.Encoding.ParallelRenditions {
+		return fmt.Errorf("ENCODING_SINGLE_PASS and ENCODING_PARALLEL_RENDITIONS are mutually exclusive")
+	}
+	if c.S3.VerifyOutput && c.S3.VerifySegmentSamples < 1 {
+		return fmt.Errorf("S3_VERIFY_SEGMENT_SAMPLES must be at least 1")
+	}
+	if c.S3.TitleUsageAlertGB < 0 {
+		return fmt.Errorf("S3_TITLE_USAGE_ALERT_GB must not be negative")
+	}
+	if t := c.Encoding.HLSSegmentType; t != "ts" && t != "fmp4" {
+		return fmt.Errorf("HLS_SEGMENT_TYPE must be ts or fmp4")
+	}
+	if !validBitDepth(c.Encoding.H264BitDepth) || !validBitDepth(c.Encoding.H265BitDepth) {
+		return fmt.Errorf("ENCODING_H264_BIT_DEPTH and ENCODING_H265_BIT_DEPTH must be 8 or 10")
+	}
+	if c.Encoding.MaxRenditions < 0 || c.Encoding.MaxEncodeMinutes < 0 {
+		return fmt.Errorf("ENCODING_MAX_RENDITIONS and ENCODING_MAX_ENCODE_MINUTES must not be negative")
+	}
+	if c.Encoding.SafeRetryAfter < 0 {
+		return fmt.Errorf("ENCODING_SAFE_RETRY_AFTER must not be negative")
+	}
+	if c.Burst.Transcoder != "" && c.Burst.BacklogThreshold < 0 {
+		return fmt.Errorf("BURST_BACKLOG_THRESHOLD must not be negative")
+	}
+	if len(c.Events.Sinks) > 0 && c.Events.QueueSize < 1 {
+		return fmt.Errorf("EVENT_QUEUE_SIZE must be at least 1")
+	}
+	if c.Temporal.HeartbeatInterval <= 0 {
+		return fmt.Errorf("TEMPORAL_HEARTBEAT_INTERVAL must be positive")
+	}
+	if c.Temporal.HeartbeatInterval >= c.Temporal.HeartbeatTimeout {
+		return fmt.Errorf("TEMPORAL_HEARTBEAT_INTERVAL must be less than TEMPORAL_HEARTBEAT_TIMEOUT")
+	}
+	if c.Scheduler.FairDispatch && c.Scheduler.MaxInFlight < 1 {
+		return fmt.Errorf("SCHEDULER_MAX_IN_FLIGHT must be at least 1")
+	}
+	if c.Scheduler.TenantMaxRunning < 0 {
+		return fmt.Errorf("SCHEDULER_TENANT_MAX_RUNNING must not be negative")
+	}
+	if c.Subtitles.Normalize && c.Subtitles.FallbackCharset != "" {
+		if _, err := htmlindex.Get(c.Subtitles.FallbackCharset); err != nil {
+			return fmt.Errorf("SUBTITLES_FALLBACK_CHARSET %q is not a known charset", c.Subtitles.FallbackCharset)
+		}
+	}
+	if c.Reconciler.Interval > 0 && c.Reconciler.BatchSize < 1 {
+		return fmt.Errorf("RECONCILER_BATCH_SIZE must be at least 1")
+	}
+	if c.Maintenance.OrphanCleanupInterval > 0 && c.Maintenance.OrphanMaxAge <= 0 {
+		return fmt.Errorf("MAINTENANCE_ORPHAN_MAX_AGE must be positive")
+	}
+	retries := []struct {
+		group string
+		retry ActivityRetryConfig
+	}{
+		{"DEFAULT", c.Retry.Default},
+		{"METADATA", c.Retry.Metadata},
+		{"TRANSCODE", c.Retry.Transcode},
+		{"UPLOAD", c.Retry.Upload},
+	}
+	for _, r := range retries {
+		if err := r.retry.validate(); err != nil {
+			return fmt.Errorf("RETRY_%s_%w", r.group, err)
+		}
+	}
+	return nil
+}
+
+// validate checks an activity retry policy. Temporal treats zero attempts as
+// unlimited, so at least one attempt is required.
+func (r ActivityRetryConfig) validate() error {
+	switch {
+	case r.MaxAttempts < 1:
+		return fmt.Errorf("MAX_ATTEMPTS must be at least 1")
+	case r.InitialInterval < time.Second:
+		return fmt.Errorf("INITIAL_INTERVAL must be at least 1s")
+	case r.BackoffCoefficient < 1:
+		return fmt.Errorf("BACKOFF must be at least 1")
+	case r.MaxInterval < r.InitialInterval:
+		return fmt.Errorf("MAX_INTERVAL must not be less than the initial interval")
+	case r.Timeout < time.Second:
+		return fmt.Errorf("TIMEOUT must be at least 1s")
+	case r.TimeoutPerMinute < 0:
+		return fmt.Errorf("TIMEOUT_PER_MINUTE must not be negative")
+	}
 	return nil
 }
 
@@ -275,3 +805,76 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+// getActivityRetry reads RETRY_<group>_* variables on top of the defaults
+func getActivityRetry(group string, defaults ActivityRetryConfig) ActivityRetryConfig {
+	prefix := "RETRY_" + group + "_"
+	return ActivityRetryConfig{
+		MaxAttempts:        getEnvInt(prefix+"MAX_ATTEMPTS", defaults.MaxAttempts),
+		InitialInterval:    getEnvDuration(prefix+"INITIAL_INTERVAL", defaults.InitialInterval),
+		BackoffCoefficient: getEnvFloat(prefix+"BACKOFF", defaults.BackoffCoefficient),
+		MaxInterval:        getEnvDuration(prefix+"MAX_INTERVAL", defaults.MaxInterval),
+		Timeout:            getEnvDuration(prefix+"TIMEOUT", defaults.Timeout),
+		TimeoutPerMinute:   getEnvDuration(prefix+"TIMEOUT_PER_MINUTE", defaults.TimeoutPerMinute),
+	}
+}
+
+// defaultSourceExtensions are the source file extensions accepted by default
+var defaultSourceExtensions = []string{
+	".mp4", ".m4v", ".mov", ".mkv", ".webm", ".avi", ".mxf",
+	".ts", ".m2ts", ".mts", ".mpg", ".mpeg", ".wmv", ".flv",
+	// Audio files, for audio-only profiles
+	".m4a", ".mp3", ".wav", ".flac", ".ogg", ".aac",
+}
+
+// getEnvListDefault parses a comma-separated list, or returns def when unset
+func getEnvListDefault(key string, def []string) []string {
+	if items := getEnvList(key); len(items) > 0 {
+		return items
+	}
+	return def
+}
+
+// validBitDepth reports whether renditions can be encoded at depth bits
+func validBitDepth(depth int) bool {
+	return depth == 8 || depth == 10
+}
+
+// getEnvCommand parses a command line: a JSON array of arguments, or
+// arguments separated by spaces. An invalid array gives no command.
+func getEnvCommand(key string) []string {
+	value := os.Getenv(key)
+	if !isJSONArray(value) {
+		return strings.Fields(value)
+	}
+	var args []string
+	if err := json.Unmarshal([]byte(value), &args); err != nil {
+		return nil
+	}
+	return args
+}
+
+// isJSONArray reports whether value is meant as a JSON array
+func isJSONArray(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), "[")
+}
+
+// getEnvList parses a comma-separated list, ignoring empty items
+func getEnvList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -22,11 +23,16 @@ func NewArtifactRepository(db *DB) *ArtifactRepository {
 func (r *ArtifactRepository) Create(ctx context.Context, artifact *domain.Artifact) error {
 	query := `
 		INSERT INTO conversion_artifacts (
-			id, job_id, type, bucket, key, size_bytes, checksum, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			id, job_id, type, bucket, key, size_bytes, checksum, tracks, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
-	_, err := r.db.Pool.Exec(ctx, query,
+	tracks, err := tracksJSON(artifact.Tracks)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Pool.Exec(ctx, query,
 		artifact.ID,
 		artifact.JobID,
 		artifact.Type,
@@ -34,6 +40,7 @@ func (r *ArtifactRepository) Create(ctx context.Context, artifact *domain.Artifa
 		artifact.Key,
 		artifact.SizeBytes,
 		artifact.Checksum,
+		tracks,
 		artifact.CreatedAt,
 	)
 	if err != nil {
@@ -53,12 +60,16 @@ func (r *ArtifactRepository) CreateBatch(ctx context.Context, artifacts []*domai
 
 	query := `
 		INSERT INTO conversion_artifacts (
-			id, job_id, type, bucket, key, size_bytes, checksum, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			id, job_id, type, bucket, key, size_bytes, checksum, tracks, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
 	for _, artifact := range artifacts {
-		_, err := tx.Exec(ctx, query,
+		tracks, err := tracksJSON(artifact.Tracks)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, query,
 			artifact.ID,
 			artifact.JobID,
 			artifact.Type,
@@ -66,6 +77,7 @@ func (r *ArtifactRepository) CreateBatch(ctx context.Context, artifacts []*domai
 			artifact.Key,
 			artifact.SizeBytes,
 			artifact.Checksum,
+			tracks,
 			artifact.CreatedAt,
 		)
 		if err != nil {
@@ -83,7 +95,7 @@ func (r *ArtifactRepository) CreateBatch(ctx context.Context, artifacts []*domai
 // GetByJobID retrieves artifacts for a job
 func (r *ArtifactRepository) GetByJobID(ctx context.Context, jobID uuid.UUID) ([]*domain.Artifact, error) {
 	query := `
-		SELECT id, job_id, type, bucket, key, size_bytes, checksum, created_at
+		SELECT id, job_id, type, bucket, key, size_bytes, checksum, tracks, created_at
 		FROM conversion_artifacts
 		WHERE job_id = $1
 		ORDER BY type, created_at
@@ -97,7 +109,10 @@ func (r *ArtifactRepository) GetByJobID(ctx context.Context, jobID uuid.UUID) ([
 
 	var artifacts []*domain.Artifact
 	for rows.Next() {
-		var artifact domain.Artifact
+		var (
+			artifact domain.Artifact
+			tracks   []byte
+		)
 		if err := rows.Scan(
 			&artifact.ID,
 			&artifact.JobID,
@@ -106,10 +121,14 @@ func (r *ArtifactRepository) GetByJobID(ctx context.Context, jobID uuid.UUID) ([
 			&artifact.Key,
 			&artifact.SizeBytes,
 			&artifact.Checksum,
+			&tracks,
 			&artifact.CreatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan artifact: %w", err)
 		}
+		if err := scanTracks(&artifact, tracks); err != nil {
+			return nil, err
+		}
 		artifacts = append(artifacts, &artifact)
 	}
 
@@ -119,7 +138,7 @@ func (r *ArtifactRepository) GetByJobID(ctx context.Context, jobID uuid.UUID) ([
 // GetByJobIDAndType retrieves artifacts by job ID and type
 func (r *ArtifactRepository) GetByJobIDAndType(ctx context.Context, jobID uuid.UUID, artifactType domain.ArtifactType) ([]*domain.Artifact, error) {
 	query := `
-		SELECT id, job_id, type, bucket, key, size_bytes, checksum, created_at
+		SELECT id, job_id, type, bucket, key, size_bytes, checksum, tracks, created_at
 		FROM conversion_artifacts
 		WHERE job_id = $1 AND type = $2
 		ORDER BY created_at
@@ -133,7 +152,10 @@ func (r *ArtifactRepository) GetByJobIDAndType(ctx context.Context, jobID uuid.U
 
 	var artifacts []*domain.Artifact
 	for rows.Next() {
-		var artifact domain.Artifact
+		var (
+			artifact domain.Artifact
+			tracks   []byte
+		)
 		if err := rows.Scan(
 			&artifact.ID,
 			&artifact.JobID,
@@ -142,10 +164,14 @@ func (r *ArtifactRepository) GetByJobIDAndType(ctx context.Context, jobID uuid.U
 			&artifact.Key,
 			&artifact.SizeBytes,
 			&artifact.Checksum,
+			&tracks,
 			&artifact.CreatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan artifact: %w", err)
 		}
+		if err := scanTracks(&artifact, tracks); err != nil {
+			return nil, err
+		}
 		artifacts = append(artifacts, &artifact)
 	}
 
@@ -186,3 +212,109 @@ func (r *ArtifactRepository) CountByType(ctx context.Context) (map[domain.Artifa
 
 	return counts, nil
 }
+
+// UsageGroup selects how storage usage is aggregated
+type UsageGroup string
+
+const (
+	UsageByJob UsageGroup = "job"
+	// UsageByVideo groups jobs of the same title; a job without videoId is its own title
+	UsageByVideo  UsageGroup = "video"
+	UsageByTenant UsageGroup = "tenant"
+)
+
+// usageKeys maps a usage group to its key expression over job_storage_usage
+var usageKeys = map[UsageGroup]string{
+	UsageByJob:    "job_id::text",
+	UsageByVideo:  "COALESCE(video_id, job_id)::text",
+	UsageByTenant: "COALESCE(tenant, '')",
+}
+
+// StorageUsage is the total artifact size of a job, title or tenant
+type StorageUsage struct {
+	Key        string `json:"key"`
+	Jobs       int    `json:"jobs"`
+	Artifacts  int    `json:"artifacts"`
+	TotalBytes int64  `json:"totalBytes"`
+}
+
+// ListUsage returns usage per group key of at least minBytes, largest first
+func (r *ArtifactRepository) ListUsage(ctx context.Context, group UsageGroup, minBytes int64, limit int) ([]*StorageUsage, error) {
+	key, ok := usageKeys[group]
+	if !ok {
+		return nil, fmt.Errorf("unknown usage group %q", group)
+	}
+
+	query := `
+		SELECT ` + key + `, COUNT(*), SUM(artifact_count), SUM(total_bytes)::BIGINT
+		FROM job_storage_usage
+		GROUP BY 1
+		HAVING SUM(total_bytes) >= $1
+		ORDER BY 4 DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, minBytes, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []*StorageUsage
+	for rows.Next() {
+		u := &StorageUsage{}
+		if err := rows.Scan(&u.Key, &u.Jobs, &u.Artifacts, &u.TotalBytes); err != nil {
+			return nil, fmt.Errorf("failed to scan storage usage: %w", err)
+		}
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list storage usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// GetUsage returns usage of a single group key; a key without artifacts has zero usage
+func (r *ArtifactRepository) GetUsage(ctx context.Context, group UsageGroup, key string) (*StorageUsage, error) {
+	expr, ok := usageKeys[group]
+	if !ok {
+		return nil, fmt.Errorf("unknown usage group %q", group)
+	}
+
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(artifact_count), 0)::BIGINT, COALESCE(SUM(total_bytes), 0)::BIGINT
+		FROM job_storage_usage
+		WHERE ` + expr + ` = $1
+	`
+
+	u := &StorageUsage{Key: key}
+	if err := r.db.Pool.QueryRow(ctx, query, key).Scan(&u.Jobs, &u.Artifacts, &u.TotalBytes); err != nil {
+		return nil, fmt.Errorf("failed to get storage usage: %w", err)
+	}
+
+	return u, nil
+}
+
+// tracksJSON encodes the tracks of an artifact, NULL for none
+func tracksJSON(tracks []domain.ArtifactTrack) ([]byte, error) {
+	if len(tracks) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(tracks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal artifact tracks: %w", err)
+	}
+	return data, nil
+}
+
+// scanTracks decodes the tracks column into the artifact
+func scanTracks(artifact *domain.Artifact, data []byte) error {
+	if data == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &artifact.Tracks); err != nil {
+		return fmt.Errorf("failed to unmarshal artifact tracks: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// JobExport is a job with its stored analysis results and artifact records, as
+// written by the export and read back by the import. JSONB columns are carried
+// verbatim so a round trip does not depend on the current domain types.
+type JobExport struct {
+	Job           *domain.Job        `json:"job"`
+	Metadata      json.RawMessage    `json:"metadata,omitempty"`
+	FFprobeOutput json.RawMessage    `json:"ffprobeOutput,omitempty"`
+	Plan          json.RawMessage    `json:"plan,omitempty"`
+	Complexity    json.RawMessage    `json:"complexity,omitempty"`
+	Warnings      json.RawMessage    `json:"warnings,omitempty"`
+	Artifacts     []*domain.Artifact `json:"artifacts"`
+}
+
+// ExportCursor is the position after the last exported job
+type ExportCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// ListExports lists jobs with the given statuses (all if empty) created after
+// the cursor, oldest first, together with their artifacts
+func (r *JobRepository) ListExports(ctx context.Context, statuses []domain.JobStatus, after *ExportCursor, limit int) ([]*JobExport, error) {
+	query := `
+		SELECT id, video_id, source_bucket, source_key, status, current_stage,
+			stage_progress, overall_progress, profile, idempotency_key,
+			workflow_id, priority, created_at, started_at, updated_at,
+			finished_at, attempt, last_error_id, lock_version, tenant, source_url,
+			cancel_reason, canceled_by, dry_run, series_id,
+			source_metadata, ffprobe_output, plan, complexity, warnings
+		FROM conversion_jobs
+		WHERE (cardinality($1::text[]) = 0 OR status = ANY($1))
+			AND ($2::timestamptz IS NULL OR (created_at, id) > ($2, $3))
+		ORDER BY created_at, id
+		LIMIT $4
+	`
+
+	statusNames := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		statusNames = append(statusNames, string(status))
+	}
+	var afterCreated *time.Time
+	var afterID uuid.UUID
+	if after != nil {
+		afterCreated, afterID = &after.CreatedAt, after.ID
+	}
+
+	rows, err := r.db.Pool.Query(ctx, query, statusNames, afterCreated, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var exports []*JobExport
+	byID := make(map[uuid.UUID]*JobExport)
+	for rows.Next() {
+		var job domain.Job
+		var profileJSON []byte
+		export := &JobExport{Job: &job, Artifacts: []*domain.Artifact{}}
+		err := rows.Scan(
+			&job.ID,
+			&job.VideoID,
+			&job.SourceBucket,
+			&job.SourceKey,
+			&job.Status,
+			&job.CurrentStage,
+			&job.StageProgress,
+			&job.OverallProgress,
+			&profileJSON,
+			&job.IdempotencyKey,
+			&job.WorkflowID,
+			&job.Priority,
+			&job.CreatedAt,
+			&job.StartedAt,
+			&job.UpdatedAt,
+			&job.FinishedAt,
+			&job.Attempt,
+			&job.LastErrorID,
+			&job.LockVersion,
+			&job.Tenant,
+			&job.SourceURL,
+			&job.CancelReason,
+			&job.CanceledBy,
+			&job.DryRun,
+			&job.SeriesID,
+			(*[]byte)(&export.Metadata),
+			(*[]byte)(&export.FFprobeOutput),
+			(*[]byte)(&export.Plan),
+			(*[]byte)(&export.Complexity),
+			(*[]byte)(&export.Warnings),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		if err := json.Unmarshal(profileJSON, &job.Profile); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal profile: %w", err)
+		}
+		exports = append(exports, export)
+		byID[job.ID] = export
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	if len(exports) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(exports))
+	for _, export := range exports {
+		ids = append(ids, export.Job.ID)
+	}
+
+	artifactRows, err := r.db.Pool.Query(ctx, `
+		SELECT id, job_id, type, bucket, key, size_bytes, checksum, tracks, created_at
+		FROM conversion_artifacts
+		WHERE job_id = ANY($1)
+		ORDER BY created_at, id
+	`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+	defer artifactRows.Close()
+
+	for artifactRows.Next() {
+		var (
+			a      domain.Artifact
+			tracks []byte
+		)
+		err := artifactRows.Scan(&a.ID, &a.JobID, &a.Type, &a.Bucket, &a.Key, &a.SizeBytes, &a.Checksum, &tracks, &a.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan artifact: %w", err)
+		}
+		if err := scanTracks(&a, tracks); err != nil {
+			return nil, err
+		}
+		byID[a.JobID].Artifacts = append(byID[a.JobID].Artifacts, &a)
+	}
+	if err := artifactRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+
+	return exports, nil
+}
+
+// Import stores an exported job with its artifacts in one transaction. It
+// returns false without changes if a job with the same ID already exists, so
+// an interrupted import can be repeated.
+func (r *JobRepository) Import(ctx context.Context, export *JobExport) (bool, error) {
+	job := export.Job
+	profileJSON, err := json.Marshal(job.Profile)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal profile: %w", err)
+	}
+	warnings := []byte(export.Warnings)
+	if len(warnings) == 0 {
+		warnings = []byte("[]")
+	}
+
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO conversion_jobs (
+			id, video_id, source_bucket, source_key, status, current_stage,
+			stage_progress, overall_progress, profile, idempotency_key,
+			workflow_id, priority, created_at, started_at, updated_at,
+			finished_at, attempt, last_error_id, tenant, source_url,
+			cancel_reason, canceled_by, dry_run, series_id,
+			source_metadata, ffprobe_output, plan, complexity, warnings
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+			$21, $22, $23, $24, $25, $26, $27, $28, $29
+		)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	result, err := tx.Exec(ctx, query,
+		job.ID,
+		job.VideoID,
+		job.SourceBucket,
+		job.SourceKey,
+		job.Status,
+		job.CurrentStage,
+		job.StageProgress,
+		job.OverallProgress,
+		profileJSON,
+		job.IdempotencyKey,
+		job.WorkflowID,
+		job.Priority,
+		job.CreatedAt,
+		job.StartedAt,
+		job.UpdatedAt,
+		job.FinishedAt,
+		job.Attempt,
+		job.LastErrorID,
+		job.Tenant,
+		job.SourceURL,
+		job.CancelReason,
+		job.CanceledBy,
+		job.DryRun,
+		job.SeriesID,
+		nullJSON(export.Metadata),
+		nullJSON(export.FFprobeOutput),
+		nullJSON(export.Plan),
+		nullJSON(export.Complexity),
+		warnings,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to import job: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	for _, a := range export.Artifacts {
+		tracks, err := tracksJSON(a.Tracks)
+		if err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO conversion_artifacts (
+				id, job_id, type, bucket, key, size_bytes, checksum, tracks, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, a.ID, job.ID, a.Type, a.Bucket, a.Key, a.SizeBytes, a.Checksum, tracks, a.CreatedAt)
+		if err != nil {
+			return false, fmt.Errorf("failed to import artifact: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// nullJSON stores an absent or JSON null value as SQL NULL
+func nullJSON(raw json.RawMessage) []byte {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	return raw
+}
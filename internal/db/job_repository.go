@@ -40,10 +40,11 @@ func (r *JobRepository) Create(ctx context.Context, job *domain.Job) error {
 			id, video_id, source_bucket, source_key, status, current_stage,
 			stage_progress, overall_progress, profile, idempotency_key,
 			workflow_id, priority, created_at, started_at, updated_at,
-			finished_at, attempt, last_error_id, lock_version
+			finished_at, attempt, last_error_id, lock_version, tenant, source_url,
+			dry_run, series_id
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
-			$11, $12, $13, $14, $15, $16, $17, $18, $19
+			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23
 		)
 	`
 
@@ -67,6 +68,10 @@ func (r *JobRepository) Create(ctx context.Context, job *domain.Job) error {
 		job.Attempt,
 		job.LastErrorID,
 		job.LockVersion,
+		job.Tenant,
+		job.SourceURL,
+		job.DryRun,
+		job.SeriesID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create job: %w", err)
@@ -81,7 +86,8 @@ func (r *JobRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Job,
 		SELECT id, video_id, source_bucket, source_key, status, current_stage,
 			stage_progress, overall_progress, profile, idempotency_key,
 			workflow_id, priority, created_at, started_at, updated_at,
-			finished_at, attempt, last_error_id, lock_version
+			finished_at, attempt, last_error_id, lock_version, tenant, source_url,
+			cancel_reason, canceled_by, dry_run, series_id
 		FROM conversion_jobs
 		WHERE id = $1
 	`
@@ -95,7 +101,8 @@ func (r *JobRepository) GetByIdempotencyKey(ctx context.Context, key string) (*d
 		SELECT id, video_id, source_bucket, source_key, status, current_stage,
 			stage_progress, overall_progress, profile, idempotency_key,
 			workflow_id, priority, created_at, started_at, updated_at,
-			finished_at, attempt, last_error_id, lock_version
+			finished_at, attempt, last_error_id, lock_version, tenant, source_url,
+			cancel_reason, canceled_by, dry_run, series_id
 		FROM conversion_jobs
 		WHERE idempotency_key = $1
 	`
@@ -192,6 +199,267 @@ func (r *JobRepository) UpdateStatus(ctx context.Context, jobID uuid.UUID, statu
 	return nil
 }
 
+// TransitionStatus changes job status only if it currently equals from.
+// It returns false if the job is in another status.
+func (r *JobRepository) TransitionStatus(ctx context.Context, jobID uuid.UUID, from, to domain.JobStatus) (bool, error) {
+	query := `UPDATE conversion_jobs SET status = $3 WHERE id = $1 AND status = $2`
+
+	result, err := r.db.Pool.Exec(ctx, query, jobID, from, to)
+	if err != nil {
+		return false, fmt.Errorf("failed to transition status: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// SetMetadata stores the parsed source metadata and the raw ffprobe output
+func (r *JobRepository) SetMetadata(ctx context.Context, jobID uuid.UUID, metadata *domain.VideoMetadata) error {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	var ffprobeJSON []byte
+	if len(metadata.Raw) > 0 {
+		ffprobeJSON = metadata.Raw
+	}
+
+	query := `UPDATE conversion_jobs SET source_metadata = $2, ffprobe_output = $3 WHERE id = $1`
+
+	_, err = r.db.Pool.Exec(ctx, query, jobID, metadataJSON, ffprobeJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set metadata: %w", err)
+	}
+
+	return nil
+}
+
+// GetMetadata retrieves stored source metadata with the raw ffprobe output.
+// It returns nil metadata if the job has not been probed yet.
+func (r *JobRepository) GetMetadata(ctx context.Context, jobID uuid.UUID) (*domain.VideoMetadata, error) {
+	query := `SELECT source_metadata, ffprobe_output FROM conversion_jobs WHERE id = $1`
+
+	var metadataJSON, ffprobeJSON []byte
+	err := r.db.Pool.QueryRow(ctx, query, jobID).Scan(&metadataJSON, &ffprobeJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get metadata: %w", err)
+	}
+
+	if metadataJSON == nil {
+		return nil, nil
+	}
+
+	var metadata domain.VideoMetadata
+	if err := json.Unmarshal(metadataJSON, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
+	}
+	metadata.Raw = ffprobeJSON
+
+	return &metadata, nil
+}
+
+// SetPlan stores the plan computed by a dry run
+func (r *JobRepository) SetPlan(ctx context.Context, jobID uuid.UUID, plan *domain.JobPlan) error {
+	planJSON, err := json.Marshal(plan)
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan: %w", err)
+	}
+
+	query := `UPDATE conversion_jobs SET plan = $2 WHERE id = $1`
+
+	_, err = r.db.Pool.Exec(ctx, query, jobID, planJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set plan: %w", err)
+	}
+
+	return nil
+}
+
+// GetPlan retrieves the plan of a dry run. It returns nil if none was stored yet.
+func (r *JobRepository) GetPlan(ctx context.Context, jobID uuid.UUID) (*domain.JobPlan, error) {
+	query := `SELECT plan FROM conversion_jobs WHERE id = $1`
+
+	var planJSON []byte
+	err := r.db.Pool.QueryRow(ctx, query, jobID).Scan(&planJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	if planJSON == nil {
+		return nil, nil
+	}
+
+	var plan domain.JobPlan
+	if err := json.Unmarshal(planJSON, &plan); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal plan: %w", err)
+	}
+
+	return &plan, nil
+}
+
+// SetComplexity stores the per-title analysis of a job together with the
+// profile whose ladder was scaled by it
+func (r *JobRepository) SetComplexity(ctx context.Context, jobID uuid.UUID, analysis *domain.ComplexityAnalysis, profile domain.Profile) error {
+	analysisJSON, err := json.Marshal(analysis)
+	if err != nil {
+		return fmt.Errorf("failed to marshal complexity: %w", err)
+	}
+	profileJSON, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile: %w", err)
+	}
+
+	query := `UPDATE conversion_jobs SET complexity = $2, profile = $3, updated_at = NOW() WHERE id = $1`
+
+	_, err = r.db.Pool.Exec(ctx, query, jobID, analysisJSON, profileJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set complexity: %w", err)
+	}
+
+	return nil
+}
+
+// SetCrop stores the metadata of a cropped source together with the profile
+// whose ladder was fitted to the crop, so a retried DetectCrop finds both
+func (r *JobRepository) SetCrop(ctx context.Context, jobID uuid.UUID, metadata *domain.VideoMetadata, profile domain.Profile) error {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	profileJSON, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile: %w", err)
+	}
+
+	query := `UPDATE conversion_jobs SET source_metadata = $2, profile = $3, updated_at = NOW() WHERE id = $1`
+
+	_, err = r.db.Pool.Exec(ctx, query, jobID, metadataJSON, profileJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set crop: %w", err)
+	}
+
+	return nil
+}
+
+// GetComplexity retrieves the per-title analysis of a job. It returns nil if none was stored yet.
+func (r *JobRepository) GetComplexity(ctx context.Context, jobID uuid.UUID) (*domain.ComplexityAnalysis, error) {
+	query := `SELECT complexity FROM conversion_jobs WHERE id = $1`
+
+	var analysisJSON []byte
+	err := r.db.Pool.QueryRow(ctx, query, jobID).Scan(&analysisJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get complexity: %w", err)
+	}
+
+	if analysisJSON == nil {
+		return nil, nil
+	}
+
+	var analysis domain.ComplexityAnalysis
+	if err := json.Unmarshal(analysisJSON, &analysis); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal complexity: %w", err)
+	}
+
+	return &analysis, nil
+}
+
+// AddWarning appends a warning to the job. A warning with the same code and
+// message is stored once, so retried activities do not repeat it.
+func (r *JobRepository) AddWarning(ctx context.Context, jobID uuid.UUID, warning domain.JobWarning) error {
+	warningJSON, err := json.Marshal([]domain.JobWarning{warning})
+	if err != nil {
+		return fmt.Errorf("failed to marshal warning: %w", err)
+	}
+	keyJSON, err := json.Marshal([]map[string]string{{"code": warning.Code, "message": warning.Message}})
+	if err != nil {
+		return fmt.Errorf("failed to marshal warning: %w", err)
+	}
+
+	query := `
+		UPDATE conversion_jobs SET warnings = warnings || $2::jsonb
+		WHERE id = $1 AND NOT warnings @> $3::jsonb
+	`
+
+	_, err = r.db.Pool.Exec(ctx, query, jobID, warningJSON, keyJSON)
+	if err != nil {
+		return fmt.Errorf("failed to add warning: %w", err)
+	}
+
+	return nil
+}
+
+// GetWarnings retrieves the warnings of a job in the order they were added
+func (r *JobRepository) GetWarnings(ctx context.Context, jobID uuid.UUID) ([]domain.JobWarning, error) {
+	query := `SELECT warnings FROM conversion_jobs WHERE id = $1`
+
+	var warningsJSON []byte
+	err := r.db.Pool.QueryRow(ctx, query, jobID).Scan(&warningsJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get warnings: %w", err)
+	}
+
+	var warnings []domain.JobWarning
+	if err := json.Unmarshal(warningsJSON, &warnings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal warnings: %w", err)
+	}
+
+	return warnings, nil
+}
+
+// SetCancelInfo records why and by whom a job was canceled
+func (r *JobRepository) SetCancelInfo(ctx context.Context, jobID uuid.UUID, reason, actor *string) error {
+	query := `UPDATE conversion_jobs SET cancel_reason = $2, canceled_by = $3 WHERE id = $1`
+
+	_, err := r.db.Pool.Exec(ctx, query, jobID, reason, actor)
+	if err != nil {
+		return fmt.Errorf("failed to set cancel info: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePriority updates job priority
+func (r *JobRepository) UpdatePriority(ctx context.Context, jobID uuid.UUID, priority int) error {
+	query := `UPDATE conversion_jobs SET priority = $2 WHERE id = $1`
+
+	result, err := r.db.Pool.Exec(ctx, query, jobID, priority)
+	if err != nil {
+		return fmt.Errorf("failed to update priority: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete deletes a job; errors and artifacts are removed by cascade
+func (r *JobRepository) Delete(ctx context.Context, jobID uuid.UUID) error {
+	query := `DELETE FROM conversion_jobs WHERE id = $1`
+
+	result, err := r.db.Pool.Exec(ctx, query, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to delete job: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
 // SetStarted marks job as started
 func (r *JobRepository) SetStarted(ctx context.Context, jobID uuid.UUID) error {
 	query := `
@@ -209,13 +477,63 @@ func (r *JobRepository) SetStarted(ctx context.Context, jobID uuid.UUID) error {
 	return nil
 }
 
+// StartForTenant marks a queued job RUNNING if fewer than limit other jobs of its
+// tenant are RUNNING. Starts of one tenant are serialized by a transaction lock,
+// so concurrent workflows cannot exceed the limit together. It returns true if
+// the job was started, including by an earlier call.
+func (r *JobRepository) StartForTenant(ctx context.Context, jobID uuid.UUID, tenant string, limit int) (bool, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('tenant_jobs:' || $1))`, tenant); err != nil {
+		return false, fmt.Errorf("failed to lock tenant jobs: %w", err)
+	}
+
+	var status domain.JobStatus
+	if err := tx.QueryRow(ctx, `SELECT status FROM conversion_jobs WHERE id = $1`, jobID).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrNotFound
+		}
+		return false, fmt.Errorf("failed to get job status: %w", err)
+	}
+	if status != domain.JobStatusQueued {
+		return true, nil
+	}
+
+	var running int
+	err = tx.QueryRow(ctx, `
+		SELECT COUNT(*) FROM conversion_jobs WHERE tenant = $1 AND status = $2
+	`, tenant, domain.JobStatusRunning).Scan(&running)
+	if err != nil {
+		return false, fmt.Errorf("failed to count running jobs: %w", err)
+	}
+	if running >= limit {
+		return false, nil
+	}
+
+	query := `UPDATE conversion_jobs SET status = $2, updated_at = $3 WHERE id = $1`
+	if _, err := tx.Exec(ctx, query, jobID, domain.JobStatusRunning, time.Now().UTC()); err != nil {
+		return false, fmt.Errorf("failed to start job: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
 // SetFinished marks job as finished
 func (r *JobRepository) SetFinished(ctx context.Context, jobID uuid.UUID, status domain.JobStatus) error {
 	query := `
 		UPDATE conversion_jobs SET
 			status = $2,
 			finished_at = $3,
-			overall_progress = CASE WHEN $2 = 'COMPLETED' THEN 100 ELSE overall_progress END
+			overall_progress = CASE WHEN $2 = 'COMPLETED' THEN 100 ELSE overall_progress END,
+			disk_reserved_bytes = 0
 		WHERE id = $1
 	`
 
@@ -227,6 +545,103 @@ func (r *JobRepository) SetFinished(ctx context.Context, jobID uuid.UUID, status
 	return nil
 }
 
+// FinishIfStatus marks job as finished only if it currently has status from.
+// It returns false if the job is in another status.
+func (r *JobRepository) FinishIfStatus(ctx context.Context, jobID uuid.UUID, from, to domain.JobStatus) (bool, error) {
+	query := `
+		UPDATE conversion_jobs SET
+			status = $3,
+			finished_at = $4,
+			disk_reserved_bytes = 0
+		WHERE id = $1 AND status = $2
+	`
+
+	result, err := r.db.Pool.Exec(ctx, query, jobID, from, to, time.Now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("failed to set finished: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// ListDiskReservations returns workdir space reserved by jobs on a worker host,
+// keyed by job ID
+func (r *JobRepository) ListDiskReservations(ctx context.Context, host string) (map[uuid.UUID]int64, error) {
+	query := `SELECT id, disk_reserved_bytes FROM conversion_jobs WHERE disk_reserved_bytes > 0 AND disk_reserved_by = $1`
+
+	rows, err := r.db.Pool.Query(ctx, query, host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disk reservations: %w", err)
+	}
+	defer rows.Close()
+
+	reservations := make(map[uuid.UUID]int64)
+	for rows.Next() {
+		var id uuid.UUID
+		var bytes int64
+		if err := rows.Scan(&id, &bytes); err != nil {
+			return nil, fmt.Errorf("failed to scan disk reservation: %w", err)
+		}
+		reservations[id] = bytes
+	}
+
+	return reservations, rows.Err()
+}
+
+// ReserveDisk reserves workdir space on a worker host for a job if all
+// reservations on that host together stay within budget. Reservations of a host
+// are serialized with an advisory lock so concurrent admissions cannot both
+// take its last free space.
+func (r *JobRepository) ReserveDisk(ctx context.Context, jobID uuid.UUID, host string, bytes, budget int64) (bool, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('conversion_jobs.disk_reserved_bytes:' || $1))`, host); err != nil {
+		return false, fmt.Errorf("failed to lock disk reservations: %w", err)
+	}
+
+	var reserved int64
+	err = tx.QueryRow(ctx,
+		`SELECT COALESCE(SUM(disk_reserved_bytes), 0) FROM conversion_jobs
+		WHERE disk_reserved_bytes > 0 AND disk_reserved_by = $2 AND id <> $1`,
+		jobID, host,
+	).Scan(&reserved)
+	if err != nil {
+		return false, fmt.Errorf("failed to sum disk reservations: %w", err)
+	}
+	if reserved+bytes > budget {
+		return false, nil
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE conversion_jobs SET disk_reserved_bytes = $2, disk_reserved_by = $3 WHERE id = $1`,
+		jobID, bytes, host,
+	); err != nil {
+		return false, fmt.Errorf("failed to reserve disk: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// ReleaseDisk drops the workdir space reservation of a job
+func (r *JobRepository) ReleaseDisk(ctx context.Context, jobID uuid.UUID) error {
+	query := `UPDATE conversion_jobs SET disk_reserved_bytes = 0 WHERE id = $1 AND disk_reserved_bytes > 0`
+
+	_, err := r.db.Pool.Exec(ctx, query, jobID)
+	if err != nil {
+		return fmt.Errorf("failed to release disk: %w", err)
+	}
+
+	return nil
+}
+
 // SetWorkflowID sets the Temporal workflow ID
 func (r *JobRepository) SetWorkflowID(ctx context.Context, jobID uuid.UUID, workflowID string) error {
 	query := `UPDATE conversion_jobs SET workflow_id = $2 WHERE id = $1`
@@ -239,13 +654,164 @@ func (r *JobRepository) SetWorkflowID(ctx context.Context, jobID uuid.UUID, work
 	return nil
 }
 
+// RequeueQueued calls requeue with the row of a QUEUED job locked, so its
+// workflow cannot mark the job RUNNING until requeue has moved it to another
+// task queue. It returns false without calling requeue if the job has left
+// the queue.
+func (r *JobRepository) RequeueQueued(ctx context.Context, jobID uuid.UUID, requeue func(ctx context.Context) error) (bool, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status domain.JobStatus
+	if err := tx.QueryRow(ctx, `SELECT status FROM conversion_jobs WHERE id = $1 FOR UPDATE`, jobID).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrNotFound
+		}
+		return false, fmt.Errorf("failed to lock job: %w", err)
+	}
+	if status != domain.JobStatusQueued {
+		return false, nil
+	}
+
+	if err := requeue(ctx); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// ClaimForDispatch atomically assigns a workflow ID to a queued job that has not been dispatched yet.
+// Series workflows claim their jobs this way right before starting them.
+// It returns false if another dispatcher claimed the job first or the job left the queue.
+func (r *JobRepository) ClaimForDispatch(ctx context.Context, jobID uuid.UUID, workflowID string) (bool, error) {
+	query := `
+		UPDATE conversion_jobs SET workflow_id = $2
+		WHERE id = $1 AND status = $3 AND workflow_id IS NULL
+	`
+
+	result, err := r.db.Pool.Exec(ctx, query, jobID, workflowID, domain.JobStatusQueued)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// ClaimSeriesJob assigns the workflow ID to a queued job of a series right before
+// its series workflow starts it. Jobs of series created before series jobs were
+// claimed already have that ID. It returns false if the job left the queue.
+func (r *JobRepository) ClaimSeriesJob(ctx context.Context, jobID uuid.UUID, workflowID string) (bool, error) {
+	query := `
+		UPDATE conversion_jobs SET workflow_id = $2
+		WHERE id = $1 AND status = $3 AND (workflow_id IS NULL OR workflow_id = $2)
+	`
+
+	result, err := r.db.Pool.Exec(ctx, query, jobID, workflowID, domain.JobStatusQueued)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim series job: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// ReleaseDispatch returns a claimed job to the dispatch queue after its workflow failed to start
+func (r *JobRepository) ReleaseDispatch(ctx context.Context, jobID uuid.UUID) error {
+	query := `UPDATE conversion_jobs SET workflow_id = NULL WHERE id = $1 AND status = $2`
+
+	_, err := r.db.Pool.Exec(ctx, query, jobID, domain.JobStatusQueued)
+	if err != nil {
+		return fmt.Errorf("failed to release job: %w", err)
+	}
+
+	return nil
+}
+
+// CountInFlight counts jobs that have a workflow and have not finished yet
+func (r *JobRepository) CountInFlight(ctx context.Context) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM conversion_jobs
+		WHERE workflow_id IS NOT NULL AND status IN ($1, $2)
+	`
+
+	var count int
+	if err := r.db.Pool.QueryRow(ctx, query, domain.JobStatusQueued, domain.JobStatusRunning).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count in-flight jobs: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListDispatchable lists queued jobs without a workflow in fair order. Jobs of a
+// series are left to their series workflow.
+// Jobs are grouped by tenant (falling back to video ID, then to the job itself) and
+// interleaved round-robin: every group's first job comes before any group's second one.
+// Within a group jobs go by priority, then first in, first out; across groups of a
+// round, higher priority and older jobs go first.
+// With tenantLimit above 0, a tenant gets no more jobs than it has slots left
+// below tenantLimit in flight, so dispatched jobs do not sit waiting for tenant
+// quota while they count against the global in-flight limit. Jobs without a
+// tenant and dry runs are not limited, as in AcquireTenantSlot.
+func (r *JobRepository) ListDispatchable(ctx context.Context, limit, tenantLimit int) ([]*domain.Job, error) {
+	query := `
+		SELECT id, video_id, source_bucket, source_key, status, current_stage,
+			stage_progress, overall_progress, profile, idempotency_key,
+			workflow_id, priority, created_at, started_at, updated_at,
+			finished_at, attempt, last_error_id, lock_version, tenant, source_url,
+			cancel_reason, canceled_by, dry_run, series_id
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (
+				PARTITION BY COALESCE(tenant, video_id::text, id::text)
+				ORDER BY priority DESC, created_at ASC, id ASC
+			) AS round
+			FROM conversion_jobs
+			WHERE status = $1 AND workflow_id IS NULL AND series_id IS NULL
+		) queued
+		LEFT JOIN (
+			SELECT tenant, COUNT(*) AS in_flight
+			FROM conversion_jobs
+			WHERE tenant IS NOT NULL AND workflow_id IS NOT NULL AND NOT dry_run
+				AND status IN ($1, $4)
+			GROUP BY tenant
+		) tenants USING (tenant)
+		WHERE $3 <= 0 OR tenant IS NULL OR dry_run
+			OR round + COALESCE(in_flight, 0) <= $3
+		ORDER BY round ASC, priority DESC, created_at ASC, id ASC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, domain.JobStatusQueued, limit, tenantLimit, domain.JobStatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dispatchable jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*domain.Job
+	for rows.Next() {
+		job, err := r.scanJobFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
 // ListByStatus lists jobs by status
 func (r *JobRepository) ListByStatus(ctx context.Context, status domain.JobStatus, limit int) ([]*domain.Job, error) {
 	query := `
 		SELECT id, video_id, source_bucket, source_key, status, current_stage,
 			stage_progress, overall_progress, profile, idempotency_key,
 			workflow_id, priority, created_at, started_at, updated_at,
-			finished_at, attempt, last_error_id, lock_version
+			finished_at, attempt, last_error_id, lock_version, tenant, source_url,
+			cancel_reason, canceled_by, dry_run, series_id
 		FROM conversion_jobs
 		WHERE status = $1
 		ORDER BY priority DESC, created_at ASC
@@ -270,6 +836,66 @@ func (r *JobRepository) ListByStatus(ctx context.Context, status domain.JobStatu
 	return jobs, nil
 }
 
+// JobFilter selects jobs for bulk operations. Empty fields match all jobs.
+type JobFilter struct {
+	Statuses      []domain.JobStatus
+	CreatedBefore *time.Time
+	UpdatedBefore *time.Time
+	VideoIDPrefix string
+	Tenant        string
+	SeriesID      *uuid.UUID
+	IDs           []uuid.UUID
+}
+
+// ListByFilter lists jobs matching the filter, oldest first
+func (r *JobRepository) ListByFilter(ctx context.Context, filter JobFilter, limit int) ([]*domain.Job, error) {
+	query := `
+		SELECT id, video_id, source_bucket, source_key, status, current_stage,
+			stage_progress, overall_progress, profile, idempotency_key,
+			workflow_id, priority, created_at, started_at, updated_at,
+			finished_at, attempt, last_error_id, lock_version, tenant, source_url,
+			cancel_reason, canceled_by, dry_run, series_id
+		FROM conversion_jobs
+		WHERE (cardinality($1::text[]) = 0 OR status = ANY($1))
+			AND ($2::timestamptz IS NULL OR created_at < $2)
+			AND ($3 = '' OR video_id::text LIKE $3 || '%')
+			AND ($4::timestamptz IS NULL OR updated_at < $4)
+			AND ($6 = '' OR tenant = $6)
+			AND ($7::uuid IS NULL OR series_id = $7)
+			AND (cardinality($8::uuid[]) = 0 OR id = ANY($8))
+		ORDER BY created_at ASC
+		LIMIT $5
+	`
+
+	statuses := make([]string, 0, len(filter.Statuses))
+	for _, status := range filter.Statuses {
+		statuses = append(statuses, string(status))
+	}
+
+	ids := filter.IDs
+	if ids == nil {
+		ids = []uuid.UUID{}
+	}
+
+	rows, err := r.db.Pool.Query(ctx, query, statuses, filter.CreatedBefore, filter.VideoIDPrefix, filter.UpdatedBefore, limit,
+		filter.Tenant, filter.SeriesID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*domain.Job
+	for rows.Next() {
+		job, err := r.scanJobFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
 // CountByStatus counts jobs by status
 func (r *JobRepository) CountByStatus(ctx context.Context) (map[domain.JobStatus]int, error) {
 	query := `SELECT status, COUNT(*) FROM conversion_jobs GROUP BY status`
@@ -317,6 +943,12 @@ func (r *JobRepository) scanJob(row pgx.Row) (*domain.Job, error) {
 		&job.Attempt,
 		&job.LastErrorID,
 		&job.LockVersion,
+		&job.Tenant,
+		&job.SourceURL,
+		&job.CancelReason,
+		&job.CanceledBy,
+		&job.DryRun,
+		&job.SeriesID,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -356,6 +988,12 @@ func (r *JobRepository) scanJobFromRows(rows pgx.Rows) (*domain.Job, error) {
 		&job.Attempt,
 		&job.LastErrorID,
 		&job.LockVersion,
+		&job.Tenant,
+		&job.SourceURL,
+		&job.CancelReason,
+		&job.CanceledBy,
+		&job.DryRun,
+		&job.SeriesID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan job: %w", err)
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Lock is a Postgres session-level advisory lock. It is held by a dedicated
+// pooled connection, so a process that dies without releasing it loses the
+// lock as soon as its connection closes.
+type Lock struct {
+	conn *pgxpool.Conn
+	key  int64
+}
+
+// lockKey maps a lock name to an advisory lock key
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("converter:" + name))
+	return int64(h.Sum64())
+}
+
+// TryLock acquires the advisory lock named name without waiting. It returns
+// nil if another session holds the lock.
+func (db *DB) TryLock(ctx context.Context, name string) (*Lock, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	key := lockKey(name)
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("failed to take lock %s: %w", name, err)
+	}
+	if !locked {
+		conn.Release()
+		return nil, nil
+	}
+
+	return &Lock{conn: conn, key: key}, nil
+}
+
+// Release unlocks the lock and returns its connection to the pool. If unlocking
+// fails the connection is closed instead, which releases the lock as well.
+func (l *Lock) Release(ctx context.Context) error {
+	var unlocked bool
+	err := l.conn.QueryRow(ctx, `SELECT pg_advisory_unlock($1)`, l.key).Scan(&unlocked)
+	if err != nil || !unlocked {
+		conn := l.conn.Hijack()
+		conn.Close(context.Background())
+		if err != nil {
+			return fmt.Errorf("failed to release lock: %w", err)
+		}
+		return nil
+	}
+	l.conn.Release()
+	return nil
+}
+
+// WithLock runs fn while holding the advisory lock named name, so it runs in
+// one process of the fleet at a time. It returns false without running fn if
+// another process holds the lock.
+func (db *DB) WithLock(ctx context.Context, name string, fn func(ctx context.Context) error) (bool, error) {
+	lock, err := db.TryLock(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	if lock == nil {
+		return false, nil
+	}
+	// Release even when ctx is canceled, or the lock stays held until the connection is recycled
+	defer lock.Release(context.WithoutCancel(ctx))
+
+	return true, fn(ctx)
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// ErrAlreadyExists is returned when a unique constraint is violated
+var ErrAlreadyExists = errors.New("already exists")
+
+// pgUniqueViolation is the PostgreSQL error code for unique constraint violations
+const pgUniqueViolation = "23505"
+
+// ProfileRepository handles profile template persistence
+type ProfileRepository struct {
+	db *DB
+}
+
+// NewProfileRepository creates a new profile repository
+func NewProfileRepository(db *DB) *ProfileRepository {
+	return &ProfileRepository{db: db}
+}
+
+// Create creates a new profile template
+func (r *ProfileRepository) Create(ctx context.Context, tmpl *domain.ProfileTemplate) error {
+	profileJSON, err := json.Marshal(tmpl.Profile)
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile: %w", err)
+	}
+
+	query := `
+		INSERT INTO conversion_profiles (
+			id, name, description, profile, version, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err = r.db.Pool.Exec(ctx, query,
+		tmpl.ID,
+		tmpl.Name,
+		tmpl.Description,
+		profileJSON,
+		tmpl.Version,
+		tmpl.CreatedAt,
+		tmpl.UpdatedAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+		return fmt.Errorf("failed to create profile: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a profile template by ID
+func (r *ProfileRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.ProfileTemplate, error) {
+	query := `
+		SELECT id, name, description, profile, version, created_at, updated_at
+		FROM conversion_profiles
+		WHERE id = $1
+	`
+
+	return r.scanProfile(r.db.Pool.QueryRow(ctx, query, id))
+}
+
+// GetByName retrieves a profile template by name
+func (r *ProfileRepository) GetByName(ctx context.Context, name string) (*domain.ProfileTemplate, error) {
+	query := `
+		SELECT id, name, description, profile, version, created_at, updated_at
+		FROM conversion_profiles
+		WHERE name = $1
+	`
+
+	return r.scanProfile(r.db.Pool.QueryRow(ctx, query, name))
+}
+
+// List lists all profile templates ordered by name
+func (r *ProfileRepository) List(ctx context.Context) ([]*domain.ProfileTemplate, error) {
+	query := `
+		SELECT id, name, description, profile, version, created_at, updated_at
+		FROM conversion_profiles
+		ORDER BY name ASC
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []*domain.ProfileTemplate
+	for rows.Next() {
+		tmpl, err := r.scanProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, tmpl)
+	}
+
+	return profiles, rows.Err()
+}
+
+// Update replaces a profile template and bumps its version.
+// If expectedVersion is non-zero the update only succeeds when it matches the stored version.
+func (r *ProfileRepository) Update(ctx context.Context, tmpl *domain.ProfileTemplate, expectedVersion int) error {
+	profileJSON, err := json.Marshal(tmpl.Profile)
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile: %w", err)
+	}
+
+	query := `
+		UPDATE conversion_profiles SET
+			name = $2,
+			description = $3,
+			profile = $4,
+			version = version + 1
+		WHERE id = $1 AND ($5 = 0 OR version = $5)
+		RETURNING version, updated_at
+	`
+
+	err = r.db.Pool.QueryRow(ctx, query,
+		tmpl.ID,
+		tmpl.Name,
+		tmpl.Description,
+		profileJSON,
+		expectedVersion,
+	).Scan(&tmpl.Version, &tmpl.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if expectedVersion != 0 {
+				if _, getErr := r.GetByID(ctx, tmpl.ID); getErr == nil {
+					return ErrConcurrentModification
+				}
+			}
+			return ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+		return fmt.Errorf("failed to update profile: %w", err)
+	}
+
+	return nil
+}
+
+// Delete deletes a profile template
+func (r *ProfileRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `DELETE FROM conversion_profiles WHERE id = $1`
+
+	result, err := r.db.Pool.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete profile: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ProfileRepository) scanProfile(row pgx.Row) (*domain.ProfileTemplate, error) {
+	var tmpl domain.ProfileTemplate
+	var profileJSON []byte
+
+	err := row.Scan(
+		&tmpl.ID,
+		&tmpl.Name,
+		&tmpl.Description,
+		&profileJSON,
+		&tmpl.Version,
+		&tmpl.CreatedAt,
+		&tmpl.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan profile: %w", err)
+	}
+
+	if err := json.Unmarshal(profileJSON, &tmpl.Profile); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal profile: %w", err)
+	}
+
+	return &tmpl, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
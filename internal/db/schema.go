@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// SchemaVersion is the migration level this build expects: the number of the
+// latest file in migrations/. Bump it together with every new migration.
+const SchemaVersion = 16
+
+// ErrSchemaMismatch is returned when the database schema does not match SchemaVersion
+var ErrSchemaMismatch = errors.New("database schema mismatch")
+
+// SchemaStatus is the migration state recorded by golang-migrate
+type SchemaStatus struct {
+	Version  int64 `json:"version"`
+	Expected int64 `json:"expected"`
+	Dirty    bool  `json:"dirty"`
+}
+
+// SchemaStatus reads the applied migration level from the schema_migrations table
+func (db *DB) SchemaStatus(ctx context.Context) (*SchemaStatus, error) {
+	status := &SchemaStatus{Expected: SchemaVersion}
+
+	var table *string
+	if err := db.Pool.QueryRow(ctx, `SELECT to_regclass('schema_migrations')::text`).Scan(&table); err != nil {
+		return nil, fmt.Errorf("failed to look up schema_migrations: %w", err)
+	}
+	if table == nil {
+		return status, nil
+	}
+
+	err := db.Pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&status.Version, &status.Dirty)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return status, nil
+}
+
+// Check returns ErrSchemaMismatch when migrations are missing or a migration
+// failed halfway. A newer schema is accepted: migrations are applied before
+// binaries are rolled out, and old instances keep serving until replaced.
+func (s *SchemaStatus) Check() error {
+	switch {
+	case s.Dirty:
+		return fmt.Errorf("%w: migration %d is dirty, fix it and force the version", ErrSchemaMismatch, s.Version)
+	case s.Version == 0:
+		return fmt.Errorf("%w: no migrations applied, expected version %d", ErrSchemaMismatch, s.Expected)
+	case s.Version < s.Expected:
+		return fmt.Errorf("%w: version %d, expected %d", ErrSchemaMismatch, s.Version, s.Expected)
+	}
+	return nil
+}
+
+// CheckSchema verifies that the database is migrated to at least SchemaVersion
+func (db *DB) CheckSchema(ctx context.Context) (*SchemaStatus, error) {
+	status, err := db.SchemaStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return status, status.Check()
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// StageRepository handles job stage timing persistence
+type StageRepository struct {
+	db *DB
+}
+
+// NewStageRepository creates a new stage repository
+func NewStageRepository(db *DB) *StageRepository {
+	return &StageRepository{db: db}
+}
+
+// Start records the start of a stage attempt
+func (r *StageRepository) Start(ctx context.Context, timing *domain.StageTiming) error {
+	query := `
+		INSERT INTO job_stages (id, job_id, stage, attempt, status, started_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := r.db.Pool.Exec(ctx, query,
+		timing.ID,
+		timing.JobID,
+		timing.Stage,
+		timing.Attempt,
+		timing.Status,
+		timing.StartedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start stage: %w", err)
+	}
+
+	return nil
+}
+
+// Finish records the end of a stage attempt
+func (r *StageRepository) Finish(ctx context.Context, id uuid.UUID, status domain.StageStatus) error {
+	query := `UPDATE job_stages SET status = $2, finished_at = NOW() WHERE id = $1`
+
+	result, err := r.db.Pool.Exec(ctx, query, id, status)
+	if err != nil {
+		return fmt.Errorf("failed to finish stage: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetByJobID retrieves stage timings for a job in execution order
+func (r *StageRepository) GetByJobID(ctx context.Context, jobID uuid.UUID) ([]*domain.StageTiming, error) {
+	query := `
+		SELECT id, job_id, stage, attempt, status, started_at, finished_at
+		FROM job_stages
+		WHERE job_id = $1
+		ORDER BY started_at ASC
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stages: %w", err)
+	}
+	defer rows.Close()
+
+	var stages []*domain.StageTiming
+	for rows.Next() {
+		var timing domain.StageTiming
+		if err := rows.Scan(
+			&timing.ID,
+			&timing.JobID,
+			&timing.Stage,
+			&timing.Attempt,
+			&timing.Status,
+			&timing.StartedAt,
+			&timing.FinishedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan stage: %w", err)
+		}
+		stages = append(stages, &timing)
+	}
+
+	return stages, rows.Err()
+}
+
+// TranscodeSpeed returns the transcoding wall time per second of source, over the
+// last limit completed transcodes of sources with a known duration. It returns 0
+// if there is no such transcode.
+func (r *StageRepository) TranscodeSpeed(ctx context.Context, limit int) (float64, error) {
+	query := `
+		SELECT COALESCE(
+			SUM(EXTRACT(EPOCH FROM recent.finished_at - recent.started_at)) /
+				NULLIF(SUM(recent.duration_ns / 1e9), 0),
+			0)
+		FROM (
+			SELECT s.started_at, s.finished_at,
+				(j.source_metadata->>'duration')::double precision AS duration_ns
+			FROM job_stages s
+			JOIN conversion_jobs j ON j.id = s.job_id
+			WHERE s.stage = $1 AND s.status = $2 AND s.finished_at IS NOT NULL
+				AND (j.source_metadata->>'duration')::double precision > 0
+			ORDER BY s.finished_at DESC
+			LIMIT $3
+		) recent
+	`
+
+	var speed float64
+	err := r.db.Pool.QueryRow(ctx, query, domain.StageTranscoding, domain.StageStatusCompleted, limit).Scan(&speed)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get transcode speed: %w", err)
+	}
+
+	return speed, nil
+}
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// VersionRepository handles the publication history of videos
+type VersionRepository struct {
+	db *DB
+}
+
+// NewVersionRepository creates a new version repository
+func NewVersionRepository(db *DB) *VersionRepository {
+	return &VersionRepository{db: db}
+}
+
+// Publish appends a version to the history of its video and sets v.Version.
+// Publications of one video are serialized with an advisory lock. It returns
+// false without publishing if the job is already live, or, with onlyNewer, if
+// the live job was created after it, so an older job finishing late does not
+// replace the output of a newer one.
+func (r *VersionRepository) Publish(ctx context.Context, v *domain.PublishedVersion, onlyNewer bool) (bool, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('published_versions:' || $1::text))`, v.VideoID); err != nil {
+		return false, fmt.Errorf("failed to lock video versions: %w", err)
+	}
+
+	var skip bool
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(p.job_id = $2 OR ($3 AND cur.created_at > j.created_at), false)
+		FROM published_versions p
+		LEFT JOIN conversion_jobs cur ON cur.id = p.job_id
+		LEFT JOIN conversion_jobs j ON j.id = $2
+		WHERE p.video_id = $1
+		ORDER BY p.version DESC
+		LIMIT 1
+	`, v.VideoID, v.JobID, onlyNewer).Scan(&skip)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("failed to check current version: %w", err)
+	}
+	if skip {
+		return false, nil
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO published_versions (id, video_id, version, job_id, action, created_at)
+		SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3, $4, $5
+		FROM published_versions
+		WHERE video_id = $2
+		RETURNING version
+	`, v.ID, v.VideoID, v.JobID, v.Action, v.CreatedAt).Scan(&v.Version)
+	if err != nil {
+		return false, fmt.Errorf("failed to publish version: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetCurrent retrieves the live version of a video
+func (r *VersionRepository) GetCurrent(ctx context.Context, videoID uuid.UUID) (*domain.PublishedVersion, error) {
+	query := `
+		SELECT id, video_id, version, job_id, action, created_at
+		FROM published_versions
+		WHERE video_id = $1
+		ORDER BY version DESC
+		LIMIT 1
+	`
+
+	v, err := scanVersion(r.db.Pool.QueryRow(ctx, query, videoID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get current version: %w", err)
+	}
+
+	return v, nil
+}
+
+// GetPrevious retrieves the newest version before the live one whose job is a
+// different one that still has its artifacts, the target of a rollback
+func (r *VersionRepository) GetPrevious(ctx context.Context, videoID uuid.UUID) (*domain.PublishedVersion, error) {
+	query := `
+		SELECT p.id, p.video_id, p.version, p.job_id, p.action, p.created_at
+		FROM published_versions p
+		WHERE p.video_id = $1
+			AND p.job_id IS DISTINCT FROM (
+				SELECT job_id FROM published_versions
+				WHERE video_id = $1
+				ORDER BY version DESC
+				LIMIT 1
+			)
+			AND EXISTS (SELECT 1 FROM conversion_artifacts a WHERE a.job_id = p.job_id)
+		ORDER BY p.version DESC
+		LIMIT 1
+	`
+
+	v, err := scanVersion(r.db.Pool.QueryRow(ctx, query, videoID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get previous version: %w", err)
+	}
+
+	return v, nil
+}
+
+// ListByVideoID retrieves the publication history of a video, newest first
+func (r *VersionRepository) ListByVideoID(ctx context.Context, videoID uuid.UUID) ([]*domain.PublishedVersion, error) {
+	query := `
+		SELECT id, video_id, version, job_id, action, created_at
+		FROM published_versions
+		WHERE video_id = $1
+		ORDER BY version DESC
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, videoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []*domain.PublishedVersion
+	for rows.Next() {
+		v, err := scanVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+
+	return versions, rows.Err()
+}
+
+// IsCurrent reports whether a job is the live version of its video
+func (r *VersionRepository) IsCurrent(ctx context.Context, jobID uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM published_versions p
+			WHERE p.job_id = $1
+				AND p.version = (SELECT MAX(version) FROM published_versions WHERE video_id = p.video_id)
+		)
+	`
+
+	var current bool
+	if err := r.db.Pool.QueryRow(ctx, query, jobID).Scan(&current); err != nil {
+		return false, fmt.Errorf("failed to check current version: %w", err)
+	}
+
+	return current, nil
+}
+
+// scanVersion scans a published_versions row
+func scanVersion(row pgx.Row) (*domain.PublishedVersion, error) {
+	var v domain.PublishedVersion
+	if err := row.Scan(
+		&v.ID,
+		&v.VideoID,
+		&v.Version,
+		&v.JobID,
+		&v.Action,
+		&v.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
@@ -12,12 +12,22 @@ type ArtifactType string
 const (
 	ArtifactTypeHLSMaster    ArtifactType = "HLS_MASTER"
 	ArtifactTypeHLSVariant   ArtifactType = "HLS_VARIANT"
+	ArtifactTypeHLSIFrames   ArtifactType = "HLS_IFRAMES"
 	ArtifactTypeDASHManifest ArtifactType = "DASH_MANIFEST"
 	ArtifactTypeSegment      ArtifactType = "SEGMENT"
 	ArtifactTypeSubtitle     ArtifactType = "SUBTITLE"
 	ArtifactTypeThumbTile    ArtifactType = "THUMB_TILE"
 	ArtifactTypeThumbVTT     ArtifactType = "THUMB_VTT"
 	ArtifactTypeMetadataJSON ArtifactType = "METADATA_JSON"
+	ArtifactTypeMezzanine    ArtifactType = "MEZZANINE"
+	ArtifactTypeRendition    ArtifactType = "RENDITION"
+	// ArtifactTypeMP4Progressive is a transcoded MP4 published for download
+	// next to the HLS output
+	ArtifactTypeMP4Progressive ArtifactType = "MP4_PROGRESSIVE"
+	// ArtifactTypeHLSHardsubMaster is the master playlist of the renditions
+	// with burned-in subtitles, in a directory named after the language
+	ArtifactTypeHLSHardsubMaster ArtifactType = "HLS_HARDSUB_MASTER"
+	ArtifactTypeDiagnostic       ArtifactType = "DIAGNOSTIC"
 )
 
 // Artifact represents an output artifact from the conversion process
@@ -29,7 +39,18 @@ type Artifact struct {
 	Key       string       `json:"key" db:"key"`
 	SizeBytes *int64       `json:"sizeBytes,omitempty" db:"size_bytes"`
 	Checksum  *string      `json:"checksum,omitempty" db:"checksum"`
-	CreatedAt time.Time    `json:"createdAt" db:"created_at"`
+	// Tracks are the audio tracks an audio rendition playlist or an MP4 carries
+	Tracks    []ArtifactTrack `json:"tracks,omitempty" db:"tracks"`
+	CreatedAt time.Time       `json:"createdAt" db:"created_at"`
+}
+
+// ArtifactTrack describes an audio track of an artifact
+type ArtifactTrack struct {
+	// SourceIndex is the stream index of the track in the source
+	SourceIndex int    `json:"sourceIndex"`
+	Language    string `json:"language,omitempty"`
+	Default     bool   `json:"default,omitempty"`
+	Description bool   `json:"description,omitempty"`
 }
 
 // NewArtifact creates a new artifact
@@ -50,6 +71,12 @@ func (a *Artifact) WithSize(size int64) *Artifact {
 	return a
 }
 
+// WithTracks sets the audio tracks of the artifact
+func (a *Artifact) WithTracks(tracks []ArtifactTrack) *Artifact {
+	a.Tracks = tracks
+	return a
+}
+
 // WithChecksum sets the checksum of the artifact
 func (a *Artifact) WithChecksum(checksum string) *Artifact {
 	a.Checksum = &checksum
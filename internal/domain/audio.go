@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Surround policies of audio tracks with more than two channels
+const (
+	// AudioSurroundDownmix encodes the track as stereo AAC in every tier
+	AudioSurroundDownmix = "downmix"
+	// AudioSurroundPassthrough copies AC-3 and E-AC-3 tracks into the modern
+	// tier; the legacy tier gets the stereo downmix
+	AudioSurroundPassthrough = "passthrough"
+)
+
+// Downmix modes of surround tracks
+const (
+	// DownmixLoudness keeps the center channel, where dialog is, in front
+	// and normalizes the loudness of the stereo result
+	DownmixLoudness = "loudness"
+	// DownmixPlain is the default matrix of ffmpeg (-ac 2)
+	DownmixPlain = "plain"
+)
+
+// defaultLoudness is the integrated loudness target of downmixed tracks, in LUFS
+const defaultLoudness = -16
+
+// passthroughAudioCodecs are the source codecs the modern tier can carry as is
+var passthroughAudioCodecs = map[string]string{
+	"ac3":  "ac-3",
+	"eac3": "ec-3",
+}
+
+// AudioConfig sets how surround tracks of the source are encoded
+type AudioConfig struct {
+	// Surround is the policy of tracks with more than two channels: downmix
+	// (default) or passthrough; audioTracks entries override it per track
+	Surround string `json:"surround,omitempty"`
+	// Downmix is loudness (default) or plain
+	Downmix string `json:"downmix,omitempty"`
+	// Loudness is the integrated loudness target of the loudness downmix in
+	// LUFS (-70 to -5); 0 uses -16
+	Loudness float64 `json:"loudness,omitempty"`
+}
+
+// Validate checks the policies and the loudness range
+func (c *AudioConfig) Validate() error {
+	if err := validateSurround(c.Surround); err != nil {
+		return err
+	}
+	if c.Downmix != "" && c.Downmix != DownmixLoudness && c.Downmix != DownmixPlain {
+		return fmt.Errorf("audio.downmix must be %s or %s", DownmixLoudness, DownmixPlain)
+	}
+	if c.Loudness != 0 && (c.Loudness < -70 || c.Loudness > -5) {
+		return fmt.Errorf("audio.loudness must be between -70 and -5 LUFS")
+	}
+	return nil
+}
+
+// validateSurround checks a surround policy, empty for the default
+func validateSurround(surround string) error {
+	if surround != "" && surround != AudioSurroundDownmix && surround != AudioSurroundPassthrough {
+		return fmt.Errorf("audio surround must be %s or %s", AudioSurroundDownmix, AudioSurroundPassthrough)
+	}
+	return nil
+}
+
+// DownmixMode returns the downmix mode, defaulted
+func (c *AudioConfig) DownmixMode() string {
+	if c == nil || c.Downmix == "" {
+		return DownmixLoudness
+	}
+	return c.Downmix
+}
+
+// LoudnessTarget returns the loudness target in LUFS, defaulted
+func (c *AudioConfig) LoudnessTarget() float64 {
+	if c == nil || c.Loudness == 0 {
+		return defaultLoudness
+	}
+	return c.Loudness
+}
+
+// AudioCodecString returns the RFC 6381 codec of a track copied into the
+// modern tier, empty if its codec cannot be copied
+func AudioCodecString(codec string) string {
+	return passthroughAudioCodecs[strings.ToLower(codec)]
+}
+
+// ApplyAudioPolicy marks the surround tracks the profile copies into the
+// modern tier. Only AC-3 and E-AC-3 tracks can be copied; other surround
+// codecs are downmixed. Bumpers are concatenated with stereo AAC, so a
+// profile with bumpers downmixes every track.
+func (m *VideoMetadata) ApplyAudioPolicy(profile Profile) {
+	for i := range m.AudioTracks {
+		track := &m.AudioTracks[i]
+		surround := ""
+		if profile.Audio != nil {
+			surround = profile.Audio.Surround
+		}
+		for _, t := range profile.AudioTracks {
+			if t.Surround != "" && t.Matches(*track) {
+				surround = t.Surround
+			}
+		}
+		track.Passthrough = surround == AudioSurroundPassthrough && !profile.HasBumpers() &&
+			track.Channels > 2 && AudioCodecString(track.Codec) != ""
+	}
+}
+
+// CopiesAudio reports whether a track is copied into the modern tier
+func (m *VideoMetadata) CopiesAudio() bool {
+	for _, track := range m.AudioTracks {
+		if track.Passthrough {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultAudioOnlyBitrates are the renditions of an audio-only profile
+// without bitrates
+var defaultAudioOnlyBitrates = []string{"64k", "128k", "256k"}
+
+// audioOnlyCodecStrings are the RFC 6381 codecs of audio-only renditions
+var audioOnlyCodecStrings = map[AudioCodec]string{
+	AudioCodecAAC:  "mp4a.40.2",
+	AudioCodecOpus: "Opus",
+}
+
+// AudioOnlyConfig makes a profile produce audio-only renditions of the main
+// audio track for podcasts and music; the video stages are skipped
+type AudioOnlyConfig struct {
+	// Codec is aac (default) or opus
+	Codec AudioCodec `json:"codec,omitempty"`
+	// Bitrates of the renditions such as "128k"; empty uses 64k, 128k and 256k
+	Bitrates []string `json:"bitrates,omitempty"`
+}
+
+// Validate checks the codec and that the bitrates are distinct
+func (c *AudioOnlyConfig) Validate() error {
+	if _, ok := audioOnlyCodecStrings[c.AudioCodec()]; !ok {
+		return fmt.Errorf("audioOnly.codec must be %s or %s", AudioCodecAAC, AudioCodecOpus)
+	}
+	seen := make(map[string]bool, len(c.Bitrates))
+	for _, b := range c.Bitrates {
+		if !validBitrate(b) {
+			return fmt.Errorf("audioOnly.bitrates: %q must be a bitrate such as 128k", b)
+		}
+		if seen[strings.ToLower(b)] {
+			return fmt.Errorf("audioOnly.bitrates: %s is listed twice", b)
+		}
+		seen[strings.ToLower(b)] = true
+	}
+	return nil
+}
+
+// AudioCodec returns the codec of the renditions, defaulted
+func (c *AudioOnlyConfig) AudioCodec() AudioCodec {
+	if c == nil || c.Codec == "" {
+		return AudioCodecAAC
+	}
+	return c.Codec
+}
+
+// CodecString returns the RFC 6381 codec of the renditions
+func (c *AudioOnlyConfig) CodecString() string {
+	return audioOnlyCodecStrings[c.AudioCodec()]
+}
+
+// AudioBitrates returns the bitrates of the renditions, defaulted
+func (c *AudioOnlyConfig) AudioBitrates() []string {
+	if c == nil || len(c.Bitrates) == 0 {
+		return defaultAudioOnlyBitrates
+	}
+	return c.Bitrates
+}
+
+// AudioOnlyName names the transcoded output and HLS rendition of a bitrate
+func AudioOnlyName(bitrate string) string {
+	return "audio_" + strings.ToLower(bitrate)
+}
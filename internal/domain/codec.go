@@ -1,5 +1,11 @@
 package domain
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // VideoCodec represents video codec type
 type VideoCodec string
 
@@ -12,7 +18,8 @@ const (
 type AudioCodec string
 
 const (
-	AudioCodecAAC AudioCodec = "aac"
+	AudioCodecAAC  AudioCodec = "aac"
+	AudioCodecOpus AudioCodec = "opus"
 )
 
 // ContainerFormat represents container format for HLS segments
@@ -37,11 +44,100 @@ type TierConfig struct {
 	VideoCodec VideoCodec
 	AudioCodec AudioCodec
 	Container  ContainerFormat
-	// Codec strings for HLS playlist (RFC 6381)
-	VideoCodecString string // e.g., "avc1.640028", "hvc1.1.6.L120.90"
+	// Codec string of the audio for HLS playlist (RFC 6381); that of the
+	// video depends on the encoder settings, see VideoCodecString
 	AudioCodecString string // e.g., "mp4a.40.2"
 }
 
+// defaultLevel is the level signaled for renditions whose level is unknown
+const defaultLevel = "4.0"
+
+// VideoCodecString returns the RFC 6381 codec of codec video encoded with
+// profile at level_idc levelIDC and bitDepth, such as avc1.640029 for H.264
+// High 4.1 or hvc1.2.4.L153.90 for H.265 Main 10 5.1. An empty profile is
+// High for H.264 and Main for H.265, 10-bit video is High 10 or Main 10, and
+// a zero levelIDC is level 4.0.
+func VideoCodecString(codec VideoCodec, profile string, levelIDC, bitDepth int) string {
+	if levelIDC <= 0 {
+		levelIDC = LevelIDC(codec, defaultLevel)
+	}
+	if codec == VideoCodecH265 {
+		if bitDepth > 8 {
+			return fmt.Sprintf("hvc1.2.4.L%d.90", levelIDC)
+		}
+		return fmt.Sprintf("hvc1.1.6.L%d.90", levelIDC)
+	}
+
+	// profile_idc with the constraint flags x264 sets for it
+	profileIDC := map[string]string{"baseline": "42c0", "main": "4d40", "high": "6400"}[profile]
+	switch {
+	case bitDepth > 8:
+		profileIDC = "6e00"
+	case profileIDC == "":
+		profileIDC = "6400"
+	}
+	return fmt.Sprintf("avc1.%s%02x", profileIDC, levelIDC)
+}
+
+// LevelIDC returns the level_idc of an encoder level such as 4.1: ten times
+// the level for H.264, thirty times for H.265. It is 0 for an empty level.
+func LevelIDC(codec VideoCodec, level string) int {
+	major, minor, _ := strings.Cut(level, ".")
+	m, err := strconv.Atoi(major)
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(minor)
+	if codec == VideoCodecH265 {
+		return 30*m + 3*n
+	}
+	return 10*m + n
+}
+
+// BitDepthConfig selects the bit depth of renditions per codec: 8, or 10 for
+// H.264 High 10 and H.265 Main 10. Sources with 8 bits are always encoded at
+// 8 bits, as 10-bit output cannot restore their precision.
+type BitDepthConfig struct {
+	H264 int `json:"h264,omitempty"`
+	H265 int `json:"h265,omitempty"`
+}
+
+// Override returns c with the non-zero fields of other applied
+func (c BitDepthConfig) Override(other *BitDepthConfig) BitDepthConfig {
+	if other == nil {
+		return c
+	}
+	if other.H264 != 0 {
+		c.H264 = other.H264
+	}
+	if other.H265 != 0 {
+		c.H265 = other.H265
+	}
+	return c
+}
+
+// Validate checks that every set bit depth is 8 or 10
+func (c *BitDepthConfig) Validate() error {
+	for _, depth := range []int{c.H264, c.H265} {
+		if depth != 0 && depth != 8 && depth != 10 {
+			return fmt.Errorf("bitDepth: bit depth must be 8 or 10")
+		}
+	}
+	return nil
+}
+
+// For returns the bit depth of codec renditions of a source with sourceBitDepth bits
+func (c BitDepthConfig) For(codec VideoCodec, sourceBitDepth int) int {
+	depth := c.H264
+	if codec == VideoCodecH265 {
+		depth = c.H265
+	}
+	if depth == 10 && sourceBitDepth > 8 {
+		return 10
+	}
+	return 8
+}
+
 // GetTierConfig returns codec configuration for tier
 func GetTierConfig(tier EncodingTier) TierConfig {
 	configs := map[EncodingTier]TierConfig{
@@ -50,7 +146,6 @@ func GetTierConfig(tier EncodingTier) TierConfig {
 			VideoCodec:       VideoCodecH264,
 			AudioCodec:       AudioCodecAAC,
 			Container:        ContainerTS,
-			VideoCodecString: "avc1.640028",
 			AudioCodecString: "mp4a.40.2",
 		},
 		TierModern: {
@@ -58,7 +153,6 @@ func GetTierConfig(tier EncodingTier) TierConfig {
 			VideoCodec:       VideoCodecH265,
 			AudioCodec:       AudioCodecAAC,
 			Container:        ContainerFMP4,
-			VideoCodecString: "hvc1.1.6.L120.90",
 			AudioCodecString: "mp4a.40.2",
 		},
 	}
@@ -78,6 +172,20 @@ func (v VideoCodec) BitrateMultiplier() float64 {
 	}
 }
 
+// IsValid reports whether c is a known segment container
+func (c ContainerFormat) IsValid() bool {
+	return c == ContainerTS || c == ContainerFMP4
+}
+
+// WithContainer returns the tier config with the legacy tier segmented into
+// container; the modern tier is always fMP4
+func (c TierConfig) WithContainer(container ContainerFormat) TierConfig {
+	if container == ContainerFMP4 {
+		c.Container = ContainerFMP4
+	}
+	return c
+}
+
 // SegmentExtension returns file extension for HLS segments
 func (c ContainerFormat) SegmentExtension() string {
 	switch c {
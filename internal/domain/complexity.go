@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+)
+
+// Defaults of PerTitleConfig
+const (
+	defaultPerTitleMinScale = 0.5
+	defaultPerTitleMaxScale = 1.2
+	defaultPerTitleCRF      = 23
+)
+
+// PerTitleConfig enables per-title encoding: before transcoding, samples of
+// the source are encoded at a constant CRF and the ladder bitrates are scaled
+// by how the result compares to the nominal bitrate. Zero fields use defaults.
+type PerTitleConfig struct {
+	// MinScale and MaxScale bound the bitrate scale; defaults 0.5 and 1.2
+	MinScale float64 `json:"minScale,omitempty"`
+	MaxScale float64 `json:"maxScale,omitempty"`
+	// CRF of the probe encodes; the default 23 matches the H.264 renditions
+	CRF int `json:"crf,omitempty"`
+}
+
+// WithDefaults fills unset fields
+func (c PerTitleConfig) WithDefaults() PerTitleConfig {
+	if c.MinScale <= 0 {
+		c.MinScale = defaultPerTitleMinScale
+	}
+	if c.MaxScale <= 0 {
+		c.MaxScale = defaultPerTitleMaxScale
+	}
+	if c.CRF <= 0 {
+		c.CRF = defaultPerTitleCRF
+	}
+	return c
+}
+
+// Validate checks the scale bounds and CRF
+func (c *PerTitleConfig) Validate() error {
+	d := c.WithDefaults()
+	if c.MinScale < 0 || c.MaxScale < 0 || d.MinScale > d.MaxScale {
+		return fmt.Errorf("perTitle: minScale must not exceed maxScale")
+	}
+	if c.CRF < 0 || c.CRF > 51 {
+		return fmt.Errorf("perTitle: crf must be between 0 and 51")
+	}
+	return nil
+}
+
+// Scale returns the bitrate scale for a probe bitrate against the nominal
+// bitrate of the same rung, within the configured bounds
+func (c PerTitleConfig) Scale(probeBitrate, nominalBitrate int64) float64 {
+	c = c.WithDefaults()
+	if probeBitrate <= 0 || nominalBitrate <= 0 {
+		return 1
+	}
+	scale := float64(probeBitrate) / float64(nominalBitrate)
+	return math.Max(c.MinScale, math.Min(c.MaxScale, scale))
+}
+
+// ComplexityAnalysis is the result of the per-title probe of a job
+type ComplexityAnalysis struct {
+	// Reference is the rung the samples were encoded at
+	Reference      Quality `json:"reference"`
+	Samples        int     `json:"samples"`
+	NominalBitrate int64   `json:"nominalBitrate"` // bits per second
+	ProbeBitrate   int64   `json:"probeBitrate"`
+	// Scale was applied to the video bitrates of the ladder
+	Scale float64 `json:"scale"`
+}
+
+// NewComplexityAnalysis compares the probe bitrate of samples encoded at the
+// reference rung with the nominal bitrate of that rung
+func NewComplexityAnalysis(reference Quality, params QualityConfig, samples int, probeBitrate int64, cfg PerTitleConfig) *ComplexityAnalysis {
+	nominal := parseBitrate(params.VideoBitrate)
+	return &ComplexityAnalysis{
+		Reference:      reference,
+		Samples:        samples,
+		NominalBitrate: nominal,
+		ProbeBitrate:   probeBitrate,
+		Scale:          cfg.Scale(probeBitrate, nominal),
+	}
+}
+
+// ScaleLadder returns ladder with the video bitrates of qualities multiplied by
+// scale. The rungs are written explicitly, so the result no longer depends on
+// the built-in ladder; origin keeps the source bitrate.
+func ScaleLadder(ladder Ladder, qualities []Quality, scale float64) Ladder {
+	scaled := make(Ladder, 0, len(ladder)+len(qualities))
+	done := make(map[Quality]bool, len(qualities))
+	for _, q := range qualities {
+		if q == QualityOrigin || done[q] {
+			continue
+		}
+		done[q] = true
+		params := ladder.Params(q)
+		scaled = append(scaled, QualityRung{
+			Name:         q,
+			Width:        params.Width,
+			Height:       params.Height,
+			VideoBitrate: scaleBitrate(params.VideoBitrate, scale),
+			MaxBitrate:   scaleBitrate(params.MaxBitrate, scale),
+			BufSize:      scaleBitrate(params.BufSize, scale),
+			AudioBitrate: params.AudioBitrate,
+			CRF:          params.CRF,
+		})
+	}
+	// Rungs of the ladder the job does not encode are kept as they are
+	for _, r := range ladder {
+		if !done[r.Name] {
+			scaled = append(scaled, r)
+		}
+	}
+	return scaled
+}
+
+// scaleBitrate multiplies an ffmpeg bitrate such as "6000k"
+func scaleBitrate(bitrate string, scale float64) string {
+	return fmt.Sprintf("%dk", int64(math.Round(float64(parseBitrate(bitrate))*scale/1000)))
+}
@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+)
+
+// defaultCropLimit is the cropdetect threshold below which a pixel counts as
+// black, on the 0-255 luma scale
+const defaultCropLimit = 24
+
+// minCropBar is the smallest share of the frame height or width the bars have
+// to take for the source to be cropped; thinner edges are left to the encoder
+const minCropBar = 0.02
+
+// AutoCropConfig enables detection of letterbox and pillarbox bars before
+// transcoding
+type AutoCropConfig struct {
+	// Limit is the cropdetect black threshold (0-255); 0 uses 24
+	Limit int `json:"limit,omitempty"`
+}
+
+// Validate checks the threshold range
+func (c *AutoCropConfig) Validate() error {
+	if c.Limit < 0 || c.Limit > 255 {
+		return fmt.Errorf("autoCrop.limit must be between 0 and 255")
+	}
+	return nil
+}
+
+// CropLimit returns the black threshold, defaulted
+func (c *AutoCropConfig) CropLimit() int {
+	if c == nil || c.Limit == 0 {
+		return defaultCropLimit
+	}
+	return c.Limit
+}
+
+// CropRect is the picture area of a source without its black bars, in pixels
+// of the upright frame
+type CropRect struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	X      int `json:"x"`
+	Y      int `json:"y"`
+}
+
+// Filter returns the ffmpeg crop filter of the area
+func (c CropRect) Filter() string {
+	return fmt.Sprintf("crop=%d:%d:%d:%d", c.Width, c.Height, c.X, c.Y)
+}
+
+// Union returns the smallest area containing both c and o, so a dark scene
+// in one sample does not crop the picture of another
+func (c CropRect) Union(o CropRect) CropRect {
+	x, y := min(c.X, o.X), min(c.Y, o.Y)
+	right, bottom := max(c.X+c.Width, o.X+o.Width), max(c.Y+c.Height, o.Y+o.Height)
+	return CropRect{Width: right - x, Height: bottom - y, X: x, Y: y}
+}
+
+// Significant reports whether cropping a frame of width x height to the area
+// removes bars worth cropping
+func (c CropRect) Significant(width, height int) bool {
+	if c.Width <= 0 || c.Height <= 0 || c.Width > width || c.Height > height {
+		return false
+	}
+	return float64(width-c.Width) >= float64(width)*minCropBar ||
+		float64(height-c.Height) >= float64(height)*minCropBar
+}
+
+// CropLadder returns ladder with the rungs of qualities fitted to a picture of
+// width x height: the rung keeps its width and gets the height of the picture
+// aspect ratio, or its height and a narrower width for pillarboxed sources.
+// The rungs are written explicitly, as in ScaleLadder; bitrates are kept, so
+// the bits of the bars go to the picture.
+func CropLadder(ladder Ladder, qualities []Quality, width, height int) Ladder {
+	cropped := make(Ladder, 0, len(ladder)+len(qualities))
+	done := make(map[Quality]bool, len(qualities))
+	for _, q := range qualities {
+		if q == QualityOrigin || done[q] {
+			continue
+		}
+		done[q] = true
+		params := ladder.Params(q)
+		w, h := params.Width, evenDimension(float64(params.Width)*float64(height)/float64(width))
+		if h > params.Height {
+			w, h = evenDimension(float64(params.Height)*float64(width)/float64(height)), params.Height
+		}
+		cropped = append(cropped, QualityRung{
+			Name:         q,
+			Width:        w,
+			Height:       h,
+			VideoBitrate: params.VideoBitrate,
+			MaxBitrate:   params.MaxBitrate,
+			BufSize:      params.BufSize,
+			AudioBitrate: params.AudioBitrate,
+			CRF:          params.CRF,
+		})
+	}
+	// Rungs of the ladder the job does not encode are kept as they are
+	for _, r := range ladder {
+		if !done[r.Name] {
+			cropped = append(cropped, r)
+		}
+	}
+	return cropped
+}
+
+// evenDimension rounds a frame dimension to the nearest even number, as the
+// 4:2:0 encoders need
+func evenDimension(v float64) int {
+	return int(math.Round(v/2)) * 2
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// CuePoint marks an ad break for server-side ad insertion. HLS playlists
+// get EXT-X-CUE-OUT and EXT-X-CUE-IN at the segment boundaries nearest to
+// it, DASH manifests a SCTE-35 event.
+type CuePoint struct {
+	// Time of the break in seconds of the source
+	Time float64 `json:"time"`
+	// Duration of the source content the break replaces, in seconds; 0
+	// inserts the break between segments without replacing content
+	Duration float64 `json:"duration,omitempty"`
+}
+
+// ValidateCuePoints checks that the breaks are in order and do not overlap
+func ValidateCuePoints(cues []CuePoint) error {
+	end := -1.0
+	for i, c := range cues {
+		if c.Time < 0 || c.Duration < 0 {
+			return fmt.Errorf("cuePoints[%d]: time and duration must not be negative", i)
+		}
+		if c.Time <= end {
+			return fmt.Errorf("cuePoints[%d]: breaks must be in order and not overlap", i)
+		}
+		end = c.Time + c.Duration
+	}
+	return nil
+}
+
+// OutputTime returns the time of the break in the output: the source time
+// moved by the trim and behind the intro. Breaks outside the trimmed segment
+// are not in the output.
+func (c CuePoint) OutputTime(trim *TrimConfig, intro time.Duration) (time.Duration, bool) {
+	if trim != nil && (c.Time < trim.Start || (trim.End > 0 && c.Time >= trim.End)) {
+		return 0, false
+	}
+	return time.Duration(c.Time*float64(time.Second)) - trim.Offset() + intro, true
+}
@@ -0,0 +1,144 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// x264 and x265 share their presets
+var encoderPresets = []string{
+	"ultrafast", "superfast", "veryfast", "faster", "fast", "medium", "slow", "slower", "veryslow", "placebo",
+}
+
+// encoderTunes are the tunes each software encoder accepts
+var encoderTunes = map[VideoCodec][]string{
+	VideoCodecH264: {"film", "animation", "grain", "stillimage", "fastdecode", "zerolatency", "psnr", "ssim"},
+	VideoCodecH265: {"animation", "grain", "fastdecode", "zerolatency", "psnr", "ssim"},
+}
+
+// encoderProfiles are the 8-bit profiles of each software encoder; 10-bit
+// renditions are always High 10 and Main 10
+var encoderProfiles = map[VideoCodec][]string{
+	VideoCodecH264: {"baseline", "main", "high"},
+	VideoCodecH265: {"main"},
+}
+
+var (
+	encoderLevel  = regexp.MustCompile(`^[1-6](\.[0-3])?$`)
+	encoderParams = regexp.MustCompile(`^[a-z0-9-]+=[A-Za-z0-9.,/_-]+(:[a-z0-9-]+=[A-Za-z0-9.,/_-]+)*$`)
+)
+
+// EncoderSettings tune the libx264 or libx265 encoder. Empty fields keep the
+// setting of the level below: ENCODING_* for the codec, then the codec
+// settings of the profile, then those of the quality.
+type EncoderSettings struct {
+	Preset string `json:"preset,omitempty"`
+	Tune   string `json:"tune,omitempty"`
+	// Profile of 8-bit renditions: baseline, main or high for H.264, main for H.265
+	Profile string `json:"profile,omitempty"`
+	// Level such as 4.1 or 5.1
+	Level string `json:"level,omitempty"`
+	// Params are extra x264-params or x265-params (key=value:key=value),
+	// appended to the ones the converter sets
+	Params string `json:"params,omitempty"`
+}
+
+// Override returns s with the non-empty fields of other applied
+func (s EncoderSettings) Override(other *EncoderSettings) EncoderSettings {
+	if other == nil {
+		return s
+	}
+	if other.Preset != "" {
+		s.Preset = other.Preset
+	}
+	if other.Tune != "" {
+		s.Tune = other.Tune
+	}
+	if other.Profile != "" {
+		s.Profile = other.Profile
+	}
+	if other.Level != "" {
+		s.Level = other.Level
+	}
+	if other.Params != "" {
+		s.Params = other.Params
+	}
+	return s
+}
+
+// Validate checks the settings against the options of the codec encoder
+func (s *EncoderSettings) Validate(codec VideoCodec) error {
+	if s.Preset != "" && !slices.Contains(encoderPresets, s.Preset) {
+		return fmt.Errorf("%s preset must be one of %s", codec, strings.Join(encoderPresets, ", "))
+	}
+	if s.Tune != "" && !slices.Contains(encoderTunes[codec], s.Tune) {
+		return fmt.Errorf("%s tune must be one of %s", codec, strings.Join(encoderTunes[codec], ", "))
+	}
+	if s.Profile != "" && !slices.Contains(encoderProfiles[codec], s.Profile) {
+		return fmt.Errorf("%s profile must be one of %s", codec, strings.Join(encoderProfiles[codec], ", "))
+	}
+	if s.Level != "" && !encoderLevel.MatchString(s.Level) {
+		return fmt.Errorf("%s level must be a level such as 4.1", codec)
+	}
+	if s.Params != "" && !encoderParams.MatchString(s.Params) {
+		return fmt.Errorf("%s params must be key=value pairs separated by colons", codec)
+	}
+	return nil
+}
+
+// CodecEncoderSettings holds encoder settings per codec, that is per tier
+type CodecEncoderSettings struct {
+	H264 *EncoderSettings `json:"h264,omitempty"`
+	H265 *EncoderSettings `json:"h265,omitempty"`
+}
+
+// For returns the settings of codec, nil if there are none
+func (c CodecEncoderSettings) For(codec VideoCodec) *EncoderSettings {
+	if codec == VideoCodecH265 {
+		return c.H265
+	}
+	return c.H264
+}
+
+// Validate checks the settings of both codecs
+func (c CodecEncoderSettings) Validate() error {
+	for _, codec := range []VideoCodec{VideoCodecH264, VideoCodecH265} {
+		if s := c.For(codec); s != nil {
+			if err := s.Validate(codec); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EncoderConfig tunes the software encoders of a profile per tier codec, with
+// overrides per quality such as level 5.1 for 2160p
+type EncoderConfig struct {
+	CodecEncoderSettings
+	Qualities map[Quality]CodecEncoderSettings `json:"qualities,omitempty"`
+}
+
+// Validate checks the codec and quality settings
+func (c *EncoderConfig) Validate() error {
+	if err := c.CodecEncoderSettings.Validate(); err != nil {
+		return fmt.Errorf("encoder: %w", err)
+	}
+	for q, settings := range c.Qualities {
+		if err := settings.Validate(); err != nil {
+			return fmt.Errorf("encoder: %s: %w", q, err)
+		}
+	}
+	return nil
+}
+
+// Settings returns base with the codec settings of the config and those of
+// quality applied
+func (c *EncoderConfig) Settings(base EncoderSettings, codec VideoCodec, quality Quality) EncoderSettings {
+	if c == nil {
+		return base
+	}
+	return base.Override(c.For(codec)).Override(c.Qualities[quality].For(codec))
+}
@@ -56,6 +56,8 @@ const (
 	ErrCodeS3AccessDenied    = "S3_ACCESS_DENIED"
 	ErrCodeS3NotFound        = "S3_NOT_FOUND"
 	ErrCodeS3Timeout         = "S3_TIMEOUT"
+	ErrCodeS3Throttled       = "S3_THROTTLED"
+	ErrCodeStorageError      = "STORAGE_ERROR"
 	ErrCodeFFmpegFailed      = "FFMPEG_FAILED"
 	ErrCodeFFprobeFailed     = "FFPROBE_FAILED"
 	ErrCodeNetworkError      = "NETWORK_ERROR"
@@ -63,17 +65,82 @@ const (
 	ErrCodeTimeout           = "TIMEOUT"
 	ErrCodeCanceled          = "CANCELED"
 	ErrCodeWorkflowFailed    = "WORKFLOW_FAILED"
+	ErrCodeWorkflowLost      = "WORKFLOW_LOST"
+	ErrCodeBudgetExceeded    = "BUDGET_EXCEEDED"
+	ErrCodeOutputIncomplete  = "OUTPUT_INCOMPLETE"
+	ErrCodeTranscoderUnavailable = "TRANSCODER_UNAVAILABLE"
+	ErrCodeCloudTranscodeFailed  = "CLOUD_TRANSCODE_FAILED"
+	ErrCodeBumperFailed          = "BUMPER_FAILED"
+	ErrCodeSourceNotAllowed      = "SOURCE_NOT_ALLOWED"
+	// ErrCodeDolbyVisionUnsupported rejects Dolby Vision sources whose base
+	// layer cannot be encoded (profile 5)
+	ErrCodeDolbyVisionUnsupported = "DOLBY_VISION_UNSUPPORTED"
+)
+
+// JobWarning is a non-fatal problem: the job went on, but the output differs
+// from what the profile asked for
+type JobWarning struct {
+	Stage     Stage     `json:"stage"`
+	Code      string    `json:"code"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewJobWarning creates a new job warning
+func NewJobWarning(stage Stage, code, message string) JobWarning {
+	return JobWarning{
+		Stage:     stage,
+		Code:      code,
+		Message:   message,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// Warning codes
+const (
+	WarnCodeSubtitleSkipped      = "SUBTITLE_SKIPPED"
+	WarnCodeSubtitleCuesDropped  = "SUBTITLE_CUES_DROPPED"
+	WarnCodeThumbnailTiles       = "THUMBNAIL_TILES_FALLBACK"
+	WarnCodeThumbnailVTT         = "THUMBNAIL_VTT_FAILED"
+	WarnCodeAudioDownmixed       = "AUDIO_DOWNMIXED"
+	WarnCodeHDRMetadataStripped  = "HDR_METADATA_STRIPPED"
+	WarnCodeArtifactsNotUploaded = "ARTIFACTS_NOT_UPLOADED"
+	WarnCodeTitleUsageExceeded   = "TITLE_USAGE_EXCEEDED"
+	WarnCodeNotPublished         = "NOT_PUBLISHED"
+	WarnCodeEncodeDegraded       = "ENCODE_DEGRADED"
+	WarnCodeDurationEstimated    = "DURATION_ESTIMATED"
+	WarnCodeDurationUnknown      = "DURATION_UNKNOWN"
+	WarnCodeAudioDescSkipped     = "AUDIO_DESCRIPTION_SKIPPED"
+	WarnCodeAudioTrackNotFound   = "AUDIO_TRACK_NOT_FOUND"
+	WarnCodePerTitleSkipped      = "PER_TITLE_SKIPPED"
+	WarnCodeAutoCropSkipped      = "AUTO_CROP_SKIPPED"
+	WarnCodeGPUFallback          = "GPU_FALLBACK"
+	WarnCodeIFramesSkipped       = "IFRAME_PLAYLIST_SKIPPED"
+	WarnCodeHardsubSkipped       = "HARDSUB_SKIPPED"
+	WarnCodeCuePointSkipped      = "CUE_POINT_SKIPPED"
 )
 
 // IsRetryable returns true if the error code is retryable
 func IsRetryable(code string) bool {
 	retryableCodes := map[string]bool{
 		ErrCodeS3Timeout:     true,
+		ErrCodeS3Throttled:   true,
 		ErrCodeNetworkError:  true,
 	}
 	return retryableCodes[code]
 }
 
+// IsPoison returns true if the error code means the source itself breaks the
+// tooling, so retrying the job would fail the same way
+func IsPoison(code string) bool {
+	switch code {
+	case ErrCodeFFmpegFailed, ErrCodeFFprobeFailed, ErrCodeCorruptedFile:
+		return true
+	default:
+		return false
+	}
+}
+
 // ClassifyError determines the error class based on error code
 func ClassifyError(code string) ErrorClass {
 	if IsRetryable(code) {
@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// languageCode is an ISO 639 language code such as "rus" or "en"
+var languageCode = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// HardsubConfig burns a subtitle track into an alternate set of the H.264
+// renditions, for platforms that cannot render text subtitles
+type HardsubConfig struct {
+	// Language of the subtitle track to burn in, as tagged in the source
+	Language string `json:"language"`
+	// Forced burns in the forced track of the language (signs and foreign
+	// dialog only) instead of the full one
+	Forced bool `json:"forced,omitempty"`
+}
+
+// Validate checks the language code
+func (c *HardsubConfig) Validate() error {
+	if !languageCode.MatchString(c.Language) {
+		return fmt.Errorf("hardsub.language must be a lowercase ISO 639 code such as rus")
+	}
+	return nil
+}
+
+// TrackName returns the name the burned-in track is extracted under
+func (c *HardsubConfig) TrackName() string {
+	if c.Forced {
+		return c.Language + ".forced"
+	}
+	return c.Language
+}
+
+// Name labels the rendition set with the language: its transcoded and HLS
+// directories are named after it
+func (c *HardsubConfig) Name() string {
+	return "hardsub_" + c.TrackName()
+}
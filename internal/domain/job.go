@@ -12,9 +12,13 @@ type JobStatus string
 const (
 	JobStatusQueued    JobStatus = "QUEUED"
 	JobStatusRunning   JobStatus = "RUNNING"
+	JobStatusPaused    JobStatus = "PAUSED"
 	JobStatusCompleted JobStatus = "COMPLETED"
 	JobStatusFailed    JobStatus = "FAILED"
 	JobStatusCanceled  JobStatus = "CANCELED"
+	// JobStatusQuarantined marks a failed job whose source is poison: it failed on
+	// every retry or crashed ffmpeg. A diagnostic bundle is uploaded next to the output.
+	JobStatusQuarantined JobStatus = "QUARANTINED"
 )
 
 // Stage represents a conversion stage
@@ -29,6 +33,7 @@ const (
 	StageThumbnailsGen       Stage = "THUMBNAILS_GENERATION"
 	StageHLSSegmentation     Stage = "HLS_SEGMENTATION"
 	StageUploading           Stage = "UPLOADING"
+	StageOutputVerification  Stage = "OUTPUT_VERIFICATION"
 	StageCleanup             Stage = "CLEANUP"
 )
 
@@ -42,6 +47,7 @@ func AllStages() []Stage {
 		StageThumbnailsGen,
 		StageHLSSegmentation,
 		StageUploading,
+		StageOutputVerification,
 		StageCleanup,
 	}
 }
@@ -56,6 +62,7 @@ func StageWeight(s Stage) int {
 		StageThumbnailsGen:       10,
 		StageHLSSegmentation:     10,
 		StageUploading:           10,
+		StageOutputVerification:  5,
 		StageCleanup:             5,
 	}
 	return weights[s]
@@ -63,10 +70,14 @@ func StageWeight(s Stage) int {
 
 // Job represents a video conversion job
 type Job struct {
-	ID              uuid.UUID  `json:"id" db:"id"`
-	VideoID         *uuid.UUID `json:"videoId,omitempty" db:"video_id"`
-	SourceBucket    string     `json:"sourceBucket" db:"source_bucket"`
-	SourceKey       string     `json:"sourceKey" db:"source_key"`
+	ID           uuid.UUID  `json:"id" db:"id"`
+	VideoID      *uuid.UUID `json:"videoId,omitempty" db:"video_id"`
+	SeriesID     *uuid.UUID `json:"seriesId,omitempty" db:"series_id"`
+	Tenant       *string    `json:"tenant,omitempty" db:"tenant"`
+	SourceBucket string     `json:"sourceBucket" db:"source_bucket"`
+	SourceKey    string     `json:"sourceKey" db:"source_key"`
+	// SourceURL is set for HLS/DASH sources that are read over HTTP instead of from S3
+	SourceURL       *string    `json:"sourceUrl,omitempty" db:"source_url"`
 	Status          JobStatus  `json:"status" db:"status"`
 	CurrentStage    *Stage     `json:"currentStage,omitempty" db:"current_stage"`
 	StageProgress   int        `json:"stageProgress" db:"stage_progress"`
@@ -81,7 +92,11 @@ type Job struct {
 	FinishedAt      *time.Time `json:"finishedAt,omitempty" db:"finished_at"`
 	Attempt         int        `json:"attempt" db:"attempt"`
 	LastErrorID     *uuid.UUID `json:"lastErrorId,omitempty" db:"last_error_id"`
-	LockVersion     int        `json:"-" db:"lock_version"`
+	CancelReason    *string    `json:"cancelReason,omitempty" db:"cancel_reason"`
+	CanceledBy      *string    `json:"canceledBy,omitempty" db:"canceled_by"`
+	// DryRun jobs stop after validation and store a JobPlan instead of encoding
+	DryRun      bool `json:"dryRun,omitempty" db:"dry_run"`
+	LockVersion int  `json:"-" db:"lock_version"`
 }
 
 // NewJob creates a new job with default values
@@ -103,6 +118,21 @@ func NewJob(sourceBucket, sourceKey string, profile Profile) *Job {
 	}
 }
 
+// OutputPrefix returns the S3 prefix of job output: "{videoId}/{jobId}",
+// or "{jobId}/{jobId}" for jobs without a video ID
+func (j *Job) OutputPrefix() string {
+	videoID := j.ID.String()
+	if j.VideoID != nil {
+		videoID = j.VideoID.String()
+	}
+	return videoID + "/" + j.ID.String()
+}
+
+// DiagnosticsPrefix returns the S3 prefix of the diagnostic bundle of a quarantined job
+func (j *Job) DiagnosticsPrefix() string {
+	return j.OutputPrefix() + "/diagnostics"
+}
+
 // CalculateOverallProgress calculates overall progress based on current stage and stage progress
 func (j *Job) CalculateOverallProgress() int {
 	if j.CurrentStage == nil {
@@ -129,3 +159,43 @@ func (j *Job) CalculateOverallProgress() int {
 	progress := completedWeight + (currentStageWeight * j.StageProgress / 100)
 	return progress * 100 / totalWeight
 }
+
+// StageStatus represents the outcome of a stage attempt
+type StageStatus string
+
+const (
+	StageStatusRunning   StageStatus = "RUNNING"
+	StageStatusCompleted StageStatus = "COMPLETED"
+	StageStatusFailed    StageStatus = "FAILED"
+)
+
+// StageTiming records when a single attempt of a stage started and finished
+type StageTiming struct {
+	ID         uuid.UUID   `json:"id" db:"id"`
+	JobID      uuid.UUID   `json:"jobId" db:"job_id"`
+	Stage      Stage       `json:"stage" db:"stage"`
+	Attempt    int         `json:"attempt" db:"attempt"`
+	Status     StageStatus `json:"status" db:"status"`
+	StartedAt  time.Time   `json:"startedAt" db:"started_at"`
+	FinishedAt *time.Time  `json:"finishedAt,omitempty" db:"finished_at"`
+}
+
+// NewStageTiming creates a running stage timing starting now
+func NewStageTiming(jobID uuid.UUID, stage Stage, attempt int) *StageTiming {
+	return &StageTiming{
+		ID:        uuid.New(),
+		JobID:     jobID,
+		Stage:     stage,
+		Attempt:   attempt,
+		Status:    StageStatusRunning,
+		StartedAt: time.Now().UTC(),
+	}
+}
+
+// Duration returns the stage duration, measured up to now while the stage is running
+func (s *StageTiming) Duration() time.Duration {
+	if s.FinishedAt == nil {
+		return time.Since(s.StartedAt)
+	}
+	return s.FinishedAt.Sub(s.StartedAt)
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // VideoMetadata holds extracted video metadata
 type VideoMetadata struct {
@@ -15,6 +18,131 @@ type VideoMetadata struct {
 	AudioTracks    []AudioTrackInfo    `json:"audioTracks"`
 	SubtitleTracks []SubtitleTrackInfo `json:"subtitleTracks"`
 	FileSize       int64         `json:"fileSize"`
+	// VideoStreamIndex selects the video stream to transcode among video streams (0:v:N)
+	VideoStreamIndex int `json:"videoStreamIndex,omitempty"`
+	HDR            *HDRInfo      `json:"hdr,omitempty"`
+	// BitDepth of the video samples, from the pixel format; 0 if unknown
+	BitDepth int `json:"bitDepth,omitempty"`
+	// Level is the level_idc of the video stream, such as 41 for H.264 4.1 or
+	// 123 for H.265 4.1; 0 if unknown
+	Level int `json:"level,omitempty"`
+	// PixelFormat of the video stream as named by ffmpeg (yuv420p)
+	PixelFormat string `json:"pixelFormat,omitempty"`
+	// FieldOrder of the video stream as reported by ffprobe: progressive, tt,
+	// bb, tb or bt; empty if unknown
+	FieldOrder string `json:"fieldOrder,omitempty"`
+	// AvgFPS is the average frame rate of the video stream; 0 if unknown
+	AvgFPS float64 `json:"avgFps,omitempty"`
+	// VariableFrameRate is set when the average frame rate differs from the
+	// stream frame rate, as in screen and phone recordings
+	VariableFrameRate bool `json:"variableFrameRate,omitempty"`
+	// Rotation is the clockwise rotation in degrees (90, 180 or 270) the video
+	// is displayed with, from the display matrix of the stream. Width and
+	// Height are the displayed size.
+	Rotation int `json:"rotation,omitempty"`
+	// Crop is the picture area without black bars found by DetectCrop, which
+	// then sets Width and Height to its size; nil if the source is not cropped
+	Crop *CropRect `json:"crop,omitempty"`
+	// KeyframeInterval is the interval between the evenly spaced keyframes of
+	// the video stream, probed for passthrough profiles only; 0 if they are
+	// irregular or unknown
+	KeyframeInterval time.Duration `json:"keyframeInterval,omitempty"`
+	// SourceDuration is the duration of the whole source when the profile
+	// trims it; Duration is then the length of the trimmed segment
+	SourceDuration time.Duration `json:"sourceDuration,omitempty"`
+	// Raw is the full ffprobe JSON output; it is persisted separately and kept out of workflow payloads
+	Raw json.RawMessage `json:"-"`
+}
+
+// Interlaced reports whether ffprobe found the video stream to be interlaced
+func (m *VideoMetadata) Interlaced() bool {
+	if m == nil {
+		return false
+	}
+	switch m.FieldOrder {
+	case "tt", "bb", "tb", "bt":
+		return true
+	}
+	return false
+}
+
+// HDRFormat represents the HDR flavour of a source
+type HDRFormat string
+
+const (
+	HDRFormatHDR10       HDRFormat = "HDR10"
+	HDRFormatHDR10Plus   HDRFormat = "HDR10_PLUS"
+	HDRFormatHLG         HDRFormat = "HLG"
+	HDRFormatDolbyVision HDRFormat = "DOLBY_VISION"
+)
+
+// HDRInfo holds HDR signaling of the first video stream
+type HDRInfo struct {
+	Format         HDRFormat `json:"format"`
+	ColorTransfer  string    `json:"colorTransfer"`
+	ColorPrimaries string    `json:"colorPrimaries"`
+	ColorSpace     string    `json:"colorSpace"`
+	PixelFormat    string    `json:"pixelFormat"`
+	// HDR10Plus is set when SMPTE 2094-40 dynamic metadata is present
+	HDR10Plus bool `json:"hdr10Plus,omitempty"`
+	// Static HDR metadata, from the container or the first frame
+	MasteringDisplay *MasteringDisplay  `json:"masteringDisplay,omitempty"`
+	ContentLight     *ContentLightLevel `json:"contentLight,omitempty"`
+	// Dolby Vision configuration record, if present
+	DolbyVisionProfile       int `json:"dolbyVisionProfile,omitempty"`
+	DolbyVisionCompatibility int `json:"dolbyVisionCompatibility,omitempty"`
+}
+
+// HasDolbyVision returns true if the source carries Dolby Vision RPU
+func (h *HDRInfo) HasDolbyVision() bool {
+	return h != nil && h.DolbyVisionProfile > 0
+}
+
+// HasCompatibleBaseLayer returns false for Dolby Vision streams (profile 5)
+// whose base layer cannot be displayed without a Dolby Vision decoder
+func (h *HDRInfo) HasCompatibleBaseLayer() bool {
+	return !h.HasDolbyVision() || h.DolbyVisionCompatibility != 0
+}
+
+// CanPreserveDolbyVision reports whether libx265 can carry the RPU of the
+// source into the H.265 renditions: profile 8, whose single layer is HDR10,
+// SDR or HLG. Profile 7 loses its enhancement layer in decoding and profile 5
+// has no base layer to encode.
+func (h *HDRInfo) CanPreserveDolbyVision() bool {
+	return h.HasDolbyVision() && h.DolbyVisionProfile == 8 && h.DolbyVisionCompatibility != 0
+}
+
+// VideoRange returns the HLS VIDEO-RANGE value for the source transfer
+func (h *HDRInfo) VideoRange() string {
+	if h == nil {
+		return "SDR"
+	}
+	if h.ColorTransfer == "arib-std-b67" {
+		return "HLG"
+	}
+	return "PQ"
+}
+
+// MasteringDisplay is the SMPTE ST 2086 colour volume of the mastering display
+type MasteringDisplay struct {
+	// CIE 1931 xy chromaticity of the primaries and the white point
+	RedX   float64 `json:"redX"`
+	RedY   float64 `json:"redY"`
+	GreenX float64 `json:"greenX"`
+	GreenY float64 `json:"greenY"`
+	BlueX  float64 `json:"blueX"`
+	BlueY  float64 `json:"blueY"`
+	WhiteX float64 `json:"whiteX"`
+	WhiteY float64 `json:"whiteY"`
+	// Luminance in cd/m²
+	MinLuminance float64 `json:"minLuminance"`
+	MaxLuminance float64 `json:"maxLuminance"`
+}
+
+// ContentLightLevel is the CTA-861.3 light level of the content in cd/m²
+type ContentLightLevel struct {
+	MaxCLL  int `json:"maxCll"`
+	MaxFALL int `json:"maxFall"`
 }
 
 // AudioTrackInfo holds audio track metadata
@@ -25,6 +153,12 @@ type AudioTrackInfo struct {
 	Channels   int    `json:"channels"`
 	SampleRate int    `json:"sampleRate"`
 	Bitrate    int64  `json:"bitrate"`
+	// Description is set for an audio description track, flagged by the source
+	// disposition or marked in the profile
+	Description bool `json:"description,omitempty"`
+	// Passthrough is set for a surround track the profile copies into the
+	// modern tier, see ApplyAudioPolicy
+	Passthrough bool `json:"passthrough,omitempty"`
 }
 
 // SubtitleTrackInfo holds subtitle track metadata
@@ -33,6 +167,22 @@ type SubtitleTrackInfo struct {
 	Codec    string `json:"codec"`
 	Language string `json:"language"`
 	Title    string `json:"title"`
+	// Forced tracks only translate foreign dialogue and signs (forced disposition)
+	Forced bool `json:"forced,omitempty"`
+}
+
+// bitmapSubtitleCodecs carry images of the text, which ffmpeg cannot convert
+// to WebVTT
+var bitmapSubtitleCodecs = map[string]bool{
+	"hdmv_pgs_subtitle": true,
+	"dvd_subtitle":      true,
+	"dvb_subtitle":      true,
+}
+
+// IsBitmap reports whether the track is a bitmap subtitle (Blu-ray PGS, DVD
+// VobSub, DVB), which needs OCR to become text
+func (t SubtitleTrackInfo) IsBitmap() bool {
+	return bitmapSubtitleCodecs[t.Codec]
 }
 
 // SupportedContainers lists supported input containers
@@ -42,6 +192,29 @@ var SupportedContainers = map[string]bool{
 	"mov":  true,
 	"webm": true,
 	"avi":  true,
+	// Broadcast and professional masters
+	"mpegts": true,
+	"mxf":    true,
+	"flv":    true,
+	"3gp":    true,
+	// Adaptive streams read directly from an HTTP URL
+	"hls":  true,
+	"dash": true,
+}
+
+// SupportedAudioContainers lists the input containers of audio files, which
+// only audio-only profiles accept. M4A sources probe as mov.
+var SupportedAudioContainers = map[string]bool{
+	"mp3":  true,
+	"wav":  true,
+	"flac": true,
+	"ogg":  true,
+	"aac":  true,
+}
+
+// IsAdaptiveContainer returns true for HLS/DASH sources that expose every variant as a separate stream
+func IsAdaptiveContainer(container string) bool {
+	return container == "hls" || container == "dash"
 }
 
 // SupportedVideoCodecs lists supported input video codecs
@@ -54,6 +227,10 @@ var SupportedVideoCodecs = map[string]bool{
 	"av1":     true,
 	"mpeg4":   true,
 	"mpeg2video": true,
+	// Mezzanine and legacy broadcast codecs
+	"prores": true,
+	"dnxhd":  true,
+	"vc1":    true,
 }
 
 // SupportedAudioCodecs lists supported input audio codecs
@@ -84,11 +261,170 @@ func IsAudioCodecSupported(codec string) bool {
 	return SupportedAudioCodecs[codec]
 }
 
-// FilterQualitiesForResolution filters qualities based on source resolution
-func FilterQualitiesForResolution(qualities []Quality, sourceHeight int) []Quality {
-	var filtered []Quality
+// FormatPolicy adjusts the built-in supported formats: Allow entries are accepted
+// in addition to the defaults, Deny entries are rejected even if supported by default.
+type FormatPolicy struct {
+	AllowContainers  []string
+	DenyContainers   []string
+	AllowVideoCodecs []string
+	DenyVideoCodecs  []string
+	AllowAudioCodecs []string
+	DenyAudioCodecs  []string
+}
+
+// IsContainerSupported checks container against defaults and policy lists
+func (p FormatPolicy) IsContainerSupported(container string) bool {
+	return isFormatAllowed(container, SupportedContainers, p.AllowContainers, p.DenyContainers)
+}
+
+// IsAudioContainerSupported checks an audio file container against defaults
+// and the container policy lists
+func (p FormatPolicy) IsAudioContainerSupported(container string) bool {
+	return isFormatAllowed(container, SupportedAudioContainers, p.AllowContainers, p.DenyContainers)
+}
+
+// IsVideoCodecSupported checks video codec against defaults and policy lists
+func (p FormatPolicy) IsVideoCodecSupported(codec string) bool {
+	return isFormatAllowed(codec, SupportedVideoCodecs, p.AllowVideoCodecs, p.DenyVideoCodecs)
+}
+
+// IsAudioCodecSupported checks audio codec against defaults and policy lists
+func (p FormatPolicy) IsAudioCodecSupported(codec string) bool {
+	return isFormatAllowed(codec, SupportedAudioCodecs, p.AllowAudioCodecs, p.DenyAudioCodecs)
+}
+
+func isFormatAllowed(name string, supported map[string]bool, allow, deny []string) bool {
+	for _, d := range deny {
+		if d == name {
+			return false
+		}
+	}
+	for _, a := range allow {
+		if a == name {
+			return true
+		}
+	}
+	return supported[name]
+}
+
+// SelectAudioTracks keeps the audio tracks the profile selects, in source
+// order, and tags the language of tracks selected by index that declare none.
+// It returns the selecting entries that matched no track; when none matched
+// at all every track is kept.
+func (m *VideoMetadata) SelectAudioTracks(tracks []AudioTrack) []AudioTrack {
+	selecting := false
+	for _, t := range tracks {
+		selecting = selecting || !t.Description
+	}
+	if !selecting || len(m.AudioTracks) == 0 {
+		return nil
+	}
+
+	var (
+		selected []AudioTrackInfo
+		missing  []AudioTrack
+	)
+	matched := make([]bool, len(tracks))
+	for _, track := range m.AudioTracks {
+		keep := false
+		for i, t := range tracks {
+			if !t.Matches(track) {
+				continue
+			}
+			keep, matched[i] = true, true
+			if t.Index != nil && t.Language != "" && (track.Language == "" || track.Language == "und") {
+				track.Language = t.Language
+			}
+		}
+		if keep {
+			selected = append(selected, track)
+		}
+	}
+	for i, t := range tracks {
+		if !matched[i] && !t.Description {
+			missing = append(missing, t)
+		}
+	}
+	if len(selected) > 0 {
+		m.AudioTracks = selected
+	}
+	return missing
+}
+
+// MarkAudioDescription applies the audio description marks of a profile. A
+// marked track replaces any flagged by the source disposition. It returns
+// false if the marked track is not among the audio tracks.
+func (m *VideoMetadata) MarkAudioDescription(tracks []AudioTrack) bool {
+	for _, t := range tracks {
+		if !t.Description {
+			continue
+		}
+		found := false
+		for i := range m.AudioTracks {
+			// A language mark applies to the first track of the language
+			m.AudioTracks[i].Description = !found && t.Matches(m.AudioTracks[i])
+			found = found || m.AudioTracks[i].Description
+		}
+		return found
+	}
+	return true
+}
+
+// DescriptionTrack returns the position among the transcoded audio streams
+// and the info of the audio description track, or -1 and nil if there is
+// none. A description needs a main track to describe, so a lone one is not
+// reported.
+func (m *VideoMetadata) DescriptionTrack() (int, *AudioTrackInfo) {
+	if m == nil || len(m.AudioTracks) < 2 {
+		return -1, nil
+	}
+	for i := range m.AudioTracks {
+		if m.AudioTracks[i].Description {
+			return i, &m.AudioTracks[i]
+		}
+	}
+	return -1, nil
+}
+
+// MainAudioTrack returns the first audio track that is not an audio description
+func (m *VideoMetadata) MainAudioTrack() *AudioTrackInfo {
+	if m == nil {
+		return nil
+	}
+	for i := range m.AudioTracks {
+		if !m.AudioTracks[i].Description {
+			return &m.AudioTracks[i]
+		}
+	}
+	return nil
+}
+
+// ResolutionBucket names the largest standard quality the source reaches by
+// width or height, so letterboxed and portrait sources land in the expected
+// bucket. Sources below 480p are "sd".
+func (m *VideoMetadata) ResolutionBucket() string {
+	if m == nil || m.Width <= 0 || m.Height <= 0 {
+		return "unknown"
+	}
+	qualities := []Quality{Quality2160p, Quality1440p, Quality1080p, Quality720p, Quality576p, Quality480p}
 	for _, q := range qualities {
 		params := q.Params()
+		long, short := m.Width, m.Height
+		if short > long {
+			long, short = short, long
+		}
+		if long >= params.Width || short >= params.Height {
+			return string(q)
+		}
+	}
+	return "sd"
+}
+
+// FilterQualitiesForResolution filters qualities of a ladder based on source resolution
+func FilterQualitiesForResolution(qualities []Quality, ladder Ladder, sourceHeight int) []Quality {
+	var filtered []Quality
+	for _, q := range qualities {
+		params := ladder.Params(q)
 		// Include quality if source is tall enough or it's origin
 		if q == QualityOrigin || sourceHeight >= params.Height {
 			filtered = append(filtered, q)
@@ -0,0 +1,77 @@
+package domain
+
+import "math"
+
+// passthroughCodecs are the ffprobe names of the tier codecs
+var passthroughCodecs = map[VideoCodec]string{
+	VideoCodecH264: "h264",
+	VideoCodecH265: "hevc",
+}
+
+// CanPassthrough reports whether the rendition of quality in tier can be a
+// stream copy of the source video. The profile has to enable passthrough, and
+// the source has to be upright progressive 8-bit 4:2:0 SDR in the codec of the tier, fit into the
+// rung and stay within its maximum bitrate (scaled for H.265 like the encoded
+// renditions), with no crop, frame rate conversion, cap or trim applying. Its
+// keyframes have to be spaced by the GOP of the profile, which has to divide
+// segments of segmentDuration seconds, so the copy is cut into the same
+// segments as the encoded renditions. The origin rendition has no size or
+// bitrate limit.
+func (p Profile) CanPassthrough(meta *VideoMetadata, tier EncodingTier, quality Quality, segmentDuration int) bool {
+	// Bumpers are concatenated without re-encoding, which needs the encoder
+	// settings of the converter in the rendition
+	if !p.Passthrough || p.HasBumpers() || meta == nil || meta.HDR != nil {
+		return false
+	}
+	// A stream copy can only be cut at keyframes
+	if p.Trim != nil && (p.Trim.Start > 0 || p.Trim.End > 0) {
+		return false
+	}
+	codec := GetTierConfig(tier).VideoCodec
+	if meta.VideoCodec != passthroughCodecs[codec] {
+		return false
+	}
+	if meta.PixelFormat != "yuv420p" && meta.PixelFormat != "yuvj420p" {
+		return false
+	}
+	// Interlaced video is deinterlaced, rotated video turned upright, black
+	// bars are cropped and frame rate conversion drops or duplicates frames,
+	// which all need encoding
+	if p.Algorithm.DeinterlaceFilter(meta) != "" || meta.Rotation != 0 || meta.Crop != nil ||
+		p.Algorithm.ConvertedFrameRate(meta) > 0 {
+		return false
+	}
+	if !p.Algorithm.keyframesAligned(meta, segmentDuration) {
+		return false
+	}
+
+	height := meta.Height
+	if quality != QualityOrigin {
+		params := p.QualityParams(quality)
+		maxBitrate := int64(float64(parseBitrate(params.MaxBitrate)) * codec.BitrateMultiplier())
+		if meta.Width > params.Width || meta.Height > params.Height {
+			return false
+		}
+		// The container bitrate includes audio, so this errs towards encoding
+		if meta.Bitrate <= 0 || maxBitrate <= 0 || meta.Bitrate > maxBitrate {
+			return false
+		}
+		height = params.Height
+	}
+	return p.Algorithm.FrameRateDivisor(height, meta.FPS) <= 1
+}
+
+// keyframesAligned reports whether the keyframes of the source fall every GOP
+// frames like those of the encoded renditions, and segments of segmentDuration
+// seconds hold a whole number of GOPs
+func (a AlgorithmConfig) keyframesAligned(meta *VideoMetadata, segmentDuration int) bool {
+	if meta.KeyframeInterval <= 0 || meta.FPS <= 0 || segmentDuration <= 0 {
+		return false
+	}
+	gop := float64(a.GOPSize(meta, 1)) / meta.FPS
+	if math.Abs(meta.KeyframeInterval.Seconds()-gop) > 0.5/meta.FPS {
+		return false
+	}
+	gops := float64(segmentDuration) / gop
+	return gops >= 1 && math.Abs(gops-math.Round(gops)) < 1e-3
+}
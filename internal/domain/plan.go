@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// JobPlan is what a job would produce, computed by a dry run without encoding.
+// Sizes are estimated from the nominal bitrates of the quality ladder.
+type JobPlan struct {
+	Tiers      []EncodingTier     `json:"tiers"`
+	Qualities  []Quality          `json:"qualities"`
+	Mezzanine  bool               `json:"mezzanine"`
+	Parallel   bool               `json:"parallel"`
+	Renditions []PlannedRendition `json:"renditions"`
+	// EncodeMinutes is source duration × renditions, as counted by the output budget
+	EncodeMinutes     int   `json:"encodeMinutes"`
+	EstimatedBytes    int64 `json:"estimatedBytes"`
+	RequiredDiskBytes int64 `json:"requiredDiskBytes"`
+	// EstimatedEncodeSeconds is based on the speed of recent transcodes; 0 without history
+	EstimatedEncodeSeconds int64 `json:"estimatedEncodeSeconds,omitempty"`
+}
+
+// PlannedRendition is a (tier, quality) rendition of a JobPlan
+type PlannedRendition struct {
+	Tier           EncodingTier `json:"tier"`
+	Quality        Quality      `json:"quality"`
+	Width          int          `json:"width,omitempty"`
+	Height         int          `json:"height,omitempty"`
+	Bandwidth      int64        `json:"bandwidth"` // Video and audio, bits per second
+	EstimatedBytes int64        `json:"estimatedBytes"`
+}
+
+// NewJobPlan plans the renditions of every tier and quality of a ladder for a
+// source. Origin renditions keep the source resolution and bitrate.
+func NewJobPlan(tiers []EncodingTier, qualities []Quality, ladder Ladder, mezzanine bool, metadata *VideoMetadata) *JobPlan {
+	plan := &JobPlan{
+		Tiers:      tiers,
+		Qualities:  qualities,
+		Mezzanine:  mezzanine,
+		Renditions: make([]PlannedRendition, 0, len(tiers)*len(qualities)),
+	}
+
+	seconds := metadata.Duration.Seconds()
+	for _, tier := range tiers {
+		multiplier := GetTierConfig(tier).VideoCodec.BitrateMultiplier()
+		for _, q := range qualities {
+			rendition := PlannedRendition{Tier: tier, Quality: q}
+			if q == QualityOrigin {
+				rendition.Width, rendition.Height = metadata.Width, metadata.Height
+				rendition.Bandwidth = metadata.Bitrate
+			} else {
+				params := ladder.Params(q)
+				rendition.Width, rendition.Height = params.Width, params.Height
+				rendition.Bandwidth = int64(float64(parseBitrate(params.VideoBitrate))*multiplier) +
+					parseBitrate(params.AudioBitrate)
+			}
+			rendition.EstimatedBytes = int64(float64(rendition.Bandwidth) / 8 * seconds)
+			plan.EstimatedBytes += rendition.EstimatedBytes
+			plan.Renditions = append(plan.Renditions, rendition)
+		}
+	}
+
+	renditions := len(plan.Renditions)
+	if mezzanine {
+		renditions++
+	}
+	plan.EncodeMinutes = int(math.Ceil(metadata.Duration.Minutes() * float64(renditions)))
+
+	return plan
+}
+
+// EstimateEncodeTime sets the expected encode time from the transcoding wall
+// time per second of source; a speed of 0 leaves it unknown
+func (p *JobPlan) EstimateEncodeTime(speed float64, duration time.Duration) {
+	p.EstimatedEncodeSeconds = int64(math.Ceil(speed * duration.Seconds()))
+}
+
+// parseBitrate parses an ffmpeg bitrate such as "1500k" into bits per second
+func parseBitrate(bitrate string) int64 {
+	bitrate = strings.TrimSuffix(strings.ToLower(bitrate), "k")
+	var value int64
+	fmt.Sscanf(bitrate, "%d", &value)
+	return value * 1000
+}
@@ -1,5 +1,17 @@
 package domain
 
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
 // Quality represents video quality preset
 type Quality string
 
@@ -13,6 +25,11 @@ const (
 	QualityOrigin Quality = "origin"
 )
 
+// IsAudioOnly reports whether the profile produces audio-only renditions
+func (p Profile) IsAudioOnly() bool {
+	return p.AudioOnly != nil
+}
+
 // QualityParams returns encoding parameters for quality
 func (q Quality) Params() QualityConfig {
 	configs := map[Quality]QualityConfig{
@@ -79,12 +96,182 @@ type QualityConfig struct {
 	MaxBitrate   string
 	BufSize      string
 	AudioBitrate string
+	// CRF overrides the encoder's quality target; 0 keeps the default
+	CRF int
+}
+
+// QualityRung is a rung of a custom bitrate ladder. Its name is used as a
+// quality of the profile; a built-in name such as "720p" overrides that rung.
+type QualityRung struct {
+	Name         Quality `json:"name"`
+	Width        int     `json:"width"`
+	Height       int     `json:"height"`
+	VideoBitrate string  `json:"videoBitrate"`
+	// MaxBitrate and BufSize default to 4/3 and twice the video bitrate
+	MaxBitrate   string `json:"maxBitrate,omitempty"`
+	BufSize      string `json:"bufSize,omitempty"`
+	AudioBitrate string `json:"audioBitrate,omitempty"` // Defaults to 128k
+	CRF          int    `json:"crf,omitempty"`
+}
+
+// Params returns the encoding parameters of the rung with defaults applied
+func (r QualityRung) Params() QualityConfig {
+	params := QualityConfig{
+		Width:        r.Width,
+		Height:       r.Height,
+		VideoBitrate: r.VideoBitrate,
+		MaxBitrate:   r.MaxBitrate,
+		BufSize:      r.BufSize,
+		AudioBitrate: r.AudioBitrate,
+		CRF:          r.CRF,
+	}
+	video := parseBitrate(r.VideoBitrate) / 1000
+	if params.MaxBitrate == "" {
+		params.MaxBitrate = fmt.Sprintf("%dk", video*4/3)
+	}
+	if params.BufSize == "" {
+		params.BufSize = fmt.Sprintf("%dk", video*2)
+	}
+	if params.AudioBitrate == "" {
+		params.AudioBitrate = "128k"
+	}
+	return params
+}
+
+// rungName matches names of custom rungs, which become parts of output paths,
+// playlist names and metric labels
+var rungName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Ladder is a custom bitrate ladder. Qualities without a rung keep the built-in parameters.
+type Ladder []QualityRung
+
+// Params returns the encoding parameters of q
+func (l Ladder) Params(q Quality) QualityConfig {
+	for _, r := range l {
+		if r.Name == q {
+			return r.Params()
+		}
+	}
+	return q.Params()
+}
+
+// Has reports whether q is a rung of the ladder or a built-in quality
+func (l Ladder) Has(q Quality) bool {
+	return q == QualityOrigin || l.Params(q).Width > 0
+}
+
+// Names returns the qualities of the rungs in ladder order
+func (l Ladder) Names() []Quality {
+	names := make([]Quality, len(l))
+	for i, r := range l {
+		names[i] = r.Name
+	}
+	return names
 }
 
-// AudioTrack represents an audio track configuration
+// Validate checks rung names, dimensions and bitrates
+func (l Ladder) Validate() error {
+	seen := make(map[Quality]bool, len(l))
+	for _, r := range l {
+		switch {
+		case !rungName.MatchString(string(r.Name)):
+			return fmt.Errorf("qualitiesCustom: invalid rung name %q", r.Name)
+		case r.Name == QualityOrigin:
+			return fmt.Errorf("qualitiesCustom: %s keeps the source parameters and cannot be a rung", QualityOrigin)
+		case seen[r.Name]:
+			return fmt.Errorf("qualitiesCustom: duplicate rung %s", r.Name)
+		}
+		seen[r.Name] = true
+
+		// Encoders and chroma subsampling need even dimensions
+		if r.Width <= 0 || r.Height <= 0 || r.Width%2 != 0 || r.Height%2 != 0 {
+			return fmt.Errorf("qualitiesCustom: %s needs positive even width and height", r.Name)
+		}
+		for _, b := range []string{r.VideoBitrate, r.MaxBitrate, r.BufSize, r.AudioBitrate} {
+			if b != "" && !validBitrate(b) {
+				return fmt.Errorf("qualitiesCustom: %s has invalid bitrate %q, expected e.g. \"3000k\"", r.Name, b)
+			}
+		}
+		if r.VideoBitrate == "" {
+			return fmt.Errorf("qualitiesCustom: %s needs videoBitrate", r.Name)
+		}
+		if r.CRF < 0 || r.CRF > 51 {
+			return fmt.Errorf("qualitiesCustom: %s crf must be between 0 and 51", r.Name)
+		}
+	}
+	return nil
+}
+
+// validBitrate reports whether b is a positive bitrate in kilobits such as "3000k"
+func validBitrate(b string) bool {
+	digits := strings.TrimSuffix(strings.ToLower(b), "k")
+	if digits == b || digits == "" {
+		return false
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return parseBitrate(b) > 0
+}
+
+// AudioTrack selects or marks a source audio track, by stream index or, without
+// one, by language. Entries that are not descriptions select: when the profile
+// has any, only the matched tracks are transcoded. A list of description marks
+// alone keeps every track.
 type AudioTrack struct {
-	Index    int    `json:"index"`
-	Language string `json:"language"`
+	// Index is the stream index of the track in the source, as in ffprobe
+	Index *int `json:"index,omitempty"`
+	// Language matches tracks without Index; with Index it tags a source
+	// track that declares no language
+	Language string `json:"language,omitempty"`
+	// Description marks the matched track as audio description of the video
+	// for visually impaired viewers
+	Description bool `json:"description,omitempty"`
+	// Surround overrides the surround policy of the profile audio for the
+	// matched track
+	Surround string `json:"surround,omitempty"`
+}
+
+// Matches reports whether the entry matches a source audio track
+func (t AudioTrack) Matches(track AudioTrackInfo) bool {
+	if t.Index != nil {
+		return *t.Index == track.Index
+	}
+	return strings.EqualFold(t.Language, track.Language)
+}
+
+// String names the entry in warnings
+func (t AudioTrack) String() string {
+	if t.Index != nil {
+		return fmt.Sprintf("with index %d", *t.Index)
+	}
+	return fmt.Sprintf("in language %q", t.Language)
+}
+
+// ValidateAudioTracks checks that every entry names a track and at most one is
+// marked as audio description
+func ValidateAudioTracks(tracks []AudioTrack) error {
+	marked := 0
+	for _, t := range tracks {
+		if t.Index == nil && t.Language == "" {
+			return fmt.Errorf("audioTracks entries need an index or a language")
+		}
+		if t.Index != nil && *t.Index < 0 {
+			return fmt.Errorf("audioTracks index must not be negative")
+		}
+		if err := validateSurround(t.Surround); err != nil {
+			return err
+		}
+		if t.Description {
+			marked++
+		}
+	}
+	if marked > 1 {
+		return fmt.Errorf("at most one audio track can be marked as description")
+	}
+	return nil
 }
 
 // SubtitleTrack represents a subtitle track configuration
@@ -93,10 +280,101 @@ type SubtitleTrack struct {
 	Language string `json:"language"`
 }
 
+// subtitleExtensions are the text subtitle formats accepted as files
+var subtitleExtensions = []string{".srt", ".ass", ".ssa", ".vtt"}
+
+// SubtitleSource is a subtitle file supplied next to the source, converted
+// to WebVTT like the tracks extracted from it
+type SubtitleSource struct {
+	S3Key string `json:"s3Key"`
+	// Bucket holding the file; empty is the source bucket of the job
+	Bucket string `json:"bucket,omitempty"`
+	// Language of the subtitles as an ISO 639 code
+	Language string `json:"language"`
+	Title    string `json:"title,omitempty"`
+	// Forced marks subtitles of signs and foreign dialog only
+	Forced bool `json:"forced,omitempty"`
+}
+
+// Validate checks the key, its format and the language
+func (s *SubtitleSource) Validate() error {
+	if s.S3Key == "" {
+		return fmt.Errorf("s3Key is required")
+	}
+	if !slices.Contains(subtitleExtensions, strings.ToLower(path.Ext(s.S3Key))) {
+		return fmt.Errorf("%s must be one of %s", s.S3Key, strings.Join(subtitleExtensions, ", "))
+	}
+	if !languageCode.MatchString(s.Language) {
+		return fmt.Errorf("%s: language must be a lowercase ISO 639 code such as rus", s.S3Key)
+	}
+	return nil
+}
+
+// Track describes the file as a subtitle track. It is not a stream of the
+// source, so its index is -1.
+func (s SubtitleSource) Track() SubtitleTrackInfo {
+	return SubtitleTrackInfo{Index: -1, Language: s.Language, Title: s.Title, Forced: s.Forced}
+}
+
 // HLSConfig holds HLS generation parameters
 type HLSConfig struct {
 	SegmentDurationSec int  `json:"segmentDurationSec"`
 	PlaylistType       string `json:"playlistType"`
+	// Encryption overrides the global AES-128 toggle for this job when set
+	Encryption *bool `json:"encryption,omitempty"`
+	// KeyURL overrides the global key URL template, e.g. "https://example.com/keys/{job_id}/key"
+	KeyURL string `json:"keyUrl,omitempty"`
+	// PreviewSec leaves the first N seconds unencrypted for try-before-auth playback.
+	// The window is rounded up to a segment boundary.
+	PreviewSec int `json:"previewSec,omitempty"`
+	// SegmentType overrides HLS_SEGMENT_TYPE: ts or fmp4 segments for the
+	// legacy tier
+	SegmentType ContainerFormat `json:"segmentType,omitempty"`
+	// SingleFile overrides HLS_SINGLE_FILE when set
+	SingleFile *bool `json:"singleFile,omitempty"`
+}
+
+// Validate checks the segment type
+func (c HLSConfig) Validate() error {
+	if c.SegmentType != "" && !c.SegmentType.IsValid() {
+		return fmt.Errorf("hls.segmentType must be ts or fmp4")
+	}
+	return nil
+}
+
+// EncryptionEnabled returns the per-job encryption setting, falling back to the global default
+func (c HLSConfig) EncryptionEnabled(defaultEnabled bool) bool {
+	if c.Encryption != nil {
+		return *c.Encryption
+	}
+	return defaultEnabled
+}
+
+// SingleFileEnabled returns the per-job single-file setting, falling back to the global default
+func (c HLSConfig) SingleFileEnabled(defaultEnabled bool) bool {
+	if c.SingleFile != nil {
+		return *c.SingleFile
+	}
+	return defaultEnabled
+}
+
+// SegmentContainer returns the per-job segment container of the legacy tier,
+// falling back to the global default. With fmp4 every tier is CMAF: segmented
+// once, with the HLS playlists and the DASH manifest referencing the same
+// segments.
+func (c HLSConfig) SegmentContainer(defaultType string) ContainerFormat {
+	if c.SegmentType != "" {
+		return c.SegmentType
+	}
+	return ContainerFormat(defaultType)
+}
+
+// KeyURLTemplate returns the per-job key URL template, falling back to the global default
+func (c HLSConfig) KeyURLTemplate(defaultTemplate string) string {
+	if c.KeyURL != "" {
+		return c.KeyURL
+	}
+	return defaultTemplate
 }
 
 // ThumbnailsConfig holds thumbnail generation parameters
@@ -108,28 +386,593 @@ type ThumbnailsConfig struct {
 	Height    int `json:"height"`
 }
 
-// IntroConfig holds intro/watermark configuration
+// TrimConfig selects the segment of the source that is converted, in seconds
+// from the start of the source
+type TrimConfig struct {
+	Start float64 `json:"start,omitempty"`
+	// End of the segment; 0 is the end of the source
+	End float64 `json:"end,omitempty"`
+}
+
+// Validate checks that the segment is not empty
+func (t *TrimConfig) Validate() error {
+	if t.Start < 0 || t.End < 0 {
+		return fmt.Errorf("trim start and end must not be negative")
+	}
+	if t.End > 0 && t.End <= t.Start {
+		return fmt.Errorf("trim end must be after start")
+	}
+	return nil
+}
+
+// Offset returns the position of the segment in the source
+func (t *TrimConfig) Offset() time.Duration {
+	if t == nil {
+		return 0
+	}
+	return time.Duration(t.Start * float64(time.Second))
+}
+
+// Duration returns the length of the segment of a source of the given
+// duration. A segment of a source of unknown duration is unknown too, unless
+// it ends at a set position.
+func (t *TrimConfig) Duration(source time.Duration) time.Duration {
+	if t == nil {
+		return source
+	}
+	end := source
+	if t.End > 0 {
+		if e := time.Duration(t.End * float64(time.Second)); source <= 0 || e < source {
+			end = e
+		}
+	}
+	if end <= 0 {
+		return end
+	}
+	return max(end-t.Offset(), 0)
+}
+
+// Bumper scale modes
+const (
+	BumperScaleFit  = "fit"  // letterboxed or pillarboxed into the frame
+	BumperScaleFill = "fill" // cropped to fill the frame
+)
+
+// IntroConfig holds a bumper clip stitched before (intro) or after (outro)
+// the video of every rendition
 type IntroConfig struct {
-	S3Key     string `json:"s3Key"`
+	S3Key string `json:"s3Key"`
+	// Bucket holding the clip; empty is the source bucket of the job
+	Bucket string `json:"bucket,omitempty"`
+	// ScaleMode fits (default) or fills the clip into the frame of the renditions
 	ScaleMode string `json:"scaleMode"`
 }
 
+// Validate checks the clip key and scale mode
+func (c *IntroConfig) Validate() error {
+	if c.S3Key == "" {
+		return fmt.Errorf("s3Key is required")
+	}
+	switch c.ScaleMode {
+	case "", BumperScaleFit, BumperScaleFill:
+	default:
+		return fmt.Errorf("scaleMode must be fit or fill")
+	}
+	return nil
+}
+
 // AlgorithmConfig holds A/V sync parameters
 type AlgorithmConfig struct {
 	FPS            float64 `json:"fps"`
 	GOP            int     `json:"gop"`
 	AresampleAsync int     `json:"aresampleAsync"`
+	// FPSCap limits the frame rate of the lower rungs of the ladder; nil keeps the source rate
+	FPSCap *FPSCap `json:"fpsCap,omitempty"`
+	// FrameRate converts the frame rate of every rendition; nil keeps the source
+	// rate and converts variable frame rate sources to a constant one
+	FrameRate *FrameRateConfig `json:"frameRate,omitempty"`
+	// Deinterlace overrides deinterlacing; nil deinterlaces sources detected as interlaced with bwdif
+	Deinterlace *DeinterlaceConfig `json:"deinterlace,omitempty"`
+	// AutoCrop detects black bars before transcoding and crops them from every
+	// rendition; nil encodes the full frame
+	AutoCrop *AutoCropConfig `json:"autoCrop,omitempty"`
+}
+
+// defaultGOP is the keyframe interval in frames when the profile sets none
+const defaultGOP = 48
+
+// FPSCap limits the output frame rate of renditions up to MaxHeight, e.g. 60 fps
+// sources encoded at 30 fps for 720p and below
+type FPSCap struct {
+	MaxFPS float64 `json:"maxFps"`
+	// MaxHeight is the tallest rendition that is capped; 0 caps every rendition
+	MaxHeight int `json:"maxHeight,omitempty"`
+}
+
+// Validate checks the cap limits
+func (c *FPSCap) Validate() error {
+	if c.MaxFPS <= 0 {
+		return fmt.Errorf("fpsCap.maxFps must be positive")
+	}
+	if c.MaxHeight < 0 {
+		return fmt.Errorf("fpsCap.maxHeight must not be negative")
+	}
+	return nil
+}
+
+// Variable frame rate handling
+const (
+	VFRConvert = "cfr"  // converted to a constant rate near their average
+	VFRKeep    = "keep" // encoded with the timestamps of the source
+)
+
+// defaultVFRRate is the constant rate of variable frame rate sources whose
+// average frame rate is unknown
+const defaultVFRRate = 30
+
+// FrameRateConfig converts the frame rate of every rendition, e.g. 50 fps
+// masters to 25 fps or problem VFR sources to a constant rate. The fps filter
+// duplicates and drops frames against the timestamps, which keeps the
+// video in sync with the audio.
+type FrameRateConfig struct {
+	// FPS is the output frame rate, a whole or NTSC rate (23.976, 29.97,
+	// 59.94); 0 keeps the source rate
+	FPS float64 `json:"fps,omitempty"`
+	// VFR selects the handling of variable frame rate sources without FPS:
+	// cfr (default) or keep
+	VFR string `json:"vfr,omitempty"`
+}
+
+// Validate checks the frame rate and VFR handling
+func (c *FrameRateConfig) Validate() error {
+	if c.FPS < 0 || c.FPS > 120 {
+		return fmt.Errorf("frameRate.fps must be between 0 and 120")
+	}
+	if num, den := FrameRate(c.FPS, 1); c.FPS > 0 && math.Abs(float64(num)/float64(den)-c.FPS) > 0.01 {
+		return fmt.Errorf("frameRate.fps must be a whole or NTSC frame rate")
+	}
+	switch c.VFR {
+	case "", VFRConvert, VFRKeep:
+	default:
+		return fmt.Errorf("frameRate.vfr must be cfr or keep")
+	}
+	return nil
+}
+
+// ConvertedFrameRate returns the constant frame rate the source is converted
+// to before encoding, 0 if its own rate is kept: the FPS of the profile, or for
+// variable frame rate sources their average rate rounded to whole frames.
+func (a AlgorithmConfig) ConvertedFrameRate(meta *VideoMetadata) float64 {
+	var c FrameRateConfig
+	if a.FrameRate != nil {
+		c = *a.FrameRate
+	}
+	if c.FPS > 0 {
+		return c.FPS
+	}
+	if meta == nil || !meta.VariableFrameRate || c.VFR == VFRKeep {
+		return 0
+	}
+	if fps := math.Round(meta.AvgFPS); fps >= 1 {
+		return fps
+	}
+	return defaultVFRRate
+}
+
+// BaseFrameRate returns the frame rate renditions are derived from: the
+// converted rate, or the source rate if it is kept
+func (a AlgorithmConfig) BaseFrameRate(meta *VideoMetadata) float64 {
+	if fps := a.ConvertedFrameRate(meta); fps > 0 {
+		return fps
+	}
+	if meta == nil {
+		return 0
+	}
+	return meta.FPS
+}
+
+// FrameRateFilter returns the fps filter converting the source to a constant
+// frame rate, empty if the source rate is kept
+func (a AlgorithmConfig) FrameRateFilter(meta *VideoMetadata) string {
+	fps := a.ConvertedFrameRate(meta)
+	if fps <= 0 {
+		return ""
+	}
+	num, den := FrameRate(fps, 1)
+	return fmt.Sprintf("fps=%d/%d", num, den)
+}
+
+// Deinterlace modes
+const (
+	DeinterlaceAuto = "auto" // sources ffprobe reports as interlaced
+	DeinterlaceOn   = "on"   // every source, for masters with wrong field flags
+	DeinterlaceOff  = "off"
+)
+
+// Deinterlace filters
+const (
+	DeinterlaceBwdif = "bwdif"
+	DeinterlaceYadif = "yadif"
+)
+
+// DeinterlaceConfig selects when and with which filter sources are deinterlaced
+type DeinterlaceConfig struct {
+	// Mode is auto (default), on or off
+	Mode string `json:"mode,omitempty"`
+	// Filter is bwdif (default) or yadif
+	Filter string `json:"filter,omitempty"`
+}
+
+// Validate checks the mode and filter
+func (c *DeinterlaceConfig) Validate() error {
+	switch c.Mode {
+	case "", DeinterlaceAuto, DeinterlaceOn, DeinterlaceOff:
+	default:
+		return fmt.Errorf("deinterlace.mode must be auto, on or off")
+	}
+	switch c.Filter {
+	case "", DeinterlaceBwdif, DeinterlaceYadif:
+	default:
+		return fmt.Errorf("deinterlace.filter must be bwdif or yadif")
+	}
+	return nil
+}
+
+// DeinterlaceFilter returns the ffmpeg filter that deinterlaces the source,
+// empty if it is progressive or deinterlacing is off. One frame is output per
+// frame, so the frame rate is kept. In auto mode only frames flagged as
+// interlaced are filtered, as broadcast masters often mix in progressive parts.
+func (a AlgorithmConfig) DeinterlaceFilter(meta *VideoMetadata) string {
+	var c DeinterlaceConfig
+	if a.Deinterlace != nil {
+		c = *a.Deinterlace
+	}
+	deint := "interlaced"
+	switch c.Mode {
+	case DeinterlaceOff:
+		return ""
+	case DeinterlaceOn:
+		deint = "all"
+	default:
+		if !meta.Interlaced() {
+			return ""
+		}
+	}
+	filter := c.Filter
+	if filter == "" {
+		filter = DeinterlaceBwdif
+	}
+	return fmt.Sprintf("%s=mode=send_frame:parity=auto:deint=%s", filter, deint)
+}
+
+// FrameRateDivisor returns the integer the source frame rate of a rendition of
+// the given height is divided by: the smallest one that brings it to MaxFPS or
+// below, so every kept frame is a source frame. It is 1 for uncapped renditions
+// and sources of unknown frame rate.
+func (a AlgorithmConfig) FrameRateDivisor(height int, sourceFPS float64) int {
+	c := a.FPSCap
+	if c == nil || c.MaxFPS <= 0 || sourceFPS <= 0 || (c.MaxHeight > 0 && height > c.MaxHeight) {
+		return 1
+	}
+	divisor := int(math.Ceil(sourceFPS/c.MaxFPS - 1e-6))
+	if divisor < 1 {
+		return 1
+	}
+	return divisor
+}
+
+// FrameRate returns the source frame rate divided by divisor as a fraction.
+// NTSC rates such as 59.94 are kept exact as multiples of 1000/1001.
+func FrameRate(sourceFPS float64, divisor int) (num, den int) {
+	if ntsc := sourceFPS * 1.001; math.Abs(ntsc-math.Round(ntsc)) < 0.01 && math.Abs(sourceFPS-math.Round(sourceFPS)) > 0.01 {
+		return int(math.Round(ntsc)) * 1000, 1001 * divisor
+	}
+	return int(math.Round(sourceFPS)), divisor
+}
+
+// GOPSize returns the keyframe interval in frames of a rendition whose frame
+// rate is divided by divisor. It is shortened by the divisor when that divides
+// it evenly, so keyframes fall at the same times in every rendition of the
+// ladder; otherwise the frame count is kept, which still lands on them. When a
+// constant-rate source is converted to another rate, the interval is first
+// rescaled to keep its duration.
+func (a AlgorithmConfig) GOPSize(meta *VideoMetadata, divisor int) int {
+	gop := a.GOP
+	if gop <= 0 {
+		gop = defaultGOP
+	}
+	if fps := a.ConvertedFrameRate(meta); fps > 0 && meta != nil && !meta.VariableFrameRate && meta.FPS > 0 {
+		gop = int(math.Max(1, math.Round(float64(gop)*fps/meta.FPS)))
+	}
+	if divisor > 1 && gop%divisor == 0 {
+		return gop / divisor
+	}
+	return gop
+}
+
+// MezzanineCodec represents an intra-frame codec for archival/editing masters
+type MezzanineCodec string
+
+const (
+	MezzanineCodecProRes MezzanineCodec = "prores"
+	MezzanineCodecDNxHR  MezzanineCodec = "dnxhr"
+)
+
+// IsValid checks if the mezzanine codec is known
+func (c MezzanineCodec) IsValid() bool {
+	return c == MezzanineCodecProRes || c == MezzanineCodecDNxHR
+}
+
+// MezzanineConfig holds optional high-bitrate mezzanine output parameters.
+// The mezzanine is uploaded as an artifact and never packaged to HLS.
+type MezzanineConfig struct {
+	Codec MezzanineCodec `json:"codec"`
+	// Profile is the codec profile: prores "proxy", "lt", "standard", "hq", "4444";
+	// dnxhr "lb", "sq", "hq", "hqx", "444"
+	Profile string `json:"profile,omitempty"`
+}
+
+// OutputBudget caps the encoding work a job may request. Zero fields are unlimited.
+type OutputBudget struct {
+	// MaxRenditions limits encoded renditions: qualities × tiers, plus the mezzanine
+	MaxRenditions int `json:"maxRenditions,omitempty"`
+	// MaxEncodeMinutes limits source duration × renditions
+	MaxEncodeMinutes int `json:"maxEncodeMinutes,omitempty"`
+}
+
+// Tighten returns a budget with the stricter limit of b and other for each field
+func (b OutputBudget) Tighten(other OutputBudget) OutputBudget {
+	stricter := func(x, y int) int {
+		if x <= 0 || (y > 0 && y < x) {
+			return y
+		}
+		return x
+	}
+	return OutputBudget{
+		MaxRenditions:    stricter(b.MaxRenditions, other.MaxRenditions),
+		MaxEncodeMinutes: stricter(b.MaxEncodeMinutes, other.MaxEncodeMinutes),
+	}
+}
+
+// Check returns an error if encoding renditions of a source of the given duration exceeds the budget
+func (b OutputBudget) Check(renditions int, duration time.Duration) error {
+	if b.MaxRenditions > 0 && renditions > b.MaxRenditions {
+		return fmt.Errorf("%d renditions exceed the limit of %d", renditions, b.MaxRenditions)
+	}
+	encodeMinutes := int(math.Ceil(duration.Minutes() * float64(renditions)))
+	if b.MaxEncodeMinutes > 0 && encodeMinutes > b.MaxEncodeMinutes {
+		return fmt.Errorf("%d encode minutes (%d renditions of %s) exceed the limit of %d",
+			encodeMinutes, renditions, duration.Round(time.Second), b.MaxEncodeMinutes)
+	}
+	return nil
+}
+
+// RetryPolicy is the Temporal retry policy and start-to-close timeout of an
+// activity group. Zero fields are inherited.
+type RetryPolicy struct {
+	MaxAttempts        int     `json:"maxAttempts,omitempty"`
+	InitialIntervalSec int     `json:"initialIntervalSec,omitempty"`
+	BackoffCoefficient float64 `json:"backoffCoefficient,omitempty"`
+	MaxIntervalSec     int     `json:"maxIntervalSec,omitempty"`
+	TimeoutSec         int     `json:"timeoutSec,omitempty"`
+	// TimeoutPerMinuteSec extends TimeoutSec per started minute of source duration
+	TimeoutPerMinuteSec int `json:"timeoutPerMinuteSec,omitempty"`
+}
+
+// Override returns p with the non-zero fields of other applied
+func (p RetryPolicy) Override(other *RetryPolicy) RetryPolicy {
+	if other == nil {
+		return p
+	}
+	if other.MaxAttempts > 0 {
+		p.MaxAttempts = other.MaxAttempts
+	}
+	if other.InitialIntervalSec > 0 {
+		p.InitialIntervalSec = other.InitialIntervalSec
+	}
+	if other.BackoffCoefficient > 0 {
+		p.BackoffCoefficient = other.BackoffCoefficient
+	}
+	if other.MaxIntervalSec > 0 {
+		p.MaxIntervalSec = other.MaxIntervalSec
+	}
+	if other.TimeoutSec > 0 {
+		p.TimeoutSec = other.TimeoutSec
+	}
+	if other.TimeoutPerMinuteSec > 0 {
+		p.TimeoutPerMinuteSec = other.TimeoutPerMinuteSec
+	}
+	return p
+}
+
+// ForDuration returns p with the timeout extended for a source of the given duration
+func (p RetryPolicy) ForDuration(duration time.Duration) RetryPolicy {
+	if p.TimeoutPerMinuteSec > 0 && duration > 0 {
+		p.TimeoutSec += int(math.Ceil(duration.Minutes())) * p.TimeoutPerMinuteSec
+	}
+	p.TimeoutPerMinuteSec = 0
+	return p
+}
+
+// Validate rejects negative fields and a backoff coefficient below 1
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 0 || p.InitialIntervalSec < 0 || p.MaxIntervalSec < 0 || p.TimeoutSec < 0 || p.TimeoutPerMinuteSec < 0 {
+		return fmt.Errorf("retry policy values must not be negative")
+	}
+	if p.BackoffCoefficient != 0 && p.BackoffCoefficient < 1 {
+		return fmt.Errorf("backoffCoefficient must be at least 1")
+	}
+	return nil
+}
+
+// RetryPolicies holds the retry policies of activity groups
+type RetryPolicies struct {
+	// Default applies to validation, subtitles, thumbnails, HLS and output verification
+	Default   *RetryPolicy `json:"default,omitempty"`
+	Metadata  *RetryPolicy `json:"metadata,omitempty"`
+	Transcode *RetryPolicy `json:"transcode,omitempty"`
+	Upload    *RetryPolicy `json:"upload,omitempty"`
+}
+
+// Override returns r with the non-zero fields of other applied group by group
+func (r RetryPolicies) Override(other *RetryPolicies) RetryPolicies {
+	if other == nil {
+		return r
+	}
+	override := func(base, o *RetryPolicy) *RetryPolicy {
+		if base == nil {
+			return o
+		}
+		merged := base.Override(o)
+		return &merged
+	}
+	return RetryPolicies{
+		Default:   override(r.Default, other.Default),
+		Metadata:  override(r.Metadata, other.Metadata),
+		Transcode: override(r.Transcode, other.Transcode),
+		Upload:    override(r.Upload, other.Upload),
+	}
+}
+
+// ForDuration returns r with the timeouts of every group extended for a source of the given duration
+func (r RetryPolicies) ForDuration(duration time.Duration) RetryPolicies {
+	scale := func(p *RetryPolicy) *RetryPolicy {
+		if p == nil {
+			return nil
+		}
+		scaled := p.ForDuration(duration)
+		return &scaled
+	}
+	return RetryPolicies{
+		Default:   scale(r.Default),
+		Metadata:  scale(r.Metadata),
+		Transcode: scale(r.Transcode),
+		Upload:    scale(r.Upload),
+	}
+}
+
+// Validate validates every group
+func (r RetryPolicies) Validate() error {
+	groups := []struct {
+		name   string
+		policy *RetryPolicy
+	}{
+		{"default", r.Default},
+		{"metadata", r.Metadata},
+		{"transcode", r.Transcode},
+		{"upload", r.Upload},
+	}
+	for _, g := range groups {
+		if g.policy == nil {
+			continue
+		}
+		if err := g.policy.Validate(); err != nil {
+			return fmt.Errorf("retry.%s: %w", g.name, err)
+		}
+	}
+	return nil
+}
+
+// StageOptions lets a profile skip optional pipeline stages
+type StageOptions struct {
+	SkipSubtitles  bool `json:"skipSubtitles,omitempty"`
+	SkipThumbnails bool `json:"skipThumbnails,omitempty"`
+	// SkipHLS uploads the transcoded MP4 renditions instead of HLS/DASH packages
+	SkipHLS bool `json:"skipHLS,omitempty"`
+	// TranscodeOnly skips subtitles, thumbnails and HLS
+	TranscodeOnly bool `json:"transcodeOnly,omitempty"`
+}
+
+// Skips reports whether stage is disabled by the options
+func (o StageOptions) Skips(stage Stage) bool {
+	switch stage {
+	case StageSubtitlesExtraction:
+		return o.SkipSubtitles || o.TranscodeOnly
+	case StageThumbnailsGen:
+		return o.SkipThumbnails || o.TranscodeOnly
+	case StageHLSSegmentation:
+		return o.SkipHLS || o.TranscodeOnly
+	default:
+		return false
+	}
 }
 
 // Profile represents the conversion profile
 type Profile struct {
 	Qualities   []Quality       `json:"qualities"`
+	// QualitiesCustom defines the rungs of the ladder by name; without Qualities
+	// every rung is encoded
+	QualitiesCustom Ladder `json:"qualitiesCustom,omitempty"`
 	AudioTracks []AudioTrack    `json:"audioTracks,omitempty"`
 	Subtitles   []SubtitleTrack `json:"subtitles,omitempty"`
+	// ExternalSubtitles are subtitle files of the source, always set from the
+	// checked subtitles of a job request and rejected in profile templates
+	ExternalSubtitles []SubtitleSource `json:"externalSubtitles,omitempty"`
 	HLS         HLSConfig       `json:"hls"`
 	Thumbnails  ThumbnailsConfig `json:"thumbnails"`
 	Intro       *IntroConfig     `json:"intro,omitempty"`
+	Outro       *IntroConfig     `json:"outro,omitempty"`
+	// Trim converts only a segment of the source; the startTime and endTime of
+	// a job request override it
+	Trim *TrimConfig `json:"trim,omitempty"`
 	Algorithm   AlgorithmConfig  `json:"algorithm"`
+	Mezzanine   *MezzanineConfig `json:"mezzanine,omitempty"`
+	// Budget tightens the worker-wide ENCODING_MAX_* limits for this profile
+	Budget *OutputBudget `json:"budget,omitempty"`
+	// Retry overrides the RETRY_<GROUP>_* activity retry policies for this profile
+	Retry *RetryPolicies `json:"retry,omitempty"`
+	// Transcoder selects the transcode backend; empty uses the worker's TRANSCODER_BACKEND
+	Transcoder string `json:"transcoder,omitempty"`
+	// PerTitle scales the ladder to the complexity of the source; ENCODING_PER_TITLE enables it with defaults
+	PerTitle *PerTitleConfig `json:"perTitle,omitempty"`
+	// BitDepth overrides ENCODING_H264_BIT_DEPTH and ENCODING_H265_BIT_DEPTH
+	BitDepth *BitDepthConfig `json:"bitDepth,omitempty"`
+	// Encoder overrides the H264_* and H265_* software encoder settings per
+	// tier and quality
+	Encoder *EncoderConfig `json:"encoder,omitempty"`
+	// Audio sets the surround policy and the downmix of surround tracks
+	Audio *AudioConfig `json:"audio,omitempty"`
+	// AudioOnly produces audio-only renditions and an audio master playlist
+	// instead of video renditions
+	AudioOnly *AudioOnlyConfig `json:"audioOnly,omitempty"`
+	// Passthrough copies the source video into renditions it already complies
+	// with instead of encoding it, see CanPassthrough
+	Passthrough bool `json:"passthrough,omitempty"`
+	// ProgressiveMP4 publishes the transcoded MP4 of every quality for
+	// download, next to the HLS output
+	ProgressiveMP4 bool `json:"progressiveMp4,omitempty"`
+	// Hardsub adds a set of renditions with a subtitle track burned in
+	Hardsub *HardsubConfig `json:"hardsub,omitempty"`
+	// CuePoints mark ad breaks in the HLS playlists and DASH manifest
+	CuePoints []CuePoint `json:"cuePoints,omitempty"`
+	StageOptions
+}
+
+// HasBumpers reports whether an intro or outro is stitched to the renditions
+func (p Profile) HasBumpers() bool {
+	return p.Intro != nil || p.Outro != nil
+}
+
+// QualityParams returns the encoding parameters of q under the profile's ladder
+func (p Profile) QualityParams(q Quality) QualityConfig {
+	return p.QualitiesCustom.Params(q)
+}
+
+// ValidateLadder checks the custom ladder and that every quality is one of its
+// rungs or built in. A profile with only custom rungs gets them as its qualities.
+func (p *Profile) ValidateLadder() error {
+	if err := p.QualitiesCustom.Validate(); err != nil {
+		return err
+	}
+	if len(p.Qualities) == 0 {
+		p.Qualities = p.QualitiesCustom.Names()
+	}
+	for _, q := range p.Qualities {
+		if !p.QualitiesCustom.Has(q) {
+			return fmt.Errorf("unknown quality: %s", q)
+		}
+	}
+	return nil
 }
 
 // DefaultProfile returns a default conversion profile
@@ -153,3 +996,27 @@ func DefaultProfile() Profile {
 		},
 	}
 }
+
+// ProfileTemplate is a named, versioned profile stored centrally and referenced by jobs
+type ProfileTemplate struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	Profile     Profile   `json:"profile" db:"profile"`
+	Version     int       `json:"version" db:"version"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// NewProfileTemplate creates a new profile template
+func NewProfileTemplate(name string, profile Profile) *ProfileTemplate {
+	now := time.Now().UTC()
+	return &ProfileTemplate{
+		ID:        uuid.New(),
+		Name:      name,
+		Profile:   profile,
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
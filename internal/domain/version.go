@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PublishAction records how a version of a video became live
+type PublishAction string
+
+const (
+	// PublishActionComplete is the automatic publication of a completed job
+	PublishActionComplete PublishAction = "COMPLETE"
+	// PublishActionPromote is an explicit promotion of a job through the API
+	PublishActionPromote PublishAction = "PROMOTE"
+	// PublishActionRollback republishes the job live before the current one
+	PublishActionRollback PublishAction = "ROLLBACK"
+)
+
+// PublishedVersion is one entry of a video's publication history. The entry
+// with the highest version is the live output of the video.
+type PublishedVersion struct {
+	ID      uuid.UUID `json:"id" db:"id"`
+	VideoID uuid.UUID `json:"videoId" db:"video_id"`
+	Version int       `json:"version" db:"version"`
+	// JobID is nil once the published job has been deleted
+	JobID     *uuid.UUID    `json:"jobId,omitempty" db:"job_id"`
+	Action    PublishAction `json:"action" db:"action"`
+	CreatedAt time.Time     `json:"createdAt" db:"created_at"`
+}
+
+// NewPublishedVersion creates a publication of a job; the version is assigned on save
+func NewPublishedVersion(videoID, jobID uuid.UUID, action PublishAction) *PublishedVersion {
+	return &PublishedVersion{
+		ID:        uuid.New(),
+		VideoID:   videoID,
+		JobID:     &jobID,
+		Action:    action,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// CurrentPrefix returns the S3 prefix of the stable pointer objects of a video
+func CurrentPrefix(videoID uuid.UUID) string {
+	return videoID.String() + "/current"
+}
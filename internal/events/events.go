@@ -0,0 +1,180 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/config"
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// Type identifies an event
+type Type string
+
+const (
+	JobCreated     Type = "job.created"
+	JobCompleted   Type = "job.completed"
+	JobFailed      Type = "job.failed"
+	JobCanceled    Type = "job.canceled"
+	JobQuarantined Type = "job.quarantined"
+	JobPaused      Type = "job.paused"
+	JobResumed     Type = "job.resumed"
+	StageStarted   Type = "stage.started"
+	StageCompleted Type = "stage.completed"
+	StageFailed    Type = "stage.failed"
+)
+
+// Event is a job or stage lifecycle event delivered to sinks
+type Event struct {
+	ID      uuid.UUID        `json:"id"`
+	Type    Type             `json:"type"`
+	JobID   uuid.UUID        `json:"jobId"`
+	Status  domain.JobStatus `json:"status,omitempty"`
+	Stage   domain.Stage     `json:"stage,omitempty"`
+	Attempt int              `json:"attempt,omitempty"`
+	Error   string           `json:"error,omitempty"`
+	// Reason and Actor are set for canceled jobs
+	Reason string    `json:"reason,omitempty"`
+	Actor  string    `json:"actor,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// JobEvent creates a job-level event
+func JobEvent(eventType Type, jobID uuid.UUID, status domain.JobStatus) Event {
+	return Event{
+		ID:     uuid.New(),
+		Type:   eventType,
+		JobID:  jobID,
+		Status: status,
+		Time:   time.Now().UTC(),
+	}
+}
+
+// StageEvent creates a stage-level event
+func StageEvent(eventType Type, jobID uuid.UUID, stage domain.Stage, attempt int) Event {
+	return Event{
+		ID:      uuid.New(),
+		Type:    eventType,
+		JobID:   jobID,
+		Stage:   stage,
+		Attempt: attempt,
+		Time:    time.Now().UTC(),
+	}
+}
+
+// Sink delivers events to an external system
+type Sink interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Factory creates a sink from configuration
+type Factory func(cfg config.EventsConfig, logger *zap.Logger) (Sink, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register makes a sink available under name for EVENT_SINKS.
+// Built-in sinks register themselves; new integrations only need to call Register.
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[name] = factory
+}
+
+// Registered returns the names of registered sinks
+func Registered() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Bus fans events out to sinks in the background so slow integrations never
+// block the API or activities. A nil Bus discards events.
+type Bus struct {
+	sinks   map[string]Sink
+	logger  *zap.Logger
+	timeout time.Duration
+	queue   chan Event
+	done    chan struct{}
+}
+
+// New creates a bus with the sinks listed in cfg.Sinks
+func New(cfg config.EventsConfig, logger *zap.Logger) (*Bus, error) {
+	b := &Bus{
+		sinks:   make(map[string]Sink),
+		logger:  logger,
+		timeout: cfg.PublishTimeout,
+		queue:   make(chan Event, cfg.QueueSize),
+		done:    make(chan struct{}),
+	}
+
+	for _, name := range cfg.Sinks {
+		factoriesMu.RLock()
+		factory, ok := factories[name]
+		factoriesMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown event sink %q, available: %v", name, Registered())
+		}
+		sink, err := factory(cfg, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create event sink %q: %w", name, err)
+		}
+		b.sinks[name] = sink
+	}
+
+	go b.run()
+	return b, nil
+}
+
+// Publish queues an event for delivery. Events are dropped when the queue is full.
+func (b *Bus) Publish(event Event) {
+	if b == nil || len(b.sinks) == 0 {
+		return
+	}
+	select {
+	case b.queue <- event:
+	default:
+		b.logger.Warn("event queue full, dropping event",
+			zap.String("type", string(event.Type)),
+			zap.String("jobId", event.JobID.String()))
+	}
+}
+
+// Close delivers queued events and stops the bus
+func (b *Bus) Close() {
+	if b == nil {
+		return
+	}
+	close(b.queue)
+	<-b.done
+}
+
+func (b *Bus) run() {
+	defer close(b.done)
+	for event := range b.queue {
+		for name, sink := range b.sinks {
+			ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+			if err := sink.Publish(ctx, event); err != nil {
+				b.logger.Warn("failed to publish event",
+					zap.String("sink", name),
+					zap.String("type", string(event.Type)),
+					zap.String("jobId", event.JobID.String()),
+					zap.Error(err))
+			}
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/config"
+)
+
+func init() {
+	Register("kafka", func(cfg config.EventsConfig, _ *zap.Logger) (Sink, error) {
+		if cfg.KafkaRESTURL == "" || cfg.KafkaTopic == "" {
+			return nil, fmt.Errorf("EVENT_KAFKA_REST_URL and EVENT_KAFKA_TOPIC are required")
+		}
+		return NewKafkaSink(cfg.KafkaRESTURL, cfg.KafkaTopic), nil
+	})
+}
+
+// KafkaSink produces events to a Kafka topic through the Confluent REST Proxy (v2 API).
+// The job ID is used as the record key so events of one job stay ordered in a partition.
+type KafkaSink struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewKafkaSink creates a Kafka sink for the REST Proxy at baseURL
+func NewKafkaSink(baseURL, topic string) *KafkaSink {
+	return &KafkaSink{
+		endpoint: strings.TrimRight(baseURL, "/") + "/topics/" + topic,
+		client:   &http.Client{},
+	}
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value Event  `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+// Publish produces the event
+func (s *KafkaSink) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(kafkaProduceRequest{
+		Records: []kafkaRecord{{Key: event.JobID.String(), Value: event}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to produce event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("kafka rest proxy returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package events
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/config"
+)
+
+func init() {
+	Register("log", func(_ config.EventsConfig, logger *zap.Logger) (Sink, error) {
+		return NewLogSink(logger), nil
+	})
+}
+
+// LogSink writes events to the application log
+type LogSink struct {
+	logger *zap.Logger
+}
+
+// NewLogSink creates a log sink
+func NewLogSink(logger *zap.Logger) *LogSink {
+	return &LogSink{logger: logger.Named("events")}
+}
+
+// Publish logs the event
+func (s *LogSink) Publish(_ context.Context, event Event) error {
+	s.logger.Info("event",
+		zap.String("id", event.ID.String()),
+		zap.String("type", string(event.Type)),
+		zap.String("jobId", event.JobID.String()),
+		zap.String("status", string(event.Status)),
+		zap.String("stage", string(event.Stage)),
+		zap.Int("attempt", event.Attempt),
+		zap.String("error", event.Error),
+	)
+	return nil
+}
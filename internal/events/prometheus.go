@@ -0,0 +1,42 @@
+package events
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/config"
+)
+
+func init() {
+	Register("prometheus", func(_ config.EventsConfig, _ *zap.Logger) (Sink, error) {
+		return NewPrometheusSink(prometheus.DefaultRegisterer)
+	})
+}
+
+// PrometheusSink counts events by type and stage
+type PrometheusSink struct {
+	events *prometheus.CounterVec
+}
+
+// NewPrometheusSink creates a Prometheus sink registered in reg
+func NewPrometheusSink(reg prometheus.Registerer) (*PrometheusSink, error) {
+	events := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "converter_events_total",
+			Help: "Total number of job and stage events by type and stage",
+		},
+		[]string{"type", "stage"},
+	)
+	if err := reg.Register(events); err != nil {
+		return nil, err
+	}
+	return &PrometheusSink{events: events}, nil
+}
+
+// Publish increments the event counter
+func (s *PrometheusSink) Publish(_ context.Context, event Event) error {
+	s.events.WithLabelValues(string(event.Type), string(event.Stage)).Inc()
+	return nil
+}
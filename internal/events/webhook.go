@@ -0,0 +1,73 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/config"
+)
+
+func init() {
+	Register("webhook", func(cfg config.EventsConfig, _ *zap.Logger) (Sink, error) {
+		if cfg.WebhookURL == "" {
+			return nil, fmt.Errorf("EVENT_WEBHOOK_URL is required")
+		}
+		return NewWebhookSink(cfg.WebhookURL, cfg.WebhookSecret), nil
+	})
+}
+
+// WebhookSink POSTs events as JSON to an HTTP endpoint
+type WebhookSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookSink creates a webhook sink. With a secret, the body is signed with
+// HMAC-SHA256 and the hex digest is sent in the X-Converter-Signature header.
+func NewWebhookSink(url, secret string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		secret: secret,
+		client: &http.Client{},
+	}
+}
+
+// Publish sends the event to the webhook
+func (s *WebhookSink) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Converter-Event", string(event.Type))
+	if s.secret != "" {
+		mac := hmac.New(sha256.New, []byte(s.secret))
+		mac.Write(body)
+		req.Header.Set("X-Converter-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
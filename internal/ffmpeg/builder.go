@@ -2,8 +2,12 @@ package ffmpeg
 
 import (
 	"fmt"
+	"math"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/tvoe/converter/internal/config"
 	"github.com/tvoe/converter/internal/domain"
@@ -12,19 +16,161 @@ import (
 // CommandBuilder builds FFmpeg commands
 type CommandBuilder struct {
 	ffmpegPath     string
-	enableGPU      bool
+	hwaccel        config.HWAccel
+	hwDevice       string
 	encodingConfig *config.EncodingConfig
+	threads        int
+	safe           bool
+	trim           *domain.TrimConfig
+	hdr10Plus      string
+	audio          *domain.AudioConfig
+	container      domain.ContainerFormat
+	singleFile     bool
 }
 
-// NewCommandBuilder creates a new command builder
-func NewCommandBuilder(ffmpegPath string, enableGPU bool, encodingConfig *config.EncodingConfig) *CommandBuilder {
+// NewCommandBuilder creates a new command builder encoding with the given
+// hardware backend, or on the CPU with config.HWAccelNone
+func NewCommandBuilder(ffmpegPath string, hwaccel config.HWAccel, encodingConfig *config.EncodingConfig) *CommandBuilder {
 	return &CommandBuilder{
 		ffmpegPath:     ffmpegPath,
-		enableGPU:      enableGPU,
+		hwaccel:        hwaccel,
 		encodingConfig: encodingConfig,
 	}
 }
 
+// WithHWDevice selects the device of the hardware backend: the GPU index for
+// NVENC, the DRM render node for VAAPI and QSV. Empty selects the default one.
+func (b *CommandBuilder) WithHWDevice(device string) *CommandBuilder {
+	b.hwDevice = device
+	return b
+}
+
+// WithThreads sets the encoder thread budget (defaults to 2)
+func (b *CommandBuilder) WithThreads(threads int) *CommandBuilder {
+	b.threads = threads
+	return b
+}
+
+// WithTrim makes commands reading the source read only the trimmed segment;
+// nil reads all of it. Builders for inputs derived from the source, such as
+// renditions, must not have it set.
+func (b *CommandBuilder) WithTrim(trim *domain.TrimConfig) *CommandBuilder {
+	b.trim = trim
+	return b
+}
+
+// WithHDR10Plus makes H.265 encodes of HDR10 renditions that keep every
+// frame of the source carry the HDR10+ metadata of the JSON at path, as
+// written by ExtractHDR10Plus; empty strips it.
+func (b *CommandBuilder) WithHDR10Plus(path string) *CommandBuilder {
+	b.hdr10Plus = path
+	return b
+}
+
+// WithAudio sets the downmix of surround tracks; nil uses the defaults
+func (b *CommandBuilder) WithAudio(audio *domain.AudioConfig) *CommandBuilder {
+	b.audio = audio
+	return b
+}
+
+// WithSegmentContainer sets the HLS segment container of the legacy tier;
+// fmp4 segments every tier as CMAF
+func (b *CommandBuilder) WithSegmentContainer(container domain.ContainerFormat) *CommandBuilder {
+	b.container = container
+	return b
+}
+
+// WithSingleFile makes HLS renditions one file each, addressed by
+// EXT-X-BYTERANGE, instead of a file per segment. fMP4 files hold their init
+// section as well.
+func (b *CommandBuilder) WithSingleFile(singleFile bool) *CommandBuilder {
+	b.singleFile = singleFile
+	return b
+}
+
+// SingleFile reports whether HLS renditions are written as one file each
+func (b *CommandBuilder) SingleFile() bool {
+	return b.singleFile
+}
+
+// segmentFilename returns the -hls_segment_filename of the rendition name:
+// numbered segments, or the only file of single-file output
+func (b *CommandBuilder) segmentFilename(outputDir, name string, container domain.ContainerFormat) string {
+	if b.singleFile {
+		return filepath.Join(outputDir, name+container.SegmentExtension())
+	}
+	return filepath.Join(outputDir, name+"_%05d"+container.SegmentExtension())
+}
+
+// hlsFlagArgs returns the -hls_flags of single-file output
+func (b *CommandBuilder) hlsFlagArgs() []string {
+	if b.singleFile {
+		return []string{"-hls_flags", "single_file"}
+	}
+	return nil
+}
+
+// TierConfig returns the codec configuration of tier, in the segment
+// container of the builder
+func (b *CommandBuilder) TierConfig(tier domain.EncodingTier) domain.TierConfig {
+	return domain.GetTierConfig(tier).WithContainer(b.container)
+}
+
+// trimArgs returns the input options seeking to the trimmed segment of the source
+func (b *CommandBuilder) trimArgs() []string {
+	var args []string
+	if b.trim != nil && b.trim.Start > 0 {
+		args = append(args, "-ss", fmt.Sprintf("%.3f", b.trim.Start))
+	}
+	if b.trim != nil && b.trim.End > 0 {
+		args = append(args, "-to", fmt.Sprintf("%.3f", b.trim.End))
+	}
+	return args
+}
+
+// WithoutGPU returns a copy of the builder that uses CPU decoding and encoding
+func (b *CommandBuilder) WithoutGPU() *CommandBuilder {
+	c := *b
+	c.hwaccel = config.HWAccelNone
+	return &c
+}
+
+// WithSafeSettings returns a copy of the builder for renditions the regular
+// settings failed to encode: CPU encoders, a fast preset, 8-bit output (HDR is
+// tonemapped to SDR) and no B-frames used as references
+func (b *CommandBuilder) WithSafeSettings() *CommandBuilder {
+	c := *b
+	c.hwaccel = config.HWAccelNone
+	c.safe = true
+	return &c
+}
+
+// UsesGPU reports whether the builder encodes with a hardware backend
+func (b *CommandBuilder) UsesGPU() bool {
+	return b.hwaccel != "" && b.hwaccel != config.HWAccelNone
+}
+
+// KeepsHDR reports whether the hardware backend encodes HDR10 H.265 with its
+// static metadata. NVENC, QSV and VAAPI encode P010 frames as Main10 and write
+// the mastering display and content light level SEI from the side data the
+// decoder attaches to every frame (NVENC needs ffmpeg 7.1 or later).
+// VideoToolbox does not, and HDR10+ and Dolby Vision are written by libx265 only.
+func (b *CommandBuilder) KeepsHDR() bool {
+	switch b.hwaccel {
+	case config.HWAccelNVENC, config.HWAccelQSV, config.HWAccelVAAPI:
+		return true
+	}
+	return false
+}
+
+// threadCount returns the configured encoder thread count
+func (b *CommandBuilder) threadCount() int {
+	if b.threads > 0 {
+		return b.threads
+	}
+	return 2
+}
+
 // TranscodeCommand holds transcode command parameters
 type TranscodeCommand struct {
 	Args       []string
@@ -39,22 +185,20 @@ func (b *CommandBuilder) BuildTranscodeCommand(
 	metadata *domain.VideoMetadata,
 	profile domain.Profile,
 ) *TranscodeCommand {
-	params := quality.Params()
+	params := profile.QualityParams(quality)
 	outputPath := filepath.Join(outputDir, string(quality)+".mp4")
 
 	args := []string{
 		"-y",
 	}
 
-	// Enable GPU decoding with CUVID when GPU encoding is enabled
-	if b.enableGPU {
-		args = append(args,
-			"-hwaccel", "cuda",
-			"-hwaccel_output_format", "cuda",
-			"-c:v", "h264_cuvid",
-		)
+	// Decode on the GPU when encoding on it
+	if b.UsesGPU() {
+		args = append(args, b.hwDecodeArgs(metadata, b.framesOnCPU(domain.VideoCodecH264, metadata, profile))...)
 	}
 
+	args = append(args, rotationArgs(metadata)...)
+	args = append(args, b.trimArgs()...)
 	args = append(args,
 		"-i", inputPath,
 		"-progress", "pipe:1",
@@ -65,14 +209,14 @@ func (b *CommandBuilder) BuildTranscodeCommand(
 	args = append(args, b.buildStreamMappings(metadata)...)
 
 	// Video encoding
-	if b.enableGPU {
+	if b.UsesGPU() {
 		args = append(args, b.buildGPUVideoArgs(quality, params, metadata, profile)...)
 	} else {
 		args = append(args, b.buildCPUVideoArgs(quality, params, metadata, profile)...)
 	}
 
 	// Audio encoding (applies to all mapped audio tracks)
-	args = append(args, b.buildAudioArgs(metadata)...)
+	args = append(args, b.buildAudioArgs(metadata, domain.TierLegacy)...)
 
 	// Output format
 	args = append(args,
@@ -86,60 +230,114 @@ func (b *CommandBuilder) BuildTranscodeCommand(
 	}
 }
 
+// buildGPUVideoArgs builds H.264 video encoding arguments for the hardware backend
 func (b *CommandBuilder) buildGPUVideoArgs(quality domain.Quality, params domain.QualityConfig, metadata *domain.VideoMetadata, profile domain.Profile) []string {
-	args := []string{
-		"-c:v", "h264_nvenc",
-		"-preset", "p2",        // Faster preset for better throughput
-		"-tune", "hq",
-		"-rc", "vbr",
-		"-cq", "23",
-		"-b_ref_mode", "middle", // Use B-frames as references for better quality
-		"-spatial_aq", "1",      // Spatial AQ for better visual quality
-		"-temporal_aq", "1",     // Temporal AQ for motion optimization
+	args := b.hwEncoderArgs(domain.VideoCodecH264, quality, rungCRF(params, 23))
+	// The profile of the encoder settings is signaled in CODECS, see VideoCodecString
+	args = append(args, "-profile:v", b.hwH264Profile(b.encoderSettings(domain.VideoCodecH264, quality, profile).Profile))
+
+	// HDR tonemapping and reduction to the 8 bits hardware encoders encode
+	// H.264 at run on the CPU, with frames decoded to system memory
+	framesOnCPU := b.framesOnCPU(domain.VideoCodecH264, metadata, profile)
+	filters := sourceFilters(metadata, profile)
+	if metadata.HDR != nil {
+		filters = append(filters, hdrToSDRFilter)
+		args = append(args, sdrColorArgs...)
+	}
+	pixFmt, upload := b.hwFrameFormat(framesOnCPU, 8)
+	if pixFmt != "" {
+		args = append(args, "-pix_fmt", pixFmt)
 	}
 
 	if quality != domain.QualityOrigin {
-		// Use GPU-accelerated scaling with scale_npp (works with CUVID decoder)
-		args = append(args, "-vf", fmt.Sprintf("scale_npp=%d:%d",
-			params.Width, params.Height))
+		if framesOnCPU {
+			filters = append(filters, cpuScaleFilter(params))
+		} else {
+			filters = append(filters, b.hwScaleFilter(params))
+		}
 		args = append(args, "-b:v", params.VideoBitrate)
 		args = append(args, "-maxrate", params.MaxBitrate)
 		args = append(args, "-bufsize", params.BufSize)
 	}
+	if upload != "" {
+		filters = append(filters, upload)
+	}
 
-	// GOP settings
-	gop := profile.Algorithm.GOP
-	if gop == 0 {
-		gop = 48
+	if len(filters) > 0 {
+		args = append(args, "-vf", strings.Join(filters, ","))
 	}
+
+	// Frame rate cap and GOP settings
+	rateArgs, divisor := frameRateArgs(quality, metadata, profile)
+	args = append(args, rateArgs...)
+	gop := profile.Algorithm.GOPSize(metadata, divisor)
 	args = append(args, "-g", fmt.Sprintf("%d", gop))
 
 	return args
 }
 
 func (b *CommandBuilder) buildCPUVideoArgs(quality domain.Quality, params domain.QualityConfig, metadata *domain.VideoMetadata, profile domain.Profile) []string {
+	settings := b.encoderSettings(domain.VideoCodecH264, quality, profile)
+	preset := settings.Preset
+	if b.safe {
+		preset = safePreset
+	}
+
+	h264Profile, pixFmt := "high", "yuv420p"
+	if settings.Profile != "" {
+		h264Profile = settings.Profile
+	}
+	if b.bitDepth(domain.VideoCodecH264, metadata, profile) == 10 {
+		h264Profile, pixFmt = "high10", "yuv420p10le"
+	}
+
 	args := []string{
 		"-c:v", "libx264",
-		"-preset", "slower",
-		"-crf", "23",
-		"-profile:v", "high",
-		"-level", "4.1",
-		"-threads", "2",
+		"-preset", preset,
+		"-crf", strconv.Itoa(rungCRF(params, 23)),
+		"-profile:v", h264Profile,
+	}
+	if settings.Level != "" {
+		args = append(args, "-level", settings.Level)
+	}
+	args = append(args,
+		"-pix_fmt", pixFmt,
+		"-threads", strconv.Itoa(b.threadCount()),
+	)
+	// Safe settings drop the tune and extra params, which may be what fails
+	if b.safe {
+		args = append(args, "-x264-params", "b-pyramid=none")
+	} else {
+		if settings.Tune != "" {
+			args = append(args, "-tune", settings.Tune)
+		}
+		if settings.Params != "" {
+			args = append(args, "-x264-params", settings.Params)
+		}
+	}
+
+	// H.264 output is 8-bit SDR: HDR sources are tonemapped instead of being squashed
+	filters := sourceFilters(metadata, profile)
+	if metadata.HDR != nil {
+		filters = append(filters, hdrToSDRFilter)
+		args = append(args, sdrColorArgs...)
 	}
 
 	if quality != domain.QualityOrigin {
-		args = append(args, "-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2",
-			params.Width, params.Height, params.Width, params.Height))
+		filters = append(filters, cpuScaleFilter(params))
 		args = append(args, "-b:v", params.VideoBitrate)
 		args = append(args, "-maxrate", params.MaxBitrate)
 		args = append(args, "-bufsize", params.BufSize)
 	}
 
-	// GOP settings
-	gop := profile.Algorithm.GOP
-	if gop == 0 {
-		gop = 48
+	if len(filters) > 0 {
+		args = append(args, "-vf", strings.Join(filters, ","))
 	}
+
+	// Frame rate cap and GOP settings
+	rateArgs, divisor := frameRateArgs(quality, metadata, profile)
+	args = append(args, rateArgs...)
+	gop := profile.Algorithm.GOPSize(metadata, divisor)
 	args = append(args, "-g", fmt.Sprintf("%d", gop))
 	args = append(args, "-keyint_min", fmt.Sprintf("%d", gop))
 	args = append(args, "-sc_threshold", "0")
@@ -147,17 +345,215 @@ func (b *CommandBuilder) buildCPUVideoArgs(quality domain.Quality, params domain
 	return args
 }
 
+// frameRateArgs returns the -r option of a rendition whose frame rate the
+// profile caps and the divisor applied to the source rate (after any frame rate
+// conversion), 1 if it is uncapped.
+// The output option drops frames after the filtergraph, so it also works with
+// CUDA frames and -filter_complex outputs.
+func frameRateArgs(quality domain.Quality, metadata *domain.VideoMetadata, profile domain.Profile) ([]string, int) {
+	if metadata == nil {
+		return nil, 1
+	}
+	height := profile.QualityParams(quality).Height
+	if quality == domain.QualityOrigin {
+		height = metadata.Height
+	}
+	fps := profile.Algorithm.BaseFrameRate(metadata)
+	divisor := profile.Algorithm.FrameRateDivisor(height, fps)
+	if divisor <= 1 {
+		return nil, 1
+	}
+	num, den := domain.FrameRate(fps, divisor)
+	return []string{"-r", fmt.Sprintf("%d/%d", num, den)}, divisor
+}
+
+// rungCRF returns the CRF of a custom ladder rung, or def when it sets none
+func rungCRF(params domain.QualityConfig, def int) int {
+	if params.CRF > 0 {
+		return params.CRF
+	}
+	return def
+}
+
+// encoderSettings returns the libx264 or libx265 settings of a rendition: the
+// H264_* or H265_* defaults of the worker with the encoder settings of the
+// profile for codec and quality applied
+func (b *CommandBuilder) encoderSettings(codec domain.VideoCodec, quality domain.Quality, profile domain.Profile) domain.EncoderSettings {
+	base := domain.EncoderSettings{Preset: "slower", Level: "4.1"}
+	if codec == domain.VideoCodecH265 {
+		base = domain.EncoderSettings{Preset: "medium"}
+	}
+	if cfg := b.encodingConfig; cfg != nil {
+		if codec == domain.VideoCodecH265 {
+			base = base.Override(&domain.EncoderSettings{
+				Preset: cfg.H265Preset, Tune: cfg.H265Tune, Level: cfg.H265Level, Params: cfg.H265Params,
+			})
+		} else {
+			base = base.Override(&domain.EncoderSettings{
+				Preset: cfg.H264Preset, Tune: cfg.H264Tune, Level: cfg.H264Level, Params: cfg.H264Params,
+			})
+		}
+	}
+	return profile.Encoder.Settings(base, codec, quality)
+}
+
+// safePreset is the x264/x265 preset of safe settings
+const safePreset = "veryfast"
+
+// hdrToSDRFilter tonemaps PQ/HLG BT.2020 to BT.709 SDR (requires zimg)
+const hdrToSDRFilter = "zscale=t=linear:npl=100,format=gbrpf32le,zscale=p=bt709,tonemap=tonemap=hable:desat=0,zscale=t=bt709:m=bt709:r=tv,format=yuv420p"
+
+// sdrColorArgs tag the output of hdrToSDRFilter as BT.709
+var sdrColorArgs = []string{
+	"-color_primaries", "bt709",
+	"-color_trc", "bt709",
+	"-colorspace", "bt709",
+}
+
+// cudaDecodeArgs returns the input options of NVDEC decoding. Frames stay in
+// CUDA memory for scale_npp unless they are filtered on the CPU, which needs
+// them downloaded after decoding. HDR and 10-bit sources are rarely H.264, so
+// they use the native decoder with the CUDA hwaccel. device is the GPU index,
+// empty for the default GPU.
+func cudaDecodeArgs(metadata *domain.VideoMetadata, cpuFilters bool, device string) []string {
+	args := []string{"-hwaccel", "cuda"}
+	if device != "" {
+		args = append(args, "-hwaccel_device", device)
+	}
+	switch {
+	case cpuFilters:
+		return args
+	case metadata == nil || (metadata.HDR == nil && metadata.BitDepth <= 8):
+		return append(args, "-hwaccel_output_format", "cuda", "-c:v", "h264_cuvid")
+	default:
+		return append(args, "-hwaccel_output_format", "cuda")
+	}
+}
+
+// bitDepth returns the bit depth of codec renditions of the source. HDR is
+// tonemapped to 8-bit SDR for H.264 and kept at 10 bits for H.265, and
+// hardware encoders encode H.264 at 8 bits only.
+func (b *CommandBuilder) bitDepth(codec domain.VideoCodec, metadata *domain.VideoMetadata, profile domain.Profile) int {
+	switch {
+	case b.safe || metadata == nil:
+		return 8
+	case metadata.HDR != nil:
+		if codec == domain.VideoCodecH265 {
+			return 10
+		}
+		return 8
+	case b.UsesGPU() && codec == domain.VideoCodecH264:
+		return 8
+	}
+
+	var cfg domain.BitDepthConfig
+	if b.encodingConfig != nil {
+		cfg = domain.BitDepthConfig{H264: b.encodingConfig.H264BitDepth, H265: b.encodingConfig.H265BitDepth}
+	}
+	return cfg.Override(profile.BitDepth).For(codec, metadata.BitDepth)
+}
+
+// gpuFiltersOnCPU reports whether a GPU encode of codec filters frames on the
+// CPU: interlaced sources are deinterlaced, rotated sources turned upright,
+// black bars cropped, HDR is tonemapped for H.264, and sources with more than
+// 8 bits are reduced for 8-bit output
+func (b *CommandBuilder) gpuFiltersOnCPU(codec domain.VideoCodec, metadata *domain.VideoMetadata, profile domain.Profile) bool {
+	if metadata == nil {
+		return false
+	}
+	if profile.Algorithm.DeinterlaceFilter(metadata) != "" || rotationFilter(metadata) != "" || metadata.Crop != nil {
+		return true
+	}
+	if metadata.HDR != nil {
+		return codec == domain.VideoCodecH264
+	}
+	return metadata.BitDepth > 8 && b.bitDepth(codec, metadata, profile) == 8
+}
+
+// sourceFilters start the filter chain: the deinterlacer, which has to see the
+// fields before any scaling or rotation mixes them, the rotation, the crop of
+// the black bars, measured on the upright frame, then the frame rate conversion
+func sourceFilters(metadata *domain.VideoMetadata, profile domain.Profile) []string {
+	var filters []string
+	if filter := profile.Algorithm.DeinterlaceFilter(metadata); filter != "" {
+		filters = append(filters, filter)
+	}
+	if filter := rotationFilter(metadata); filter != "" {
+		filters = append(filters, filter)
+	}
+	if metadata != nil && metadata.Crop != nil {
+		filters = append(filters, metadata.Crop.Filter())
+	}
+	if filter := profile.Algorithm.FrameRateFilter(metadata); filter != "" {
+		filters = append(filters, filter)
+	}
+	return filters
+}
+
+// rotationFilter turns the frames of a rotated source upright, so renditions
+// need no display matrix, which HLS players ignore
+func rotationFilter(metadata *domain.VideoMetadata) string {
+	if metadata == nil {
+		return ""
+	}
+	switch metadata.Rotation {
+	case 90:
+		return "transpose=clock"
+	case 180:
+		return "hflip,vflip"
+	case 270:
+		return "transpose=cclock"
+	}
+	return ""
+}
+
+// rotationArgs turns off the automatic rotation of ffmpeg for sources that
+// sourceFilters rotates, so the frames are not turned twice
+func rotationArgs(metadata *domain.VideoMetadata) []string {
+	if rotationFilter(metadata) == "" {
+		return nil
+	}
+	return []string{"-noautorotate"}
+}
+
+// cpuScaleFilter scales to the target size keeping aspect ratio and pads to exact dimensions
+func cpuScaleFilter(params domain.QualityConfig) string {
+	return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2",
+		params.Width, params.Height, params.Width, params.Height)
+}
+
+// nppScaleFilter scales CUDA frames on the GPU with scale_npp (works with CUVID decoder)
+func nppScaleFilter(params domain.QualityConfig) string {
+	return fmt.Sprintf("scale_npp=%d:%d", params.Width, params.Height)
+}
+
 // buildStreamMappings generates -map arguments for video and all audio tracks
 // This enables multiple audio track support for seamless switching in players
 func (b *CommandBuilder) buildStreamMappings(metadata *domain.VideoMetadata) []string {
 	args := []string{
-		"-map", "0:v:0", // Map first video stream
+		"-map", videoStreamSpec(metadata),
+	}
+
+	return append(args, b.buildAudioMappings(metadata)...)
+}
+
+// videoStreamSpec returns the stream specifier of the video stream to transcode
+func videoStreamSpec(metadata *domain.VideoMetadata) string {
+	if metadata == nil {
+		return "0:v:0"
 	}
+	return fmt.Sprintf("0:v:%d", metadata.VideoStreamIndex)
+}
+
+// buildAudioMappings generates -map arguments for all audio tracks
+func (b *CommandBuilder) buildAudioMappings(metadata *domain.VideoMetadata) []string {
+	var args []string
 
 	// Map all audio tracks
 	if len(metadata.AudioTracks) > 0 {
-		for i := range metadata.AudioTracks {
-			args = append(args, "-map", fmt.Sprintf("0:a:%d", i))
+		// Map by absolute stream index: adaptive sources skip duplicate renditions
+		for _, track := range metadata.AudioTracks {
+			args = append(args, "-map", fmt.Sprintf("0:%d", track.Index))
 		}
 	} else {
 		// Fallback: map first audio stream if no track info available
@@ -167,7 +563,15 @@ func (b *CommandBuilder) buildStreamMappings(metadata *domain.VideoMetadata) []s
 	return args
 }
 
-func (b *CommandBuilder) buildAudioArgs(metadata *domain.VideoMetadata) []string {
+// buildAudioArgs returns the audio codec and filter arguments of the tracks
+// muxed into a rendition of tier
+func (b *CommandBuilder) buildAudioArgs(metadata *domain.VideoMetadata, tier domain.EncodingTier) []string {
+	return append(b.audioCodecArgs(metadata, tier), b.audioFilterArgs(metadata, tier)...)
+}
+
+// audioCodecArgs encodes every track as stereo AAC, except the surround tracks
+// copied into the modern tier
+func (b *CommandBuilder) audioCodecArgs(metadata *domain.VideoMetadata, tier domain.EncodingTier) []string {
 	// Base audio encoding parameters applied to all tracks
 	args := []string{
 		"-c:a", "aac",
@@ -176,54 +580,162 @@ func (b *CommandBuilder) buildAudioArgs(metadata *domain.VideoMetadata) []string
 		"-b:a", "192k",
 	}
 
-	// Check if downmix is needed for any track
-	for _, track := range metadata.AudioTracks {
-		if track.Channels > 2 {
-			args = append(args, "-af", "aresample=async=1000")
-			break
+	// Per-stream options win over the ones for all tracks
+	for j, track := range metadata.AudioTracks {
+		if copiesAudio(track, tier) {
+			args = append(args, fmt.Sprintf("-c:a:%d", j), "copy")
+		}
+	}
+
+	// Keep the audio description flagged and out of the default track, which
+	// players pick when the viewer has not asked for a description
+	if i, _ := metadata.DescriptionTrack(); i >= 0 {
+		for j := range metadata.AudioTracks {
+			disposition := "0"
+			switch {
+			case j == i:
+				disposition = "visual_impaired+descriptions"
+			case &metadata.AudioTracks[j] == metadata.MainAudioTrack():
+				disposition = "default"
+			}
+			args = append(args, fmt.Sprintf("-disposition:a:%d", j), disposition)
 		}
 	}
 
 	return args
 }
 
-// buildH265GPUArgs builds H.265 video encoding arguments for GPU (NVIDIA NVENC)
+// audioFilterArgs downmixes the surround tracks that are encoded. The loudness
+// downmix keeps the center channel, which carries dialog, at full level and
+// the surrounds at -3 dB, then normalizes the result with loudnorm, so speech
+// does not sink under effects as with the plain -ac 2 matrix.
+func (b *CommandBuilder) audioFilterArgs(metadata *domain.VideoMetadata, tier domain.EncodingTier) []string {
+	var args []string
+	for j, track := range metadata.AudioTracks {
+		if track.Channels <= 2 || copiesAudio(track, tier) {
+			continue
+		}
+		args = append(args, fmt.Sprintf("-filter:a:%d", j), b.downmixFilter())
+	}
+	return args
+}
+
+// downmixFilter returns the audio filter of a surround track encoded as stereo
+func (b *CommandBuilder) downmixFilter() string {
+	if b.audio.DownmixMode() == domain.DownmixLoudness {
+		return fmt.Sprintf("aresample=async=1000:ochl=stereo:clev=1.414:slev=0.707,"+
+			"loudnorm=I=%g:TP=-1.5:LRA=11,aresample=48000", b.audio.LoudnessTarget())
+	}
+	return "aresample=async=1000"
+}
+
+// audioOnlyEncoders are the ffmpeg encoders of audio-only renditions
+var audioOnlyEncoders = map[domain.AudioCodec]string{
+	domain.AudioCodecAAC:  "aac",
+	domain.AudioCodecOpus: "libopus",
+}
+
+// BuildAudioOnlyCommand encodes the main audio track of the source into an
+// audio-only MP4 of codec at bitrate, as 48 kHz stereo. Surround tracks get
+// the downmix of the profile.
+func (b *CommandBuilder) BuildAudioOnlyCommand(
+	inputPath string,
+	outputPath string,
+	metadata *domain.VideoMetadata,
+	codec domain.AudioCodec,
+	bitrate string,
+) *TranscodeCommand {
+	args := []string{"-y"}
+	args = append(args, b.trimArgs()...)
+	args = append(args, "-i", inputPath)
+
+	track := metadata.MainAudioTrack()
+	if track != nil {
+		args = append(args, "-map", fmt.Sprintf("0:%d", track.Index))
+	} else {
+		args = append(args, "-map", "0:a:0")
+	}
+	args = append(args,
+		"-vn", "-sn", "-dn",
+		"-c:a", audioOnlyEncoders[codec],
+		"-b:a", bitrate,
+		"-ar", "48000",
+		"-ac", "2",
+	)
+	if track != nil && track.Channels > 2 {
+		args = append(args, "-filter:a", b.downmixFilter())
+	}
+	args = append(args,
+		"-movflags", "+faststart",
+		"-progress", "pipe:1",
+		outputPath,
+	)
+
+	return &TranscodeCommand{
+		Args:       args,
+		OutputPath: outputPath,
+	}
+}
+
+// copiesAudio reports whether track is copied into renditions of tier
+func copiesAudio(track domain.AudioTrackInfo, tier domain.EncodingTier) bool {
+	return track.Passthrough && tier == domain.TierModern
+}
+
+// buildH265GPUArgs builds H.265 video encoding arguments for the hardware backend
 func (b *CommandBuilder) buildH265GPUArgs(quality domain.Quality, params domain.QualityConfig, metadata *domain.VideoMetadata, profile domain.Profile) []string {
 	crf := 26
 	if b.encodingConfig != nil && b.encodingConfig.H265CRF > 0 {
 		crf = b.encodingConfig.H265CRF
 	}
 
-	args := []string{
-		"-c:v", "hevc_nvenc",
-		"-preset", "p2",        // Faster preset for better throughput
-		"-tune", "hq",
-		"-rc", "vbr",
-		"-cq", fmt.Sprintf("%d", crf),
-		"-tag:v", "hvc1",       // Apple compatibility
-		// Note: P100 doesn't support temporal_aq and some advanced features for HEVC
-		// Keep only basic parameters for maximum compatibility
+	args := b.hwEncoderArgs(domain.VideoCodecH265, quality, rungCRF(params, crf))
+
+	// Hardware decoders keep 10-bit frames as P010, which the encoders encode
+	// as Main10; for 8-bit output they are reduced on the CPU
+	framesOnCPU := b.framesOnCPU(domain.VideoCodecH265, metadata, profile)
+	bitDepth := b.bitDepth(domain.VideoCodecH265, metadata, profile)
+	switch {
+	case metadata.HDR != nil:
+		// The mastering display and MaxCLL of the source reach the SEI
+		// through frame side data, see KeepsHDR
+		args = append(args, "-profile:v", "main10")
+		args = append(args, hdrColorArgs(metadata.HDR)...)
+	case bitDepth == 10:
+		args = append(args, "-profile:v", "main10")
+	}
+	pixFmt, upload := b.hwFrameFormat(framesOnCPU, bitDepth)
+	if pixFmt != "" {
+		args = append(args, "-pix_fmt", pixFmt)
 	}
 
+	filters := sourceFilters(metadata, profile)
 	if quality != domain.QualityOrigin {
 		// Adjust bitrate for H.265 efficiency (40% savings)
 		videoBitrate := adjustBitrateForCodec(params.VideoBitrate, domain.VideoCodecH265)
 		maxBitrate := adjustBitrateForCodec(params.MaxBitrate, domain.VideoCodecH265)
 		bufSize := adjustBitrateForCodec(params.BufSize, domain.VideoCodecH265)
 
-		// Use GPU-accelerated scaling with scale_npp (works with CUVID decoder)
-		args = append(args, "-vf", fmt.Sprintf("scale_npp=%d:%d",
-			params.Width, params.Height))
+		if framesOnCPU {
+			filters = append(filters, cpuScaleFilter(params))
+		} else {
+			filters = append(filters, b.hwScaleFilter(params))
+		}
 		args = append(args, "-b:v", videoBitrate)
 		args = append(args, "-maxrate", maxBitrate)
 		args = append(args, "-bufsize", bufSize)
 	}
-
-	// GOP settings
-	gop := profile.Algorithm.GOP
-	if gop == 0 {
-		gop = 48
+	if upload != "" {
+		filters = append(filters, upload)
 	}
+	if len(filters) > 0 {
+		args = append(args, "-vf", strings.Join(filters, ","))
+	}
+
+	// Frame rate cap and GOP settings
+	rateArgs, divisor := frameRateArgs(quality, metadata, profile)
+	args = append(args, rateArgs...)
+	gop := profile.Algorithm.GOPSize(metadata, divisor)
 	args = append(args, "-g", fmt.Sprintf("%d", gop))
 
 	return args
@@ -231,24 +743,67 @@ func (b *CommandBuilder) buildH265GPUArgs(quality domain.Quality, params domain.
 
 // buildH265CPUArgs builds H.265 video encoding arguments for CPU (libx265)
 func (b *CommandBuilder) buildH265CPUArgs(quality domain.Quality, params domain.QualityConfig, metadata *domain.VideoMetadata, profile domain.Profile) []string {
-	preset := "medium"
+	settings := b.encoderSettings(domain.VideoCodecH265, quality, profile)
+	preset := settings.Preset
 	crf := 26
-	if b.encodingConfig != nil {
-		if b.encodingConfig.H265Preset != "" {
-			preset = b.encodingConfig.H265Preset
-		}
-		if b.encodingConfig.H265CRF > 0 {
-			crf = b.encodingConfig.H265CRF
+	if b.encodingConfig != nil && b.encodingConfig.H265CRF > 0 {
+		crf = b.encodingConfig.H265CRF
+	}
+
+	if b.safe {
+		preset = safePreset
+	}
+
+	rateArgs, divisor := frameRateArgs(quality, metadata, profile)
+
+	x265Params := fmt.Sprintf("log-level=error:pools=%d", b.threadCount())
+	if settings.Level != "" {
+		x265Params += ":level-idc=" + settings.Level
+	}
+	if b.safe {
+		x265Params += ":b-pyramid=0"
+	} else if metadata.HDR != nil && metadata.HDR.ColorTransfer == "smpte2084" {
+		// Keep HDR10 static metadata (mastering display, MaxCLL) in every keyframe
+		x265Params += ":hdr10=1:hdr10-opt=1:repeat-headers=1" + x265StaticHDRParams(metadata.HDR)
+		// HDR10+ metadata is matched to frames by their index
+		if b.hdr10Plus != "" && divisor == 1 {
+			x265Params += ":dhdr10-info=" + b.hdr10Plus
 		}
 	}
+	// Safe settings drop the tune and extra params, which may be what fails
+	if !b.safe && settings.Params != "" {
+		x265Params += ":" + settings.Params
+	}
 
 	args := []string{
 		"-c:v", "libx265",
 		"-preset", preset,
-		"-crf", fmt.Sprintf("%d", crf),
+		"-crf", strconv.Itoa(rungCRF(params, crf)),
 		"-tag:v", "hvc1", // Apple compatibility
-		"-x265-params", "log-level=error:pools=2",
-		"-threads", "2",
+		"-x265-params", x265Params,
+		"-threads", strconv.Itoa(b.threadCount()),
+	}
+	if !b.safe && settings.Tune != "" {
+		args = append(args, "-tune", settings.Tune)
+	}
+
+	// Safe settings encode 8-bit Main: HDR sources are tonemapped like the H.264 tier
+	filters := sourceFilters(metadata, profile)
+	if b.safe {
+		args = append(args, "-pix_fmt", "yuv420p", "-profile:v", "main")
+		if metadata.HDR != nil {
+			filters = append(filters, hdrToSDRFilter)
+			args = append(args, sdrColorArgs...)
+		}
+	} else if metadata.HDR != nil {
+		args = append(args, b.buildHDRArgs(metadata)...)
+	} else if b.bitDepth(domain.VideoCodecH265, metadata, profile) == 10 {
+		args = append(args, "-pix_fmt", "yuv420p10le", "-profile:v", "main10")
+	} else {
+		args = append(args, "-pix_fmt", "yuv420p")
+		if settings.Profile != "" {
+			args = append(args, "-profile:v", settings.Profile)
+		}
 	}
 
 	if quality != domain.QualityOrigin {
@@ -257,18 +812,19 @@ func (b *CommandBuilder) buildH265CPUArgs(quality domain.Quality, params domain.
 		maxBitrate := adjustBitrateForCodec(params.MaxBitrate, domain.VideoCodecH265)
 		bufSize := adjustBitrateForCodec(params.BufSize, domain.VideoCodecH265)
 
-		args = append(args, "-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2",
-			params.Width, params.Height, params.Width, params.Height))
+		filters = append(filters, cpuScaleFilter(params))
 		args = append(args, "-b:v", videoBitrate)
 		args = append(args, "-maxrate", maxBitrate)
 		args = append(args, "-bufsize", bufSize)
 	}
 
-	// GOP settings
-	gop := profile.Algorithm.GOP
-	if gop == 0 {
-		gop = 48
+	if len(filters) > 0 {
+		args = append(args, "-vf", strings.Join(filters, ","))
 	}
+
+	// Frame rate cap and GOP settings
+	args = append(args, rateArgs...)
+	gop := profile.Algorithm.GOPSize(metadata, divisor)
 	args = append(args, "-g", fmt.Sprintf("%d", gop))
 	args = append(args, "-keyint_min", fmt.Sprintf("%d", gop))
 	args = append(args, "-sc_threshold", "0")
@@ -276,6 +832,58 @@ func (b *CommandBuilder) buildH265CPUArgs(quality domain.Quality, params domain.
 	return args
 }
 
+// buildHDRArgs keeps 10-bit BT.2020 signaling for HEVC output of HDR sources.
+// Dolby Vision RPU is passed through only when enabled and the source is profile 8.
+func (b *CommandBuilder) buildHDRArgs(metadata *domain.VideoMetadata) []string {
+	hdr := metadata.HDR
+	if hdr == nil {
+		return nil
+	}
+
+	args := []string{
+		"-pix_fmt", "yuv420p10le",
+		"-profile:v", "main10",
+	}
+	args = append(args, hdrColorArgs(hdr)...)
+
+	if hdr.CanPreserveDolbyVision() && b.encodingConfig != nil && b.encodingConfig.PreserveDolbyVision {
+		args = append(args, "-dolbyvision", "1")
+	}
+
+	return args
+}
+
+// hdrColorArgs tag HEVC output with the BT.2020 primaries and transfer of the source
+func hdrColorArgs(hdr *domain.HDRInfo) []string {
+	transfer := hdr.ColorTransfer
+	if transfer == "" || transfer == "unknown" {
+		transfer = "smpte2084"
+	}
+	return []string{
+		"-color_primaries", "bt2020",
+		"-color_trc", transfer,
+		"-colorspace", "bt2020nc",
+	}
+}
+
+// x265StaticHDRParams returns x265 options with the mastering display and
+// content light level of the source. x265 takes chromaticity in units of
+// 0.00002 and luminance in units of 0.0001 cd/m².
+func x265StaticHDRParams(hdr *domain.HDRInfo) string {
+	var params string
+	if m := hdr.MasteringDisplay; m != nil && m.MaxLuminance > 0 {
+		xy := func(v float64) int { return int(math.Round(v * 50000)) }
+		lum := func(v float64) int { return int(math.Round(v * 10000)) }
+		params += fmt.Sprintf(":master-display=G(%d,%d)B(%d,%d)R(%d,%d)WP(%d,%d)L(%d,%d)",
+			xy(m.GreenX), xy(m.GreenY), xy(m.BlueX), xy(m.BlueY), xy(m.RedX), xy(m.RedY),
+			xy(m.WhiteX), xy(m.WhiteY), lum(m.MaxLuminance), lum(m.MinLuminance))
+	}
+	if l := hdr.ContentLight; l != nil && l.MaxCLL > 0 {
+		params += fmt.Sprintf(":max-cll=%d,%d", l.MaxCLL, l.MaxFALL)
+	}
+	return params
+}
+
 // adjustBitrateForCodec adjusts bitrate based on codec efficiency
 func adjustBitrateForCodec(bitrate string, codec domain.VideoCodec) string {
 	multiplier := codec.BitrateMultiplier()
@@ -309,7 +917,7 @@ func (b *CommandBuilder) BuildHLSCommandWithEncryption(
 	encryption *EncryptionInfo,
 ) *TranscodeCommand {
 	playlistPath := filepath.Join(outputDir, quality+".m3u8")
-	segmentPath := filepath.Join(outputDir, quality+"_%05d.ts")
+	segmentPath := b.segmentFilename(outputDir, quality, domain.ContainerTS)
 
 	args := []string{
 		"-y",
@@ -321,6 +929,7 @@ func (b *CommandBuilder) BuildHLSCommandWithEncryption(
 		"-hls_segment_filename", segmentPath,
 		"-hls_list_size", "0",
 	}
+	args = append(args, b.hlsFlagArgs()...)
 
 	// Add encryption options if provided
 	if encryption != nil {
@@ -360,7 +969,7 @@ func (b *CommandBuilder) BuildHLSCommandFMP4WithEncryption(
 ) *TranscodeCommand {
 	playlistPath := filepath.Join(outputDir, quality+".m3u8")
 	initPath := quality + "_init.mp4"
-	segmentPath := filepath.Join(outputDir, quality+"_%05d.m4s")
+	segmentPath := b.segmentFilename(outputDir, quality, domain.ContainerFMP4)
 
 	args := []string{
 		"-y",
@@ -374,6 +983,7 @@ func (b *CommandBuilder) BuildHLSCommandFMP4WithEncryption(
 		"-hls_segment_filename", segmentPath,
 		"-hls_list_size", "0",
 	}
+	args = append(args, b.hlsFlagArgs()...)
 
 	// Add encryption options if provided
 	if encryption != nil {
@@ -402,22 +1012,21 @@ func (b *CommandBuilder) BuildTranscodeCommandForTier(
 	profile domain.Profile,
 	tier domain.EncodingTier,
 ) *TranscodeCommand {
-	params := quality.Params()
+	params := profile.QualityParams(quality)
 	outputPath := filepath.Join(outputDir, string(quality)+".mp4")
 
 	args := []string{
 		"-y",
 	}
 
-	// Enable GPU decoding with CUVID when GPU encoding is enabled
-	if b.enableGPU {
-		args = append(args,
-			"-hwaccel", "cuda",
-			"-hwaccel_output_format", "cuda",
-			"-c:v", "h264_cuvid",
-		)
+	// Decode on the GPU when encoding on it
+	if b.UsesGPU() {
+		codec := domain.GetTierConfig(tier).VideoCodec
+		args = append(args, b.hwDecodeArgs(metadata, b.framesOnCPU(codec, metadata, profile))...)
 	}
 
+	args = append(args, rotationArgs(metadata)...)
+	args = append(args, b.trimArgs()...)
 	args = append(args,
 		"-i", inputPath,
 		"-progress", "pipe:1",
@@ -431,14 +1040,14 @@ func (b *CommandBuilder) BuildTranscodeCommandForTier(
 	switch tier {
 	case domain.TierModern:
 		// H.265 encoding
-		if b.enableGPU {
+		if b.UsesGPU() {
 			args = append(args, b.buildH265GPUArgs(quality, params, metadata, profile)...)
 		} else {
 			args = append(args, b.buildH265CPUArgs(quality, params, metadata, profile)...)
 		}
 	default:
 		// Legacy tier - H.264 encoding
-		if b.enableGPU {
+		if b.UsesGPU() {
 			args = append(args, b.buildGPUVideoArgs(quality, params, metadata, profile)...)
 		} else {
 			args = append(args, b.buildCPUVideoArgs(quality, params, metadata, profile)...)
@@ -446,7 +1055,7 @@ func (b *CommandBuilder) BuildTranscodeCommandForTier(
 	}
 
 	// Audio encoding (AAC for both tiers)
-	args = append(args, b.buildAudioArgs(metadata)...)
+	args = append(args, b.buildAudioArgs(metadata, tier)...)
 
 	// Output format
 	args = append(args,
@@ -460,6 +1069,264 @@ func (b *CommandBuilder) BuildTranscodeCommandForTier(
 	}
 }
 
+// BuildRemuxCommandForTier builds a command that copies the source video into
+// the rendition of quality in tier instead of encoding it. Audio is encoded as
+// in the other renditions, which costs little next to video.
+func (b *CommandBuilder) BuildRemuxCommandForTier(
+	inputPath string,
+	outputDir string,
+	quality domain.Quality,
+	metadata *domain.VideoMetadata,
+	tier domain.EncodingTier,
+) *TranscodeCommand {
+	outputPath := filepath.Join(outputDir, string(quality)+".mp4")
+
+	args := []string{"-y"}
+	args = append(args, b.trimArgs()...)
+	args = append(args,
+		"-i", inputPath,
+		"-progress", "pipe:1",
+		"-stats_period", "1",
+	)
+	args = append(args, b.buildStreamMappings(metadata)...)
+	args = append(args, "-c:v", "copy")
+	if domain.GetTierConfig(tier).VideoCodec == domain.VideoCodecH265 {
+		args = append(args, "-tag:v", "hvc1") // Apple compatibility
+	}
+	args = append(args, b.buildAudioArgs(metadata, tier)...)
+	args = append(args,
+		"-movflags", "+faststart",
+		outputPath,
+	)
+
+	return &TranscodeCommand{
+		Args:       args,
+		OutputPath: outputPath,
+	}
+}
+
+// MultiOutputCommand holds a single ffmpeg invocation producing several qualities
+type MultiOutputCommand struct {
+	Args        []string
+	OutputPaths map[domain.Quality]string
+}
+
+// BuildMultiOutputCommandForTier builds one command that decodes the source once and
+// encodes all qualities of a tier via a split/scale filtergraph with multiple outputs.
+// With a hardware backend frames stay in device memory: the GPU decodes once, its
+// scaler resizes each branch and every output is encoded on it, avoiding PCIe
+// round trips.
+func (b *CommandBuilder) BuildMultiOutputCommandForTier(
+	inputPath string,
+	outputDir string,
+	qualities []domain.Quality,
+	metadata *domain.VideoMetadata,
+	profile domain.Profile,
+	tier domain.EncodingTier,
+) *MultiOutputCommand {
+	args := []string{
+		"-y",
+	}
+
+	scaleFilter := cpuScaleFilter
+	tonemap := metadata.HDR != nil && tier != domain.TierModern
+	// VAAPI encoders take frames filtered on the CPU uploaded at the end of each branch
+	var upload string
+	if b.UsesGPU() {
+		codec := domain.GetTierConfig(tier).VideoCodec
+		framesOnCPU := b.framesOnCPU(codec, metadata, profile)
+		args = append(args, b.hwDecodeArgs(metadata, framesOnCPU)...)
+		if !framesOnCPU {
+			scaleFilter = b.hwScaleFilter
+		}
+		_, upload = b.hwFrameFormat(framesOnCPU, b.bitDepth(codec, metadata, profile))
+	}
+
+	args = append(args, rotationArgs(metadata)...)
+	args = append(args, b.trimArgs()...)
+	args = append(args,
+		"-i", inputPath,
+		"-progress", "pipe:1",
+		"-stats_period", "1",
+	)
+
+	// Split decoded video once, scale each branch to its quality
+	var filters []string
+	splitLabels := make([]string, len(qualities))
+	for i := range qualities {
+		splitLabels[i] = fmt.Sprintf("[v%d]", i)
+	}
+	source := "[" + videoStreamSpec(metadata) + "]"
+	for _, filter := range sourceFilters(metadata, profile) {
+		source += filter + ","
+	}
+	if tonemap {
+		source += hdrToSDRFilter + ","
+	}
+	filters = append(filters, fmt.Sprintf("%ssplit=%d%s", source, len(qualities), strings.Join(splitLabels, "")))
+
+	outLabels := make([]string, len(qualities))
+	for i, quality := range qualities {
+		var chain []string
+		if quality != domain.QualityOrigin {
+			chain = append(chain, scaleFilter(profile.QualityParams(quality)))
+		}
+		if upload != "" {
+			chain = append(chain, upload)
+		}
+		if len(chain) == 0 {
+			outLabels[i] = splitLabels[i]
+			continue
+		}
+		outLabels[i] = fmt.Sprintf("[out%d]", i)
+		filters = append(filters, fmt.Sprintf("%s%s%s", splitLabels[i], strings.Join(chain, ","), outLabels[i]))
+	}
+	args = append(args, "-filter_complex", strings.Join(filters, ";"))
+
+	// All outputs share one process, so split the thread budget between encoders
+	enc := *b
+	enc.threads = b.threadCount() / len(qualities)
+	if enc.threads < 1 {
+		enc.threads = 1
+	}
+
+	outputPaths := make(map[domain.Quality]string, len(qualities))
+	for i, quality := range qualities {
+		params := profile.QualityParams(quality)
+		outputPath := filepath.Join(outputDir, string(quality)+".mp4")
+		outputPaths[quality] = outputPath
+
+		// Video from the filtergraph, audio tracks from the source
+		args = append(args, "-map", outLabels[i])
+		args = append(args, b.buildAudioMappings(metadata)...)
+
+		// Scaling is already done in the filtergraph
+		var videoArgs []string
+		switch {
+		case tier == domain.TierModern && b.UsesGPU():
+			videoArgs = enc.buildH265GPUArgs(quality, params, metadata, profile)
+		case tier == domain.TierModern:
+			videoArgs = enc.buildH265CPUArgs(quality, params, metadata, profile)
+		case b.UsesGPU():
+			videoArgs = enc.buildGPUVideoArgs(quality, params, metadata, profile)
+		default:
+			videoArgs = enc.buildCPUVideoArgs(quality, params, metadata, profile)
+		}
+		args = append(args, dropOption(videoArgs, "-vf")...)
+
+		args = append(args, b.buildAudioArgs(metadata, tier)...)
+		args = append(args,
+			"-movflags", "+faststart",
+			outputPath,
+		)
+	}
+
+	return &MultiOutputCommand{
+		Args:        args,
+		OutputPaths: outputPaths,
+	}
+}
+
+// dropOption removes an option with its value, such as -vf, from encoder args;
+// outputs fed by -filter_complex cannot have a simple filter
+func dropOption(args []string, option string) []string {
+	result := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == option {
+			i++
+			continue
+		}
+		result = append(result, args[i])
+	}
+	return result
+}
+
+// proresProfiles maps profile names to prores_ks profile numbers
+var proresProfiles = map[string]string{
+	"proxy":    "0",
+	"lt":       "1",
+	"standard": "2",
+	"hq":       "3",
+	"4444":     "4",
+}
+
+// BuildMezzanineCommand builds a ProRes or DNxHR mezzanine command.
+// Decoding stays on CPU since the intra-frame encoders are CPU-only.
+func (b *CommandBuilder) BuildMezzanineCommand(
+	inputPath string,
+	outputDir string,
+	metadata *domain.VideoMetadata,
+	mezzanine domain.MezzanineConfig,
+) (*TranscodeCommand, error) {
+	outputPath := filepath.Join(outputDir, "mezzanine.mov")
+
+	args := []string{"-y"}
+	args = append(args, b.trimArgs()...)
+	args = append(args,
+		"-i", inputPath,
+		"-progress", "pipe:1",
+		"-stats_period", "1",
+	)
+
+	args = append(args, b.buildStreamMappings(metadata)...)
+
+	switch mezzanine.Codec {
+	case domain.MezzanineCodecProRes:
+		profile := mezzanine.Profile
+		if profile == "" {
+			profile = "hq"
+		}
+		num, ok := proresProfiles[profile]
+		if !ok {
+			return nil, fmt.Errorf("unknown prores profile: %s", profile)
+		}
+		pixFmt := "yuv422p10le"
+		if profile == "4444" {
+			pixFmt = "yuva444p10le"
+		}
+		args = append(args,
+			"-c:v", "prores_ks",
+			"-profile:v", num,
+			"-vendor", "apl0",
+			"-pix_fmt", pixFmt,
+		)
+	case domain.MezzanineCodecDNxHR:
+		profile := mezzanine.Profile
+		if profile == "" {
+			profile = "hq"
+		}
+		var pixFmt string
+		switch profile {
+		case "lb", "sq", "hq":
+			pixFmt = "yuv422p"
+		case "hqx":
+			pixFmt = "yuv422p10le"
+		case "444":
+			pixFmt = "yuv444p10le"
+		default:
+			return nil, fmt.Errorf("unknown dnxhr profile: %s", profile)
+		}
+		args = append(args,
+			"-c:v", "dnxhd",
+			"-profile:v", "dnxhr_"+profile,
+			"-pix_fmt", pixFmt,
+		)
+	default:
+		return nil, fmt.Errorf("unsupported mezzanine codec: %s", mezzanine.Codec)
+	}
+
+	args = append(args,
+		"-threads", strconv.Itoa(b.threadCount()),
+		"-c:a", "pcm_s24le",
+		outputPath,
+	)
+
+	return &TranscodeCommand{
+		Args:       args,
+		OutputPath: outputPath,
+	}, nil
+}
+
 // BuildHLSCommandForTier builds HLS command for a specific tier (TS or fMP4)
 func (b *CommandBuilder) BuildHLSCommandForTier(
 	inputPath string,
@@ -469,26 +1336,149 @@ func (b *CommandBuilder) BuildHLSCommandForTier(
 	tier domain.EncodingTier,
 	encryption *EncryptionInfo,
 ) *TranscodeCommand {
-	tierConfig := domain.GetTierConfig(tier)
-
-	if tierConfig.Container == domain.ContainerFMP4 {
+	if b.TierConfig(tier).Container == domain.ContainerFMP4 {
 		return b.BuildHLSCommandFMP4WithEncryption(inputPath, outputDir, quality, segmentDuration, encryption)
 	}
 	return b.BuildHLSCommandWithEncryption(inputPath, outputDir, quality, segmentDuration, encryption)
 }
 
+// AudioDescriptionName names the HLS playlist and segments of the audio description rendition
+const AudioDescriptionName = "audio_description"
+
+// BuildHLSVideoCommandForTier builds the video-only HLS rendition of a
+// transcoded output, whose audio is segmented into the audio renditions
+func (b *CommandBuilder) BuildHLSVideoCommandForTier(
+	inputPath string,
+	outputDir string,
+	quality string,
+	segmentDuration int,
+	tier domain.EncodingTier,
+	encryption *EncryptionInfo,
+) *TranscodeCommand {
+	return b.buildHLSStreamCommand(inputPath, outputDir, quality, []string{"-map", "0:v:0"}, segmentDuration, tier, encryption)
+}
+
+// BuildHLSAudioCommandForTier builds an audio-only HLS rendition of one audio
+// stream of a transcoded output, in the segment container of the tier
+func (b *CommandBuilder) BuildHLSAudioCommandForTier(
+	inputPath string,
+	outputDir string,
+	name string,
+	audioIndex int,
+	segmentDuration int,
+	tier domain.EncodingTier,
+	encryption *EncryptionInfo,
+) *TranscodeCommand {
+	return b.buildHLSStreamCommand(inputPath, outputDir, name, []string{
+		"-map", fmt.Sprintf("0:a:%d", audioIndex),
+		// The output has a single stream: the description flag must not make it non-default
+		"-disposition:a:0", "default",
+	}, segmentDuration, tier, encryption)
+}
+
+// buildHLSStreamCommand segments the streams selected by mapArgs into the HLS
+// rendition name, in the segment container of the tier
+func (b *CommandBuilder) buildHLSStreamCommand(
+	inputPath string,
+	outputDir string,
+	name string,
+	mapArgs []string,
+	segmentDuration int,
+	tier domain.EncodingTier,
+	encryption *EncryptionInfo,
+) *TranscodeCommand {
+	playlistPath := filepath.Join(outputDir, name+".m3u8")
+
+	args := []string{
+		"-y",
+		"-i", inputPath,
+	}
+	args = append(args, mapArgs...)
+	args = append(args,
+		"-c", "copy",
+		"-f", "hls",
+		"-hls_time", fmt.Sprintf("%d", segmentDuration),
+		"-hls_playlist_type", "vod",
+		"-hls_list_size", "0",
+	)
+
+	container := b.TierConfig(tier).Container
+	if container == domain.ContainerFMP4 {
+		args = append(args,
+			"-hls_segment_type", "fmp4",
+			"-hls_fmp4_init_filename", name+"_init.mp4",
+		)
+	}
+	args = append(args, "-hls_segment_filename", b.segmentFilename(outputDir, name, container))
+	args = append(args, b.hlsFlagArgs()...)
+
+	if encryption != nil {
+		args = append(args, "-hls_key_info_file", encryption.KeyInfoPath)
+	}
+
+	args = append(args,
+		"-progress", "pipe:1",
+		playlistPath,
+	)
+
+	return &TranscodeCommand{
+		Args:       args,
+		OutputPath: playlistPath,
+	}
+}
+
 // BuildSubtitleExtractCommand builds subtitle extraction command
 func (b *CommandBuilder) BuildSubtitleExtractCommand(
 	inputPath string,
 	outputPath string,
 	streamIndex int,
 ) *TranscodeCommand {
-	args := []string{
-		"-y",
+	args := []string{"-y"}
+	args = append(args, b.trimArgs()...)
+	args = append(args,
 		"-i", inputPath,
 		"-map", fmt.Sprintf("0:%d", streamIndex),
 		"-c:s", "webvtt",
 		outputPath,
+	)
+
+	return &TranscodeCommand{
+		Args:       args,
+		OutputPath: outputPath,
+	}
+}
+
+// BuildComplexityProbeCommand encodes a video-only sample of the source at a
+// constant CRF with a fast preset. The size of the output measures how hard
+// the content is to compress at the resolution of params.
+func (b *CommandBuilder) BuildComplexityProbeCommand(
+	inputPath string,
+	outputPath string,
+	start, length time.Duration,
+	params domain.QualityConfig,
+	metadata *domain.VideoMetadata,
+	crf int,
+) *TranscodeCommand {
+	filters := cpuScaleFilter(params)
+	if metadata.HDR != nil {
+		filters = hdrToSDRFilter + "," + filters
+	}
+
+	args := []string{
+		"-y",
+		"-ss", fmt.Sprintf("%.3f", start.Seconds()),
+		"-t", fmt.Sprintf("%.3f", length.Seconds()),
+		"-i", inputPath,
+		"-map", videoStreamSpec(metadata),
+		"-an", "-sn",
+		"-vf", filters,
+		"-c:v", "libx264",
+		"-preset", safePreset,
+		"-crf", strconv.Itoa(crf),
+		"-pix_fmt", "yuv420p",
+		"-threads", strconv.Itoa(b.threadCount()),
+		"-progress", "pipe:1",
+		outputPath,
 	}
 
 	return &TranscodeCommand{
@@ -505,14 +1495,15 @@ func (b *CommandBuilder) BuildThumbnailCommand(
 	interval float64,
 	width, height int,
 ) *TranscodeCommand {
-	args := []string{
-		"-y",
+	args := []string{"-y"}
+	args = append(args, b.trimArgs()...)
+	args = append(args,
 		"-i", inputPath,
 		"-vf", fmt.Sprintf("fps=1/%f,scale=%d:-2", interval, width),
 		"-vsync", "vfr",
 		"-progress", "pipe:1",
 		outputPattern,
-	}
+	)
 
 	return &TranscodeCommand{
 		Args:       args,
@@ -539,28 +1530,17 @@ func (b *CommandBuilder) BuildTileCommand(
 	}
 }
 
-// BuildConcatCommand builds video concatenation command (for intro)
-func (b *CommandBuilder) BuildConcatCommand(
-	introPath string,
-	mainPath string,
-	outputPath string,
-) *TranscodeCommand {
-	// Create filter complex for concat
-	filterComplex := "[0:v:0][0:a:0][1:v:0][1:a:0]concat=n=2:v=1:a=1[outv][outa]"
-
+// BuildConcatCommand joins clips encoded with the same settings, listed in a
+// concat demuxer file, into one MP4 without re-encoding them
+func (b *CommandBuilder) BuildConcatCommand(listPath string, outputPath string) *TranscodeCommand {
 	args := []string{
 		"-y",
-		"-i", introPath,
-		"-i", mainPath,
-		"-filter_complex", filterComplex,
-		"-map", "[outv]",
-		"-map", "[outa]",
-		"-c:v", "libx264",
-		"-preset", "slower",
-		"-crf", "23",
-		"-threads", "2",
-		"-c:a", "aac",
-		"-b:a", "192k",
+		"-f", "concat",
+		"-safe", "0",
+		"-i", listPath,
+		"-map", "0",
+		"-c", "copy",
+		"-movflags", "+faststart",
 		"-progress", "pipe:1",
 		outputPath,
 	}
@@ -571,73 +1551,318 @@ func (b *CommandBuilder) BuildConcatCommand(
 	}
 }
 
-// GenerateMasterPlaylist generates HLS master playlist content (legacy single-tier)
-func GenerateMasterPlaylist(qualities []domain.Quality, include4K bool) string {
+// GenerateMasterPlaylist generates HLS master playlist content (legacy single-tier).
+// Subtitles form a group referenced by every variant. Qualities with an entry
+// in iFrames get an EXT-X-I-FRAME-STREAM-INF for trick play.
+func GenerateMasterPlaylist(qualities []domain.Quality, ladder domain.Ladder, include4K bool, subtitles []SubtitleRendition, iFrames map[domain.Quality]*IFramePlaylist) string {
 	var sb strings.Builder
 	sb.WriteString("#EXTM3U\n")
-	sb.WriteString("#EXT-X-VERSION:3\n\n")
+	if len(iFrames) > 0 {
+		sb.WriteString("#EXT-X-VERSION:4\n\n")
+	} else {
+		sb.WriteString("#EXT-X-VERSION:3\n\n")
+	}
+
+	var subtitlesAttr string
+	if len(subtitles) > 0 {
+		sb.WriteString(subtitleMediaTags(subtitles))
+		sb.WriteString("\n")
+		subtitlesAttr = fmt.Sprintf(",SUBTITLES=\"%s\"", SubtitlesGroupID)
+	}
 
 	for _, q := range qualities {
 		if q == domain.Quality2160p && !include4K {
 			continue
 		}
 
-		params := q.Params()
+		params := ladder.Params(q)
 		bandwidth := parseBitrate(params.VideoBitrate) + parseBitrate(params.AudioBitrate)
 
 		if q == domain.QualityOrigin {
-			sb.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,NAME=\"%s\"\n", bandwidth, q))
+			sb.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,NAME=\"%s\"%s\n", bandwidth, q, subtitlesAttr))
 		} else {
-			sb.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d,NAME=\"%s\"\n",
-				bandwidth, params.Width, params.Height, q))
+			sb.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d,NAME=\"%s\"%s\n",
+				bandwidth, params.Width, params.Height, q, subtitlesAttr))
 		}
 		sb.WriteString(fmt.Sprintf("%s.m3u8\n\n", q))
 	}
 
+	for _, q := range qualities {
+		if iframes := iFrames[q]; iframes != nil && (q != domain.Quality2160p || include4K) {
+			sb.WriteString(iFrameStreamTag(iframes, ladder.Params(q), q, "", iframes.URI))
+		}
+	}
+
+	return sb.String()
+}
+
+// iFrameStreamTag returns the EXT-X-I-FRAME-STREAM-INF of the I-frame playlist
+// of a quality; attrs are the CODECS and VIDEO-RANGE of its tier, if any
+func iFrameStreamTag(iframes *IFramePlaylist, params domain.QualityConfig, q domain.Quality, attrs, uri string) string {
+	resolution := ""
+	if q != domain.QualityOrigin {
+		resolution = fmt.Sprintf(",RESOLUTION=%dx%d", params.Width, params.Height)
+	}
+	return fmt.Sprintf("#EXT-X-I-FRAME-STREAM-INF:BANDWIDTH=%d%s%s,URI=\"%s\"\n", iframes.Bandwidth, resolution, attrs, uri)
+}
+
+// AudioRendition is an audio-only HLS rendition cut from one audio stream of
+// the transcoded outputs, shared by all variants of a tier
+type AudioRendition struct {
+	// Name of the playlist and segments, e.g. "audio_rus"
+	Name string
+	// Index of the audio stream in the outputs
+	Index    int
+	Language string
+	// Default marks the main audio, which players pick without a preference
+	Default bool
+	// Description marks the audio description
+	Description bool
+	// Codec is the RFC 6381 codec of the rendition
+	Codec    string
+	Channels int
+	// Bitrate of a copied track in bits per second; 0 for AAC, whose bitrate
+	// is that of the ladder
+	Bitrate int
+}
+
+// AudioRenditions returns the audio renditions of the audio tracks muxed into
+// the outputs of tier: one per language, the first track of a language
+// winning, and the audio description apart from them. Names do not depend on
+// the tier; codecs do, as surround tracks are copied into the modern tier only.
+func AudioRenditions(tracks []domain.AudioTrackInfo, tier domain.EncodingTier) []AudioRendition {
+	metadata := &domain.VideoMetadata{AudioTracks: tracks}
+	descriptionIndex, _ := metadata.DescriptionTrack()
+	main := metadata.MainAudioTrack()
+
+	var renditions []AudioRendition
+	seen := make(map[string]bool, len(tracks))
+	for i := range tracks {
+		track := &tracks[i]
+		if i == descriptionIndex {
+			renditions = append(renditions, audioRendition(AudioDescriptionName, i, track, tier))
+			continue
+		}
+		language := track.Language
+		if language == "" {
+			language = "und"
+		}
+		if track.Description || seen[language] {
+			continue
+		}
+		seen[language] = true
+		r := audioRendition("audio_"+language, i, track, tier)
+		r.Default = track == main
+		renditions = append(renditions, r)
+	}
+	return renditions
+}
+
+// audioRendition returns the rendition name of the track at position index
+func audioRendition(name string, index int, track *domain.AudioTrackInfo, tier domain.EncodingTier) AudioRendition {
+	r := AudioRendition{
+		Name:        name,
+		Index:       index,
+		Language:    track.Language,
+		Description: track.Description,
+		Codec:       domain.GetTierConfig(tier).AudioCodecString,
+		Channels:    2,
+	}
+	if copiesAudio(*track, tier) {
+		r.Codec, r.Channels, r.Bitrate = domain.AudioCodecString(track.Codec), track.Channels, int(track.Bitrate)
+	}
+	return r
+}
+
+// audioCodecs returns the codecs of the tier variants: the video codec and
+// every codec of the audio renditions, or the audio codec of the tier when
+// audio is muxed into the variants
+func audioCodecs(videoCodec string, renditions []AudioRendition, tierConfig domain.TierConfig) string {
+	codecs := []string{videoCodec}
+	if len(renditions) == 0 {
+		return videoCodec + "," + tierConfig.AudioCodecString
+	}
+	for _, r := range renditions {
+		if !slices.Contains(codecs, r.Codec) {
+			codecs = append(codecs, r.Codec)
+		}
+	}
+	return strings.Join(codecs, ",")
+}
+
+// audioMediaTags returns the EXT-X-MEDIA group of a tier with its audio renditions
+func audioMediaTags(renditions []AudioRendition, groupID, tier string) string {
+	var sb strings.Builder
+	for _, r := range renditions {
+		name, defaultAttr, characteristics := audioRenditionName(r), "NO", ""
+		if r.Default {
+			defaultAttr = "YES"
+		}
+		if r.Description {
+			characteristics = "CHARACTERISTICS=\"public.accessibility.describes-video\","
+		}
+		sb.WriteString(fmt.Sprintf("#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"%s\",NAME=\"%s\",%sDEFAULT=%s,AUTOSELECT=YES,%sCHANNELS=\"%d\",URI=\"%s/%s.m3u8\"\n",
+			groupID, name, languageAttr(r.Language), defaultAttr, characteristics, r.Channels, tier, r.Name))
+	}
 	return sb.String()
 }
 
+// audioRenditionName returns the NAME players show for an audio rendition
+func audioRenditionName(r AudioRendition) string {
+	switch {
+	case r.Description:
+		return "Audio description"
+	case r.Language == "" || r.Language == "und":
+		return "Main"
+	default:
+		return r.Language
+	}
+}
+
+// languageAttr returns the LANGUAGE attribute of a known language
+func languageAttr(language string) string {
+	if language == "" || language == "und" {
+		return ""
+	}
+	return fmt.Sprintf("LANGUAGE=\"%s\",", language)
+}
+
+// tierVideoRange returns the VIDEO-RANGE of tier: videoRange for the modern
+// tier, SDR for the legacy one
+func tierVideoRange(tier domain.EncodingTier, videoRange string) string {
+	if tier == domain.TierModern && videoRange != "" {
+		return videoRange
+	}
+	return "SDR"
+}
+
+// VideoCodecString returns the RFC 6381 codec of the rendition of quality in
+// tier: the profile and level of its encoder settings, with 10-bit output and
+// HDR as High 10 or Main 10. rendition is the probed output, nil if unknown;
+// its level is signaled when known, as x265 and hardware encoders pick their
+// own without a level in the settings.
+func (b *CommandBuilder) VideoCodecString(tier domain.EncodingTier, quality domain.Quality, profile domain.Profile, videoRange string, rendition *domain.VideoMetadata) string {
+	codec := domain.GetTierConfig(tier).VideoCodec
+	settings := b.encoderSettings(codec, quality, profile)
+
+	bitDepth, levelIDC := 8, 0
+	if rendition != nil {
+		bitDepth, levelIDC = rendition.BitDepth, rendition.Level
+	}
+	if tierVideoRange(tier, videoRange) != "SDR" {
+		bitDepth = 10
+	}
+	if levelIDC == 0 {
+		levelIDC = domain.LevelIDC(codec, settings.Level)
+	}
+	return domain.VideoCodecString(codec, settings.Profile, levelIDC, bitDepth)
+}
+
 // GenerateMultiCodecMasterPlaylist generates HLS master playlist with multiple codec tiers
-// Browsers will automatically select the best compatible stream based on CODECS attribute
-func GenerateMultiCodecMasterPlaylist(qualities []domain.Quality, tiers []domain.EncodingTier, include4K bool) string {
+// Browsers will automatically select the best compatible stream based on CODECS attribute.
+// videoRange ("SDR", "PQ", "HLG") applies to the modern tier; the legacy tier is always SDR.
+// codecs holds the RFC 6381 video codec of each rendition, see VideoCodecString.
+// Audio renditions form an audio group per tier referenced by its variants,
+// which carry video only.
+// Subtitles form one group referenced by the variants of all tiers.
+// Qualities take their resolution and bandwidth from ladder. Renditions with an
+// entry in iFrames get an EXT-X-I-FRAME-STREAM-INF for trick play.
+func GenerateMultiCodecMasterPlaylist(
+	qualities []domain.Quality,
+	ladder domain.Ladder,
+	tiers []domain.EncodingTier,
+	include4K bool,
+	videoRange string,
+	codecs map[domain.EncodingTier]map[domain.Quality]string,
+	audio map[domain.EncodingTier][]AudioRendition,
+	subtitles []SubtitleRendition,
+	iFrames map[domain.EncodingTier]map[domain.Quality]*IFramePlaylist,
+) string {
 	var sb strings.Builder
 	sb.WriteString("#EXTM3U\n")
 	sb.WriteString("#EXT-X-VERSION:7\n")
 	sb.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n\n")
 
+	var subtitlesAttr string
+	if len(subtitles) > 0 {
+		sb.WriteString(subtitleMediaTags(subtitles))
+		sb.WriteString("\n")
+		subtitlesAttr = fmt.Sprintf(",SUBTITLES=\"%s\"", SubtitlesGroupID)
+	}
+
 	for _, tier := range tiers {
 		tierConfig := domain.GetTierConfig(tier)
-		codecsAttr := fmt.Sprintf("%s,%s", tierConfig.VideoCodecString, tierConfig.AudioCodecString)
+		tierRange := tierVideoRange(tier, videoRange)
 
 		sb.WriteString(fmt.Sprintf("# %s tier (%s/%s)\n", tier, tierConfig.VideoCodec, tierConfig.AudioCodec))
 
+		// Rendition groups of the variants
+		groupAttrs := subtitlesAttr
+		if len(audio[tier]) > 0 {
+			groupID := "audio-" + string(tier)
+			sb.WriteString(audioMediaTags(audio[tier], groupID, string(tier)))
+			groupAttrs = fmt.Sprintf(",AUDIO=\"%s\"", groupID) + groupAttrs
+		}
+
 		for _, q := range qualities {
 			if q == domain.Quality2160p && !include4K {
 				continue
 			}
 
-			params := q.Params()
+			params := ladder.Params(q)
 
 			// Adjust bandwidth for codec efficiency
 			videoBandwidth := int(float64(parseBitrate(params.VideoBitrate)) * tierConfig.VideoCodec.BitrateMultiplier())
 			audioBandwidth := parseBitrate(params.AudioBitrate)
+			// Copied surround tracks are played instead of the AAC of the ladder
+			for _, r := range audio[tier] {
+				audioBandwidth = max(audioBandwidth, r.Bitrate)
+			}
 			totalBandwidth := videoBandwidth + audioBandwidth
+			codecsAttr := audioCodecs(codecs[tier][q], audio[tier], tierConfig)
 
 			if q == domain.QualityOrigin {
-				sb.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,CODECS=\"%s\",NAME=\"%s-%s\"\n",
-					totalBandwidth, codecsAttr, q, tier))
+				sb.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,CODECS=\"%s\",VIDEO-RANGE=%s,NAME=\"%s-%s\"%s\n",
+					totalBandwidth, codecsAttr, tierRange, q, tier, groupAttrs))
 			} else {
-				sb.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d,CODECS=\"%s\",NAME=\"%s-%s\"\n",
-					totalBandwidth, params.Width, params.Height, codecsAttr, q, tier))
+				sb.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d,CODECS=\"%s\",VIDEO-RANGE=%s,NAME=\"%s-%s\"%s\n",
+					totalBandwidth, params.Width, params.Height, codecsAttr, tierRange, q, tier, groupAttrs))
 			}
 			sb.WriteString(fmt.Sprintf("%s/%s.m3u8\n", tier, q))
 		}
+
+		// I-frames carry no audio
+		for _, q := range qualities {
+			if iframes := iFrames[tier][q]; iframes != nil && (q != domain.Quality2160p || include4K) {
+				iFrameAttrs := fmt.Sprintf(",CODECS=\"%s\",VIDEO-RANGE=%s", codecs[tier][q], tierRange)
+				sb.WriteString(iFrameStreamTag(iframes, ladder.Params(q), q, iFrameAttrs, string(tier)+"/"+iframes.URI))
+			}
+		}
 		sb.WriteString("\n")
 	}
 
 	return sb.String()
 }
 
+// GenerateAudioMasterPlaylist generates the master playlist of an audio-only
+// profile: one variant per bitrate, named as domain.AudioOnlyName, whose
+// CODECS is the audio codec alone, so players select among them by bandwidth
+func GenerateAudioMasterPlaylist(bitrates []string, codecString string) string {
+	var sb strings.Builder
+	sb.WriteString("#EXTM3U\n")
+	sb.WriteString("#EXT-X-VERSION:7\n")
+	sb.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n\n")
+
+	for _, bitrate := range bitrates {
+		name := domain.AudioOnlyName(bitrate)
+		sb.WriteString(fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,CODECS=\"%s\",NAME=\"%s\"\n",
+			parseBitrate(bitrate), codecString, name))
+		sb.WriteString(fmt.Sprintf("%s.m3u8\n\n", name))
+	}
+
+	return sb.String()
+}
+
 func parseBitrate(bitrate string) int {
 	bitrate = strings.TrimSuffix(bitrate, "k")
 	bitrate = strings.TrimSuffix(bitrate, "K")
@@ -0,0 +1,132 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// bumperSDRWhite is the luminance in nits SDR white of a bumper is mapped to
+// in HDR renditions (the reference white of BT.2408)
+const bumperSDRWhite = 203
+
+// BuildBumperCommandForTier builds a command that encodes an intro or outro
+// clip like the rendition of quality in tier, so the two can be concatenated
+// without re-encoding the rendition. The video gets the encoder settings the
+// source was encoded with, scaled to the frame of the rendition and converted
+// to its frame rate and color; the audio is copied into every audio track of
+// the rendition, or filled with silence if the clip has none.
+func (b *CommandBuilder) BuildBumperCommandForTier(
+	bumperPath string,
+	outputPath string,
+	bumper *domain.VideoMetadata,
+	rendition *domain.VideoMetadata,
+	source *domain.VideoMetadata,
+	quality domain.Quality,
+	profile domain.Profile,
+	tier domain.EncodingTier,
+	scaleMode string,
+) *TranscodeCommand {
+	params := profile.QualityParams(quality)
+	codec := domain.GetTierConfig(tier).VideoCodec
+	duration := fmt.Sprintf("%.3f", bumper.Duration.Seconds())
+
+	args := []string{"-y"}
+	if b.UsesGPU() {
+		args = append(args, hwDeviceArgs(b.hwaccel, b.hwDevice)...)
+	}
+	args = append(args, "-i", bumperPath)
+	audioInput := "0:a:0"
+	if len(bumper.AudioTracks) == 0 {
+		args = append(args, "-f", "lavfi", "-t", duration, "-i", "anullsrc=r=48000:cl=stereo")
+		audioInput = "1:a:0"
+	}
+	args = append(args,
+		"-progress", "pipe:1",
+		"-stats_period", "1",
+	)
+
+	// Video: frame size, rate and color of the rendition
+	filters := []string{bumperScaleFilter(rendition.Width, rendition.Height, scaleMode), "setsar=1"}
+	if rendition.FPS > 0 {
+		num, den := domain.FrameRate(rendition.FPS, 1)
+		filters = append(filters, fmt.Sprintf("fps=%d/%d", num, den))
+	}
+	bitDepth := b.bitDepth(codec, source, profile)
+	if hdr := rendition.HDR; hdr != nil {
+		filters = append(filters, fmt.Sprintf("zscale=tin=bt709:min=bt709:pin=bt709:t=%s:m=bt2020nc:p=bt2020:npl=%d",
+			hdr.ColorTransfer, bumperSDRWhite))
+		bitDepth = 10
+	}
+	pixFmt, upload := "yuv420p", ""
+	if bitDepth == 10 {
+		pixFmt = "yuv420p10le"
+	}
+	if b.UsesGPU() {
+		pixFmt, upload = b.hwFrameFormat(true, bitDepth)
+	}
+	if pixFmt != "" {
+		filters = append(filters, "format="+pixFmt)
+	}
+	if upload != "" {
+		filters = append(filters, upload)
+	}
+
+	var video []string
+	switch {
+	case codec == domain.VideoCodecH265 && b.UsesGPU():
+		video = b.buildH265GPUArgs(quality, params, source, profile)
+	case codec == domain.VideoCodecH265:
+		video = b.buildH265CPUArgs(quality, params, source, profile)
+	case b.UsesGPU():
+		video = b.buildGPUVideoArgs(quality, params, source, profile)
+	default:
+		video = b.buildCPUVideoArgs(quality, params, source, profile)
+	}
+	// The clip has no Dolby Vision metadata to carry
+	video = dropOption(dropOption(video, "-vf"), "-dolbyvision")
+
+	args = append(args, "-map", "0:v:0")
+	args = append(args, video...)
+	args = append(args, "-vf", strings.Join(filters, ","))
+
+	// Audio: one copy of the clip audio per track of the rendition, padded to
+	// the length of the video so the tracks stay in sync after the clip
+	if tracks := len(rendition.AudioTracks); tracks > 0 {
+		labels := make([]string, tracks)
+		for i := range labels {
+			labels[i] = fmt.Sprintf("[a%d]", i)
+		}
+		graph := fmt.Sprintf("[%s]aresample=48000,aformat=channel_layouts=stereo,apad", audioInput)
+		if tracks > 1 {
+			graph += fmt.Sprintf(",asplit=%d", tracks)
+		}
+		args = append(args, "-filter_complex", graph+strings.Join(labels, ""))
+		for _, label := range labels {
+			args = append(args, "-map", label)
+		}
+		args = append(args, b.audioCodecArgs(source, tier)...)
+	}
+
+	args = append(args,
+		"-t", duration,
+		"-movflags", "+faststart",
+		outputPath,
+	)
+
+	return &TranscodeCommand{
+		Args:       args,
+		OutputPath: outputPath,
+	}
+}
+
+// bumperScaleFilter scales a clip into a frame of width x height: fit pads it,
+// fill crops it
+func bumperScaleFilter(width, height int, scaleMode string) string {
+	if scaleMode == domain.BumperScaleFill {
+		return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d", width, height, width, height)
+	}
+	return fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2",
+		width, height, width, height)
+}
@@ -0,0 +1,120 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// MezzanineRendition is the checkpoint key of the archival master
+const MezzanineRendition = "mezzanine"
+
+// checkpointEntry records a rendition that was encoded and validated
+type checkpointEntry struct {
+	Rendition string `json:"rendition"`
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+}
+
+// TranscodeCheckpoint is an append-only JSON lines log of completed renditions.
+// A retried transcode activity skips renditions listed there whose output is
+// still on disk with the recorded size, instead of encoding everything again.
+type TranscodeCheckpoint struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]checkpointEntry
+}
+
+// LoadTranscodeCheckpoint reads the checkpoint log; a missing file is an empty checkpoint
+func LoadTranscodeCheckpoint(path string) (*TranscodeCheckpoint, error) {
+	c := &TranscodeCheckpoint{
+		path:    path,
+		entries: make(map[string]checkpointEntry),
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+		return nil, fmt.Errorf("failed to open transcode checkpoint: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry checkpointEntry
+		// A torn last line from a crash is ignored; that rendition is encoded again
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		c.entries[entry.Rendition] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transcode checkpoint: %w", err)
+	}
+
+	return c, nil
+}
+
+// RenditionKey names a (tier, quality) rendition in the checkpoint
+func RenditionKey(tier, quality string) string {
+	return tier + "/" + quality
+}
+
+// Lookup returns the output path of a completed rendition if the file still
+// exists with the size recorded when it was validated
+func (c *TranscodeCheckpoint) Lookup(rendition string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[rendition]
+	c.mu.Unlock()
+	if !ok {
+		return "", false
+	}
+
+	info, err := os.Stat(entry.Path)
+	if err != nil || info.Size() != entry.Size {
+		return "", false
+	}
+	return entry.Path, true
+}
+
+// Record appends a validated rendition to the checkpoint
+func (c *TranscodeCheckpoint) Record(rendition, path string) error {
+	if c == nil {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat rendition: %w", err)
+	}
+	entry := checkpointEntry{Rendition: rendition, Path: path, Size: info.Size()}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	file, err := os.OpenFile(c.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open transcode checkpoint: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write transcode checkpoint: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write transcode checkpoint: %w", err)
+	}
+
+	c.entries[rendition] = entry
+	return nil
+}
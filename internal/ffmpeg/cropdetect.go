@@ -0,0 +1,58 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// cropPattern matches the area cropdetect logs for every frame
+var cropPattern = regexp.MustCompile(`crop=(\d+):(\d+):(\d+):(\d+)`)
+
+// DetectCrop runs cropdetect over length of the source from start and returns
+// the picture area of the sample. cropdetect never resets, so the last area it
+// logs contains the picture of every frame of the sample. The frames are
+// turned upright by ffmpeg, matching the frame the encodes crop.
+func DetectCrop(
+	ctx context.Context,
+	ffmpegPath string,
+	inputPath string,
+	metadata *domain.VideoMetadata,
+	start, length time.Duration,
+	limit int,
+) (*domain.CropRect, error) {
+	args := []string{
+		"-hide_banner", "-nostats",
+		"-ss", fmt.Sprintf("%.3f", start.Seconds()),
+		"-t", fmt.Sprintf("%.3f", length.Seconds()),
+		"-i", inputPath,
+		"-map", videoStreamSpec(metadata),
+		"-an", "-sn",
+		"-vf", fmt.Sprintf("cropdetect=limit=%d:round=2:reset=0", limit),
+		"-f", "null", "-",
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpegPath, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("cropdetect failed: %w", err)
+	}
+
+	matches := cropPattern.FindAllSubmatch(stderr.Bytes(), -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("cropdetect reported no area")
+	}
+	last := matches[len(matches)-1]
+	var values [4]int
+	for i := range values {
+		values[i], _ = strconv.Atoi(string(last[i+1]))
+	}
+	return &domain.CropRect{Width: values[0], Height: values[1], X: values[2], Y: values[3]}, nil
+}
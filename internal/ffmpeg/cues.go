@@ -0,0 +1,107 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CueMarker is an ad break on the timeline of the output
+type CueMarker struct {
+	Start time.Duration
+	// Duration of the content the break replaces; 0 inserts the break
+	// between two segments
+	Duration time.Duration
+}
+
+// InsertCueMarkers marks ad breaks in the media playlist at playlistPath for
+// server-side ad insertion and returns them as placed. Ads can only be
+// spliced between segments, so a break starts at the segment boundary
+// nearest to its start with EXT-X-CUE-OUT and returns with EXT-X-CUE-IN at
+// the boundary nearest to its end, later than the start; a break without
+// duration gets both at one boundary. The duration of EXT-X-CUE-OUT is the
+// one between the boundaries. Breaks that would overlap the previous one
+// once placed are left out.
+func InsertCueMarkers(playlistPath string, breaks []CueMarker) ([]CueMarker, error) {
+	content, err := os.ReadFile(playlistPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read playlist: %w", err)
+	}
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+
+	// Segment i starts at starts[i] with the line at heads[i]; the last entry
+	// is the end of the playlist
+	var (
+		starts  []time.Duration
+		heads   []int
+		elapsed time.Duration
+		end     = len(lines)
+	)
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			seconds, _ := strconv.ParseFloat(value, 64)
+			starts = append(starts, elapsed)
+			heads = append(heads, i)
+			elapsed += time.Duration(seconds * float64(time.Second))
+		case line == "#EXT-X-ENDLIST":
+			end = i
+		}
+	}
+	if len(starts) == 0 {
+		return nil, fmt.Errorf("playlist has no segments")
+	}
+	starts = append(starts, elapsed)
+	heads = append(heads, end)
+
+	tags := make(map[int][]string)
+	var placed []CueMarker
+	next := 0 // first boundary the next break can start at
+	for _, b := range breaks {
+		out := nearestBoundary(starts, b.Start, 0, len(starts)-2)
+		if out < next {
+			continue
+		}
+		in := out
+		if b.Duration > 0 {
+			in = nearestBoundary(starts, b.Start+b.Duration, out+1, len(starts)-1)
+		}
+
+		m := CueMarker{Start: starts[out], Duration: starts[in] - starts[out]}
+		tags[heads[out]] = append(tags[heads[out]], fmt.Sprintf("#EXT-X-CUE-OUT:%.3f", m.Duration.Seconds()))
+		tags[heads[in]] = append(tags[heads[in]], "#EXT-X-CUE-IN")
+		placed = append(placed, m)
+		next = max(in, out+1)
+	}
+
+	var sb strings.Builder
+	for i, line := range lines {
+		for _, tag := range tags[i] {
+			sb.WriteString(tag + "\n")
+		}
+		sb.WriteString(line + "\n")
+	}
+	for _, tag := range tags[len(lines)] {
+		sb.WriteString(tag + "\n")
+	}
+	if err := os.WriteFile(playlistPath, []byte(sb.String()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write playlist: %w", err)
+	}
+	return placed, nil
+}
+
+// nearestBoundary returns the index in starts, between from and to, of the
+// boundary nearest to t
+func nearestBoundary(starts []time.Duration, t time.Duration, from, to int) int {
+	best := from
+	for i := from + 1; i <= to; i++ {
+		if (starts[i] - t).Abs() < (starts[best] - t).Abs() {
+			best = i
+		}
+	}
+	return best
+}
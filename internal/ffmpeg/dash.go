@@ -3,8 +3,8 @@ package ffmpeg
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,8 +16,46 @@ type DASHManifest struct {
 	Duration        time.Duration
 	SegmentDuration int
 	Qualities       []domain.Quality
-	TierDir         string // e.g., "modern" for fMP4 segments
 	BaseURL         string // optional base URL for segments
+	// Ladder holds the custom rungs of the qualities, if any
+	Ladder domain.Ladder
+	// Video holds a video adaptation set per tier segmented as fMP4; DASH
+	// clients pick the codec they decode, as HLS clients pick a tier
+	Video []DASHVideo
+	// Audio adds an adaptation set per audio-only rendition; audio muxed into
+	// the video segments has none, as it is only read with the video
+	Audio []AudioRendition
+	// AudioDir holds the segments of the audio, those of the first tier
+	AudioDir string
+	// Subtitles adds a WebVTT adaptation set per track, read from SubtitlesDir
+	Subtitles []SubtitleRendition
+	// Cues are the ad breaks, signaled as SCTE-35 events of the period
+	Cues []CueMarker
+}
+
+// DASHVideo is the video adaptation set of the renditions of one tier
+type DASHVideo struct {
+	// Dir holds the segments, relative to the manifest, e.g. "modern"
+	Dir        string
+	VideoCodec domain.VideoCodec
+	// Codecs holds the RFC 6381 codec of each rendition
+	Codecs map[domain.Quality]string
+	// FrameRates holds the frame rate of each rendition, see DASHFrameRate;
+	// renditions without one are signaled without frameRate
+	FrameRates map[domain.Quality]string
+}
+
+// DASHFrameRate returns the frameRate attribute of a rendition of fps frames
+// per second: a whole rate, or NTSC rates such as 29.97 as 30000/1001
+func DASHFrameRate(fps float64) string {
+	if fps <= 0 {
+		return ""
+	}
+	num, den := domain.FrameRate(fps, 1)
+	if den == 1 {
+		return strconv.Itoa(num)
+	}
+	return fmt.Sprintf("%d/%d", num, den)
 }
 
 // GenerateDASHManifest generates DASH MPD manifest for fMP4 segments (CMAF compatible)
@@ -45,75 +83,71 @@ func GenerateDASHManifest(manifest DASHManifest) string {
 	}
 
 	sb.WriteString("  <Period>\n")
-
-	// Video AdaptationSet
-	sb.WriteString(`    <AdaptationSet mimeType="video/mp4" segmentAlignment="true" startWithSAP="1">`)
-	sb.WriteString("\n")
+	if len(manifest.Cues) > 0 {
+		writeCueEventStream(&sb, manifest.Cues)
+	}
 
 	// Sort qualities by resolution (descending)
 	sortedQualities := make([]domain.Quality, len(manifest.Qualities))
 	copy(sortedQualities, manifest.Qualities)
 	sort.Slice(sortedQualities, func(i, j int) bool {
-		pi := sortedQualities[i].Params()
-		pj := sortedQualities[j].Params()
+		pi := manifest.Ladder.Params(sortedQualities[i])
+		pj := manifest.Ladder.Params(sortedQualities[j])
 		return pi.Width*pi.Height > pj.Width*pj.Height
 	})
 
-	for _, q := range sortedQualities {
-		if q == domain.QualityOrigin {
-			continue // Skip origin for DASH (no fixed resolution)
-		}
-
-		params := q.Params()
-		// Apply H.265 bitrate multiplier for modern tier
-		videoBitrate := int(float64(parseBitrate(params.VideoBitrate)) * domain.VideoCodecH265.BitrateMultiplier())
-
-		qualityStr := string(q)
-		initPath := qualityStr + "_init.mp4"
-		mediaTemplate := qualityStr + "_$Number%05d$.m4s"
+	for _, video := range manifest.Video {
+		writeVideoAdaptationSet(&sb, video, manifest, sortedQualities)
+	}
 
-		if manifest.TierDir != "" {
-			initPath = manifest.TierDir + "/" + initPath
-			mediaTemplate = manifest.TierDir + "/" + mediaTemplate
-		}
+	// Audio AdaptationSets; the bitrate of the top rung applies to all
+	audioBitrate := 0
+	if len(sortedQualities) > 0 {
+		audioBitrate = parseBitrate(manifest.Ladder.Params(sortedQualities[0]).AudioBitrate)
+	}
+	for _, r := range manifest.Audio {
+		writeAudioAdaptationSet(&sb, r, manifest, audioBitrate, len(manifest.Audio) > 1)
+	}
 
-		sb.WriteString(fmt.Sprintf(`      <Representation id="%s" bandwidth="%d" width="%d" height="%d" codecs="hvc1.1.6.L120.90" frameRate="24">`,
-			qualityStr, videoBitrate, params.Width, params.Height))
-		sb.WriteString("\n")
-		sb.WriteString(fmt.Sprintf(`        <SegmentTemplate timescale="1000" duration="%d" initialization="%s" media="%s" startNumber="0"/>`,
-			manifest.SegmentDuration*1000, initPath, mediaTemplate))
-		sb.WriteString("\n")
-		sb.WriteString("      </Representation>\n")
+	for _, sub := range manifest.Subtitles {
+		writeSubtitleAdaptationSet(&sb, sub)
 	}
 
-	sb.WriteString("    </AdaptationSet>\n")
+	sb.WriteString("  </Period>\n")
+	sb.WriteString("</MPD>\n")
+
+	return sb.String()
+}
 
-	// Audio AdaptationSet
-	sb.WriteString(`    <AdaptationSet mimeType="audio/mp4" segmentAlignment="true" startWithSAP="1" lang="und">`)
+// writeVideoAdaptationSet writes the adaptation set of the renditions of a
+// tier. Representation ids carry the tier, as the ids of all adaptation sets
+// share one namespace.
+func writeVideoAdaptationSet(sb *strings.Builder, video DASHVideo, manifest DASHManifest, sortedQualities []domain.Quality) {
+	sb.WriteString(`    <AdaptationSet mimeType="video/mp4" segmentAlignment="true" startWithSAP="1">`)
 	sb.WriteString("\n")
 
-	// Use first quality for audio (all have same audio)
-	if len(sortedQualities) > 0 {
-		firstQuality := sortedQualities[0]
-		if firstQuality == domain.QualityOrigin && len(sortedQualities) > 1 {
-			firstQuality = sortedQualities[1]
+	for _, q := range sortedQualities {
+		if q == domain.QualityOrigin {
+			continue // Skip origin for DASH (no fixed resolution)
 		}
 
-		params := firstQuality.Params()
-		audioBitrate := parseBitrate(params.AudioBitrate)
-		qualityStr := string(firstQuality)
-		initPath := qualityStr + "_init.mp4"
-		mediaTemplate := qualityStr + "_$Number%05d$.m4s"
+		params := manifest.Ladder.Params(q)
+		// Adjust bitrate for codec efficiency
+		videoBitrate := int(float64(parseBitrate(params.VideoBitrate)) * video.VideoCodec.BitrateMultiplier())
 
-		if manifest.TierDir != "" {
-			initPath = manifest.TierDir + "/" + initPath
-			mediaTemplate = manifest.TierDir + "/" + mediaTemplate
+		id := string(q)
+		initPath := segmentPath(video.Dir, id+"_init.mp4")
+		mediaTemplate := segmentPath(video.Dir, id+"_$Number%05d$.m4s")
+		if video.Dir != "" {
+			id = video.Dir + "-" + id
 		}
 
-		sb.WriteString(fmt.Sprintf(`      <Representation id="audio" bandwidth="%d" codecs="mp4a.40.2" audioSamplingRate="48000">`,
-			audioBitrate))
-		sb.WriteString("\n")
-		sb.WriteString(`        <AudioChannelConfiguration schemeIdUri="urn:mpeg:dash:23003:3:audio_channel_configuration:2011" value="2"/>`)
+		frameRate := ""
+		if rate := video.FrameRates[q]; rate != "" {
+			frameRate = fmt.Sprintf(` frameRate="%s"`, rate)
+		}
+		sb.WriteString(fmt.Sprintf(`      <Representation id="%s" bandwidth="%d" width="%d" height="%d" codecs="%s"%s>`,
+			id, videoBitrate, params.Width, params.Height, video.Codecs[q], frameRate))
 		sb.WriteString("\n")
 		sb.WriteString(fmt.Sprintf(`        <SegmentTemplate timescale="1000" duration="%d" initialization="%s" media="%s" startNumber="0"/>`,
 			manifest.SegmentDuration*1000, initPath, mediaTemplate))
@@ -122,122 +156,131 @@ func GenerateDASHManifest(manifest DASHManifest) string {
 	}
 
 	sb.WriteString("    </AdaptationSet>\n")
-	sb.WriteString("  </Period>\n")
-	sb.WriteString("</MPD>\n")
-
-	return sb.String()
 }
 
-// GenerateDASHManifestWithSegmentList generates DASH MPD with explicit segment list
-// This is more accurate but requires scanning the segment files
-func GenerateDASHManifestWithSegmentList(
-	hlsDir string,
-	tierDir string,
-	qualities []domain.Quality,
-	duration time.Duration,
-	segmentDuration int,
-) (string, error) {
-	var sb strings.Builder
+// segmentPath returns the path of a segment file relative to the manifest
+func segmentPath(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
 
-	durationISO := formatDuration(duration)
+// writeAudioAdaptationSet writes the adaptation set of an audio-only rendition.
+// With several renditions the main audio gets the main role; the audio
+// description is signaled with the DVB audio purpose scheme players use for
+// accessibility.
+func writeAudioAdaptationSet(sb *strings.Builder, r AudioRendition, manifest DASHManifest, bitrate int, roles bool) {
+	initPath := segmentPath(manifest.AudioDir, r.Name+"_init.mp4")
+	mediaTemplate := segmentPath(manifest.AudioDir, r.Name+"_$Number%05d$.m4s")
 
-	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	sb.WriteString(fmt.Sprintf(`    <AdaptationSet mimeType="audio/mp4" segmentAlignment="true" startWithSAP="1" lang="%s">`, dashLanguage(r.Language)))
 	sb.WriteString("\n")
-	sb.WriteString(fmt.Sprintf(`<MPD xmlns="urn:mpeg:dash:schema:mpd:2011" `+
-		`profiles="urn:mpeg:dash:profile:isoff-on-demand:2011" `+
-		`type="static" `+
-		`mediaPresentationDuration="%s" `+
-		`minBufferTime="PT2S">`, durationISO))
+	switch {
+	case r.Description:
+		sb.WriteString(`      <Accessibility schemeIdUri="urn:tva:metadata:cs:AudioPurposeCS:2007" value="1"/>`)
+		sb.WriteString("\n")
+		sb.WriteString(`      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="description"/>`)
+		sb.WriteString("\n")
+	case roles && r.Default:
+		sb.WriteString(`      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="main"/>`)
+		sb.WriteString("\n")
+	case roles:
+		sb.WriteString(`      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="alternate"/>`)
+		sb.WriteString("\n")
+	}
+	if r.Bitrate > 0 {
+		bitrate = r.Bitrate
+	}
+	sb.WriteString(fmt.Sprintf(`      <Representation id="%s" bandwidth="%d" codecs="%s" audioSamplingRate="48000">`,
+		r.Name, bitrate, r.Codec))
 	sb.WriteString("\n")
-
-	sb.WriteString("  <Period>\n")
-
-	// Video AdaptationSet
-	sb.WriteString(`    <AdaptationSet mimeType="video/mp4" segmentAlignment="true" startWithSAP="1">`)
+	sb.WriteString(fmt.Sprintf(`        <AudioChannelConfiguration schemeIdUri="%s" value="%s"/>`,
+		audioChannelScheme(r), audioChannelValue(r)))
 	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf(`        <SegmentTemplate timescale="1000" duration="%d" initialization="%s" media="%s" startNumber="0"/>`,
+		manifest.SegmentDuration*1000, initPath, mediaTemplate))
+	sb.WriteString("\n")
+	sb.WriteString("      </Representation>\n")
+	sb.WriteString("    </AdaptationSet>\n")
+}
 
-	// Sort qualities
-	sortedQualities := make([]domain.Quality, len(qualities))
-	copy(sortedQualities, qualities)
-	sort.Slice(sortedQualities, func(i, j int) bool {
-		pi := sortedQualities[i].Params()
-		pj := sortedQualities[j].Params()
-		return pi.Width*pi.Height > pj.Width*pj.Height
-	})
-
-	for _, q := range sortedQualities {
-		if q == domain.QualityOrigin {
-			continue
-		}
-
-		params := q.Params()
-		videoBitrate := int(float64(parseBitrate(params.VideoBitrate)) * domain.VideoCodecH265.BitrateMultiplier())
-		qualityStr := string(q)
-
-		// Find segment files
-		segmentDir := hlsDir
-		if tierDir != "" {
-			segmentDir = filepath.Join(hlsDir, tierDir)
-		}
-
-		segments, err := findSegments(segmentDir, qualityStr)
-		if err != nil {
-			continue // Skip this quality if segments not found
-		}
+// audioChannelScheme returns the channel configuration scheme of a rendition:
+// the Dolby scheme for copied AC-3 and E-AC-3 tracks, MPEG for AAC
+func audioChannelScheme(r AudioRendition) string {
+	if r.Codec == "ac-3" || r.Codec == "ec-3" {
+		return "tag:dolby.com,2014:dash:audio_channel_configuration:2011"
+	}
+	return "urn:mpeg:dash:23003:3:audio_channel_configuration:2011"
+}
 
-		initFile := qualityStr + "_init.mp4"
-		initPath := initFile
-		if tierDir != "" {
-			initPath = tierDir + "/" + initFile
+// audioChannelValue returns the channel configuration of a rendition: the
+// Dolby channel mask of 5.1 and 7.1 layouts, the channel count otherwise
+func audioChannelValue(r AudioRendition) string {
+	if audioChannelScheme(r) != "urn:mpeg:dash:23003:3:audio_channel_configuration:2011" {
+		switch r.Channels {
+		case 6:
+			return "F801"
+		case 8:
+			return "FA01"
 		}
+	}
+	return strconv.Itoa(r.Channels)
+}
 
-		sb.WriteString(fmt.Sprintf(`      <Representation id="%s" bandwidth="%d" width="%d" height="%d" codecs="hvc1.1.6.L120.90">`,
-			qualityStr, videoBitrate, params.Width, params.Height))
+// writeCueEventStream writes the ad breaks as SCTE-35 splice_insert events in
+// the XML form of SCTE 214, timed in 90 kHz ticks like the splice times. A
+// break without duration has no break duration.
+func writeCueEventStream(sb *strings.Builder, cues []CueMarker) {
+	sb.WriteString(`    <EventStream schemeIdUri="urn:scte:scte35:2013:xml" timescale="90000">`)
+	sb.WriteString("\n")
+	for i, cue := range cues {
+		start := int64(cue.Start * 90000 / time.Second)
+		duration := int64(cue.Duration * 90000 / time.Second)
+		sb.WriteString(fmt.Sprintf(`      <Event presentationTime="%d" duration="%d" id="%d">`, start, duration, i+1))
 		sb.WriteString("\n")
-		sb.WriteString(fmt.Sprintf(`        <SegmentBase indexRange="0-0">
-          <Initialization sourceURL="%s"/>
-        </SegmentBase>`, initPath))
+		sb.WriteString(`        <scte35:SpliceInfoSection xmlns:scte35="http://www.scte.org/schemas/35/2016">`)
 		sb.WriteString("\n")
-		sb.WriteString("        <SegmentList>\n")
-
-		for _, seg := range segments {
-			segPath := seg
-			if tierDir != "" {
-				segPath = tierDir + "/" + seg
-			}
-			sb.WriteString(fmt.Sprintf(`          <SegmentURL media="%s"/>`, segPath))
+		sb.WriteString(fmt.Sprintf(`          <scte35:SpliceInsert spliceEventId="%d" outOfNetworkIndicator="true" spliceImmediateFlag="false">`, i+1))
+		sb.WriteString("\n")
+		sb.WriteString(fmt.Sprintf(`            <scte35:Program><scte35:SpliceTime ptsTime="%d"/></scte35:Program>`, start))
+		sb.WriteString("\n")
+		if duration > 0 {
+			sb.WriteString(fmt.Sprintf(`            <scte35:BreakDuration autoReturn="true" duration="%d"/>`, duration))
 			sb.WriteString("\n")
 		}
-
-		sb.WriteString("        </SegmentList>\n")
-		sb.WriteString("      </Representation>\n")
+		sb.WriteString("          </scte35:SpliceInsert>\n")
+		sb.WriteString("        </scte35:SpliceInfoSection>\n")
+		sb.WriteString("      </Event>\n")
 	}
-
-	sb.WriteString("    </AdaptationSet>\n")
-	sb.WriteString("  </Period>\n")
-	sb.WriteString("</MPD>\n")
-
-	return sb.String(), nil
+	sb.WriteString("    </EventStream>\n")
 }
 
-// findSegments finds all .m4s segment files for a quality
-func findSegments(dir string, quality string) ([]string, error) {
-	pattern := filepath.Join(dir, quality+"_*.m4s")
-	matches, err := filepath.Glob(pattern)
-	if err != nil {
-		return nil, err
+// writeSubtitleAdaptationSet writes the adaptation set of a sidecar WebVTT track.
+// Forced tracks get the forced-subtitle role so players show them automatically.
+func writeSubtitleAdaptationSet(sb *strings.Builder, sub SubtitleRendition) {
+	role := "subtitle"
+	if sub.Forced {
+		role = "forced-subtitle"
 	}
 
-	// Sort by name to ensure correct order
-	sort.Strings(matches)
+	sb.WriteString(fmt.Sprintf(`    <AdaptationSet mimeType="text/vtt" lang="%s">`, dashLanguage(sub.Language)))
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf(`      <Role schemeIdUri="urn:mpeg:dash:role:2011" value="%s"/>`, role))
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf(`      <Representation id="subtitles-%s" bandwidth="256">`, sub.Name))
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("        <BaseURL>%s/%s.vtt</BaseURL>\n", SubtitlesDir, sub.Name))
+	sb.WriteString("      </Representation>\n")
+	sb.WriteString("    </AdaptationSet>\n")
+}
 
-	// Extract just filenames
-	segments := make([]string, len(matches))
-	for i, m := range matches {
-		segments[i] = filepath.Base(m)
+// dashLanguage returns the lang attribute of an adaptation set, "und" if unknown
+func dashLanguage(language string) string {
+	if language == "" {
+		return "und"
 	}
-
-	return segments, nil
+	return language
 }
 
 // formatDuration converts Go duration to ISO 8601 duration format
@@ -1,12 +1,17 @@
 package ffmpeg
 
 import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -91,3 +96,97 @@ func (e *EncryptionInfo) KeyHex() string {
 func (e *EncryptionInfo) IVHex() string {
 	return hex.EncodeToString(e.IV)
 }
+
+// ApplyPreviewEncryption encrypts segments of an unencrypted VOD playlist that start at or
+// after the preview window and inserts EXT-X-KEY before the first protected segment, so the
+// head of the stream plays without a key. Returns the number of encrypted segments.
+func ApplyPreviewEncryption(playlistPath string, encryption *EncryptionInfo, preview time.Duration) (int, error) {
+	content, err := os.ReadFile(playlistPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read playlist: %w", err)
+	}
+
+	dir := filepath.Dir(playlistPath)
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	out := make([]string, 0, len(lines)+1)
+
+	var (
+		elapsed     time.Duration
+		segDuration time.Duration
+		blockStart  int
+		keyInserted bool
+		encrypted   int
+	)
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "#EXTINF:"):
+			value := strings.TrimPrefix(trimmed, "#EXTINF:")
+			if i := strings.Index(value, ","); i >= 0 {
+				value = value[:i]
+			}
+			sec, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return encrypted, fmt.Errorf("invalid EXTINF %q: %w", trimmed, err)
+			}
+			segDuration = time.Duration(sec * float64(time.Second))
+			out = append(out, line)
+
+		case trimmed != "" && !strings.HasPrefix(trimmed, "#"):
+			// Segment URI: everything from blockStart belongs to this segment
+			if elapsed >= preview {
+				if !keyInserted {
+					keyTag := fmt.Sprintf("#EXT-X-KEY:METHOD=AES-128,URI=\"%s\",IV=0x%s", encryption.KeyURL, encryption.IVHex())
+					out = append(out[:blockStart], append([]string{keyTag}, out[blockStart:]...)...)
+					keyInserted = true
+				}
+				if err := encryptSegment(filepath.Join(dir, trimmed), encryption); err != nil {
+					return encrypted, err
+				}
+				encrypted++
+			}
+			out = append(out, line)
+			elapsed += segDuration
+			segDuration = 0
+			blockStart = len(out)
+
+		default:
+			out = append(out, line)
+			if strings.HasPrefix(trimmed, "#EXT-X-MAP") {
+				// Init segment stays in clear, key applies to media segments only
+				blockStart = len(out)
+			}
+		}
+	}
+
+	if err := os.WriteFile(playlistPath, []byte(strings.Join(out, "\n")+"\n"), 0644); err != nil {
+		return encrypted, fmt.Errorf("failed to write playlist: %w", err)
+	}
+
+	return encrypted, nil
+}
+
+// encryptSegment encrypts a segment in place with AES-128-CBC and PKCS#7 padding
+func encryptSegment(path string, encryption *EncryptionInfo) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read segment: %w", err)
+	}
+
+	block, err := aes.NewCipher(encryption.Key)
+	if err != nil {
+		return fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	data = append(data, bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	cipher.NewCBCEncrypter(block, encryption.IV).CryptBlocks(data, data)
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write segment: %w", err)
+	}
+	return nil
+}
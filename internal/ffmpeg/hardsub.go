@@ -0,0 +1,97 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// BuildHardsubCommand burns the WebVTT subtitles at subtitlePath into an H.264
+// rendition. The rendition is already scaled, cropped, upright and stitched
+// with the bumpers the subtitles were shifted for, so it is encoded again with
+// its own rung parameters instead of the source being filtered once more.
+// libass renders on the CPU; the audio is copied.
+func (b *CommandBuilder) BuildHardsubCommand(
+	inputPath string,
+	outputDir string,
+	subtitlePath string,
+	quality domain.Quality,
+	metadata *domain.VideoMetadata,
+	profile domain.Profile,
+) *TranscodeCommand {
+	params := profile.QualityParams(quality)
+	outputPath := filepath.Join(outputDir, string(quality)+".mp4")
+
+	settings := b.encoderSettings(domain.VideoCodecH264, quality, profile)
+	h264Profile, pixFmt := "high", "yuv420p"
+	if settings.Profile != "" {
+		h264Profile = settings.Profile
+	}
+	if b.bitDepth(domain.VideoCodecH264, metadata, profile) == 10 {
+		h264Profile, pixFmt = "high10", "yuv420p10le"
+	}
+
+	args := []string{
+		"-y",
+		"-i", inputPath,
+		"-progress", "pipe:1",
+		"-stats_period", "1",
+		"-map", "0:v:0",
+		"-map", "0:a?",
+		"-vf", "subtitles=filename=" + filterPath(subtitlePath),
+		"-c:v", "libx264",
+		"-preset", settings.Preset,
+		"-crf", strconv.Itoa(rungCRF(params, 23)),
+		"-profile:v", h264Profile,
+	}
+	if settings.Level != "" {
+		args = append(args, "-level", settings.Level)
+	}
+	args = append(args,
+		"-pix_fmt", pixFmt,
+		"-threads", strconv.Itoa(b.threadCount()),
+	)
+	if settings.Tune != "" {
+		args = append(args, "-tune", settings.Tune)
+	}
+	if settings.Params != "" {
+		args = append(args, "-x264-params", settings.Params)
+	}
+	if metadata != nil && metadata.HDR != nil {
+		args = append(args, sdrColorArgs...)
+	}
+	if quality != domain.QualityOrigin {
+		args = append(args,
+			"-b:v", params.VideoBitrate,
+			"-maxrate", params.MaxBitrate,
+			"-bufsize", params.BufSize,
+		)
+	}
+
+	// The rendition has the capped frame rate already; only the GOP follows it
+	_, divisor := frameRateArgs(quality, metadata, profile)
+	gop := profile.Algorithm.GOPSize(metadata, divisor)
+	args = append(args,
+		"-g", fmt.Sprintf("%d", gop),
+		"-keyint_min", fmt.Sprintf("%d", gop),
+		"-sc_threshold", "0",
+		"-c:a", "copy",
+		"-movflags", "+faststart",
+		outputPath,
+	)
+
+	return &TranscodeCommand{
+		Args:       args,
+		OutputPath: outputPath,
+	}
+}
+
+// filterPath escapes a file path as a filter option value: once for the
+// option parser, then for the filtergraph
+func filterPath(path string) string {
+	option := strings.NewReplacer(`\`, `\\`, `'`, `\'`, `:`, `\:`).Replace(path)
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`, `,`, `\,`, `;`, `\;`, `[`, `\[`, `]`, `\]`).Replace(option)
+}
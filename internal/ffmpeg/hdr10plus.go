@@ -0,0 +1,49 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// ExtractHDR10Plus writes the HDR10+ dynamic metadata of an HEVC source to
+// outputPath as the JSON of hdr10plus_tool, which libx265 reads per frame
+// with dhdr10-info. The video is demuxed to Annex B by ffmpeg and piped into
+// the tool.
+func ExtractHDR10Plus(ctx context.Context, ffmpegPath, toolPath, inputPath string, metadata *domain.VideoMetadata, outputPath string) error {
+	demux := exec.CommandContext(ctx, ffmpegPath,
+		"-v", "error",
+		"-i", inputPath,
+		"-map", videoStreamSpec(metadata),
+		"-c:v", "copy",
+		"-bsf:v", "hevc_mp4toannexb",
+		"-f", "hevc", "-",
+	)
+	extract := exec.CommandContext(ctx, toolPath, "extract", "-o", outputPath, "-")
+
+	var stderr bytes.Buffer
+	demux.Stderr = &stderr
+	extract.Stderr = &stderr
+	pipe, err := demux.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	extract.Stdin = pipe
+
+	if err := demux.Start(); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+	extractErr := extract.Run()
+	demuxErr := demux.Wait()
+	if extractErr != nil {
+		return fmt.Errorf("hdr10plus_tool failed: %w: %s", extractErr, strings.TrimSpace(stderr.String()))
+	}
+	if demuxErr != nil {
+		return fmt.Errorf("ffmpeg failed: %w: %s", demuxErr, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
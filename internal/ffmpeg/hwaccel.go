@@ -0,0 +1,224 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tvoe/converter/internal/config"
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// defaultDRMDevice is the render node of the VAAPI and QSV backends without a configured device
+const defaultDRMDevice = "/dev/dri/renderD128"
+
+// vtOriginQuality is the VideoToolbox constant quality (0-100) of the origin
+// rendition, which has no bitrate to encode at
+const vtOriginQuality = 65
+
+// hwEncoder returns the ffmpeg encoder of codec on a hardware backend
+func hwEncoder(hwaccel config.HWAccel, codec domain.VideoCodec) string {
+	suffix := map[config.HWAccel]string{
+		config.HWAccelNVENC:        "nvenc",
+		config.HWAccelQSV:          "qsv",
+		config.HWAccelVAAPI:        "vaapi",
+		config.HWAccelVideoToolbox: "videotoolbox",
+	}[hwaccel]
+	if codec == domain.VideoCodecH265 {
+		return "hevc_" + suffix
+	}
+	return "h264_" + suffix
+}
+
+// hwDeviceArgs returns the global options that open the device of the VAAPI
+// and QSV backends. QSV is derived from a VAAPI device, which works on both
+// the Media SDK and oneVPL runtimes (Intel Arc needs the latter).
+func hwDeviceArgs(hwaccel config.HWAccel, device string) []string {
+	if device == "" {
+		device = defaultDRMDevice
+	}
+	switch hwaccel {
+	case config.HWAccelQSV:
+		return []string{
+			"-init_hw_device", "vaapi=va:" + device,
+			"-init_hw_device", "qsv=qs@va",
+			"-filter_hw_device", "qs",
+		}
+	case config.HWAccelVAAPI:
+		return []string{
+			"-init_hw_device", "vaapi=va:" + device,
+			"-filter_hw_device", "va",
+		}
+	}
+	return nil
+}
+
+// hwDecodeArgs returns the input options of hardware decoding. Frames stay in
+// device memory for the hardware scaler unless they are filtered on the CPU.
+func (b *CommandBuilder) hwDecodeArgs(metadata *domain.VideoMetadata, framesOnCPU bool) []string {
+	args := hwDeviceArgs(b.hwaccel, b.hwDevice)
+	switch b.hwaccel {
+	case config.HWAccelNVENC:
+		return cudaDecodeArgs(metadata, framesOnCPU, b.hwDevice)
+	case config.HWAccelQSV:
+		args = append(args, "-hwaccel", "qsv", "-hwaccel_device", "qs")
+		if !framesOnCPU {
+			args = append(args, "-hwaccel_output_format", "qsv")
+		}
+	case config.HWAccelVAAPI:
+		args = append(args, "-hwaccel", "vaapi", "-hwaccel_device", "va")
+		if !framesOnCPU {
+			args = append(args, "-hwaccel_output_format", "vaapi")
+		}
+	case config.HWAccelVideoToolbox:
+		args = append(args, "-hwaccel", "videotoolbox")
+	}
+	return args
+}
+
+// framesOnCPU reports whether a hardware encode of codec receives frames from
+// system memory: when they are filtered on the CPU, and always on
+// VideoToolbox, which has no scaler that works across ffmpeg versions
+func (b *CommandBuilder) framesOnCPU(codec domain.VideoCodec, metadata *domain.VideoMetadata, profile domain.Profile) bool {
+	return b.hwaccel == config.HWAccelVideoToolbox || b.gpuFiltersOnCPU(codec, metadata, profile)
+}
+
+// hwScaleFilter scales frames in device memory to the target size
+func (b *CommandBuilder) hwScaleFilter(params domain.QualityConfig) string {
+	switch b.hwaccel {
+	case config.HWAccelNVENC:
+		return nppScaleFilter(params)
+	case config.HWAccelQSV:
+		return fmt.Sprintf("scale_qsv=w=%d:h=%d", params.Width, params.Height)
+	case config.HWAccelVAAPI:
+		return fmt.Sprintf("scale_vaapi=w=%d:h=%d", params.Width, params.Height)
+	}
+	return cpuScaleFilter(params)
+}
+
+// hwFrameFormat returns how frames in system memory reach the hardware
+// encoder at bitDepth: the pixel format the encoder accepts them in, or for
+// VAAPI, which only encodes device frames, the filter uploading them
+func (b *CommandBuilder) hwFrameFormat(framesOnCPU bool, bitDepth int) (pixFmt, upload string) {
+	if !framesOnCPU {
+		return "", ""
+	}
+	switch {
+	case b.hwaccel == config.HWAccelVAAPI && bitDepth == 10:
+		return "", "format=p010le,hwupload"
+	case b.hwaccel == config.HWAccelVAAPI:
+		return "", "format=nv12,hwupload"
+	case bitDepth == 10:
+		return "p010le", ""
+	case b.hwaccel == config.HWAccelNVENC:
+		return "yuv420p", ""
+	}
+	return "nv12", ""
+}
+
+// hwEncoderArgs returns the encoder and rate control options of codec on the
+// hardware backend. cq is the quality target: constant quality for the origin
+// rendition, which has no bitrate, and the quality ceiling of VBR rungs.
+func (b *CommandBuilder) hwEncoderArgs(codec domain.VideoCodec, quality domain.Quality, cq int) []string {
+	args := []string{"-c:v", hwEncoder(b.hwaccel, codec)}
+
+	switch b.hwaccel {
+	case config.HWAccelNVENC:
+		args = append(args,
+			"-preset", "p2", // Faster preset for better throughput
+			"-tune", "hq",
+			"-rc", "vbr",
+			"-cq", strconv.Itoa(cq),
+		)
+		if codec == domain.VideoCodecH264 {
+			args = append(args,
+				"-b_ref_mode", "middle", // Use B-frames as references for better quality
+				"-spatial_aq", "1", // Spatial AQ for better visual quality
+				"-temporal_aq", "1", // Temporal AQ for motion optimization
+			)
+		}
+		// Note: P100 doesn't support temporal_aq and some advanced features for HEVC
+		// Keep only basic parameters for maximum compatibility
+		// With several GPUs the encoder runs on the one the frames were decoded on
+		if b.hwDevice != "" {
+			args = append(args, "-gpu", b.hwDevice)
+		}
+	case config.HWAccelQSV:
+		// ICQ without a bitrate, VBR with -b:v and -maxrate
+		args = append(args,
+			"-preset", "medium",
+			"-global_quality", strconv.Itoa(cq),
+		)
+	case config.HWAccelVAAPI:
+		// The driver picks CQP/ICQ without a bitrate, VBR/QVBR with one
+		args = append(args, "-global_quality", strconv.Itoa(cq))
+	case config.HWAccelVideoToolbox:
+		args = append(args, "-allow_sw", "0", "-realtime", "0")
+		// -q:v selects constant quality, which ignores the bitrate of rungs
+		if quality == domain.QualityOrigin {
+			args = append(args, "-q:v", strconv.Itoa(vtOriginQuality))
+		}
+	}
+
+	if codec == domain.VideoCodecH265 {
+		args = append(args, "-tag:v", "hvc1") // Apple compatibility
+	}
+	return args
+}
+
+// hwH264Profile returns the H.264 profile of the encoder settings, High if
+// unset, as the hardware encoder names it
+func (b *CommandBuilder) hwH264Profile(profile string) string {
+	switch {
+	case profile == "":
+		return "high"
+	case profile == "baseline" && b.hwaccel == config.HWAccelVAAPI:
+		return "constrained_baseline"
+	}
+	return profile
+}
+
+// DetectHWAccel checks that the hardware backend can encode H.264 and H.265
+// by encoding a few frames of a test pattern with each encoder
+func DetectHWAccel(ctx context.Context, ffmpegPath string, hwaccel config.HWAccel, device string) error {
+	if hwaccel == config.HWAccelNone {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var failed []string
+	for _, codec := range []domain.VideoCodec{domain.VideoCodecH264, domain.VideoCodecH265} {
+		encoder := hwEncoder(hwaccel, codec)
+		args := []string{"-hide_banner", "-v", "error"}
+		args = append(args, hwDeviceArgs(hwaccel, device)...)
+		args = append(args, "-f", "lavfi", "-i", "testsrc2=size=640x360:rate=25")
+		switch hwaccel {
+		case config.HWAccelVAAPI:
+			args = append(args, "-vf", "format=nv12,hwupload")
+		case config.HWAccelQSV:
+			args = append(args, "-pix_fmt", "nv12")
+		}
+		args = append(args, "-c:v", encoder, "-frames:v", "5", "-f", "null", "-")
+
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, ffmpegPath, args...)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			msg := strings.TrimSpace(stderr.String())
+			if i := strings.LastIndexByte(msg, '\n'); i >= 0 {
+				msg = msg[i+1:]
+			}
+			failed = append(failed, fmt.Sprintf("%s: %v: %s", encoder, err, msg))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s encoding unavailable: %s", hwaccel, strings.Join(failed, "; "))
+	}
+	return nil
+}
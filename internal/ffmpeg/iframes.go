@@ -0,0 +1,258 @@
+package ffmpeg
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// IFramePlaylist is the EXT-X-I-FRAMES-ONLY playlist of one HLS rendition
+type IFramePlaylist struct {
+	// URI of the playlist, relative to the media playlist
+	URI string
+	// Bandwidth is the peak bit rate of the I-frames, in bits per second
+	Bandwidth int
+}
+
+// iframeSegment is a segment of a media playlist and the byte range of its key frame
+type iframeSegment struct {
+	duration float64
+	uri      string
+	// section of the file holding the segment
+	section byteRange
+	length  int64
+}
+
+// byteRange is a section of a file; a negative length is the whole file
+type byteRange struct {
+	offset, length int64
+}
+
+// parseByteRange parses an EXT-X-BYTERANGE value, length[@offset]; without an
+// offset the range starts at next
+func parseByteRange(value string, next int64) (byteRange, error) {
+	lengthValue, offsetValue, hasOffset := strings.Cut(value, "@")
+	length, err := strconv.ParseInt(lengthValue, 10, 64)
+	if err != nil {
+		return byteRange{}, fmt.Errorf("invalid byte range %q", value)
+	}
+	r := byteRange{offset: next, length: length}
+	if hasOffset {
+		if r.offset, err = strconv.ParseInt(offsetValue, 10, 64); err != nil {
+			return byteRange{}, fmt.Errorf("invalid byte range %q", value)
+		}
+	}
+	return r, nil
+}
+
+// IFramePlaylistName returns the file name of the I-frame playlist of a rendition
+func IFramePlaylistName(name string) string {
+	return name + "_iframes.m3u8"
+}
+
+// GenerateIFramePlaylist writes the I-frame playlist of the media playlist at
+// playlistPath next to it. Segments are cut at key frames, so every segment
+// contributes its first key frame as a byte range from its start, which keeps
+// the PAT/PMT of transport streams and the moof of fMP4 fragments with it.
+// Segments that are byte ranges of a single file get ranges within them.
+// Segments encrypted as a whole cannot be addressed by byte range and are not
+// supported.
+func GenerateIFramePlaylist(ctx context.Context, ffprobePath, playlistPath string) (*IFramePlaylist, error) {
+	content, err := os.ReadFile(playlistPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read playlist: %w", err)
+	}
+
+	dir := filepath.Dir(playlistPath)
+	var (
+		header    []string
+		initURI   string
+		initRange = byteRange{length: -1}
+		segments  []iframeSegment
+		duration  float64
+		section   = byteRange{length: -1}
+		next      int64
+		version   = 4
+	)
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line == "#EXTM3U" || line == "#EXT-X-ENDLIST":
+		case strings.HasPrefix(line, "#EXT-X-VERSION:"):
+			if v, _ := strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-VERSION:")); v > version {
+				version = v
+			}
+		case strings.HasPrefix(line, "#EXT-X-MAP:"):
+			header = append(header, line)
+			initURI = tagURI(line)
+			if value := tagAttribute(line, "BYTERANGE"); value != "" {
+				if initRange, err = parseByteRange(value, 0); err != nil {
+					return nil, err
+				}
+			}
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			duration, _ = strconv.ParseFloat(value, 64)
+		case strings.HasPrefix(line, "#EXT-X-BYTERANGE:"):
+			if section, err = parseByteRange(strings.TrimPrefix(line, "#EXT-X-BYTERANGE:"), next); err != nil {
+				return nil, err
+			}
+			next = section.offset + section.length
+		case strings.HasPrefix(line, "#"):
+			if strings.HasPrefix(line, "#EXT-X-TARGETDURATION:") || strings.HasPrefix(line, "#EXT-X-PLAYLIST-TYPE:") {
+				header = append(header, line)
+			}
+		default:
+			segments = append(segments, iframeSegment{duration: duration, uri: line, section: section})
+			section = byteRange{length: -1}
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("playlist has no segments")
+	}
+
+	var initPath string
+	if initURI != "" {
+		initPath = filepath.Join(dir, initURI)
+	}
+
+	bandwidth := 0
+	for i := range segments {
+		length, err := keyFrameEnd(ctx, ffprobePath, initPath, initRange, filepath.Join(dir, segments[i].uri), segments[i].section)
+		if err != nil {
+			return nil, fmt.Errorf("segment %s: %w", segments[i].uri, err)
+		}
+		segments[i].length = length
+		if segments[i].duration > 0 {
+			bandwidth = max(bandwidth, int(math.Ceil(float64(length)*8/segments[i].duration)))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("#EXTM3U\n")
+	sb.WriteString(fmt.Sprintf("#EXT-X-VERSION:%d\n", version))
+	sb.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n")
+	for _, line := range header {
+		sb.WriteString(line + "\n")
+	}
+	sb.WriteString("#EXT-X-I-FRAMES-ONLY\n")
+	for _, s := range segments {
+		sb.WriteString(fmt.Sprintf("#EXTINF:%.6f,\n", s.duration))
+		sb.WriteString(fmt.Sprintf("#EXT-X-BYTERANGE:%d@%d\n", s.length, max(s.section.offset, 0)))
+		sb.WriteString(s.uri + "\n")
+	}
+	sb.WriteString("#EXT-X-ENDLIST\n")
+
+	name := IFramePlaylistName(strings.TrimSuffix(filepath.Base(playlistPath), filepath.Ext(playlistPath)))
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(sb.String()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write I-frame playlist: %w", err)
+	}
+	return &IFramePlaylist{URI: name, Bandwidth: bandwidth}, nil
+}
+
+// keyFrameEnd returns the offset in the segment at which its first key frame
+// ends: the position of the next video packet, or the segment size for a
+// segment holding a single frame. fMP4 segments are probed after their init
+// segment, whose size is subtracted from the positions.
+func keyFrameEnd(ctx context.Context, ffprobePath, initPath string, initRange byteRange, segmentPath string, section byteRange) (int64, error) {
+	segment, size, err := openSection(segmentPath, section)
+	if err != nil {
+		return 0, err
+	}
+	defer segment.Close()
+
+	var (
+		input  io.Reader = segment
+		offset int64
+	)
+	if initPath != "" {
+		initFile, initSize, err := openSection(initPath, initRange)
+		if err != nil {
+			return 0, err
+		}
+		defer initFile.Close()
+		input, offset = io.MultiReader(initFile, segment), initSize
+	}
+
+	cmd := exec.CommandContext(ctx, ffprobePath,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "packet=pos,flags",
+		"-of", "csv=p=0",
+		"pipe:0",
+	)
+	cmd.Stdin = input
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	keyFrame := false
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		posValue, flags, _ := strings.Cut(scanner.Text(), ",")
+		pos, err := strconv.ParseInt(posValue, 10, 64)
+		if err != nil {
+			continue
+		}
+		if keyFrame {
+			return pos - offset, nil
+		}
+		keyFrame = strings.HasPrefix(flags, "K")
+	}
+	if !keyFrame {
+		return 0, fmt.Errorf("segment has no key frame")
+	}
+	return size, nil
+}
+
+// sectionFile is a section of an open file
+type sectionFile struct {
+	*io.SectionReader
+	file *os.File
+}
+
+func (f sectionFile) Close() error { return f.file.Close() }
+
+// openSection opens the section r of the file at path and returns its size
+func openSection(path string, r byteRange) (io.ReadCloser, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	if r.length < 0 {
+		r = byteRange{length: info.Size()}
+	}
+	if r.offset+r.length > info.Size() {
+		file.Close()
+		return nil, 0, fmt.Errorf("byte range %d@%d is past the end of %s", r.length, r.offset, filepath.Base(path))
+	}
+	return sectionFile{io.NewSectionReader(file, r.offset, r.length), file}, r.length, nil
+}
+
+// tagURI returns the URI attribute of a playlist tag
+func tagURI(line string) string {
+	return tagAttribute(line, "URI")
+}
+
+// tagAttribute returns a quoted attribute of a playlist tag
+func tagAttribute(line, name string) string {
+	_, value, ok := strings.Cut(line, name+`="`)
+	if !ok {
+		return ""
+	}
+	value, _, _ = strings.Cut(value, `"`)
+	return value
+}
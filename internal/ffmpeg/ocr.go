@@ -0,0 +1,73 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// BitmapSubtitleExt returns the extension of the file a bitmap subtitle codec
+// is copied to for OCR: a raw .sup for Blu-ray PGS, Matroska for the rest,
+// which keeps the palette of VobSub
+func BitmapSubtitleExt(codec string) string {
+	if codec == "hdmv_pgs_subtitle" {
+		return ".sup"
+	}
+	return ".mks"
+}
+
+// BuildBitmapSubtitleExtractCommand copies a bitmap subtitle stream as is,
+// since ffmpeg cannot convert images to WebVTT. The output extension selects
+// the container, see BitmapSubtitleExt.
+func (b *CommandBuilder) BuildBitmapSubtitleExtractCommand(
+	inputPath string,
+	outputPath string,
+	streamIndex int,
+) *TranscodeCommand {
+	args := []string{"-y"}
+	args = append(args, b.trimArgs()...)
+	args = append(args,
+		"-i", inputPath,
+		"-map", fmt.Sprintf("0:%d", streamIndex),
+		"-c:s", "copy",
+		outputPath,
+	)
+
+	return &TranscodeCommand{
+		Args:       args,
+		OutputPath: outputPath,
+	}
+}
+
+// BuildSRTToVTTCommand converts the SRT an OCR tool writes to WebVTT. The SRT
+// is cut from the trimmed stream already, so no trim applies.
+func (b *CommandBuilder) BuildSRTToVTTCommand(inputPath, outputPath string) *TranscodeCommand {
+	return &TranscodeCommand{
+		Args:       []string{"-y", "-i", inputPath, "-c:s", "webvtt", outputPath},
+		OutputPath: outputPath,
+	}
+}
+
+// OCRSubtitle runs the OCR command on a bitmap subtitle file and expects SRT at
+// outputPath. The {input}, {output} and {language} placeholders in the
+// arguments are replaced with the paths and the ISO 639 language of the track.
+func OCRSubtitle(ctx context.Context, command []string, inputPath, outputPath, language string) error {
+	if len(command) == 0 {
+		return fmt.Errorf("no OCR command configured")
+	}
+	replacer := strings.NewReplacer("{input}", inputPath, "{output}", outputPath, "{language}", language)
+	args := make([]string, len(command)-1)
+	for i, arg := range command[1:] {
+		args[i] = replacer.Replace(arg)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -1,10 +1,14 @@
 package ffmpeg
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os/exec"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -43,7 +47,180 @@ func (p *Prober) Probe(ctx context.Context, inputPath string) (*domain.VideoMeta
 		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
 	}
 
-	return p.parseProbeOutput(&probeData)
+	meta, err := p.parseProbeOutput(&probeData)
+	if err != nil {
+		return nil, err
+	}
+	meta.Raw = output
+
+	// HDR10+ is carried per frame and transport streams carry static metadata
+	// in SEI, so the first frame is decoded for what the streams do not show
+	if meta.HDR != nil {
+		sideData := p.firstFrameSideData(ctx, inputPath)
+		applyHDRSideData(meta.HDR, sideData)
+		if meta.HDR.ColorTransfer == "smpte2084" && hasHDR10Plus(sideData) {
+			meta.HDR.HDR10Plus = true
+			if meta.HDR.Format == domain.HDRFormatHDR10 {
+				meta.HDR.Format = domain.HDRFormatHDR10Plus
+			}
+		}
+	}
+
+	return meta, nil
+}
+
+// firstFrameSideData returns the side data of the first video frame
+func (p *Prober) firstFrameSideData(ctx context.Context, inputPath string) []probeSideData {
+	args := []string{
+		"-v", "quiet",
+		"-print_format", "json",
+		"-select_streams", "v:0",
+		"-read_intervals", "%+#1",
+		"-show_frames",
+		"-show_entries", "frame=side_data_list",
+		inputPath,
+	}
+
+	output, err := exec.CommandContext(ctx, p.ffprobePath, args...).Output()
+	if err != nil {
+		return nil
+	}
+
+	var frames probeFrames
+	if err := json.Unmarshal(output, &frames); err != nil {
+		return nil
+	}
+
+	var sideData []probeSideData
+	for _, frame := range frames.Frames {
+		sideData = append(sideData, frame.SideDataList...)
+	}
+	return sideData
+}
+
+// hasHDR10Plus checks side data for SMPTE 2094-40 dynamic metadata
+func hasHDR10Plus(sideData []probeSideData) bool {
+	for _, sd := range sideData {
+		if strings.Contains(sd.SideDataType, "SMPTE2094-40") || strings.Contains(sd.SideDataType, "HDR10+") {
+			return true
+		}
+	}
+	return false
+}
+
+// DemuxDuration measures the duration of a source whose container does not
+// declare one by reading every video packet (audio if there is no video).
+// It reads the whole input, so it is only used when the probe found no duration.
+func (p *Prober) DemuxDuration(ctx context.Context, inputPath string) (time.Duration, error) {
+	var longest time.Duration
+	for _, streams := range []string{"v:0", "a:0"} {
+		d, err := p.demuxStream(ctx, inputPath, streams)
+		if err != nil {
+			return 0, err
+		}
+		if d > longest {
+			longest = d
+		}
+		if longest > 0 {
+			break
+		}
+	}
+	if longest <= 0 {
+		return 0, fmt.Errorf("no packets with timestamps in %s", inputPath)
+	}
+	return longest, nil
+}
+
+// demuxStream returns the end time of the last packet of a stream
+func (p *Prober) demuxStream(ctx context.Context, inputPath, stream string) (time.Duration, error) {
+	args := []string{
+		"-v", "error",
+		"-select_streams", stream,
+		"-show_entries", "packet=pts_time,duration_time",
+		"-of", "csv=p=0",
+		inputPath,
+	}
+
+	cmd := exec.CommandContext(ctx, p.ffprobePath, args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start ffprobe: %w", err)
+	}
+
+	var end float64
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ",")
+		pts, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 {
+			if d, err := strconv.ParseFloat(fields[1], 64); err == nil {
+				pts += d
+			}
+		}
+		if pts > end {
+			end = pts
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		return 0, fmt.Errorf("ffprobe demux failed: %w", err)
+	}
+
+	return time.Duration(end * float64(time.Second)), nil
+}
+
+// keyframeSample is the length of the source KeyframeInterval reads
+const keyframeSample = 60 * time.Second
+
+// KeyframeInterval returns the interval between the keyframes of the video
+// stream over the first minute, or 0 if they are not evenly spaced, as with
+// scene-cut keyframes, or there are fewer than three of them
+func (p *Prober) KeyframeInterval(ctx context.Context, inputPath string, metadata *domain.VideoMetadata) (time.Duration, error) {
+	args := []string{
+		"-v", "error",
+		"-select_streams", fmt.Sprintf("v:%d", metadata.VideoStreamIndex),
+		"-read_intervals", fmt.Sprintf("%%+%d", int(keyframeSample.Seconds())),
+		"-show_entries", "packet=pts_time,flags",
+		"-of", "csv=p=0",
+		inputPath,
+	}
+
+	output, err := exec.CommandContext(ctx, p.ffprobePath, args...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe keyframes failed: %w", err)
+	}
+
+	var keyframes []float64
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ",")
+		if len(fields) < 2 || !strings.Contains(fields[1], "K") {
+			continue
+		}
+		if pts, err := strconv.ParseFloat(fields[0], 64); err == nil {
+			keyframes = append(keyframes, pts)
+		}
+	}
+	if len(keyframes) < 3 {
+		return 0, nil
+	}
+	sort.Float64s(keyframes)
+
+	// Timestamps are rounded to the time base, so intervals may differ by
+	// less than half a frame
+	tolerance := 0.5 / math.Max(metadata.FPS, 1)
+	first := keyframes[1] - keyframes[0]
+	for i := 2; i < len(keyframes); i++ {
+		if math.Abs(keyframes[i]-keyframes[i-1]-first) > tolerance {
+			return 0, nil
+		}
+	}
+	interval := (keyframes[len(keyframes)-1] - keyframes[0]) / float64(len(keyframes)-1)
+	return time.Duration(interval * float64(time.Second)), nil
 }
 
 type probeOutput struct {
@@ -61,19 +238,56 @@ type probeFormat struct {
 }
 
 type probeStream struct {
-	Index          int               `json:"index"`
-	CodecName      string            `json:"codec_name"`
-	CodecLongName  string            `json:"codec_long_name"`
-	CodecType      string            `json:"codec_type"`
-	Width          int               `json:"width"`
-	Height         int               `json:"height"`
-	RFrameRate     string            `json:"r_frame_rate"`
-	AvgFrameRate   string            `json:"avg_frame_rate"`
-	BitRate        string            `json:"bit_rate"`
-	Channels       int               `json:"channels"`
-	SampleRate     string            `json:"sample_rate"`
-	Tags           map[string]string `json:"tags"`
-	Disposition    map[string]int    `json:"disposition"`
+	Index            int               `json:"index"`
+	CodecName        string            `json:"codec_name"`
+	CodecLongName    string            `json:"codec_long_name"`
+	CodecType        string            `json:"codec_type"`
+	Duration         string            `json:"duration"`
+	Width            int               `json:"width"`
+	Height           int               `json:"height"`
+	RFrameRate       string            `json:"r_frame_rate"`
+	AvgFrameRate     string            `json:"avg_frame_rate"`
+	BitRate          string            `json:"bit_rate"`
+	Channels         int               `json:"channels"`
+	SampleRate       string            `json:"sample_rate"`
+	Tags             map[string]string `json:"tags"`
+	Disposition      map[string]int    `json:"disposition"`
+	Level            int               `json:"level"`
+	PixFmt           string            `json:"pix_fmt"`
+	FieldOrder       string            `json:"field_order"`
+	BitsPerRawSample string            `json:"bits_per_raw_sample"`
+	ColorTransfer    string            `json:"color_transfer"`
+	ColorPrimaries   string            `json:"color_primaries"`
+	ColorSpace       string            `json:"color_space"`
+	SideDataList     []probeSideData   `json:"side_data_list"`
+}
+
+type probeSideData struct {
+	SideDataType              string `json:"side_data_type"`
+	DVProfile                 int    `json:"dv_profile"`
+	DVBLSignalCompatibilityID int    `json:"dv_bl_signal_compatibility_id"`
+	// Mastering display metadata, as rationals like "34000/50000"
+	RedX         string `json:"red_x"`
+	RedY         string `json:"red_y"`
+	GreenX       string `json:"green_x"`
+	GreenY       string `json:"green_y"`
+	BlueX        string `json:"blue_x"`
+	BlueY        string `json:"blue_y"`
+	WhitePointX  string `json:"white_point_x"`
+	WhitePointY  string `json:"white_point_y"`
+	MinLuminance string `json:"min_luminance"`
+	MaxLuminance string `json:"max_luminance"`
+	// Content light level metadata
+	MaxContent int `json:"max_content"`
+	MaxAverage int `json:"max_average"`
+	// Display matrix rotation, counterclockwise in degrees
+	Rotation int `json:"rotation"`
+}
+
+type probeFrames struct {
+	Frames []struct {
+		SideDataList []probeSideData `json:"side_data_list"`
+	} `json:"frames"`
 }
 
 func (p *Prober) parseProbeOutput(data *probeOutput) (*domain.VideoMetadata, error) {
@@ -90,24 +304,61 @@ func (p *Prober) parseProbeOutput(data *probeOutput) (*domain.VideoMetadata, err
 		meta.Bitrate = bitrate
 	}
 
+	// Some fragmented MP4s only report durations per stream
+	if meta.Duration <= 0 {
+		for _, stream := range data.Streams {
+			if d, err := strconv.ParseFloat(stream.Duration, 64); err == nil && d > 0 {
+				if sd := time.Duration(d * float64(time.Second)); sd > meta.Duration {
+					meta.Duration = sd
+				}
+			}
+		}
+	}
+	if meta.Duration < 0 {
+		meta.Duration = 0
+	}
+
 	meta.Container = normalizeContainer(data.Format.FormatName)
 
+	// HLS/DASH expose every variant as separate streams: the highest resolution video
+	// is transcoded and audio renditions repeated across variants are kept once
+	adaptive := domain.IsAdaptiveContainer(meta.Container)
+	videoIndex := 0
+
 	// Parse streams
 	for _, stream := range data.Streams {
 		switch stream.CodecType {
 		case "video":
-			if meta.VideoCodec == "" {
+			if meta.VideoCodec == "" || (adaptive && stream.Height > meta.Height) {
+				meta.VideoStreamIndex = videoIndex
 				meta.VideoCodec = stream.CodecName
 				meta.Width = stream.Width
 				meta.Height = stream.Height
 				meta.FPS = parseFrameRate(stream.RFrameRate)
+				meta.HDR = detectHDR(&stream)
+				meta.BitDepth = streamBitDepth(&stream)
+				meta.Level = max(stream.Level, 0)
+				meta.PixelFormat = stream.PixFmt
+				meta.FieldOrder = stream.FieldOrder
+				meta.AvgFPS = parseFrameRate(stream.AvgFrameRate)
+				meta.VariableFrameRate = isVariableFrameRate(meta)
+				meta.Rotation = streamRotation(&stream)
+				if meta.Rotation == 90 || meta.Rotation == 270 {
+					meta.Width, meta.Height = meta.Height, meta.Width
+				}
 			}
+			videoIndex++
 		case "audio":
+			if adaptive && hasAudioRendition(meta.AudioTracks, &stream) {
+				continue
+			}
 			audioTrack := domain.AudioTrackInfo{
 				Index:    stream.Index,
 				Codec:    stream.CodecName,
 				Language: getLanguage(stream.Tags),
 				Channels: stream.Channels,
+				// Audio description tracks are flagged visual_impaired (descriptions in newer ffmpeg)
+				Description: isDescription(&stream),
 			}
 			if sr, err := strconv.Atoi(stream.SampleRate); err == nil {
 				audioTrack.SampleRate = sr
@@ -125,6 +376,7 @@ func (p *Prober) parseProbeOutput(data *probeOutput) (*domain.VideoMetadata, err
 				Codec:    stream.CodecName,
 				Language: getLanguage(stream.Tags),
 				Title:    stream.Tags["title"],
+				Forced:   stream.Disposition["forced"] == 1,
 			}
 			meta.SubtitleTracks = append(meta.SubtitleTracks, subTrack)
 		}
@@ -133,6 +385,147 @@ func (p *Prober) parseProbeOutput(data *probeOutput) (*domain.VideoMetadata, err
 	return meta, nil
 }
 
+// isDescription reports whether the disposition of an audio stream marks it as audio description
+func isDescription(stream *probeStream) bool {
+	return stream.Disposition["visual_impaired"] == 1 || stream.Disposition["descriptions"] == 1
+}
+
+// hasAudioRendition checks whether an equivalent audio track was already collected
+func hasAudioRendition(tracks []domain.AudioTrackInfo, stream *probeStream) bool {
+	language := getLanguage(stream.Tags)
+	for _, t := range tracks {
+		if t.Codec == stream.CodecName && t.Language == language && t.Channels == stream.Channels &&
+			t.Description == isDescription(stream) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectHDR returns HDR info for PQ/HLG or Dolby Vision streams, nil for SDR
+func detectHDR(stream *probeStream) *domain.HDRInfo {
+	info := &domain.HDRInfo{
+		ColorTransfer:  stream.ColorTransfer,
+		ColorPrimaries: stream.ColorPrimaries,
+		ColorSpace:     stream.ColorSpace,
+		PixelFormat:    stream.PixFmt,
+	}
+
+	for _, sd := range stream.SideDataList {
+		if sd.SideDataType == "DOVI configuration record" {
+			info.DolbyVisionProfile = sd.DVProfile
+			info.DolbyVisionCompatibility = sd.DVBLSignalCompatibilityID
+		}
+	}
+	applyHDRSideData(info, stream.SideDataList)
+
+	switch {
+	case info.HasDolbyVision():
+		info.Format = domain.HDRFormatDolbyVision
+	case stream.ColorTransfer == "smpte2084":
+		info.Format = domain.HDRFormatHDR10
+	case stream.ColorTransfer == "arib-std-b67":
+		info.Format = domain.HDRFormatHLG
+	default:
+		return nil
+	}
+
+	return info
+}
+
+// pixFmtBitDepth matches the bit depth suffix of planar formats (yuv420p10le)
+// and of semi-planar ones (p010le)
+var pixFmtBitDepth = regexp.MustCompile(`p0?(\d{1,2})(le|be)$`)
+
+// streamBitDepth returns the bit depth of a video stream from its pixel
+// format, falling back to bits_per_raw_sample; 0 if neither is known
+func streamBitDepth(stream *probeStream) int {
+	if m := pixFmtBitDepth.FindStringSubmatch(stream.PixFmt); m != nil {
+		if depth, err := strconv.Atoi(m[1]); err == nil && depth >= 8 {
+			return depth
+		}
+	}
+	if depth, err := strconv.Atoi(stream.BitsPerRawSample); err == nil && depth > 0 {
+		return depth
+	}
+	if stream.PixFmt != "" {
+		return 8
+	}
+	return 0
+}
+
+// applyHDRSideData fills the static HDR metadata of info that is not set yet
+func applyHDRSideData(info *domain.HDRInfo, sideData []probeSideData) {
+	for _, sd := range sideData {
+		switch sd.SideDataType {
+		case "Mastering display metadata":
+			if info.MasteringDisplay != nil || sd.MaxLuminance == "" {
+				continue
+			}
+			info.MasteringDisplay = &domain.MasteringDisplay{
+				RedX:         parseRational(sd.RedX),
+				RedY:         parseRational(sd.RedY),
+				GreenX:       parseRational(sd.GreenX),
+				GreenY:       parseRational(sd.GreenY),
+				BlueX:        parseRational(sd.BlueX),
+				BlueY:        parseRational(sd.BlueY),
+				WhiteX:       parseRational(sd.WhitePointX),
+				WhiteY:       parseRational(sd.WhitePointY),
+				MinLuminance: parseRational(sd.MinLuminance),
+				MaxLuminance: parseRational(sd.MaxLuminance),
+			}
+		case "Content light level metadata":
+			if info.ContentLight == nil {
+				info.ContentLight = &domain.ContentLightLevel{MaxCLL: sd.MaxContent, MaxFALL: sd.MaxAverage}
+			}
+		}
+	}
+}
+
+// parseRational parses an ffprobe rational such as "34000/50000"
+func parseRational(value string) float64 {
+	if !strings.Contains(value, "/") {
+		f, _ := strconv.ParseFloat(value, 64)
+		return f
+	}
+	return parseFrameRate(value)
+}
+
+// vfrTolerance is the relative difference between the average and the stream
+// frame rate above which a source has a variable frame rate
+const vfrTolerance = 0.02
+
+// isVariableFrameRate compares the average frame rate with the stream frame
+// rate. Interlaced streams are excluded, as ffprobe often reports their field
+// rate as the stream frame rate.
+func isVariableFrameRate(meta *domain.VideoMetadata) bool {
+	if meta.FPS <= 0 || meta.AvgFPS <= 0 || meta.Interlaced() {
+		return false
+	}
+	return math.Abs(meta.AvgFPS-meta.FPS)/meta.FPS > vfrTolerance
+}
+
+// streamRotation returns the clockwise display rotation of a video stream.
+// Newer ffprobe reports the display matrix as side data, older ones the
+// rotate tag of MP4 and MOV.
+func streamRotation(stream *probeStream) int {
+	degrees := 0
+	if rotate, err := strconv.Atoi(stream.Tags["rotate"]); err == nil {
+		degrees = rotate
+	}
+	for _, sd := range stream.SideDataList {
+		if sd.SideDataType == "Display Matrix" {
+			degrees = -sd.Rotation
+		}
+	}
+	// Only quarter turns are applied; other angles are left as coded
+	degrees = (degrees%360 + 360) % 360
+	if degrees%90 != 0 {
+		return 0
+	}
+	return degrees
+}
+
 func parseFrameRate(rate string) float64 {
 	parts := strings.Split(rate, "/")
 	if len(parts) != 2 {
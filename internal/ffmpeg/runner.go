@@ -3,12 +3,14 @@ package ffmpeg
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -27,10 +29,21 @@ type Progress struct {
 // ProgressCallback is called with progress updates
 type ProgressCallback func(Progress)
 
+// ProgressThrottle controls how often progress callbacks are delivered.
+// FFmpeg emits a dozen key=value lines per stats period, so without throttling
+// every line results in a DB write and a heartbeat.
+type ProgressThrottle struct {
+	MinInterval time.Duration // minimum wall-clock time between callbacks
+	MinDelta    time.Duration // minimum advance of encoded media time between callbacks
+}
+
 // Runner executes FFmpeg commands
 type Runner struct {
 	ffmpegPath string
 	timeout    time.Duration
+	throttle   ProgressThrottle
+	slots      chan struct{}
+	pauser     *Pauser
 }
 
 // NewRunner creates a new runner
@@ -41,12 +54,41 @@ func NewRunner(ffmpegPath string, timeout time.Duration) *Runner {
 	}
 }
 
+// WithThrottle sets progress callback throttling for the runner
+func (r *Runner) WithThrottle(throttle ProgressThrottle) *Runner {
+	r.throttle = throttle
+	return r
+}
+
+// WithSlots limits concurrent FFmpeg processes using a semaphore shared between runners
+func (r *Runner) WithSlots(slots chan struct{}) *Runner {
+	r.slots = slots
+	return r
+}
+
+// WithPauser lets the pauser suspend processes started by the runner
+func (r *Runner) WithPauser(p *Pauser) *Runner {
+	r.pauser = p
+	return r
+}
+
 // Run executes an FFmpeg command with progress tracking
 func (r *Runner) Run(ctx context.Context, args []string, progressFn ProgressCallback) error {
-	ctx, cancel := context.WithTimeout(ctx, r.timeout)
-	defer cancel()
+	release, err := r.acquireSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	// The timeout is suspended with the process, see processTimer
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	timeout := newProcessTimer(r.timeout, func() { cancel(errProcessTimeout) })
+	defer timeout.stop()
 
 	cmd := exec.CommandContext(ctx, r.ffmpegPath, args...)
+	exited := terminateOnCancel(cmd)
+	defer exited()
 
 	// Get stdout for progress
 	stdout, err := cmd.StdoutPipe()
@@ -63,12 +105,16 @@ func (r *Runner) Run(ctx context.Context, args []string, progressFn ProgressCall
 	if err := cmd.Start(); err != nil {
 		return fmt.Errorf("failed to start ffmpeg: %w", err)
 	}
+	if r.pauser != nil {
+		defer r.pauser.track(cmd.Process, timeout)()
+	}
 
 	// Channel to track last progress update
 	progressChan := make(chan Progress, 1)
 	done := make(chan struct{})
 
 	// Read progress from stdout
+	throttled := r.throttled(progressFn)
 	go func() {
 		defer close(done)
 		scanner := bufio.NewScanner(stdout)
@@ -82,8 +128,8 @@ func (r *Runner) Run(ctx context.Context, args []string, progressFn ProgressCall
 				default:
 					// Channel full, skip
 				}
-				if progressFn != nil {
-					progressFn(progress)
+				if throttled != nil {
+					throttled(progress)
 				}
 			}
 		}
@@ -113,7 +159,9 @@ func (r *Runner) Run(ctx context.Context, args []string, progressFn ProgressCall
 
 	// Collect stderr
 	var stderrOutput strings.Builder
+	stderrDone := make(chan struct{})
 	go func() {
+		defer close(stderrDone)
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
 			stderrOutput.WriteString(scanner.Text())
@@ -121,20 +169,181 @@ func (r *Runner) Run(ctx context.Context, args []string, progressFn ProgressCall
 		}
 	}()
 
+	// Both pipes are read to EOF before Wait closes them, so stderr is complete
+	<-done
+	<-stderrDone
 	err = cmd.Wait()
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
+		if context.Cause(ctx) == errProcessTimeout {
 			return fmt.Errorf("ffmpeg timed out: %w", err)
 		}
 		if ctx.Err() == context.Canceled {
 			return fmt.Errorf("ffmpeg canceled: %w", err)
 		}
-		return fmt.Errorf("ffmpeg failed: %w\nstderr: %s", err, stderrOutput.String())
+		return &ProcessError{
+			Err:     err,
+			Command: r.ffmpegPath + " " + strings.Join(args, " "),
+			Stderr:  stderrOutput.String(),
+		}
 	}
 
 	return nil
 }
 
+// killGrace is how long a canceled ffmpeg may take to exit after SIGTERM
+// before its process group is killed
+const killGrace = 10 * time.Second
+
+// terminateOnCancel runs the command in its own process group and makes
+// canceling its context stop the whole group: SIGTERM, SIGCONT in case a
+// Pauser suspended it, then SIGKILL if it is still running after killGrace.
+// The returned func must be called once Wait has returned.
+func terminateOnCancel(cmd *exec.Cmd) func() {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	exited := make(chan struct{})
+	cmd.Cancel = func() error {
+		pgid := cmd.Process.Pid
+		if err := syscall.Kill(-pgid, syscall.SIGTERM); err != nil {
+			return cmd.Process.Kill()
+		}
+		syscall.Kill(-pgid, syscall.SIGCONT)
+		go func() {
+			select {
+			case <-exited:
+			case <-time.After(killGrace):
+				syscall.Kill(-pgid, syscall.SIGKILL)
+			}
+		}()
+		return nil
+	}
+	return func() { close(exited) }
+}
+
+// ProcessError is returned when ffmpeg exits with an error by itself, rather
+// than being timed out or canceled
+type ProcessError struct {
+	Err     error
+	Command string
+	Stderr  string
+}
+
+func (e *ProcessError) Error() string {
+	return fmt.Sprintf("ffmpeg failed: %v\ncommand: %s\nstderr: %s", e.Err, e.Command, e.Stderr)
+}
+
+func (e *ProcessError) Unwrap() error {
+	return e.Err
+}
+
+// encoderFailures are stderr messages of failures of the encoder or its rate
+// control, which other encoder settings may avoid
+var encoderFailures = []string{
+	"Error while opening encoder",
+	"Error initializing output stream",
+	"Error initializing the encoder",
+	"Error submitting video frame to the encoder",
+	"Error encoding a frame",
+	"Video encoding failed",
+	"Error during encoding",
+	"Incompatible pixel format",
+	"is invalid or not supported",
+	"x264 [error]",
+	"x265 [error]",
+	// NVENC
+	"InitializeEncoder failed",
+	"EncodePicture failed",
+	"Provided device doesn't support required NVENC features",
+	// VA-API
+	"Failed to end picture encode issue",
+	"Failed to upload encode parameters",
+}
+
+// inputFailures are stderr messages of a missing, truncated or corrupt input
+var inputFailures = []string{
+	"Invalid data found when processing input",
+	"moov atom not found",
+	"matches no streams",
+	"Error while decoding stream",
+	"No such file or directory",
+}
+
+// IsEncoderError reports whether ffmpeg failed in the encoder, e.g. on
+// settings the encoder or GPU rejected or a rate control failure, as opposed
+// to a missing or corrupt input, a full disk, a killed process, a timeout or a
+// cancellation
+func IsEncoderError(err error) bool {
+	var perr *ProcessError
+	if !errors.As(err, &perr) {
+		return false
+	}
+	if IsGPUError(err) {
+		return true
+	}
+	if IsInputError(err) {
+		return false
+	}
+	for _, msg := range encoderFailures {
+		if strings.Contains(perr.Stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInputError reports whether ffmpeg failed because its input is missing,
+// truncated or corrupt, which no retry or other settings can fix
+func IsInputError(err error) bool {
+	var perr *ProcessError
+	if !errors.As(err, &perr) {
+		return false
+	}
+	for _, msg := range inputFailures {
+		if strings.Contains(perr.Stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// gpuFailures are stderr messages of failures of the GPU, its driver or its
+// encode sessions rather than of the encoder settings or the input
+var gpuFailures = []string{
+	// NVIDIA
+	"OpenEncodeSessionEx failed",
+	"No NVENC capable devices found",
+	"No capable devices found",
+	"Driver does not support the required nvenc API version",
+	"The minimum required Nvidia driver",
+	"Cannot load libcuda",
+	"Cannot load libnvidia-encode",
+	"CUDA_ERROR_",
+	"cuInit(0) failed",
+	// Intel QSV and VA-API
+	"Error creating a MFX session",
+	"Error initializing an internal MFX session",
+	"Failed to initialise VAAPI connection",
+	"Failed to create a VAAPI device",
+	// Any backend
+	"Device creation failed",
+	"for option 'init_hw_device'",
+}
+
+// IsGPUError reports whether ffmpeg failed because the GPU, its driver or its
+// encode sessions failed (no free NVENC session, out of device memory, lost
+// device), which the CPU encoders do not depend on
+func IsGPUError(err error) bool {
+	var perr *ProcessError
+	if !errors.As(err, &perr) {
+		return false
+	}
+	for _, msg := range gpuFailures {
+		if strings.Contains(perr.Stderr, msg) {
+			return true
+		}
+	}
+	return false
+}
+
 // RunWithCancel executes an FFmpeg command with cancelation support
 func (r *Runner) RunWithCancel(ctx context.Context, args []string, progressFn ProgressCallback) (*exec.Cmd, error) {
 	cmd := exec.CommandContext(ctx, r.ffmpegPath, args...)
@@ -153,13 +362,14 @@ func (r *Runner) RunWithCancel(ctx context.Context, args []string, progressFn Pr
 	}
 
 	// Read progress from stdout
+	throttled := r.throttled(progressFn)
 	go func() {
 		scanner := bufio.NewScanner(stdout)
 		progress := Progress{}
 		for scanner.Scan() {
 			line := scanner.Text()
-			if updated := parseProgressLine(line, &progress); updated && progressFn != nil {
-				progressFn(progress)
+			if updated := parseProgressLine(line, &progress); updated && throttled != nil {
+				throttled(progress)
 			}
 		}
 	}()
@@ -193,6 +403,173 @@ func (r *Runner) Stop(cmd *exec.Cmd) error {
 	}
 }
 
+// Pauser suspends and resumes FFmpeg processes with SIGSTOP/SIGCONT.
+// Processes started while paused are suspended right away. A suspended process
+// keeps its FFmpeg slot, memory and GPU context, but stops using CPU/GPU time.
+type Pauser struct {
+	mu     sync.Mutex
+	paused bool
+	procs  map[*os.Process]*processTimer
+}
+
+// NewPauser creates a new pauser
+func NewPauser() *Pauser {
+	return &Pauser{procs: make(map[*os.Process]*processTimer)}
+}
+
+// Pause suspends all tracked processes
+func (p *Pauser) Pause() error {
+	return p.set(true, syscall.SIGSTOP)
+}
+
+// Resume continues all tracked processes
+func (p *Pauser) Resume() error {
+	return p.set(false, syscall.SIGCONT)
+}
+
+// Paused reports whether the pauser is paused
+func (p *Pauser) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+func (p *Pauser) set(paused bool, sig syscall.Signal) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.paused == paused {
+		return nil
+	}
+	p.paused = paused
+
+	var firstErr error
+	for proc, timeout := range p.procs {
+		if paused {
+			timeout.suspend()
+		} else {
+			timeout.resume()
+		}
+		if err := proc.Signal(sig); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to signal ffmpeg pid %d: %w", proc.Pid, err)
+		}
+	}
+	return firstErr
+}
+
+// track registers a running process with its timeout and returns a func that
+// unregisters it
+func (p *Pauser) track(proc *os.Process, timeout *processTimer) func() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.procs[proc] = timeout
+	if p.paused {
+		timeout.suspend()
+		proc.Signal(syscall.SIGSTOP)
+	}
+
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.procs, proc)
+	}
+}
+
+// errProcessTimeout cancels a process that ran longer than the runner timeout
+var errProcessTimeout = errors.New("ffmpeg process timeout")
+
+// processTimer is the timeout of a process. A Pauser suspends it along with
+// the process, so FFMPEG_PROCESS_TIMEOUT only counts the time it runs.
+type processTimer struct {
+	mu        sync.Mutex
+	timer     *time.Timer
+	remaining time.Duration
+	started   time.Time
+	suspended bool
+}
+
+// newProcessTimer calls expire after d unless stopped
+func newProcessTimer(d time.Duration, expire func()) *processTimer {
+	return &processTimer{timer: time.AfterFunc(d, expire), remaining: d, started: time.Now()}
+}
+
+// suspend stops the timer, keeping the time left
+func (t *processTimer) suspend() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.suspended || !t.timer.Stop() {
+		return
+	}
+	t.suspended = true
+	t.remaining -= time.Since(t.started)
+}
+
+// resume restarts a suspended timer with the time left
+func (t *processTimer) resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.suspended {
+		return
+	}
+	t.suspended = false
+	t.started = time.Now()
+	t.timer.Reset(t.remaining)
+}
+
+// stop stops the timer for good
+func (t *processTimer) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.suspended = false
+	t.timer.Stop()
+}
+
+// acquireSlot waits for a free FFmpeg slot if the runner is limited
+func (r *Runner) acquireSlot(ctx context.Context) (func(), error) {
+	if r.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case r.slots <- struct{}{}:
+		return func() { <-r.slots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for ffmpeg slot: %w", ctx.Err())
+	}
+}
+
+// throttled wraps progressFn so it is invoked at most once per MinInterval and
+// only when encoding advanced by MinDelta. The first and the final ("end")
+// updates are always delivered.
+func (r *Runner) throttled(progressFn ProgressCallback) ProgressCallback {
+	if progressFn == nil {
+		return nil
+	}
+	if r.throttle.MinInterval <= 0 && r.throttle.MinDelta <= 0 {
+		return progressFn
+	}
+
+	var (
+		delivered   bool
+		lastSent    time.Time
+		lastOutTime time.Duration
+	)
+	return func(p Progress) {
+		if delivered && p.Progress != "end" {
+			if r.throttle.MinInterval > 0 && time.Since(lastSent) < r.throttle.MinInterval {
+				return
+			}
+			if r.throttle.MinDelta > 0 && p.OutTime-lastOutTime < r.throttle.MinDelta {
+				return
+			}
+		}
+		delivered = true
+		lastSent = time.Now()
+		lastOutTime = p.OutTime
+		progressFn(p)
+	}
+}
+
 var (
 	frameRegex    = regexp.MustCompile(`frame=\s*(\d+)`)
 	fpsRegex      = regexp.MustCompile(`fps=\s*([\d.]+)`)
@@ -254,11 +631,20 @@ func parseProgressLine(line string, progress *Progress) bool {
 	return updated
 }
 
-// CalculateProgress calculates percentage progress
+// unknownDurationScale is the encoded media time at which progress of a
+// source with unknown duration reaches 50%
+const unknownDurationScale = 30 * time.Minute
+
+// CalculateProgress calculates percentage progress. Without a known total it
+// grows with the encoded time towards 99%, so progress keeps moving but never
+// claims completion.
 func CalculateProgress(current, total time.Duration) int {
-	if total == 0 {
+	if current <= 0 {
 		return 0
 	}
+	if total <= 0 {
+		return int(99 * float64(current) / float64(current+unknownDurationScale))
+	}
 	progress := int(float64(current) / float64(total) * 100)
 	if progress > 100 {
 		progress = 100
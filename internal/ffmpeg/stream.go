@@ -0,0 +1,68 @@
+package ffmpeg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// ErrStreamNotAllowed is returned for stream sources on hosts that are not
+// listed or not public
+var ErrStreamNotAllowed = errors.New("stream source not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), used by some
+// cluster networks
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// CheckStreamURL checks the manifest URL of an HLS/DASH source before ffmpeg
+// reads it. The host must match hosts when any are listed, and every address
+// it resolves to must be public, so a job cannot make the API or a worker
+// fetch cloud metadata or cluster services. An entry of hosts starting with a
+// dot matches the subdomains of the domain. Hosts that are not allowed give
+// ErrStreamNotAllowed.
+func CheckStreamURL(ctx context.Context, rawURL string, hosts []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return errors.New("source url must be an http(s) URL")
+	}
+	host := strings.ToLower(u.Hostname())
+	if len(hosts) > 0 && !streamHostAllowed(host, hosts) {
+		return fmt.Errorf("%w: host %s is not listed", ErrStreamNotAllowed, host)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve source host %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !publicAddr(addr.Unmap()) {
+			return fmt.Errorf("%w: host %s resolves to non-public address %s", ErrStreamNotAllowed, host, addr)
+		}
+	}
+	return nil
+}
+
+// streamHostAllowed reports whether host is listed in hosts
+func streamHostAllowed(host string, hosts []string) bool {
+	for _, entry := range hosts {
+		entry = strings.ToLower(entry)
+		if host == entry || (strings.HasPrefix(entry, ".") && strings.HasSuffix(host, entry)) {
+			return true
+		}
+	}
+	return false
+}
+
+// publicAddr reports whether addr is a global unicast address outside the
+// private, loopback, link-local and shared ranges
+func publicAddr(addr netip.Addr) bool {
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!addr.IsLoopback() &&
+		!addr.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(addr)
+}
@@ -0,0 +1,385 @@
+package ffmpeg
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// VTTNormalizeOptions configures NormalizeVTT
+type VTTNormalizeOptions struct {
+	// FallbackCharset decodes files that are not valid UTF-8, e.g. "windows-1251"
+	FallbackCharset string
+	// StripTags lists cue tags to remove, e.g. "b", "i", "font"; "*" removes all tags
+	StripTags []string
+}
+
+// VTTReport describes what NormalizeVTT changed
+type VTTReport struct {
+	Charset     string
+	Cues        int
+	DroppedCues int
+}
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+
+	// ASS override blocks such as {\an8} leak into WebVTT from SSA/ASS sources
+	assOverrideRegex = regexp.MustCompile(`\{\\[^}]*\}`)
+	cueTimingRegex   = regexp.MustCompile(`^((?:\d+:)?\d{2}:\d{2}\.\d{3})\s+-->\s+((?:\d+:)?\d{2}:\d{2}\.\d{3})(.*)$`)
+)
+
+// NormalizeVTT rewrites a WebVTT file as UTF-8 without BOM with LF line endings,
+// removes configured style tags and drops cues with broken timings or no text.
+// A file without any valid cue is left unchanged and reported with Cues == 0.
+func NormalizeVTT(path string, opts VTTNormalizeOptions) (*VTTReport, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read VTT file: %w", err)
+	}
+
+	text, charset, err := decodeSubtitle(raw, opts.FallbackCharset)
+	if err != nil {
+		return nil, err
+	}
+	report := &VTTReport{Charset: charset}
+
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = assOverrideRegex.ReplaceAllString(text, "")
+	if tags := stripTagsRegex(opts.StripTags); tags != nil {
+		text = tags.ReplaceAllString(text, "")
+	}
+
+	var blocks []string
+	for i, block := range strings.Split(text, "\n\n") {
+		block = strings.Trim(block, "\n")
+		if block == "" {
+			continue
+		}
+		if i == 0 && strings.HasPrefix(block, "WEBVTT") {
+			// The header is rewritten below
+			continue
+		}
+		if strings.HasPrefix(block, "NOTE") || strings.HasPrefix(block, "STYLE") || strings.HasPrefix(block, "REGION") {
+			blocks = append(blocks, block)
+			continue
+		}
+
+		cue, ok := normalizeCue(block)
+		if !ok {
+			report.DroppedCues++
+			continue
+		}
+		blocks = append(blocks, cue)
+		report.Cues++
+	}
+
+	if report.Cues == 0 {
+		return report, nil
+	}
+
+	out := "WEBVTT\n\n" + strings.Join(blocks, "\n\n") + "\n"
+	if err := os.WriteFile(path, []byte(out), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write VTT file: %w", err)
+	}
+	return report, nil
+}
+
+// decodeSubtitle converts raw subtitle bytes to a UTF-8 string. BOMs select the
+// encoding; without one, invalid UTF-8 is decoded with the fallback charset.
+func decodeSubtitle(raw []byte, fallback string) (string, string, error) {
+	var dec *encoding.Decoder
+	charset := "utf-8"
+
+	switch {
+	case bytes.HasPrefix(raw, utf8BOM):
+		raw = raw[len(utf8BOM):]
+	case bytes.HasPrefix(raw, utf16LEBOM):
+		dec, charset = unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder(), "utf-16le"
+	case bytes.HasPrefix(raw, utf16BEBOM):
+		dec, charset = unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder(), "utf-16be"
+	case !utf8.Valid(raw):
+		if fallback == "" {
+			return "", "", fmt.Errorf("subtitle is not valid UTF-8 and no fallback charset is configured")
+		}
+		enc, err := htmlindex.Get(fallback)
+		if err != nil {
+			return "", "", fmt.Errorf("unknown subtitle charset %q: %w", fallback, err)
+		}
+		dec, charset = enc.NewDecoder(), fallback
+	}
+
+	if dec == nil {
+		return string(raw), charset, nil
+	}
+	decoded, err := dec.Bytes(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode subtitle as %s: %w", charset, err)
+	}
+	return strings.TrimPrefix(string(decoded), "\uFEFF"), charset, nil
+}
+
+// stripTagsRegex builds a regex matching opening and closing forms of tags,
+// including classes and annotations such as <c.yellow> or <v Speaker>
+func stripTagsRegex(tags []string) *regexp.Regexp {
+	if len(tags) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag == "*" {
+			return regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+		}
+		names = append(names, regexp.QuoteMeta(tag))
+	}
+	return regexp.MustCompile(`(?i)</?(?:` + strings.Join(names, "|") + `)(?:[.\s][^>]*)?>`)
+}
+
+// normalizeCue validates a cue block: an optional identifier, a timing line with
+// start <= end and at least one non-empty text line
+func normalizeCue(block string) (string, bool) {
+	lines := strings.Split(block, "\n")
+	var id string
+	if !strings.Contains(lines[0], "-->") {
+		id, lines = lines[0], lines[1:]
+	}
+	if len(lines) == 0 {
+		return "", false
+	}
+
+	matches := cueTimingRegex.FindStringSubmatch(strings.TrimSpace(lines[0]))
+	if matches == nil {
+		return "", false
+	}
+	start, err := parseCueTimestamp(matches[1])
+	if err != nil {
+		return "", false
+	}
+	end, err := parseCueTimestamp(matches[2])
+	if err != nil || end < start {
+		return "", false
+	}
+
+	var text []string
+	for _, line := range lines[1:] {
+		if strings.Contains(line, "-->") {
+			return "", false
+		}
+		if strings.TrimSpace(line) != "" {
+			text = append(text, line)
+		}
+	}
+	if len(text) == 0 {
+		return "", false
+	}
+
+	cue := make([]string, 0, len(text)+2)
+	if id != "" {
+		cue = append(cue, id)
+	}
+	cue = append(cue, formatCueTimestamp(start)+" --> "+formatCueTimestamp(end)+matches[3])
+	cue = append(cue, text...)
+	return strings.Join(cue, "\n"), true
+}
+
+// parseCueTimestamp parses "HH:MM:SS.mmm" or "MM:SS.mmm"
+func parseCueTimestamp(ts string) (time.Duration, error) {
+	parts := strings.Split(ts, ":")
+	if len(parts) == 2 {
+		parts = append([]string{"0"}, parts...)
+	}
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", ts)
+	}
+	secParts := strings.SplitN(parts[2], ".", 2)
+	if len(secParts) != 2 {
+		return 0, fmt.Errorf("invalid timestamp %q", ts)
+	}
+
+	var values [4]int
+	for i, s := range []string{parts[0], parts[1], secParts[0], secParts[1]} {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q: %w", ts, err)
+		}
+		values[i] = v
+	}
+	if values[1] > 59 || values[2] > 59 {
+		return 0, fmt.Errorf("invalid timestamp %q", ts)
+	}
+
+	return time.Duration(values[0])*time.Hour +
+		time.Duration(values[1])*time.Minute +
+		time.Duration(values[2])*time.Second +
+		time.Duration(values[3])*time.Millisecond, nil
+}
+
+// formatCueTimestamp formats a duration as "HH:MM:SS.mmm"
+func formatCueTimestamp(d time.Duration) string {
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	d -= m * time.Minute
+	s := d / time.Second
+	d -= s * time.Second
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, d/time.Millisecond)
+}
+
+// SubtitlesDir is the directory of the subtitle renditions in the HLS output
+const SubtitlesDir = "subtitles"
+
+// SubtitlesGroupID is the EXT-X-MEDIA group of the subtitle renditions, shared by all tiers
+const SubtitlesGroupID = "subs"
+
+// SubtitleRendition is a WebVTT track signaled in the master playlist and DASH manifest
+type SubtitleRendition struct {
+	// Name of the .vtt and .m3u8 files in SubtitlesDir, e.g. "rus" or "rus.forced"
+	Name     string
+	Language string
+	Title    string
+	// Forced tracks only translate foreign dialogue and signs; players show them
+	// in the matching language without the viewer selecting subtitles
+	Forced bool
+}
+
+// displayName returns the NAME of the rendition: its title, or its language
+func (s SubtitleRendition) displayName() string {
+	name := s.Title
+	if name == "" {
+		name = s.Language
+	}
+	if name == "" || name == "und" {
+		name = s.Name
+	}
+	if s.Forced && s.Title == "" {
+		name += " (forced)"
+	}
+	return strings.ReplaceAll(name, `"`, "'")
+}
+
+// SubtitleSegmentName returns the file name of segment i of a subtitle rendition
+func SubtitleSegmentName(name string, i int) string {
+	return fmt.Sprintf("%s_%05d.vtt", name, i)
+}
+
+// SegmentVTT cuts a WebVTT track into segments of segmentDuration covering
+// duration, like the segments of the video, writes them to dir as
+// SubtitleSegmentName and returns their media playlist. Cues keep their
+// timings; a cue spanning a boundary is repeated in every segment it
+// overlaps, and cues past duration go to the last segment. STYLE and REGION
+// blocks lead every segment, NOTE blocks are dropped.
+func SegmentVTT(vtt []byte, dir, name string, duration, segmentDuration time.Duration) (string, error) {
+	if duration <= 0 || segmentDuration <= 0 {
+		return "", fmt.Errorf("subtitle segmentation needs a duration")
+	}
+
+	type cue struct {
+		block      string
+		start, end time.Duration
+	}
+	var (
+		header []string
+		cues   []cue
+	)
+	text := strings.ReplaceAll(string(vtt), "\r\n", "\n")
+	for i, block := range strings.Split(text, "\n\n") {
+		block = strings.Trim(block, "\n")
+		switch {
+		case block == "" || strings.HasPrefix(block, "NOTE"):
+		case i == 0 && strings.HasPrefix(block, "WEBVTT"):
+		case strings.HasPrefix(block, "STYLE") || strings.HasPrefix(block, "REGION"):
+			header = append(header, block)
+		default:
+			start, end, ok := cueTimes(block)
+			if ok {
+				cues = append(cues, cue{block: block, start: start, end: end})
+			}
+		}
+	}
+
+	count := int(math.Ceil(float64(duration) / float64(segmentDuration)))
+	var sb strings.Builder
+	sb.WriteString("#EXTM3U\n")
+	sb.WriteString("#EXT-X-VERSION:3\n")
+	sb.WriteString(fmt.Sprintf("#EXT-X-TARGETDURATION:%d\n", int(math.Ceil(segmentDuration.Seconds()))))
+	sb.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n")
+	sb.WriteString("#EXT-X-PLAYLIST-TYPE:VOD\n")
+	for i := 0; i < count; i++ {
+		from := time.Duration(i) * segmentDuration
+		to := min(from+segmentDuration, duration)
+
+		blocks := append([]string{"WEBVTT"}, header...)
+		for _, c := range cues {
+			overlaps := c.start < to && (c.end > from || c.start >= from)
+			if overlaps || (i == count-1 && c.start >= to) {
+				blocks = append(blocks, c.block)
+			}
+		}
+
+		file := SubtitleSegmentName(name, i)
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(strings.Join(blocks, "\n\n")+"\n"), 0644); err != nil {
+			return "", fmt.Errorf("failed to write subtitle segment: %w", err)
+		}
+		sb.WriteString(fmt.Sprintf("#EXTINF:%.3f,\n", (to - from).Seconds()))
+		sb.WriteString(file + "\n")
+	}
+	sb.WriteString("#EXT-X-ENDLIST\n")
+	return sb.String(), nil
+}
+
+// cueTimes returns the start and end of a cue block
+func cueTimes(block string) (time.Duration, time.Duration, bool) {
+	for _, line := range strings.Split(block, "\n") {
+		matches := cueTimingRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if matches == nil {
+			continue
+		}
+		start, err := parseCueTimestamp(matches[1])
+		if err != nil {
+			return 0, 0, false
+		}
+		end, err := parseCueTimestamp(matches[2])
+		if err != nil {
+			return 0, 0, false
+		}
+		return start, end, true
+	}
+	return 0, 0, false
+}
+
+// subtitleMediaTags returns the EXT-X-MEDIA subtitles group. Forced tracks are
+// signaled with FORCED=YES so players select them automatically.
+func subtitleMediaTags(subtitles []SubtitleRendition) string {
+	var sb strings.Builder
+	names := make(map[string]bool, len(subtitles))
+	for _, s := range subtitles {
+		// NAME must be unique within the group
+		name := s.displayName()
+		if names[name] {
+			name = s.Name
+		}
+		names[name] = true
+
+		forced := "NO"
+		if s.Forced {
+			forced = "YES"
+		}
+		sb.WriteString(fmt.Sprintf("#EXT-X-MEDIA:TYPE=SUBTITLES,GROUP-ID=\"%s\",NAME=\"%s\",%sDEFAULT=NO,AUTOSELECT=YES,FORCED=%s,URI=\"%s/%s.m3u8\"\n",
+			SubtitlesGroupID, name, languageAttr(s.Language), forced, SubtitlesDir, s.Name))
+	}
+	return sb.String()
+}
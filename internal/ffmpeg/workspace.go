@@ -25,6 +25,8 @@ type WorkspacePaths struct {
 	Subtitles  string
 	Thumbs     string
 	HLS        string
+	Mezzanine  string
+	Bumpers    string
 }
 
 // NewWorkspace creates a new workspace for a job
@@ -41,6 +43,8 @@ func NewWorkspace(root string, jobID uuid.UUID) *Workspace {
 			Subtitles:  filepath.Join(jobDir, "subtitles"),
 			Thumbs:     filepath.Join(jobDir, "thumbs"),
 			HLS:        filepath.Join(jobDir, "hls"),
+			Mezzanine:  filepath.Join(jobDir, "mezzanine"),
+			Bumpers:    filepath.Join(jobDir, "bumpers"),
 		},
 	}
 }
@@ -54,6 +58,8 @@ func (w *Workspace) Create() error {
 		w.paths.Subtitles,
 		w.paths.Thumbs,
 		w.paths.HLS,
+		w.paths.Mezzanine,
+		w.paths.Bumpers,
 	}
 
 	for _, dir := range dirs {
@@ -93,11 +99,22 @@ func (w *Workspace) TranscodedPath(quality string) string {
 	return filepath.Join(w.paths.Transcoded, quality+".mp4")
 }
 
+// BumperPath returns path for an intro or outro clip or one of its encodes
+func (w *Workspace) BumperPath(name string) string {
+	return filepath.Join(w.paths.Bumpers, name)
+}
+
 // SubtitlePath returns path for subtitle file
 func (w *Workspace) SubtitlePath(lang string) string {
 	return filepath.Join(w.paths.Subtitles, lang+".vtt")
 }
 
+// SubtitleOCRPath returns path for a bitmap subtitle track or its OCR output,
+// outside the uploaded subtitles directory
+func (w *Workspace) SubtitleOCRPath(filename string) string {
+	return filepath.Join(w.paths.Input, "ocr_"+filename)
+}
+
 // ThumbnailPath returns path for thumbnail
 func (w *Workspace) ThumbnailPath(index int) string {
 	return filepath.Join(w.paths.Thumbs, fmt.Sprintf("thumb_%05d.jpg", index))
@@ -118,6 +135,21 @@ func (w *Workspace) MetaPath(filename string) string {
 	return filepath.Join(w.paths.Meta, filename)
 }
 
+// UploadManifestPath returns path for the log of completed uploads
+func (w *Workspace) UploadManifestPath() string {
+	return filepath.Join(w.paths.Root, ".uploads.jsonl")
+}
+
+// TranscodeCheckpointPath returns path for the log of completed renditions
+func (w *Workspace) TranscodeCheckpointPath() string {
+	return filepath.Join(w.paths.Root, ".transcodes.jsonl")
+}
+
+// DiagnosticsPath returns path for the diagnostic bundle of a quarantined job
+func (w *Workspace) DiagnosticsPath() string {
+	return filepath.Join(w.paths.Root, "diagnostics")
+}
+
 // Exists checks if workspace exists
 func (w *Workspace) Exists() bool {
 	_, err := os.Stat(w.paths.Root)
@@ -146,13 +178,15 @@ func (w *Workspace) GetDiskUsage() (int64, error) {
 	return size, err
 }
 
-// CleanupOrphans removes workspaces older than maxAge that are not locked
-func CleanupOrphans(root string, maxAge time.Duration) error {
+// CleanupOrphans removes workspaces older than maxAge that are not locked and
+// returns how many were removed
+func CleanupOrphans(root string, maxAge time.Duration) (int, error) {
 	entries, err := os.ReadDir(root)
 	if err != nil {
-		return fmt.Errorf("failed to read workspace root: %w", err)
+		return 0, fmt.Errorf("failed to read workspace root: %w", err)
 	}
 
+	removed := 0
 	cutoff := time.Now().Add(-maxAge)
 
 	for _, entry := range entries {
@@ -183,8 +217,10 @@ func CleanupOrphans(root string, maxAge time.Duration) error {
 		}
 
 		// Remove orphan workspace
-		os.RemoveAll(dirPath)
+		if err := os.RemoveAll(dirPath); err == nil {
+			removed++
+		}
 	}
 
-	return nil
+	return removed, nil
 }
@@ -0,0 +1,319 @@
+package mediaconvert
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// JobSettings is the subset of MediaConvert job settings the backend uses
+type JobSettings struct {
+	Inputs       []Input       `json:"inputs"`
+	OutputGroups []OutputGroup `json:"outputGroups"`
+}
+
+// Input is the source of a job
+type Input struct {
+	FileInput      string                   `json:"fileInput"`
+	AudioSelectors map[string]AudioSelector `json:"audioSelectors,omitempty"`
+	VideoSelector  VideoSelector            `json:"videoSelector"`
+	TimecodeSource string                   `json:"timecodeSource"`
+	InputClippings []InputClipping          `json:"inputClippings,omitempty"`
+}
+
+// InputClipping selects a segment of the input by zero-based timecodes
+// (HH:MM:SS:FF, or HH:MM:SS;FF for drop-frame)
+type InputClipping struct {
+	StartTimecode string `json:"startTimecode,omitempty"`
+	EndTimecode   string `json:"endTimecode,omitempty"`
+}
+
+// VideoSelector configures the source video
+type VideoSelector struct {
+	// Rotate AUTO turns rotated sources upright like the ffmpeg encodes
+	Rotate string `json:"rotate,omitempty"`
+}
+
+// AudioSelector picks a source audio track
+type AudioSelector struct {
+	Tracks []int `json:"tracks"`
+}
+
+// OutputGroup writes its outputs as files under a destination
+type OutputGroup struct {
+	Name                string              `json:"name"`
+	OutputGroupSettings OutputGroupSettings `json:"outputGroupSettings"`
+	Outputs             []Output            `json:"outputs"`
+}
+
+// OutputGroupSettings of a file group
+type OutputGroupSettings struct {
+	Type              string            `json:"type"`
+	FileGroupSettings FileGroupSettings `json:"fileGroupSettings"`
+}
+
+// FileGroupSettings holds the destination prefix of a file group
+type FileGroupSettings struct {
+	Destination string `json:"destination"`
+}
+
+// Output is one MP4 rendition
+type Output struct {
+	NameModifier      string             `json:"nameModifier"`
+	ContainerSettings ContainerSettings  `json:"containerSettings"`
+	VideoDescription  VideoDescription   `json:"videoDescription"`
+	AudioDescriptions []AudioDescription `json:"audioDescriptions,omitempty"`
+}
+
+// ContainerSettings of an MP4 output
+type ContainerSettings struct {
+	Container   string      `json:"container"`
+	Mp4Settings Mp4Settings `json:"mp4Settings"`
+}
+
+// Mp4Settings places the moov atom up front, like -movflags +faststart
+type Mp4Settings struct {
+	MoovPlacement string `json:"moovPlacement"`
+}
+
+// VideoDescription of an output; zero size keeps the source resolution
+type VideoDescription struct {
+	Width         int           `json:"width,omitempty"`
+	Height        int           `json:"height,omitempty"`
+	Crop          *Rectangle    `json:"crop,omitempty"`
+	CodecSettings CodecSettings `json:"codecSettings"`
+}
+
+// Rectangle is an area of the input frame in pixels
+type Rectangle struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	X      int `json:"x"`
+	Y      int `json:"y"`
+}
+
+// CodecSettings of a video output
+type CodecSettings struct {
+	Codec        string         `json:"codec"`
+	H264Settings *VideoSettings `json:"h264Settings,omitempty"`
+	H265Settings *VideoSettings `json:"h265Settings,omitempty"`
+}
+
+// VideoSettings are the H.264/H.265 settings shared by both tiers. Scene change
+// detection is off so keyframes fall on GOP boundaries and SegmentHLS can cut
+// segments there, as with the ffmpeg encodes.
+type VideoSettings struct {
+	RateControlMode       string `json:"rateControlMode"`
+	Bitrate               int    `json:"bitrate"`
+	MaxBitrate            int    `json:"maxBitrate,omitempty"`
+	HrdBufferSize         int    `json:"hrdBufferSize,omitempty"`
+	GopSize               int    `json:"gopSize"`
+	GopSizeUnits          string `json:"gopSizeUnits"`
+	SceneChangeDetect     string `json:"sceneChangeDetect"`
+	CodecProfile          string `json:"codecProfile"`
+	CodecLevel            string `json:"codecLevel"`
+	WriteMp4PackagingType string `json:"writeMp4PackagingType,omitempty"`
+	// Frame rate of renditions capped by the profile; unset follows the source
+	FramerateControl             string `json:"framerateControl,omitempty"`
+	FramerateConversionAlgorithm string `json:"framerateConversionAlgorithm,omitempty"`
+	FramerateNumerator           int    `json:"framerateNumerator,omitempty"`
+	FramerateDenominator         int    `json:"framerateDenominator,omitempty"`
+}
+
+// AudioDescription encodes one selected audio track to AAC
+type AudioDescription struct {
+	AudioSourceName string             `json:"audioSourceName"`
+	CodecSettings   AudioCodecSettings `json:"codecSettings"`
+}
+
+// AudioCodecSettings of an AAC track
+type AudioCodecSettings struct {
+	Codec       string      `json:"codec"`
+	AacSettings AacSettings `json:"aacSettings"`
+}
+
+// AacSettings of an AAC track
+type AacSettings struct {
+	Bitrate    int    `json:"bitrate"`
+	CodingMode string `json:"codingMode"`
+	SampleRate int    `json:"sampleRate"`
+}
+
+// rendition is a (tier, quality) output of a job
+type rendition struct {
+	Tier    domain.EncodingTier
+	Quality domain.Quality
+}
+
+// nameModifier is appended to the destination to form the output file name
+func (r rendition) nameModifier() string {
+	return fmt.Sprintf("_%s_%s", r.Tier, r.Quality)
+}
+
+// buildSettings maps the renditions of a job onto one file output group. The
+// ladder mirrors ffmpeg's: quality bitrates, H.265 at the codec multiplier,
+// every source audio track as AAC.
+func buildSettings(fileInput, destination string, renditions []rendition, metadata *domain.VideoMetadata, profile domain.Profile) JobSettings {
+	input := Input{
+		FileInput:      fileInput,
+		VideoSelector:  VideoSelector{Rotate: "AUTO"},
+		TimecodeSource: "ZEROBASED",
+	}
+	if trim := profile.Trim; trim != nil && (trim.Start > 0 || trim.End > 0) {
+		var fps float64
+		if metadata != nil {
+			fps = metadata.FPS
+		}
+		clipping := InputClipping{}
+		if trim.Start > 0 {
+			clipping.StartTimecode = timecode(trim.Start, fps)
+		}
+		if trim.End > 0 {
+			clipping.EndTimecode = timecode(trim.End, fps)
+		}
+		input.InputClippings = []InputClipping{clipping}
+	}
+	var audio []string
+	if metadata != nil && len(metadata.AudioTracks) > 0 {
+		input.AudioSelectors = make(map[string]AudioSelector, len(metadata.AudioTracks))
+		for i := range metadata.AudioTracks {
+			name := fmt.Sprintf("Audio Selector %d", i+1)
+			input.AudioSelectors[name] = AudioSelector{Tracks: []int{i + 1}}
+			audio = append(audio, name)
+		}
+	}
+
+	outputs := make([]Output, 0, len(renditions))
+	for _, r := range renditions {
+		params := profile.QualityParams(r.Quality)
+		if r.Quality == domain.QualityOrigin {
+			params = domain.Quality1080p.Params()
+			params.Width, params.Height = 0, 0
+		}
+
+		// Frame rate conversion and cap of the profile, as in the ffmpeg encodes
+		divisor := 1
+		fps := profile.Algorithm.BaseFrameRate(metadata)
+		if metadata != nil {
+			height := params.Height
+			if r.Quality == domain.QualityOrigin {
+				height = metadata.Height
+			}
+			divisor = profile.Algorithm.FrameRateDivisor(height, fps)
+		}
+
+		codec := domain.GetTierConfig(r.Tier).VideoCodec
+		multiplier := codec.BitrateMultiplier()
+		video := &VideoSettings{
+			RateControlMode:   "VBR",
+			Bitrate:           int(float64(parseBitrate(params.VideoBitrate)) * multiplier),
+			MaxBitrate:        int(float64(parseBitrate(params.MaxBitrate)) * multiplier),
+			HrdBufferSize:     int(float64(parseBitrate(params.BufSize)) * multiplier),
+			GopSize:           profile.Algorithm.GOPSize(metadata, divisor),
+			GopSizeUnits:      "FRAMES",
+			SceneChangeDetect: "DISABLED",
+			CodecLevel:        "AUTO",
+		}
+		if divisor > 1 || profile.Algorithm.ConvertedFrameRate(metadata) > 0 {
+			video.FramerateControl = "SPECIFIED"
+			video.FramerateConversionAlgorithm = "DUPLICATE_DROP"
+			video.FramerateNumerator, video.FramerateDenominator = domain.FrameRate(fps, divisor)
+		}
+		codecSettings := CodecSettings{}
+		if codec == domain.VideoCodecH265 {
+			video.CodecProfile = "MAIN_MAIN"
+			video.WriteMp4PackagingType = "HVC1"
+			codecSettings.Codec = "H_265"
+			codecSettings.H265Settings = video
+		} else {
+			video.CodecProfile = "HIGH"
+			codecSettings.Codec = "H_264"
+			codecSettings.H264Settings = video
+		}
+
+		output := Output{
+			NameModifier: r.nameModifier(),
+			ContainerSettings: ContainerSettings{
+				Container:   "MP4",
+				Mp4Settings: Mp4Settings{MoovPlacement: "PROGRESSIVE_DOWNLOAD"},
+			},
+			VideoDescription: VideoDescription{
+				Width:         params.Width,
+				Height:        params.Height,
+				CodecSettings: codecSettings,
+			},
+		}
+		// The black bars DetectCrop found, as in the ffmpeg encodes
+		if metadata != nil && metadata.Crop != nil {
+			c := metadata.Crop
+			output.VideoDescription.Crop = &Rectangle{Width: c.Width, Height: c.Height, X: c.X, Y: c.Y}
+		}
+		for _, name := range audio {
+			output.AudioDescriptions = append(output.AudioDescriptions, AudioDescription{
+				AudioSourceName: name,
+				CodecSettings: AudioCodecSettings{
+					Codec: "AAC",
+					AacSettings: AacSettings{
+						Bitrate:    parseBitrate(params.AudioBitrate),
+						CodingMode: "CODING_MODE_2_0",
+						SampleRate: 48000,
+					},
+				},
+			})
+		}
+		outputs = append(outputs, output)
+	}
+
+	return JobSettings{
+		Inputs: []Input{input},
+		OutputGroups: []OutputGroup{{
+			Name: "File Group",
+			OutputGroupSettings: OutputGroupSettings{
+				Type:              "FILE_GROUP_SETTINGS",
+				FileGroupSettings: FileGroupSettings{Destination: destination},
+			},
+			Outputs: outputs,
+		}},
+	}
+}
+
+// timecode formats a position in seconds as a timecode of the source;
+// MediaConvert clips on frame boundaries. Frames are counted at the real rate:
+// NTSC rates are labelled with their nominal rate, and 29.97 and 59.94 use
+// drop-frame timecode (HH:MM:SS;FF) so the labels keep up with the clock.
+func timecode(seconds, fps float64) string {
+	if fps <= 0 {
+		fps = 25
+	}
+	num, den := domain.FrameRate(fps, 1)
+	rate := num
+	if den == 1001 {
+		rate = num / 1000
+	}
+	frames := int(math.Round(seconds * float64(num) / float64(den)))
+
+	sep := ":"
+	if drop := rate / 15; den == 1001 && rate%30 == 0 {
+		// Frame labels 0..drop-1 are skipped every minute except each tenth
+		perMinute := rate*60 - drop
+		perTenMinutes := rate*600 - 9*drop
+		tens, rest := frames/perTenMinutes, frames%perTenMinutes
+		frames += 9 * drop * tens
+		if rest > drop {
+			frames += drop * ((rest - drop) / perMinute)
+		}
+		sep = ";"
+	}
+	total := frames / rate
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d", total/3600, total/60%60, total%60, sep, frames%rate)
+}
+
+// parseBitrate converts "1500k" to bits per second
+func parseBitrate(bitrate string) int {
+	bitrate = strings.TrimSuffix(strings.TrimSuffix(bitrate, "k"), "K")
+	var value int
+	fmt.Sscanf(bitrate, "%d", &value)
+	return value * 1000
+}
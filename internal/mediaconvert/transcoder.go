@@ -0,0 +1,336 @@
+package mediaconvert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/config"
+	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/ffmpeg"
+	"github.com/tvoe/converter/internal/storage/s3"
+	"github.com/tvoe/converter/internal/temporal/activities"
+)
+
+// Name is the name the backend is registered under
+const Name = "mediaconvert"
+
+func init() {
+	activities.RegisterTranscoder(Name, func(cfg *config.Config, logger *zap.Logger) (activities.Transcoder, error) {
+		return New(cfg.Burst, logger)
+	})
+}
+
+// errMezzanine is returned for jobs whose profile asks for a mezzanine master
+var errMezzanine = errors.New("mediaconvert backend does not encode the mezzanine, use the ffmpeg transcoder")
+
+// Transcoder encodes renditions with MediaConvert. Local sources are copied to
+// MEDIACONVERT_BUCKET, outputs are written there and downloaded into the job
+// workspace under the paths the ffmpeg backend uses, so the later stages and the
+// transcode checkpoint work unchanged.
+type Transcoder struct {
+	cfg     config.BurstConfig
+	client  *Client
+	storage *s3.Client
+	logger  *zap.Logger
+}
+
+// New creates the MediaConvert backend
+func New(cfg config.BurstConfig, logger *zap.Logger) (*Transcoder, error) {
+	if cfg.MediaConvertEndpoint == "" || cfg.MediaConvertRoleARN == "" || cfg.MediaConvertBucket == "" {
+		return nil, fmt.Errorf("MEDIACONVERT_ENDPOINT, MEDIACONVERT_ROLE_ARN and MEDIACONVERT_BUCKET are required")
+	}
+	if cfg.MediaConvertPollInterval <= 0 {
+		return nil, fmt.Errorf("MEDIACONVERT_POLL_INTERVAL must be positive")
+	}
+
+	storage, err := s3.New(config.S3Config{
+		Endpoint:     fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.MediaConvertRegion),
+		Region:       cfg.MediaConvertRegion,
+		AccessKey:    cfg.MediaConvertAccessKey,
+		SecretKey:    cfg.MediaConvertSecretKey,
+		BucketOutput: cfg.MediaConvertBucket,
+		UseSSL:       true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mediaconvert bucket client: %w", err)
+	}
+
+	return &Transcoder{
+		cfg:     cfg,
+		client:  NewClient(cfg.MediaConvertEndpoint, cfg.MediaConvertRegion, cfg.MediaConvertAccessKey, cfg.MediaConvertSecretKey),
+		storage: storage,
+		logger:  logger.With(zap.String("transcoder", Name)),
+	}, nil
+}
+
+// Transcode encodes every (tier, quality) rendition in one MediaConvert job
+func (t *Transcoder) Transcode(ctx context.Context, req *activities.TranscodeRequest) (*activities.TranscodeOutput, error) {
+	if req.Job.Profile.Mezzanine != nil {
+		return nil, &activities.TranscodeError{Code: domain.ErrCodeTranscoderUnavailable, Err: errMezzanine}
+	}
+
+	var renditions []rendition
+	for _, tier := range req.Tiers {
+		for _, quality := range req.Qualities {
+			renditions = append(renditions, rendition{Tier: tier, Quality: quality})
+		}
+	}
+
+	paths, err := t.encode(ctx, req.Job, req.Metadata, req.Workspace, req.InputPath, "all", renditions, req.OnProgress)
+	if err != nil {
+		return nil, err
+	}
+
+	tierOutputPaths := make(map[domain.EncodingTier]map[domain.Quality]string)
+	for r, p := range paths {
+		if tierOutputPaths[r.Tier] == nil {
+			tierOutputPaths[r.Tier] = make(map[domain.Quality]string)
+		}
+		tierOutputPaths[r.Tier][r.Quality] = p
+	}
+	// Legacy tier paths are the main output, as with ffmpeg
+	outputPaths := tierOutputPaths[domain.TierLegacy]
+	if len(outputPaths) == 0 {
+		outputPaths = tierOutputPaths[domain.TierModern]
+	}
+
+	return &activities.TranscodeOutput{
+		OutputPaths:     outputPaths,
+		TierOutputPaths: tierOutputPaths,
+		EnabledTiers:    req.Tiers,
+	}, nil
+}
+
+// TranscodeRendition encodes one rendition in its own MediaConvert job
+func (t *Transcoder) TranscodeRendition(ctx context.Context, req *activities.RenditionRequest) (string, error) {
+	if req.Mezzanine {
+		return "", &activities.TranscodeError{Code: domain.ErrCodeTranscoderUnavailable, Err: errMezzanine}
+	}
+
+	r := rendition{Tier: req.Tier, Quality: req.Quality}
+	paths, err := t.encode(ctx, req.Job, req.Metadata, req.Workspace, req.InputPath,
+		fmt.Sprintf("%s_%s", req.Tier, req.Quality), []rendition{r}, req.OnProgress)
+	if err != nil {
+		return "", err
+	}
+	return paths[r], nil
+}
+
+// encode runs the renditions missing from the checkpoint as one MediaConvert job.
+// The job ID is kept in the workspace under runKey, so a retried activity waits
+// for the job it submitted before instead of paying for a second one.
+func (t *Transcoder) encode(
+	ctx context.Context,
+	job *domain.Job,
+	metadata *domain.VideoMetadata,
+	workspace *ffmpeg.Workspace,
+	inputPath string,
+	runKey string,
+	renditions []rendition,
+	onProgress func(percent int),
+) (map[rendition]string, error) {
+	logger := t.logger.With(zap.String("jobId", job.ID.String()), zap.String("run", runKey))
+
+	checkpoint, err := ffmpeg.LoadTranscodeCheckpoint(workspace.TranscodeCheckpointPath())
+	if err != nil {
+		logger.Warn("failed to load transcode checkpoint, encoding all renditions", zap.Error(err))
+	}
+
+	paths := make(map[rendition]string, len(renditions))
+	var remaining []rendition
+	for _, r := range renditions {
+		if p, ok := checkpoint.Lookup(ffmpeg.RenditionKey(string(r.Tier), string(r.Quality))); ok {
+			paths[r] = p
+			continue
+		}
+		remaining = append(remaining, r)
+	}
+	if len(remaining) == 0 {
+		onProgress(100)
+		return paths, nil
+	}
+
+	prefix := path.Join(job.ID.String(), runKey)
+	statePath := filepath.Join(workspace.Paths().Root, ".mediaconvert-"+runKey)
+
+	jobID, err := t.resume(ctx, statePath, logger)
+	if err != nil {
+		return nil, err
+	}
+	if jobID == "" {
+		fileInput, err := t.stageSource(ctx, job, inputPath, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		created, err := t.client.CreateJob(ctx, &CreateJobRequest{
+			Role:                 t.cfg.MediaConvertRoleARN,
+			Queue:                t.cfg.MediaConvertQueue,
+			Settings:             buildSettings(fileInput, t.bucketURL(prefix+"/out"), remaining, metadata, job.Profile),
+			StatusUpdateInterval: "SECONDS_10",
+			UserMetadata:         map[string]string{"jobId": job.ID.String()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		jobID = created.ID
+		if err := os.WriteFile(statePath, []byte(jobID), 0644); err != nil {
+			logger.Warn("failed to save mediaconvert job id", zap.Error(err))
+		}
+		logger.Info("mediaconvert job submitted",
+			zap.String("mediaconvertJobId", jobID),
+			zap.Int("renditions", len(remaining)))
+	}
+
+	result, err := t.wait(ctx, jobID, onProgress, logger)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status != StatusComplete {
+		os.Remove(statePath)
+		t.deleteOutputs(prefix, logger)
+		return nil, &activities.TranscodeError{
+			Code: domain.ErrCodeCloudTranscodeFailed,
+			Err:  fmt.Errorf("mediaconvert job %s %s: %d %s", jobID, strings.ToLower(result.Status), result.ErrorCode, result.ErrorMessage),
+		}
+	}
+
+	for _, r := range remaining {
+		tierDir := filepath.Join(workspace.Paths().Transcoded, string(r.Tier))
+		if err := os.MkdirAll(tierDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create tier directory: %w", err)
+		}
+		outputPath := filepath.Join(tierDir, string(r.Quality)+".mp4")
+		key := prefix + "/out" + r.nameModifier() + ".mp4"
+		if err := t.storage.Download(ctx, t.cfg.MediaConvertBucket, key, outputPath); err != nil {
+			return nil, fmt.Errorf("failed to download rendition %s: %w", key, err)
+		}
+		if err := ffmpeg.ValidateOutput(outputPath); err != nil {
+			return nil, &activities.TranscodeError{Code: domain.ErrCodeCloudTranscodeFailed, Err: err}
+		}
+		if err := checkpoint.Record(ffmpeg.RenditionKey(string(r.Tier), string(r.Quality)), outputPath); err != nil {
+			logger.Warn("failed to record transcode checkpoint", zap.Error(err))
+		}
+		paths[r] = outputPath
+	}
+
+	os.Remove(statePath)
+	t.deleteOutputs(prefix, logger)
+	logger.Info("mediaconvert renditions downloaded", zap.Int("renditions", len(remaining)))
+	return paths, nil
+}
+
+// resume returns the MediaConvert job submitted by a previous attempt, or "" to
+// submit a new one. Canceled jobs, e.g. by a worker shutdown, are submitted again.
+func (t *Transcoder) resume(ctx context.Context, statePath string, logger *zap.Logger) (string, error) {
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read mediaconvert job id: %w", err)
+	}
+
+	jobID := strings.TrimSpace(string(data))
+	job, err := t.client.GetJob(ctx, jobID)
+	if err != nil {
+		return "", err
+	}
+	if job.Status == StatusCanceled {
+		os.Remove(statePath)
+		return "", nil
+	}
+	logger.Info("resuming mediaconvert job", zap.String("mediaconvertJobId", jobID), zap.String("status", job.Status))
+	return jobID, nil
+}
+
+// stageSource returns the MediaConvert input of the job: HTTP sources as is,
+// local files copied to the bucket once per job
+func (t *Transcoder) stageSource(ctx context.Context, job *domain.Job, inputPath string, logger *zap.Logger) (string, error) {
+	if strings.HasPrefix(inputPath, "http://") || strings.HasPrefix(inputPath, "https://") {
+		return inputPath, nil
+	}
+
+	info, err := os.Stat(inputPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat source: %w", err)
+	}
+	key := path.Join(job.ID.String(), "source"+filepath.Ext(inputPath))
+	if obj, err := t.storage.Head(ctx, t.cfg.MediaConvertBucket, key); err == nil && obj.Size == info.Size() {
+		return t.bucketURL(key), nil
+	}
+
+	start := time.Now()
+	if _, err := t.storage.Upload(ctx, t.cfg.MediaConvertBucket, key, inputPath); err != nil {
+		return "", fmt.Errorf("failed to copy source to mediaconvert bucket: %w", err)
+	}
+	logger.Info("source copied to mediaconvert bucket",
+		zap.String("key", key),
+		zap.Int64("bytes", info.Size()),
+		zap.Duration("duration", time.Since(start)))
+	return t.bucketURL(key), nil
+}
+
+// wait polls the job until it finishes, reporting its progress. If the activity
+// is canceled the MediaConvert job is canceled too.
+func (t *Transcoder) wait(ctx context.Context, jobID string, onProgress func(percent int), logger *zap.Logger) (*Job, error) {
+	ticker := time.NewTicker(t.cfg.MediaConvertPollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := t.client.GetJob(ctx, jobID)
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+		if job != nil {
+			switch job.Status {
+			case StatusComplete, StatusError, StatusCanceled:
+				return job, nil
+			}
+			onProgress(job.JobPercentComplete)
+		}
+
+		select {
+		case <-ctx.Done():
+			cancelCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := t.client.CancelJob(cancelCtx, jobID); err != nil {
+				logger.Warn("failed to cancel mediaconvert job", zap.String("mediaconvertJobId", jobID), zap.Error(err))
+			}
+			cancel()
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// deleteOutputs removes the downloaded outputs of a run from the bucket. Source
+// copies are shared by the runs of a job and left to the bucket lifecycle rule.
+func (t *Transcoder) deleteOutputs(prefix string, logger *zap.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	objects, err := t.storage.ListObjects(ctx, t.cfg.MediaConvertBucket, prefix+"/")
+	if err != nil {
+		logger.Warn("failed to list mediaconvert outputs", zap.Error(err))
+		return
+	}
+	keys := make([]string, len(objects))
+	for i, obj := range objects {
+		keys[i] = obj.Key
+	}
+	if _, err := t.storage.DeleteMany(ctx, t.cfg.MediaConvertBucket, keys); err != nil {
+		logger.Warn("failed to delete mediaconvert outputs", zap.Error(err))
+	}
+}
+
+func (t *Transcoder) bucketURL(key string) string {
+	return fmt.Sprintf("s3://%s/%s", t.cfg.MediaConvertBucket, key)
+}
@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"strconv"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -9,9 +11,16 @@ import (
 type Metrics struct {
 	jobsTotal           *prometheus.CounterVec
 	jobsActive          prometheus.Gauge
+	jobDuration         *prometheus.HistogramVec
 	stageDuration       *prometheus.HistogramVec
 	stageFailures       *prometheus.CounterVec
 	ffmpegProcesses     prometheus.Gauge
+	transcodesTotal     *prometheus.CounterVec
+	degradedEncodes     prometheus.Counter
+	gpuFallbacks        prometheus.Counter
+	gpuTranscodes       *prometheus.GaugeVec
+	admissionWaits      prometheus.Counter
+	admissionClosed     prometheus.Gauge
 	uploadBytesTotal    prometheus.Counter
 	uploadDuration      prometheus.Histogram
 	diskFreeBytes       prometheus.Gauge
@@ -34,6 +43,14 @@ func New() *Metrics {
 				Help: "Number of currently active conversion jobs",
 			},
 		),
+		jobDuration: promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "converter_job_duration_seconds",
+				Help:    "End-to-end job duration from creation to finish by outcome, source resolution and encoded tiers",
+				Buckets: []float64{60, 300, 600, 900, 1200, 1800, 2700, 3600, 5400, 7200, 10800, 14400, 21600, 43200},
+			},
+			[]string{"outcome", "resolution", "tiers"},
+		),
 		stageDuration: promauto.NewHistogramVec(
 			prometheus.HistogramOpts{
 				Name:    "converter_stage_duration_seconds",
@@ -55,6 +72,44 @@ func New() *Metrics {
 				Help: "Number of currently running FFmpeg processes",
 			},
 		),
+		transcodesTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "converter_transcodes_total",
+				Help: "Total number of transcode activities by backend and whether they were offloaded",
+			},
+			[]string{"transcoder", "offloaded"},
+		),
+		degradedEncodes: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Name: "converter_degraded_encodes_total",
+				Help: "Total number of renditions encoded with safe settings after repeated encoder failures",
+			},
+		),
+		gpuFallbacks: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Name: "converter_gpu_fallbacks_total",
+				Help: "Total number of renditions encoded on the CPU after a GPU driver or session failure",
+			},
+		),
+		gpuTranscodes: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "converter_gpu_transcodes_active",
+				Help: "Number of transcodes running on each GPU of the worker",
+			},
+			[]string{"device"},
+		),
+		admissionWaits: promauto.NewCounter(
+			prometheus.CounterOpts{
+				Name: "converter_admission_waits_total",
+				Help: "Total number of transcode queue polls held by disk or GPU memory pressure",
+			},
+		),
+		admissionClosed: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "converter_admission_closed",
+				Help: "1 while the worker holds new local transcodes because of disk or GPU memory pressure",
+			},
+		),
 		uploadBytesTotal: promauto.NewCounter(
 			prometheus.CounterOpts{
 				Name: "converter_upload_bytes_total",
@@ -105,6 +160,11 @@ func (m *Metrics) SetJobsActive(count float64) {
 	m.jobsActive.Set(count)
 }
 
+// RecordJobDuration records the end-to-end duration of a finished job
+func (m *Metrics) RecordJobDuration(outcome, resolution, tiers string, seconds float64) {
+	m.jobDuration.WithLabelValues(outcome, resolution, tiers).Observe(seconds)
+}
+
 // RecordStageDuration records the duration of a stage
 func (m *Metrics) RecordStageDuration(stage string, seconds float64) {
 	m.stageDuration.WithLabelValues(stage).Observe(seconds)
@@ -125,6 +185,44 @@ func (m *Metrics) DecrementFFmpegProcesses() {
 	m.ffmpegProcesses.Dec()
 }
 
+// IncrementTranscodes counts a transcode started on a backend
+func (m *Metrics) IncrementTranscodes(transcoder string, offloaded bool) {
+	m.transcodesTotal.WithLabelValues(transcoder, strconv.FormatBool(offloaded)).Inc()
+}
+
+// IncrementDegradedEncodes counts a rendition encoded with safe settings
+func (m *Metrics) IncrementDegradedEncodes() {
+	m.degradedEncodes.Inc()
+}
+
+// IncrementGPUFallbacks counts a rendition encoded on the CPU after a GPU failure
+func (m *Metrics) IncrementGPUFallbacks() {
+	m.gpuFallbacks.Inc()
+}
+
+// SetGPUTranscodes sets the number of transcodes running on a GPU; the empty
+// device is the default one of the hardware backend
+func (m *Metrics) SetGPUTranscodes(device string, n int) {
+	if device == "" {
+		device = "default"
+	}
+	m.gpuTranscodes.WithLabelValues(device).Set(float64(n))
+}
+
+// IncrementAdmissionWaits counts a transcode queue poll held by admission
+func (m *Metrics) IncrementAdmissionWaits() {
+	m.admissionWaits.Inc()
+}
+
+// SetAdmissionClosed sets whether the worker holds new transcodes
+func (m *Metrics) SetAdmissionClosed(closed bool) {
+	if closed {
+		m.admissionClosed.Set(1)
+	} else {
+		m.admissionClosed.Set(0)
+	}
+}
+
 // SetFFmpegProcesses sets the FFmpeg processes gauge
 func (m *Metrics) SetFFmpegProcesses(count float64) {
 	m.ffmpegProcesses.Set(count)
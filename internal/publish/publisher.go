@@ -0,0 +1,193 @@
+// Package publish makes the output of one job the live version of its video.
+//
+// Every video may be converted by several jobs. Publishing appends a version to
+// the video's history in published_versions and rewrites the stable pointer
+// objects under "{videoId}/current/": a copy of the job's HLS master playlist
+// whose URIs point at the job's output, and version.json naming the live job.
+// Players use "{videoId}/current/master.m3u8" and never see a job ID.
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/db"
+	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/storage/s3"
+)
+
+// pointerCacheControl keeps CDNs from serving a stale live version for long
+const pointerCacheControl = "max-age=10"
+
+// maxMasterSize bounds the master playlist copied to the pointer
+const maxMasterSize = 1 << 20
+
+var (
+	// ErrNotPublishable is returned for jobs whose output cannot go live
+	ErrNotPublishable = errors.New("job is not publishable")
+	// ErrNoPreviousVersion is returned when there is nothing to roll back to
+	ErrNoPreviousVersion = errors.New("no previous version to roll back to")
+)
+
+// Pointer is the content of "{videoId}/current/version.json"
+type Pointer struct {
+	VideoID     uuid.UUID `json:"videoId"`
+	Version     int       `json:"version"`
+	JobID       uuid.UUID `json:"jobId"`
+	Prefix      string    `json:"prefix"`
+	Master      string    `json:"master,omitempty"`
+	PublishedAt time.Time `json:"publishedAt"`
+}
+
+// Publisher publishes job outputs and maintains the pointer objects
+type Publisher struct {
+	jobRepo      *db.JobRepository
+	artifactRepo *db.ArtifactRepository
+	versionRepo  *db.VersionRepository
+	s3Client     *s3.Client
+	logger       *zap.Logger
+}
+
+// New creates a new publisher
+func New(
+	jobRepo *db.JobRepository,
+	artifactRepo *db.ArtifactRepository,
+	versionRepo *db.VersionRepository,
+	s3Client *s3.Client,
+	logger *zap.Logger,
+) *Publisher {
+	return &Publisher{
+		jobRepo:      jobRepo,
+		artifactRepo: artifactRepo,
+		versionRepo:  versionRepo,
+		s3Client:     s3Client,
+		logger:       logger.With(zap.String("component", "publisher")),
+	}
+}
+
+// Publish makes a completed job the live version of its video and returns the
+// live version. The bool is false if no version was added: the job was already
+// live or, for PublishActionComplete, a newer job of the video is live. The
+// pointer is rewritten either way, so publishing again repairs a pointer whose
+// update failed.
+func (p *Publisher) Publish(ctx context.Context, job *domain.Job, action domain.PublishAction) (*domain.PublishedVersion, bool, error) {
+	if job.VideoID == nil {
+		return nil, false, fmt.Errorf("%w: job has no video ID", ErrNotPublishable)
+	}
+	if job.Status != domain.JobStatusCompleted {
+		return nil, false, fmt.Errorf("%w: job is %s", ErrNotPublishable, job.Status)
+	}
+	if job.DryRun {
+		return nil, false, fmt.Errorf("%w: job is a dry run", ErrNotPublishable)
+	}
+	artifacts, err := p.artifactRepo.GetByJobID(ctx, job.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(artifacts) == 0 {
+		return nil, false, fmt.Errorf("%w: job has no artifacts", ErrNotPublishable)
+	}
+
+	version := domain.NewPublishedVersion(*job.VideoID, job.ID, action)
+	published, err := p.versionRepo.Publish(ctx, version, action == domain.PublishActionComplete)
+	if err != nil {
+		return nil, false, err
+	}
+
+	current, err := p.versionRepo.GetCurrent(ctx, *job.VideoID)
+	if err != nil {
+		return nil, false, err
+	}
+	if published {
+		p.logger.Info("published version",
+			zap.String("videoId", job.VideoID.String()),
+			zap.Int("version", version.Version),
+			zap.String("jobId", job.ID.String()),
+			zap.String("action", string(action)))
+	}
+
+	// Concurrent publications may finish in any order: write the pointer of
+	// whatever is live now rather than of this job
+	if err := p.writePointer(ctx, current); err != nil {
+		return current, published, fmt.Errorf("failed to update pointer: %w", err)
+	}
+	return current, published, nil
+}
+
+// Rollback republishes the newest earlier version whose job differs from the
+// live one and still has its artifacts
+func (p *Publisher) Rollback(ctx context.Context, videoID uuid.UUID) (*domain.PublishedVersion, error) {
+	previous, err := p.versionRepo.GetPrevious(ctx, videoID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, ErrNoPreviousVersion
+		}
+		return nil, err
+	}
+
+	job, err := p.jobRepo.GetByID(ctx, *previous.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, _, err := p.Publish(ctx, job, domain.PublishActionRollback)
+	return current, err
+}
+
+// writePointer rewrites the pointer objects of a video to the given version
+func (p *Publisher) writePointer(ctx context.Context, current *domain.PublishedVersion) error {
+	if current.JobID == nil {
+		return fmt.Errorf("%w: live job was deleted", ErrNotPublishable)
+	}
+
+	artifacts, err := p.artifactRepo.GetByJobIDAndType(ctx, *current.JobID, domain.ArtifactTypeHLSMaster)
+	if err != nil {
+		return err
+	}
+
+	prefix := domain.CurrentPrefix(current.VideoID)
+	pointer := Pointer{
+		VideoID:     current.VideoID,
+		Version:     current.Version,
+		JobID:       *current.JobID,
+		Prefix:      current.VideoID.String() + "/" + current.JobID.String(),
+		PublishedAt: current.CreatedAt,
+	}
+	bucket := p.s3Client.GetDefaultBucket()
+
+	// The master playlist is written before version.json, so a reader that
+	// sees the new version also sees its playlist. Relative URIs only resolve
+	// within the bucket of the job output.
+	if len(artifacts) > 0 {
+		master := artifacts[0]
+		bucket = master.Bucket
+		content, err := p.s3Client.ReadObject(ctx, master.Bucket, master.Key, maxMasterSize)
+		if err != nil {
+			return err
+		}
+		// Both prefixes are under "{videoId}/": step out of "current" into the job output
+		base := "../" + strings.TrimPrefix(path.Dir(master.Key), current.VideoID.String()+"/") + "/"
+		rebased := s3.RebasePlaylist(string(content), base)
+		if err := p.s3Client.PutObject(ctx, bucket, prefix+"/master.m3u8", []byte(rebased), pointerCacheControl); err != nil {
+			return err
+		}
+		pointer.Master = prefix + "/master.m3u8"
+	} else if err := p.s3Client.Delete(ctx, bucket, prefix+"/master.m3u8"); err != nil {
+		// The live job has no HLS output; an old playlist must not stay reachable
+		return err
+	}
+
+	data, err := json.MarshalIndent(pointer, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pointer: %w", err)
+	}
+	return p.s3Client.PutObject(ctx, bucket, prefix+"/version.json", data, pointerCacheControl)
+}
@@ -63,53 +63,12 @@ func New(cfg config.S3Config) (*Client, error) {
 
 // Download downloads a file from S3
 func (c *Client) Download(ctx context.Context, bucket, key, destPath string) error {
-	output, err := c.client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-	})
-	if err != nil {
-		return fmt.Errorf("failed to get object: %w", err)
-	}
-	defer output.Body.Close()
-
-	// Create destination directory
-	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
-	}
-
-	file, err := os.Create(destPath)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer file.Close()
-
-	_, err = io.Copy(file, output.Body)
-	if err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
+	return c.DownloadResumable(ctx, bucket, key, destPath, nil, nil)
 }
 
 // Upload uploads a file to S3 using multipart upload for large files
 func (c *Client) Upload(ctx context.Context, bucket, key, srcPath string) (*UploadResult, error) {
-	file, err := os.Open(srcPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
-	}
-	defer file.Close()
-
-	stat, err := file.Stat()
-	if err != nil {
-		return nil, fmt.Errorf("failed to stat file: %w", err)
-	}
-
-	size := stat.Size()
-	if size < MinPartSize {
-		return c.uploadSimple(ctx, bucket, key, file, size)
-	}
-
-	return c.uploadMultipart(ctx, bucket, key, file, size)
+	return c.UploadResumable(ctx, bucket, key, srcPath, nil, nil)
 }
 
 // uploadSimple uploads a small file in a single request
@@ -124,7 +83,7 @@ func (c *Client) uploadSimple(ctx context.Context, bucket, key string, file *os.
 		ContentType:   aws.String(contentType),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to upload: %w", err)
+		return nil, fmt.Errorf("failed to upload: %w", classifyError(err))
 	}
 
 	return &UploadResult{
@@ -135,21 +94,44 @@ func (c *Client) uploadSimple(ctx context.Context, bucket, key string, file *os.
 	}, nil
 }
 
-// uploadMultipart uploads a large file using multipart upload
-func (c *Client) uploadMultipart(ctx context.Context, bucket, key string, file *os.File, size int64) (*UploadResult, error) {
+// uploadMultipart uploads a large file using multipart upload, continuing the
+// upload described by resume if S3 still has it open
+func (c *Client) uploadMultipart(
+	ctx context.Context,
+	bucket, key string,
+	file *os.File,
+	size int64,
+	resume *MultipartState,
+	onStart func(MultipartState),
+) (*UploadResult, error) {
 	contentType := detectContentType(key)
 
-	// Initiate multipart upload
-	createOutput, err := c.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
-		Bucket:      aws.String(bucket),
-		Key:         aws.String(key),
-		ContentType: aws.String(contentType),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create multipart upload: %w", err)
+	var uploadID string
+	var uploaded map[int32]uploadedPart
+	if resume != nil && resume.UploadID != "" {
+		parts, err := c.listParts(ctx, bucket, key, resume.UploadID)
+		if err == nil {
+			uploadID, uploaded = resume.UploadID, parts
+		} else if !IsNotFound(err) {
+			return nil, err
+		}
 	}
 
-	uploadID := aws.ToString(createOutput.UploadId)
+	if uploadID == "" {
+		// Initiate multipart upload
+		createOutput, err := c.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+			Bucket:      aws.String(bucket),
+			Key:         aws.String(key),
+			ContentType: aws.String(contentType),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create multipart upload: %w", classifyError(err))
+		}
+		uploadID = aws.ToString(createOutput.UploadId)
+	}
+	if onStart != nil {
+		onStart(MultipartState{Key: key, UploadID: uploadID})
+	}
 
 	// Calculate part size and count
 	partSize := int64(DefaultPartSize)
@@ -170,6 +152,15 @@ func (c *Client) uploadMultipart(ctx context.Context, bucket, key string, file *
 			currentPartSize = remaining
 		}
 
+		// Parts of a resumed upload that S3 already holds in full are not sent again
+		if part, ok := uploaded[int32(partNum)]; ok && part.size == currentPartSize {
+			completedParts = append(completedParts, types.CompletedPart{
+				ETag:       part.etag,
+				PartNumber: aws.Int32(int32(partNum)),
+			})
+			continue
+		}
+
 		partData := make([]byte, currentPartSize)
 		n, err := file.ReadAt(partData, offset)
 		if err != nil && err != io.EOF {
@@ -205,7 +196,7 @@ func (c *Client) uploadMultipart(ctx context.Context, bucket, key string, file *
 
 		if uploadErr != nil {
 			c.abortMultipartUpload(ctx, bucket, key, uploadID)
-			return nil, fmt.Errorf("failed to upload part %d: %w", partNum, uploadErr)
+			return nil, fmt.Errorf("failed to upload part %d: %w", partNum, classifyError(uploadErr))
 		}
 	}
 
@@ -220,7 +211,7 @@ func (c *Client) uploadMultipart(ctx context.Context, bucket, key string, file *
 	})
 	if err != nil {
 		c.abortMultipartUpload(ctx, bucket, key, uploadID)
-		return nil, fmt.Errorf("failed to complete multipart upload: %w", err)
+		return nil, fmt.Errorf("failed to complete multipart upload: %w", classifyError(err))
 	}
 
 	return &UploadResult{
@@ -231,8 +222,13 @@ func (c *Client) uploadMultipart(ctx context.Context, bucket, key string, file *
 	}, nil
 }
 
-// abortMultipartUpload aborts a multipart upload
+// abortMultipartUpload aborts a multipart upload. An upload interrupted by
+// cancellation is left open for the next attempt to continue; uploads never
+// continued are removed by AbortStaleMultipartUploads.
 func (c *Client) abortMultipartUpload(ctx context.Context, bucket, key, uploadID string) {
+	if ctx.Err() != nil {
+		return
+	}
 	c.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
 		Bucket:   aws.String(bucket),
 		Key:      aws.String(key),
@@ -240,6 +236,59 @@ func (c *Client) abortMultipartUpload(ctx context.Context, bucket, key, uploadID
 	})
 }
 
+// AbortStaleMultipartUploads aborts incomplete multipart uploads in the bucket
+// initiated more than maxAge ago. Returns the number of aborted uploads.
+func (c *Client) AbortStaleMultipartUploads(ctx context.Context, bucket string, maxAge time.Duration) (int, error) {
+	cutoff := time.Now().Add(-maxAge)
+	aborted := 0
+
+	input := &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(bucket),
+	}
+	for {
+		page, err := c.client.ListMultipartUploads(ctx, input)
+		if err != nil {
+			return aborted, fmt.Errorf("failed to list multipart uploads: %w", classifyError(err))
+		}
+
+		for _, upload := range page.Uploads {
+			if upload.Initiated == nil || upload.Initiated.After(cutoff) {
+				continue
+			}
+			_, err := c.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(bucket),
+				Key:      upload.Key,
+				UploadId: upload.UploadId,
+			})
+			if err != nil {
+				return aborted, fmt.Errorf("failed to abort multipart upload %s: %w", aws.ToString(upload.Key), classifyError(err))
+			}
+			aborted++
+		}
+
+		if !aws.ToBool(page.IsTruncated) {
+			break
+		}
+		input.KeyMarker = page.NextKeyMarker
+		input.UploadIdMarker = page.NextUploadIdMarker
+	}
+
+	return aborted, nil
+}
+
+// PresignGet returns a time-limited URL for downloading an object without credentials
+func (c *Client) PresignGet(ctx context.Context, bucket, key string, expires time.Duration) (string, error) {
+	presigner := s3.NewPresignClient(c.client)
+	req, err := presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", fmt.Errorf("failed to presign object: %w", classifyError(err))
+	}
+	return req.URL, nil
+}
+
 // Delete deletes an object from S3
 func (c *Client) Delete(ctx context.Context, bucket, key string) error {
 	_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{
@@ -247,11 +296,48 @@ func (c *Client) Delete(ctx context.Context, bucket, key string) error {
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return fmt.Errorf("failed to delete object: %w", err)
+		return fmt.Errorf("failed to delete object: %w", classifyError(err))
 	}
 	return nil
 }
 
+// maxDeleteBatch is the maximum number of keys accepted by a single DeleteObjects call
+const maxDeleteBatch = 1000
+
+// DeleteMany deletes objects in batches and returns the number of deleted keys
+func (c *Client) DeleteMany(ctx context.Context, bucket string, keys []string) (int, error) {
+	deleted := 0
+	for start := 0; start < len(keys); start += maxDeleteBatch {
+		end := start + maxDeleteBatch
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		objects := make([]types.ObjectIdentifier, 0, end-start)
+		for _, key := range keys[start:end] {
+			objects = append(objects, types.ObjectIdentifier{Key: aws.String(key)})
+		}
+
+		out, err := c.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(bucket),
+			Delete: &types.Delete{
+				Objects: objects,
+				Quiet:   aws.Bool(true),
+			},
+		})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete objects: %w", classifyError(err))
+		}
+		if len(out.Errors) > 0 {
+			first := out.Errors[0]
+			return deleted + len(objects) - len(out.Errors), fmt.Errorf("failed to delete %d objects, first %s: %s",
+				len(out.Errors), aws.ToString(first.Key), aws.ToString(first.Message))
+		}
+		deleted += len(objects)
+	}
+	return deleted, nil
+}
+
 // Exists checks if an object exists in S3
 func (c *Client) Exists(ctx context.Context, bucket, key string) (bool, error) {
 	_, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
@@ -259,11 +345,73 @@ func (c *Client) Exists(ctx context.Context, bucket, key string) (bool, error) {
 		Key:    aws.String(key),
 	})
 	if err != nil {
-		return false, nil
+		if IsNotFound(classifyError(err)) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to head object: %w", classifyError(err))
 	}
 	return true, nil
 }
 
+// Head returns size and ETag of an object
+func (c *Client) Head(ctx context.Context, bucket, key string) (*ObjectInfo, error) {
+	out, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to head object: %w", classifyError(err))
+	}
+	return &ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		LastModified: aws.ToTime(out.LastModified),
+		ETag:         aws.ToString(out.ETag),
+	}, nil
+}
+
+// ReadObject returns the content of a small object, such as a playlist.
+// Objects larger than maxBytes are rejected.
+func (c *Client) ReadObject(ctx context.Context, bucket, key string, maxBytes int64) ([]byte, error) {
+	out, err := c.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object: %w", classifyError(err))
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(out.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", classifyError(err))
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, fmt.Errorf("object %s exceeds %d bytes", key, maxBytes)
+	}
+	return data, nil
+}
+
+// PutObject writes a small object, such as a playlist, from memory. An empty
+// cacheControl leaves the Cache-Control header unset.
+func (c *Client) PutObject(ctx context.Context, bucket, key string, data []byte, cacheControl string) error {
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(bucket),
+		Key:           aws.String(key),
+		Body:          NewSectionReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+		ContentType:   aws.String(detectContentType(key)),
+	}
+	if cacheControl != "" {
+		input.CacheControl = aws.String(cacheControl)
+	}
+
+	if _, err := c.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("failed to put object: %w", classifyError(err))
+	}
+	return nil
+}
+
 // ListObjects lists objects with a given prefix
 func (c *Client) ListObjects(ctx context.Context, bucket, prefix string) ([]ObjectInfo, error) {
 	var objects []ObjectInfo
@@ -276,7 +424,7 @@ func (c *Client) ListObjects(ctx context.Context, bucket, prefix string) ([]Obje
 	for paginator.HasMorePages() {
 		page, err := paginator.NextPage(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list objects: %w", err)
+			return nil, fmt.Errorf("failed to list objects: %w", classifyError(err))
 		}
 
 		for _, obj := range page.Contents {
@@ -297,7 +445,7 @@ func (c *Client) Health(ctx context.Context) error {
 	_, err := c.client.HeadBucket(ctx, &s3.HeadBucketInput{
 		Bucket: aws.String(c.bucket),
 	})
-	return err
+	return classifyError(err)
 }
 
 // GetDefaultBucket returns the default output bucket
@@ -349,6 +497,7 @@ func detectContentType(key string) string {
 		".m3u8": "application/vnd.apple.mpegurl",
 		".ts":   "video/mp2t",
 		".mp4":  "video/mp4",
+		".mov":  "video/quicktime",
 		".vtt":  "text/vtt",
 		".jpg":  "image/jpeg",
 		".jpeg": "image/jpeg",
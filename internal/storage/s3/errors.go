@@ -0,0 +1,109 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// Sentinel errors describing the kind of S3 failure
+var (
+	ErrNotFound     = errors.New("s3: not found")
+	ErrAccessDenied = errors.New("s3: access denied")
+	ErrThrottled    = errors.New("s3: request throttled")
+	ErrTimeout      = errors.New("s3: request timed out")
+	ErrNetwork      = errors.New("s3: request not sent")
+)
+
+// classifyError wraps an AWS SDK error with the sentinel matching its kind.
+// Unrecognized errors are returned unchanged.
+func classifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if kind := errorKind(err); kind != nil {
+		return fmt.Errorf("%w: %w", kind, err)
+	}
+	return err
+}
+
+func errorKind(err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NoSuchKey", "NoSuchBucket", "NoSuchUpload", "NotFound":
+			return ErrNotFound
+		case "AccessDenied", "Forbidden", "InvalidAccessKeyId", "SignatureDoesNotMatch", "AllAccessDisabled":
+			return ErrAccessDenied
+		case "SlowDown", "Throttling", "ThrottlingException", "RequestLimitExceeded", "TooManyRequests", "RequestThrottled":
+			return ErrThrottled
+		case "RequestTimeout":
+			return ErrTimeout
+		}
+	}
+
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.HTTPStatusCode() {
+		case http.StatusNotFound:
+			return ErrNotFound
+		case http.StatusForbidden:
+			return ErrAccessDenied
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return ErrThrottled
+		case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+			return ErrTimeout
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrTimeout
+	}
+	// The request did not reach S3 or the connection broke: DNS, refused or
+	// reset connections and bodies cut short
+	var sendErr *smithyhttp.RequestSendError
+	if errors.As(err, &sendErr) || errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrNetwork
+	}
+
+	return nil
+}
+
+// IsNotFound returns true if the error means the object or bucket does not exist
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// ErrorCode maps an S3 client error to a domain error code. Errors of unknown
+// kind are not retried.
+func ErrorCode(err error) string {
+	var incomplete *IncompleteOutputError
+	switch {
+	case errors.As(err, &incomplete):
+		return domain.ErrCodeOutputIncomplete
+	case errors.Is(err, ErrAccessDenied):
+		return domain.ErrCodeS3AccessDenied
+	case errors.Is(err, ErrNotFound):
+		return domain.ErrCodeS3NotFound
+	case errors.Is(err, ErrThrottled):
+		return domain.ErrCodeS3Throttled
+	case errors.Is(err, ErrTimeout):
+		return domain.ErrCodeS3Timeout
+	case errors.Is(err, ErrNetwork):
+		return domain.ErrCodeNetworkError
+	default:
+		return domain.ErrCodeStorageError
+	}
+}
@@ -0,0 +1,98 @@
+package s3
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// uploadManifest is an append-only JSON lines log of completed uploads.
+// It survives activity retries on the same worker, so already uploaded
+// objects are not sent again.
+type uploadManifest struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]*UploadResult
+}
+
+func loadUploadManifest(path string) (*uploadManifest, error) {
+	m := &uploadManifest{
+		path:    path,
+		entries: make(map[string]*UploadResult),
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return m, nil
+		}
+		return nil, fmt.Errorf("failed to open upload manifest: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry UploadResult
+		// A torn last line from a crash is ignored; that object is uploaded again
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		m.entries[manifestKey(entry.Bucket, entry.Key)] = &entry
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read upload manifest: %w", err)
+	}
+
+	return m, nil
+}
+
+// lookup returns a previous upload result if the object was uploaded with the same size
+func (m *uploadManifest) lookup(bucket, key string, size int64) (*UploadResult, bool) {
+	if m == nil {
+		return nil, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[manifestKey(bucket, key)]
+	if !ok || entry.Size != size {
+		return nil, false
+	}
+	return entry, true
+}
+
+// record appends an upload result to the manifest
+func (m *uploadManifest) record(result *UploadResult) error {
+	if m == nil {
+		return nil
+	}
+	line, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	m.entries[manifestKey(result.Bucket, result.Key)] = result
+	return nil
+}
+
+func manifestKey(bucket, key string) string {
+	return bucket + "/" + key
+}
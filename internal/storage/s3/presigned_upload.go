@@ -0,0 +1,174 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// maxUploadParts is the S3 limit on parts per multipart upload
+const maxUploadParts = 10000
+
+// PresignedUpload describes a multipart upload that clients perform directly against S3
+type PresignedUpload struct {
+	UploadID string
+	Bucket   string
+	Key      string
+	PartSize int64
+	Parts    []PresignedPart
+}
+
+// PresignedPart holds the presigned PUT URL for one part
+type PresignedPart struct {
+	PartNumber int
+	URL        string
+}
+
+// UploadedPart identifies a part uploaded by the client
+type UploadedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+// CreatePresignedUpload starts a multipart upload and presigns a PUT URL for every part.
+// The client uploads parts in order of PartNumber, each PartSize bytes except the last one.
+func (c *Client) CreatePresignedUpload(ctx context.Context, bucket, key string, size int64, expires time.Duration) (*PresignedUpload, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("upload size must be positive")
+	}
+
+	partSize := int64(DefaultPartSize)
+	if (size+partSize-1)/partSize > maxUploadParts {
+		partSize = (size + maxUploadParts - 1) / maxUploadParts
+	}
+	partCount := (size + partSize - 1) / partSize
+
+	createOutput, err := c.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String(detectContentType(key)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create multipart upload: %w", classifyError(err))
+	}
+	uploadID := aws.ToString(createOutput.UploadId)
+
+	presigner := s3.NewPresignClient(c.client)
+	parts := make([]PresignedPart, 0, partCount)
+	for partNum := int32(1); int64(partNum) <= partCount; partNum++ {
+		req, err := presigner.PresignUploadPart(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(bucket),
+			Key:        aws.String(key),
+			UploadId:   aws.String(uploadID),
+			PartNumber: aws.Int32(partNum),
+		}, s3.WithPresignExpires(expires))
+		if err != nil {
+			c.abortMultipartUpload(ctx, bucket, key, uploadID)
+			return nil, fmt.Errorf("failed to presign part %d: %w", partNum, classifyError(err))
+		}
+		parts = append(parts, PresignedPart{PartNumber: int(partNum), URL: req.URL})
+	}
+
+	return &PresignedUpload{
+		UploadID: uploadID,
+		Bucket:   bucket,
+		Key:      key,
+		PartSize: partSize,
+		Parts:    parts,
+	}, nil
+}
+
+// CompletePresignedUpload completes a client-side multipart upload.
+// If parts is empty, the uploaded parts are listed from S3, which covers clients
+// that cannot read the ETag response header because of CORS.
+func (c *Client) CompletePresignedUpload(ctx context.Context, bucket, key, uploadID string, parts []UploadedPart) (*UploadResult, error) {
+	if len(parts) == 0 {
+		listed, err := c.listUploadedParts(ctx, bucket, key, uploadID)
+		if err != nil {
+			return nil, err
+		}
+		parts = listed
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("no parts uploaded")
+	}
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	completed := make([]types.CompletedPart, 0, len(parts))
+	for _, p := range parts {
+		completed = append(completed, types.CompletedPart{
+			ETag:       aws.String(p.ETag),
+			PartNumber: aws.Int32(int32(p.PartNumber)),
+		})
+	}
+
+	output, err := c.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete multipart upload: %w", classifyError(err))
+	}
+
+	head, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat uploaded object: %w", classifyError(err))
+	}
+
+	return &UploadResult{
+		Bucket: bucket,
+		Key:    key,
+		ETag:   aws.ToString(output.ETag),
+		Size:   aws.ToInt64(head.ContentLength),
+	}, nil
+}
+
+// AbortPresignedUpload aborts a client-side multipart upload and frees its parts
+func (c *Client) AbortPresignedUpload(ctx context.Context, bucket, key, uploadID string) error {
+	_, err := c.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %w", classifyError(err))
+	}
+	return nil
+}
+
+// listUploadedParts lists parts of an in-progress multipart upload
+func (c *Client) listUploadedParts(ctx context.Context, bucket, key, uploadID string) ([]UploadedPart, error) {
+	var parts []UploadedPart
+	input := &s3.ListPartsInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	}
+	for {
+		page, err := c.client.ListParts(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list parts: %w", classifyError(err))
+		}
+		for _, p := range page.Parts {
+			parts = append(parts, UploadedPart{
+				PartNumber: int(aws.ToInt32(p.PartNumber)),
+				ETag:       aws.ToString(p.ETag),
+			})
+		}
+		if !aws.ToBool(page.IsTruncated) {
+			break
+		}
+		input.PartNumberMarker = page.NextPartNumberMarker
+	}
+	return parts, nil
+}
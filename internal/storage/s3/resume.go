@@ -0,0 +1,228 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// progressInterval bounds how often transfer progress is reported
+const progressInterval = time.Second
+
+// DownloadState identifies a partial download so a later attempt can continue it
+type DownloadState struct {
+	Key  string `json:"key"`
+	ETag string `json:"etag"`
+	// Bytes written to the destination file
+	Bytes int64 `json:"bytes"`
+	Size  int64 `json:"size"`
+}
+
+// MultipartState identifies a multipart upload in progress so a later attempt
+// can continue it; the uploaded parts are listed from S3
+type MultipartState struct {
+	Key      string `json:"key"`
+	UploadID string `json:"uploadId"`
+}
+
+// DownloadResumable downloads an object, continuing a partial destination file
+// left by an earlier attempt described by resume. The file is continued only
+// while the object still has the ETag it was started with; otherwise it is
+// downloaded from the start. onProgress, if set, is called as data arrives.
+func (c *Client) DownloadResumable(
+	ctx context.Context,
+	bucket, key, destPath string,
+	resume *DownloadState,
+	onProgress func(DownloadState),
+) error {
+	var offset int64
+	if resume != nil && resume.Key == key && resume.ETag != "" {
+		// Bytes written after the last reported progress are kept as well
+		if info, err := os.Stat(destPath); err == nil {
+			offset = info.Size()
+		}
+	}
+
+	// A file completed by the earlier attempt only needs the object to be unchanged
+	if offset > 0 && offset == resume.Size {
+		_, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket:  aws.String(bucket),
+			Key:     aws.String(key),
+			IfMatch: aws.String(resume.ETag),
+		})
+		if err == nil {
+			if onProgress != nil {
+				onProgress(*resume)
+			}
+			return nil
+		}
+		if !isStaleRange(err) {
+			return fmt.Errorf("failed to head object: %w", classifyError(err))
+		}
+		offset = 0
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}
+	if offset > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+		input.IfMatch = aws.String(resume.ETag)
+	}
+
+	output, err := c.client.GetObject(ctx, input)
+	if err != nil {
+		if offset > 0 && isStaleRange(err) {
+			// The object changed or the file is complete: start over
+			return c.DownloadResumable(ctx, bucket, key, destPath, nil, onProgress)
+		}
+		return fmt.Errorf("failed to get object: %w", classifyError(err))
+	}
+	defer output.Body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_WRONLY
+	}
+	file, err := os.OpenFile(destPath, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek file: %w", err)
+	}
+
+	state := DownloadState{
+		Key:   key,
+		ETag:  aws.ToString(output.ETag),
+		Bytes: offset,
+		Size:  offset + aws.ToInt64(output.ContentLength),
+	}
+	writer := &progressWriter{w: file, state: &state, onProgress: onProgress}
+	if onProgress != nil {
+		onProgress(state)
+	}
+
+	if _, err := io.Copy(writer, output.Body); err != nil {
+		return fmt.Errorf("failed to write file: %w", classifyError(err))
+	}
+	if onProgress != nil {
+		onProgress(state)
+	}
+
+	return nil
+}
+
+// isStaleRange reports whether a conditional request failed because the
+// object changed (412) or the range starts past its end (416)
+func isStaleRange(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "PreconditionFailed", "InvalidRange":
+			return true
+		}
+	}
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.HTTPStatusCode() {
+		case http.StatusPreconditionFailed, http.StatusRequestedRangeNotSatisfiable:
+			return true
+		}
+	}
+	return false
+}
+
+// progressWriter counts written bytes and reports them at most once per progressInterval
+type progressWriter struct {
+	w          io.Writer
+	state      *DownloadState
+	onProgress func(DownloadState)
+	reported   time.Time
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.state.Bytes += int64(n)
+	if p.onProgress != nil && time.Since(p.reported) >= progressInterval {
+		p.reported = time.Now()
+		p.onProgress(*p.state)
+	}
+	return n, err
+}
+
+// UploadResumable uploads a file like Upload. A multipart upload continues the
+// one described by resume if it is still open, skipping the parts S3 already
+// has; onStart, if set, is called with the upload to record before parts are sent.
+func (c *Client) UploadResumable(
+	ctx context.Context,
+	bucket, key, srcPath string,
+	resume *MultipartState,
+	onStart func(MultipartState),
+) (*UploadResult, error) {
+	file, err := os.Open(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	size := stat.Size()
+	if size < MinPartSize {
+		return c.uploadSimple(ctx, bucket, key, file, size)
+	}
+
+	if resume != nil && resume.Key != key {
+		resume = nil
+	}
+	return c.uploadMultipart(ctx, bucket, key, file, size, resume, onStart)
+}
+
+// listParts returns the sizes and ETags of the parts of an open multipart upload by part number
+func (c *Client) listParts(ctx context.Context, bucket, key, uploadID string) (map[int32]uploadedPart, error) {
+	parts := make(map[int32]uploadedPart)
+	paginator := s3.NewListPartsPaginator(c.client, &s3.ListPartsInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list parts: %w", classifyError(err))
+		}
+		for _, p := range page.Parts {
+			parts[aws.ToInt32(p.PartNumber)] = uploadedPart{
+				size: aws.ToInt64(p.Size),
+				etag: p.ETag,
+			}
+		}
+	}
+	return parts, nil
+}
+
+// uploadedPart is a part S3 already holds for a multipart upload
+type uploadedPart struct {
+	size int64
+	etag *string
+}
@@ -2,13 +2,17 @@ package s3
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/tvoe/converter/internal/config"
 	"github.com/tvoe/converter/internal/domain"
 )
 
@@ -20,11 +24,54 @@ type UploadProgress struct {
 	UploadedBytes  int64
 }
 
+// FailedUpload describes a file that could not be uploaded
+type FailedUpload struct {
+	Key      string
+	Attempts int
+	Err      error
+}
+
+// UploadError is returned by UploadDirectory when some files still failed after retries
+type UploadError struct {
+	TotalFiles int
+	Failed     []FailedUpload
+}
+
+func (e *UploadError) Error() string {
+	keys := make([]string, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		keys = append(keys, f.Key)
+	}
+	if len(keys) > 5 {
+		keys = append(keys[:5], "...")
+	}
+	return fmt.Sprintf("%d of %d files failed to upload (%s): %v",
+		len(e.Failed), e.TotalFiles, strings.Join(keys, ", "), e.Failed[0].Err)
+}
+
+// Unwrap exposes per-file errors so ErrorCode can classify the failure
+func (e *UploadError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		errs = append(errs, f.Err)
+	}
+	return errs
+}
+
 // DirectoryUploader handles uploading directories to S3
 type DirectoryUploader struct {
 	client         *Client
 	maxConcurrent  int
 	progressChan   chan UploadProgress
+	manifest       *uploadManifest
+	// Multipart uploads in progress by key, reported to onMultipart
+	multipartMu sync.Mutex
+	multipart   map[string]MultipartState
+	onMultipart func([]MultipartState)
+	// Per-file retries of transient failures
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
 }
 
 // NewDirectoryUploader creates a new directory uploader
@@ -32,6 +79,76 @@ func NewDirectoryUploader(client *Client, maxConcurrent int) *DirectoryUploader
 	return &DirectoryUploader{
 		client:        client,
 		maxConcurrent: maxConcurrent,
+		attempts:      1,
+	}
+}
+
+// WithRetry retries files that failed with a transient error (timeout, throttling,
+// network) before the whole directory upload is reported as failed
+func (u *DirectoryUploader) WithRetry(cfg config.RetryConfig) *DirectoryUploader {
+	u.attempts = cfg.Count + 1
+	u.baseDelay = time.Duration(cfg.BaseDelayMs) * time.Millisecond
+	u.maxDelay = time.Duration(cfg.MaxDelayMs) * time.Millisecond
+	return u
+}
+
+// WithManifest enables resumable uploads: every uploaded object is recorded in
+// the manifest file, and objects already listed there are skipped on retry
+func (u *DirectoryUploader) WithManifest(path string) (*DirectoryUploader, error) {
+	manifest, err := loadUploadManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	u.manifest = manifest
+	return u, nil
+}
+
+// WithMultipartResume continues the multipart uploads an earlier attempt left
+// open. onChange, if set, is called with the uploads in progress whenever one
+// starts or completes, for the caller to record for the next attempt.
+func (u *DirectoryUploader) WithMultipartResume(uploads []MultipartState, onChange func([]MultipartState)) *DirectoryUploader {
+	u.multipart = make(map[string]MultipartState, len(uploads))
+	for _, m := range uploads {
+		u.multipart[m.Key] = m
+	}
+	u.onMultipart = onChange
+	return u
+}
+
+// multipartResume returns the open multipart upload of a key, if any
+func (u *DirectoryUploader) multipartResume(key string) *MultipartState {
+	u.multipartMu.Lock()
+	defer u.multipartMu.Unlock()
+	if m, ok := u.multipart[key]; ok {
+		return &m
+	}
+	return nil
+}
+
+// setMultipart records a started multipart upload, or with an empty upload ID
+// forgets the completed one of a key, and reports the uploads in progress
+func (u *DirectoryUploader) setMultipart(key string, m MultipartState) {
+	if u.multipart == nil {
+		return
+	}
+	// Reported under the lock so concurrent files report in order
+	u.multipartMu.Lock()
+	defer u.multipartMu.Unlock()
+	if m.UploadID == "" {
+		if _, ok := u.multipart[key]; !ok {
+			return
+		}
+		delete(u.multipart, key)
+	} else {
+		u.multipart[key] = m
+	}
+	uploads := make([]MultipartState, 0, len(u.multipart))
+	for _, m := range u.multipart {
+		uploads = append(uploads, m)
+	}
+
+	if u.onMultipart != nil {
+		u.onMultipart(uploads)
 	}
 }
 
@@ -87,6 +204,8 @@ func (u *DirectoryUploader) UploadDirectory(
 
 	sem := make(chan struct{}, u.maxConcurrent)
 	errChan := make(chan error, len(files))
+	var failed []FailedUpload
+	var failedMu sync.Mutex
 	var wg sync.WaitGroup
 
 	for _, f := range files {
@@ -102,10 +221,25 @@ func (u *DirectoryUploader) UploadDirectory(
 				defer func() { <-sem }()
 			}
 
-			result, err := u.client.Upload(ctx, bucket, f.key, f.localPath)
-			if err != nil {
-				errChan <- fmt.Errorf("failed to upload %s: %w", f.key, err)
-				return
+			result, ok := u.manifest.lookup(bucket, f.key, f.size)
+			if !ok {
+				var attempts int
+				var err error
+				result, attempts, err = u.uploadFile(ctx, bucket, f)
+				if err != nil {
+					if ctx.Err() != nil {
+						errChan <- ctx.Err()
+						return
+					}
+					failedMu.Lock()
+					failed = append(failed, FailedUpload{Key: f.key, Attempts: attempts, Err: err})
+					failedMu.Unlock()
+					return
+				}
+				if err := u.manifest.record(result); err != nil {
+					errChan <- fmt.Errorf("failed to record upload of %s: %w", f.key, err)
+					return
+				}
 			}
 
 			artifact := domain.NewArtifact(jobID, determineArtifactType(f.key), bucket, f.key)
@@ -141,13 +275,43 @@ func (u *DirectoryUploader) UploadDirectory(
 		}
 	}
 	if len(errs) > 0 {
-		return nil, fmt.Errorf("upload errors: %v", errs)
+		return nil, fmt.Errorf("upload errors: %w", errors.Join(errs...))
+	}
+	if len(failed) > 0 {
+		return nil, &UploadError{TotalFiles: len(files), Failed: failed}
 	}
 
 	_ = progress // Used for progress tracking
 	return artifacts, nil
 }
 
+// uploadFile uploads one file, retrying transient failures with exponential backoff.
+// Returns the number of attempts made.
+func (u *DirectoryUploader) uploadFile(ctx context.Context, bucket string, f fileInfo) (*UploadResult, int, error) {
+	delay := u.baseDelay
+	for attempt := 1; ; attempt++ {
+		result, err := u.client.UploadResumable(ctx, bucket, f.key, f.localPath, u.multipartResume(f.key),
+			func(m MultipartState) { u.setMultipart(f.key, m) })
+		if err == nil {
+			u.setMultipart(f.key, MultipartState{})
+			return result, attempt, nil
+		}
+		if attempt >= u.attempts || !domain.IsRetryable(ErrorCode(err)) {
+			return nil, attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, attempt, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+		if u.maxDelay > 0 && delay > u.maxDelay {
+			delay = u.maxDelay
+		}
+	}
+}
+
 type fileInfo struct {
 	localPath string
 	key       string
@@ -160,10 +324,14 @@ func determineArtifactType(key string) domain.ArtifactType {
 	base := filepath.Base(key)
 
 	switch {
+	case base == "master.m3u8" && strings.HasPrefix(filepath.Base(filepath.Dir(key)), "hardsub_"):
+		return domain.ArtifactTypeHLSHardsubMaster
 	case base == "master.m3u8":
 		return domain.ArtifactTypeHLSMaster
 	case ext == ".mpd":
 		return domain.ArtifactTypeDASHManifest
+	case strings.HasSuffix(base, "_iframes.m3u8"):
+		return domain.ArtifactTypeHLSIFrames
 	case ext == ".m3u8":
 		return domain.ArtifactTypeHLSVariant
 	case ext == ".ts" || ext == ".m4s":
@@ -176,6 +344,12 @@ func determineArtifactType(key string) domain.ArtifactType {
 		return domain.ArtifactTypeThumbTile
 	case ext == ".json":
 		return domain.ArtifactTypeMetadataJSON
+	case ext == ".mov" || ext == ".mxf":
+		return domain.ArtifactTypeMezzanine
+	case ext == ".mp4" && strings.Contains(key, "/renditions/"):
+		return domain.ArtifactTypeRendition
+	case ext == ".mp4" && strings.Contains(key, "/downloads/"):
+		return domain.ArtifactTypeMP4Progressive
 	default:
 		return domain.ArtifactTypeSegment
 	}
@@ -0,0 +1,266 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/tvoe/converter/internal/domain"
+)
+
+// maxPlaylistSize bounds playlists and manifests read during verification
+const maxPlaylistSize = 16 << 20
+
+// VerifyReport summarizes an output verification
+type VerifyReport struct {
+	Playlists       int
+	SegmentsChecked int
+	ObjectsChecked  int
+	Problems        []string
+}
+
+// IncompleteOutputError is returned when published output is missing objects
+// or objects differ from what was uploaded
+type IncompleteOutputError struct {
+	Problems []string
+}
+
+func (e *IncompleteOutputError) Error() string {
+	return fmt.Sprintf("output incomplete: %d problems, first: %s", len(e.Problems), e.Problems[0])
+}
+
+// OutputVerifier checks that the output of a job is fetchable from S3
+type OutputVerifier struct {
+	client         *Client
+	segmentSamples int
+	expectedSizes  map[string]int64
+	seen           map[string]bool
+	report         *VerifyReport
+}
+
+// NewOutputVerifier creates a verifier that HEAD-checks up to segmentSamples
+// segments of every variant playlist
+func NewOutputVerifier(client *Client, segmentSamples int) *OutputVerifier {
+	if segmentSamples < 1 {
+		segmentSamples = 1
+	}
+	return &OutputVerifier{
+		client:         client,
+		segmentSamples: segmentSamples,
+	}
+}
+
+// Verify checks every HLS master playlist and its variants, DASH manifests, and
+// MP4 renditions and mezzanines among artifacts. Sizes are compared to the sizes
+// recorded at upload. Missing or mismatched objects yield *IncompleteOutputError;
+// any other error is an S3 failure that may be retried.
+func (v *OutputVerifier) Verify(ctx context.Context, artifacts []*domain.Artifact) (*VerifyReport, error) {
+	v.expectedSizes = make(map[string]int64)
+	v.seen = make(map[string]bool)
+	v.report = &VerifyReport{}
+
+	for _, a := range artifacts {
+		if a.SizeBytes != nil {
+			v.expectedSizes[a.Key] = *a.SizeBytes
+		}
+	}
+
+	for _, a := range artifacts {
+		var err error
+		switch a.Type {
+		case domain.ArtifactTypeHLSMaster, domain.ArtifactTypeHLSHardsubMaster:
+			err = v.verifyMaster(ctx, a.Bucket, a.Key)
+		case domain.ArtifactTypeDASHManifest:
+			_, err = v.readPlaylist(ctx, a.Bucket, a.Key)
+		case domain.ArtifactTypeRendition, domain.ArtifactTypeMP4Progressive, domain.ArtifactTypeMezzanine:
+			err = v.verifyObject(ctx, a.Bucket, a.Key)
+			v.report.ObjectsChecked++
+		}
+		if err != nil {
+			return v.report, err
+		}
+	}
+
+	if len(v.report.Problems) > 0 {
+		return v.report, &IncompleteOutputError{Problems: v.report.Problems}
+	}
+	return v.report, nil
+}
+
+// verifyMaster fetches a master playlist and every playlist it references
+func (v *OutputVerifier) verifyMaster(ctx context.Context, bucket, key string) error {
+	content, err := v.readPlaylist(ctx, bucket, key)
+	if err != nil || content == "" {
+		return err
+	}
+
+	lines, tagURIs := playlistURIs(content)
+	for _, uri := range append(lines, tagURIs...) {
+		variantKey, ok := resolveKey(key, uri)
+		if !ok || v.seen[variantKey] {
+			continue
+		}
+		v.seen[variantKey] = true
+		if err := v.verifyVariant(ctx, bucket, variantKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyVariant fetches a media playlist and HEAD-checks its init segment and sampled segments
+func (v *OutputVerifier) verifyVariant(ctx context.Context, bucket, key string) error {
+	content, err := v.readPlaylist(ctx, bucket, key)
+	if err != nil || content == "" {
+		return err
+	}
+
+	segments, initURIs := playlistURIs(content)
+	var keys []string
+	for _, uri := range initURIs {
+		if k, ok := resolveKey(key, uri); ok {
+			keys = append(keys, k)
+		}
+	}
+	for _, i := range sampleIndexes(len(segments), v.segmentSamples) {
+		if k, ok := resolveKey(key, segments[i]); ok {
+			keys = append(keys, k)
+		}
+	}
+
+	for _, k := range keys {
+		if err := v.verifyObject(ctx, bucket, k); err != nil {
+			return err
+		}
+		v.report.SegmentsChecked++
+	}
+	return nil
+}
+
+// readPlaylist fetches a playlist. A missing playlist is recorded as a problem
+// and returns empty content.
+func (v *OutputVerifier) readPlaylist(ctx context.Context, bucket, key string) (string, error) {
+	data, err := v.client.ReadObject(ctx, bucket, key, maxPlaylistSize)
+	if err != nil {
+		if IsNotFound(err) {
+			v.report.Problems = append(v.report.Problems, fmt.Sprintf("%s: not found", key))
+			return "", nil
+		}
+		return "", err
+	}
+	v.report.Playlists++
+	if len(data) == 0 {
+		v.report.Problems = append(v.report.Problems, fmt.Sprintf("%s: empty", key))
+	}
+	return string(data), nil
+}
+
+// verifyObject HEAD-checks an object and compares its size with the uploaded size
+func (v *OutputVerifier) verifyObject(ctx context.Context, bucket, key string) error {
+	info, err := v.client.Head(ctx, bucket, key)
+	if err != nil {
+		if IsNotFound(err) {
+			v.report.Problems = append(v.report.Problems, fmt.Sprintf("%s: not found", key))
+			return nil
+		}
+		return err
+	}
+	if expected, ok := v.expectedSizes[key]; ok && expected != info.Size {
+		v.report.Problems = append(v.report.Problems,
+			fmt.Sprintf("%s: size %d, uploaded %d", key, info.Size, expected))
+	}
+	return nil
+}
+
+// playlistURIs returns URI lines of a playlist (variants or segments) and URI
+// attributes of EXT-X-MEDIA, EXT-X-I-FRAME-STREAM-INF and EXT-X-MAP tags.
+// EXT-X-KEY URIs point to the key server and are not returned.
+func playlistURIs(content string) (lines, tagURIs []string) {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-MEDIA:"),
+			strings.HasPrefix(line, "#EXT-X-I-FRAME-STREAM-INF:"),
+			strings.HasPrefix(line, "#EXT-X-MAP:"):
+			if uri := uriAttribute(line); uri != "" {
+				tagURIs = append(tagURIs, uri)
+			}
+		case strings.HasPrefix(line, "#"):
+		default:
+			lines = append(lines, line)
+		}
+	}
+	return lines, tagURIs
+}
+
+// uriAttribute extracts the quoted URI attribute of a playlist tag
+func uriAttribute(line string) string {
+	i := strings.Index(line, `URI="`)
+	if i < 0 {
+		return ""
+	}
+	value := line[i+len(`URI="`):]
+	if j := strings.Index(value, `"`); j >= 0 {
+		return value[:j]
+	}
+	return ""
+}
+
+// resolveKey resolves a playlist URI relative to the playlist key.
+// Absolute URLs point outside the bucket and are skipped.
+func resolveKey(playlistKey, uri string) (string, bool) {
+	if strings.Contains(uri, "://") || strings.HasPrefix(uri, "/") {
+		return "", false
+	}
+	if i := strings.IndexAny(uri, "?#"); i >= 0 {
+		uri = uri[:i]
+	}
+	return path.Join(path.Dir(playlistKey), uri), true
+}
+
+// RebasePlaylist prefixes the relative URIs of a playlist with base, so a copy
+// of it served from another prefix still points at the original objects.
+// Absolute URLs, such as EXT-X-KEY key server URIs, are kept.
+func RebasePlaylist(content, base string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#"):
+			if uri := uriAttribute(trimmed); uri != "" && isRelativeURI(uri) {
+				lines[i] = strings.Replace(line, `URI="`+uri+`"`, `URI="`+base+uri+`"`, 1)
+			}
+		case isRelativeURI(trimmed):
+			lines[i] = base + trimmed
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isRelativeURI reports whether a playlist URI is relative to the playlist
+func isRelativeURI(uri string) bool {
+	return !strings.Contains(uri, "://") && !strings.HasPrefix(uri, "/")
+}
+
+// sampleIndexes picks up to n indexes out of total, spread evenly from the first
+// to the last segment. A single sample checks the last segment.
+func sampleIndexes(total, n int) []int {
+	if total <= n {
+		indexes := make([]int, total)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes
+	}
+	if n == 1 {
+		return []int{total - 1}
+	}
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = i * (total - 1) / (n - 1)
+	}
+	return indexes
+}
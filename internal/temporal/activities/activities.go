@@ -3,9 +3,13 @@ package activities
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -18,8 +22,10 @@ import (
 	"github.com/tvoe/converter/internal/db"
 	"github.com/tvoe/converter/internal/domain"
 	"github.com/tvoe/converter/internal/drm"
+	"github.com/tvoe/converter/internal/events"
 	"github.com/tvoe/converter/internal/ffmpeg"
 	"github.com/tvoe/converter/internal/metrics"
+	"github.com/tvoe/converter/internal/publish"
 	"github.com/tvoe/converter/internal/storage/s3"
 )
 
@@ -29,9 +35,21 @@ type Activities struct {
 	jobRepo     *db.JobRepository
 	errorRepo   *db.ErrorRepository
 	artifactRepo *db.ArtifactRepository
+	stageRepo   *db.StageRepository
 	s3Client    *s3.Client
+	publisher   *publish.Publisher
 	logger      *zap.Logger
 	metrics     *metrics.Metrics
+	events      *events.Bus
+	ffmpegSlots chan struct{}
+	jobSlots    chan struct{}
+	admission   *Admission
+	gpus        *GPUPool
+	// host scopes workdir space reservations to this machine
+	host string
+
+	transcodersMu sync.Mutex
+	transcoders   map[string]Transcoder
 }
 
 // NewActivities creates a new activities instance
@@ -40,21 +58,42 @@ func NewActivities(
 	jobRepo *db.JobRepository,
 	errorRepo *db.ErrorRepository,
 	artifactRepo *db.ArtifactRepository,
+	stageRepo *db.StageRepository,
 	s3Client *s3.Client,
+	publisher *publish.Publisher,
 	logger *zap.Logger,
 	m *metrics.Metrics,
+	bus *events.Bus,
 ) *Activities {
 	return &Activities{
 		config:       cfg,
 		jobRepo:      jobRepo,
 		errorRepo:    errorRepo,
 		artifactRepo: artifactRepo,
+		stageRepo:    stageRepo,
 		s3Client:     s3Client,
+		publisher:    publisher,
 		logger:       logger,
 		metrics:      m,
+		events:       bus,
+		ffmpegSlots:  make(chan struct{}, cfg.Worker.MaxParallelFFmpeg),
+		jobSlots:     make(chan struct{}, cfg.Worker.MaxParallelJobs),
+		admission: NewAdmission(
+			int64(cfg.Worker.AdmissionMinFreeDiskGB)<<30,
+			int64(cfg.Worker.AdmissionMinFreeGPUMB)<<20,
+			m,
+		),
+		gpus: NewGPUPool(cfg.Worker.GPUDevices, m),
+		host: hostname(),
 	}
 }
 
+// hostname returns the host name of the worker, empty if it is unknown
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
+}
+
 // ActivityInput holds common input for activities
 type ActivityInput struct {
 	JobID uuid.UUID `json:"jobId"`
@@ -65,13 +104,45 @@ type MetadataOutput struct {
 	Metadata *domain.VideoMetadata `json:"metadata"`
 }
 
+// AcquireTenantSlot starts a queued job if fewer than SCHEDULER_TENANT_MAX_RUNNING
+// jobs of its tenant are RUNNING. It returns false if the job has to keep waiting.
+// Jobs without a tenant and dry runs are not limited.
+func (a *Activities) AcquireTenantSlot(ctx context.Context, input ActivityInput) (bool, error) {
+	limit := a.config.Scheduler.TenantMaxRunning
+	if limit <= 0 {
+		return true, nil
+	}
+
+	job, err := a.jobRepo.GetByID(ctx, input.JobID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get job: %w", err)
+	}
+	if job.Tenant == nil || job.DryRun {
+		return true, nil
+	}
+
+	started, err := a.jobRepo.StartForTenant(ctx, input.JobID, *job.Tenant, limit)
+	if err != nil {
+		return false, err
+	}
+	if !started {
+		a.logger.Debug("tenant is at its running job limit, job waits",
+			zap.String("jobId", input.JobID.String()),
+			zap.String("tenant", *job.Tenant),
+			zap.Int("limit", limit))
+	}
+	return started, nil
+}
+
 // ExtractMetadata extracts video metadata
-func (a *Activities) ExtractMetadata(ctx context.Context, input ActivityInput) (*MetadataOutput, error) {
+func (a *Activities) ExtractMetadata(ctx context.Context, input ActivityInput) (_ *MetadataOutput, err error) {
 	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "ExtractMetadata"))
 	startTime := time.Now()
 	defer func() {
 		a.metrics.RecordStageDuration(string(domain.StageMetadataExtraction), time.Since(startTime).Seconds())
 	}()
+	stageDone := a.startStage(ctx, input.JobID, domain.StageMetadataExtraction)
+	defer func() { stageDone(err) }()
 
 	// Update job status to RUNNING
 	if err := a.jobRepo.UpdateStatus(ctx, input.JobID, domain.JobStatusRunning); err != nil {
@@ -96,19 +167,38 @@ func (a *Activities) ExtractMetadata(ctx context.Context, input ActivityInput) (
 		return nil, fmt.Errorf("failed to create workspace: %w", err)
 	}
 
-	// Download source file with periodic heartbeat
-	inputPath := workspace.InputPath("source" + filepath.Ext(job.SourceKey))
-	stopHeartbeat := startPeriodicHeartbeat(ctx, 30*time.Second, "downloading source file")
-	err = a.s3Client.Download(ctx, job.SourceBucket, job.SourceKey, inputPath)
-	stopHeartbeat()
-	if err != nil {
-		return nil, a.recordError(ctx, input.JobID, domain.StageMetadataExtraction, domain.ErrCodeS3NotFound, err)
+	// Download source file with periodic heartbeat; HLS/DASH sources are read
+	// from their URL, checked again as its host may resolve differently now
+	inputPath := sourceInput(job, workspace)
+	hb := newHeartbeat(ctx)
+	if job.SourceURL != nil {
+		if err := ffmpeg.CheckStreamURL(ctx, *job.SourceURL, a.config.Input.StreamHosts); err != nil {
+			code := domain.ErrCodeNetworkError
+			if errors.Is(err, ffmpeg.ErrStreamNotAllowed) {
+				code = domain.ErrCodeSourceNotAllowed
+			}
+			return nil, a.recordError(ctx, input.JobID, domain.StageMetadataExtraction, code, err)
+		}
+	} else {
+		// A retried attempt continues the partial file of the previous one
+		if d := hb.Previous().Download; d != nil {
+			logger.Info("resuming source download", zap.Int64("bytes", d.Bytes), zap.Int64("size", d.Size))
+		}
+		stopHeartbeat := hb.KeepAlive(a.config.Temporal.HeartbeatInterval)
+		err = a.s3Client.DownloadResumable(ctx, job.SourceBucket, job.SourceKey, inputPath, hb.Previous().Download,
+			func(d s3.DownloadState) {
+				hb.Update(func(h *HeartbeatDetails) { h.Download = &d })
+			})
+		stopHeartbeat()
+		if err != nil {
+			return nil, a.recordError(ctx, input.JobID, domain.StageMetadataExtraction, s3.ErrorCode(err), err)
+		}
 	}
 
 	if err := a.updateProgress(ctx, input.JobID, domain.StageMetadataExtraction, 50); err != nil {
 		logger.Error("failed to update progress", zap.Error(err))
 	}
-	activity.RecordHeartbeat(ctx, "probing file")
+	hb.Update(func(*HeartbeatDetails) {})
 
 	// Probe file
 	prober := ffmpeg.NewProber(a.config.FFmpeg.FFprobePath)
@@ -117,10 +207,60 @@ func (a *Activities) ExtractMetadata(ctx context.Context, input ActivityInput) (
 		return nil, a.recordError(ctx, input.JobID, domain.StageMetadataExtraction, domain.ErrCodeFFprobeFailed, err)
 	}
 
+	for _, t := range metadata.SelectAudioTracks(job.Profile.AudioTracks) {
+		a.addWarning(ctx, input.JobID, domain.StageMetadataExtraction, domain.WarnCodeAudioTrackNotFound,
+			fmt.Sprintf("audio track %s selected by the profile not found in source", t))
+	}
+	metadata.ApplyAudioPolicy(job.Profile)
+	if !metadata.MarkAudioDescription(job.Profile.AudioTracks) {
+		a.addWarning(ctx, input.JobID, domain.StageMetadataExtraction, domain.WarnCodeAudioDescSkipped,
+			"audio track marked as description not found in source, audio description not signaled")
+	}
+
+	// Some fragmented MP4s and raw streams declare no duration: measure it by demuxing.
+	// Live HLS/DASH has none to measure and is rejected by validation.
+	if metadata.Duration <= 0 && !domain.IsAdaptiveContainer(metadata.Container) {
+		hb.Update(func(*HeartbeatDetails) {})
+		stopHeartbeat := hb.KeepAlive(a.config.Temporal.HeartbeatInterval)
+		duration, err := prober.DemuxDuration(ctx, inputPath)
+		stopHeartbeat()
+		if err != nil {
+			logger.Warn("failed to measure source duration", zap.Error(err))
+		} else {
+			metadata.Duration = duration
+			a.addWarning(ctx, input.JobID, domain.StageMetadataExtraction, domain.WarnCodeDurationEstimated,
+				fmt.Sprintf("source declares no duration, %s measured by demuxing", duration.Round(time.Millisecond)))
+		}
+	}
+
+	// A stream copy keeps the keyframes of the source, see CanPassthrough
+	if job.Profile.Passthrough {
+		interval, err := prober.KeyframeInterval(ctx, inputPath, metadata)
+		if err != nil {
+			logger.Warn("failed to probe keyframe interval", zap.Error(err))
+		}
+		metadata.KeyframeInterval = interval
+	}
+
+	// Everything downstream works on the trimmed segment only
+	if trim := job.Profile.Trim; trim != nil {
+		if metadata.Duration > 0 && trim.Offset() >= metadata.Duration {
+			return nil, a.recordError(ctx, input.JobID, domain.StageMetadataExtraction, domain.ErrCodeUnsupportedFormat,
+				fmt.Errorf("trim start %.3fs is beyond the end of the source (%s)", trim.Start, metadata.Duration))
+		}
+		metadata.SourceDuration = metadata.Duration
+		metadata.Duration = trim.Duration(metadata.Duration)
+	}
+
 	// Save metadata to file
 	metaJSON, _ := json.MarshalIndent(metadata, "", "  ")
 	os.WriteFile(workspace.MetaPath("metadata.json"), metaJSON, 0644)
 
+	// Persist metadata so it can be queried without fetching the artifact
+	if err := a.jobRepo.SetMetadata(ctx, input.JobID, metadata); err != nil {
+		logger.Warn("failed to store metadata", zap.Error(err))
+	}
+
 	if err := a.updateProgress(ctx, input.JobID, domain.StageMetadataExtraction, 100); err != nil {
 		logger.Error("failed to update progress", zap.Error(err))
 	}
@@ -142,48 +282,123 @@ type ValidationInput struct {
 }
 
 // ValidateInputs validates input files and resources
-func (a *Activities) ValidateInputs(ctx context.Context, input ValidationInput) error {
+func (a *Activities) ValidateInputs(ctx context.Context, input ValidationInput) (err error) {
 	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "ValidateInputs"))
 	startTime := time.Now()
 	defer func() {
 		a.metrics.RecordStageDuration(string(domain.StageValidation), time.Since(startTime).Seconds())
 	}()
+	stageDone := a.startStage(ctx, input.JobID, domain.StageValidation)
+	defer func() { stageDone(err) }()
 
 	if err := a.updateProgress(ctx, input.JobID, domain.StageValidation, 0); err != nil {
 		logger.Error("failed to update progress", zap.Error(err))
 	}
 
+	// Get the job to validate the source against its profile
+	job, err := a.jobRepo.GetByID(ctx, input.JobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+
+	policy := a.formatPolicy()
+
 	// Validate container
-	if !domain.IsContainerSupported(input.Metadata.Container) {
+	if !policy.IsContainerSupported(input.Metadata.Container) &&
+		!(job.Profile.IsAudioOnly() && policy.IsAudioContainerSupported(input.Metadata.Container)) {
 		return a.recordError(ctx, input.JobID, domain.StageValidation, domain.ErrCodeUnsupportedFormat,
 			fmt.Errorf("unsupported container: %s", input.Metadata.Container))
 	}
 
-	// Validate video codec
-	if !domain.IsVideoCodecSupported(input.Metadata.VideoCodec) {
+	// Validate video codec; audio-only profiles need an audio track instead
+	if job.Profile.IsAudioOnly() {
+		track := input.Metadata.MainAudioTrack()
+		if track == nil {
+			return a.recordError(ctx, input.JobID, domain.StageValidation, domain.ErrCodeUnsupportedFormat,
+				fmt.Errorf("audio-only profile needs a source with an audio track"))
+		}
+		if !policy.IsAudioCodecSupported(track.Codec) {
+			return a.recordError(ctx, input.JobID, domain.StageValidation, domain.ErrCodeUnsupportedFormat,
+				fmt.Errorf("unsupported audio codec: %s", track.Codec))
+		}
+	} else if !policy.IsVideoCodecSupported(input.Metadata.VideoCodec) {
 		return a.recordError(ctx, input.JobID, domain.StageValidation, domain.ErrCodeUnsupportedFormat,
 			fmt.Errorf("unsupported video codec: %s", input.Metadata.VideoCodec))
 	}
 
+	// Live HLS/DASH has no end, so it cannot be converted to VOD
+	if domain.IsAdaptiveContainer(input.Metadata.Container) && input.Metadata.Duration <= 0 {
+		return a.recordError(ctx, input.JobID, domain.StageValidation, domain.ErrCodeUnsupportedFormat,
+			fmt.Errorf("%s source has no fixed duration, live streams are not supported", input.Metadata.Container))
+	}
+
+	// Without a duration the job still converts, but progress, thumbnail spacing
+	// and the encode minutes budget can only be estimated
+	if input.Metadata.Duration <= 0 {
+		logger.Warn("source duration is unknown")
+		a.addWarning(ctx, input.JobID, domain.StageValidation, domain.WarnCodeDurationUnknown,
+			"source duration is unknown, progress and thumbnail spacing are estimated")
+	}
+
+	// Dolby Vision without a compatible base layer decodes to green/purple garbage
+	if !input.Metadata.HDR.HasCompatibleBaseLayer() {
+		return a.recordError(ctx, input.JobID, domain.StageValidation, domain.ErrCodeDolbyVisionUnsupported,
+			fmt.Errorf("dolby vision profile %d has no HDR10/SDR compatible base layer", input.Metadata.HDR.DolbyVisionProfile))
+	}
+
+	// Reject profiles that would create more encoding work than allowed
+	budget := domain.OutputBudget{
+		MaxRenditions:    a.config.Encoding.MaxRenditions,
+		MaxEncodeMinutes: a.config.Encoding.MaxEncodeMinutes,
+	}
+	if job.Profile.Budget != nil {
+		budget = budget.Tighten(*job.Profile.Budget)
+	}
+	qualities := domain.FilterQualitiesForResolution(job.Profile.Qualities, job.Profile.QualitiesCustom, input.Metadata.Height)
+	renditions := len(qualities) * len(a.enabledTiers())
+	if job.Profile.IsAudioOnly() {
+		renditions = len(job.Profile.AudioOnly.AudioBitrates())
+	} else if job.Profile.Hardsub != nil && slices.Contains(a.enabledTiers(), domain.TierLegacy) {
+		// The burned-in set encodes the H.264 qualities again
+		renditions += len(qualities)
+	}
+	if job.Profile.Mezzanine != nil {
+		renditions++
+	}
+	if err := budget.Check(renditions, input.Metadata.Duration); err != nil {
+		return a.recordError(ctx, input.JobID, domain.StageValidation, domain.ErrCodeBudgetExceeded,
+			fmt.Errorf("output budget exceeded: %w", err))
+	}
+
 	if err := a.updateProgress(ctx, input.JobID, domain.StageValidation, 50); err != nil {
 		logger.Error("failed to update progress", zap.Error(err))
 	}
 
-	// Check disk space
+	// Check disk space. Jobs admitted earlier may not have written their output yet,
+	// so the estimate is reserved against free space minus their outstanding reservations.
 	var stat syscall.Statfs_t
 	if err := syscall.Statfs(a.config.Worker.WorkdirRoot, &stat); err == nil {
-		freeSpace := stat.Bavail * uint64(stat.Bsize)
-		requiredSpace := uint64(input.Metadata.FileSize) * 5 // Estimate 5x source size needed
+		freeSpace := int64(stat.Bavail) * int64(stat.Bsize)
+		requiredSpace := requiredDiskSpace(input.Metadata)
 
-		if freeSpace < requiredSpace {
+		budget, reservedByOthers, err := a.diskBudget(ctx, input.JobID, freeSpace)
+		if err != nil {
+			return err
+		}
+		reserved, err := a.jobRepo.ReserveDisk(ctx, input.JobID, a.host, requiredSpace, budget)
+		if err != nil {
+			return fmt.Errorf("failed to reserve disk space: %w", err)
+		}
+		if !reserved {
 			return a.recordError(ctx, input.JobID, domain.StageValidation, domain.ErrCodeInsufficientDisk,
-				fmt.Errorf("insufficient disk space: %d bytes free, %d required", freeSpace, requiredSpace))
+				fmt.Errorf("insufficient disk space: %d bytes free, %d reserved by other jobs, %d required",
+					freeSpace, reservedByOthers, requiredSpace))
 		}
 	}
 
 	// Validate S3 access
 	if err := a.s3Client.Health(ctx); err != nil {
-		return a.recordError(ctx, input.JobID, domain.StageValidation, domain.ErrCodeS3AccessDenied, err)
+		return a.recordError(ctx, input.JobID, domain.StageValidation, s3.ErrorCode(err), err)
 	}
 
 	if err := a.updateProgress(ctx, input.JobID, domain.StageValidation, 100); err != nil {
@@ -207,39 +422,340 @@ type TranscodeOutput struct {
 	TierOutputPaths map[domain.EncodingTier]map[domain.Quality]string `json:"tierOutputPaths,omitempty"`
 	// EnabledTiers lists which tiers were encoded
 	EnabledTiers []domain.EncodingTier `json:"enabledTiers,omitempty"`
+	// MezzaninePath is the archival master, if requested; it is not packaged to HLS
+	MezzaninePath string `json:"mezzaninePath,omitempty"`
+	// AudioOutputs are the renditions of an audio-only profile by name
+	AudioOutputs map[string]string `json:"audioOutputs,omitempty"`
 }
 
-// Transcode transcodes video to target qualities
-func (a *Activities) Transcode(ctx context.Context, input TranscodeInput) (*TranscodeOutput, error) {
+// Transcode transcodes video to target qualities with the job's transcoder backend
+func (a *Activities) Transcode(ctx context.Context, input TranscodeInput) (_ *TranscodeOutput, err error) {
 	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "Transcode"))
 	startTime := time.Now()
 	defer func() {
 		a.metrics.RecordStageDuration(string(domain.StageTranscoding), time.Since(startTime).Seconds())
 	}()
+	stageDone := a.startStage(ctx, input.JobID, domain.StageTranscoding)
+	defer func() { stageDone(err) }()
 
 	if err := a.updateProgress(ctx, input.JobID, domain.StageTranscoding, 0); err != nil {
 		logger.Error("failed to update progress", zap.Error(err))
 	}
 
-	a.metrics.IncrementFFmpegProcesses()
-	defer a.metrics.DecrementFFmpegProcesses()
-
 	// Get job
 	job, err := a.jobRepo.GetByID(ctx, input.JobID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get job: %w", err)
 	}
 
+	transcoder, err := a.transcoder(ctx, job, input.Metadata)
+	if err != nil {
+		return nil, a.recordError(ctx, input.JobID, domain.StageTranscoding, domain.ErrCodeTranscoderUnavailable, err)
+	}
+
 	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, input.JobID)
-	inputPath := workspace.InputPath("source" + filepath.Ext(job.SourceKey))
+	hb := newHeartbeat(ctx)
+	output, err := transcoder.Transcode(ctx, &TranscodeRequest{
+		Job:       job,
+		Metadata:  input.Metadata,
+		Workspace: workspace,
+		InputPath: sourceInput(job, workspace),
+		Tiers:     a.enabledTiers(),
+		// Filter qualities based on source resolution
+		Qualities: domain.FilterQualitiesForResolution(job.Profile.Qualities, job.Profile.QualitiesCustom, input.Metadata.Height),
+		OnProgress: func(percent int) {
+			a.updateProgress(ctx, input.JobID, domain.StageTranscoding, percent)
+			hb.Update(func(d *HeartbeatDetails) { d.Percent = percent })
+		},
+		OnFrames: hb.frames,
+	})
+	if err != nil {
+		return nil, a.transcodeError(ctx, input.JobID, err)
+	}
+
+	if err := a.updateProgress(ctx, input.JobID, domain.StageTranscoding, 100); err != nil {
+		logger.Error("failed to update progress", zap.Error(err))
+	}
+
+	return output, nil
+}
+
+// TranscodePlan describes the renditions a job needs
+type TranscodePlan struct {
+	// Parallel means renditions are encoded by separate TranscodeRendition activities
+	Parallel  bool                  `json:"parallel"`
+	Tiers     []domain.EncodingTier `json:"tiers"`
+	Qualities []domain.Quality      `json:"qualities"`
+	Mezzanine bool                  `json:"mezzanine"`
+}
+
+// PlanTranscode decides which renditions to encode and whether to encode them in parallel
+func (a *Activities) PlanTranscode(ctx context.Context, input TranscodeInput) (*TranscodePlan, error) {
+	job, err := a.jobRepo.GetByID(ctx, input.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	if err := a.updateProgress(ctx, input.JobID, domain.StageTranscoding, 0); err != nil {
+		a.logger.Error("failed to update progress", zap.Error(err), zap.String("jobId", input.JobID.String()))
+	}
+
+	return a.transcodePlan(job, input.Metadata), nil
+}
+
+// transcodePlan returns the renditions of a job for a source
+func (a *Activities) transcodePlan(job *domain.Job, metadata *domain.VideoMetadata) *TranscodePlan {
+	return &TranscodePlan{
+		Parallel:  a.config.Encoding.ParallelRenditions,
+		Tiers:     a.enabledTiers(),
+		Qualities: domain.FilterQualitiesForResolution(job.Profile.Qualities, job.Profile.QualitiesCustom, metadata.Height),
+		Mezzanine: job.Profile.Mezzanine != nil,
+	}
+}
+
+// transcodeSpeedSamples is how many recent transcodes the encode time of a plan is estimated from
+const transcodeSpeedSamples = 50
 
-	// Filter qualities based on source resolution
-	qualities := domain.FilterQualitiesForResolution(job.Profile.Qualities, input.Metadata.Height)
+// PlanJob computes and stores the plan of a dry-run job: the renditions it
+// would encode, their estimated size and the expected encode time
+func (a *Activities) PlanJob(ctx context.Context, input TranscodeInput) (*domain.JobPlan, error) {
+	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "PlanJob"))
 
-	builder := ffmpeg.NewCommandBuilder(a.config.FFmpeg.BinaryPath, a.config.Worker.EnableGPU, &a.config.Encoding)
-	runner := ffmpeg.NewRunner(a.config.FFmpeg.BinaryPath, a.config.FFmpeg.ProcessTimeout)
+	job, err := a.jobRepo.GetByID(ctx, input.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
 
-	// Determine enabled tiers
+	transcodePlan := a.transcodePlan(job, input.Metadata)
+	plan := domain.NewJobPlan(transcodePlan.Tiers, transcodePlan.Qualities, job.Profile.QualitiesCustom, transcodePlan.Mezzanine, input.Metadata)
+	plan.Parallel = transcodePlan.Parallel
+	plan.RequiredDiskBytes = requiredDiskSpace(input.Metadata)
+
+	// Without history the encode time stays unknown rather than failing the plan
+	speed, err := a.stageRepo.TranscodeSpeed(ctx, transcodeSpeedSamples)
+	if err != nil {
+		logger.Warn("failed to get transcode speed", zap.Error(err))
+	}
+	plan.EstimateEncodeTime(speed, input.Metadata.Duration)
+
+	if err := a.jobRepo.SetPlan(ctx, input.JobID, plan); err != nil {
+		return nil, err
+	}
+
+	logger.Info("job planned",
+		zap.Int("renditions", len(plan.Renditions)),
+		zap.Int64("estimatedBytes", plan.EstimatedBytes),
+		zap.Int64("estimatedEncodeSeconds", plan.EstimatedEncodeSeconds),
+	)
+	return plan, nil
+}
+
+// RenditionInput holds input for encoding a single rendition
+type RenditionInput struct {
+	JobID    uuid.UUID             `json:"jobId"`
+	Metadata *domain.VideoMetadata `json:"metadata"`
+	Tier     domain.EncodingTier   `json:"tier,omitempty"`
+	Quality  domain.Quality        `json:"quality,omitempty"`
+	// Mezzanine encodes the archival master instead of a (tier, quality) rendition
+	Mezzanine bool `json:"mezzanine,omitempty"`
+}
+
+// RenditionOutput holds the encoded rendition
+type RenditionOutput struct {
+	Tier       domain.EncodingTier `json:"tier,omitempty"`
+	Quality    domain.Quality      `json:"quality,omitempty"`
+	OutputPath string              `json:"outputPath"`
+}
+
+// TranscodeRendition encodes one rendition. Renditions of a job run as separate
+// activities so they can be picked up by different workers sharing WORKDIR_ROOT.
+func (a *Activities) TranscodeRendition(ctx context.Context, input RenditionInput) (*RenditionOutput, error) {
+	startTime := time.Now()
+	defer func() {
+		a.metrics.RecordStageDuration(string(domain.StageTranscoding), time.Since(startTime).Seconds())
+	}()
+
+	job, err := a.jobRepo.GetByID(ctx, input.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	transcoder, err := a.transcoder(ctx, job, input.Metadata)
+	if err != nil {
+		return nil, a.recordError(ctx, input.JobID, domain.StageTranscoding, domain.ErrCodeTranscoderUnavailable, err)
+	}
+
+	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, input.JobID)
+	hb := newHeartbeat(ctx)
+	outputPath, err := transcoder.TranscodeRendition(ctx, &RenditionRequest{
+		Job:       job,
+		Metadata:  input.Metadata,
+		Workspace: workspace,
+		InputPath: sourceInput(job, workspace),
+		Tier:      input.Tier,
+		Quality:   input.Quality,
+		Mezzanine: input.Mezzanine,
+		OnProgress: func(percent int) {
+			hb.Update(func(d *HeartbeatDetails) { d.Percent = percent })
+		},
+		OnFrames: hb.frames,
+	})
+	if err != nil {
+		return nil, a.transcodeError(ctx, input.JobID, err)
+	}
+
+	return &RenditionOutput{Tier: input.Tier, Quality: input.Quality, OutputPath: outputPath}, nil
+}
+
+// ProgressInput holds a stage progress update
+type ProgressInput struct {
+	JobID    uuid.UUID    `json:"jobId"`
+	Stage    domain.Stage `json:"stage"`
+	Progress int          `json:"progress"`
+}
+
+// ReportProgress stores stage progress computed by the workflow
+func (a *Activities) ReportProgress(ctx context.Context, input ProgressInput) error {
+	return a.updateProgress(ctx, input.JobID, input.Stage, input.Progress)
+}
+
+// GetJobStatus returns the current status of a job; a deleted job has an empty status
+func (a *Activities) GetJobStatus(ctx context.Context, input ActivityInput) (domain.JobStatus, error) {
+	job, err := a.jobRepo.GetByID(ctx, input.JobID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get job: %w", err)
+	}
+	return job.Status, nil
+}
+
+// ClaimSeriesJobInput holds input of ClaimSeriesJob
+type ClaimSeriesJobInput struct {
+	JobID      uuid.UUID `json:"jobId"`
+	WorkflowID string    `json:"workflowId"`
+}
+
+// ClaimSeriesJob sets the workflow ID of a queued series job before the series
+// workflow starts it, so the job counts as in flight only from then on. It
+// returns false for a job canceled or deleted while waiting for its turn.
+func (a *Activities) ClaimSeriesJob(ctx context.Context, input ClaimSeriesJobInput) (bool, error) {
+	return a.jobRepo.ClaimSeriesJob(ctx, input.JobID, input.WorkflowID)
+}
+
+// transcodeBuilder returns a command builder for the source; HDR sources the
+// hardware backend cannot encode with their metadata are encoded on CPU.
+// Lossy conversions of the source are recorded as job warnings.
+func (a *Activities) transcodeBuilder(ctx context.Context, jobID uuid.UUID, metadata *domain.VideoMetadata, logger *zap.Logger) *ffmpeg.CommandBuilder {
+	builder := a.newBuilder()
+
+	for _, track := range metadata.AudioTracks {
+		switch {
+		case track.Passthrough:
+			a.addWarning(ctx, jobID, domain.StageTranscoding, domain.WarnCodeAudioDownmixed,
+				fmt.Sprintf("audio track %d copied to the modern tier, downmixed from %d channels to stereo in the legacy tier", track.Index, track.Channels))
+		case track.Channels > 2:
+			a.addWarning(ctx, jobID, domain.StageTranscoding, domain.WarnCodeAudioDownmixed,
+				fmt.Sprintf("audio track %d downmixed from %d channels to stereo", track.Index, track.Channels))
+		}
+	}
+
+	if hdr := metadata.HDR; hdr != nil {
+		logger.Info("HDR source detected",
+			zap.String("format", string(hdr.Format)),
+			zap.String("transfer", hdr.ColorTransfer))
+
+		if reason := a.hdrCPUReason(builder, hdr); reason != "" {
+			logger.Warn("HDR source, falling back to CPU encoding", zap.String("reason", reason))
+			builder = builder.WithoutGPU()
+		}
+		if hdr.HasDolbyVision() && (!a.config.Encoding.PreserveDolbyVision || !hdr.CanPreserveDolbyVision()) {
+			logger.Warn("Dolby Vision RPU will be stripped, base layer is kept",
+				zap.Int("dvProfile", hdr.DolbyVisionProfile))
+			a.addWarning(ctx, jobID, domain.StageTranscoding, domain.WarnCodeHDRMetadataStripped,
+				fmt.Sprintf("Dolby Vision profile %d RPU stripped, base layer is kept", hdr.DolbyVisionProfile))
+		}
+		if a.config.Encoding.EnableLegacyTier {
+			logger.Warn("legacy tier will be tonemapped to SDR")
+		}
+	}
+
+	return builder
+}
+
+// hdrCPUReason returns why the HDR source is encoded with libx265 instead of
+// the hardware backend of builder, empty when the backend keeps it
+func (a *Activities) hdrCPUReason(builder *ffmpeg.CommandBuilder, hdr *domain.HDRInfo) string {
+	switch {
+	case !builder.UsesGPU():
+		return ""
+	case !builder.KeepsHDR():
+		return "hardware encoder does not keep HDR10 metadata"
+	case hdr.HDR10Plus && a.config.FFmpeg.HDR10PlusToolPath != "":
+		return "HDR10+ dynamic metadata is written by libx265 only"
+	case hdr.HasDolbyVision() && a.config.Encoding.PreserveDolbyVision && hdr.CanPreserveDolbyVision():
+		return "Dolby Vision RPU is written by libx265 only"
+	}
+	return ""
+}
+
+// hdr10PlusMetadata returns the HDR10+ metadata of the source for the H.265
+// renditions, extracted with hdr10plus_tool once per job into the workspace.
+// Without HDR10PLUS_TOOL_PATH, for sources other than HEVC and when renditions
+// do not keep every frame of the source, the metadata is stripped with a
+// warning and the path is empty.
+func (a *Activities) hdr10PlusMetadata(ctx context.Context, job *domain.Job, workspace *ffmpeg.Workspace, inputPath string, metadata *domain.VideoMetadata, logger *zap.Logger) string {
+	if metadata.HDR == nil || !metadata.HDR.HDR10Plus {
+		return ""
+	}
+
+	var reason string
+	switch trim := job.Profile.Trim; {
+	case a.config.FFmpeg.HDR10PlusToolPath == "":
+		reason = "HDR10PLUS_TOOL_PATH is not set"
+	case metadata.VideoCodec != "hevc":
+		reason = "source is not HEVC"
+	case trim != nil && (trim.Start > 0 || trim.End > 0):
+		reason = "source is trimmed"
+	case job.Profile.Algorithm.ConvertedFrameRate(metadata) > 0:
+		reason = "frame rate is converted"
+	}
+
+	path := workspace.MetaPath("hdr10plus.json")
+	if reason == "" {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+
+		// The whole source is demuxed, which reports no progress
+		hb := newHeartbeat(ctx)
+		hb.Update(func(d *HeartbeatDetails) { *d = hb.Previous() })
+		stopHeartbeat := hb.KeepAlive(a.config.Temporal.HeartbeatInterval)
+		// Renditions encoded in parallel may extract at the same time
+		tmpPath := path + "." + uuid.NewString()
+		err := ffmpeg.ExtractHDR10Plus(ctx, a.config.FFmpeg.BinaryPath, a.config.FFmpeg.HDR10PlusToolPath,
+			inputPath, metadata, tmpPath)
+		stopHeartbeat()
+		if err == nil {
+			err = os.Rename(tmpPath, path)
+		}
+		if err == nil {
+			logger.Info("HDR10+ dynamic metadata extracted")
+			return path
+		}
+		os.Remove(tmpPath)
+		reason = err.Error()
+	}
+
+	logger.Warn("HDR10+ dynamic metadata will be stripped, static HDR10 metadata is kept", zap.String("reason", reason))
+	a.addWarning(ctx, job.ID, domain.StageTranscoding, domain.WarnCodeHDRMetadataStripped,
+		"HDR10+ dynamic metadata stripped, static HDR10 metadata is kept: "+reason)
+	return ""
+}
+
+// enabledTiers returns the encoding tiers enabled in config
+func (a *Activities) enabledTiers() []domain.EncodingTier {
 	var enabledTiers []domain.EncodingTier
 	if a.config.Encoding.EnableLegacyTier {
 		enabledTiers = append(enabledTiers, domain.TierLegacy)
@@ -252,94 +768,335 @@ func (a *Activities) Transcode(ctx context.Context, input TranscodeInput) (*Tran
 	if len(enabledTiers) == 0 {
 		enabledTiers = []domain.EncodingTier{domain.TierLegacy}
 	}
+	return enabledTiers
+}
 
-	logger.Info("multi-tier transcoding",
-		zap.Int("tiers", len(enabledTiers)),
-		zap.Int("qualities", len(qualities)),
-		zap.Strings("enabledTiers", func() []string {
-			s := make([]string, len(enabledTiers))
-			for i, t := range enabledTiers {
-				s[i] = string(t)
-			}
-			return s
-		}()))
+// loadCheckpoint loads the completed renditions of a previous attempt. Without
+// a readable checkpoint every rendition is encoded.
+func (a *Activities) loadCheckpoint(workspace *ffmpeg.Workspace, logger *zap.Logger) *ffmpeg.TranscodeCheckpoint {
+	checkpoint, err := ffmpeg.LoadTranscodeCheckpoint(workspace.TranscodeCheckpointPath())
+	if err != nil {
+		logger.Warn("failed to load transcode checkpoint, encoding all renditions", zap.Error(err))
+		return nil
+	}
+	return checkpoint
+}
 
-	tierOutputPaths := make(map[domain.EncodingTier]map[domain.Quality]string)
-	outputPaths := make(map[domain.Quality]string) // Legacy compatibility
+// recordCheckpoint marks a validated rendition as done; a failure only costs a re-encode on retry
+func (a *Activities) recordCheckpoint(jobID uuid.UUID, checkpoint *ffmpeg.TranscodeCheckpoint, rendition, path string) {
+	if err := checkpoint.Record(rendition, path); err != nil {
+		a.logger.Warn("failed to record transcode checkpoint",
+			zap.String("jobId", jobID.String()),
+			zap.String("rendition", rendition),
+			zap.Error(err))
+	}
+}
 
-	totalTasks := len(enabledTiers) * len(qualities)
-	currentTask := 0
+// transcodeQuality encodes one (tier, quality) rendition into tierDir and returns
+// its path. A rendition completed by a previous attempt is reused.
+func (a *Activities) transcodeQuality(
+	ctx context.Context,
+	jobID uuid.UUID,
+	job *domain.Job,
+	metadata *domain.VideoMetadata,
+	inputPath string,
+	tierDir string,
+	tier domain.EncodingTier,
+	quality domain.Quality,
+	builder *ffmpeg.CommandBuilder,
+	runner *ffmpeg.Runner,
+	checkpoint *ffmpeg.TranscodeCheckpoint,
+	onProgress func(percent int),
+	onFrames func(rendition string, frames int64),
+) (string, error) {
+	rendition := ffmpeg.RenditionKey(string(tier), string(quality))
+	if path, ok := checkpoint.Lookup(rendition); ok {
+		a.logger.Info("rendition already transcoded, skipping",
+			zap.String("jobId", jobID.String()),
+			zap.String("rendition", rendition))
+		onProgress(100)
+		return path, nil
+	}
+
+	progressFn := func(progress ffmpeg.Progress) {
+		onProgress(ffmpeg.CalculateProgress(progress.OutTime, metadata.Duration))
+		onFrames(rendition, progress.Frame)
+	}
+
+	if job.Profile.CanPassthrough(metadata, tier, quality, a.segmentDuration(job)) {
+		if path, ok := a.remuxQuality(ctx, jobID, metadata, inputPath, tierDir, tier, quality, builder, runner, checkpoint, progressFn); ok {
+			return path, nil
+		}
+	}
 
-	for _, tier := range enabledTiers {
-		tierConfig := domain.GetTierConfig(tier)
-		tierDir := filepath.Join(workspace.Paths().Transcoded, string(tier))
+	cmd, err := a.runEncode(ctx, jobID, rendition, builder, runner,
+		func(b *ffmpeg.CommandBuilder) *ffmpeg.TranscodeCommand {
+			return b.BuildTranscodeCommandForTier(inputPath, tierDir, quality, metadata, job.Profile, tier)
+		},
+		progressFn)
+	if err != nil {
+		return "", a.recordError(ctx, jobID, domain.StageTranscoding, encodeErrorCode(err),
+			fmt.Errorf("tier=%s quality=%s: %w", tier, quality, err))
+	}
 
-		// Create tier directory
-		if err := os.MkdirAll(tierDir, 0755); err != nil {
-			return nil, fmt.Errorf("failed to create tier directory: %w", err)
+	if err := ffmpeg.ValidateOutput(cmd.OutputPath); err != nil {
+		return "", a.recordError(ctx, jobID, domain.StageTranscoding, domain.ErrCodeFFmpegFailed, err)
+	}
+	a.recordCheckpoint(jobID, checkpoint, rendition, cmd.OutputPath)
+
+	return cmd.OutputPath, nil
+}
+
+// remuxQuality copies the source video into a rendition it already complies
+// with. It reports false if the copy failed, for the rendition to be encoded.
+func (a *Activities) remuxQuality(
+	ctx context.Context,
+	jobID uuid.UUID,
+	metadata *domain.VideoMetadata,
+	inputPath string,
+	tierDir string,
+	tier domain.EncodingTier,
+	quality domain.Quality,
+	builder *ffmpeg.CommandBuilder,
+	runner *ffmpeg.Runner,
+	checkpoint *ffmpeg.TranscodeCheckpoint,
+	progressFn ffmpeg.ProgressCallback,
+) (string, bool) {
+	rendition := ffmpeg.RenditionKey(string(tier), string(quality))
+	logger := a.logger.With(zap.String("jobId", jobID.String()), zap.String("rendition", rendition))
+	logger.Info("source complies with the rendition, copying video")
+
+	cmd := builder.BuildRemuxCommandForTier(inputPath, tierDir, quality, metadata, tier)
+	err := runner.Run(ctx, cmd.Args, progressFn)
+	if err == nil {
+		err = ffmpeg.ValidateOutput(cmd.OutputPath)
+	}
+	if err != nil {
+		logger.Warn("video copy failed, encoding rendition", zap.Error(err))
+		return "", false
+	}
+
+	a.recordCheckpoint(jobID, checkpoint, rendition, cmd.OutputPath)
+	return cmd.OutputPath, true
+}
+
+// encodeErrorCode is the error code of a failed encode: CORRUPTED_FILE when
+// ffmpeg could not read the input, FFMPEG_FAILED otherwise
+func encodeErrorCode(err error) string {
+	if ffmpeg.IsInputError(err) {
+		return domain.ErrCodeCorruptedFile
+	}
+	return domain.ErrCodeFFmpegFailed
+}
+
+// runEncode runs the encode built by build. A GPU encode that fails because of
+// the GPU or its driver is rebuilt for the CPU at once. While it fails with
+// encoder errors it is repeated until it failed ENCODING_SAFE_RETRY_AFTER
+// times, then built once more with safe settings; an output encoded that way
+// or after a GPU failure is flagged as a job warning. It returns the command
+// that succeeded.
+func (a *Activities) runEncode(
+	ctx context.Context,
+	jobID uuid.UUID,
+	rendition string,
+	builder *ffmpeg.CommandBuilder,
+	runner *ffmpeg.Runner,
+	build func(b *ffmpeg.CommandBuilder) *ffmpeg.TranscodeCommand,
+	progressFn ffmpeg.ProgressCallback,
+) (*ffmpeg.TranscodeCommand, error) {
+	logger := a.logger.With(zap.String("jobId", jobID.String()), zap.String("rendition", rendition))
+	retryAfter := a.config.Encoding.SafeRetryAfter
+
+	cmd := build(builder)
+	err := runner.Run(ctx, cmd.Args, progressFn)
+	if err != nil && builder.UsesGPU() && ffmpeg.IsGPUError(err) {
+		logger.Warn("GPU failed, retrying rendition on the CPU", zap.Error(err))
+		builder = builder.WithoutGPU()
+		cmd = build(builder)
+		if err = runner.Run(ctx, cmd.Args, progressFn); err == nil {
+			a.metrics.IncrementGPUFallbacks()
+			a.addWarning(ctx, jobID, domain.StageTranscoding, domain.WarnCodeGPUFallback,
+				fmt.Sprintf("rendition %s encoded on the CPU after a GPU failure", rendition))
 		}
+	}
+	for failures := 1; err != nil && ffmpeg.IsEncoderError(err) && failures < retryAfter; failures++ {
+		logger.Warn("encoder failed, retrying rendition", zap.Int("failures", failures), zap.Error(err))
+		err = runner.Run(ctx, cmd.Args, progressFn)
+	}
+	if err == nil || !ffmpeg.IsEncoderError(err) || retryAfter == 0 {
+		return cmd, err
+	}
 
-		tierOutputPaths[tier] = make(map[domain.Quality]string)
+	logger.Warn("encoder failed, retrying rendition with safe settings", zap.Int("failures", retryAfter), zap.Error(err))
+	cmd = build(builder.WithSafeSettings())
+	if err := runner.Run(ctx, cmd.Args, progressFn); err != nil {
+		return nil, err
+	}
 
-		for _, quality := range qualities {
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			default:
-			}
+	a.metrics.IncrementDegradedEncodes()
+	a.addWarning(ctx, jobID, domain.StageTranscoding, domain.WarnCodeEncodeDegraded,
+		fmt.Sprintf("rendition %s encoded with safe settings (CPU, fast preset, 8-bit, no B-frame references) after %d encoder failures",
+			rendition, retryAfter))
+	return cmd, nil
+}
 
-			logger.Info("transcoding",
-				zap.String("tier", string(tier)),
-				zap.String("quality", string(quality)),
-				zap.String("videoCodec", string(tierConfig.VideoCodec)))
+// transcodeMezzanine encodes the archival master requested by the profile
+func (a *Activities) transcodeMezzanine(
+	ctx context.Context,
+	jobID uuid.UUID,
+	job *domain.Job,
+	metadata *domain.VideoMetadata,
+	inputPath string,
+	workspace *ffmpeg.Workspace,
+	builder *ffmpeg.CommandBuilder,
+	runner *ffmpeg.Runner,
+	checkpoint *ffmpeg.TranscodeCheckpoint,
+	onProgress func(percent int),
+	onFrames func(rendition string, frames int64),
+	logger *zap.Logger,
+) (string, error) {
+	if path, ok := checkpoint.Lookup(ffmpeg.MezzanineRendition); ok {
+		logger.Info("mezzanine already transcoded, skipping")
+		onProgress(100)
+		return path, nil
+	}
 
-			cmd := builder.BuildTranscodeCommandForTier(inputPath, tierDir, quality, input.Metadata, job.Profile, tier)
+	cmd, err := builder.BuildMezzanineCommand(inputPath, workspace.Paths().Mezzanine, metadata, *job.Profile.Mezzanine)
+	if err != nil {
+		return "", a.recordError(ctx, jobID, domain.StageTranscoding, domain.ErrCodeUnsupportedFormat, err)
+	}
 
-			err := runner.Run(ctx, cmd.Args, func(progress ffmpeg.Progress) {
-				percent := ffmpeg.CalculateProgress(progress.OutTime, input.Metadata.Duration)
-				overallPercent := (currentTask*100 + percent) / totalTasks
-				a.updateProgress(ctx, input.JobID, domain.StageTranscoding, overallPercent)
-				activity.RecordHeartbeat(ctx, overallPercent)
-			})
+	if err := os.MkdirAll(workspace.Paths().Mezzanine, 0755); err != nil {
+		return "", fmt.Errorf("failed to create mezzanine directory: %w", err)
+	}
 
-			if err != nil {
-				return nil, a.recordError(ctx, input.JobID, domain.StageTranscoding, domain.ErrCodeFFmpegFailed,
-					fmt.Errorf("tier=%s quality=%s: %w", tier, quality, err))
-			}
+	logger.Info("transcoding mezzanine", zap.String("codec", string(job.Profile.Mezzanine.Codec)))
 
-			if err := ffmpeg.ValidateOutput(cmd.OutputPath); err != nil {
-				return nil, a.recordError(ctx, input.JobID, domain.StageTranscoding, domain.ErrCodeFFmpegFailed, err)
-			}
+	err = runner.Run(ctx, cmd.Args, func(progress ffmpeg.Progress) {
+		onProgress(ffmpeg.CalculateProgress(progress.OutTime, metadata.Duration))
+		onFrames(ffmpeg.MezzanineRendition, progress.Frame)
+	})
+	if err != nil {
+		return "", a.recordError(ctx, jobID, domain.StageTranscoding, encodeErrorCode(err),
+			fmt.Errorf("mezzanine: %w", err))
+	}
 
-			tierOutputPaths[tier][quality] = cmd.OutputPath
+	if err := ffmpeg.ValidateOutput(cmd.OutputPath); err != nil {
+		return "", a.recordError(ctx, jobID, domain.StageTranscoding, domain.ErrCodeFFmpegFailed, err)
+	}
+	a.recordCheckpoint(jobID, checkpoint, ffmpeg.MezzanineRendition, cmd.OutputPath)
 
-			// For backward compatibility, use legacy tier paths as main output
-			if tier == domain.TierLegacy {
-				outputPaths[quality] = cmd.OutputPath
-			}
+	return cmd.OutputPath, nil
+}
 
-			currentTask++
-			logger.Info("quality transcoded",
-				zap.String("tier", string(tier)),
-				zap.String("quality", string(quality)),
-				zap.String("output", cmd.OutputPath))
+// transcodeTierSinglePass encodes all qualities of a tier with one ffmpeg invocation.
+// Qualities completed by a previous attempt are reused and left out of the command.
+func (a *Activities) transcodeTierSinglePass(
+	ctx context.Context,
+	job *domain.Job,
+	metadata *domain.VideoMetadata,
+	inputPath string,
+	tierDir string,
+	tier domain.EncodingTier,
+	qualities []domain.Quality,
+	builder *ffmpeg.CommandBuilder,
+	runner *ffmpeg.Runner,
+	checkpoint *ffmpeg.TranscodeCheckpoint,
+	currentTask int,
+	totalTasks int,
+	onProgress func(percent int),
+	onFrames func(rendition string, frames int64),
+	logger *zap.Logger,
+) (map[domain.Quality]string, error) {
+	paths := make(map[domain.Quality]string, len(qualities))
+	var remaining []domain.Quality
+	for _, quality := range qualities {
+		if path, ok := checkpoint.Lookup(ffmpeg.RenditionKey(string(tier), string(quality))); ok {
+			paths[quality] = path
+			continue
 		}
+		remaining = append(remaining, quality)
+	}
+	if len(remaining) == 0 {
+		logger.Info("tier already transcoded, skipping", zap.String("tier", string(tier)))
+		return paths, nil
 	}
+	currentTask += len(paths)
 
-	// If only modern tier is enabled, use it as main output
-	if len(outputPaths) == 0 && len(tierOutputPaths[domain.TierModern]) > 0 {
-		outputPaths = tierOutputPaths[domain.TierModern]
+	// Renditions the source complies with are copied on their own rather than
+	// encoded by the shared process
+	var encode []domain.Quality
+	for _, quality := range remaining {
+		if !job.Profile.CanPassthrough(metadata, tier, quality, a.segmentDuration(job)) {
+			encode = append(encode, quality)
+			continue
+		}
+		task := currentTask
+		path, err := a.transcodeQuality(ctx, job.ID, job, metadata, inputPath, tierDir, tier, quality,
+			builder, runner, checkpoint, func(percent int) {
+				onProgress((task*100 + percent) / totalTasks)
+			}, onFrames)
+		if err != nil {
+			return nil, err
+		}
+		paths[quality] = path
+		currentTask++
+	}
+	remaining = encode
+	if len(remaining) == 0 {
+		return paths, nil
 	}
 
-	if err := a.updateProgress(ctx, input.JobID, domain.StageTranscoding, 100); err != nil {
-		logger.Error("failed to update progress", zap.Error(err))
+	logger.Info("single-pass transcoding",
+		zap.String("tier", string(tier)),
+		zap.Int("qualities", len(remaining)),
+		zap.Int("reused", len(paths)))
+
+	cmd := builder.BuildMultiOutputCommandForTier(inputPath, tierDir, remaining, metadata, job.Profile, tier)
+
+	// One process covers len(qualities) tasks, so its progress advances all of them at once
+	err := runner.Run(ctx, cmd.Args, func(progress ffmpeg.Progress) {
+		percent := ffmpeg.CalculateProgress(progress.OutTime, metadata.Duration)
+		onProgress((currentTask*100 + percent*len(remaining)) / totalTasks)
+		onFrames(string(tier), progress.Frame)
+	})
+	if err != nil {
+		// One process encodes all qualities, so the failing rendition is unknown:
+		// encode them one by one, each with its own retries and GPU fallback
+		if ffmpeg.IsEncoderError(err) && (a.config.Encoding.SafeRetryAfter > 0 || ffmpeg.IsGPUError(err)) {
+			logger.Warn("single-pass encode failed, encoding qualities separately",
+				zap.String("tier", string(tier)), zap.Error(err))
+			for i, quality := range remaining {
+				task := currentTask + i
+				path, err := a.transcodeQuality(ctx, job.ID, job, metadata, inputPath, tierDir, tier, quality,
+					builder, runner, checkpoint, func(percent int) {
+						onProgress((task*100 + percent) / totalTasks)
+					}, onFrames)
+				if err != nil {
+					return nil, err
+				}
+				paths[quality] = path
+			}
+			return paths, nil
+		}
+		return nil, a.recordError(ctx, job.ID, domain.StageTranscoding, encodeErrorCode(err),
+			fmt.Errorf("tier=%s single-pass: %w", tier, err))
 	}
 
-	return &TranscodeOutput{
-		OutputPaths:     outputPaths,
-		TierOutputPaths: tierOutputPaths,
-		EnabledTiers:    enabledTiers,
-	}, nil
+	for quality, outputPath := range cmd.OutputPaths {
+		if err := ffmpeg.ValidateOutput(outputPath); err != nil {
+			return nil, a.recordError(ctx, job.ID, domain.StageTranscoding, domain.ErrCodeFFmpegFailed,
+				fmt.Errorf("quality=%s: %w", quality, err))
+		}
+		a.recordCheckpoint(job.ID, checkpoint, ffmpeg.RenditionKey(string(tier), string(quality)), outputPath)
+		paths[quality] = outputPath
+	}
+
+	logger.Info("tier transcoded",
+		zap.String("tier", string(tier)),
+		zap.Int("qualities", len(paths)))
+
+	return paths, nil
 }
 
 // SubtitlesInput holds subtitles extraction input
@@ -355,75 +1112,240 @@ type SubtitlesOutput struct {
 }
 
 // ExtractSubtitles extracts subtitles from video
-func (a *Activities) ExtractSubtitles(ctx context.Context, input SubtitlesInput) (*SubtitlesOutput, error) {
+func (a *Activities) ExtractSubtitles(ctx context.Context, input SubtitlesInput) (_ *SubtitlesOutput, err error) {
 	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "ExtractSubtitles"))
 	startTime := time.Now()
 	defer func() {
 		a.metrics.RecordStageDuration(string(domain.StageSubtitlesExtraction), time.Since(startTime).Seconds())
 	}()
+	stageDone := a.startStage(ctx, input.JobID, domain.StageSubtitlesExtraction)
+	defer func() { stageDone(err) }()
 
 	if err := a.updateProgress(ctx, input.JobID, domain.StageSubtitlesExtraction, 0); err != nil {
 		logger.Error("failed to update progress", zap.Error(err))
 	}
 
-	if len(input.Metadata.SubtitleTracks) == 0 {
-		logger.Info("no subtitles to extract")
-		a.updateProgress(ctx, input.JobID, domain.StageSubtitlesExtraction, 100)
-		return &SubtitlesOutput{SubtitlePaths: make(map[string]string)}, nil
-	}
-
 	job, err := a.jobRepo.GetByID(ctx, input.JobID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get job: %w", err)
 	}
 
+	if len(input.Metadata.SubtitleTracks) == 0 && len(job.Profile.ExternalSubtitles) == 0 {
+		logger.Info("no subtitles to extract")
+		a.updateProgress(ctx, input.JobID, domain.StageSubtitlesExtraction, 100)
+		return &SubtitlesOutput{SubtitlePaths: make(map[string]string)}, nil
+	}
+
 	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, input.JobID)
-	inputPath := workspace.InputPath("source" + filepath.Ext(job.SourceKey))
+	inputPath := sourceInput(job, workspace)
 
-	builder := ffmpeg.NewCommandBuilder(a.config.FFmpeg.BinaryPath, a.config.Worker.EnableGPU, &a.config.Encoding)
-	runner := ffmpeg.NewRunner(a.config.FFmpeg.BinaryPath, a.config.FFmpeg.ProcessTimeout)
+	builder := a.newBuilder().WithTrim(job.Profile.Trim)
+	runner := a.newRunner()
 
 	subtitlePaths := make(map[string]string)
-	totalTracks := len(input.Metadata.SubtitleTracks)
+	totalTracks := len(input.Metadata.SubtitleTracks) + len(job.Profile.ExternalSubtitles)
 
 	for i, track := range input.Metadata.SubtitleTracks {
-		lang := track.Language
-		if lang == "" || lang == "und" {
-			lang = fmt.Sprintf("track%d", track.Index)
-		}
+		lang := subtitleName(track)
 
 		outputPath := workspace.SubtitlePath(lang)
-		cmd := builder.BuildSubtitleExtractCommand(inputPath, outputPath, track.Index)
-
-		if err := runner.Run(ctx, cmd.Args, nil); err != nil {
-			logger.Warn("failed to extract subtitle", zap.String("language", lang), zap.Error(err))
-			continue
-		}
-
-		// Shift timestamps if intro was added
-		if input.IntroDuration > 0 {
-			if err := shiftVTTTimestamps(outputPath, input.IntroDuration); err != nil {
-				logger.Warn("failed to shift subtitle timestamps", zap.Error(err))
+		if track.IsBitmap() {
+			// Image-based tracks of Blu-ray and DVD sources are read by OCR
+			if len(a.config.FFmpeg.SubtitleOCRCommand) == 0 {
+				logger.Info("skipping bitmap subtitle, no OCR command configured", zap.String("language", lang))
+				a.addWarning(ctx, input.JobID, domain.StageSubtitlesExtraction, domain.WarnCodeSubtitleSkipped,
+					fmt.Sprintf("subtitle track %d (%s) skipped: %s needs OCR, which is not configured", track.Index, lang, track.Codec))
+				continue
+			}
+			if err := a.ocrSubtitle(ctx, builder, runner, workspace, inputPath, outputPath, track, lang); err != nil {
+				logger.Warn("failed to OCR subtitle", zap.String("language", lang), zap.Error(err))
+				a.addWarning(ctx, input.JobID, domain.StageSubtitlesExtraction, domain.WarnCodeSubtitleSkipped,
+					fmt.Sprintf("subtitle track %d (%s) skipped: OCR failed", track.Index, lang))
+				continue
+			}
+		} else {
+			cmd := builder.BuildSubtitleExtractCommand(inputPath, outputPath, track.Index)
+			if err := runner.Run(ctx, cmd.Args, nil); err != nil {
+				logger.Warn("failed to extract subtitle", zap.String("language", lang), zap.Error(err))
+				a.addWarning(ctx, input.JobID, domain.StageSubtitlesExtraction, domain.WarnCodeSubtitleSkipped,
+					fmt.Sprintf("subtitle track %d (%s) skipped: extraction failed", track.Index, lang))
+				continue
 			}
 		}
 
-		subtitlePaths[lang] = outputPath
+		if a.finishSubtitle(ctx, input, outputPath, lang, fmt.Sprintf("subtitle track %d (%s)", track.Index, lang), logger) {
+			subtitlePaths[lang] = outputPath
+		}
 
 		progress := ((i + 1) * 100) / totalTracks
 		a.updateProgress(ctx, input.JobID, domain.StageSubtitlesExtraction, progress)
 		activity.RecordHeartbeat(ctx, progress)
 	}
 
+	// Files supplied with the job come after the tracks and replace a track
+	// of the same language
+	for i, source := range job.Profile.ExternalSubtitles {
+		lang := subtitleName(source.Track())
+		label := fmt.Sprintf("subtitle file %s (%s)", source.S3Key, lang)
+
+		outputPath := workspace.SubtitlePath(lang)
+		if err := a.convertSubtitleSource(ctx, job, builder, runner, workspace, source, outputPath, lang); err != nil {
+			logger.Warn("failed to convert subtitle file", zap.String("key", source.S3Key), zap.Error(err))
+			a.addWarning(ctx, input.JobID, domain.StageSubtitlesExtraction, domain.WarnCodeSubtitleSkipped,
+				fmt.Sprintf("%s skipped: %v", label, err))
+			continue
+		}
+		if a.finishSubtitle(ctx, input, outputPath, lang, label, logger) {
+			subtitlePaths[lang] = outputPath
+		}
+
+		progress := ((len(input.Metadata.SubtitleTracks) + i + 1) * 100) / totalTracks
+		a.updateProgress(ctx, input.JobID, domain.StageSubtitlesExtraction, progress)
+		activity.RecordHeartbeat(ctx, progress)
+	}
+
 	a.updateProgress(ctx, input.JobID, domain.StageSubtitlesExtraction, 100)
 	logger.Info("subtitles extracted", zap.Int("count", len(subtitlePaths)))
 
 	return &SubtitlesOutput{SubtitlePaths: subtitlePaths}, nil
 }
 
+// finishSubtitle normalizes the WebVTT file of a subtitle and shifts it behind
+// the intro. A file without valid cues is removed with a warning naming the
+// subtitle by label, and false is returned.
+func (a *Activities) finishSubtitle(ctx context.Context, input SubtitlesInput, outputPath, lang, label string, logger *zap.Logger) bool {
+	// Mixed source encodings and leftover styling render as mojibake in players
+	if a.config.Subtitles.Normalize {
+		report, err := ffmpeg.NormalizeVTT(outputPath, ffmpeg.VTTNormalizeOptions{
+			FallbackCharset: a.config.Subtitles.FallbackCharset,
+			StripTags:       a.config.Subtitles.StripTags,
+		})
+		if err == nil && report.Cues == 0 {
+			err = fmt.Errorf("no valid cues")
+		}
+		if err != nil {
+			logger.Warn("dropping invalid subtitle", zap.String("language", lang), zap.Error(err))
+			a.addWarning(ctx, input.JobID, domain.StageSubtitlesExtraction, domain.WarnCodeSubtitleSkipped,
+				fmt.Sprintf("%s skipped: %v", label, err))
+			os.Remove(outputPath)
+			return false
+		}
+		if report.DroppedCues > 0 {
+			a.addWarning(ctx, input.JobID, domain.StageSubtitlesExtraction, domain.WarnCodeSubtitleCuesDropped,
+				fmt.Sprintf("%s: %d invalid cues dropped", label, report.DroppedCues))
+		}
+		if report.Charset != "utf-8" || report.DroppedCues > 0 {
+			logger.Info("subtitle normalized",
+				zap.String("language", lang),
+				zap.String("charset", report.Charset),
+				zap.Int("cues", report.Cues),
+				zap.Int("droppedCues", report.DroppedCues))
+		}
+	}
+
+	// Shift timestamps if intro was added
+	if input.IntroDuration > 0 {
+		if err := shiftVTTTimestamps(outputPath, input.IntroDuration); err != nil {
+			logger.Warn("failed to shift subtitle timestamps", zap.Error(err))
+		}
+	}
+	return true
+}
+
+// convertSubtitleSource downloads a subtitle file of the job and converts it
+// to WebVTT at outputPath. The file is timed like the source, so the trim of
+// the job applies to it as to the extracted tracks.
+func (a *Activities) convertSubtitleSource(
+	ctx context.Context,
+	job *domain.Job,
+	builder *ffmpeg.CommandBuilder,
+	runner *ffmpeg.Runner,
+	workspace *ffmpeg.Workspace,
+	source domain.SubtitleSource,
+	outputPath string,
+	name string,
+) error {
+	bucket := source.Bucket
+	if bucket == "" {
+		bucket = job.SourceBucket
+	}
+	path := workspace.InputPath("subtitle_" + name + strings.ToLower(filepath.Ext(source.S3Key)))
+	defer os.Remove(path)
+	if err := a.s3Client.Download(ctx, bucket, source.S3Key, path); err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+
+	cmd := builder.BuildSubtitleExtractCommand(path, outputPath, 0)
+	if err := runner.Run(ctx, cmd.Args, nil); err != nil {
+		return fmt.Errorf("conversion failed: %w", err)
+	}
+	return nil
+}
+
+// subtitleName returns the file name of an extracted subtitle track: its
+// language, or "track<index>" if unknown. Forced tracks get a ".forced" suffix
+// so they do not replace the full track of the same language.
+func subtitleName(track domain.SubtitleTrackInfo) string {
+	name := track.Language
+	if name == "" || name == "und" {
+		name = fmt.Sprintf("track%d", track.Index)
+	}
+	if track.Forced {
+		name += ".forced"
+	}
+	return name
+}
+
+// ocrSubtitle writes a bitmap subtitle track to outputPath as WebVTT: the
+// stream is copied out, read by the OCR command to SRT and converted. The
+// intermediates stay in the input directory, which is not uploaded.
+func (a *Activities) ocrSubtitle(
+	ctx context.Context,
+	builder *ffmpeg.CommandBuilder,
+	runner *ffmpeg.Runner,
+	workspace *ffmpeg.Workspace,
+	inputPath, outputPath string,
+	track domain.SubtitleTrackInfo,
+	name string,
+) error {
+	bitmapPath := workspace.SubtitleOCRPath(name + ffmpeg.BitmapSubtitleExt(track.Codec))
+	srtPath := workspace.SubtitleOCRPath(name + ".srt")
+	defer os.Remove(bitmapPath)
+	defer os.Remove(srtPath)
+
+	// OCR of a feature-length track takes minutes without any progress output
+	hb := newHeartbeat(ctx)
+	hb.Update(func(*HeartbeatDetails) {})
+	stopHeartbeat := hb.KeepAlive(a.config.Temporal.HeartbeatInterval)
+	defer stopHeartbeat()
+
+	cmd := builder.BuildBitmapSubtitleExtractCommand(inputPath, bitmapPath, track.Index)
+	if err := runner.Run(ctx, cmd.Args, nil); err != nil {
+		return fmt.Errorf("extraction failed: %w", err)
+	}
+
+	if err := ffmpeg.OCRSubtitle(ctx, a.config.FFmpeg.SubtitleOCRCommand, bitmapPath, srtPath, track.Language); err != nil {
+		return err
+	}
+
+	cmd = builder.BuildSRTToVTTCommand(srtPath, outputPath)
+	if err := runner.Run(ctx, cmd.Args, nil); err != nil {
+		return fmt.Errorf("SRT conversion failed: %w", err)
+	}
+	return nil
+}
+
 // ThumbnailsInput holds thumbnails generation input
 type ThumbnailsInput struct {
 	JobID    uuid.UUID             `json:"jobId"`
 	Metadata *domain.VideoMetadata `json:"metadata"`
+	// RenditionPaths are the transcoded renditions; thumbnails are taken from the
+	// smallest one instead of decoding the source again
+	RenditionPaths map[domain.Quality]string `json:"renditionPaths,omitempty"`
+	// Duration of the renditions with the intro and outro; 0 is the source duration
+	Duration time.Duration `json:"duration,omitempty"`
+	// IntroDuration shifts thumbnails taken from the source behind the intro
+	IntroDuration time.Duration `json:"introDuration,omitempty"`
 }
 
 // ThumbnailsOutput holds thumbnails generation output
@@ -433,12 +1355,14 @@ type ThumbnailsOutput struct {
 }
 
 // GenerateThumbnails generates video thumbnails
-func (a *Activities) GenerateThumbnails(ctx context.Context, input ThumbnailsInput) (*ThumbnailsOutput, error) {
+func (a *Activities) GenerateThumbnails(ctx context.Context, input ThumbnailsInput) (_ *ThumbnailsOutput, err error) {
 	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "GenerateThumbnails"))
 	startTime := time.Now()
 	defer func() {
 		a.metrics.RecordStageDuration(string(domain.StageThumbnailsGen), time.Since(startTime).Seconds())
 	}()
+	stageDone := a.startStage(ctx, input.JobID, domain.StageThumbnailsGen)
+	defer func() { stageDone(err) }()
 
 	if err := a.updateProgress(ctx, input.JobID, domain.StageThumbnailsGen, 0); err != nil {
 		logger.Error("failed to update progress", zap.Error(err))
@@ -450,7 +1374,6 @@ func (a *Activities) GenerateThumbnails(ctx context.Context, input ThumbnailsInp
 	}
 
 	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, input.JobID)
-	inputPath := workspace.InputPath("source" + filepath.Ext(job.SourceKey))
 
 	thumbConfig := job.Profile.Thumbnails
 	if thumbConfig.MaxFrames == 0 {
@@ -469,22 +1392,30 @@ func (a *Activities) GenerateThumbnails(ctx context.Context, input ThumbnailsInp
 		thumbConfig.Height = 90
 	}
 
-	// Calculate interval
-	durationSec := input.Metadata.Duration.Seconds()
-	interval := durationSec / float64(thumbConfig.MaxFrames)
-	if interval < 1 {
-		interval = 1
+	// Renditions carry the intro and outro and are already trimmed, the source
+	// is not
+	inputPath := sourceInput(job, workspace)
+	duration, shift, trim := input.Metadata.Duration, input.IntroDuration, job.Profile.Trim
+	if path, quality, ok := thumbnailRendition(input.RenditionPaths, job.Profile.QualitiesCustom, thumbConfig.Width); ok {
+		inputPath = path
+		if input.Duration > 0 {
+			duration = input.Duration
+		}
+		shift, trim = 0, nil
+		logger.Info("generating thumbnails from rendition", zap.String("quality", string(quality)))
 	}
 
-	builder := ffmpeg.NewCommandBuilder(a.config.FFmpeg.BinaryPath, a.config.Worker.EnableGPU, &a.config.Encoding)
-	runner := ffmpeg.NewRunner(a.config.FFmpeg.BinaryPath, a.config.FFmpeg.ProcessTimeout)
+	interval := thumbnailInterval(duration, thumbConfig.MaxFrames)
+
+	builder := a.newBuilder().WithTrim(trim)
+	runner := a.newRunner()
 
 	// Generate thumbnails
 	thumbPattern := filepath.Join(workspace.Paths().Thumbs, "thumb_%05d.jpg")
 	thumbCmd := builder.BuildThumbnailCommand(inputPath, thumbPattern, interval, thumbConfig.Width, thumbConfig.Height)
 
 	if err := runner.Run(ctx, thumbCmd.Args, func(p ffmpeg.Progress) {
-		percent := ffmpeg.CalculateProgress(p.OutTime, input.Metadata.Duration) / 2
+		percent := ffmpeg.CalculateProgress(p.OutTime, duration) / 2
 		a.updateProgress(ctx, input.JobID, domain.StageThumbnailsGen, percent)
 		activity.RecordHeartbeat(ctx, percent)
 	}); err != nil {
@@ -495,6 +1426,8 @@ func (a *Activities) GenerateThumbnails(ctx context.Context, input ThumbnailsInp
 	tilePaths, err := createThumbnailTiles(ctx, workspace.Paths().Thumbs, thumbConfig.TileX, thumbConfig.TileY, builder, runner)
 	if err != nil {
 		logger.Warn("failed to create tiles, using individual thumbnails", zap.Error(err))
+		a.addWarning(ctx, input.JobID, domain.StageThumbnailsGen, domain.WarnCodeThumbnailTiles,
+			"thumbnail tiles could not be created, individual thumbnails are used")
 	}
 
 	a.updateProgress(ctx, input.JobID, domain.StageThumbnailsGen, 80)
@@ -503,6 +1436,12 @@ func (a *Activities) GenerateThumbnails(ctx context.Context, input ThumbnailsInp
 	vttPath := filepath.Join(workspace.Paths().Thumbs, "thumbnails.vtt")
 	if err := generateThumbnailVTT(vttPath, tilePaths, interval, thumbConfig.Width, thumbConfig.Height, thumbConfig.TileX, thumbConfig.TileY); err != nil {
 		logger.Warn("failed to generate VTT manifest", zap.Error(err))
+		a.addWarning(ctx, input.JobID, domain.StageThumbnailsGen, domain.WarnCodeThumbnailVTT,
+			"thumbnails VTT manifest could not be generated")
+	} else if shift > 0 {
+		if err := shiftVTTTimestamps(vttPath, shift); err != nil {
+			logger.Warn("failed to shift thumbnail timestamps", zap.Error(err))
+		}
 	}
 
 	a.updateProgress(ctx, input.JobID, domain.StageThumbnailsGen, 100)
@@ -514,6 +1453,53 @@ func (a *Activities) GenerateThumbnails(ctx context.Context, input ThumbnailsInp
 	}, nil
 }
 
+// thumbnailRendition picks the smallest finished rendition that is at least as
+// wide as the thumbnails. Without one thumbnails are generated from the source.
+func thumbnailRendition(renditions map[domain.Quality]string, ladder domain.Ladder, width int) (string, domain.Quality, bool) {
+	var best domain.Quality
+	bestWidth := 0
+	for quality, path := range renditions {
+		w := ladder.Params(quality).Width
+		if w == 0 || w < width || (bestWidth > 0 && w >= bestWidth) {
+			continue
+		}
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			continue
+		}
+		best, bestWidth = quality, w
+	}
+	if bestWidth == 0 {
+		return "", "", false
+	}
+	return renditions[best], best, true
+}
+
+// renditionCodecs returns the RFC 6381 video codec of every rendition for the
+// manifests, from the encoder settings of the job and the bit depth and level
+// of the probed rendition, along with the DASH frame rate of the rendition.
+// Renditions that fail to probe are signaled with the encoder settings alone
+// and without frame rate.
+func (a *Activities) renditionCodecs(ctx context.Context, logger *zap.Logger, builder *ffmpeg.CommandBuilder, job *domain.Job, videoRange string, tierPaths map[domain.EncodingTier]map[domain.Quality]string) (codecs, frameRates map[domain.EncodingTier]map[domain.Quality]string) {
+	prober := ffmpeg.NewProber(a.config.FFmpeg.FFprobePath)
+	codecs = make(map[domain.EncodingTier]map[domain.Quality]string, len(tierPaths))
+	frameRates = make(map[domain.EncodingTier]map[domain.Quality]string, len(tierPaths))
+	for tier, paths := range tierPaths {
+		codecs[tier] = make(map[domain.Quality]string, len(paths))
+		frameRates[tier] = make(map[domain.Quality]string, len(paths))
+		for quality, path := range paths {
+			metadata, err := prober.Probe(ctx, path)
+			if err != nil {
+				logger.Warn("failed to probe rendition codec", zap.String("tier", string(tier)),
+					zap.String("quality", string(quality)), zap.Error(err))
+			} else {
+				frameRates[tier][quality] = ffmpeg.DASHFrameRate(metadata.FPS)
+			}
+			codecs[tier][quality] = builder.VideoCodecString(tier, quality, job.Profile, videoRange, metadata)
+		}
+	}
+	return codecs, frameRates
+}
+
 // HLSInput holds HLS segmentation input
 type HLSInput struct {
 	JobID       uuid.UUID                 `json:"jobId"`
@@ -524,12 +1510,26 @@ type HLSInput struct {
 	EnabledTiers []domain.EncodingTier `json:"enabledTiers,omitempty"`
 	// Duration of the video for DASH manifest generation
 	Duration time.Duration `json:"duration,omitempty"`
+	// IntroDuration moves the cue points of the profile behind the intro
+	IntroDuration time.Duration `json:"introDuration,omitempty"`
+	// VideoRange of the modern tier output: SDR, PQ or HLG
+	VideoRange string `json:"videoRange,omitempty"`
+	// AudioTracks of the source, in the order they are muxed into the outputs
+	AudioTracks []domain.AudioTrackInfo `json:"audioTracks,omitempty"`
+	// SubtitleTracks of the source; the extracted ones are signaled in the manifests
+	SubtitleTracks []domain.SubtitleTrackInfo `json:"subtitleTracks,omitempty"`
+	// AudioOutputs are the renditions of an audio-only profile, packaged
+	// instead of OutputPaths
+	AudioOutputs map[string]string `json:"audioOutputs,omitempty"`
+	// Hardsub are the renditions with burned-in subtitles, packaged as an HLS
+	// output of their own
+	Hardsub *HardsubOutput `json:"hardsub,omitempty"`
 }
 
 // HLSOutput holds HLS segmentation output
 type HLSOutput struct {
 	MasterPlaylistPath string `json:"masterPlaylistPath"`
-	MPDPath            string `json:"mpdPath,omitempty"` // DASH manifest for DRM
+	MPDPath            string `json:"mpdPath,omitempty"` // DASH manifest of fMP4 or DRM output
 	HLSDir             string `json:"hlsDir"`
 	Encrypted          bool   `json:"encrypted"`
 	DRMEnabled         bool   `json:"drmEnabled"`
@@ -542,12 +1542,14 @@ type HLSOutput struct {
 }
 
 // SegmentHLS creates HLS segments
-func (a *Activities) SegmentHLS(ctx context.Context, input HLSInput) (*HLSOutput, error) {
+func (a *Activities) SegmentHLS(ctx context.Context, input HLSInput) (_ *HLSOutput, err error) {
 	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "SegmentHLS"))
 	startTime := time.Now()
 	defer func() {
 		a.metrics.RecordStageDuration(string(domain.StageHLSSegmentation), time.Since(startTime).Seconds())
 	}()
+	stageDone := a.startStage(ctx, input.JobID, domain.StageHLSSegmentation)
+	defer func() { stageDone(err) }()
 
 	if err := a.updateProgress(ctx, input.JobID, domain.StageHLSSegmentation, 0); err != nil {
 		logger.Error("failed to update progress", zap.Error(err))
@@ -561,11 +1563,23 @@ func (a *Activities) SegmentHLS(ctx context.Context, input HLSInput) (*HLSOutput
 	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, input.JobID)
 	hlsDir := workspace.HLSPath()
 
-	// Check if DRM is enabled and Shaka Packager is available
-	if a.config.DRM.Enabled {
+	// Check if DRM is enabled and Shaka Packager is available. The packager
+	// takes video renditions, so audio-only output keeps AES-128 at most.
+	if a.config.DRM.Enabled && len(input.AudioOutputs) > 0 {
+		logger.Warn("DRM packaging does not support audio-only output, falling back to FFmpeg")
+	} else if a.config.DRM.Enabled {
 		packager := drm.NewPackager(&a.config.DRM)
 		if packager.IsAvailable() {
 			logger.Info("Using DRM packaging with Shaka Packager", zap.String("provider", a.config.DRM.Provider))
+			// Clear hardsub segments would bypass the DRM of the main output
+			if input.Hardsub != nil && len(input.Hardsub.OutputPaths) > 0 {
+				a.addWarning(ctx, input.JobID, domain.StageHLSSegmentation, domain.WarnCodeHardsubSkipped,
+					"burned-in subtitles skipped: the set is not packaged with DRM")
+			}
+			if len(job.Profile.CuePoints) > 0 {
+				a.addWarning(ctx, input.JobID, domain.StageHLSSegmentation, domain.WarnCodeCuePointSkipped,
+					"cue points skipped: ad markers are not inserted into DRM output")
+			}
 			return a.segmentHLSWithDRM(ctx, input, packager, hlsDir, logger)
 		}
 		logger.Warn("DRM enabled but Shaka Packager not available, falling back to FFmpeg")
@@ -610,6 +1624,14 @@ func (a *Activities) segmentHLSWithDRM(
 	}, nil
 }
 
+// segmentDuration returns the HLS segment duration of job in seconds
+func (a *Activities) segmentDuration(job *domain.Job) int {
+	if job.Profile.HLS.SegmentDurationSec > 0 {
+		return job.Profile.HLS.SegmentDurationSec
+	}
+	return a.config.HLS.SegmentDurationSec
+}
+
 // segmentHLSWithFFmpeg uses FFmpeg for HLS (with optional AES-128 encryption)
 func (a *Activities) segmentHLSWithFFmpeg(
 	ctx context.Context,
@@ -618,24 +1640,45 @@ func (a *Activities) segmentHLSWithFFmpeg(
 	hlsDir string,
 	logger *zap.Logger,
 ) (*HLSOutput, error) {
-	segmentDuration := job.Profile.HLS.SegmentDurationSec
-	if segmentDuration == 0 {
-		segmentDuration = a.config.HLS.SegmentDurationSec
-	}
+	segmentDuration := a.segmentDuration(job)
 
-	builder := ffmpeg.NewCommandBuilder(a.config.FFmpeg.BinaryPath, a.config.Worker.EnableGPU, &a.config.Encoding)
-	runner := ffmpeg.NewRunner(a.config.FFmpeg.BinaryPath, a.config.FFmpeg.ProcessTimeout)
+	builder := a.newBuilder().WithSegmentContainer(job.Profile.HLS.SegmentContainer(a.config.Encoding.HLSSegmentType))
+	runner := a.newRunner()
 
 	// Generate encryption if enabled
 	var encryption *ffmpeg.EncryptionInfo
-	if a.config.HLS.EnableEncryption {
+	if job.Profile.HLS.EncryptionEnabled(a.config.HLS.EnableEncryption) {
 		var err error
-		encryption, err = ffmpeg.GenerateEncryption(hlsDir, input.JobID, a.config.HLS.KeyURL)
+		keyURL := job.Profile.HLS.KeyURLTemplate(a.config.HLS.KeyURL)
+		encryption, err = ffmpeg.GenerateEncryption(hlsDir, input.JobID, keyURL)
 		if err != nil {
 			return nil, a.recordError(ctx, input.JobID, domain.StageHLSSegmentation, domain.ErrCodeFFmpegFailed,
 				fmt.Errorf("failed to generate encryption: %w", err))
 		}
 		logger.Info("HLS AES-128 encryption enabled", zap.String("keyURL", encryption.KeyURL))
+		if job.Profile.HLS.PreviewSec > 0 {
+			logger.Info("HLS free preview enabled", zap.Int("previewSec", job.Profile.HLS.PreviewSec))
+		}
+	}
+
+	// Byte ranges of one file cannot be encrypted segment by segment, so
+	// encrypted output keeps a file per segment
+	if job.Profile.HLS.SingleFileEnabled(a.config.HLS.SingleFile) {
+		if encryption != nil {
+			logger.Warn("single-file HLS is not supported with AES-128 encryption, writing a file per segment")
+		} else {
+			builder.WithSingleFile(true)
+		}
+	}
+
+	if len(input.AudioOutputs) > 0 {
+		return a.segmentHLSAudioOnly(ctx, input, job, hlsDir, segmentDuration, builder, runner, encryption, logger)
+	}
+
+	if input.Hardsub != nil && len(input.Hardsub.OutputPaths) > 0 {
+		if err := a.segmentHardsub(ctx, input, job, hlsDir, segmentDuration, builder, runner, encryption, logger); err != nil {
+			return nil, err
+		}
 	}
 
 	// Check if multi-tier is enabled
@@ -651,35 +1694,100 @@ func (a *Activities) segmentHLSWithFFmpeg(
 		qualities = append(qualities, q)
 	}
 
+	// Segmentation is stream copy and mostly I/O bound, so renditions run concurrently.
+	// The runner's FFmpeg slots keep the total process count within the worker limit.
 	totalQualities := len(qualities)
-	for i, quality := range qualities {
-		inputPath := input.OutputPaths[quality]
-		cmd := builder.BuildHLSCommandWithEncryption(inputPath, hlsDir, string(quality), segmentDuration, encryption)
+	var (
+		progressMu sync.Mutex
+		completed  int
+	)
+	muxEncryption, preview := splitPreviewEncryption(job, encryption)
+	iFrames := make(map[domain.Quality]*ffmpeg.IFramePlaylist, totalQualities)
+	tasks := make([]func(ctx context.Context) error, 0, totalQualities)
+	for _, quality := range qualities {
+		quality := quality
+		tasks = append(tasks, func(ctx context.Context) error {
+			inputPath := input.OutputPaths[quality]
+			cmd := builder.BuildHLSCommandForTier(inputPath, hlsDir, string(quality), segmentDuration, domain.TierLegacy, muxEncryption)
 
-		if err := runner.Run(ctx, cmd.Args, func(p ffmpeg.Progress) {
-			activity.RecordHeartbeat(ctx, i)
-		}); err != nil {
-			return nil, a.recordError(ctx, input.JobID, domain.StageHLSSegmentation, domain.ErrCodeFFmpegFailed, err)
-		}
+			if err := runner.Run(ctx, cmd.Args, func(p ffmpeg.Progress) {
+				activity.RecordHeartbeat(ctx, string(quality))
+			}); err != nil {
+				return fmt.Errorf("quality=%s: %w", quality, err)
+			}
 
-		progress := ((i + 1) * 100) / totalQualities
-		a.updateProgress(ctx, input.JobID, domain.StageHLSSegmentation, progress)
-		activity.RecordHeartbeat(ctx, progress)
-		logger.Info("HLS segmentation complete for quality", zap.String("quality", string(quality)))
+			if preview > 0 {
+				if _, err := ffmpeg.ApplyPreviewEncryption(cmd.OutputPath, encryption, preview); err != nil {
+					return fmt.Errorf("quality=%s preview encryption: %w", quality, err)
+				}
+			}
+			iframes := a.iFramePlaylist(ctx, input.JobID, cmd.OutputPath, encryption, logger)
+
+			progressMu.Lock()
+			completed++
+			progress := (completed * 100) / totalQualities
+			if iframes != nil {
+				iFrames[quality] = iframes
+			}
+			progressMu.Unlock()
+
+			a.updateProgress(ctx, input.JobID, domain.StageHLSSegmentation, progress)
+			activity.RecordHeartbeat(ctx, progress)
+			logger.Info("HLS segmentation complete for quality", zap.String("quality", string(quality)))
+			return nil
+		})
+	}
+
+	if err := runParallel(ctx, a.config.Worker.MaxParallelFFmpeg, tasks); err != nil {
+		return nil, a.recordError(ctx, input.JobID, domain.StageHLSSegmentation, domain.ErrCodeFFmpegFailed, err)
+	}
+
+	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, input.JobID)
+	subtitles, err := packageSubtitles(workspace, hlsDir, subtitleTracks(job, input.SubtitleTracks), input.Duration, segmentDuration)
+	if err != nil {
+		return nil, a.recordError(ctx, input.JobID, domain.StageHLSSegmentation, domain.ErrCodeFFmpegFailed, err)
 	}
 
 	// Generate master playlist
-	masterContent := ffmpeg.GenerateMasterPlaylist(qualities, true)
+	masterContent := ffmpeg.GenerateMasterPlaylist(qualities, job.Profile.QualitiesCustom, true, subtitles, iFrames)
 	masterPath := filepath.Join(hlsDir, "master.m3u8")
 	if err := os.WriteFile(masterPath, []byte(masterContent), 0644); err != nil {
 		return nil, fmt.Errorf("failed to write master playlist: %w", err)
 	}
 
+	cues, err := a.insertCueMarkers(ctx, input, job, hlsDir, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// CMAF output is described by a DASH manifest as well
+	var mpdPath string
+	if builder.TierConfig(domain.TierLegacy).Container == domain.ContainerFMP4 {
+		codecs, frameRates := a.renditionCodecs(ctx, logger, builder, job, "", map[domain.EncodingTier]map[domain.Quality]string{domain.TierLegacy: input.OutputPaths})
+		mpdPath = writeDASHManifest(hlsDir, ffmpeg.DASHManifest{
+			Duration:        input.Duration,
+			SegmentDuration: segmentDuration,
+			Qualities:       qualities,
+			Ladder:          job.Profile.QualitiesCustom,
+			Subtitles:       subtitles,
+			Cues:            cues,
+			Video: []ffmpeg.DASHVideo{{
+				VideoCodec: domain.VideoCodecH264,
+				Codecs:     codecs[domain.TierLegacy],
+				FrameRates: frameRates[domain.TierLegacy],
+			}},
+		}, encryption != nil || builder.SingleFile(), logger)
+	}
+
 	a.updateProgress(ctx, input.JobID, domain.StageHLSSegmentation, 100)
-	logger.Info("HLS segmentation complete", zap.String("masterPlaylist", masterPath), zap.Bool("encrypted", encryption != nil))
+	logger.Info("HLS segmentation complete",
+		zap.String("masterPlaylist", masterPath),
+		zap.String("dashManifest", mpdPath),
+		zap.Bool("encrypted", encryption != nil))
 
 	output := &HLSOutput{
 		MasterPlaylistPath: masterPath,
+		MPDPath:            mpdPath,
 		HLSDir:             hlsDir,
 		Encrypted:          encryption != nil,
 	}
@@ -720,8 +1828,26 @@ func (a *Activities) segmentHLSMultiTier(
 		}
 	}
 
-	currentTask := 0
-	var qualities []domain.Quality
+	muxEncryption, preview := splitPreviewEncryption(job, encryption)
+
+	// Every language and the audio description get an audio-only rendition per
+	// tier, cut from one of the outputs: all outputs of a tier carry the same
+	// audio, surround tracks being copied into the modern tier only. The video
+	// renditions then carry video only. Outputs of sources without track info
+	// keep their audio muxed.
+	audio := make(map[domain.EncodingTier][]ffmpeg.AudioRendition, len(input.EnabledTiers))
+	for _, tier := range input.EnabledTiers {
+		audio[tier] = ffmpeg.AudioRenditions(input.AudioTracks, tier)
+		totalTasks += len(audio[tier])
+	}
+
+	var (
+		qualities   []domain.Quality
+		tasks       []func(ctx context.Context) error
+		progressMu  sync.Mutex
+		currentTask int
+		iFrames     = make(map[domain.EncodingTier]map[domain.Quality]*ffmpeg.IFramePlaylist, len(input.EnabledTiers))
+	)
 
 	for _, tier := range input.EnabledTiers {
 		tierPaths, ok := input.TierOutputPaths[tier]
@@ -729,7 +1855,7 @@ func (a *Activities) segmentHLSMultiTier(
 			continue
 		}
 
-		tierConfig := domain.GetTierConfig(tier)
+		tierConfig := builder.TierConfig(tier)
 		tierHLSDir := filepath.Join(hlsDir, string(tier))
 
 		// Create tier HLS directory
@@ -738,68 +1864,145 @@ func (a *Activities) segmentHLSMultiTier(
 		}
 
 		for quality, inputPath := range tierPaths {
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			default:
-			}
-
 			// Collect qualities for master playlist (use first tier)
 			if tier == input.EnabledTiers[0] {
 				qualities = append(qualities, quality)
 			}
 
-			logger.Info("HLS segmentation",
-				zap.String("tier", string(tier)),
-				zap.String("quality", string(quality)),
-				zap.String("container", string(tierConfig.Container)))
-
-			cmd := builder.BuildHLSCommandForTier(inputPath, tierHLSDir, string(quality), segmentDuration, tier, encryption)
-
-			if err := runner.Run(ctx, cmd.Args, func(p ffmpeg.Progress) {
-				activity.RecordHeartbeat(ctx, currentTask)
-			}); err != nil {
-				return nil, a.recordError(ctx, input.JobID, domain.StageHLSSegmentation, domain.ErrCodeFFmpegFailed,
-					fmt.Errorf("tier=%s quality=%s: %w", tier, quality, err))
-			}
+			tier, quality, inputPath := tier, quality, inputPath
+			tasks = append(tasks, func(ctx context.Context) error {
+				logger.Info("HLS segmentation",
+					zap.String("tier", string(tier)),
+					zap.String("quality", string(quality)),
+					zap.String("container", string(tierConfig.Container)))
+
+				cmd := builder.BuildHLSCommandForTier(inputPath, tierHLSDir, string(quality), segmentDuration, tier, muxEncryption)
+				if len(audio[tier]) > 0 {
+					cmd = builder.BuildHLSVideoCommandForTier(inputPath, tierHLSDir, string(quality), segmentDuration, tier, muxEncryption)
+				}
+
+				if err := runner.Run(ctx, cmd.Args, func(p ffmpeg.Progress) {
+					activity.RecordHeartbeat(ctx, string(tier)+"/"+string(quality))
+				}); err != nil {
+					return fmt.Errorf("tier=%s quality=%s: %w", tier, quality, err)
+				}
+
+				if preview > 0 {
+					if _, err := ffmpeg.ApplyPreviewEncryption(cmd.OutputPath, encryption, preview); err != nil {
+						return fmt.Errorf("tier=%s quality=%s preview encryption: %w", tier, quality, err)
+					}
+				}
+				iframes := a.iFramePlaylist(ctx, input.JobID, cmd.OutputPath, encryption, logger)
+
+				progressMu.Lock()
+				currentTask++
+				progress := (currentTask * 100) / totalTasks
+				if iframes != nil {
+					if iFrames[tier] == nil {
+						iFrames[tier] = make(map[domain.Quality]*ffmpeg.IFramePlaylist)
+					}
+					iFrames[tier][quality] = iframes
+				}
+				progressMu.Unlock()
+
+				a.updateProgress(ctx, input.JobID, domain.StageHLSSegmentation, progress)
+				activity.RecordHeartbeat(ctx, progress)
+
+				logger.Info("HLS segmentation complete",
+					zap.String("tier", string(tier)),
+					zap.String("quality", string(quality)))
+				return nil
+			})
+		}
 
-			currentTask++
-			progress := (currentTask * 100) / totalTasks
-			a.updateProgress(ctx, input.JobID, domain.StageHLSSegmentation, progress)
-			activity.RecordHeartbeat(ctx, progress)
+		if len(tierPaths) == 0 {
+			continue
+		}
+		for _, rendition := range audio[tier] {
+			tier, inputPath, rendition := tier, firstRendition(tierPaths), rendition
+			tasks = append(tasks, func(ctx context.Context) error {
+				cmd := builder.BuildHLSAudioCommandForTier(inputPath, tierHLSDir, rendition.Name,
+					rendition.Index, segmentDuration, tier, muxEncryption)
+
+				if err := runner.Run(ctx, cmd.Args, func(p ffmpeg.Progress) {
+					activity.RecordHeartbeat(ctx, string(tier)+"/"+rendition.Name)
+				}); err != nil {
+					return fmt.Errorf("tier=%s audio=%s: %w", tier, rendition.Name, err)
+				}
+
+				if preview > 0 {
+					if _, err := ffmpeg.ApplyPreviewEncryption(cmd.OutputPath, encryption, preview); err != nil {
+						return fmt.Errorf("tier=%s audio=%s preview encryption: %w", tier, rendition.Name, err)
+					}
+				}
+
+				progressMu.Lock()
+				currentTask++
+				progress := (currentTask * 100) / totalTasks
+				progressMu.Unlock()
+
+				a.updateProgress(ctx, input.JobID, domain.StageHLSSegmentation, progress)
+				logger.Info("audio rendition complete", zap.String("tier", string(tier)), zap.String("audio", rendition.Name))
+				return nil
+			})
+		}
+	}
 
-			logger.Info("HLS segmentation complete",
-				zap.String("tier", string(tier)),
-				zap.String("quality", string(quality)))
+	// Segment all tier/quality pairs concurrently within the FFmpeg slot limit
+	if err := runParallel(ctx, a.config.Worker.MaxParallelFFmpeg, tasks); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
+		return nil, a.recordError(ctx, input.JobID, domain.StageHLSSegmentation, domain.ErrCodeFFmpegFailed, err)
+	}
+
+	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, input.JobID)
+	subtitles, err := packageSubtitles(workspace, hlsDir, subtitleTracks(job, input.SubtitleTracks), input.Duration, segmentDuration)
+	if err != nil {
+		return nil, a.recordError(ctx, input.JobID, domain.StageHLSSegmentation, domain.ErrCodeFFmpegFailed, err)
 	}
 
 	// Generate multi-codec master playlist
-	masterContent := ffmpeg.GenerateMultiCodecMasterPlaylist(qualities, input.EnabledTiers, true)
+	codecs, frameRates := a.renditionCodecs(ctx, logger, builder, job, input.VideoRange, input.TierOutputPaths)
+	masterContent := ffmpeg.GenerateMultiCodecMasterPlaylist(qualities, job.Profile.QualitiesCustom, input.EnabledTiers, true, input.VideoRange, codecs, audio, subtitles, iFrames)
 	masterPath := filepath.Join(hlsDir, "master.m3u8")
 	if err := os.WriteFile(masterPath, []byte(masterContent), 0644); err != nil {
 		return nil, fmt.Errorf("failed to write master playlist: %w", err)
 	}
 
-	// Generate DASH manifest for modern tier (fMP4 segments)
-	var mpdPath string
+	cues, err := a.insertCueMarkers(ctx, input, job, hlsDir, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// The DASH manifest references the segments of every tier segmented as
+	// fMP4 (CMAF); the audio is that of the first of them
+	dashManifest := ffmpeg.DASHManifest{
+		Duration:        input.Duration,
+		SegmentDuration: segmentDuration,
+		Qualities:       qualities,
+		Ladder:          job.Profile.QualitiesCustom,
+		Subtitles:       subtitles,
+		Cues:            cues,
+	}
 	for _, tier := range input.EnabledTiers {
-		tierConfig := domain.GetTierConfig(tier)
-		if tierConfig.Container == domain.ContainerFMP4 {
-			dashManifest := ffmpeg.GenerateDASHManifest(ffmpeg.DASHManifest{
-				Duration:        input.Duration,
-				SegmentDuration: segmentDuration,
-				Qualities:       qualities,
-				TierDir:         string(tier),
-			})
-			mpdPath = filepath.Join(hlsDir, "manifest.mpd")
-			if err := ffmpeg.WriteDASHManifest(mpdPath, dashManifest); err != nil {
-				logger.Warn("failed to write DASH manifest", zap.Error(err))
-			} else {
-				logger.Info("DASH manifest generated", zap.String("path", mpdPath))
-			}
-			break // Only generate one DASH manifest
+		tierConfig := builder.TierConfig(tier)
+		if tierConfig.Container != domain.ContainerFMP4 {
+			continue
 		}
+		if len(dashManifest.Video) == 0 {
+			dashManifest.Audio, dashManifest.AudioDir = audio[tier], string(tier)
+		}
+		dashManifest.Video = append(dashManifest.Video, ffmpeg.DASHVideo{
+			Dir:        string(tier),
+			VideoCodec: tierConfig.VideoCodec,
+			Codecs:     codecs[tier],
+			FrameRates: frameRates[tier],
+		})
+	}
+	var mpdPath string
+	if len(dashManifest.Video) > 0 {
+		mpdPath = writeDASHManifest(hlsDir, dashManifest, encryption != nil || builder.SingleFile(), logger)
 	}
 
 	a.updateProgress(ctx, input.JobID, domain.StageHLSSegmentation, 100)
@@ -824,6 +2027,125 @@ func (a *Activities) segmentHLSMultiTier(
 	return output, nil
 }
 
+// writeDASHManifest writes the DASH manifest of fMP4 renditions next to the
+// master playlist and returns its path, empty if none was written. Segments
+// encrypted as a whole with AES-128 cannot be played by DASH clients, and
+// single-file renditions have no segment files for the segment template, so
+// such output gets none.
+func writeDASHManifest(hlsDir string, manifest ffmpeg.DASHManifest, skip bool, logger *zap.Logger) string {
+	if skip {
+		logger.Info("encrypted or single-file output, DASH manifest skipped")
+		return ""
+	}
+	mpdPath := filepath.Join(hlsDir, "manifest.mpd")
+	if err := ffmpeg.WriteDASHManifest(mpdPath, ffmpeg.GenerateDASHManifest(manifest)); err != nil {
+		logger.Warn("failed to write DASH manifest", zap.Error(err))
+		return ""
+	}
+	logger.Info("DASH manifest generated", zap.String("path", mpdPath))
+	return mpdPath
+}
+
+// iFramePlaylist writes the I-frame playlist of a segmented rendition for
+// trick play. Encrypted segments cannot be addressed by byte range, so
+// encrypted output gets none; a failure leaves the rendition without one.
+func (a *Activities) iFramePlaylist(ctx context.Context, jobID uuid.UUID, playlistPath string, encryption *ffmpeg.EncryptionInfo, logger *zap.Logger) *ffmpeg.IFramePlaylist {
+	if encryption != nil {
+		return nil
+	}
+	iframes, err := ffmpeg.GenerateIFramePlaylist(ctx, a.config.FFmpeg.FFprobePath, playlistPath)
+	if err != nil {
+		logger.Warn("failed to generate I-frame playlist", zap.String("playlist", playlistPath), zap.Error(err))
+		a.addWarning(ctx, jobID, domain.StageHLSSegmentation, domain.WarnCodeIFramesSkipped,
+			fmt.Sprintf("I-frame playlist of %s not generated, trick play is unavailable", filepath.Base(playlistPath)))
+		return nil
+	}
+	return iframes
+}
+
+// subtitleTracks returns the subtitle files of the job as tracks, followed by
+// the tracks of the source. A file replaces the track of its language, so it
+// comes first and its title is the one signaled.
+func subtitleTracks(job *domain.Job, tracks []domain.SubtitleTrackInfo) []domain.SubtitleTrackInfo {
+	all := make([]domain.SubtitleTrackInfo, 0, len(job.Profile.ExternalSubtitles)+len(tracks))
+	for _, source := range job.Profile.ExternalSubtitles {
+		all = append(all, source.Track())
+	}
+	return append(all, tracks...)
+}
+
+// packageSubtitles copies the extracted subtitle tracks into the HLS output,
+// whole for DASH and cut into WebVTT segments of segmentDuration seconds with
+// a media playlist for HLS, and returns them for the manifests. Tracks that
+// were skipped or failed extraction are left out.
+func packageSubtitles(workspace *ffmpeg.Workspace, hlsDir string, tracks []domain.SubtitleTrackInfo, duration time.Duration, segmentDuration int) ([]ffmpeg.SubtitleRendition, error) {
+	// The segments are cut to the duration of the video
+	if duration <= 0 {
+		return nil, nil
+	}
+
+	dir := filepath.Join(hlsDir, ffmpeg.SubtitlesDir)
+	var renditions []ffmpeg.SubtitleRendition
+	seen := make(map[string]bool, len(tracks))
+	for _, track := range tracks {
+		name := subtitleName(track)
+		if seen[name] {
+			continue // Tracks of the same language were extracted to one file
+		}
+		seen[name] = true
+
+		vtt, err := os.ReadFile(workspace.SubtitlePath(name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read subtitle: %w", err)
+		}
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create subtitles directory: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".vtt"), vtt, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write subtitle: %w", err)
+		}
+		playlist, err := ffmpeg.SegmentVTT(vtt, dir, name, duration, time.Duration(segmentDuration)*time.Second)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".m3u8"), []byte(playlist), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write subtitle playlist: %w", err)
+		}
+
+		renditions = append(renditions, ffmpeg.SubtitleRendition{
+			Name:     name,
+			Language: track.Language,
+			Title:    track.Title,
+			Forced:   track.Forced,
+		})
+	}
+	return renditions, nil
+}
+
+// firstRendition returns the output path of the lowest quality name, so retries cut from the same file
+func firstRendition(paths map[domain.Quality]string) string {
+	qualities := make([]domain.Quality, 0, len(paths))
+	for q := range paths {
+		qualities = append(qualities, q)
+	}
+	slices.Sort(qualities)
+	return paths[qualities[0]]
+}
+
+// splitPreviewEncryption decides who encrypts HLS segments. Without a preview window
+// ffmpeg encrypts everything; with one, ffmpeg writes clear segments and the tail is
+// encrypted afterwards so the first PreviewSec seconds stay playable without a key.
+func splitPreviewEncryption(job *domain.Job, encryption *ffmpeg.EncryptionInfo) (*ffmpeg.EncryptionInfo, time.Duration) {
+	if encryption == nil || job.Profile.HLS.PreviewSec <= 0 {
+		return encryption, 0
+	}
+	return nil, time.Duration(job.Profile.HLS.PreviewSec) * time.Second
+}
+
 // UploadInput holds upload input
 type UploadInput struct {
 	JobID uuid.UUID `json:"jobId"`
@@ -835,12 +2157,14 @@ type UploadOutput struct {
 }
 
 // UploadArtifacts uploads artifacts to S3
-func (a *Activities) UploadArtifacts(ctx context.Context, input UploadInput) (*UploadOutput, error) {
+func (a *Activities) UploadArtifacts(ctx context.Context, input UploadInput) (_ *UploadOutput, err error) {
 	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "UploadArtifacts"))
 	startTime := time.Now()
 	defer func() {
 		a.metrics.RecordStageDuration(string(domain.StageUploading), time.Since(startTime).Seconds())
 	}()
+	stageDone := a.startStage(ctx, input.JobID, domain.StageUploading)
+	defer func() { stageDone(err) }()
 
 	if err := a.updateProgress(ctx, input.JobID, domain.StageUploading, 0); err != nil {
 		logger.Error("failed to update progress", zap.Error(err))
@@ -854,85 +2178,265 @@ func (a *Activities) UploadArtifacts(ctx context.Context, input UploadInput) (*U
 	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, input.JobID)
 	bucket := a.s3Client.GetDefaultBucket()
 
-	// Build S3 prefix
-	videoID := input.JobID.String()
-	if job.VideoID != nil {
-		videoID = job.VideoID.String()
-	}
-	prefix := fmt.Sprintf("%s/%s", videoID, input.JobID.String())
+	prefix := job.OutputPrefix()
 
-	uploader := s3.NewDirectoryUploader(a.s3Client, a.config.Worker.MaxParallelUploads)
+	// The manifest lets a retried activity skip objects uploaded before a crash,
+	// and the multipart uploads in the heartbeat let it continue large files mid-way
+	hb := newHeartbeat(ctx)
+	if uploads := hb.Previous().Uploads; len(uploads) > 0 {
+		logger.Info("resuming multipart uploads", zap.Int("uploads", len(uploads)))
+	}
+	uploader, err := s3.NewDirectoryUploader(a.s3Client, a.config.Worker.MaxParallelUploads).
+		WithRetry(a.config.Retry).
+		WithMultipartResume(hb.Previous().Uploads, func(uploads []s3.MultipartState) {
+			hb.Update(func(d *HeartbeatDetails) { d.Uploads = uploads })
+		}).
+		WithManifest(workspace.UploadManifestPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load upload manifest: %w", err)
+	}
+	// uploadProgress records the progress of one directory upload, which starts at percent
+	var uploadedBefore int64
+	uploadProgress := func(p s3.UploadProgress, percent int) {
+		a.updateProgress(ctx, input.JobID, domain.StageUploading, percent)
+		hb.Update(func(d *HeartbeatDetails) {
+			d.Percent = percent
+			d.UploadedBytes = uploadedBefore + p.UploadedBytes
+		})
+	}
 
 	var allArtifacts []*domain.Artifact
 
-	// Upload HLS
-	hlsArtifacts, err := uploader.UploadDirectory(ctx, input.JobID, workspace.HLSPath(), bucket, prefix+"/hls", func(p s3.UploadProgress) {
-		progress := p.CompletedFiles * 50 / p.TotalFiles
-		a.updateProgress(ctx, input.JobID, domain.StageUploading, progress)
+	// Upload HLS, or the transcoded MP4 renditions when the profile skips packaging
+	mainDir, mainPrefix := workspace.HLSPath(), prefix+"/hls"
+	if job.Profile.Skips(domain.StageHLSSegmentation) {
+		mainDir, mainPrefix = workspace.Paths().Transcoded, prefix+"/renditions"
+	}
+	hlsArtifacts, err := uploader.UploadDirectory(ctx, input.JobID, mainDir, bucket, mainPrefix, func(p s3.UploadProgress) {
 		a.metrics.AddUploadBytes(float64(p.UploadedBytes))
-		activity.RecordHeartbeat(ctx, progress)
+		uploadProgress(p, p.CompletedFiles*50/p.TotalFiles)
 	})
 	if err != nil {
-		return nil, a.recordError(ctx, input.JobID, domain.StageUploading, domain.ErrCodeNetworkError, err)
+		logUploadFailures(logger, err)
+		return nil, a.recordError(ctx, input.JobID, domain.StageUploading, s3.ErrorCode(err), err)
 	}
 	allArtifacts = append(allArtifacts, hlsArtifacts...)
+	uploadedBefore += artifactBytes(hlsArtifacts)
+
+	// Publish the transcoded MP4s for offline download as well; without HLS
+	// they are the renditions already
+	if job.Profile.ProgressiveMP4 && !job.Profile.Skips(domain.StageHLSSegmentation) {
+		downloadArtifacts, err := uploader.UploadDirectory(ctx, input.JobID, workspace.Paths().Transcoded, bucket, prefix+"/downloads", func(p s3.UploadProgress) {
+			a.metrics.AddUploadBytes(float64(p.UploadedBytes))
+			uploadProgress(p, 50)
+		})
+		if err != nil {
+			logUploadFailures(logger, err)
+			return nil, a.recordError(ctx, input.JobID, domain.StageUploading, s3.ErrorCode(err), err)
+		}
+		allArtifacts = append(allArtifacts, downloadArtifacts...)
+		uploadedBefore += artifactBytes(downloadArtifacts)
+	}
 
 	// Upload thumbnails
 	thumbsArtifacts, err := uploader.UploadDirectory(ctx, input.JobID, workspace.Paths().Thumbs, bucket, prefix+"/thumbs", func(p s3.UploadProgress) {
-		progress := 50 + p.CompletedFiles*30/p.TotalFiles
-		a.updateProgress(ctx, input.JobID, domain.StageUploading, progress)
-		activity.RecordHeartbeat(ctx, progress)
+		uploadProgress(p, 50+p.CompletedFiles*30/p.TotalFiles)
 	})
 	if err != nil {
 		logger.Warn("failed to upload thumbnails", zap.Error(err))
+		a.addWarning(ctx, input.JobID, domain.StageUploading, domain.WarnCodeArtifactsNotUploaded, "thumbnails were not uploaded")
 	} else {
 		allArtifacts = append(allArtifacts, thumbsArtifacts...)
+		uploadedBefore += artifactBytes(thumbsArtifacts)
 	}
 
 	// Upload subtitles
 	subsArtifacts, err := uploader.UploadDirectory(ctx, input.JobID, workspace.Paths().Subtitles, bucket, prefix+"/subtitles", func(p s3.UploadProgress) {
-		progress := 80 + p.CompletedFiles*10/p.TotalFiles
-		a.updateProgress(ctx, input.JobID, domain.StageUploading, progress)
-		activity.RecordHeartbeat(ctx, progress)
+		uploadProgress(p, 80+p.CompletedFiles*10/p.TotalFiles)
 	})
 	if err != nil {
 		logger.Warn("failed to upload subtitles", zap.Error(err))
+		a.addWarning(ctx, input.JobID, domain.StageUploading, domain.WarnCodeArtifactsNotUploaded, "subtitles were not uploaded")
 	} else {
 		allArtifacts = append(allArtifacts, subsArtifacts...)
+		uploadedBefore += artifactBytes(subsArtifacts)
+	}
+
+	// Upload mezzanine master if it was produced
+	if entries, err := os.ReadDir(workspace.Paths().Mezzanine); err == nil && len(entries) > 0 {
+		mezzArtifacts, err := uploader.UploadDirectory(ctx, input.JobID, workspace.Paths().Mezzanine, bucket, prefix+"/mezzanine", func(p s3.UploadProgress) {
+			uploadProgress(p, 90)
+		})
+		if err != nil {
+			logUploadFailures(logger, err)
+			return nil, a.recordError(ctx, input.JobID, domain.StageUploading, s3.ErrorCode(err), err)
+		}
+		allArtifacts = append(allArtifacts, mezzArtifacts...)
 	}
 
 	// Upload metadata
 	metaArtifacts, err := uploader.UploadDirectory(ctx, input.JobID, workspace.Paths().Meta, bucket, prefix+"/meta", nil)
 	if err != nil {
 		logger.Warn("failed to upload metadata", zap.Error(err))
+		a.addWarning(ctx, input.JobID, domain.StageUploading, domain.WarnCodeArtifactsNotUploaded, "metadata was not uploaded")
 	} else {
 		allArtifacts = append(allArtifacts, metaArtifacts...)
 	}
 
-	// Save artifacts to database
+	metadata, err := a.jobRepo.GetMetadata(ctx, input.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata: %w", err)
+	}
+	setArtifactTracks(allArtifacts, metadata)
+
+	// Save artifacts to database, replacing rows left by a previous attempt
+	if err := a.artifactRepo.DeleteByJobID(ctx, input.JobID); err != nil {
+		return nil, fmt.Errorf("failed to clear previous artifacts: %w", err)
+	}
 	if err := a.artifactRepo.CreateBatch(ctx, allArtifacts); err != nil {
 		return nil, fmt.Errorf("failed to save artifacts: %w", err)
 	}
 
+	a.checkTitleUsage(ctx, job, logger)
+
 	a.updateProgress(ctx, input.JobID, domain.StageUploading, 100)
 	logger.Info("artifacts uploaded", zap.Int("count", len(allArtifacts)))
 
 	return &UploadOutput{ArtifactCount: len(allArtifacts)}, nil
 }
 
+// setArtifactTracks records the audio tracks of the artifacts that carry them:
+// every track for MP4 renditions, downloads and mezzanines, its own for the playlist of
+// an audio rendition
+func setArtifactTracks(artifacts []*domain.Artifact, metadata *domain.VideoMetadata) {
+	if metadata == nil || len(metadata.AudioTracks) == 0 {
+		return
+	}
+
+	main := metadata.MainAudioTrack()
+	tracks := make([]domain.ArtifactTrack, len(metadata.AudioTracks))
+	for i := range metadata.AudioTracks {
+		track := &metadata.AudioTracks[i]
+		tracks[i] = domain.ArtifactTrack{
+			SourceIndex: track.Index,
+			Language:    track.Language,
+			Default:     track == main,
+			Description: track.Description,
+		}
+	}
+	playlists := make(map[string]domain.ArtifactTrack)
+	for _, r := range ffmpeg.AudioRenditions(metadata.AudioTracks, domain.TierLegacy) {
+		playlists[r.Name+".m3u8"] = tracks[r.Index]
+	}
+
+	for _, artifact := range artifacts {
+		switch artifact.Type {
+		case domain.ArtifactTypeRendition, domain.ArtifactTypeMP4Progressive, domain.ArtifactTypeMezzanine:
+			artifact.WithTracks(tracks)
+		case domain.ArtifactTypeHLSVariant:
+			if track, ok := playlists[filepath.Base(artifact.Key)]; ok {
+				artifact.WithTracks([]domain.ArtifactTrack{track})
+			}
+		}
+	}
+}
+
+// checkTitleUsage warns when the output of the job's title across all its jobs
+// exceeds S3_TITLE_USAGE_ALERT_GB
+func (a *Activities) checkTitleUsage(ctx context.Context, job *domain.Job, logger *zap.Logger) {
+	limitGB := a.config.S3.TitleUsageAlertGB
+	if limitGB <= 0 {
+		return
+	}
+
+	titleID := job.ID
+	if job.VideoID != nil {
+		titleID = *job.VideoID
+	}
+	usage, err := a.artifactRepo.GetUsage(ctx, db.UsageByVideo, titleID.String())
+	if err != nil {
+		logger.Warn("failed to check title storage usage", zap.Error(err))
+		return
+	}
+
+	if usage.TotalBytes > int64(limitGB)<<30 {
+		logger.Warn("title storage usage exceeds alert threshold",
+			zap.String("titleId", titleID.String()),
+			zap.Int64("totalBytes", usage.TotalBytes),
+			zap.Int("jobs", usage.Jobs),
+			zap.Int("alertGB", limitGB))
+		a.addWarning(ctx, job.ID, domain.StageUploading, domain.WarnCodeTitleUsageExceeded,
+			fmt.Sprintf("title output is %.1f GB across %d jobs, alert threshold is %d GB",
+				float64(usage.TotalBytes)/(1<<30), usage.Jobs, limitGB))
+	}
+}
+
+// VerifyInput holds output verification input
+type VerifyInput struct {
+	JobID uuid.UUID `json:"jobId"`
+}
+
+// VerifyOutput re-reads the published output from S3: the master playlist, every
+// variant playlist and a sample of segments, so a job with incomplete output fails
+// instead of being marked COMPLETED.
+func (a *Activities) VerifyOutput(ctx context.Context, input VerifyInput) (err error) {
+	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "VerifyOutput"))
+	if !a.config.S3.VerifyOutput {
+		logger.Info("output verification disabled")
+		return nil
+	}
+
+	startTime := time.Now()
+	defer func() {
+		a.metrics.RecordStageDuration(string(domain.StageOutputVerification), time.Since(startTime).Seconds())
+	}()
+	stageDone := a.startStage(ctx, input.JobID, domain.StageOutputVerification)
+	defer func() { stageDone(err) }()
+
+	if err := a.updateProgress(ctx, input.JobID, domain.StageOutputVerification, 0); err != nil {
+		logger.Error("failed to update progress", zap.Error(err))
+	}
+
+	artifacts, err := a.artifactRepo.GetByJobID(ctx, input.JobID)
+	if err != nil {
+		return fmt.Errorf("failed to get artifacts: %w", err)
+	}
+
+	report, err := s3.NewOutputVerifier(a.s3Client, a.config.S3.VerifySegmentSamples).Verify(ctx, artifacts)
+	if err != nil {
+		var incomplete *s3.IncompleteOutputError
+		if errors.As(err, &incomplete) {
+			for _, problem := range incomplete.Problems {
+				logger.Error("output verification problem", zap.String("problem", problem))
+			}
+		}
+		return a.recordError(ctx, input.JobID, domain.StageOutputVerification, s3.ErrorCode(err), err)
+	}
+
+	a.updateProgress(ctx, input.JobID, domain.StageOutputVerification, 100)
+	logger.Info("output verified",
+		zap.Int("playlists", report.Playlists),
+		zap.Int("segments", report.SegmentsChecked),
+		zap.Int("objects", report.ObjectsChecked))
+
+	return nil
+}
+
 // CleanupInput holds cleanup input
 type CleanupInput struct {
 	JobID uuid.UUID `json:"jobId"`
 }
 
 // Cleanup cleans up workspace
-func (a *Activities) Cleanup(ctx context.Context, input CleanupInput) error {
+func (a *Activities) Cleanup(ctx context.Context, input CleanupInput) (err error) {
 	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "Cleanup"))
 	startTime := time.Now()
 	defer func() {
 		a.metrics.RecordStageDuration(string(domain.StageCleanup), time.Since(startTime).Seconds())
 		a.metrics.DecrementJobsActive()
 	}()
+	stageDone := a.startStage(ctx, input.JobID, domain.StageCleanup)
+	defer func() { stageDone(err) }()
 
 	if err := a.updateProgress(ctx, input.JobID, domain.StageCleanup, 0); err != nil {
 		logger.Error("failed to update progress", zap.Error(err))
@@ -944,6 +2448,10 @@ func (a *Activities) Cleanup(ctx context.Context, input CleanupInput) error {
 		logger.Warn("failed to cleanup workspace", zap.Error(err))
 	}
 
+	if err := a.jobRepo.ReleaseDisk(ctx, input.JobID); err != nil {
+		logger.Warn("failed to release disk reservation", zap.Error(err))
+	}
+
 	a.updateProgress(ctx, input.JobID, domain.StageCleanup, 100)
 	logger.Info("cleanup complete")
 
@@ -952,25 +2460,191 @@ func (a *Activities) Cleanup(ctx context.Context, input CleanupInput) error {
 
 // Helper methods
 
-// startPeriodicHeartbeat starts a goroutine that sends heartbeats every interval
-// Returns a cancel function to stop the goroutine
-func startPeriodicHeartbeat(ctx context.Context, interval time.Duration, details interface{}) func() {
-	done := make(chan struct{})
+// sourceInput returns the ffmpeg input for a job: the downloaded file in the
+// workspace, or the playlist/manifest URL for HLS/DASH sources
+func sourceInput(job *domain.Job, workspace *ffmpeg.Workspace) string {
+	if job.SourceURL != nil {
+		return *job.SourceURL
+	}
+	return workspace.InputPath("source" + filepath.Ext(job.SourceKey))
+}
+
+// requiredDiskSpace estimates the workdir space a job needs: 5x the source size
+func requiredDiskSpace(metadata *domain.VideoMetadata) int64 {
+	sourceSize := metadata.FileSize
+	if sourceSize == 0 {
+		// Streamed sources report no size, estimate it from bitrate
+		sourceSize = metadata.Bitrate / 8 * int64(metadata.Duration.Seconds())
+	}
+	return sourceSize * 5
+}
+
+// diskBudget returns the workdir space that reservations of jobs on this host may
+// add up to: free space plus what its reserving jobs (and this job's source)
+// already occupy. Only reservations made on this host count, since other hosts
+// have their own WORKDIR_ROOT.
+func (a *Activities) diskBudget(ctx context.Context, jobID uuid.UUID, freeSpace int64) (budget, reservedByOthers int64, err error) {
+	reservations, err := a.jobRepo.ListDiskReservations(ctx, a.host)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list disk reservations: %w", err)
+	}
+
+	budget = freeSpace
+	if used, err := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, jobID).GetDiskUsage(); err == nil {
+		budget += used
+	}
+	for id, reserved := range reservations {
+		if id == jobID {
+			continue
+		}
+		reservedByOthers += reserved
+		used, err := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, id).GetDiskUsage()
+		if err != nil {
+			continue
+		}
+		budget += min(used, reserved)
+	}
+
+	return budget, reservedByOthers, nil
+}
+
+// artifactBytes sums the sizes of uploaded artifacts
+func artifactBytes(artifacts []*domain.Artifact) int64 {
+	var total int64
+	for _, artifact := range artifacts {
+		if artifact.SizeBytes != nil {
+			total += *artifact.SizeBytes
+		}
+	}
+	return total
+}
+
+// logUploadFailures logs every file of a partially failed directory upload
+func logUploadFailures(logger *zap.Logger, err error) {
+	var uploadErr *s3.UploadError
+	if !errors.As(err, &uploadErr) {
+		return
+	}
+	for _, f := range uploadErr.Failed {
+		logger.Error("file upload failed",
+			zap.String("key", f.Key),
+			zap.Int("attempts", f.Attempts),
+			zap.Error(f.Err),
+		)
+	}
+}
+
+// watchJob polls the job status and suspends FFmpeg processes while the job is PAUSED.
+// Heartbeats continue while suspended so the activity does not time out. Once the
+// job is CANCELED the returned context is canceled, which terminates running FFmpeg
+// processes without waiting for Temporal to deliver the activity cancellation on
+// the next heartbeat. The returned func stops watching.
+func (a *Activities) watchJob(ctx context.Context, jobID uuid.UUID, pauser *ffmpeg.Pauser) (context.Context, func()) {
+	logger := a.logger.With(zap.String("jobId", jobID.String()))
+	ctx, cancel := context.WithCancel(ctx)
 	go func() {
-		ticker := time.NewTicker(interval)
+		ticker := time.NewTicker(a.config.Worker.PausePollInterval)
 		defer ticker.Stop()
 		for {
 			select {
-			case <-done:
-				return
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				activity.RecordHeartbeat(ctx, details)
+				job, err := a.jobRepo.GetByID(ctx, jobID)
+				if err != nil {
+					logger.Warn("failed to check pause state", zap.Error(err))
+					continue
+				}
+
+				if job.Status == domain.JobStatusCanceled {
+					logger.Info("job canceled, terminating ffmpeg")
+					cancel()
+					return
+				}
+
+				paused := job.Status == domain.JobStatusPaused
+				if paused != pauser.Paused() {
+					if paused {
+						err = pauser.Pause()
+						logger.Info("job paused, ffmpeg suspended")
+					} else {
+						err = pauser.Resume()
+						logger.Info("job resumed, ffmpeg continued")
+					}
+					if err != nil {
+						logger.Warn("failed to signal ffmpeg", zap.Error(err))
+					}
+				}
+				if paused {
+					activity.RecordHeartbeat(ctx, "paused")
+				}
 			}
 		}
 	}()
-	return func() { close(done) }
+
+	return ctx, cancel
+}
+
+// newRunner creates an FFmpeg runner with progress throttling from config.
+// All runners share the worker-wide FFmpeg slots limited by MAX_PARALLEL_FFMPEG.
+func (a *Activities) newRunner() *ffmpeg.Runner {
+	return ffmpeg.NewRunner(a.config.FFmpeg.BinaryPath, a.config.FFmpeg.ProcessTimeout).
+		WithThrottle(ffmpeg.ProgressThrottle{
+			MinInterval: a.config.FFmpeg.ProgressInterval,
+			MinDelta:    a.config.FFmpeg.ProgressMinDelta,
+		}).
+		WithSlots(a.ffmpegSlots)
+}
+
+// newBuilder creates an FFmpeg command builder with the worker thread budget
+func (a *Activities) newBuilder() *ffmpeg.CommandBuilder {
+	return ffmpeg.NewCommandBuilder(a.config.FFmpeg.BinaryPath, a.config.Worker.HWAccel, &a.config.Encoding).
+		WithHWDevice(a.config.Worker.HWDevice).
+		WithThreads(a.config.Worker.ThreadsPerFFmpeg())
+}
+
+// formatPolicy returns supported input formats adjusted by config allow/deny lists
+func (a *Activities) formatPolicy() domain.FormatPolicy {
+	return domain.FormatPolicy{
+		AllowContainers:  a.config.Input.AllowContainers,
+		DenyContainers:   a.config.Input.DenyContainers,
+		AllowVideoCodecs: a.config.Input.AllowVideoCodecs,
+		DenyVideoCodecs:  a.config.Input.DenyVideoCodecs,
+		AllowAudioCodecs: a.config.Input.AllowAudioCodecs,
+		DenyAudioCodecs:  a.config.Input.DenyAudioCodecs,
+	}
+}
+
+// startStage persists the start of a stage attempt and returns a func recording its outcome.
+// Timing is best-effort: persistence failures are logged and never fail the activity.
+func (a *Activities) startStage(ctx context.Context, jobID uuid.UUID, stage domain.Stage) func(err error) {
+	timing := domain.NewStageTiming(jobID, stage, int(activity.GetInfo(ctx).Attempt))
+	a.events.Publish(events.StageEvent(events.StageStarted, jobID, stage, timing.Attempt))
+
+	recorded := true
+	if err := a.stageRepo.Start(ctx, timing); err != nil {
+		a.logger.Warn("failed to record stage start", zap.String("jobId", jobID.String()), zap.String("stage", string(stage)), zap.Error(err))
+		recorded = false
+	}
+
+	return func(err error) {
+		status := domain.StageStatusCompleted
+		event := events.StageEvent(events.StageCompleted, jobID, stage, timing.Attempt)
+		if err != nil {
+			status = domain.StageStatusFailed
+			event.Type = events.StageFailed
+			event.Error = err.Error()
+		}
+		a.events.Publish(event)
+
+		if !recorded {
+			return
+		}
+		// The activity context may already be canceled; the outcome must still be written
+		if ferr := a.stageRepo.Finish(context.WithoutCancel(ctx), timing.ID, status); ferr != nil {
+			a.logger.Warn("failed to record stage finish", zap.String("jobId", jobID.String()), zap.String("stage", string(stage)), zap.Error(ferr))
+		}
+	}
 }
 
 func (a *Activities) updateProgress(ctx context.Context, jobID uuid.UUID, stage domain.Stage, stageProgress int) error {
@@ -1005,6 +2679,108 @@ func (a *Activities) recordError(ctx context.Context, jobID uuid.UUID, stage dom
 	return err
 }
 
+// QuarantineJobInput holds quarantine input
+type QuarantineJobInput struct {
+	JobID uuid.UUID `json:"jobId"`
+}
+
+// QuarantineJob uploads a diagnostic bundle of a poison job to its diagnostics
+// prefix: the job row, recorded errors with ffmpeg command lines and stderr,
+// stage timings, metadata.json and the raw ffprobe output. FinalizeJob then
+// marks the job QUARANTINED.
+func (a *Activities) QuarantineJob(ctx context.Context, input QuarantineJobInput) error {
+	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "QuarantineJob"))
+
+	job, err := a.jobRepo.GetByID(ctx, input.JobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job: %w", err)
+	}
+	convErrors, err := a.errorRepo.GetByJobID(ctx, input.JobID)
+	if err != nil {
+		return fmt.Errorf("failed to get errors: %w", err)
+	}
+	stages, err := a.stageRepo.GetByJobID(ctx, input.JobID)
+	if err != nil {
+		return fmt.Errorf("failed to get job stages: %w", err)
+	}
+	metadata, err := a.jobRepo.GetMetadata(ctx, input.JobID)
+	if err != nil {
+		return fmt.Errorf("failed to get metadata: %w", err)
+	}
+
+	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, input.JobID)
+	dir := workspace.DiagnosticsPath()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create diagnostics directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]any{
+		"job.json":    job,
+		"errors.json": convErrors,
+		"stages.json": stages,
+	}
+	if metadata != nil {
+		files["metadata.json"] = metadata
+		if len(metadata.Raw) > 0 {
+			files["ffprobe.json"] = metadata.Raw
+		}
+	}
+	for name, v := range files {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	// Tool failures carry the command line and stderr; keep them readable as plain text
+	var toolLog strings.Builder
+	for _, e := range convErrors {
+		if domain.IsPoison(e.Code) {
+			fmt.Fprintf(&toolLog, "=== %s %s attempt %d at %s\n%s\n\n",
+				e.Stage, e.Code, e.Attempt, e.CreatedAt.Format(time.RFC3339), e.Message)
+		}
+	}
+	if toolLog.Len() > 0 {
+		if err := os.WriteFile(filepath.Join(dir, "ffmpeg.log"), []byte(toolLog.String()), 0644); err != nil {
+			return fmt.Errorf("failed to write ffmpeg.log: %w", err)
+		}
+	}
+
+	uploader := s3.NewDirectoryUploader(a.s3Client, a.config.Worker.MaxParallelUploads).WithRetry(a.config.Retry)
+	artifacts, err := uploader.UploadDirectory(ctx, input.JobID, dir, a.s3Client.GetDefaultBucket(), job.DiagnosticsPrefix(), nil)
+	if err != nil {
+		logUploadFailures(logger, err)
+		return fmt.Errorf("failed to upload diagnostic bundle: %w", err)
+	}
+
+	// Recorded as artifacts so purging the job removes the bundle too
+	for _, artifact := range artifacts {
+		artifact.Type = domain.ArtifactTypeDiagnostic
+	}
+	if err := a.artifactRepo.CreateBatch(ctx, artifacts); err != nil {
+		return fmt.Errorf("failed to save diagnostic artifacts: %w", err)
+	}
+
+	logger.Info("diagnostic bundle uploaded",
+		zap.String("prefix", job.DiagnosticsPrefix()),
+		zap.Int("files", len(artifacts)))
+	return nil
+}
+
+// addWarning records a non-fatal problem on the job so it is returned by the API
+func (a *Activities) addWarning(ctx context.Context, jobID uuid.UUID, stage domain.Stage, code, message string) {
+	if err := a.jobRepo.AddWarning(ctx, jobID, domain.NewJobWarning(stage, code, message)); err != nil {
+		a.logger.Warn("failed to record warning",
+			zap.String("jobId", jobID.String()),
+			zap.String("code", code),
+			zap.Error(err))
+	}
+}
+
 // FinalizeJobInput holds finalize job input
 type FinalizeJobInput struct {
 	JobID  uuid.UUID        `json:"jobId"`
@@ -1012,7 +2788,7 @@ type FinalizeJobInput struct {
 	Error  string           `json:"error,omitempty"`
 }
 
-// FinalizeJob updates job status to final state (completed/failed/canceled)
+// FinalizeJob updates job status to final state (completed/failed/quarantined/canceled)
 func (a *Activities) FinalizeJob(ctx context.Context, input FinalizeJobInput) error {
 	logger := a.logger.With(
 		zap.String("jobId", input.JobID.String()),
@@ -1026,7 +2802,8 @@ func (a *Activities) FinalizeJob(ctx context.Context, input FinalizeJobInput) er
 	}
 
 	// Record error if job failed
-	if input.Status == domain.JobStatusFailed && input.Error != "" {
+	failed := input.Status == domain.JobStatusFailed || input.Status == domain.JobStatusQuarantined
+	if failed && input.Error != "" {
 		convErr := domain.NewConversionError(
 			input.JobID,
 			domain.StageUnknown,
@@ -1040,9 +2817,82 @@ func (a *Activities) FinalizeJob(ctx context.Context, input FinalizeJobInput) er
 		}
 	}
 
+	// Canceled jobs are announced by the API, which knows the reason
+	switch input.Status {
+	case domain.JobStatusCompleted:
+		a.events.Publish(events.JobEvent(events.JobCompleted, input.JobID, input.Status))
+		if a.config.Publish.OnComplete {
+			a.publishCompleted(ctx, input.JobID, logger)
+		}
+	case domain.JobStatusFailed:
+		event := events.JobEvent(events.JobFailed, input.JobID, input.Status)
+		event.Error = input.Error
+		a.events.Publish(event)
+	case domain.JobStatusQuarantined:
+		event := events.JobEvent(events.JobQuarantined, input.JobID, input.Status)
+		event.Error = input.Error
+		a.events.Publish(event)
+	}
+
 	// Update metrics
 	a.metrics.IncrementJobsTotal(string(input.Status))
+	a.recordJobDuration(ctx, input.JobID, input.Status, logger)
 
 	logger.Info("job finalized", zap.String("finalStatus", string(input.Status)))
 	return nil
 }
+
+// publishCompleted makes a completed job the live version of its video unless a
+// newer job of the video is live. Failures only leave a warning: the output is
+// complete and can still be promoted through the API.
+func (a *Activities) publishCompleted(ctx context.Context, jobID uuid.UUID, logger *zap.Logger) {
+	job, err := a.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		logger.Warn("failed to load job for publishing", zap.Error(err))
+		return
+	}
+	if job.VideoID == nil || job.DryRun {
+		return
+	}
+
+	current, published, err := a.publisher.Publish(ctx, job, domain.PublishActionComplete)
+	if err != nil {
+		logger.Warn("failed to publish job", zap.Error(err))
+		a.addWarning(ctx, jobID, domain.StageUnknown, domain.WarnCodeNotPublished, "output was not published: "+err.Error())
+		return
+	}
+	if !published && current.JobID != nil && *current.JobID != jobID {
+		logger.Info("newer job of the video is live, not publishing", zap.String("liveJobId", current.JobID.String()))
+	}
+}
+
+// recordJobDuration observes the time from job creation to finish, labeled by
+// outcome, source resolution bucket and the encoded tiers, "audio" for
+// audio-only jobs
+func (a *Activities) recordJobDuration(ctx context.Context, jobID uuid.UUID, status domain.JobStatus, logger *zap.Logger) {
+	job, err := a.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		logger.Warn("failed to load job for duration metric", zap.Error(err))
+		return
+	}
+	finishedAt := time.Now().UTC()
+	if job.FinishedAt != nil {
+		finishedAt = *job.FinishedAt
+	}
+
+	metadata, err := a.jobRepo.GetMetadata(ctx, jobID)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		logger.Warn("failed to load metadata for duration metric", zap.Error(err))
+	}
+
+	tiers := "audio"
+	if !job.Profile.IsAudioOnly() {
+		names := make([]string, 0, 2)
+		for _, tier := range a.enabledTiers() {
+			names = append(names, string(tier))
+		}
+		tiers = strings.Join(names, ",")
+	}
+
+	a.metrics.RecordJobDuration(string(status), metadata.ResolutionBucket(), tiers, finishedAt.Sub(job.CreatedAt).Seconds())
+}
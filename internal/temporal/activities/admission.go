@@ -0,0 +1,128 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.temporal.io/api/workflowservice/v1"
+	"google.golang.org/grpc"
+
+	"github.com/tvoe/converter/internal/metrics"
+)
+
+// TranscodeQueueSuffix names the task queue of local transcode activities:
+// transcodes of a workflow on queue "q" are scheduled on "q-transcode".
+const TranscodeQueueSuffix = "-transcode"
+
+// admissionPollHold bounds how long a held poll waits for admission to reopen
+// before it returns an empty poll to the SDK
+const admissionPollHold = 30 * time.Second
+
+// pollActivityTaskQueue is the gRPC method the worker polls activity tasks with
+const pollActivityTaskQueue = "/temporal.api.workflowservice.v1.WorkflowService/PollActivityTaskQueue"
+
+// TranscodeTaskQueue returns the transcode task queue of a workflow task queue
+func TranscodeTaskQueue(taskQueue string) string {
+	return taskQueue + TranscodeQueueSuffix
+}
+
+// Admission holds new local transcodes back while the worker is short of free
+// disk or GPU memory, so they start once resources are back instead of failing
+// mid-job. monitorDiskSpace in the worker feeds it samples.
+//
+// Transcodes run on their own task queues (see TranscodeTaskQueue). While
+// admission is closed the worker stops polling those queues, so held transcodes
+// stay in Temporal for other workers and take no activity slot here; every
+// other activity is polled as usual.
+type Admission struct {
+	minFreeDisk int64
+	minFreeGPU  int64
+	metrics     *metrics.Metrics
+
+	mu sync.Mutex
+	// reason is why admission is closed; empty while it is open
+	reason string
+	// open is closed when admission reopens
+	open chan struct{}
+}
+
+// NewAdmission creates an open admission controller. A threshold of 0 disables its check.
+func NewAdmission(minFreeDisk, minFreeGPU int64, m *metrics.Metrics) *Admission {
+	return &Admission{
+		minFreeDisk: minFreeDisk,
+		minFreeGPU:  minFreeGPU,
+		metrics:     m,
+		open:        make(chan struct{}),
+	}
+}
+
+// Update records a sample of free disk and GPU memory in bytes; a negative
+// value means it is unknown and is not checked. It returns why admission is
+// closed, empty if it is open, and whether that changed with this sample.
+func (ad *Admission) Update(freeDisk, freeGPU int64) (string, bool) {
+	var reason string
+	switch {
+	case ad.minFreeDisk > 0 && freeDisk >= 0 && freeDisk < ad.minFreeDisk:
+		reason = fmt.Sprintf("free disk %d bytes below %d", freeDisk, ad.minFreeDisk)
+	case ad.minFreeGPU > 0 && freeGPU >= 0 && freeGPU < ad.minFreeGPU:
+		reason = fmt.Sprintf("free GPU memory %d bytes below %d", freeGPU, ad.minFreeGPU)
+	}
+
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+
+	changed := (reason == "") != (ad.reason == "")
+	if ad.reason != "" && reason == "" {
+		close(ad.open)
+		ad.open = make(chan struct{})
+	}
+	ad.reason = reason
+	return reason, changed
+}
+
+// state returns why admission is closed and the channel closed when it reopens
+func (ad *Admission) state() (string, <-chan struct{}) {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	return ad.reason, ad.open
+}
+
+// PollInterceptor returns a gRPC client interceptor for the worker's Temporal
+// client that holds polls of transcode task queues while admission is closed.
+// A held poll waits up to admissionPollHold for admission to reopen and
+// otherwise returns empty, as a long poll that found no task does.
+func (ad *Admission) PollInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if method != pollActivityTaskQueue {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		poll, ok := req.(*workflowservice.PollActivityTaskQueueRequest)
+		if !ok || !strings.HasSuffix(poll.GetTaskQueue().GetName(), TranscodeQueueSuffix) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		reason, open := ad.state()
+		if reason == "" {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		ad.metrics.IncrementAdmissionWaits()
+
+		timer := time.NewTimer(admissionPollHold)
+		defer timer.Stop()
+		select {
+		case <-open:
+			return invoker(ctx, method, req, reply, cc, opts...)
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		return nil
+	}
+}
+
+// Admission returns the admission controller of local transcodes
+func (a *Activities) Admission() *Admission {
+	return a.admission
+}
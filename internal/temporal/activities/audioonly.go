@@ -0,0 +1,177 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/ffmpeg"
+)
+
+// TranscodeAudio encodes the main audio track of the source once per bitrate
+// of an audio-only profile, in place of Transcode. The outputs are audio-only
+// MP4s named as domain.AudioOnlyName in the transcoded directory, which
+// SegmentHLS packages and UploadArtifacts uploads as renditions when HLS is
+// skipped.
+func (a *Activities) TranscodeAudio(ctx context.Context, input TranscodeInput) (_ *TranscodeOutput, err error) {
+	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "TranscodeAudio"))
+	startTime := time.Now()
+	defer func() {
+		a.metrics.RecordStageDuration(string(domain.StageTranscoding), time.Since(startTime).Seconds())
+	}()
+	stageDone := a.startStage(ctx, input.JobID, domain.StageTranscoding)
+	defer func() { stageDone(err) }()
+
+	if err := a.updateProgress(ctx, input.JobID, domain.StageTranscoding, 0); err != nil {
+		logger.Error("failed to update progress", zap.Error(err))
+	}
+
+	job, err := a.jobRepo.GetByID(ctx, input.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	if track := input.Metadata.MainAudioTrack(); track != nil && track.Channels > 2 {
+		a.addWarning(ctx, input.JobID, domain.StageTranscoding, domain.WarnCodeAudioDownmixed,
+			fmt.Sprintf("audio track %d downmixed from %d channels to stereo", track.Index, track.Channels))
+	}
+
+	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, input.JobID)
+	inputPath := sourceInput(job, workspace)
+	builder := a.newBuilder().WithTrim(job.Profile.Trim).WithAudio(job.Profile.Audio)
+	runner := a.newRunner()
+	codec := job.Profile.AudioOnly.AudioCodec()
+	bitrates := job.Profile.AudioOnly.AudioBitrates()
+
+	// Audio encodes are light, so the renditions run concurrently within the
+	// worker's FFmpeg limit
+	var (
+		progressMu sync.Mutex
+		completed  int
+		outputs    = make(map[string]string, len(bitrates))
+	)
+	tasks := make([]func(ctx context.Context) error, 0, len(bitrates))
+	for _, bitrate := range bitrates {
+		bitrate := bitrate
+		tasks = append(tasks, func(ctx context.Context) error {
+			name := domain.AudioOnlyName(bitrate)
+			cmd := builder.BuildAudioOnlyCommand(inputPath, workspace.TranscodedPath(name), input.Metadata, codec, bitrate)
+			if err := runner.Run(ctx, cmd.Args, func(p ffmpeg.Progress) {
+				activity.RecordHeartbeat(ctx, name)
+			}); err != nil {
+				return fmt.Errorf("audio=%s: %w", name, err)
+			}
+
+			progressMu.Lock()
+			completed++
+			outputs[name] = cmd.OutputPath
+			progress := completed * 100 / len(bitrates)
+			progressMu.Unlock()
+
+			a.updateProgress(ctx, input.JobID, domain.StageTranscoding, progress)
+			logger.Info("audio rendition encoded", zap.String("rendition", name), zap.String("codec", string(codec)))
+			return nil
+		})
+	}
+
+	if err := runParallel(ctx, a.config.Worker.MaxParallelFFmpeg, tasks); err != nil {
+		if a.jobCanceled(ctx, input.JobID) {
+			return nil, a.discardCanceled(ctx, input.JobID, err)
+		}
+		return nil, a.recordError(ctx, input.JobID, domain.StageTranscoding, domain.ErrCodeFFmpegFailed, err)
+	}
+
+	return &TranscodeOutput{AudioOutputs: outputs}, nil
+}
+
+// segmentHLSAudioOnly segments the renditions of an audio-only profile and
+// writes their master playlist. Segments are fMP4, the only HLS container
+// of Opus.
+func (a *Activities) segmentHLSAudioOnly(
+	ctx context.Context,
+	input HLSInput,
+	job *domain.Job,
+	hlsDir string,
+	segmentDuration int,
+	builder *ffmpeg.CommandBuilder,
+	runner *ffmpeg.Runner,
+	encryption *ffmpeg.EncryptionInfo,
+	logger *zap.Logger,
+) (*HLSOutput, error) {
+	builder.WithSegmentContainer(domain.ContainerFMP4)
+	bitrates := job.Profile.AudioOnly.AudioBitrates()
+	muxEncryption, preview := splitPreviewEncryption(job, encryption)
+
+	var (
+		progressMu sync.Mutex
+		completed  int
+	)
+	tasks := make([]func(ctx context.Context) error, 0, len(bitrates))
+	for _, bitrate := range bitrates {
+		name := domain.AudioOnlyName(bitrate)
+		inputPath, ok := input.AudioOutputs[name]
+		if !ok {
+			return nil, fmt.Errorf("audio rendition %s was not transcoded", name)
+		}
+		tasks = append(tasks, func(ctx context.Context) error {
+			cmd := builder.BuildHLSAudioCommandForTier(inputPath, hlsDir, name, 0, segmentDuration, domain.TierLegacy, muxEncryption)
+			if err := runner.Run(ctx, cmd.Args, func(p ffmpeg.Progress) {
+				activity.RecordHeartbeat(ctx, name)
+			}); err != nil {
+				return fmt.Errorf("audio=%s: %w", name, err)
+			}
+
+			if preview > 0 {
+				if _, err := ffmpeg.ApplyPreviewEncryption(cmd.OutputPath, encryption, preview); err != nil {
+					return fmt.Errorf("audio=%s preview encryption: %w", name, err)
+				}
+			}
+
+			progressMu.Lock()
+			completed++
+			progress := completed * 100 / len(bitrates)
+			progressMu.Unlock()
+
+			a.updateProgress(ctx, input.JobID, domain.StageHLSSegmentation, progress)
+			logger.Info("HLS segmentation complete for audio rendition", zap.String("rendition", name))
+			return nil
+		})
+	}
+
+	if err := runParallel(ctx, a.config.Worker.MaxParallelFFmpeg, tasks); err != nil {
+		return nil, a.recordError(ctx, input.JobID, domain.StageHLSSegmentation, domain.ErrCodeFFmpegFailed, err)
+	}
+
+	if _, err := a.insertCueMarkers(ctx, input, job, hlsDir, logger); err != nil {
+		return nil, err
+	}
+
+	masterContent := ffmpeg.GenerateAudioMasterPlaylist(bitrates, job.Profile.AudioOnly.CodecString())
+	masterPath := filepath.Join(hlsDir, "master.m3u8")
+	if err := os.WriteFile(masterPath, []byte(masterContent), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write master playlist: %w", err)
+	}
+
+	a.updateProgress(ctx, input.JobID, domain.StageHLSSegmentation, 100)
+	logger.Info("audio-only HLS segmentation complete",
+		zap.String("masterPlaylist", masterPath),
+		zap.Int("renditions", len(bitrates)),
+		zap.Bool("encrypted", encryption != nil))
+
+	output := &HLSOutput{
+		MasterPlaylistPath: masterPath,
+		HLSDir:             hlsDir,
+		Encrypted:          encryption != nil,
+	}
+	if encryption != nil {
+		output.KeyPath = encryption.KeyPath
+	}
+	return output, nil
+}
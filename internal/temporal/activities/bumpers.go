@@ -0,0 +1,236 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/ffmpeg"
+	"github.com/tvoe/converter/internal/storage/s3"
+)
+
+// StitchInput holds input for stitching the intro and outro to the renditions
+type StitchInput struct {
+	JobID     uuid.UUID             `json:"jobId"`
+	Metadata  *domain.VideoMetadata `json:"metadata"`
+	Transcode *TranscodeOutput      `json:"transcode"`
+}
+
+// StitchOutput holds the length of the stitched bumpers. The renditions keep
+// their paths.
+type StitchOutput struct {
+	// IntroDuration shifts everything taken from the source, such as subtitles
+	IntroDuration time.Duration `json:"introDuration,omitempty"`
+	OutroDuration time.Duration `json:"outroDuration,omitempty"`
+}
+
+// bumper is a downloaded intro or outro clip
+type bumper struct {
+	name      string
+	path      string
+	metadata  *domain.VideoMetadata
+	scaleMode string
+}
+
+// StitchBumpers concatenates the intro and outro of the profile with every
+// rendition before segmentation. The clips are encoded once per rendition with
+// its settings, so the rendition itself is copied, not encoded again. The
+// mezzanine is an archival master of the source and is left as is.
+func (a *Activities) StitchBumpers(ctx context.Context, input StitchInput) (*StitchOutput, error) {
+	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "StitchBumpers"))
+	startTime := time.Now()
+	defer func() {
+		a.metrics.RecordStageDuration(string(domain.StageTranscoding), time.Since(startTime).Seconds())
+	}()
+
+	job, err := a.jobRepo.GetByID(ctx, input.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, input.JobID)
+	if err := os.MkdirAll(workspace.Paths().Bumpers, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create bumpers directory: %w", err)
+	}
+
+	output := &StitchOutput{}
+	var intro, outro *bumper
+	if c := job.Profile.Intro; c != nil {
+		if intro, err = a.downloadBumper(ctx, job, workspace, "intro", c); err != nil {
+			return nil, err
+		}
+		output.IntroDuration = intro.metadata.Duration
+	}
+	if c := job.Profile.Outro; c != nil {
+		if outro, err = a.downloadBumper(ctx, job, workspace, "outro", c); err != nil {
+			return nil, err
+		}
+		output.OutroDuration = outro.metadata.Duration
+	}
+	if intro == nil && outro == nil {
+		return output, nil
+	}
+
+	// Bumpers are encoded like the renditions
+	builder := a.newBuilder()
+	if hdr := input.Metadata.HDR; hdr != nil && a.hdrCPUReason(builder, hdr) != "" {
+		builder = builder.WithoutGPU()
+	}
+	builder, releaseGPU := a.assignGPU(builder, logger)
+	defer releaseGPU()
+	runner := a.newRunner()
+	checkpoint := a.loadCheckpoint(workspace, logger)
+	hb := newHeartbeat(ctx)
+
+	for tier, paths := range input.Transcode.TierOutputPaths {
+		for quality, path := range paths {
+			rendition := "bumpers/" + ffmpeg.RenditionKey(string(tier), string(quality))
+			if _, ok := checkpoint.Lookup(rendition); ok {
+				logger.Info("bumpers already stitched, skipping", zap.String("rendition", rendition))
+				continue
+			}
+
+			err := a.stitchRendition(ctx, workspace, job, input.Metadata, path, tier, quality, intro, outro, builder, runner, hb)
+			if err != nil {
+				return nil, a.recordError(ctx, input.JobID, domain.StageTranscoding, domain.ErrCodeBumperFailed,
+					fmt.Errorf("tier=%s quality=%s: %w", tier, quality, err))
+			}
+			// A crash before this record stitches the rendition again on retry,
+			// from the original kept until now
+			a.recordCheckpoint(input.JobID, checkpoint, rendition, path)
+			os.Remove(bumperOriginalPath(workspace, tier, quality))
+		}
+	}
+
+	logger.Info("bumpers stitched",
+		zap.Duration("intro", output.IntroDuration),
+		zap.Duration("outro", output.OutroDuration))
+
+	return output, nil
+}
+
+// downloadBumper downloads and probes the intro or outro clip. It is read from
+// the source bucket of the job unless the profile names another one.
+func (a *Activities) downloadBumper(ctx context.Context, job *domain.Job, workspace *ffmpeg.Workspace, name string, c *domain.IntroConfig) (*bumper, error) {
+	bucket := c.Bucket
+	if bucket == "" {
+		bucket = job.SourceBucket
+	}
+	path := workspace.BumperPath(name + filepath.Ext(c.S3Key))
+	if err := a.s3Client.Download(ctx, bucket, c.S3Key, path); err != nil {
+		return nil, a.recordError(ctx, job.ID, domain.StageTranscoding, s3.ErrorCode(err),
+			fmt.Errorf("failed to download %s: %w", name, err))
+	}
+
+	metadata, err := ffmpeg.NewProber(a.config.FFmpeg.FFprobePath).Probe(ctx, path)
+	if err == nil && (metadata.VideoCodec == "" || metadata.Duration <= 0) {
+		err = fmt.Errorf("no video stream of known duration")
+	}
+	if err != nil {
+		return nil, a.recordError(ctx, job.ID, domain.StageTranscoding, domain.ErrCodeBumperFailed,
+			fmt.Errorf("invalid %s: %w", name, err))
+	}
+
+	scaleMode := c.ScaleMode
+	if scaleMode == "" {
+		scaleMode = domain.BumperScaleFit
+	}
+	return &bumper{name: name, path: path, metadata: metadata, scaleMode: scaleMode}, nil
+}
+
+// bumperOriginalPath returns where the rendition of tier and quality is kept
+// unstitched while its bumpers are stitched
+func bumperOriginalPath(workspace *ffmpeg.Workspace, tier domain.EncodingTier, quality domain.Quality) string {
+	return workspace.BumperPath(fmt.Sprintf("%s_%s_original.mp4", tier, quality))
+}
+
+// stitchRendition encodes the bumpers like the rendition at path and replaces
+// it with the concatenation of intro, rendition and outro. The rendition is
+// first moved aside and stitched from there, so a retry before the checkpoint
+// stitches the original again rather than adding the bumpers twice.
+func (a *Activities) stitchRendition(
+	ctx context.Context,
+	workspace *ffmpeg.Workspace,
+	job *domain.Job,
+	metadata *domain.VideoMetadata,
+	path string,
+	tier domain.EncodingTier,
+	quality domain.Quality,
+	intro, outro *bumper,
+	builder *ffmpeg.CommandBuilder,
+	runner *ffmpeg.Runner,
+	hb *heartbeat,
+) error {
+	original := bumperOriginalPath(workspace, tier, quality)
+	if _, err := os.Stat(original); os.IsNotExist(err) {
+		if err := os.Rename(path, original); err != nil {
+			return fmt.Errorf("failed to move rendition aside: %w", err)
+		}
+	}
+
+	renditionMeta, err := ffmpeg.NewProber(a.config.FFmpeg.FFprobePath).Probe(ctx, original)
+	if err != nil {
+		return fmt.Errorf("failed to probe rendition: %w", err)
+	}
+
+	prefix := fmt.Sprintf("%s_%s_", tier, quality)
+	encode := func(b *bumper) (string, error) {
+		cmd := builder.BuildBumperCommandForTier(b.path, workspace.BumperPath(prefix+b.name+".mp4"),
+			b.metadata, renditionMeta, metadata, quality, job.Profile, tier, b.scaleMode)
+		if err := runner.Run(ctx, cmd.Args, func(ffmpeg.Progress) {
+			hb.Update(func(*HeartbeatDetails) {})
+		}); err != nil {
+			return "", fmt.Errorf("failed to encode %s: %w", b.name, err)
+		}
+		return cmd.OutputPath, nil
+	}
+
+	var parts []string
+	if intro != nil {
+		part, err := encode(intro)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, part)
+	}
+	parts = append(parts, original)
+	if outro != nil {
+		part, err := encode(outro)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, part)
+	}
+
+	// The concat demuxer reads the parts from a list file
+	var list strings.Builder
+	for _, part := range parts {
+		fmt.Fprintf(&list, "file '%s'\n", strings.ReplaceAll(part, "'", `'\''`))
+	}
+	listPath := workspace.BumperPath(prefix + "concat.txt")
+	if err := os.WriteFile(listPath, []byte(list.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write concat list: %w", err)
+	}
+
+	stitchedPath := workspace.BumperPath(prefix + "stitched.mp4")
+	cmd := builder.BuildConcatCommand(listPath, stitchedPath)
+	if err := runner.Run(ctx, cmd.Args, nil); err != nil {
+		return fmt.Errorf("failed to concatenate: %w", err)
+	}
+	if err := ffmpeg.ValidateOutput(stitchedPath); err != nil {
+		return err
+	}
+	hb.Update(func(*HeartbeatDetails) {})
+
+	if err := os.Rename(stitchedPath, path); err != nil {
+		return fmt.Errorf("failed to replace rendition: %w", err)
+	}
+	return nil
+}
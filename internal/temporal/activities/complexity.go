@@ -0,0 +1,154 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/ffmpeg"
+)
+
+// Samples of the source encoded by the per-title probe
+const (
+	complexitySamples      = 5
+	complexitySampleLength = 4 * time.Second
+)
+
+// AnalyzeComplexity scales the ladder of a job to the complexity of its source.
+// Samples spread over the source are encoded at a constant CRF at the top rung
+// the job encodes, and every rung's video bitrate is multiplied by the ratio of
+// the resulting bitrate to the nominal one, within the PerTitleConfig bounds.
+// The scaled ladder is stored in the job profile, so every later activity uses
+// it. A job analyzed before keeps its ladder; a failed probe keeps the nominal
+// ladder with a warning.
+func (a *Activities) AnalyzeComplexity(ctx context.Context, input TranscodeInput) (*domain.ComplexityAnalysis, error) {
+	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "AnalyzeComplexity"))
+
+	analysis, err := a.jobRepo.GetComplexity(ctx, input.JobID)
+	if err != nil {
+		return nil, err
+	}
+	if analysis != nil {
+		return analysis, nil
+	}
+
+	job, err := a.jobRepo.GetByID(ctx, input.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	perTitle := domain.PerTitleConfig{}
+	if job.Profile.PerTitle != nil {
+		perTitle = *job.Profile.PerTitle
+	}
+	perTitle = perTitle.WithDefaults()
+
+	// The tallest rung the job encodes is the reference; origin has no nominal bitrate
+	ladder := job.Profile.QualitiesCustom
+	var reference domain.Quality
+	var params domain.QualityConfig
+	for _, q := range domain.FilterQualitiesForResolution(job.Profile.Qualities, ladder, input.Metadata.Height) {
+		if p := ladder.Params(q); q != domain.QualityOrigin && p.Height > params.Height {
+			reference, params = q, p
+		}
+	}
+	if reference == "" {
+		logger.Info("no rung to analyze, ladder kept")
+		return nil, nil
+	}
+
+	probeBitrate, samples, err := a.probeBitrate(ctx, job, input.Metadata, params, perTitle.CRF)
+	if err != nil {
+		logger.Warn("complexity probe failed, nominal ladder kept", zap.Error(err))
+		a.addWarning(ctx, input.JobID, domain.StageTranscoding, domain.WarnCodePerTitleSkipped,
+			"per-title analysis failed, the nominal ladder is used")
+		return nil, nil
+	}
+
+	analysis = domain.NewComplexityAnalysis(reference, params, samples, probeBitrate, perTitle)
+
+	profile := job.Profile
+	profile.QualitiesCustom = domain.ScaleLadder(ladder, job.Profile.Qualities, analysis.Scale)
+	if err := a.jobRepo.SetComplexity(ctx, input.JobID, analysis, profile); err != nil {
+		return nil, err
+	}
+
+	logger.Info("ladder scaled to source complexity",
+		zap.String("reference", string(reference)),
+		zap.Int64("probeBitrate", analysis.ProbeBitrate),
+		zap.Int64("nominalBitrate", analysis.NominalBitrate),
+		zap.Float64("scale", analysis.Scale),
+	)
+	return analysis, nil
+}
+
+// probeBitrate encodes samples of the source at the given rung and CRF and
+// returns their average video bitrate and the number of samples
+func (a *Activities) probeBitrate(
+	ctx context.Context,
+	job *domain.Job,
+	metadata *domain.VideoMetadata,
+	params domain.QualityConfig,
+	crf int,
+) (int64, int, error) {
+	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, job.ID)
+	probeDir := filepath.Join(workspace.Paths().Meta, "complexity")
+	if err := os.MkdirAll(probeDir, 0755); err != nil {
+		return 0, 0, fmt.Errorf("failed to create probe directory: %w", err)
+	}
+	defer os.RemoveAll(probeDir)
+
+	builder := a.newBuilder()
+	runner := a.newRunner()
+	inputPath := sourceInput(job, workspace)
+
+	var bytes int64
+	var encoded time.Duration
+	starts := complexitySampleStarts(metadata.Duration)
+	for i, start := range starts {
+		length := complexitySampleLength
+		if len(starts) == 1 && metadata.Duration > 0 && metadata.Duration < length {
+			length = metadata.Duration
+		}
+
+		outputPath := filepath.Join(probeDir, fmt.Sprintf("sample_%d.mp4", i))
+		// Samples are taken from the trimmed segment
+		cmd := builder.BuildComplexityProbeCommand(inputPath, outputPath, job.Profile.Trim.Offset()+start, length, params, metadata, crf)
+		if err := runner.Run(ctx, cmd.Args, func(ffmpeg.Progress) {
+			activity.RecordHeartbeat(ctx, i)
+		}); err != nil {
+			return 0, 0, fmt.Errorf("sample %d: %w", i, err)
+		}
+
+		info, err := os.Stat(outputPath)
+		if err != nil {
+			return 0, 0, fmt.Errorf("sample %d: %w", i, err)
+		}
+		bytes += info.Size()
+		encoded += length
+		activity.RecordHeartbeat(ctx, i+1)
+	}
+
+	if encoded <= 0 || bytes == 0 {
+		return 0, 0, fmt.Errorf("no video was encoded")
+	}
+	return int64(float64(bytes*8) / encoded.Seconds()), len(starts), nil
+}
+
+// complexitySampleStarts spreads the samples evenly over the source, away
+// from its start and end. Sources too short for them get one sample.
+func complexitySampleStarts(duration time.Duration) []time.Duration {
+	if duration < complexitySamples*complexitySampleLength*2 {
+		return []time.Duration{0}
+	}
+	starts := make([]time.Duration, complexitySamples)
+	for i := range starts {
+		starts[i] = duration*time.Duration(i+1)/(complexitySamples+1) - complexitySampleLength/2
+	}
+	return starts
+}
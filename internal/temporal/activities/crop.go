@@ -0,0 +1,86 @@
+package activities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"go.temporal.io/sdk/activity"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/ffmpeg"
+)
+
+// DetectCrop measures the letterbox and pillarbox bars of the source with
+// cropdetect over the samples the per-title probe uses and returns the
+// metadata every later activity encodes with. A source with bars gets the
+// picture area as Crop and its size as Width and Height, and the rungs of the
+// job profile are fitted to it, so renditions carry no bars. The crop is
+// stored with the metadata and written to metadata.json; a job cropped before
+// keeps its crop. A failed detection encodes the full frame with a warning.
+func (a *Activities) DetectCrop(ctx context.Context, input TranscodeInput) (*domain.VideoMetadata, error) {
+	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "DetectCrop"))
+
+	stored, err := a.jobRepo.GetMetadata(ctx, input.JobID)
+	if err != nil {
+		return nil, err
+	}
+	if stored != nil && stored.Crop != nil {
+		return stored, nil
+	}
+
+	job, err := a.jobRepo.GetByID(ctx, input.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	metadata := input.Metadata
+	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, input.JobID)
+	inputPath := sourceInput(job, workspace)
+	limit := job.Profile.Algorithm.AutoCrop.CropLimit()
+
+	var area *domain.CropRect
+	for i, start := range complexitySampleStarts(metadata.Duration) {
+		// Samples are taken from the trimmed segment
+		sample, err := ffmpeg.DetectCrop(ctx, a.config.FFmpeg.BinaryPath, inputPath, metadata,
+			job.Profile.Trim.Offset()+start, complexitySampleLength, limit)
+		if err != nil {
+			logger.Warn("crop detection failed, full frame kept", zap.Int("sample", i), zap.Error(err))
+			a.addWarning(ctx, input.JobID, domain.StageTranscoding, domain.WarnCodeAutoCropSkipped,
+				"black bar detection failed, the full frame is encoded")
+			return metadata, nil
+		}
+		if area == nil {
+			area = sample
+		} else {
+			*area = area.Union(*sample)
+		}
+		activity.RecordHeartbeat(ctx, i)
+	}
+
+	if area == nil || !area.Significant(metadata.Width, metadata.Height) {
+		logger.Info("no black bars found, full frame kept")
+		return metadata, nil
+	}
+
+	cropped := *metadata
+	cropped.Crop = area
+	cropped.Width, cropped.Height = area.Width, area.Height
+
+	profile := job.Profile
+	profile.QualitiesCustom = domain.CropLadder(job.Profile.QualitiesCustom, job.Profile.Qualities, area.Width, area.Height)
+	if err := a.jobRepo.SetCrop(ctx, input.JobID, &cropped, profile); err != nil {
+		return nil, err
+	}
+
+	metaJSON, _ := json.MarshalIndent(&cropped, "", "  ")
+	os.WriteFile(workspace.MetaPath("metadata.json"), metaJSON, 0644)
+
+	logger.Info("black bars cropped",
+		zap.String("crop", area.Filter()),
+		zap.Int("sourceWidth", metadata.Width),
+		zap.Int("sourceHeight", metadata.Height))
+	return &cropped, nil
+}
@@ -0,0 +1,74 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/ffmpeg"
+)
+
+// insertCueMarkers marks the ad breaks of the profile in every video and
+// audio media playlist under hlsDir and returns them as placed, for the DASH
+// manifest. Cue points are source times, moved by the trim and behind the
+// intro; ones outside the output are skipped with a warning. Segments of all
+// renditions are cut at the same key frames, so the breaks land on the same
+// boundaries in each playlist. Subtitle and I-frame playlists get no markers.
+func (a *Activities) insertCueMarkers(ctx context.Context, input HLSInput, job *domain.Job, hlsDir string, logger *zap.Logger) ([]ffmpeg.CueMarker, error) {
+	if len(job.Profile.CuePoints) == 0 {
+		return nil, nil
+	}
+
+	breaks := make([]ffmpeg.CueMarker, 0, len(job.Profile.CuePoints))
+	for _, cue := range job.Profile.CuePoints {
+		start, ok := cue.OutputTime(job.Profile.Trim, input.IntroDuration)
+		if !ok || (input.Duration > 0 && start >= input.Duration) {
+			a.addWarning(ctx, input.JobID, domain.StageHLSSegmentation, domain.WarnCodeCuePointSkipped,
+				fmt.Sprintf("cue point at %.3fs skipped: outside the output", cue.Time))
+			continue
+		}
+		breaks = append(breaks, ffmpeg.CueMarker{Start: start, Duration: time.Duration(cue.Duration * float64(time.Second))})
+	}
+	if len(breaks) == 0 {
+		return nil, nil
+	}
+
+	var placed []ffmpeg.CueMarker
+	err := filepath.WalkDir(hlsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ffmpeg.SubtitlesDir {
+			return filepath.SkipDir
+		}
+		name := d.Name()
+		if d.IsDir() || filepath.Ext(name) != ".m3u8" || name == "master.m3u8" || strings.HasSuffix(name, "_iframes.m3u8") {
+			return nil
+		}
+		markers, err := ffmpeg.InsertCueMarkers(path, breaks)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if placed == nil {
+			placed = markers
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, a.recordError(ctx, input.JobID, domain.StageHLSSegmentation, domain.ErrCodeInternalError,
+			fmt.Errorf("failed to insert ad markers: %w", err))
+	}
+
+	if len(placed) < len(breaks) {
+		a.addWarning(ctx, input.JobID, domain.StageHLSSegmentation, domain.WarnCodeCuePointSkipped,
+			fmt.Sprintf("%d cue points skipped: they fall on the segment boundary of an earlier break", len(breaks)-len(placed)))
+	}
+	logger.Info("ad markers inserted", zap.Int("breaks", len(placed)))
+	return placed, nil
+}
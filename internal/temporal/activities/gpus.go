@@ -0,0 +1,80 @@
+package activities
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/ffmpeg"
+	"github.com/tvoe/converter/internal/metrics"
+)
+
+// GPUPool spreads the GPU transcodes of a worker over its devices: each
+// transcode gets the device running the fewest of them, so a worker with four
+// GPUs runs four concurrent transcodes on four different GPUs. Devices are
+// NVIDIA GPU indexes for NVENC and DRM render nodes for QSV and VAAPI; the
+// empty device is the default one of the backend.
+type GPUPool struct {
+	devices []string
+	metrics *metrics.Metrics
+
+	mu     sync.Mutex
+	active []int
+}
+
+// NewGPUPool creates a pool of devices; without devices it hands out the default device
+func NewGPUPool(devices []string, m *metrics.Metrics) *GPUPool {
+	if len(devices) == 0 {
+		devices = []string{""}
+	}
+	p := &GPUPool{
+		devices: devices,
+		metrics: m,
+		active:  make([]int, len(devices)),
+	}
+	for _, device := range devices {
+		m.SetGPUTranscodes(device, 0)
+	}
+	return p
+}
+
+// Acquire assigns the least busy device to a transcode. The returned function
+// releases it and must be called once the transcode is done.
+func (p *GPUPool) Acquire() (string, func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	best := 0
+	for i, n := range p.active {
+		if n < p.active[best] {
+			best = i
+		}
+	}
+	p.active[best]++
+	p.metrics.SetGPUTranscodes(p.devices[best], p.active[best])
+
+	var once sync.Once
+	return p.devices[best], func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.active[best]--
+			p.metrics.SetGPUTranscodes(p.devices[best], p.active[best])
+		})
+	}
+}
+
+// assignGPU pins a GPU builder to a device of the pool. The default device of
+// a pool without devices keeps the device the builder has, WORKER_HWACCEL_DEVICE.
+// The returned function releases the device; it is a no-op for CPU builders.
+func (a *Activities) assignGPU(builder *ffmpeg.CommandBuilder, logger *zap.Logger) (*ffmpeg.CommandBuilder, func()) {
+	if !builder.UsesGPU() {
+		return builder, func() {}
+	}
+	device, release := a.gpus.Acquire()
+	if device == "" {
+		return builder, release
+	}
+	logger.Info("GPU assigned", zap.String("device", device))
+	return builder.WithHWDevice(device), release
+}
@@ -0,0 +1,177 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/activity"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/ffmpeg"
+)
+
+// HardsubInput holds input for burning subtitles into the renditions
+type HardsubInput struct {
+	JobID     uuid.UUID             `json:"jobId"`
+	Metadata  *domain.VideoMetadata `json:"metadata"`
+	Transcode *TranscodeOutput      `json:"transcode"`
+}
+
+// HardsubOutput holds the renditions with burned-in subtitles. Name labels the
+// set with the language; no paths means the set was skipped.
+type HardsubOutput struct {
+	Name        string                    `json:"name,omitempty"`
+	OutputPaths map[domain.Quality]string `json:"outputPaths,omitempty"`
+}
+
+// BurnSubtitles encodes the H.264 renditions again with the subtitle track of
+// the profile's hardsub language burned in, for players that cannot render
+// text subtitles. It runs after ExtractSubtitles and reads the WebVTT file
+// extracted there, already shifted for the intro. The renditions go to
+// transcoded/hardsub_<language>/ and SegmentHLS packages them as a separate
+// HLS output. A source without the track gets no set and a warning.
+func (a *Activities) BurnSubtitles(ctx context.Context, input HardsubInput) (*HardsubOutput, error) {
+	logger := a.logger.With(zap.String("jobId", input.JobID.String()), zap.String("activity", "BurnSubtitles"))
+	startTime := time.Now()
+	defer func() {
+		a.metrics.RecordStageDuration(string(domain.StageTranscoding), time.Since(startTime).Seconds())
+	}()
+
+	job, err := a.jobRepo.GetByID(ctx, input.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	hardsub := job.Profile.Hardsub
+	if hardsub == nil {
+		return &HardsubOutput{}, nil
+	}
+
+	// Players without text rendering decode H.264 only
+	renditions := input.Transcode.TierOutputPaths[domain.TierLegacy]
+	if len(input.Transcode.TierOutputPaths) == 0 {
+		renditions = input.Transcode.OutputPaths
+	}
+	if len(renditions) == 0 {
+		a.addWarning(ctx, input.JobID, domain.StageTranscoding, domain.WarnCodeHardsubSkipped,
+			"burned-in subtitles skipped: the legacy tier was not encoded")
+		return &HardsubOutput{}, nil
+	}
+
+	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, input.JobID)
+	subtitlePath := workspace.SubtitlePath(hardsub.TrackName())
+	if _, err := os.Stat(subtitlePath); err != nil {
+		logger.Warn("no subtitle track to burn in", zap.String("track", hardsub.TrackName()))
+		a.addWarning(ctx, input.JobID, domain.StageTranscoding, domain.WarnCodeHardsubSkipped,
+			fmt.Sprintf("burned-in subtitles skipped: no %s subtitle track was extracted", hardsub.TrackName()))
+		return &HardsubOutput{}, nil
+	}
+
+	name := hardsub.Name()
+	outputDir := filepath.Join(workspace.Paths().Transcoded, name)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create hardsub directory: %w", err)
+	}
+
+	builder := a.newBuilder()
+	runner := a.newRunner()
+	checkpoint := a.loadCheckpoint(workspace, logger)
+	hb := newHeartbeat(ctx)
+
+	output := &HardsubOutput{Name: name, OutputPaths: make(map[domain.Quality]string, len(renditions))}
+	for quality, path := range renditions {
+		rendition := name + "/" + string(quality)
+		if done, ok := checkpoint.Lookup(rendition); ok {
+			logger.Info("subtitles already burned in, skipping", zap.String("rendition", rendition))
+			output.OutputPaths[quality] = done
+			continue
+		}
+
+		cmd := builder.BuildHardsubCommand(path, outputDir, subtitlePath, quality, input.Metadata, job.Profile)
+		if err := runner.Run(ctx, cmd.Args, func(p ffmpeg.Progress) {
+			hb.Update(func(d *HeartbeatDetails) {
+				d.Rendition, d.FramesEncoded = rendition, p.Frame
+			})
+		}); err != nil {
+			return nil, a.recordError(ctx, input.JobID, domain.StageTranscoding, domain.ErrCodeFFmpegFailed,
+				fmt.Errorf("hardsub quality=%s: %w", quality, err))
+		}
+		a.recordCheckpoint(input.JobID, checkpoint, rendition, cmd.OutputPath)
+		output.OutputPaths[quality] = cmd.OutputPath
+		logger.Info("subtitles burned in", zap.String("rendition", rendition))
+	}
+
+	return output, nil
+}
+
+// segmentHardsub segments the renditions with burned-in subtitles into
+// hls/<name>/ with a master playlist of their own, encrypted like the main
+// output. The subtitles are in the picture, so the master declares none.
+func (a *Activities) segmentHardsub(
+	ctx context.Context,
+	input HLSInput,
+	job *domain.Job,
+	hlsDir string,
+	segmentDuration int,
+	builder *ffmpeg.CommandBuilder,
+	runner *ffmpeg.Runner,
+	encryption *ffmpeg.EncryptionInfo,
+	logger *zap.Logger,
+) error {
+	dir := filepath.Join(hlsDir, input.Hardsub.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create hardsub HLS directory: %w", err)
+	}
+
+	muxEncryption, preview := splitPreviewEncryption(job, encryption)
+	var (
+		qualities = make([]domain.Quality, 0, len(input.Hardsub.OutputPaths))
+		iFrames   = make(map[domain.Quality]*ffmpeg.IFramePlaylist, len(input.Hardsub.OutputPaths))
+		iFramesMu sync.Mutex
+		tasks     = make([]func(ctx context.Context) error, 0, len(input.Hardsub.OutputPaths))
+	)
+	for quality, inputPath := range input.Hardsub.OutputPaths {
+		quality, inputPath := quality, inputPath
+		qualities = append(qualities, quality)
+		tasks = append(tasks, func(ctx context.Context) error {
+			cmd := builder.BuildHLSCommandForTier(inputPath, dir, string(quality), segmentDuration, domain.TierLegacy, muxEncryption)
+			if err := runner.Run(ctx, cmd.Args, func(p ffmpeg.Progress) {
+				activity.RecordHeartbeat(ctx, input.Hardsub.Name)
+			}); err != nil {
+				return fmt.Errorf("%s quality=%s: %w", input.Hardsub.Name, quality, err)
+			}
+
+			if preview > 0 {
+				if _, err := ffmpeg.ApplyPreviewEncryption(cmd.OutputPath, encryption, preview); err != nil {
+					return fmt.Errorf("%s quality=%s preview encryption: %w", input.Hardsub.Name, quality, err)
+				}
+			}
+			if iframes := a.iFramePlaylist(ctx, input.JobID, cmd.OutputPath, encryption, logger); iframes != nil {
+				iFramesMu.Lock()
+				iFrames[quality] = iframes
+				iFramesMu.Unlock()
+			}
+			return nil
+		})
+	}
+
+	if err := runParallel(ctx, a.config.Worker.MaxParallelFFmpeg, tasks); err != nil {
+		return a.recordError(ctx, input.JobID, domain.StageHLSSegmentation, domain.ErrCodeFFmpegFailed, err)
+	}
+
+	masterContent := ffmpeg.GenerateMasterPlaylist(qualities, job.Profile.QualitiesCustom, true, nil, iFrames)
+	masterPath := filepath.Join(dir, "master.m3u8")
+	if err := os.WriteFile(masterPath, []byte(masterContent), 0644); err != nil {
+		return fmt.Errorf("failed to write hardsub master playlist: %w", err)
+	}
+
+	logger.Info("hardsub HLS segmentation complete",
+		zap.String("masterPlaylist", masterPath),
+		zap.Int("qualities", len(qualities)))
+	return nil
+}
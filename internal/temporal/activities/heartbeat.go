@@ -0,0 +1,91 @@
+package activities
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+
+	"github.com/tvoe/converter/internal/storage/s3"
+)
+
+// HeartbeatDetails is the payload of the heartbeats of the download, transcode
+// and upload activities. A retried attempt reads the last one recorded by the
+// previous attempt to continue where it stopped.
+type HeartbeatDetails struct {
+	Percent int `json:"percent"`
+	// Rendition being encoded ("<tier>/<quality>", "mezzanine", or the tier in
+	// single-pass mode) and its encoded frames
+	Rendition     string `json:"rendition,omitempty"`
+	FramesEncoded int64  `json:"framesEncoded,omitempty"`
+	// Download of the source file
+	Download *s3.DownloadState `json:"download,omitempty"`
+	// Multipart uploads in progress and bytes of the files uploaded so far
+	Uploads       []s3.MultipartState `json:"uploads,omitempty"`
+	UploadedBytes int64               `json:"uploadedBytes,omitempty"`
+}
+
+// heartbeat records HeartbeatDetails. Several parts of an activity update
+// different fields, so each update records the merged details.
+type heartbeat struct {
+	ctx      context.Context
+	previous HeartbeatDetails
+
+	mu      sync.Mutex
+	details HeartbeatDetails
+}
+
+// newHeartbeat loads the details recorded by the previous attempt of the activity.
+// Details of another shape, from attempts before structured heartbeats, are ignored.
+func newHeartbeat(ctx context.Context) *heartbeat {
+	h := &heartbeat{ctx: ctx}
+	if activity.HasHeartbeatDetails(ctx) {
+		if err := activity.GetHeartbeatDetails(ctx, &h.previous); err != nil {
+			h.previous = HeartbeatDetails{}
+		}
+	}
+	return h
+}
+
+// Previous returns the details recorded by the previous attempt
+func (h *heartbeat) Previous() HeartbeatDetails {
+	return h.previous
+}
+
+// Update applies fn to the details and records them
+func (h *heartbeat) Update(fn func(d *HeartbeatDetails)) {
+	h.mu.Lock()
+	fn(&h.details)
+	details := h.details
+	h.mu.Unlock()
+	activity.RecordHeartbeat(h.ctx, details)
+}
+
+// frames records the frames encoded of a rendition
+func (h *heartbeat) frames(rendition string, frames int64) {
+	h.Update(func(d *HeartbeatDetails) {
+		d.Rendition, d.FramesEncoded = rendition, frames
+	})
+}
+
+// KeepAlive re-records the current details every interval, for work that
+// reports no progress of its own for a while. The returned func stops it.
+func (h *heartbeat) KeepAlive(interval time.Duration) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-h.ctx.Done():
+				return
+			case <-ticker.C:
+				h.Update(func(*HeartbeatDetails) {})
+			}
+		}
+	}()
+	return func() { close(done) }
+}
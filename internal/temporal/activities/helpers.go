@@ -10,11 +10,60 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/tvoe/converter/internal/ffmpeg"
 )
 
+// runParallel runs tasks with at most limit of them in flight and returns the first error.
+// Remaining tasks are skipped once a task fails.
+func runParallel(ctx context.Context, limit int, tasks []func(ctx context.Context) error) error {
+	if limit < 1 {
+		limit = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, limit)
+
+	for _, task := range tasks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(task func(ctx context.Context) error) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := task(ctx); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(task)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
 // shiftVTTTimestamps shifts all timestamps in a VTT file by the given duration
 func shiftVTTTimestamps(vttPath string, shift time.Duration) error {
 	content, err := os.ReadFile(vttPath)
@@ -74,6 +123,22 @@ func formatVTTTimestamp(d time.Duration) string {
 	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, seconds, millis)
 }
 
+// unknownDurationThumbInterval spaces thumbnails of a source with unknown duration
+const unknownDurationThumbInterval = 10.0
+
+// thumbnailInterval returns the seconds between thumbnails so that at most
+// maxFrames cover the source, and at least one second
+func thumbnailInterval(duration time.Duration, maxFrames int) float64 {
+	if duration <= 0 || maxFrames <= 0 {
+		return unknownDurationThumbInterval
+	}
+	interval := duration.Seconds() / float64(maxFrames)
+	if interval < 1 {
+		interval = 1
+	}
+	return interval
+}
+
 // createThumbnailTiles creates thumbnail tiles from individual thumbnails
 func createThumbnailTiles(ctx context.Context, thumbsDir string, tileX, tileY int, builder *ffmpeg.CommandBuilder, runner *ffmpeg.Runner) ([]string, error) {
 	// Find all thumbnails
@@ -0,0 +1,93 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+)
+
+// jobSlotHeartbeat is how often an activity waiting for a job slot heartbeats
+const jobSlotHeartbeat = 10 * time.Second
+
+// unbudgetedActivities are short bookkeeping activities and the cleanups that
+// free disk; they run without a job slot so they are never held behind the
+// media work of the same worker
+var unbudgetedActivities = map[string]bool{
+	"AcquireTenantSlot":       true,
+	"ReportProgress":          true,
+	"GetJobStatus":            true,
+	"ClaimSeriesJob":          true,
+	"Cleanup":                 true,
+	"FinalizeJob":             true,
+	"CleanupOrphanWorkspaces": true,
+	"AbortStaleUploads":       true,
+	"ReconcileStaleJobs":      true,
+}
+
+// JobSlotInterceptor returns a worker interceptor that runs at most
+// MAX_PARALLEL_JOBS activities at a time in the process. The worker polls
+// several task queues, each with its own activity slots; the interceptor makes
+// them share one budget.
+func (a *Activities) JobSlotInterceptor() interceptor.WorkerInterceptor {
+	return &jobSlotInterceptor{slots: a.jobSlots}
+}
+
+type jobSlotInterceptor struct {
+	interceptor.WorkerInterceptorBase
+	slots chan struct{}
+}
+
+func (i *jobSlotInterceptor) InterceptActivity(ctx context.Context, next interceptor.ActivityInboundInterceptor) interceptor.ActivityInboundInterceptor {
+	in := &jobSlotActivityInbound{slots: i.slots}
+	in.Next = next
+	return in
+}
+
+type jobSlotActivityInbound struct {
+	interceptor.ActivityInboundInterceptorBase
+	slots chan struct{}
+}
+
+func (in *jobSlotActivityInbound) ExecuteActivity(ctx context.Context, input *interceptor.ExecuteActivityInput) (interface{}, error) {
+	if unbudgetedActivities[activity.GetInfo(ctx).ActivityType.Name] {
+		return in.Next.ExecuteActivity(ctx, input)
+	}
+	if err := acquireJobSlot(ctx, in.slots); err != nil {
+		return nil, err
+	}
+	defer func() { <-in.slots }()
+	return in.Next.ExecuteActivity(ctx, input)
+}
+
+// acquireJobSlot waits for a free job slot. While it waits it re-records the
+// heartbeat of the previous attempt, so the activity does not time out and a
+// retry still resumes from that attempt's progress.
+func acquireJobSlot(ctx context.Context, slots chan struct{}) error {
+	select {
+	case slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	interval := jobSlotHeartbeat
+	if timeout := activity.GetInfo(ctx).HeartbeatTimeout; timeout > 0 && timeout/2 < interval {
+		interval = timeout / 2
+	}
+	previous := newHeartbeat(ctx).Previous()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		activity.RecordHeartbeat(ctx, previous)
+		select {
+		case slots <- struct{}{}:
+			return nil
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for job slot: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
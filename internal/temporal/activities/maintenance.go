@@ -0,0 +1,283 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/client"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/config"
+	"github.com/tvoe/converter/internal/db"
+	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/events"
+	"github.com/tvoe/converter/internal/ffmpeg"
+	"github.com/tvoe/converter/internal/metrics"
+	"github.com/tvoe/converter/internal/storage/s3"
+)
+
+// Maintenance holds the periodic maintenance activities. MaintenanceWorkflow runs
+// them from Temporal schedules, so each runs once per period for the whole fleet.
+// A database lock keeps a retried run from overlapping one still going on a stuck worker.
+type Maintenance struct {
+	config         *config.Config
+	database       *db.DB
+	temporalClient client.Client
+	jobRepo        *db.JobRepository
+	errorRepo      *db.ErrorRepository
+	artifactRepo   *db.ArtifactRepository
+	s3Client       *s3.Client
+	events         *events.Bus
+	metrics        *metrics.Metrics
+	logger         *zap.Logger
+}
+
+// NewMaintenance creates the maintenance activities
+func NewMaintenance(
+	cfg *config.Config,
+	database *db.DB,
+	temporalClient client.Client,
+	jobRepo *db.JobRepository,
+	errorRepo *db.ErrorRepository,
+	artifactRepo *db.ArtifactRepository,
+	s3Client *s3.Client,
+	bus *events.Bus,
+	m *metrics.Metrics,
+	logger *zap.Logger,
+) *Maintenance {
+	return &Maintenance{
+		config:         cfg,
+		database:       database,
+		temporalClient: temporalClient,
+		jobRepo:        jobRepo,
+		errorRepo:      errorRepo,
+		artifactRepo:   artifactRepo,
+		s3Client:       s3Client,
+		events:         bus,
+		metrics:        m,
+		logger:         logger.With(zap.String("component", "maintenance")),
+	}
+}
+
+// MaintenanceResult is the outcome of a maintenance run, shown as the workflow result
+type MaintenanceResult struct {
+	// Processed counts removed workspaces, aborted uploads or reconciled jobs
+	Processed int `json:"processed"`
+	// Skipped is set when another worker was running the same task
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// exclusive runs a maintenance task while holding its lock. A schedule starts
+// one run at a time, but the activity of a run may time out on a stuck worker
+// and be retried elsewhere while the first attempt still runs; the retry is
+// then skipped instead of repeating the work in parallel.
+func (m *Maintenance) exclusive(
+	ctx context.Context,
+	task string,
+	run func(ctx context.Context) (*MaintenanceResult, error),
+) (*MaintenanceResult, error) {
+	var result *MaintenanceResult
+	locked, err := m.database.WithLock(ctx, "maintenance:"+task, func(ctx context.Context) error {
+		var err error
+		result, err = run(ctx)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+	if !locked {
+		m.logger.Info("maintenance task is running on another worker, skipping", zap.String("task", task))
+		return &MaintenanceResult{Skipped: true}, nil
+	}
+	return result, nil
+}
+
+// CleanupOrphanWorkspaces removes unlocked workspaces older than MAINTENANCE_ORPHAN_MAX_AGE
+// from WORKDIR_ROOT. Workspaces on a disk not shared with the worker running it are not seen.
+func (m *Maintenance) CleanupOrphanWorkspaces(ctx context.Context) (*MaintenanceResult, error) {
+	return m.exclusive(ctx, "CleanupOrphanWorkspaces", m.cleanupOrphanWorkspaces)
+}
+
+func (m *Maintenance) cleanupOrphanWorkspaces(ctx context.Context) (*MaintenanceResult, error) {
+	removed, err := ffmpeg.CleanupOrphans(m.config.Worker.WorkdirRoot, m.config.Maintenance.OrphanMaxAge)
+	if err != nil {
+		return nil, err
+	}
+	if removed > 0 {
+		m.logger.Info("removed orphan workspaces", zap.Int("count", removed))
+	}
+	return &MaintenanceResult{Processed: removed}, nil
+}
+
+// AbortStaleUploads aborts multipart uploads older than S3_MULTIPART_MAX_AGE in
+// the output bucket and abandoned direct uploads in the staging bucket
+func (m *Maintenance) AbortStaleUploads(ctx context.Context) (*MaintenanceResult, error) {
+	return m.exclusive(ctx, "AbortStaleUploads", m.abortStaleUploads)
+}
+
+func (m *Maintenance) abortStaleUploads(ctx context.Context) (*MaintenanceResult, error) {
+	cfg := m.config.S3
+	buckets := []string{cfg.BucketOutput}
+	if cfg.BucketStaging != "" && cfg.BucketStaging != cfg.BucketOutput {
+		buckets = append(buckets, cfg.BucketStaging)
+	}
+
+	total := 0
+	var errs []error
+	for _, bucket := range buckets {
+		activity.RecordHeartbeat(ctx, total)
+
+		aborted, err := m.s3Client.AbortStaleMultipartUploads(ctx, bucket, cfg.MultipartMaxAge)
+		total += aborted
+		if err != nil {
+			errs = append(errs, fmt.Errorf("bucket %s: %w", bucket, err))
+		}
+		if aborted > 0 {
+			m.logger.Info("aborted stale multipart uploads",
+				zap.String("bucket", bucket),
+				zap.Int("count", aborted),
+				zap.Duration("maxAge", cfg.MultipartMaxAge),
+			)
+		}
+	}
+	return &MaintenanceResult{Processed: total}, errors.Join(errs...)
+}
+
+// ReconcileStaleJobs fails RUNNING jobs whose workflow is gone, terminated, timed
+// out or failed in Temporal, and cancels those whose workflow was canceled. Such
+// jobs are left behind when FinalizeJob did not run or could not reach the
+// database, and would otherwise stay RUNNING forever.
+// Only jobs not updated for RECONCILER_STALE_AFTER are checked.
+func (m *Maintenance) ReconcileStaleJobs(ctx context.Context) (*MaintenanceResult, error) {
+	return m.exclusive(ctx, "ReconcileStaleJobs", m.reconcileStaleJobs)
+}
+
+func (m *Maintenance) reconcileStaleJobs(ctx context.Context) (*MaintenanceResult, error) {
+	cfg := m.config.Reconciler
+	updatedBefore := time.Now().UTC().Add(-cfg.StaleAfter)
+	jobs, err := m.jobRepo.ListByFilter(ctx, db.JobFilter{
+		Statuses:      []domain.JobStatus{domain.JobStatusRunning},
+		UpdatedBefore: &updatedBefore,
+	}, cfg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	reconciled := 0
+	for _, job := range jobs {
+		activity.RecordHeartbeat(ctx, reconciled)
+
+		status, reason, err := m.workflowOutcome(ctx, job)
+		if err != nil {
+			m.logger.Warn("failed to describe workflow", zap.String("jobId", job.ID.String()), zap.Error(err))
+			continue
+		}
+		if status == "" {
+			continue
+		}
+
+		var ok bool
+		if status == domain.JobStatusCanceled {
+			ok, err = m.cancelStaleJob(ctx, job, reason)
+		} else {
+			ok, err = m.failStaleJob(ctx, job, reason)
+		}
+		if err != nil {
+			m.logger.Error("failed to reconcile stale job", zap.String("jobId", job.ID.String()), zap.Error(err))
+			continue
+		}
+		if ok {
+			reconciled++
+		}
+	}
+
+	if reconciled > 0 {
+		m.logger.Info("reconciled stale jobs without a running workflow", zap.Int("count", reconciled))
+	}
+	return &MaintenanceResult{Processed: reconciled}, nil
+}
+
+// workflowOutcome returns the status a RUNNING job is reconciled to and why:
+// FAILED when its workflow is gone, was terminated, timed out or failed, and
+// CANCELED when it was canceled. It returns an empty status while the workflow
+// runs, and for a completed workflow, whose outputs only FinalizeJob records:
+// such a job is logged and left for an operator.
+func (m *Maintenance) workflowOutcome(ctx context.Context, job *domain.Job) (domain.JobStatus, string, error) {
+	if job.WorkflowID == nil {
+		return domain.JobStatusFailed, "job has no workflow", nil
+	}
+
+	resp, err := m.temporalClient.DescribeWorkflowExecution(ctx, *job.WorkflowID, "")
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			return domain.JobStatusFailed, fmt.Sprintf("workflow %s not found", *job.WorkflowID), nil
+		}
+		return "", "", err
+	}
+
+	status := resp.GetWorkflowExecutionInfo().GetStatus()
+	reason := fmt.Sprintf("workflow %s is %s", *job.WorkflowID, status)
+	switch status {
+	case enumspb.WORKFLOW_EXECUTION_STATUS_TERMINATED,
+		enumspb.WORKFLOW_EXECUTION_STATUS_TIMED_OUT,
+		enumspb.WORKFLOW_EXECUTION_STATUS_FAILED:
+		return domain.JobStatusFailed, reason, nil
+	case enumspb.WORKFLOW_EXECUTION_STATUS_CANCELED:
+		return domain.JobStatusCanceled, reason, nil
+	case enumspb.WORKFLOW_EXECUTION_STATUS_COMPLETED:
+		m.logger.Warn("job is RUNNING after its workflow completed, skipping",
+			zap.String("jobId", job.ID.String()), zap.String("workflowId", *job.WorkflowID))
+	}
+	return "", "", nil
+}
+
+// cancelStaleJob marks a RUNNING job canceled after its workflow was canceled
+// without the cancel endpoint, e.g. with temporal workflow cancel.
+// It returns false if the job left RUNNING in the meantime.
+func (m *Maintenance) cancelStaleJob(ctx context.Context, job *domain.Job, reason string) (bool, error) {
+	ok, err := m.jobRepo.FinishIfStatus(ctx, job.ID, domain.JobStatusRunning, domain.JobStatusCanceled)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	event := events.JobEvent(events.JobCanceled, job.ID, domain.JobStatusCanceled)
+	event.Reason = "job reconciled: " + reason
+	m.events.Publish(event)
+	m.metrics.IncrementJobsTotal(string(domain.JobStatusCanceled))
+
+	m.logger.Warn("canceled stale job", zap.String("jobId", job.ID.String()), zap.String("reason", reason))
+	return true, nil
+}
+
+// failStaleJob marks a RUNNING job failed with ErrCodeWorkflowLost.
+// It returns false if the job left RUNNING in the meantime.
+func (m *Maintenance) failStaleJob(ctx context.Context, job *domain.Job, reason string) (bool, error) {
+	ok, err := m.jobRepo.FinishIfStatus(ctx, job.ID, domain.JobStatusRunning, domain.JobStatusFailed)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	stage := domain.StageUnknown
+	if job.CurrentStage != nil {
+		stage = *job.CurrentStage
+	}
+	message := "job reconciled: " + reason
+	convErr := domain.NewConversionError(job.ID, stage, domain.ErrorClassFatal, domain.ErrCodeWorkflowLost, message, job.Attempt)
+	if err := m.errorRepo.Create(ctx, convErr); err != nil {
+		m.logger.Warn("failed to record error", zap.String("jobId", job.ID.String()), zap.Error(err))
+	}
+
+	event := events.JobEvent(events.JobFailed, job.ID, domain.JobStatusFailed)
+	event.Error = message
+	m.events.Publish(event)
+	m.metrics.IncrementJobsTotal(string(domain.JobStatusFailed))
+
+	m.logger.Warn("failed stale job", zap.String("jobId", job.ID.String()), zap.String("reason", reason))
+	return true, nil
+}
@@ -0,0 +1,427 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"go.temporal.io/sdk/temporal"
+	"go.uber.org/zap"
+
+	"github.com/tvoe/converter/internal/config"
+	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/ffmpeg"
+)
+
+// DefaultTranscoder is the built-in ffmpeg backend
+const DefaultTranscoder = "ffmpeg"
+
+// TranscodeRequest describes the renditions a backend encodes for a job
+type TranscodeRequest struct {
+	Job       *domain.Job
+	Metadata  *domain.VideoMetadata
+	Workspace *ffmpeg.Workspace
+	InputPath string
+	Tiers     []domain.EncodingTier
+	Qualities []domain.Quality
+	// OnProgress reports overall transcode progress in percent
+	OnProgress func(percent int)
+	// OnFrames, if set, reports the frames encoded of the rendition in progress.
+	// Backends that do not count frames never call it.
+	OnFrames func(rendition string, frames int64)
+}
+
+// RenditionRequest describes a single rendition, or the mezzanine, of a job
+type RenditionRequest struct {
+	Job        *domain.Job
+	Metadata   *domain.VideoMetadata
+	Workspace  *ffmpeg.Workspace
+	InputPath  string
+	Tier       domain.EncodingTier
+	Quality    domain.Quality
+	Mezzanine  bool
+	OnProgress func(percent int)
+	OnFrames   func(rendition string, frames int64)
+}
+
+// Transcoder encodes renditions of a job into its workspace. Outputs must be
+// MP4 files under Workspace.Paths().Transcoded/<tier> (and Mezzanine for the
+// master), because the later stages read them from there.
+type Transcoder interface {
+	// Transcode encodes every (tier, quality) rendition and the mezzanine if the profile asks for it
+	Transcode(ctx context.Context, req *TranscodeRequest) (*TranscodeOutput, error)
+	// TranscodeRendition encodes one rendition and returns its path
+	TranscodeRendition(ctx context.Context, req *RenditionRequest) (string, error)
+}
+
+// TranscodeError is returned by a backend for a failure that is recorded on the
+// job with Code. Codes that domain.IsRetryable rejects stop activity retries;
+// errors of other types are retried without being recorded.
+type TranscodeError struct {
+	Code string
+	Err  error
+}
+
+func (e *TranscodeError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TranscodeError) Unwrap() error {
+	return e.Err
+}
+
+// TranscoderFactory creates a backend from configuration
+type TranscoderFactory func(cfg *config.Config, logger *zap.Logger) (Transcoder, error)
+
+var (
+	transcodersMu sync.RWMutex
+	transcoders   = make(map[string]TranscoderFactory)
+)
+
+// RegisterTranscoder makes a backend available under name for TRANSCODER_BACKEND
+// and the profile field "transcoder". Backends register from init() of their
+// package, imported by cmd/worker. The ffmpeg backend is always available.
+func RegisterTranscoder(name string, factory TranscoderFactory) {
+	transcodersMu.Lock()
+	defer transcodersMu.Unlock()
+	transcoders[name] = factory
+}
+
+// IsTranscoderRegistered reports whether a backend is available under name
+func IsTranscoderRegistered(name string) bool {
+	if name == DefaultTranscoder {
+		return true
+	}
+	transcodersMu.RLock()
+	defer transcodersMu.RUnlock()
+	_, ok := transcoders[name]
+	return ok
+}
+
+// RegisteredTranscoders returns the names of available backends
+func RegisteredTranscoders() []string {
+	transcodersMu.RLock()
+	defer transcodersMu.RUnlock()
+	names := []string{DefaultTranscoder}
+	for name := range transcoders {
+		if name != DefaultTranscoder {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// transcoder returns the backend of a job: the profile's, the burst backend while
+// the queue backlog is over BURST_BACKLOG_THRESHOLD, else TRANSCODER_BACKEND
+func (a *Activities) transcoder(ctx context.Context, job *domain.Job, metadata *domain.VideoMetadata) (Transcoder, error) {
+	if name := job.Profile.Transcoder; name != "" {
+		return a.transcoderByName(name, false)
+	}
+	if a.shouldOffload(ctx, job, metadata) {
+		t, err := a.transcoderByName(a.config.Burst.Transcoder, true)
+		if err == nil {
+			return t, nil
+		}
+		a.logger.Warn("burst transcoder unavailable, transcoding locally",
+			zap.String("jobId", job.ID.String()),
+			zap.String("transcoder", a.config.Burst.Transcoder),
+			zap.Error(err))
+	}
+	return a.transcoderByName(a.config.Encoding.Transcoder, false)
+}
+
+// shouldOffload reports whether the local queue is backed up enough to send the
+// job to the burst backend. Jobs with a mezzanine, an HDR source or surround
+// passthrough stay local: the offload ladder is SDR renditions with stereo AAC only.
+func (a *Activities) shouldOffload(ctx context.Context, job *domain.Job, metadata *domain.VideoMetadata) bool {
+	burst := a.config.Burst
+	if burst.Transcoder == "" || job.Profile.Mezzanine != nil ||
+		(metadata != nil && (metadata.HDR != nil || metadata.CopiesAudio())) {
+		return false
+	}
+
+	counts, err := a.jobRepo.CountByStatus(ctx)
+	if err != nil {
+		a.logger.Warn("failed to count queued jobs", zap.Error(err))
+		return false
+	}
+	backlog := counts[domain.JobStatusQueued]
+	if backlog <= burst.BacklogThreshold {
+		return false
+	}
+
+	a.logger.Info("queue backlog over threshold, offloading transcode",
+		zap.String("jobId", job.ID.String()),
+		zap.String("transcoder", burst.Transcoder),
+		zap.Int("backlog", backlog),
+		zap.Int("threshold", burst.BacklogThreshold))
+	return true
+}
+
+// transcoderByName returns a registered backend. Backends are created on first
+// use and shared by all jobs of the worker.
+func (a *Activities) transcoderByName(name string, offloaded bool) (Transcoder, error) {
+	if name == "" {
+		name = DefaultTranscoder
+	}
+	if name == DefaultTranscoder {
+		a.metrics.IncrementTranscodes(name, offloaded)
+		return &ffmpegTranscoder{a: a}, nil
+	}
+
+	a.transcodersMu.Lock()
+	defer a.transcodersMu.Unlock()
+	t, ok := a.transcoders[name]
+	if !ok {
+		transcodersMu.RLock()
+		factory, registered := transcoders[name]
+		transcodersMu.RUnlock()
+		if !registered {
+			return nil, fmt.Errorf("unknown transcoder %q, available: %v", name, RegisteredTranscoders())
+		}
+		var err error
+		t, err = factory(a.config, a.logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create transcoder %q: %w", name, err)
+		}
+		if a.transcoders == nil {
+			a.transcoders = make(map[string]Transcoder)
+		}
+		a.transcoders[name] = t
+	}
+
+	a.metrics.IncrementTranscodes(name, offloaded)
+	return t, nil
+}
+
+// transcodeError records a *TranscodeError on the job; other errors pass through
+func (a *Activities) transcodeError(ctx context.Context, jobID uuid.UUID, err error) error {
+	if a.jobCanceled(ctx, jobID) {
+		return a.discardCanceled(ctx, jobID, err)
+	}
+	var terr *TranscodeError
+	if errors.As(err, &terr) {
+		return a.recordError(ctx, jobID, domain.StageTranscoding, terr.Code, terr.Err)
+	}
+	return err
+}
+
+// jobCanceled reports whether the job was canceled, also once ctx is done
+func (a *Activities) jobCanceled(ctx context.Context, jobID uuid.UUID) bool {
+	job, err := a.jobRepo.GetByID(context.WithoutCancel(ctx), jobID)
+	return err == nil && job.Status == domain.JobStatusCanceled
+}
+
+// discardCanceled removes the workspace of a canceled job from this worker,
+// where the encoder wrote it, and stops retries of the transcode. The Cleanup
+// activity run by the workflow may be scheduled on another worker.
+func (a *Activities) discardCanceled(ctx context.Context, jobID uuid.UUID, err error) error {
+	workspace := ffmpeg.NewWorkspace(a.config.Worker.WorkdirRoot, jobID)
+	if rmErr := workspace.Cleanup(); rmErr != nil {
+		a.logger.Warn("failed to remove workspace of canceled job",
+			zap.String("jobId", jobID.String()), zap.Error(rmErr))
+	} else {
+		a.logger.Info("workspace of canceled job removed", zap.String("jobId", jobID.String()))
+	}
+	return temporal.NewNonRetryableApplicationError("job canceled", domain.ErrCodeCanceled, err)
+}
+
+// ffmpegTranscoder encodes renditions with the local ffmpeg
+type ffmpegTranscoder struct {
+	a *Activities
+}
+
+// Transcode encodes all renditions tier by tier, then the mezzanine
+func (t *ffmpegTranscoder) Transcode(ctx context.Context, req *TranscodeRequest) (*TranscodeOutput, error) {
+	a := t.a
+	job := req.Job
+	logger := a.logger.With(zap.String("jobId", job.ID.String()), zap.String("transcoder", DefaultTranscoder))
+
+	a.metrics.IncrementFFmpegProcesses()
+	defer a.metrics.DecrementFFmpegProcesses()
+
+	builder, releaseGPU := a.assignGPU(a.transcodeBuilder(ctx, job.ID, req.Metadata, logger).WithTrim(job.Profile.Trim).WithAudio(job.Profile.Audio), logger)
+	defer releaseGPU()
+	pauser := ffmpeg.NewPauser()
+	runner := a.newRunner().WithPauser(pauser)
+	ctx, stopWatch := a.watchJob(ctx, job.ID, pauser)
+	defer stopWatch()
+	checkpoint := a.loadCheckpoint(req.Workspace, logger)
+	onFrames := framesReporter(req.OnFrames)
+
+	qualities := req.Qualities
+	enabledTiers := req.Tiers
+	if slices.Contains(enabledTiers, domain.TierModern) {
+		builder = builder.WithHDR10Plus(a.hdr10PlusMetadata(ctx, job, req.Workspace, req.InputPath, req.Metadata, logger))
+	}
+
+	logger.Info("multi-tier transcoding",
+		zap.Int("tiers", len(enabledTiers)),
+		zap.Int("qualities", len(qualities)),
+		zap.Strings("enabledTiers", func() []string {
+			s := make([]string, len(enabledTiers))
+			for i, t := range enabledTiers {
+				s[i] = string(t)
+			}
+			return s
+		}()))
+
+	tierOutputPaths := make(map[domain.EncodingTier]map[domain.Quality]string)
+	outputPaths := make(map[domain.Quality]string) // Legacy compatibility
+
+	totalTasks := len(enabledTiers) * len(qualities)
+	if job.Profile.Mezzanine != nil {
+		totalTasks++
+	}
+	currentTask := 0
+	taskProgress := func(percent int) {
+		req.OnProgress((currentTask*100 + percent) / totalTasks)
+	}
+
+	for _, tier := range enabledTiers {
+		tierConfig := domain.GetTierConfig(tier)
+		tierDir := filepath.Join(req.Workspace.Paths().Transcoded, string(tier))
+
+		// Create tier directory
+		if err := os.MkdirAll(tierDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create tier directory: %w", err)
+		}
+
+		tierOutputPaths[tier] = make(map[domain.Quality]string)
+
+		// Single-pass mode decodes the source once for all qualities of the tier
+		if a.config.Encoding.SinglePass && len(qualities) > 0 {
+			paths, err := a.transcodeTierSinglePass(ctx, job, req.Metadata, req.InputPath, tierDir, tier, qualities,
+				builder, runner, checkpoint, currentTask, totalTasks, req.OnProgress, onFrames, logger)
+			if err != nil {
+				return nil, err
+			}
+
+			tierOutputPaths[tier] = paths
+			if tier == domain.TierLegacy {
+				outputPaths = paths
+			}
+			currentTask += len(qualities)
+			continue
+		}
+
+		for _, quality := range qualities {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+
+			logger.Info("transcoding",
+				zap.String("tier", string(tier)),
+				zap.String("quality", string(quality)),
+				zap.String("videoCodec", string(tierConfig.VideoCodec)))
+
+			outputPath, err := a.transcodeQuality(ctx, job.ID, job, req.Metadata, req.InputPath, tierDir, tier, quality,
+				builder, runner, checkpoint, taskProgress, onFrames)
+			if err != nil {
+				return nil, err
+			}
+
+			tierOutputPaths[tier][quality] = outputPath
+
+			// For backward compatibility, use legacy tier paths as main output
+			if tier == domain.TierLegacy {
+				outputPaths[quality] = outputPath
+			}
+
+			currentTask++
+			logger.Info("quality transcoded",
+				zap.String("tier", string(tier)),
+				zap.String("quality", string(quality)),
+				zap.String("output", outputPath))
+		}
+	}
+
+	// If only modern tier is enabled, use it as main output
+	if len(outputPaths) == 0 && len(tierOutputPaths[domain.TierModern]) > 0 {
+		outputPaths = tierOutputPaths[domain.TierModern]
+	}
+
+	// Mezzanine master for archival/editing, kept out of the HLS outputs
+	var mezzaninePath string
+	if job.Profile.Mezzanine != nil {
+		var err error
+		mezzaninePath, err = a.transcodeMezzanine(ctx, job.ID, job, req.Metadata, req.InputPath, req.Workspace,
+			builder, runner, checkpoint, taskProgress, onFrames, logger)
+		if err != nil {
+			return nil, err
+		}
+		currentTask++
+	}
+
+	return &TranscodeOutput{
+		OutputPaths:     outputPaths,
+		TierOutputPaths: tierOutputPaths,
+		EnabledTiers:    enabledTiers,
+		MezzaninePath:   mezzaninePath,
+	}, nil
+}
+
+// TranscodeRendition encodes one rendition or the mezzanine
+func (t *ffmpegTranscoder) TranscodeRendition(ctx context.Context, req *RenditionRequest) (string, error) {
+	a := t.a
+	job := req.Job
+	logger := a.logger.With(
+		zap.String("jobId", job.ID.String()),
+		zap.String("activity", "TranscodeRendition"),
+		zap.String("tier", string(req.Tier)),
+		zap.String("quality", string(req.Quality)),
+	)
+
+	a.metrics.IncrementFFmpegProcesses()
+	defer a.metrics.DecrementFFmpegProcesses()
+
+	builder, releaseGPU := a.assignGPU(a.transcodeBuilder(ctx, job.ID, req.Metadata, logger).WithTrim(job.Profile.Trim).WithAudio(job.Profile.Audio), logger)
+	defer releaseGPU()
+	pauser := ffmpeg.NewPauser()
+	runner := a.newRunner().WithPauser(pauser)
+	ctx, stopWatch := a.watchJob(ctx, job.ID, pauser)
+	defer stopWatch()
+	checkpoint := a.loadCheckpoint(req.Workspace, logger)
+	onFrames := framesReporter(req.OnFrames)
+
+	if !req.Mezzanine && domain.GetTierConfig(req.Tier).VideoCodec == domain.VideoCodecH265 {
+		builder = builder.WithHDR10Plus(a.hdr10PlusMetadata(ctx, job, req.Workspace, req.InputPath, req.Metadata, logger))
+	}
+
+	if req.Mezzanine {
+		return a.transcodeMezzanine(ctx, job.ID, job, req.Metadata, req.InputPath, req.Workspace,
+			builder, runner, checkpoint, req.OnProgress, onFrames, logger)
+	}
+
+	tierDir := filepath.Join(req.Workspace.Paths().Transcoded, string(req.Tier))
+	if err := os.MkdirAll(tierDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create tier directory: %w", err)
+	}
+
+	logger.Info("transcoding rendition")
+	outputPath, err := a.transcodeQuality(ctx, job.ID, job, req.Metadata, req.InputPath, tierDir,
+		req.Tier, req.Quality, builder, runner, checkpoint, req.OnProgress, onFrames)
+	if err != nil {
+		return "", err
+	}
+
+	logger.Info("rendition transcoded", zap.String("output", outputPath))
+	return outputPath, nil
+}
+
+// framesReporter returns fn, or a no-op if it is nil
+func framesReporter(fn func(rendition string, frames int64)) func(rendition string, frames int64) {
+	if fn == nil {
+		return func(string, int64) {}
+	}
+	return fn
+}
@@ -1,10 +1,12 @@
 package workflows
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	enumspb "go.temporal.io/api/enums/v1"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 
@@ -12,9 +14,155 @@ import (
 	"github.com/tvoe/converter/internal/temporal/activities"
 )
 
+// Signals handled by VideoConversionWorkflow
+const (
+	SignalCancel = "cancel"
+	SignalPause  = "pause"
+	SignalResume = "resume"
+)
+
+// QueryProgress is the query returning in-flight progress of VideoConversionWorkflow
+const QueryProgress = "progress"
+
+// RenditionStatus is the state of a single rendition in the progress query
+type RenditionStatus string
+
+const (
+	RenditionPending   RenditionStatus = "PENDING"
+	RenditionRunning   RenditionStatus = "RUNNING"
+	RenditionCompleted RenditionStatus = "COMPLETED"
+	RenditionFailed    RenditionStatus = "FAILED"
+	RenditionCanceled  RenditionStatus = "CANCELED"
+)
+
+// RenditionProgress is the status of one (tier, quality) rendition
+type RenditionProgress struct {
+	Tier      domain.EncodingTier `json:"tier,omitempty"`
+	Quality   domain.Quality      `json:"quality,omitempty"`
+	Mezzanine bool                `json:"mezzanine,omitempty"`
+	Status    RenditionStatus     `json:"status"`
+}
+
+// Progress is the result of the progress query. It reflects workflow state and
+// is fresher than the job row, which is only updated by activities.
+type Progress struct {
+	Stage           domain.Stage         `json:"stage,omitempty"`
+	StageProgress   int                  `json:"stageProgress"`
+	OverallProgress int                  `json:"overallProgress"`
+	Paused          bool                 `json:"paused"`
+	Renditions      []*RenditionProgress `json:"renditions,omitempty"`
+}
+
+// setStage records the current stage and recalculates overall progress
+func (p *Progress) setStage(stage domain.Stage, stageProgress int) {
+	job := domain.Job{CurrentStage: &stage, StageProgress: stageProgress}
+	p.Stage = stage
+	p.StageProgress = stageProgress
+	p.OverallProgress = job.CalculateOverallProgress()
+}
+
+// setRenditions marks all renditions with status
+func (p *Progress) setRenditions(status RenditionStatus) {
+	for _, r := range p.Renditions {
+		r.Status = status
+	}
+}
+
+// CancelSignal is the payload of the cancel signal
+type CancelSignal struct {
+	Reason string `json:"reason,omitempty"`
+	Actor  string `json:"actor,omitempty"`
+}
+
+// ActivityTimeouts holds heartbeat timeouts for workflow activities.
+// Zero values fall back to the built-in defaults.
+type ActivityTimeouts struct {
+	Heartbeat          time.Duration `json:"heartbeat,omitempty"`
+	TranscodeHeartbeat time.Duration `json:"transcodeHeartbeat,omitempty"`
+	UploadHeartbeat    time.Duration `json:"uploadHeartbeat,omitempty"`
+	// HeartbeatPerGB is added to transcode/upload heartbeat timeouts per GB of source
+	HeartbeatPerGB time.Duration `json:"heartbeatPerGb,omitempty"`
+}
+
+// withDefaults fills unset timeouts
+func (t *ActivityTimeouts) withDefaults() ActivityTimeouts {
+	out := ActivityTimeouts{}
+	if t != nil {
+		out = *t
+	}
+	if out.Heartbeat <= 0 {
+		out.Heartbeat = 1 * time.Minute
+	}
+	if out.TranscodeHeartbeat <= 0 {
+		out.TranscodeHeartbeat = 5 * time.Minute
+	}
+	if out.UploadHeartbeat <= 0 {
+		out.UploadHeartbeat = 1 * time.Minute
+	}
+	return out
+}
+
+// scaled extends a heartbeat timeout by HeartbeatPerGB for each started GB of source
+func (t ActivityTimeouts) scaled(base time.Duration, sizeBytes int64) time.Duration {
+	if t.HeartbeatPerGB <= 0 || sizeBytes <= 0 {
+		return base
+	}
+	const gb = 1 << 30
+	return base + time.Duration((sizeBytes+gb-1)/gb)*t.HeartbeatPerGB
+}
+
+// defaultRetryPolicies are used for groups and fields the workflow input does not set
+func defaultRetryPolicies() domain.RetryPolicies {
+	return domain.RetryPolicies{
+		Default:   &domain.RetryPolicy{MaxAttempts: 3, InitialIntervalSec: 1, BackoffCoefficient: 2.0, MaxIntervalSec: 60, TimeoutSec: 6 * 3600},
+		Metadata:  &domain.RetryPolicy{MaxAttempts: 3, InitialIntervalSec: 1, BackoffCoefficient: 2.0, MaxIntervalSec: 60, TimeoutSec: 6 * 3600},
+		Transcode: &domain.RetryPolicy{MaxAttempts: 2, InitialIntervalSec: 10, BackoffCoefficient: 2.0, MaxIntervalSec: 300, TimeoutSec: 12 * 3600},
+		Upload:    &domain.RetryPolicy{MaxAttempts: 5, InitialIntervalSec: 5, BackoffCoefficient: 2.0, MaxIntervalSec: 120, TimeoutSec: 2 * 3600},
+	}
+}
+
+// activityOptions builds activity options from a retry policy
+func activityOptions(p *domain.RetryPolicy, heartbeat time.Duration) workflow.ActivityOptions {
+	return workflow.ActivityOptions{
+		StartToCloseTimeout: time.Duration(p.TimeoutSec) * time.Second,
+		HeartbeatTimeout:    heartbeat,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Duration(p.InitialIntervalSec) * time.Second,
+			BackoffCoefficient: p.BackoffCoefficient,
+			MaximumInterval:    time.Duration(p.MaxIntervalSec) * time.Second,
+			MaximumAttempts:    int32(p.MaxAttempts),
+		},
+	}
+}
+
+// Bounds of the wait between attempts to take a slot of the job's tenant
+const (
+	tenantSlotMinWait = 15 * time.Second
+	tenantSlotMaxWait = 5 * time.Minute
+)
+
 // VideoConversionWorkflowInput holds workflow input
 type VideoConversionWorkflowInput struct {
-	JobID uuid.UUID `json:"jobId"`
+	JobID    uuid.UUID         `json:"jobId"`
+	Timeouts *ActivityTimeouts `json:"timeouts,omitempty"`
+	// Stages are the profile's stage skip flags
+	Stages domain.StageOptions `json:"stages"`
+	// Retry are the activity retry policies resolved from RETRY_* and the profile
+	Retry *domain.RetryPolicies `json:"retry,omitempty"`
+	// DryRun stops after validation and returns the plan of the job instead of encoding
+	DryRun bool `json:"dryRun,omitempty"`
+	// AutoCrop crops the black bars of the source from the renditions
+	AutoCrop bool `json:"autoCrop,omitempty"`
+	// PerTitle scales the ladder to the complexity of the source before transcoding
+	PerTitle bool `json:"perTitle,omitempty"`
+	// Bumpers stitches the intro and outro of the profile to the renditions
+	Bumpers bool `json:"bumpers,omitempty"`
+	// AudioOnly encodes audio-only renditions with TranscodeAudio and skips
+	// the video stages
+	AudioOnly bool `json:"audioOnly,omitempty"`
+	// Hardsub burns the subtitle track of the profile into an alternate set
+	// of renditions after subtitle extraction
+	Hardsub bool `json:"hardsub,omitempty"`
 }
 
 // VideoConversionWorkflowOutput holds workflow output
@@ -22,25 +170,20 @@ type VideoConversionWorkflowOutput struct {
 	Status        domain.JobStatus `json:"status"`
 	ArtifactCount int             `json:"artifactCount"`
 	Error         string          `json:"error,omitempty"`
+	// Plan is set for dry runs
+	Plan *domain.JobPlan `json:"plan,omitempty"`
 }
 
 // VideoConversionWorkflow orchestrates the video conversion process
-func VideoConversionWorkflow(ctx workflow.Context, input VideoConversionWorkflowInput) (*VideoConversionWorkflowOutput, error) {
+func VideoConversionWorkflow(ctx workflow.Context, input VideoConversionWorkflowInput) (_ *VideoConversionWorkflowOutput, err error) {
 	logger := workflow.GetLogger(ctx)
 	logger.Info("Starting video conversion workflow", "jobId", input.JobID.String())
 
+	timeouts := input.Timeouts.withDefaults()
+	retry := defaultRetryPolicies().Override(input.Retry)
+
 	// Set up activity options with retry policy
-	activityOptions := workflow.ActivityOptions{
-		StartToCloseTimeout: 6 * time.Hour,
-		HeartbeatTimeout:    1 * time.Minute,
-		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:    time.Second,
-			BackoffCoefficient: 2.0,
-			MaximumInterval:    time.Minute,
-			MaximumAttempts:    3,
-		},
-	}
-	ctx = workflow.WithActivityOptions(ctx, activityOptions)
+	ctx = workflow.WithActivityOptions(ctx, activityOptions(retry.Default, timeouts.Heartbeat))
 
 	// Ensure job status is updated on workflow completion (success or failure)
 	output := &VideoConversionWorkflowOutput{
@@ -60,6 +203,21 @@ func VideoConversionWorkflow(ctx workflow.Context, input VideoConversionWorkflow
 		}
 		finalizeCtx = workflow.WithActivityOptions(finalizeCtx, finalizeOptions)
 
+		// Poison sources are quarantined with a diagnostic bundle instead of failing.
+		// Workflows started before quarantine existed keep failing them.
+		if output.Status == domain.JobStatusFailed && isPoison(err) &&
+			workflow.GetVersion(finalizeCtx, changeQuarantine, workflow.DefaultVersion, 1) == 1 {
+			quarantineCtx := workflow.WithStartToCloseTimeout(finalizeCtx, 10*time.Minute)
+			qerr := workflow.ExecuteActivity(quarantineCtx, "QuarantineJob", activities.QuarantineJobInput{
+				JobID: input.JobID,
+			}).Get(quarantineCtx, nil)
+			if qerr != nil {
+				logger.Warn("Failed to quarantine job, marking it failed", "error", qerr)
+			} else {
+				output.Status = domain.JobStatusQuarantined
+			}
+		}
+
 		_ = workflow.ExecuteActivity(finalizeCtx, "FinalizeJob", activities.FinalizeJobInput{
 			JobID:  input.JobID,
 			Status: output.Status,
@@ -67,31 +225,117 @@ func VideoConversionWorkflow(ctx workflow.Context, input VideoConversionWorkflow
 		}).Get(finalizeCtx, nil)
 	}()
 
+	progress := &Progress{}
+	var paused bool
+	err = workflow.SetQueryHandler(ctx, QueryProgress, func() (Progress, error) {
+		p := *progress
+		p.Paused = paused
+		p.Renditions = make([]*RenditionProgress, len(progress.Renditions))
+		for i, r := range progress.Renditions {
+			rendition := *r
+			p.Renditions[i] = &rendition
+		}
+		return p, nil
+	})
+	if err != nil {
+		output.Status = domain.JobStatusFailed
+		output.Error = fmt.Sprintf("failed to register progress query: %v", err)
+		return output, err
+	}
+
 	// Set up signal channel for cancellation
-	cancelChan := workflow.GetSignalChannel(ctx, "cancel")
+	cancelChan := workflow.GetSignalChannel(ctx, SignalCancel)
 
 	// Create selector for handling signals
 	selector := workflow.NewSelector(ctx)
 
 	var cancelled bool
+	var cancelSignal CancelSignal
 	selector.AddReceive(cancelChan, func(c workflow.ReceiveChannel, more bool) {
+		c.Receive(ctx, &cancelSignal)
 		cancelled = true
-		logger.Info("Received cancel signal")
+		logger.Info("Received cancel signal", "reason", cancelSignal.Reason, "actor", cancelSignal.Actor)
 	})
 
-	// Helper to check cancellation
-	checkCancelled := func() bool {
-		// Non-blocking check
+	// Pause/resume signals are handled in the background so they are seen while waiting
+	workflow.Go(ctx, func(ctx workflow.Context) {
+		pauseChan := workflow.GetSignalChannel(ctx, SignalPause)
+		resumeChan := workflow.GetSignalChannel(ctx, SignalResume)
+		for {
+			s := workflow.NewSelector(ctx)
+			s.AddReceive(pauseChan, func(c workflow.ReceiveChannel, more bool) {
+				c.Receive(ctx, nil)
+				paused = true
+			})
+			s.AddReceive(resumeChan, func(c workflow.ReceiveChannel, more bool) {
+				c.Receive(ctx, nil)
+				paused = false
+			})
+			s.Select(ctx)
+		}
+	})
+
+	// cancelRequested reports whether a cancel signal has been received, without blocking
+	cancelRequested := func() bool {
 		for selector.HasPending() {
 			selector.Select(ctx)
 		}
 		return cancelled
 	}
 
+	// Helper to check cancellation. While paused it blocks, so no new activity is
+	// dispatched until the workflow is resumed or cancelled.
+	checkCancelled := func() bool {
+		if !cancelRequested() && paused {
+			logger.Info("Workflow paused")
+			// A cancel signal ends the pause as well; executions paused before
+			// that wait for a resume only
+			resumed := func() bool { return !paused }
+			if workflow.GetVersion(ctx, changeCancelPaused, workflow.DefaultVersion, 1) == 1 {
+				resumed = func() bool { return !paused || selector.HasPending() }
+			}
+			if err := workflow.Await(ctx, resumed); err != nil {
+				// Workflow cancelled while paused
+				return true
+			}
+			if cancelRequested() {
+				return true
+			}
+			logger.Info("Workflow resumed")
+		}
+		return cancelled
+	}
+
+	// Wait until the tenant of the job is below its RUNNING limit. The job stays
+	// QUEUED meanwhile. Workflows started before tenant quotas existed start right away.
+	if workflow.GetVersion(ctx, changeTenantQuota, workflow.DefaultVersion, 1) == 1 {
+		wait := tenantSlotMinWait
+		for {
+			var acquired bool
+			err = workflow.ExecuteActivity(ctx, "AcquireTenantSlot", activities.ActivityInput{JobID: input.JobID}).Get(ctx, &acquired)
+			if err != nil {
+				output.Status = domain.JobStatusFailed
+				output.Error = fmt.Sprintf("failed to acquire tenant slot: %v", err)
+				return output, err
+			}
+			if acquired {
+				break
+			}
+
+			// Back off so a long wait does not bloat the history; a cancel signal ends it early
+			if _, err := workflow.AwaitWithTimeout(ctx, wait, selector.HasPending); err != nil || checkCancelled() {
+				return handleCancellation(ctx, input.JobID, output, cancelSignal)
+			}
+			wait = min(wait*2, tenantSlotMaxWait)
+		}
+	}
+
 	// Step 1: Extract Metadata
 	logger.Info("Starting metadata extraction")
+	progress.setStage(domain.StageMetadataExtraction, 0)
 	var metadataOutput *activities.MetadataOutput
-	err := workflow.ExecuteActivity(ctx, "ExtractMetadata", activities.ActivityInput{JobID: input.JobID}).Get(ctx, &metadataOutput)
+	metadataCtx := workflow.WithActivityOptions(ctx, activityOptions(retry.Metadata, timeouts.Heartbeat))
+	err = workflow.ExecuteActivity(metadataCtx, "ExtractMetadata", activities.ActivityInput{JobID: input.JobID}).Get(ctx, &metadataOutput)
 	if err != nil {
 		output.Status = domain.JobStatusFailed
 		output.Error = fmt.Sprintf("metadata extraction failed: %v", err)
@@ -99,11 +343,16 @@ func VideoConversionWorkflow(ctx workflow.Context, input VideoConversionWorkflow
 	}
 
 	if checkCancelled() {
-		return handleCancellation(ctx, input.JobID, output)
+		return handleCancellation(ctx, input.JobID, output, cancelSignal)
 	}
 
+	// Timeouts of the remaining activities may grow with the source duration
+	retry = retry.ForDuration(metadataOutput.Metadata.Duration)
+	ctx = workflow.WithActivityOptions(ctx, activityOptions(retry.Default, timeouts.Heartbeat))
+
 	// Step 2: Validate Inputs
 	logger.Info("Starting validation")
+	progress.setStage(domain.StageValidation, 0)
 	err = workflow.ExecuteActivity(ctx, "ValidateInputs", activities.ValidationInput{
 		JobID:    input.JobID,
 		Metadata: metadataOutput.Metadata,
@@ -115,103 +364,244 @@ func VideoConversionWorkflow(ctx workflow.Context, input VideoConversionWorkflow
 	}
 
 	if checkCancelled() {
-		return handleCancellation(ctx, input.JobID, output)
+		return handleCancellation(ctx, input.JobID, output, cancelSignal)
+	}
+
+	// A dry run ends with the plan; only new executions can have the flag set
+	if input.DryRun {
+		return planDryRun(ctx, input.JobID, metadataOutput.Metadata, output)
 	}
 
 	// Step 3: Transcode
 	logger.Info("Starting transcoding")
-	transcodeOptions := workflow.ActivityOptions{
-		StartToCloseTimeout: 12 * time.Hour,
-		HeartbeatTimeout:    5 * time.Minute,
-		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:    10 * time.Second,
-			BackoffCoefficient: 2.0,
-			MaximumInterval:    5 * time.Minute,
-			MaximumAttempts:    2,
-		},
+	progress.setStage(domain.StageTranscoding, 0)
+	sourceSize := metadataOutput.Metadata.FileSize
+
+	// The crop is found before the per-title probe, which encodes the cropped
+	// ladder; only new executions have the flag set
+	if input.AutoCrop {
+		cropCtx := workflow.WithActivityOptions(ctx,
+			activityOptions(retry.Default, timeouts.scaled(timeouts.TranscodeHeartbeat, sourceSize)))
+		err = workflow.ExecuteActivity(cropCtx, "DetectCrop", activities.TranscodeInput{
+			JobID:    input.JobID,
+			Metadata: metadataOutput.Metadata,
+		}).Get(ctx, &metadataOutput.Metadata)
+		if err != nil {
+			output.Status = domain.JobStatusFailed
+			output.Error = fmt.Sprintf("crop detection failed: %v", err)
+			return output, err
+		}
+
+		if checkCancelled() {
+			return handleCancellation(ctx, input.JobID, output, cancelSignal)
+		}
 	}
-	transcodeCtx := workflow.WithActivityOptions(ctx, transcodeOptions)
 
-	var transcodeOutput *activities.TranscodeOutput
-	err = workflow.ExecuteActivity(transcodeCtx, "Transcode", activities.TranscodeInput{
+	// Per-title encoding stores the scaled ladder in the job profile; only new executions have the flag set
+	if input.PerTitle {
+		analyzeCtx := workflow.WithActivityOptions(ctx,
+			activityOptions(retry.Default, timeouts.scaled(timeouts.TranscodeHeartbeat, sourceSize)))
+		err = workflow.ExecuteActivity(analyzeCtx, "AnalyzeComplexity", activities.TranscodeInput{
+			JobID:    input.JobID,
+			Metadata: metadataOutput.Metadata,
+		}).Get(ctx, nil)
+		if err != nil {
+			output.Status = domain.JobStatusFailed
+			output.Error = fmt.Sprintf("complexity analysis failed: %v", err)
+			return output, err
+		}
+
+		if checkCancelled() {
+			return handleCancellation(ctx, input.JobID, output, cancelSignal)
+		}
+	}
+	transcodeCtx := workflow.WithActivityOptions(ctx,
+		activityOptions(retry.Transcode, timeouts.scaled(timeouts.TranscodeHeartbeat, sourceSize)))
+
+	// Video transcodes run on the transcode queue, which a worker under disk or
+	// GPU memory pressure stops polling
+	encodeCtx := transcodeCtx
+	if workflow.GetVersion(ctx, changeTranscodeQueue, workflow.DefaultVersion, 1) == 1 {
+		encodeCtx = workflow.WithTaskQueue(transcodeCtx, activities.TranscodeTaskQueue(workflow.GetInfo(ctx).TaskQueueName))
+	}
+
+	transcodeInput := activities.TranscodeInput{
 		JobID:    input.JobID,
 		Metadata: metadataOutput.Metadata,
-	}).Get(ctx, &transcodeOutput)
+	}
+
+	// Workflows started before parallel renditions existed keep the single Transcode activity.
+	// Audio-only jobs have no renditions to plan; only new executions have the flag set.
+	var plan *activities.TranscodePlan
+	if !input.AudioOnly && workflow.GetVersion(ctx, changeParallelRenditions, workflow.DefaultVersion, 1) == 1 {
+		err = workflow.ExecuteActivity(ctx, "PlanTranscode", transcodeInput).Get(ctx, &plan)
+		if err != nil {
+			output.Status = domain.JobStatusFailed
+			output.Error = fmt.Sprintf("transcoding failed: %v", err)
+			return output, err
+		}
+	}
+
+	if plan != nil {
+		progress.Renditions = planRenditions(plan)
+	}
+
+	var transcodeOutput *activities.TranscodeOutput
+	if input.AudioOnly {
+		err = workflow.ExecuteActivity(transcodeCtx, "TranscodeAudio", transcodeInput).Get(ctx, &transcodeOutput)
+	} else if plan != nil && plan.Parallel {
+		transcodeOutput, err = transcodeRenditions(encodeCtx, ctx, input.JobID, metadataOutput.Metadata, plan, progress)
+	} else {
+		// The single Transcode activity encodes all renditions; per-rendition state is not known
+		progress.setRenditions(RenditionRunning)
+		err = workflow.ExecuteActivity(encodeCtx, "Transcode", transcodeInput).Get(ctx, &transcodeOutput)
+		if err == nil {
+			progress.setRenditions(RenditionCompleted)
+		} else {
+			progress.setRenditions(RenditionFailed)
+		}
+	}
 	if err != nil {
+		// A canceled job kills its encoder, which fails the transcode: finish as canceled
+		if (temporal.IsCanceledError(err) || cancelRequested()) &&
+			workflow.GetVersion(ctx, changeCancelTranscode, workflow.DefaultVersion, 1) == 1 {
+			return handleCancellation(ctx, input.JobID, output, cancelSignal)
+		}
 		output.Status = domain.JobStatusFailed
 		output.Error = fmt.Sprintf("transcoding failed: %v", err)
 		return output, err
 	}
 
 	if checkCancelled() {
-		return handleCancellation(ctx, input.JobID, output)
+		return handleCancellation(ctx, input.JobID, output, cancelSignal)
 	}
 
-	// Step 4: Extract Subtitles (optional, non-blocking)
-	logger.Info("Starting subtitle extraction")
-	var subtitlesOutput *activities.SubtitlesOutput
-	err = workflow.ExecuteActivity(ctx, "ExtractSubtitles", activities.SubtitlesInput{
-		JobID:    input.JobID,
-		Metadata: metadataOutput.Metadata,
-	}).Get(ctx, &subtitlesOutput)
-	if err != nil {
-		// Log but don't fail - subtitles are optional
-		logger.Warn("Subtitle extraction failed", "error", err)
+	// The intro and outro are part of every rendition; only new executions have
+	// the flag set. Later steps see the stitched duration.
+	var stitchOutput activities.StitchOutput
+	duration := metadataOutput.Metadata.Duration
+	if input.Bumpers {
+		err = workflow.ExecuteActivity(transcodeCtx, "StitchBumpers", activities.StitchInput{
+			JobID:     input.JobID,
+			Metadata:  metadataOutput.Metadata,
+			Transcode: transcodeOutput,
+		}).Get(ctx, &stitchOutput)
+		if err != nil {
+			output.Status = domain.JobStatusFailed
+			output.Error = fmt.Sprintf("bumper stitching failed: %v", err)
+			return output, err
+		}
+		duration += stitchOutput.IntroDuration + stitchOutput.OutroDuration
+
+		if checkCancelled() {
+			return handleCancellation(ctx, input.JobID, output, cancelSignal)
+		}
 	}
 
-	if checkCancelled() {
-		return handleCancellation(ctx, input.JobID, output)
+	// Step 4: Extract Subtitles (optional, non-blocking); audio-only output has no video to caption
+	var hardsubOutput *activities.HardsubOutput
+	if input.AudioOnly || input.Stages.Skips(domain.StageSubtitlesExtraction) {
+		logger.Info("Skipping subtitle extraction")
+	} else {
+		logger.Info("Starting subtitle extraction")
+		progress.setStage(domain.StageSubtitlesExtraction, 0)
+		var subtitlesOutput *activities.SubtitlesOutput
+		err = workflow.ExecuteActivity(ctx, "ExtractSubtitles", activities.SubtitlesInput{
+			JobID:         input.JobID,
+			Metadata:      metadataOutput.Metadata,
+			IntroDuration: stitchOutput.IntroDuration,
+		}).Get(ctx, &subtitlesOutput)
+		if err != nil {
+			// Log but don't fail - subtitles are optional
+			logger.Warn("Subtitle extraction failed", "error", err)
+		}
+
+		if checkCancelled() {
+			return handleCancellation(ctx, input.JobID, output, cancelSignal)
+		}
+
+		// The burned-in set reads the extracted track; it is requested output,
+		// so a failed encode fails the job
+		if input.Hardsub {
+			logger.Info("Burning in subtitles")
+			err = workflow.ExecuteActivity(transcodeCtx, "BurnSubtitles", activities.HardsubInput{
+				JobID:     input.JobID,
+				Metadata:  metadataOutput.Metadata,
+				Transcode: transcodeOutput,
+			}).Get(ctx, &hardsubOutput)
+			if err != nil {
+				output.Status = domain.JobStatusFailed
+				output.Error = fmt.Sprintf("subtitle burn-in failed: %v", err)
+				return output, err
+			}
+
+			if checkCancelled() {
+				return handleCancellation(ctx, input.JobID, output, cancelSignal)
+			}
+		}
 	}
 
 	// Step 5: Generate Thumbnails
-	logger.Info("Starting thumbnail generation")
-	var thumbnailsOutput *activities.ThumbnailsOutput
-	err = workflow.ExecuteActivity(ctx, "GenerateThumbnails", activities.ThumbnailsInput{
-		JobID:    input.JobID,
-		Metadata: metadataOutput.Metadata,
-	}).Get(ctx, &thumbnailsOutput)
-	if err != nil {
-		// Log but don't fail - thumbnails are optional
-		logger.Warn("Thumbnail generation failed", "error", err)
-	}
+	if input.AudioOnly || input.Stages.Skips(domain.StageThumbnailsGen) {
+		logger.Info("Skipping thumbnail generation")
+	} else {
+		logger.Info("Starting thumbnail generation")
+		progress.setStage(domain.StageThumbnailsGen, 0)
+		var thumbnailsOutput *activities.ThumbnailsOutput
+		err = workflow.ExecuteActivity(ctx, "GenerateThumbnails", activities.ThumbnailsInput{
+			JobID:          input.JobID,
+			Metadata:       metadataOutput.Metadata,
+			RenditionPaths: transcodeOutput.OutputPaths,
+			Duration:       duration,
+			IntroDuration:  stitchOutput.IntroDuration,
+		}).Get(ctx, &thumbnailsOutput)
+		if err != nil {
+			// Log but don't fail - thumbnails are optional
+			logger.Warn("Thumbnail generation failed", "error", err)
+		}
 
-	if checkCancelled() {
-		return handleCancellation(ctx, input.JobID, output)
-	}
-
-	// Step 6: HLS Segmentation (and DASH manifest generation for fMP4)
-	logger.Info("Starting HLS segmentation")
-	var hlsOutput *activities.HLSOutput
-	err = workflow.ExecuteActivity(ctx, "SegmentHLS", activities.HLSInput{
-		JobID:           input.JobID,
-		OutputPaths:     transcodeOutput.OutputPaths,
-		TierOutputPaths: transcodeOutput.TierOutputPaths,
-		EnabledTiers:    transcodeOutput.EnabledTiers,
-		Duration:        metadataOutput.Metadata.Duration,
-	}).Get(ctx, &hlsOutput)
-	if err != nil {
-		output.Status = domain.JobStatusFailed
-		output.Error = fmt.Sprintf("HLS segmentation failed: %v", err)
-		return output, err
+		if checkCancelled() {
+			return handleCancellation(ctx, input.JobID, output, cancelSignal)
+		}
 	}
 
-	if checkCancelled() {
-		return handleCancellation(ctx, input.JobID, output)
+	// Step 6: HLS Segmentation (and DASH manifest generation for fMP4).
+	// Without it UploadArtifacts uploads the transcoded renditions instead.
+	if input.Stages.Skips(domain.StageHLSSegmentation) {
+		logger.Info("Skipping HLS segmentation")
+	} else {
+		logger.Info("Starting HLS segmentation")
+		progress.setStage(domain.StageHLSSegmentation, 0)
+		var hlsOutput *activities.HLSOutput
+		err = workflow.ExecuteActivity(ctx, "SegmentHLS", activities.HLSInput{
+			JobID:           input.JobID,
+			OutputPaths:     transcodeOutput.OutputPaths,
+			TierOutputPaths: transcodeOutput.TierOutputPaths,
+			EnabledTiers:    transcodeOutput.EnabledTiers,
+			Duration:        duration,
+			IntroDuration:   stitchOutput.IntroDuration,
+			VideoRange:      metadataOutput.Metadata.HDR.VideoRange(),
+			AudioTracks:     metadataOutput.Metadata.AudioTracks,
+			SubtitleTracks:  metadataOutput.Metadata.SubtitleTracks,
+			AudioOutputs:    transcodeOutput.AudioOutputs,
+			Hardsub:         hardsubOutput,
+		}).Get(ctx, &hlsOutput)
+		if err != nil {
+			output.Status = domain.JobStatusFailed
+			output.Error = fmt.Sprintf("HLS segmentation failed: %v", err)
+			return output, err
+		}
+
+		if checkCancelled() {
+			return handleCancellation(ctx, input.JobID, output, cancelSignal)
+		}
 	}
 
 	// Step 7: Upload Artifacts
 	logger.Info("Starting artifact upload")
-	uploadOptions := workflow.ActivityOptions{
-		StartToCloseTimeout: 2 * time.Hour,
-		HeartbeatTimeout:    1 * time.Minute,
-		RetryPolicy: &temporal.RetryPolicy{
-			InitialInterval:    5 * time.Second,
-			BackoffCoefficient: 2.0,
-			MaximumInterval:    2 * time.Minute,
-			MaximumAttempts:    5,
-		},
-	}
-	uploadCtx := workflow.WithActivityOptions(ctx, uploadOptions)
+	progress.setStage(domain.StageUploading, 0)
+	uploadCtx := workflow.WithActivityOptions(ctx,
+		activityOptions(retry.Upload, timeouts.scaled(timeouts.UploadHeartbeat, sourceSize)))
 
 	var uploadOutput *activities.UploadOutput
 	err = workflow.ExecuteActivity(uploadCtx, "UploadArtifacts", activities.UploadInput{
@@ -223,8 +613,23 @@ func VideoConversionWorkflow(ctx workflow.Context, input VideoConversionWorkflow
 		return output, err
 	}
 
-	// Step 8: Cleanup
+	// Step 8: Verify published output. Workflows started before verification existed skip it.
+	if workflow.GetVersion(ctx, changeVerifyOutput, workflow.DefaultVersion, 1) == 1 {
+		logger.Info("Starting output verification")
+		progress.setStage(domain.StageOutputVerification, 0)
+		err = workflow.ExecuteActivity(ctx, "VerifyOutput", activities.VerifyInput{
+			JobID: input.JobID,
+		}).Get(ctx, nil)
+		if err != nil {
+			output.Status = domain.JobStatusFailed
+			output.Error = fmt.Sprintf("output verification failed: %v", err)
+			return output, err
+		}
+	}
+
+	// Step 9: Cleanup
 	logger.Info("Starting cleanup")
+	progress.setStage(domain.StageCleanup, 0)
 	cleanupOptions := workflow.ActivityOptions{
 		StartToCloseTimeout: 5 * time.Minute,
 		RetryPolicy: &temporal.RetryPolicy{
@@ -244,6 +649,8 @@ func VideoConversionWorkflow(ctx workflow.Context, input VideoConversionWorkflow
 		logger.Warn("Cleanup failed", "error", err)
 	}
 
+	progress.setStage(domain.StageCleanup, 100)
+
 	output.Status = domain.JobStatusCompleted
 	output.ArtifactCount = uploadOutput.ArtifactCount
 	logger.Info("Video conversion workflow completed successfully",
@@ -253,10 +660,171 @@ func VideoConversionWorkflow(ctx workflow.Context, input VideoConversionWorkflow
 	return output, nil
 }
 
+// transcodeRenditions runs one TranscodeRendition activity per (tier, quality) pair
+// concurrently and merges the results into a TranscodeOutput for SegmentHLS.
+// progressCtx carries the default activity options used for progress reports.
+// progress.Renditions must come from planRenditions(plan).
+func transcodeRenditions(
+	ctx workflow.Context,
+	progressCtx workflow.Context,
+	jobID uuid.UUID,
+	metadata *domain.VideoMetadata,
+	plan *activities.TranscodePlan,
+	progress *Progress,
+) (*activities.TranscodeOutput, error) {
+	logger := workflow.GetLogger(ctx)
+
+	inputs := make([]activities.RenditionInput, 0, len(progress.Renditions))
+	for _, r := range progress.Renditions {
+		inputs = append(inputs, activities.RenditionInput{
+			JobID:     jobID,
+			Metadata:  metadata,
+			Tier:      r.Tier,
+			Quality:   r.Quality,
+			Mezzanine: r.Mezzanine,
+		})
+	}
+
+	logger.Info("Transcoding renditions in parallel", "renditions", len(inputs))
+
+	// A failed rendition cancels the others
+	ctx, cancel := workflow.WithCancel(ctx)
+	defer cancel()
+
+	output := &activities.TranscodeOutput{
+		OutputPaths:     make(map[domain.Quality]string),
+		TierOutputPaths: make(map[domain.EncodingTier]map[domain.Quality]string),
+		EnabledTiers:    plan.Tiers,
+	}
+	for _, tier := range plan.Tiers {
+		output.TierOutputPaths[tier] = make(map[domain.Quality]string)
+	}
+
+	selector := workflow.NewSelector(ctx)
+	var firstErr error
+	for i, in := range inputs {
+		state := progress.Renditions[i]
+		state.Status = RenditionRunning
+		future := workflow.ExecuteActivity(ctx, "TranscodeRendition", in)
+		selector.AddFuture(future, func(f workflow.Future) {
+			var rendition activities.RenditionOutput
+			if err := f.Get(ctx, &rendition); err != nil {
+				state.Status = RenditionFailed
+				if temporal.IsCanceledError(err) {
+					state.Status = RenditionCanceled
+				}
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				return
+			}
+			state.Status = RenditionCompleted
+			if rendition.Quality == "" {
+				output.MezzaninePath = rendition.OutputPath
+				return
+			}
+			output.TierOutputPaths[rendition.Tier][rendition.Quality] = rendition.OutputPath
+		})
+	}
+
+	for done := 1; done <= len(inputs); done++ {
+		selector.Select(ctx)
+		if firstErr == nil {
+			progress.setStage(domain.StageTranscoding, done*100/len(inputs))
+			_ = workflow.ExecuteActivity(progressCtx, "ReportProgress", activities.ProgressInput{
+				JobID:    jobID,
+				Stage:    domain.StageTranscoding,
+				Progress: done * 100 / len(inputs),
+			}).Get(progressCtx, nil)
+		}
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	// Legacy tier paths are the main output; fall back to modern when legacy is disabled
+	if paths, ok := output.TierOutputPaths[domain.TierLegacy]; ok {
+		output.OutputPaths = paths
+	} else if paths, ok := output.TierOutputPaths[domain.TierModern]; ok {
+		output.OutputPaths = paths
+	}
+
+	return output, nil
+}
+
+// planRenditions lists the renditions of a transcode plan as pending
+func planRenditions(plan *activities.TranscodePlan) []*RenditionProgress {
+	var renditions []*RenditionProgress
+	for _, tier := range plan.Tiers {
+		for _, quality := range plan.Qualities {
+			renditions = append(renditions, &RenditionProgress{
+				Tier:    tier,
+				Quality: quality,
+				Status:  RenditionPending,
+			})
+		}
+	}
+	if plan.Mezzanine {
+		renditions = append(renditions, &RenditionProgress{Mezzanine: true, Status: RenditionPending})
+	}
+	return renditions
+}
+
+// isPoison reports whether an activity failure should quarantine the job: the
+// activity exhausted its retry policy, or ffmpeg/ffprobe rejected the source
+func isPoison(err error) bool {
+	var activityErr *temporal.ActivityError
+	if !errors.As(err, &activityErr) {
+		return false
+	}
+	if activityErr.RetryState() == enumspb.RETRY_STATE_MAXIMUM_ATTEMPTS_REACHED {
+		return true
+	}
+	var appErr *temporal.ApplicationError
+	return errors.As(err, &appErr) && domain.IsPoison(appErr.Type())
+}
+
+// planDryRun plans a dry-run job, then cleans up its source and disk reservation
+func planDryRun(ctx workflow.Context, jobID uuid.UUID, metadata *domain.VideoMetadata, output *VideoConversionWorkflowOutput) (*VideoConversionWorkflowOutput, error) {
+	logger := workflow.GetLogger(ctx)
+
+	err := workflow.ExecuteActivity(ctx, "PlanJob", activities.TranscodeInput{
+		JobID:    jobID,
+		Metadata: metadata,
+	}).Get(ctx, &output.Plan)
+	if err != nil {
+		output.Status = domain.JobStatusFailed
+		output.Error = fmt.Sprintf("planning failed: %v", err)
+		return output, err
+	}
+
+	cleanupCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 5 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    30 * time.Second,
+			MaximumAttempts:    3,
+		},
+	})
+	err = workflow.ExecuteActivity(cleanupCtx, "Cleanup", activities.CleanupInput{
+		JobID: jobID,
+	}).Get(ctx, nil)
+	if err != nil {
+		// Log but don't fail - cleanup is best effort
+		logger.Warn("Cleanup failed", "error", err)
+	}
+
+	output.Status = domain.JobStatusCompleted
+	logger.Info("Dry run completed", "jobId", jobID.String(), "renditions", len(output.Plan.Renditions))
+	return output, nil
+}
+
 // handleCancellation handles workflow cancellation
-func handleCancellation(ctx workflow.Context, jobID uuid.UUID, output *VideoConversionWorkflowOutput) (*VideoConversionWorkflowOutput, error) {
+func handleCancellation(ctx workflow.Context, jobID uuid.UUID, output *VideoConversionWorkflowOutput, signal CancelSignal) (*VideoConversionWorkflowOutput, error) {
 	logger := workflow.GetLogger(ctx)
-	logger.Info("Handling cancellation", "jobId", jobID.String())
+	logger.Info("Handling cancellation", "jobId", jobID.String(), "reason", signal.Reason, "actor", signal.Actor)
 
 	// Create disconnected context for cleanup
 	cleanupCtx, _ := workflow.NewDisconnectedContext(ctx)
@@ -275,5 +843,8 @@ func handleCancellation(ctx workflow.Context, jobID uuid.UUID, output *VideoConv
 
 	output.Status = domain.JobStatusCanceled
 	output.Error = "workflow cancelled by user"
+	if signal.Reason != "" {
+		output.Error = "workflow cancelled: " + signal.Reason
+	}
 	return output, nil
 }
@@ -0,0 +1,73 @@
+package workflows
+
+import (
+	"fmt"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/tvoe/converter/internal/temporal/activities"
+)
+
+// MaintenanceWorkflowName is the registered name of the maintenance workflow
+const MaintenanceWorkflowName = "MaintenanceWorkflow"
+
+// MaintenanceTask names a periodic maintenance task; each has its own schedule
+type MaintenanceTask string
+
+const (
+	MaintenanceOrphanWorkspaces MaintenanceTask = "orphan-workspaces"
+	MaintenanceStaleUploads     MaintenanceTask = "stale-uploads"
+	MaintenanceStaleJobs        MaintenanceTask = "stale-jobs"
+)
+
+// maintenanceActivities maps tasks to the activity that performs them
+var maintenanceActivities = map[MaintenanceTask]string{
+	MaintenanceOrphanWorkspaces: "CleanupOrphanWorkspaces",
+	MaintenanceStaleUploads:     "AbortStaleUploads",
+	MaintenanceStaleJobs:        "ReconcileStaleJobs",
+}
+
+// MaintenanceTasks returns all maintenance tasks
+func MaintenanceTasks() []MaintenanceTask {
+	return []MaintenanceTask{
+		MaintenanceOrphanWorkspaces,
+		MaintenanceStaleUploads,
+		MaintenanceStaleJobs,
+	}
+}
+
+// MaintenanceWorkflowInput holds maintenance workflow input
+type MaintenanceWorkflowInput struct {
+	Task MaintenanceTask `json:"task"`
+}
+
+// MaintenanceWorkflow runs one maintenance task. It is started by the task's
+// Temporal schedule, which skips a run while the previous one is still going.
+func MaintenanceWorkflow(ctx workflow.Context, input MaintenanceWorkflowInput) (*activities.MaintenanceResult, error) {
+	activityName, ok := maintenanceActivities[input.Task]
+	if !ok {
+		return nil, temporal.NewNonRetryableApplicationError(
+			fmt.Sprintf("unknown maintenance task %q", input.Task), "UnknownMaintenanceTask", nil)
+	}
+
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 1 * time.Hour,
+		HeartbeatTimeout:    5 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    10 * time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    5 * time.Minute,
+			MaximumAttempts:    3,
+		},
+	})
+
+	var result activities.MaintenanceResult
+	if err := workflow.ExecuteActivity(ctx, activityName).Get(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	workflow.GetLogger(ctx).Info("Maintenance finished", "task", string(input.Task), "processed", result.Processed, "skipped", result.Skipped)
+	return &result, nil
+}
@@ -0,0 +1,197 @@
+package workflows
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/tvoe/converter/internal/domain"
+	"github.com/tvoe/converter/internal/temporal/activities"
+)
+
+// SeriesWorkflowName is the registered name of the series workflow
+const SeriesWorkflowName = "SeriesConversionWorkflow"
+
+// QuerySeriesProgress is the query returning progress of SeriesConversionWorkflow
+const QuerySeriesProgress = "series-progress"
+
+// defaultSeriesItemsPerRun bounds the history of one series run. Each item adds
+// a status check and a child workflow to the history, so a run continues as new
+// after this many items.
+const defaultSeriesItemsPerRun = 50
+
+// changeSeriesClaim gates claiming series jobs before their child starts; series
+// runs started before it check the job status instead
+const changeSeriesClaim = "series-claim"
+
+// SeriesItem is one job of a series with the child workflow that converts it
+type SeriesItem struct {
+	WorkflowID string                       `json:"workflowId"`
+	TaskQueue  string                       `json:"taskQueue"`
+	Input      VideoConversionWorkflowInput `json:"input"`
+}
+
+// SeriesProgress counts finished items of a series across all runs
+type SeriesProgress struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+	Canceled  int `json:"canceled"`
+	// Skipped items were canceled or deleted before their turn
+	Skipped int         `json:"skipped"`
+	Running []uuid.UUID `json:"running"`
+	// Runs is the number of workflow runs so far; a new run starts every ItemsPerRun items
+	Runs int `json:"runs"`
+}
+
+// SeriesWorkflowInput holds series workflow input
+type SeriesWorkflowInput struct {
+	SeriesID uuid.UUID `json:"seriesId"`
+	// ConversionWorkflow is the workflow version each item runs as a child
+	ConversionWorkflow string `json:"conversionWorkflow"`
+	// Items are the items not started yet
+	Items       []SeriesItem `json:"items"`
+	MaxParallel int          `json:"maxParallel"`
+	ItemsPerRun int          `json:"itemsPerRun,omitempty"`
+	// Progress is carried over from the previous run
+	Progress SeriesProgress `json:"progress"`
+}
+
+// SeriesConversionWorkflow converts the jobs of a series, at most MaxParallel at
+// a time, each as a child VideoConversionWorkflow with the job's usual workflow
+// ID, so cancel, pause and progress of single jobs work as for standalone jobs.
+// After ItemsPerRun items it continues as new with the remaining items.
+func SeriesConversionWorkflow(ctx workflow.Context, input SeriesWorkflowInput) (*SeriesProgress, error) {
+	logger := workflow.GetLogger(ctx)
+
+	progress := input.Progress
+	progress.Runs++
+	progress.Running = nil
+
+	err := workflow.SetQueryHandler(ctx, QuerySeriesProgress, func() (SeriesProgress, error) {
+		p := progress
+		p.Running = append([]uuid.UUID(nil), progress.Running...)
+		return p, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	maxParallel := input.MaxParallel
+	if maxParallel < 1 {
+		maxParallel = 1
+	}
+	itemsPerRun := input.ItemsPerRun
+	if itemsPerRun <= 0 {
+		itemsPerRun = defaultSeriesItemsPerRun
+	}
+	runItems := input.Items
+	if len(runItems) > itemsPerRun {
+		runItems = runItems[:itemsPerRun]
+	}
+
+	statusCtx := workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 1 * time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumInterval:    10 * time.Second,
+			MaximumAttempts:    5,
+		},
+	})
+
+	selector := workflow.NewSelector(ctx)
+	running := 0
+	next := 0
+	for {
+		for running < maxParallel && next < len(runItems) && ctx.Err() == nil {
+			item := runItems[next]
+			next++
+			jobID := item.Input.JobID
+
+			// Jobs canceled or deleted while waiting for their turn are not started
+			if workflow.GetVersion(ctx, changeSeriesClaim, workflow.DefaultVersion, 1) == 1 {
+				var claimed bool
+				err := workflow.ExecuteActivity(statusCtx, "ClaimSeriesJob", activities.ClaimSeriesJobInput{
+					JobID:      jobID,
+					WorkflowID: item.WorkflowID,
+				}).Get(ctx, &claimed)
+				if err != nil || !claimed {
+					logger.Info("Skipping series item", "jobId", jobID.String(), "error", err)
+					progress.Skipped++
+					continue
+				}
+			} else {
+				var status domain.JobStatus
+				err := workflow.ExecuteActivity(statusCtx, "GetJobStatus", activities.ActivityInput{JobID: jobID}).Get(ctx, &status)
+				if err != nil || status != domain.JobStatusQueued {
+					logger.Info("Skipping series item", "jobId", jobID.String(), "status", string(status), "error", err)
+					progress.Skipped++
+					continue
+				}
+			}
+
+			childCtx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{
+				WorkflowID: item.WorkflowID,
+				TaskQueue:  item.TaskQueue,
+				// Terminating the series does not kill conversions halfway
+				ParentClosePolicy: enumspb.PARENT_CLOSE_POLICY_ABANDON,
+			})
+			future := workflow.ExecuteChildWorkflow(childCtx, input.ConversionWorkflow, item.Input)
+			running++
+			progress.Running = append(progress.Running, jobID)
+
+			selector.AddFuture(future, func(f workflow.Future) {
+				running--
+				for i, id := range progress.Running {
+					if id == jobID {
+						progress.Running = append(progress.Running[:i], progress.Running[i+1:]...)
+						break
+					}
+				}
+
+				var out *VideoConversionWorkflowOutput
+				if err := f.Get(ctx, &out); err != nil || out == nil {
+					progress.Failed++
+					return
+				}
+				switch out.Status {
+				case domain.JobStatusCompleted:
+					progress.Completed++
+				case domain.JobStatusCanceled:
+					progress.Canceled++
+				default:
+					progress.Failed++
+				}
+			})
+		}
+
+		if running == 0 {
+			break
+		}
+		selector.Select(ctx)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return &progress, temporal.NewCanceledError(progress)
+	}
+
+	if remaining := input.Items[next:]; len(remaining) > 0 {
+		logger.Info("Continuing series as new", "seriesId", input.SeriesID.String(), "remaining", len(remaining))
+		input.Items = remaining
+		input.Progress = progress
+		input.Progress.Running = nil
+		return nil, workflow.NewContinueAsNewError(ctx, SeriesWorkflowName, input)
+	}
+
+	logger.Info("Series finished",
+		"seriesId", input.SeriesID.String(),
+		"completed", progress.Completed,
+		"failed", progress.Failed,
+		"canceled", progress.Canceled,
+		"skipped", progress.Skipped)
+	return &progress, nil
+}
@@ -0,0 +1,67 @@
+package workflows
+
+import (
+	"sort"
+
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+// ConversionWorkflowName is the registered name of the current conversion workflow.
+// It matches the function name, so executions started before explicit names existed keep running.
+const ConversionWorkflowName = "VideoConversionWorkflow"
+
+// conversionWorkflows are all conversion workflow versions a worker runs, by name.
+//
+// Most pipeline changes go behind a workflow.GetVersion gate (see the change IDs
+// below). A rewrite that cannot be gated is added as a new function under a new
+// name, e.g. "VideoConversionWorkflowV2", and the API is switched to it with
+// TEMPORAL_CONVERSION_WORKFLOW once all workers run it. The old function stays
+// registered until no execution of it is running.
+var conversionWorkflows = map[string]interface{}{
+	ConversionWorkflowName: VideoConversionWorkflow,
+}
+
+// Change IDs of workflow.GetVersion gates in VideoConversionWorkflow. Each gate
+// keeps executions started before the change on the old code path. A gate may
+// be removed only after every execution started before it has finished.
+const (
+	changeParallelRenditions = "parallel-renditions"
+	changeVerifyOutput       = "verify-output"
+	changeQuarantine         = "quarantine"
+	changeCancelTranscode    = "cancel-transcode"
+	changeTenantQuota        = "tenant-quota"
+	changeCancelPaused       = "cancel-paused"
+	changeTranscodeQueue     = "transcode-queue"
+)
+
+// Register registers all conversion workflow versions, the series workflow and
+// the maintenance workflow on a worker
+func Register(r worker.WorkflowRegistry) {
+	for name, fn := range conversionWorkflows {
+		r.RegisterWorkflowWithOptions(fn, workflow.RegisterOptions{Name: name})
+	}
+	r.RegisterWorkflowWithOptions(SeriesConversionWorkflow, workflow.RegisterOptions{Name: SeriesWorkflowName})
+	r.RegisterWorkflowWithOptions(MaintenanceWorkflow, workflow.RegisterOptions{Name: MaintenanceWorkflowName})
+}
+
+// IsConversionWorkflow reports whether name is a registered conversion workflow version
+func IsConversionWorkflow(name string) bool {
+	_, ok := conversionWorkflows[name]
+	return ok
+}
+
+// ConversionWorkflowNames returns the registered conversion workflow versions
+func ConversionWorkflowNames() []string {
+	names := make([]string, 0, len(conversionWorkflows))
+	for name := range conversionWorkflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WorkflowNames returns every workflow type Register registers
+func WorkflowNames() []string {
+	return append(ConversionWorkflowNames(), SeriesWorkflowName, MaintenanceWorkflowName)
+}
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS update_conversion_profiles_updated_at ON conversion_profiles;
+DROP TABLE IF EXISTS conversion_profiles;
@@ -0,0 +1,20 @@
+-- Encoding profile templates
+CREATE TABLE IF NOT EXISTS conversion_profiles (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT,
+    profile JSONB NOT NULL,
+    version INT NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Profiles are referenced by name
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conversion_profiles_name
+    ON conversion_profiles (name);
+
+-- Trigger to auto-update updated_at
+CREATE TRIGGER update_conversion_profiles_updated_at
+    BEFORE UPDATE ON conversion_profiles
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
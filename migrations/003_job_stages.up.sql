@@ -0,0 +1,14 @@
+-- Per-stage timings, one row per activity attempt
+CREATE TABLE IF NOT EXISTS job_stages (
+    id UUID PRIMARY KEY,
+    job_id UUID NOT NULL REFERENCES conversion_jobs(id) ON DELETE CASCADE,
+    stage TEXT NOT NULL,
+    attempt INT NOT NULL DEFAULT 1,
+    status TEXT NOT NULL DEFAULT 'RUNNING',
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ
+);
+
+-- Index for job stage lookups
+CREATE INDEX IF NOT EXISTS idx_job_stages_job_started
+    ON job_stages (job_id, started_at);
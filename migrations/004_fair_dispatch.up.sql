@@ -0,0 +1,7 @@
+-- Tenant used to interleave queued jobs fairly between customers
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS tenant TEXT;
+
+-- Index for the dispatcher's queued-and-not-dispatched scan
+CREATE INDEX IF NOT EXISTS idx_conversion_jobs_dispatch
+    ON conversion_jobs (created_at)
+    WHERE status = 'QUEUED' AND workflow_id IS NULL;
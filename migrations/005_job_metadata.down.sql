@@ -0,0 +1,2 @@
+ALTER TABLE conversion_jobs DROP COLUMN IF EXISTS ffprobe_output;
+ALTER TABLE conversion_jobs DROP COLUMN IF EXISTS source_metadata;
@@ -0,0 +1,3 @@
+-- Source metadata: parsed summary and the full ffprobe output
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS source_metadata JSONB;
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS ffprobe_output JSONB;
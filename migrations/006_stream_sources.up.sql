@@ -0,0 +1,2 @@
+-- HLS/DASH manifest URL for sources read over HTTP instead of from S3
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS source_url TEXT;
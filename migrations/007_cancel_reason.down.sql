@@ -0,0 +1,2 @@
+ALTER TABLE conversion_jobs DROP COLUMN IF EXISTS canceled_by;
+ALTER TABLE conversion_jobs DROP COLUMN IF EXISTS cancel_reason;
@@ -0,0 +1,3 @@
+-- Who canceled a job and why
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS cancel_reason TEXT;
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS canceled_by TEXT;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_conversion_jobs_disk_reserved;
+ALTER TABLE conversion_jobs DROP COLUMN IF EXISTS disk_reserved_bytes;
@@ -0,0 +1,6 @@
+-- Workdir space reserved by a job at validation, released on cleanup/finish
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS disk_reserved_bytes BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_conversion_jobs_disk_reserved
+    ON conversion_jobs (id)
+    WHERE disk_reserved_bytes > 0;
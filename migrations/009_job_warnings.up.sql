@@ -0,0 +1,2 @@
+-- Non-fatal pipeline warnings returned by the API
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS warnings JSONB NOT NULL DEFAULT '[]';
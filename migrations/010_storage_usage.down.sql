@@ -0,0 +1 @@
+DROP VIEW IF EXISTS job_storage_usage;
@@ -0,0 +1,11 @@
+-- Output size of every job that has artifacts, for storage cost attribution
+CREATE OR REPLACE VIEW job_storage_usage AS
+SELECT
+    j.id AS job_id,
+    j.video_id,
+    j.tenant,
+    COUNT(a.id) AS artifact_count,
+    COALESCE(SUM(a.size_bytes), 0)::BIGINT AS total_bytes
+FROM conversion_jobs j
+JOIN conversion_artifacts a ON a.job_id = j.id
+GROUP BY j.id;
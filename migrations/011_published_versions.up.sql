@@ -0,0 +1,15 @@
+-- Publication history per video: the row with the highest version is live.
+-- job_id is cleared when the job is deleted so the history survives it.
+CREATE TABLE IF NOT EXISTS published_versions (
+    id UUID PRIMARY KEY,
+    video_id UUID NOT NULL,
+    version INT NOT NULL,
+    job_id UUID REFERENCES conversion_jobs(id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (video_id, version)
+);
+
+-- Index for "is this job published" lookups
+CREATE INDEX IF NOT EXISTS idx_published_versions_job
+    ON published_versions (job_id);
@@ -0,0 +1,2 @@
+ALTER TABLE conversion_jobs DROP COLUMN IF EXISTS plan;
+ALTER TABLE conversion_jobs DROP COLUMN IF EXISTS dry_run;
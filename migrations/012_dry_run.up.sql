@@ -0,0 +1,3 @@
+-- Dry-run jobs stop after validation and store the plan of the pipeline instead of output
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS dry_run BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS plan JSONB;
@@ -0,0 +1,2 @@
+-- Per-title analysis of the source the ladder of the job profile was scaled by
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS complexity JSONB;
@@ -0,0 +1 @@
+ALTER TABLE conversion_artifacts DROP COLUMN IF EXISTS tracks;
@@ -0,0 +1,2 @@
+-- Audio tracks carried by audio rendition playlists and MP4 artifacts
+ALTER TABLE conversion_artifacts ADD COLUMN IF NOT EXISTS tracks JSONB;
@@ -0,0 +1 @@
+ALTER TABLE conversion_jobs DROP COLUMN IF EXISTS series_id;
@@ -0,0 +1,2 @@
+-- Jobs of a series are started by their series workflow, not by the dispatcher
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS series_id UUID;
@@ -0,0 +1,3 @@
+-- Workdir space is reserved on the worker host that validated the job; only
+-- reservations of the same host share its free space
+ALTER TABLE conversion_jobs ADD COLUMN IF NOT EXISTS disk_reserved_by TEXT;